@@ -1,3 +1,8 @@
+//go:build integration
+// +build integration
+
+// The tests in this file call a live regtest node on localhost and fail without one.
+
 package bitcoin
 
 import (
@@ -25,7 +30,7 @@ func TestSendRawTransactions(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
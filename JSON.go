@@ -1,9 +1,13 @@
 package bitcoin
 
+import "math"
+
 type gbtParams struct {
 	Mode         string   `json:"mode,omitempty"`
 	Capabilities []string `json:"capabilities,omitempty"`
 	Rules        []string `json:"rules,omitempty"`
+	Data         string   `json:"data,omitempty"` // block of a proposal
+	LongPollID   string   `json:"longpollid,omitempty"`
 }
 
 // BlockchainInfo comment
@@ -15,11 +19,31 @@ type BlockchainInfo struct {
 	Difficulty           float64 `json:"difficulty"`
 	MedianTime           int64   `json:"mediantime"`
 	VerificationProgress float64 `json:"verificationprogress,omitempty"`
+	InitialBlockDownload bool    `json:"initialblockdownload"`
 	Pruned               bool    `json:"pruned"`
 	PruneHeight          int32   `json:"pruneheight,omitempty"`
 	ChainWork            string  `json:"chainwork,omitempty"`
 }
 
+// ChainStates is the result of getchainstates (v26+). A node loaded from an assumeutxo snapshot has two
+// chainstates until the background validation of the blocks below the snapshot completes.
+type ChainStates struct {
+	Headers     int32        `json:"headers"`
+	ChainStates []ChainState `json:"chainstates"` // the background chainstate first, the active one last
+}
+
+// ChainState is a chainstate reported by getchainstates.
+type ChainState struct {
+	Blocks               int32   `json:"blocks"`
+	BestBlockHash        string  `json:"bestblockhash"`
+	Difficulty           float64 `json:"difficulty"`
+	VerificationProgress float64 `json:"verificationprogress"`
+	SnapshotBlockHash    string  `json:"snapshot_blockhash,omitempty"` // set if the chainstate was loaded from a snapshot
+	CoinsDBCacheBytes    uint64  `json:"coins_db_cache_bytes"`
+	CoinsTipCacheBytes   uint64  `json:"coins_tip_cache_bytes"`
+	Validated            bool    `json:"validated"` // all blocks of the chainstate were validated
+}
+
 // GetInfo comment
 type GetInfo struct {
 	Version                      int32   `json:"version"`
@@ -117,7 +141,9 @@ type NetworkInfo struct {
 	Connections                     int            `json:"connections"`
 	AddressCount                    int            `json:"addresscount"`
 	Networks                        []Network      `json:"networks"`
-	RelayFee                        float64        `json:"relayfee"`
+	RelayFee                        float64        `json:"relayfee"`       // BTC/kvB
+	IncrementalFee                  float64        `json:"incrementalfee"` // BTC/kvB
+	LocalServicesNames              []string       `json:"localservicesnames"`
 	MinConsolidationFactor          int            `json:"minconsolidationfactor"`
 	MinConsolidationInputMaturity   int            `json:"minconsolidationinputmaturity"`
 	MaxConsolidationInputScriptSize int            `json:"maxconsolidationinputscriptsize"`
@@ -179,29 +205,31 @@ type BytesData struct {
 
 // Peer struct
 type Peer struct {
-	ID             int     `json:"id"`
-	Addr           string  `json:"addr"`
-	AddrLocal      string  `json:"addrlocal"`
-	Services       string  `json:"services"`
-	RelayTXes      bool    `json:"relaytxes"`
-	LastSend       int     `json:"lastsend"`
-	LastRecv       int     `json:"lastrecv"`
-	BytesSent      int     `json:"bytessent"`
-	BytesRecv      int     `json:"bytesrecv"`
-	ConnTime       int     `json:"conntime"`
-	TimeOffset     int     `json:"timeoffset"`
-	PingTime       float64 `json:"pingtime"`
-	MinPing        float64 `json:"minping"`
-	Version        int     `json:"version"`
-	Subver         string  `json:"subver"`
-	Inbound        bool    `json:"inbound"`
-	AddNode        bool    `json:"addnode"`
-	StartingHeight int     `json:"startingheight"`
-	TXNInvSize     int     `json:"txninvsize"`
-	Banscore       int     `json:"banscore"`
-	SyncedHeaders  int     `json:"synced_headers"`
-	SyncedBlocks   int     `json:"synced_blocks"`
-	// "inflight": [],
+	ID              int       `json:"id"`
+	Addr            string    `json:"addr"`
+	AddrLocal       string    `json:"addrlocal"`
+	Services        string    `json:"services"`
+	RelayTXes       bool      `json:"relaytxes"`
+	LastSend        int       `json:"lastsend"`
+	LastRecv        int       `json:"lastrecv"`
+	BytesSent       int       `json:"bytessent"`
+	BytesRecv       int       `json:"bytesrecv"`
+	ConnTime        int       `json:"conntime"`
+	TimeOffset      int       `json:"timeoffset"`
+	PingTime        float64   `json:"pingtime"`
+	MinPing         float64   `json:"minping"`
+	Version         int       `json:"version"`
+	Subver          string    `json:"subver"`
+	Inbound         bool      `json:"inbound"`
+	AddNode         bool      `json:"addnode"`
+	StartingHeight  int       `json:"startingheight"`
+	TXNInvSize      int       `json:"txninvsize"`
+	Banscore        int       `json:"banscore"`
+	SyncedHeaders   int       `json:"synced_headers"`
+	SyncedBlocks    int       `json:"synced_blocks"`
+	Inflight        []int     `json:"inflight"`
+	MinFeeFilter    float64   `json:"minfeefilter"`
+	ConnectionType  string    `json:"connection_type"`
 	WhiteListed     bool      `json:"whitelisted"`
 	BytesSendPerMsg BytesData `json:"bytessent_per_msg"`
 	BytesRecvPerMsg BytesData `json:"bytesrecv_per_msg"`
@@ -210,6 +238,16 @@ type Peer struct {
 // PeerInfo comment
 type PeerInfo []Peer
 
+// BannedNode is an entry of the listbanned result
+type BannedNode struct {
+	Address       string `json:"address"`
+	BanCreated    int64  `json:"ban_created"`
+	BannedUntil   int64  `json:"banned_until"`
+	BanDuration   int64  `json:"ban_duration"`
+	TimeRemaining int64  `json:"time_remaining"`
+	BanReason     string `json:"ban_reason"`
+}
+
 // RawMemPool comment
 type RawMemPool []string
 
@@ -227,15 +265,72 @@ type MempoolInfo struct {
 	MaxMempoolSizeDisk int     `json:"maxmempoolsizedisk"` // Maximum disk usage for storing mempool transactions
 	MaxMempoolSizeCpfp int     `json:"maxmempoolsizecpfp"` // Maximum memory usage for the low paying transactions
 	MemPoolMinFree     float64 `json:"mempoolminfee"`      // Minimum fee (in BSV/kB) for tx to be accepted
+	MinRelayTxFee      float64 `json:"minrelaytxfee"`      // Minimum relay fee (in BTC/kvB) of the node
 }
 
 type MempoolEntry struct {
-	Size        int      `json:"size"`
-	Fee         float64  `json:"fee"`
-	ModifiedFee float64  `json:"modifiedfee"`
-	Time        int      `json:"time"`
-	Height      int      `json:"height"`
-	Depends     []string `json:"depends"`
+	WTxID       string            `json:"wtxid,omitempty"`
+	Size        int               `json:"size"`
+	VSize       int               `json:"vsize"`
+	Fee         float64           `json:"fee"`
+	ModifiedFee float64           `json:"modifiedfee"`
+	Fees        *MempoolEntryFees `json:"fees,omitempty"`
+	Time        int               `json:"time"`
+	Height      int               `json:"height"`
+	Depends     []string          `json:"depends"`
+}
+
+// MempoolEntryFees holds the fees of a mempool entry in BTC as reported by newer nodes
+type MempoolEntryFees struct {
+	Base       float64 `json:"base"`
+	Modified   float64 `json:"modified"`
+	Ancestor   float64 `json:"ancestor"`
+	Descendant float64 `json:"descendant"`
+}
+
+// FeeRate returns the fee rate of the entry in satoshis per virtual byte, falling back to the fee and size
+// fields reported by older nodes.
+func (e MempoolEntry) FeeRate() float64 {
+	fee := e.Fee
+	if e.Fees != nil {
+		fee = e.Fees.Base
+	}
+
+	size := e.VSize
+	if size == 0 {
+		size = e.Size
+	}
+
+	if size == 0 {
+		return 0
+	}
+
+	return math.Round(fee*1e8) / float64(size)
+}
+
+// Outpoint identifies a transaction output
+type Outpoint struct {
+	TxID string `json:"txid"`
+	Vout uint32 `json:"vout"`
+}
+
+// TxSpendingPrevout is an entry of the gettxspendingprevout result. SpendingTxID is empty when no
+// mempool transaction spends the outpoint.
+type TxSpendingPrevout struct {
+	TxID         string `json:"txid"`
+	Vout         uint32 `json:"vout"`
+	SpendingTxID string `json:"spendingtxid,omitempty"`
+}
+
+// OrphanTx is an entry of the getorphantxs result
+type OrphanTx struct {
+	TxID   string `json:"txid"`
+	WTxID  string `json:"wtxid"`
+	Bytes  uint64 `json:"bytes"`
+	VSize  uint64 `json:"vsize"`
+	Weight uint64 `json:"weight"`
+	From   []int  `json:"from"` // ids of the peers that announced the transaction
+	Hex    string `json:"hex,omitempty"`
 }
 
 // ChainTXStats struct
@@ -258,6 +353,80 @@ type Address struct {
 	IsScript     bool   `json:"isscript"`
 }
 
+// SmartFeeEstimate is the result of estimatesmartfee. FeeRate is in BTC/kvB and zero when no estimate is available.
+type SmartFeeEstimate struct {
+	FeeRate float64  `json:"feerate,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+	Blocks  int      `json:"blocks"`
+}
+
+// FeeEstimatorBucket is a range of fee rates tracked by the fee estimator
+type FeeEstimatorBucket struct {
+	StartRange     float64 `json:"startrange"`
+	EndRange       float64 `json:"endrange"`
+	WithinTarget   float64 `json:"withintarget"`
+	TotalConfirmed float64 `json:"totalconfirmed"`
+	InMempool      float64 `json:"inmempool"`
+	LeftMempool    float64 `json:"leftmempool"`
+}
+
+// FeeEstimatorHorizon is the estimate of one of the fee estimator's time horizons. FeeRate is in BTC/kvB
+// and zero when no estimate is available.
+type FeeEstimatorHorizon struct {
+	FeeRate float64             `json:"feerate,omitempty"`
+	Decay   float64             `json:"decay"`
+	Scale   int                 `json:"scale"`
+	Pass    *FeeEstimatorBucket `json:"pass,omitempty"`
+	Fail    *FeeEstimatorBucket `json:"fail,omitempty"`
+	Errors  []string            `json:"errors,omitempty"`
+}
+
+// RawFeeEstimate is the result of estimaterawfee. Horizons that do not track the requested target are nil.
+type RawFeeEstimate struct {
+	Short  *FeeEstimatorHorizon `json:"short,omitempty"`
+	Medium *FeeEstimatorHorizon `json:"medium,omitempty"`
+	Long   *FeeEstimatorHorizon `json:"long,omitempty"`
+}
+
+// MempoolSequence is the result of getrawmempool with mempool_sequence set
+type MempoolSequence struct {
+	TxIDs           []string `json:"txids"`
+	MempoolSequence uint64   `json:"mempool_sequence"`
+}
+
+// DescriptorInfo is the result of getdescriptorinfo
+type DescriptorInfo struct {
+	Descriptor     string `json:"descriptor"`
+	Checksum       string `json:"checksum"`
+	IsRange        bool   `json:"isrange"`
+	IsSolvable     bool   `json:"issolvable"`
+	HasPrivateKeys bool   `json:"hasprivatekeys"`
+}
+
+// DecodedScript is the result of decodescript
+type DecodedScript struct {
+	ASM       string        `json:"asm"`
+	Desc      string        `json:"desc,omitempty"`
+	Type      string        `json:"type"`
+	Address   string        `json:"address,omitempty"`
+	ReqSigs   int64         `json:"reqSigs,omitempty"`   // before v22
+	Addresses []string      `json:"addresses,omitempty"` // before v22
+	P2SH      string        `json:"p2sh,omitempty"`
+	Segwit    *SegwitScript `json:"segwit,omitempty"`
+	LocalType string        `json:"-"` // type of the script according to ClassifyScript
+}
+
+// SegwitScript is the segwit equivalent of a decoded script, the script wrapped in P2WSH or, for a
+// public key hash, P2WPKH.
+type SegwitScript struct {
+	ASM        string `json:"asm"`
+	Hex        string `json:"hex"`
+	Type       string `json:"type"`
+	Address    string `json:"address,omitempty"`
+	Desc       string `json:"desc,omitempty"`
+	P2SHSegwit string `json:"p2sh-segwit,omitempty"`
+}
+
 // Transaction comment
 type Transaction struct {
 	TXID string `json:"txid"`
@@ -282,6 +451,7 @@ type BlockTemplate struct {
 	WeightLimit              uint64        `json:"weightlimit"`
 	SigOpLimit               int64         `json:"sigoplimit"`
 	VBRequired               int64         `json:"vbrequired"`
+	LongPollID               string        `json:"longpollid"`
 	// extra mining candidate fields
 	IsMiningCandidate bool             `json:"-"`
 	MiningCandidateID string           `json:"-"`
@@ -470,6 +640,77 @@ type BlockHeaderAndCoinbase struct {
 	Tx                []RawTransaction `json:"tx"`
 }
 
+// Prevout is the output spent by an input, as included by getblock verbosity 3
+type Prevout struct {
+	Generated    bool         `json:"generated"`
+	Height       uint64       `json:"height"`
+	Value        float64      `json:"value"`
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
+// VinWithPrevout is an input including the output it spends
+type VinWithPrevout struct {
+	Vin
+	TxInWitness []string `json:"txinwitness,omitempty"`
+	Prevout     *Prevout `json:"prevout,omitempty"`
+}
+
+// TransactionWithPrevouts is a transaction as returned by getblock verbosity 3
+type TransactionWithPrevouts struct {
+	TxID     string            `json:"txid"`
+	Hash     string            `json:"hash"`
+	Version  int32             `json:"version"`
+	Size     uint32            `json:"size"`
+	VSize    uint32            `json:"vsize"`
+	Weight   uint32            `json:"weight"`
+	LockTime uint32            `json:"locktime"`
+	Vin      []*VinWithPrevout `json:"vin"`
+	Vout     []*Vout           `json:"vout"`
+	Fee      float64           `json:"fee,omitempty"`
+	Hex      string            `json:"hex"`
+}
+
+// InputValue returns the total value of the outputs spent by the transaction, 0 for a coinbase.
+func (tx *TransactionWithPrevouts) InputValue() (value float64) {
+	for _, in := range tx.Vin {
+		if in.Prevout != nil {
+			value += in.Prevout.Value
+		}
+	}
+	return
+}
+
+// FeeRate returns the fee rate of the transaction in satoshis per virtual byte.
+func (tx *TransactionWithPrevouts) FeeRate() float64 {
+	if tx.VSize == 0 {
+		return 0
+	}
+	return tx.Fee * 1e8 / float64(tx.VSize)
+}
+
+// BlockWithPrevouts is a block as returned by getblock verbosity 3 on Bitcoin Core 25 and later
+type BlockWithPrevouts struct {
+	Hash              string                    `json:"hash"`
+	Confirmations     int64                     `json:"confirmations"`
+	Size              uint64                    `json:"size"`
+	StrippedSize      uint64                    `json:"strippedsize"`
+	Weight            uint64                    `json:"weight"`
+	Height            uint64                    `json:"height"`
+	Version           int64                     `json:"version"`
+	VersionHex        string                    `json:"versionHex"`
+	MerkleRoot        string                    `json:"merkleroot"`
+	Time              uint64                    `json:"time"`
+	MedianTime        uint64                    `json:"mediantime"`
+	Nonce             uint64                    `json:"nonce"`
+	Bits              string                    `json:"bits"`
+	Difficulty        float64                   `json:"difficulty"`
+	Chainwork         string                    `json:"chainwork"`
+	NTx               uint64                    `json:"nTx"`
+	PreviousBlockHash string                    `json:"previousblockhash"`
+	NextBlockHash     string                    `json:"nextblockhash"`
+	Tx                []TransactionWithPrevouts `json:"tx"`
+}
+
 type BlockStats struct {
 	AvgFee        float64 `json:"avgfee"`
 	AvgFeeRate    float64 `json:"avgfeerate"`
@@ -567,6 +808,8 @@ type ScriptPubKey struct {
 	ReqSigs     int64     `json:"reqSigs,omitempty"`
 	Type        string    `json:"type"`
 	Addresses   []string  `json:"addresses,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	Desc        string    `json:"desc,omitempty"`
 	OpReturn    *OpReturn `json:"opReturn,omitempty"`
 	Tag         *Tag      `json:"tag,omitempty"`
 	IsTruncated bool      `json:"isTruncated"`
@@ -587,6 +830,8 @@ type UnspentTransaction struct {
 	Amount        float64 `json:"amount"`
 	Satoshis      uint64  `json:"satoshis"`
 	Confirmations uint32  `json:"confirmations"`
+	Spendable     bool    `json:"spendable"`
+	Safe          bool    `json:"safe"`
 }
 
 type TXOut struct {
@@ -597,6 +842,125 @@ type TXOut struct {
 	Coinbase      bool         `json:"coinbase"`
 }
 
+// ImportDescriptorRequest is a descriptor to import with importdescriptors. Timestamp is a unix time or
+// "now"; the wallet rescans from that time.
+type ImportDescriptorRequest struct {
+	Desc      string      `json:"desc"`
+	Timestamp interface{} `json:"timestamp"`
+	Active    bool        `json:"active,omitempty"`
+	Range     []int       `json:"range,omitempty"`
+	Internal  bool        `json:"internal,omitempty"`
+	Label     string      `json:"label,omitempty"`
+}
+
+// ImportDescriptorResult is the result of importing a single descriptor
+type ImportDescriptorResult struct {
+	Success  bool     `json:"success"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// FundedPSBT is the result of walletcreatefundedpsbt
+type FundedPSBT struct {
+	PSBT      string  `json:"psbt"`
+	Fee       float64 `json:"fee"`
+	ChangePos int     `json:"changepos"`
+}
+
+// FinalizedPSBT is the result of finalizepsbt. Hex is only set when the transaction is complete.
+type FinalizedPSBT struct {
+	PSBT     string `json:"psbt,omitempty"`
+	Hex      string `json:"hex,omitempty"`
+	Complete bool   `json:"complete"`
+}
+
+// ProcessPSBTOptions are the optional parameters of WalletProcessPSBT and DescriptorProcessPSBT. The zero
+// value is the node's default: sign with SigHashDefault, which is SIGHASH_ALL for inputs other than
+// taproot ones, include the BIP32 derivation paths and finalize the inputs that are complete.
+type ProcessPSBTOptions struct {
+	DontSign        bool
+	SigHashType     byte // one of the SigHash constants, optionally or'ed with SigHashAnyoneCanPay
+	SkipBIP32Derivs bool
+	// DontFinalize leaves the signatures in the partial signature fields, for coordinators collecting
+	// signatures from several signers before finalizing.
+	DontFinalize bool
+}
+
+// ProcessedPSBT is the result of walletprocesspsbt and descriptorprocesspsbt. Hex is only set by nodes of
+// v26 and later, for complete and finalized PSBTs.
+type ProcessedPSBT struct {
+	PSBT     string `json:"psbt"`
+	Complete bool   `json:"complete"`
+	Hex      string `json:"hex,omitempty"`
+}
+
+// ScanTxOutSetUnspent is an unspent output found by scantxoutset
+type ScanTxOutSetUnspent struct {
+	TxID         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	ScriptPubKey string  `json:"scriptPubKey"`
+	Desc         string  `json:"desc"`
+	Amount       float64 `json:"amount"`
+	Coinbase     bool    `json:"coinbase"`
+	Height       uint64  `json:"height"`
+	BlockHash    string  `json:"blockhash"`
+}
+
+// ScanTxOutSetResult is the result of scantxoutset start
+type ScanTxOutSetResult struct {
+	Success     bool                  `json:"success"`
+	TxOuts      uint64                `json:"txouts"`
+	Height      uint64                `json:"height"`
+	BestBlock   string                `json:"bestblock"`
+	Unspents    []ScanTxOutSetUnspent `json:"unspents"`
+	TotalAmount float64               `json:"total_amount"`
+}
+
+// RescanResult is the result of rescanblockchain
+type RescanResult struct {
+	StartHeight uint64 `json:"start_height"`
+	StopHeight  uint64 `json:"stop_height"`
+}
+
+// WalletTransactionDetail is an entry of the details array of gettransaction
+type WalletTransactionDetail struct {
+	Address   string  `json:"address"`
+	Category  string  `json:"category"`
+	Amount    float64 `json:"amount"`
+	Label     string  `json:"label"`
+	Vout      uint32  `json:"vout"`
+	Fee       float64 `json:"fee"`
+	Abandoned bool    `json:"abandoned"`
+}
+
+// WalletTransaction is the result of gettransaction. Confirmations is negative when the transaction
+// conflicts with a transaction in the active chain.
+type WalletTransaction struct {
+	Amount            float64                   `json:"amount"`
+	Fee               float64                   `json:"fee"`
+	Confirmations     int64                     `json:"confirmations"`
+	Generated         bool                      `json:"generated"`
+	Trusted           bool                      `json:"trusted"`
+	BlockHash         string                    `json:"blockhash"`
+	BlockHeight       uint64                    `json:"blockheight"`
+	BlockIndex        uint64                    `json:"blockindex"`
+	BlockTime         int64                     `json:"blocktime"`
+	TxID              string                    `json:"txid"`
+	WTxID             string                    `json:"wtxid"`
+	WalletConflicts   []string                  `json:"walletconflicts"`
+	MempoolConflicts  []string                  `json:"mempoolconflicts"`
+	ReplacedByTxID    string                    `json:"replaced_by_txid"`
+	ReplacesTxID      string                    `json:"replaces_txid"`
+	Time              int64                     `json:"time"`
+	TimeReceived      int64                     `json:"timereceived"`
+	BIP125Replaceable string                    `json:"bip125-replaceable"`
+	Details           []WalletTransactionDetail `json:"details"`
+	Hex               string                    `json:"hex"`
+}
+
 // SignRawTransactionResponse struct
 type SignRawTransactionResponse struct {
 	Hex      string `json:"hex"`
@@ -0,0 +1,32 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestBlockWithPrevoutsDecode(t *testing.T) {
+	data := `{"hash":"00","height":800000,"nTx":2,"tx":[
+		{"txid":"aa","vsize":100,"vin":[{"coinbase":"03","sequence":4294967295}],"vout":[{"value":6.3,"n":0,"scriptPubKey":{"hex":"00","type":"witness_v0_keyhash","address":"bc1q"}}]},
+		{"txid":"bb","vsize":200,"fee":0.00004,"vin":[{"txid":"cc","vout":1,"txinwitness":["30","02"],"prevout":{"generated":false,"height":799990,"value":0.5,"scriptPubKey":{"hex":"00","type":"witness_v0_keyhash","address":"bc1q"}},"sequence":4294967293}],"vout":[{"value":0.49996,"n":0,"scriptPubKey":{"hex":"00","type":"witness_v0_keyhash"}}]}
+	]}`
+
+	var block BlockWithPrevouts
+	if err := json.Unmarshal([]byte(data), &block); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := block.Tx[1]
+	if tx.Vin[0].Txid != "cc" || tx.Vin[0].Prevout.Height != 799990 || tx.Vin[0].Prevout.ScriptPubKey.Address != "bc1q" {
+		t.Errorf("Unexpected input %+v", tx.Vin[0])
+	}
+
+	if tx.InputValue() != 0.5 || math.Abs(tx.FeeRate()-20) > 1e-9 {
+		t.Errorf("Expected input value 0.5 and fee rate 20, got %v and %v", tx.InputValue(), tx.FeeRate())
+	}
+
+	if block.Tx[0].InputValue() != 0 {
+		t.Errorf("Expected coinbase input value 0, got %v", block.Tx[0].InputValue())
+	}
+}
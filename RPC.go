@@ -1,16 +1,17 @@
 package bitcoin
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
-	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"bitbucket.org/simon_ordish/cryptolib"
@@ -18,12 +19,18 @@ import (
 	"golang.org/x/sync/singleflight"
 )
 
-// A Bitcoind represents a Bitcoind client
+// A Bitcoind represents a Bitcoind client. It is safe for concurrent use by multiple goroutines, one
+// client should be shared rather than created per request. Options are applied when the client is
+// created; loggers, hooks and other callbacks passed as options are called from the goroutines making
+// the calls and must be safe for concurrent use themselves.
 type Bitcoind struct {
 	client    *rpcClient
 	Storage   *cache.Cache
 	group     singleflight.Group
 	IPAddress string
+
+	mu      sync.RWMutex // guards txIndex
+	txIndex *TxIndex
 }
 
 func NewFromURL(rpcUrl *url.URL, useSSL bool, opts ...Option) (*Bitcoind, error) {
@@ -73,15 +80,58 @@ func New(host string, port int, path, user, passwd string, useSSL bool, opts ...
 	}, nil
 }
 
+// WithOptions returns a client for the same node with the given options applied on top of the options of b.
+// The derived client shares the connection pool and response cache with b, so different parts of an
+// application can use tailored timeouts, loggers or headers without opening additional connections.
+func (b *Bitcoind) WithOptions(opts ...Option) *Bitcoind {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return &Bitcoind{
+		client:    b.client.derive(opts...),
+		Storage:   b.Storage,
+		IPAddress: b.IPAddress,
+		txIndex:   b.txIndex,
+	}
+}
+
 func (b *Bitcoind) call(method string, params []interface{}) (rpcResponse, error) {
+	return b.callContext(context.Background(), method, params)
+}
+
+// callContext is call with a context that cancels the request.
+func (b *Bitcoind) callContext(ctx context.Context, method string, params []interface{}) (rpcResponse, error) {
 	keyfunc := func(method string, params []interface{}) string {
 		return fmt.Sprintf("%s|%v", method, params)
 	}
 
-	return b.callWithKeyFunc(method, params, keyfunc)
+	return b.callWithKeyFunc(ctx, method, params, keyfunc)
+}
+
+// uncachedCall makes a call bypassing the cache and decodes its result into v, for calls whose result must
+// be current, e.g. because it depends on wallet or mempool state, or that change state.
+func (b *Bitcoind) uncachedCall(method string, params []interface{}, v interface{}) error {
+	return b.uncachedCallContext(context.Background(), method, params, v)
+}
+
+// uncachedCallContext is uncachedCall with a context that cancels the request.
+func (b *Bitcoind) uncachedCallContext(ctx context.Context, method string, params []interface{}, v interface{}) error {
+	r, err := b.client.callContext(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	if r.Err != nil {
+		return fmt.Errorf("%w (%s)", responseError(r.Err), method)
+	}
+
+	return json.Unmarshal(r.Result, v)
 }
 
-func (b *Bitcoind) callWithKeyFunc(method string, params []interface{}, keyfunc func(string, []interface{}) string) (rpcResponse, error) {
+// callWithKeyFunc makes a cached call. Concurrent callers of the same key share one request, which is made
+// with the values but not the cancellation of the first caller's context, so that a caller giving up does
+// not fail the others; each caller stops waiting when its own context is done.
+func (b *Bitcoind) callWithKeyFunc(ctx context.Context, method string, params []interface{}, keyfunc func(string, []interface{}) string) (rpcResponse, error) {
 	key := keyfunc(method, params)
 
 	// Check cache
@@ -91,9 +141,13 @@ func (b *Bitcoind) callWithKeyFunc(method string, params []interface{}, keyfunc
 		return value.(rpcResponse), nil
 	}
 
+	if err := ctx.Err(); err != nil {
+		return rpcResponse{}, err
+	}
+
 	// Combine memoized function with a cache store
-	value, err, _ := b.group.Do(key, func() (interface{}, error) {
-		data, innerErr := b.client.call(method, params)
+	ch := b.group.DoChan(key, func() (interface{}, error) {
+		data, innerErr := b.client.callContext(detachedContext{ctx}, method, params)
 
 		if innerErr == nil {
 			b.Storage.Set(key, data, cache.DefaultExpiration)
@@ -101,16 +155,96 @@ func (b *Bitcoind) callWithKeyFunc(method string, params []interface{}, keyfunc
 
 		return data, innerErr
 	})
-	return value.(rpcResponse), err
+
+	select {
+	case res := <-ch:
+		return res.Val.(rpcResponse), res.Err
+	case <-ctx.Done():
+		return rpcResponse{}, ctx.Err()
+	}
+}
+
+// detachedContext keeps the values of a context but not its deadline and cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (b *Bitcoind) read(ctx context.Context, method string, params []interface{}) (io.ReadCloser, error) {
+	return b.client.read(ctx, method, params)
+}
+
+// fundMovingMethods are the wallet methods RawCall refuses while a withdrawal policy is set, as their
+// withdrawals can only be checked by their wrappers.
+var fundMovingMethods = map[string]bool{
+	"bumpfee":             true,
+	"psbtbumpfee":         true,
+	"send":                true,
+	"sendall":             true,
+	"sendmany":            true,
+	"sendtoaddress":       true,
+	"sendrawtransactions": true,
+	"submitpackage":       true,
+}
+
+// RawCall calls any RPC method with the given params, bypassing the cache, and returns the raw result.
+// Typed wrappers should be preferred where they exist. While a withdrawal policy is set, transactions
+// passed to sendrawtransaction are checked against it and other methods sending funds are refused.
+func (b *Bitcoind) RawCall(method string, params ...interface{}) (json.RawMessage, error) {
+	return b.RawCallContext(context.Background(), method, params...)
 }
 
-func (b *Bitcoind) read(method string, params []interface{}) (io.ReadCloser, error) {
-	return b.client.read(method, params)
+// CallCtx calls any RPC method with the given params and a context that cancels the request, the context
+// first counterpart of RawCall for methods without a wrapper. Every wrapper has a Ctx variant as well, e.g.
+// GetBlockCtx for GetBlock.
+func (b *Bitcoind) CallCtx(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+	return b.RawCallContext(ctx, method, params...)
+}
+
+// RawCallContext is RawCall with a context that cancels the request.
+func (b *Bitcoind) RawCallContext(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
+	if b.client.withdrawalPolicy != nil {
+		if fundMovingMethods[method] {
+			return nil, fmt.Errorf("%w: %s cannot be called directly while a withdrawal policy is set", ErrWithdrawalRejected, method)
+		}
+
+		if method == "sendrawtransaction" {
+			var txHex string
+			if len(params) > 0 {
+				txHex, _ = params[0].(string)
+			}
+			if txHex == "" {
+				return nil, fmt.Errorf("%w: sendrawtransaction without a transaction", ErrWithdrawalRejected)
+			}
+			if err := b.authorizeTransaction(ctx, method, txHex); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	r, err := b.client.callContext(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Err != nil {
+		return nil, responseError(r.Err)
+	}
+
+	return r.Result, nil
 }
 
 // GetConnectionCount returns the number of connections to other nodes.
 func (b *Bitcoind) GetConnectionCount() (count uint64, err error) {
-	r, err := b.call("getconnectioncount", nil)
+	return b.GetConnectionCountCtx(context.Background())
+}
+
+// GetConnectionCountCtx is GetConnectionCount with a context that cancels the request.
+func (b *Bitcoind) GetConnectionCountCtx(ctx context.Context) (count uint64, err error) {
+	r, err := b.callContext(ctx, "getconnectioncount", nil)
 	if err != nil {
 		return 0, err
 	}
@@ -120,14 +254,18 @@ func (b *Bitcoind) GetConnectionCount() (count uint64, err error) {
 
 // GetBlockchainInfo returns the number of connections to other nodes.
 func (b *Bitcoind) GetBlockchainInfo() (info BlockchainInfo, err error) {
-	r, err := b.call("getblockchaininfo", nil)
+	return b.GetBlockchainInfoCtx(context.Background())
+}
+
+// GetBlockchainInfoCtx is GetBlockchainInfo with a context that cancels the request.
+func (b *Bitcoind) GetBlockchainInfoCtx(ctx context.Context) (info BlockchainInfo, err error) {
+	r, err := b.callContext(ctx, "getblockchaininfo", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -135,16 +273,42 @@ func (b *Bitcoind) GetBlockchainInfo() (info BlockchainInfo, err error) {
 	return
 }
 
+// GetChainStates returns the chainstates of the node, which includes the background validation of an
+// assumeutxo snapshot.
+func (b *Bitcoind) GetChainStates() (states ChainStates, err error) {
+	return b.GetChainStatesCtx(context.Background())
+}
+
+// GetChainStatesCtx is GetChainStates with a context that cancels the request.
+func (b *Bitcoind) GetChainStatesCtx(ctx context.Context) (states ChainStates, err error) {
+	r, err := b.callContext(ctx, "getchainstates", nil)
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &states)
+	return
+}
+
 // GetInfo returns the number of connections to other nodes.
 func (b *Bitcoind) GetInfo() (info GetInfo, err error) {
-	r, err := b.call("getinfo", nil)
+	return b.GetInfoCtx(context.Background())
+}
+
+// GetInfoCtx is GetInfo with a context that cancels the request.
+func (b *Bitcoind) GetInfoCtx(ctx context.Context) (info GetInfo, err error) {
+	r, err := b.callContext(ctx, "getinfo", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -154,14 +318,18 @@ func (b *Bitcoind) GetInfo() (info GetInfo, err error) {
 
 // GetInfo returns the number of connections to other nodes.
 func (b *Bitcoind) GetSettings() (settings Settings, err error) {
-	r, err := b.call("getsettings", nil)
+	return b.GetSettingsCtx(context.Background())
+}
+
+// GetSettingsCtx is GetSettings with a context that cancels the request.
+func (b *Bitcoind) GetSettingsCtx(ctx context.Context) (settings Settings, err error) {
+	r, err := b.callContext(ctx, "getsettings", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -171,14 +339,18 @@ func (b *Bitcoind) GetSettings() (settings Settings, err error) {
 
 // GetNetworkInfo returns the number of connections to other nodes.
 func (b *Bitcoind) GetNetworkInfo() (info NetworkInfo, err error) {
-	r, err := b.call("getnetworkinfo", nil)
+	return b.GetNetworkInfoCtx(context.Background())
+}
+
+// GetNetworkInfoCtx is GetNetworkInfo with a context that cancels the request.
+func (b *Bitcoind) GetNetworkInfoCtx(ctx context.Context) (info NetworkInfo, err error) {
+	r, err := b.callContext(ctx, "getnetworkinfo", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -188,14 +360,18 @@ func (b *Bitcoind) GetNetworkInfo() (info NetworkInfo, err error) {
 
 // GetNetTotals returns the number of connections to other nodes.
 func (b *Bitcoind) GetNetTotals() (totals NetTotals, err error) {
-	r, err := b.call("getnettotals", nil)
+	return b.GetNetTotalsCtx(context.Background())
+}
+
+// GetNetTotalsCtx is GetNetTotals with a context that cancels the request.
+func (b *Bitcoind) GetNetTotalsCtx(ctx context.Context) (totals NetTotals, err error) {
+	r, err := b.callContext(ctx, "getnettotals", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -205,14 +381,18 @@ func (b *Bitcoind) GetNetTotals() (totals NetTotals, err error) {
 
 // GetMiningInfo comment
 func (b *Bitcoind) GetMiningInfo() (info MiningInfo, err error) {
-	r, err := b.call("getmininginfo", nil)
+	return b.GetMiningInfoCtx(context.Background())
+}
+
+// GetMiningInfoCtx is GetMiningInfo with a context that cancels the request.
+func (b *Bitcoind) GetMiningInfoCtx(ctx context.Context) (info MiningInfo, err error) {
+	r, err := b.callContext(ctx, "getmininginfo", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -222,7 +402,12 @@ func (b *Bitcoind) GetMiningInfo() (info MiningInfo, err error) {
 
 // Uptime returns the number of connections to other nodes.
 func (b *Bitcoind) Uptime() (uptime uint64, err error) {
-	r, err := b.call("uptime", nil)
+	return b.UptimeCtx(context.Background())
+}
+
+// UptimeCtx is Uptime with a context that cancels the request.
+func (b *Bitcoind) UptimeCtx(ctx context.Context) (uptime uint64, err error) {
+	r, err := b.callContext(ctx, "uptime", nil)
 	if err != nil {
 		return 0, err
 	}
@@ -232,14 +417,18 @@ func (b *Bitcoind) Uptime() (uptime uint64, err error) {
 
 // GetPeerInfo returns the number of connections to other nodes.
 func (b *Bitcoind) GetPeerInfo() (info PeerInfo, err error) {
-	r, err := b.call("getpeerinfo", nil)
+	return b.GetPeerInfoCtx(context.Background())
+}
+
+// GetPeerInfoCtx is GetPeerInfo with a context that cancels the request.
+func (b *Bitcoind) GetPeerInfoCtx(ctx context.Context) (info PeerInfo, err error) {
+	r, err := b.callContext(ctx, "getpeerinfo", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -247,6 +436,66 @@ func (b *Bitcoind) GetPeerInfo() (info PeerInfo, err error) {
 	return
 }
 
+// DisconnectNode immediately disconnects from the peer with the given node id.
+func (b *Bitcoind) DisconnectNode(nodeID int) error {
+	return b.DisconnectNodeCtx(context.Background(), nodeID)
+}
+
+// DisconnectNodeCtx is DisconnectNode with a context that cancels the request.
+func (b *Bitcoind) DisconnectNodeCtx(ctx context.Context, nodeID int) error {
+	r, err := b.client.callContext(ctx, "disconnectnode", []interface{}{"", nodeID})
+	if err != nil {
+		return err
+	}
+
+	if r.Err != nil {
+		return responseError(r.Err)
+	}
+
+	return nil
+}
+
+// SetBan adds or removes an IP/Subnet from the banned list. Command is "add" or "remove", banTime is in seconds
+// (0 uses the node's default) and is treated as a unix timestamp when absolute is true.
+func (b *Bitcoind) SetBan(subnet string, command string, banTime int64, absolute bool) error {
+	return b.SetBanCtx(context.Background(), subnet, command, banTime, absolute)
+}
+
+// SetBanCtx is SetBan with a context that cancels the request.
+func (b *Bitcoind) SetBanCtx(ctx context.Context, subnet string, command string, banTime int64, absolute bool) error {
+	r, err := b.client.callContext(ctx, "setban", []interface{}{subnet, command, banTime, absolute})
+	if err != nil {
+		return err
+	}
+
+	if r.Err != nil {
+		return responseError(r.Err)
+	}
+
+	return nil
+}
+
+// ListBanned returns all manually banned IPs/Subnets.
+func (b *Bitcoind) ListBanned() (banned []BannedNode, err error) {
+	return b.ListBannedCtx(context.Background())
+}
+
+// ListBannedCtx is ListBanned with a context that cancels the request.
+func (b *Bitcoind) ListBannedCtx(ctx context.Context) (banned []BannedNode, err error) {
+	r, err := b.callContext(ctx, "listbanned", nil)
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &banned)
+	return
+}
+
 // GetChainTips return information about all known tips in the block tree, including the main chain as well as orphaned branches.
 // Possible values for status:
 // 1.  "invalid"               This branch contains at least one invalid block
@@ -255,14 +504,18 @@ func (b *Bitcoind) GetPeerInfo() (info PeerInfo, err error) {
 // 4.  "valid-fork"            This branch is not part of the active chain, but is fully validated
 // 5.  "active"                This is the tip of the active main chain, which is certainly valid
 func (b *Bitcoind) GetChainTips() (tips ChainTips, err error) {
-	r, err := b.call("getchaintips", nil)
+	return b.GetChainTipsCtx(context.Background())
+}
+
+// GetChainTipsCtx is GetChainTips with a context that cancels the request.
+func (b *Bitcoind) GetChainTipsCtx(ctx context.Context) (tips ChainTips, err error) {
+	r, err := b.callContext(ctx, "getchaintips", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -272,14 +525,18 @@ func (b *Bitcoind) GetChainTips() (tips ChainTips, err error) {
 
 // GetMempoolInfo comment
 func (b *Bitcoind) GetMempoolInfo() (info MempoolInfo, err error) {
-	r, err := b.call("getmempoolinfo", nil)
+	return b.GetMempoolInfoCtx(context.Background())
+}
+
+// GetMempoolInfoCtx is GetMempoolInfo with a context that cancels the request.
+func (b *Bitcoind) GetMempoolInfoCtx(ctx context.Context) (info MempoolInfo, err error) {
+	r, err := b.callContext(ctx, "getmempoolinfo", nil)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -289,15 +546,19 @@ func (b *Bitcoind) GetMempoolInfo() (info MempoolInfo, err error) {
 
 // GetMempoolEntry returns the entry in the current mempool for a specific tx id
 func (b *Bitcoind) GetMempoolEntry(txid string) (entry MempoolEntry, err error) {
+	return b.GetMempoolEntryCtx(context.Background(), txid)
+}
+
+// GetMempoolEntryCtx is GetMempoolEntry with a context that cancels the request.
+func (b *Bitcoind) GetMempoolEntryCtx(ctx context.Context, txid string) (entry MempoolEntry, err error) {
 	p := []interface{}{txid}
-	r, err := b.call("getmempoolentry", p)
+	r, err := b.callContext(ctx, "getmempoolentry", p)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -307,15 +568,19 @@ func (b *Bitcoind) GetMempoolEntry(txid string) (entry MempoolEntry, err error)
 
 // GetRawMempool returns the number of connections to other nodes.
 func (b *Bitcoind) GetRawMempool(details bool) (raw []byte, err error) {
+	return b.GetRawMempoolCtx(context.Background(), details)
+}
+
+// GetRawMempoolCtx is GetRawMempool with a context that cancels the request.
+func (b *Bitcoind) GetRawMempoolCtx(ctx context.Context, details bool) (raw []byte, err error) {
 	p := []interface{}{details}
-	r, err := b.call("getrawmempool", p)
+	r, err := b.callContext(ctx, "getrawmempool", p)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -324,9 +589,36 @@ func (b *Bitcoind) GetRawMempool(details bool) (raw []byte, err error) {
 	return
 }
 
+// GetRawMempoolSequence returns the txids in the mempool together with the mempool sequence number they
+// are valid at, for synchronising with the sequence ZMQ topic. The response is never cached.
+func (b *Bitcoind) GetRawMempoolSequence() (seq *MempoolSequence, err error) {
+	return b.GetRawMempoolSequenceCtx(context.Background())
+}
+
+// GetRawMempoolSequenceCtx is GetRawMempoolSequence with a context that cancels the request.
+func (b *Bitcoind) GetRawMempoolSequenceCtx(ctx context.Context) (seq *MempoolSequence, err error) {
+	r, err := b.client.callContext(ctx, "getrawmempool", []interface{}{false, true})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &seq)
+	return
+}
+
 // GetRawNonFinalMempool returns all transaction ids in the non-final memory pool as a json array of string transaction ids.
 func (b *Bitcoind) GetRawNonFinalMempool() ([]string, error) {
-	r, err := b.call("getrawnonfinalmempool", nil)
+	return b.GetRawNonFinalMempoolCtx(context.Background())
+}
+
+// GetRawNonFinalMempoolCtx is GetRawNonFinalMempool with a context that cancels the request.
+func (b *Bitcoind) GetRawNonFinalMempoolCtx(ctx context.Context) ([]string, error) {
+	r, err := b.callContext(ctx, "getrawnonfinalmempool", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -339,15 +631,19 @@ func (b *Bitcoind) GetRawNonFinalMempool() ([]string, error) {
 
 // GetMempoolAncestors if txid is in the mempool, returns all in-mempool ancestors..
 func (b *Bitcoind) GetMempoolAncestors(txid string, details bool) (raw []byte, err error) {
+	return b.GetMempoolAncestorsCtx(context.Background(), txid, details)
+}
+
+// GetMempoolAncestorsCtx is GetMempoolAncestors with a context that cancels the request.
+func (b *Bitcoind) GetMempoolAncestorsCtx(ctx context.Context, txid string, details bool) (raw []byte, err error) {
 	p := []interface{}{txid}
-	r, err := b.call("getmempoolancestors", p)
+	r, err := b.callContext(ctx, "getmempoolancestors", p)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -357,15 +653,19 @@ func (b *Bitcoind) GetMempoolAncestors(txid string, details bool) (raw []byte, e
 
 // GetMempoolDescendants if txid is in the mempool, returns all in-mempool descendants..
 func (b *Bitcoind) GetMempoolDescendants(txid string, details bool) (raw []byte, err error) {
+	return b.GetMempoolDescendantsCtx(context.Background(), txid, details)
+}
+
+// GetMempoolDescendantsCtx is GetMempoolDescendants with a context that cancels the request.
+func (b *Bitcoind) GetMempoolDescendantsCtx(ctx context.Context, txid string, details bool) (raw []byte, err error) {
 	p := []interface{}{txid}
-	r, err := b.call("getmempooldescendants", p)
+	r, err := b.callContext(ctx, "getmempooldescendants", p)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -373,17 +673,92 @@ func (b *Bitcoind) GetMempoolDescendants(txid string, details bool) (raw []byte,
 	return
 }
 
+// GetTxSpendingPrevout returns, for each of the given outpoints, the mempool transaction spending it if any.
+// The result is not cached, so a spend accepted by the node is seen by the next call.
+func (b *Bitcoind) GetTxSpendingPrevout(outpoints []Outpoint) (res []TxSpendingPrevout, err error) {
+	return b.GetTxSpendingPrevoutCtx(context.Background(), outpoints)
+}
+
+// GetTxSpendingPrevoutCtx is GetTxSpendingPrevout with a context that cancels the request.
+func (b *Bitcoind) GetTxSpendingPrevoutCtx(ctx context.Context, outpoints []Outpoint) (res []TxSpendingPrevout, err error) {
+	r, err := b.client.callContext(ctx, "gettxspendingprevout", []interface{}{outpoints})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &res)
+	return
+}
+
+// GetOrphanTxIDs returns the txids of all transactions in the node's orphan pool (Bitcoin Core 28 and later).
+// The orphan pool changes quickly, so the result is not cached.
+func (b *Bitcoind) GetOrphanTxIDs() (txids []string, err error) {
+	return b.GetOrphanTxIDsCtx(context.Background())
+}
+
+// GetOrphanTxIDsCtx is GetOrphanTxIDs with a context that cancels the request.
+func (b *Bitcoind) GetOrphanTxIDsCtx(ctx context.Context) (txids []string, err error) {
+	r, err := b.client.callContext(ctx, "getorphantxs", []interface{}{0})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &txids)
+	return
+}
+
+// GetOrphanTxs returns the transactions in the node's orphan pool (Bitcoin Core 28 and later),
+// including the raw transaction hex when includeHex is true. Like GetOrphanTxIDs it is not cached.
+func (b *Bitcoind) GetOrphanTxs(includeHex bool) (orphans []OrphanTx, err error) {
+	return b.GetOrphanTxsCtx(context.Background(), includeHex)
+}
+
+// GetOrphanTxsCtx is GetOrphanTxs with a context that cancels the request.
+func (b *Bitcoind) GetOrphanTxsCtx(ctx context.Context, includeHex bool) (orphans []OrphanTx, err error) {
+	verbosity := 1
+	if includeHex {
+		verbosity = 2
+	}
+
+	r, err := b.client.callContext(ctx, "getorphantxs", []interface{}{verbosity})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &orphans)
+	return
+}
+
 // GetChainTxStats returns the number of connections to other nodes.
 func (b *Bitcoind) GetChainTxStats(blockcount int) (stats ChainTXStats, err error) {
+	return b.GetChainTxStatsCtx(context.Background(), blockcount)
+}
+
+// GetChainTxStatsCtx is GetChainTxStats with a context that cancels the request.
+func (b *Bitcoind) GetChainTxStatsCtx(ctx context.Context, blockcount int) (stats ChainTXStats, err error) {
 	p := []interface{}{blockcount}
-	r, err := b.call("getchaintxstats", p)
+	r, err := b.callContext(ctx, "getchaintxstats", p)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -393,15 +768,19 @@ func (b *Bitcoind) GetChainTxStats(blockcount int) (stats ChainTXStats, err erro
 
 // ValidateAddress returns the number of connections to other nodes.
 func (b *Bitcoind) ValidateAddress(address string) (addr Address, err error) {
+	return b.ValidateAddressCtx(context.Background(), address)
+}
+
+// ValidateAddressCtx is ValidateAddress with a context that cancels the request.
+func (b *Bitcoind) ValidateAddressCtx(ctx context.Context, address string) (addr Address, err error) {
 	p := []interface{}{address}
-	r, err := b.call("validateaddress", p)
+	r, err := b.callContext(ctx, "validateaddress", p)
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -409,9 +788,149 @@ func (b *Bitcoind) ValidateAddress(address string) (addr Address, err error) {
 	return
 }
 
+// EstimateSmartFee estimates the fee rate needed for confirmation within confTarget blocks. mode is
+// "economical", "conservative" or "" for the node's default.
+func (b *Bitcoind) EstimateSmartFee(confTarget int, mode string) (estimate *SmartFeeEstimate, err error) {
+	return b.EstimateSmartFeeCtx(context.Background(), confTarget, mode)
+}
+
+// EstimateSmartFeeCtx is EstimateSmartFee with a context that cancels the request.
+func (b *Bitcoind) EstimateSmartFeeCtx(ctx context.Context, confTarget int, mode string) (estimate *SmartFeeEstimate, err error) {
+	p := []interface{}{confTarget}
+	if mode != "" {
+		p = append(p, mode)
+	}
+
+	r, err := b.callContext(ctx, "estimatesmartfee", p)
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &estimate)
+	return
+}
+
+// ErrHiddenCallsDisabled is returned by wrappers of hidden RPCs unless the client was created with WithHiddenCalls.
+var ErrHiddenCallsDisabled = errors.New("hidden RPC calls are disabled, enable them with WithHiddenCalls")
+
+// EstimateRawFee returns the fee estimator's raw estimates for confirmation within confTarget blocks,
+// including the bucket statistics behind them. A threshold of 0 uses the node's default success threshold.
+// estimaterawfee is a hidden RPC, so the client must be created with WithHiddenCalls.
+func (b *Bitcoind) EstimateRawFee(confTarget int, threshold float64) (estimate *RawFeeEstimate, err error) {
+	return b.EstimateRawFeeCtx(context.Background(), confTarget, threshold)
+}
+
+// EstimateRawFeeCtx is EstimateRawFee with a context that cancels the request.
+func (b *Bitcoind) EstimateRawFeeCtx(ctx context.Context, confTarget int, threshold float64) (estimate *RawFeeEstimate, err error) {
+	if !b.client.hiddenCalls {
+		err = ErrHiddenCallsDisabled
+		return
+	}
+
+	p := []interface{}{confTarget}
+	if threshold > 0 {
+		p = append(p, threshold)
+	}
+
+	r, err := b.callContext(ctx, "estimaterawfee", p)
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &estimate)
+	return
+}
+
+// GetDescriptorInfo analyses a descriptor, returning it in canonical form including its checksum.
+func (b *Bitcoind) GetDescriptorInfo(descriptor string) (info *DescriptorInfo, err error) {
+	return b.GetDescriptorInfoCtx(context.Background(), descriptor)
+}
+
+// GetDescriptorInfoCtx is GetDescriptorInfo with a context that cancels the request.
+func (b *Bitcoind) GetDescriptorInfoCtx(ctx context.Context, descriptor string) (info *DescriptorInfo, err error) {
+	r, err := b.callContext(ctx, "getdescriptorinfo", []interface{}{descriptor})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &info)
+	return
+}
+
+// DeriveAddresses derives the addresses at indexes begin to end (inclusive) of a ranged descriptor.
+// The descriptor must include its checksum.
+func (b *Bitcoind) DeriveAddresses(descriptor string, begin, end uint32) (addresses []string, err error) {
+	return b.DeriveAddressesCtx(context.Background(), descriptor, begin, end)
+}
+
+// DeriveAddressesCtx is DeriveAddresses with a context that cancels the request.
+func (b *Bitcoind) DeriveAddressesCtx(ctx context.Context, descriptor string, begin, end uint32) (addresses []string, err error) {
+	r, err := b.callContext(ctx, "deriveaddresses", []interface{}{descriptor, []uint32{begin, end}})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &addresses)
+	return
+}
+
+// DecodeScript decodes a hex encoded script. Besides the type and address, the result holds the P2SH
+// address and the segwit equivalent of the script where bitcoind can derive them. LocalType is set to
+// the type ClassifyScript finds, which helps to tell which template a nonstandard script misses.
+func (b *Bitcoind) DecodeScript(hexScript string) (script *DecodedScript, err error) {
+	return b.DecodeScriptCtx(context.Background(), hexScript)
+}
+
+// DecodeScriptCtx is DecodeScript with a context that cancels the request.
+func (b *Bitcoind) DecodeScriptCtx(ctx context.Context, hexScript string) (script *DecodedScript, err error) {
+	r, err := b.callContext(ctx, "decodescript", []interface{}{hexScript})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	if err = json.Unmarshal(r.Result, &script); err != nil {
+		return
+	}
+
+	if raw, decodeErr := hex.DecodeString(hexScript); decodeErr == nil {
+		script.LocalType = ClassifyScript(raw)
+	}
+	return
+}
+
 // GetHelp returns the number of connections to other nodes.
 func (b *Bitcoind) GetHelp() (j []byte, err error) {
-	r, err := b.call("help", nil)
+	return b.GetHelpCtx(context.Background())
+}
+
+// GetHelpCtx is GetHelp with a context that cancels the request.
+func (b *Bitcoind) GetHelpCtx(ctx context.Context) (j []byte, err error) {
+	r, err := b.callContext(ctx, "help", nil)
 	if err != nil {
 		return
 	}
@@ -422,7 +941,12 @@ func (b *Bitcoind) GetHelp() (j []byte, err error) {
 
 // GetBestBlockHash comment
 func (b *Bitcoind) GetBestBlockHash() (hash string, err error) {
-	r, err := b.call("getbestblockhash", nil)
+	return b.GetBestBlockHashCtx(context.Background())
+}
+
+// GetBestBlockHashCtx is GetBestBlockHash with a context that cancels the request.
+func (b *Bitcoind) GetBestBlockHashCtx(ctx context.Context) (hash string, err error) {
+	r, err := b.callContext(ctx, "getbestblockhash", nil)
 	if err != nil {
 		return "", err
 	}
@@ -434,8 +958,13 @@ func (b *Bitcoind) GetBestBlockHash() (hash string, err error) {
 
 // GetBlockHash comment
 func (b *Bitcoind) GetBlockHash(blockHeight int) (blockHash string, err error) {
+	return b.GetBlockHashCtx(context.Background(), blockHeight)
+}
+
+// GetBlockHashCtx is GetBlockHash with a context that cancels the request.
+func (b *Bitcoind) GetBlockHashCtx(ctx context.Context, blockHeight int) (blockHash string, err error) {
 	p := []interface{}{blockHeight}
-	r, err := b.call("getblockhash", p)
+	r, err := b.callContext(ctx, "getblockhash", p)
 	if err != nil {
 		return "", err
 	}
@@ -447,6 +976,54 @@ func (b *Bitcoind) GetBlockHash(blockHeight int) (blockHash string, err error) {
 	return
 }
 
+const getBlockHashesBatchSize = 500
+
+// GetBlockHashes returns the hashes of the blocks at heights fromHeight to toHeight (inclusive), using
+// JSON-RPC batches of up to getBlockHashesBatchSize requests.
+func (b *Bitcoind) GetBlockHashes(fromHeight, toHeight int) ([]string, error) {
+	return b.GetBlockHashesCtx(context.Background(), fromHeight, toHeight)
+}
+
+// GetBlockHashesCtx is GetBlockHashes with a context that cancels the request.
+func (b *Bitcoind) GetBlockHashesCtx(ctx context.Context, fromHeight, toHeight int) ([]string, error) {
+	if toHeight < fromHeight {
+		return nil, fmt.Errorf("invalid height range %d-%d", fromHeight, toHeight)
+	}
+
+	hashes := make([]string, 0, toHeight-fromHeight+1)
+
+	for start := fromHeight; start <= toHeight; start += getBlockHashesBatchSize {
+		end := start + getBlockHashesBatchSize - 1
+		if end > toHeight {
+			end = toHeight
+		}
+
+		params := make([][]interface{}, 0, end-start+1)
+		for height := start; height <= end; height++ {
+			params = append(params, []interface{}{height})
+		}
+
+		result, err := b.BatchCallCtx(ctx, "getblockhash", params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			if item.Err != nil {
+				return nil, fmt.Errorf("%w (height %d)", item.Err, start+item.Index)
+			}
+
+			var hash string
+			if err := json.Unmarshal(item.Value.(json.RawMessage), &hash); err != nil {
+				return nil, err
+			}
+			hashes = append(hashes, hash)
+		}
+	}
+
+	return hashes, nil
+}
+
 func keyFuncSendRawTransaction(method string, params []interface{}) string {
 	var b strings.Builder
 
@@ -458,7 +1035,16 @@ func keyFuncSendRawTransaction(method string, params []interface{}) string {
 }
 
 func (b *Bitcoind) SendRawTransaction(hex string) (txid string, err error) {
-	r, err := b.callWithKeyFunc("sendrawtransaction", []interface{}{hex}, keyFuncSendRawTransaction)
+	return b.SendRawTransactionCtx(context.Background(), hex)
+}
+
+// SendRawTransactionCtx is SendRawTransaction with a context that cancels the request.
+func (b *Bitcoind) SendRawTransactionCtx(ctx context.Context, hex string) (txid string, err error) {
+	if err = b.authorizeTransaction(ctx, "sendrawtransaction", hex); err != nil {
+		return "", err
+	}
+
+	r, err := b.callWithKeyFunc(ctx, "sendrawtransaction", []interface{}{hex}, keyFuncSendRawTransaction)
 	if err != nil {
 		return "", err
 	}
@@ -471,6 +1057,11 @@ func (b *Bitcoind) SendRawTransaction(hex string) (txid string, err error) {
 }
 
 func (b *Bitcoind) SendRawTransactionWithoutFeeCheck(hex string) (txid string, err error) {
+	return b.SendRawTransactionWithoutFeeCheckCtx(context.Background(), hex)
+}
+
+// SendRawTransactionWithoutFeeCheckCtx is SendRawTransactionWithoutFeeCheck with a context that cancels the request.
+func (b *Bitcoind) SendRawTransactionWithoutFeeCheckCtx(ctx context.Context, hex string) (txid string, err error) {
 
 	// Doing this function is 4 times faster than the normal fmt.Sprintf("%s|%v", method, params).  As we know that
 	// we will always pass (string, bool, bool) as the params, we can avoid the cost of reflection.
@@ -495,7 +1086,11 @@ func (b *Bitcoind) SendRawTransactionWithoutFeeCheck(hex string) (txid string, e
 		return s.String()
 	}
 
-	r, err := b.callWithKeyFunc("sendrawtransaction", []interface{}{hex, false, true}, keyFunc)
+	if err = b.authorizeTransaction(ctx, "sendrawtransaction", hex); err != nil {
+		return "", err
+	}
+
+	r, err := b.callWithKeyFunc(ctx, "sendrawtransaction", []interface{}{hex, false, true}, keyFunc)
 	if err != nil {
 		return "", err
 	}
@@ -528,7 +1123,18 @@ type BatchResults struct {
 }
 
 func (b *Bitcoind) SendRawTransactions(batchedTransactions []*BatchedTransaction, config map[string]interface{}) (*BatchResults, error) {
-	r, err := b.call("sendrawtransactions", []interface{}{batchedTransactions, config})
+	return b.SendRawTransactionsCtx(context.Background(), batchedTransactions, config)
+}
+
+// SendRawTransactionsCtx is SendRawTransactions with a context that cancels the request.
+func (b *Bitcoind) SendRawTransactionsCtx(ctx context.Context, batchedTransactions []*BatchedTransaction, config map[string]interface{}) (*BatchResults, error) {
+	for _, tx := range batchedTransactions {
+		if err := b.authorizeTransaction(ctx, "sendrawtransactions", tx.Hex); err != nil {
+			return nil, err
+		}
+	}
+
+	r, err := b.callContext(ctx, "sendrawtransactions", []interface{}{batchedTransactions, config})
 	if err != nil {
 		return nil, err
 	}
@@ -543,6 +1149,11 @@ func (b *Bitcoind) SendRawTransactions(batchedTransactions []*BatchedTransaction
 }
 
 func (b *Bitcoind) SendRawTransactionWithoutFeeCheckOrScriptCheck(raw string) (string, error) {
+	return b.SendRawTransactionWithoutFeeCheckOrScriptCheckCtx(context.Background(), raw)
+}
+
+// SendRawTransactionWithoutFeeCheckOrScriptCheckCtx is SendRawTransactionWithoutFeeCheckOrScriptCheck with a context that cancels the request.
+func (b *Bitcoind) SendRawTransactionWithoutFeeCheckOrScriptCheckCtx(ctx context.Context, raw string) (string, error) {
 
 	transactions := []*BatchedTransaction{{
 		Hex:                      raw,
@@ -552,7 +1163,11 @@ func (b *Bitcoind) SendRawTransactionWithoutFeeCheckOrScriptCheck(raw string) (s
 		Config:                   map[string]interface{}{"maxscriptsizepolicy": 50_000_000},
 	}}
 
-	r, err := b.call("sendrawtransactions", []interface{}{transactions})
+	if err := b.authorizeTransaction(ctx, "sendrawtransactions", raw); err != nil {
+		return "", err
+	}
+
+	r, err := b.callContext(ctx, "sendrawtransactions", []interface{}{transactions})
 	if err != nil {
 		return "", err
 	}
@@ -582,14 +1197,18 @@ func (b *Bitcoind) SendRawTransactionWithoutFeeCheckOrScriptCheck(raw string) (s
 
 // SignRawTransaction comment
 func (b *Bitcoind) SignRawTransaction(hex string) (sr *SignRawTransactionResponse, err error) {
-	r, err := b.call("signrawtransaction", []interface{}{hex})
+	return b.SignRawTransactionCtx(context.Background(), hex)
+}
+
+// SignRawTransactionCtx is SignRawTransaction with a context that cancels the request.
+func (b *Bitcoind) SignRawTransactionCtx(ctx context.Context, hex string) (sr *SignRawTransactionResponse, err error) {
+	r, err := b.callContext(ctx, "signrawtransaction", []interface{}{hex})
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -599,15 +1218,19 @@ func (b *Bitcoind) SignRawTransaction(hex string) (sr *SignRawTransactionRespons
 
 // GetBlock returns information about the block with the given hash.
 func (b *Bitcoind) GetBlock(blockHash string) (block *Block, err error) {
-	r, err := b.call("getblock", []interface{}{blockHash})
+	return b.GetBlockCtx(context.Background(), blockHash)
+}
+
+// GetBlockCtx is GetBlock with a context that cancels the request.
+func (b *Bitcoind) GetBlockCtx(ctx context.Context, blockHash string) (block *Block, err error) {
+	r, err := b.callContext(ctx, "getblock", []interface{}{blockHash})
 
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -617,14 +1240,18 @@ func (b *Bitcoind) GetBlock(blockHash string) (block *Block, err error) {
 
 // GetBlockStatsByHeight returns block stats from the given block height.
 func (b *Bitcoind) GetBlockStatsByHeight(blockHeight int) (block *BlockStats, err error) {
-	r, err := b.call("getblockstatsbyheight", []interface{}{blockHeight})
+	return b.GetBlockStatsByHeightCtx(context.Background(), blockHeight)
+}
+
+// GetBlockStatsByHeightCtx is GetBlockStatsByHeight with a context that cancels the request.
+func (b *Bitcoind) GetBlockStatsByHeightCtx(ctx context.Context, blockHeight int) (block *BlockStats, err error) {
+	r, err := b.callContext(ctx, "getblockstatsbyheight", []interface{}{blockHeight})
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -634,14 +1261,18 @@ func (b *Bitcoind) GetBlockStatsByHeight(blockHeight int) (block *BlockStats, er
 
 // GetBlockStats returns block stats from the given block hash.
 func (b *Bitcoind) GetBlockStats(blockHash string) (block *BlockStats, err error) {
-	r, err := b.call("getblockstats", []interface{}{blockHash})
+	return b.GetBlockStatsCtx(context.Background(), blockHash)
+}
+
+// GetBlockStatsCtx is GetBlockStats with a context that cancels the request.
+func (b *Bitcoind) GetBlockStatsCtx(ctx context.Context, blockHash string) (block *BlockStats, err error) {
+	r, err := b.callContext(ctx, "getblockstats", []interface{}{blockHash})
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -650,14 +1281,18 @@ func (b *Bitcoind) GetBlockStats(blockHash string) (block *BlockStats, err error
 }
 
 func (b *Bitcoind) GetBlockByHeight(blockHeight int) (block *Block, err error) {
-	r, err := b.call("getblockbyheight", []interface{}{blockHeight})
+	return b.GetBlockByHeightCtx(context.Background(), blockHeight)
+}
+
+// GetBlockByHeightCtx is GetBlockByHeight with a context that cancels the request.
+func (b *Bitcoind) GetBlockByHeightCtx(ctx context.Context, blockHeight int) (block *Block, err error) {
+	r, err := b.callContext(ctx, "getblockbyheight", []interface{}{blockHeight})
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -667,7 +1302,12 @@ func (b *Bitcoind) GetBlockByHeight(blockHeight int) (block *Block, err error) {
 
 // GetRawBlock returns the raw bytes of the block with the given hash.
 func (b *Bitcoind) GetRawBlock(blockHash string) ([]byte, error) {
-	r, err := b.call("getblock", []interface{}{blockHash, 0})
+	return b.GetRawBlockCtx(context.Background(), blockHash)
+}
+
+// GetRawBlockCtx is GetRawBlock with a context that cancels the request.
+func (b *Bitcoind) GetRawBlockCtx(ctx context.Context, blockHash string) ([]byte, error) {
+	r, err := b.callContext(ctx, "getblock", []interface{}{blockHash, 0})
 	if err != nil {
 		return nil, err
 	}
@@ -688,41 +1328,38 @@ func (b *Bitcoind) GetRawBlock(blockHash string) ([]byte, error) {
 
 // GetRawBlockReader returns a reader of the block with the given hash.
 func (b *Bitcoind) GetRawBlockReader(blockHash string) (io.ReadCloser, error) {
-	return b.read("getblock", []interface{}{blockHash, 0})
+	return b.GetRawBlockReaderCtx(context.Background(), blockHash)
 }
 
-func (b *Bitcoind) GetRawBlockRest(blockHash string) (io.ReadCloser, error) {
-	resp, err := http.Get(fmt.Sprintf("%s/rest/block/%s.bin", b.client.serverAddr, blockHash))
-	if err != nil {
-		return nil, fmt.Errorf("Could not GET block: %v", err)
-	}
-
-	if resp.StatusCode != 200 {
-		defer resp.Body.Close()
-
-		data, err := io.ReadAll(resp.Body)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
+// GetRawBlockReaderCtx is GetRawBlockReader with a context that cancels the request.
+func (b *Bitcoind) GetRawBlockReaderCtx(ctx context.Context, blockHash string) (io.ReadCloser, error) {
+	return b.read(ctx, "getblock", []interface{}{blockHash, 0})
+}
 
-		return nil, fmt.Errorf("ERROR: code %d: %s", resp.StatusCode, data)
-	}
+func (b *Bitcoind) GetRawBlockRest(blockHash string) (io.ReadCloser, error) {
+	return b.GetRawBlockRestCtx(context.Background(), blockHash)
+}
 
-	return resp.Body, nil
+// GetRawBlockRestCtx is GetRawBlockRest with a context that cancels the request.
+func (b *Bitcoind) GetRawBlockRestCtx(ctx context.Context, blockHash string) (io.ReadCloser, error) {
+	return b.Rest().StreamBlock(ctx, blockHash)
 }
 
 // GetBlockOverview returns basic information about the block with the given hash.
 func (b *Bitcoind) GetBlockOverview(blockHash string) (block *BlockOverview, err error) {
-	r, err := b.call("getblock", []interface{}{blockHash})
+	return b.GetBlockOverviewCtx(context.Background(), blockHash)
+}
+
+// GetBlockOverviewCtx is GetBlockOverview with a context that cancels the request.
+func (b *Bitcoind) GetBlockOverviewCtx(ctx context.Context, blockHash string) (block *BlockOverview, err error) {
+	r, err := b.callContext(ctx, "getblock", []interface{}{blockHash})
 
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -732,15 +1369,19 @@ func (b *Bitcoind) GetBlockOverview(blockHash string) (block *BlockOverview, err
 
 // GetBlockHeaderHex returns the block header hex for the given hash.
 func (b *Bitcoind) GetBlockHeaderHex(blockHash string) (blockHeader *string, err error) {
-	r, err := b.call("getblockheader", []interface{}{blockHash, false})
+	return b.GetBlockHeaderHexCtx(context.Background(), blockHash)
+}
+
+// GetBlockHeaderHexCtx is GetBlockHeaderHex with a context that cancels the request.
+func (b *Bitcoind) GetBlockHeaderHexCtx(ctx context.Context, blockHash string) (blockHeader *string, err error) {
+	r, err := b.callContext(ctx, "getblockheader", []interface{}{blockHash, false})
 
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -750,15 +1391,19 @@ func (b *Bitcoind) GetBlockHeaderHex(blockHash string) (blockHeader *string, err
 
 // GetBlockHeader returns the block header for the given hash.
 func (b *Bitcoind) GetBlockHeader(blockHash string) (blockHeader *BlockHeader, err error) {
-	r, err := b.call("getblockheader", []interface{}{blockHash})
+	return b.GetBlockHeaderCtx(context.Background(), blockHash)
+}
+
+// GetBlockHeaderCtx is GetBlockHeader with a context that cancels the request.
+func (b *Bitcoind) GetBlockHeaderCtx(ctx context.Context, blockHash string) (blockHeader *BlockHeader, err error) {
+	r, err := b.callContext(ctx, "getblockheader", []interface{}{blockHash})
 
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -768,7 +1413,12 @@ func (b *Bitcoind) GetBlockHeader(blockHash string) (blockHeader *BlockHeader, e
 
 // GetBlockHex returns information about the block with the given hash.
 func (b *Bitcoind) GetBlockHex(blockHash string) (raw *string, err error) {
-	r, err := b.call("getblock", []interface{}{blockHash, 0})
+	return b.GetBlockHexCtx(context.Background(), blockHash)
+}
+
+// GetBlockHexCtx is GetBlockHex with a context that cancels the request.
+func (b *Bitcoind) GetBlockHexCtx(ctx context.Context, blockHash string) (raw *string, err error) {
+	r, err := b.callContext(ctx, "getblock", []interface{}{blockHash, 0})
 	if err != nil {
 		return
 	}
@@ -779,7 +1429,12 @@ func (b *Bitcoind) GetBlockHex(blockHash string) (raw *string, err error) {
 
 // GetBlockHeaderAndCoinbase returns information about the block with the given hash.
 func (b *Bitcoind) GetBlockHeaderAndCoinbase(blockHash string) (blockHeaderAndCoinbase *BlockHeaderAndCoinbase, err error) {
-	r, err := b.call("getblock", []interface{}{blockHash, 3})
+	return b.GetBlockHeaderAndCoinbaseCtx(context.Background(), blockHash)
+}
+
+// GetBlockHeaderAndCoinbaseCtx is GetBlockHeaderAndCoinbase with a context that cancels the request.
+func (b *Bitcoind) GetBlockHeaderAndCoinbaseCtx(ctx context.Context, blockHash string) (blockHeaderAndCoinbase *BlockHeaderAndCoinbase, err error) {
+	r, err := b.callContext(ctx, "getblock", []interface{}{blockHash, 3})
 	if err != nil {
 		return
 	}
@@ -788,6 +1443,29 @@ func (b *Bitcoind) GetBlockHeaderAndCoinbase(blockHash string) (blockHeaderAndCo
 	return
 }
 
+// GetBlockWithPrevouts returns the block with the given hash including all transactions, where every input
+// carries the output it spends (getblock verbosity 3 on Bitcoin Core 25 and later). Note that on nodes where
+// verbosity 3 has a different meaning (see GetBlockHeaderAndCoinbase) the prevout data will be missing.
+func (b *Bitcoind) GetBlockWithPrevouts(blockHash string) (block *BlockWithPrevouts, err error) {
+	return b.GetBlockWithPrevoutsCtx(context.Background(), blockHash)
+}
+
+// GetBlockWithPrevoutsCtx is GetBlockWithPrevouts with a context that cancels the request.
+func (b *Bitcoind) GetBlockWithPrevoutsCtx(ctx context.Context, blockHash string) (block *BlockWithPrevouts, err error) {
+	r, err := b.callContext(ctx, "getblock", []interface{}{blockHash, 3})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &block)
+	return
+}
+
 func keyFuncForGetRawTransaction(method string, params []interface{}) string {
 	var b strings.Builder
 	b.WriteString(method)
@@ -805,6 +1483,11 @@ func keyFuncForGetRawTransaction(method string, params []interface{}) string {
 
 // GetRawTransaction returns raw transaction representation for given transaction id.
 func (b *Bitcoind) GetRawTransaction(txID string) (rawTx *RawTransaction, err error) {
+	return b.GetRawTransactionCtx(context.Background(), txID)
+}
+
+// GetRawTransactionCtx is GetRawTransaction with a context that cancels the request.
+func (b *Bitcoind) GetRawTransactionCtx(ctx context.Context, txID string) (rawTx *RawTransaction, err error) {
 	if txID == "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" {
 		// This is the genesis coinbase transaction and cannot be retrieved in this way.
 		return &RawTransaction{
@@ -839,7 +1522,7 @@ func (b *Bitcoind) GetRawTransaction(txID string) (rawTx *RawTransaction, err er
 		}, nil
 	}
 
-	r, err := b.callWithKeyFunc("getrawtransaction", []interface{}{txID, 1}, keyFuncForGetRawTransaction)
+	r, err := b.callWithKeyFunc(ctx, "getrawtransaction", b.rawTransactionParams(txID, 1), keyFuncForGetRawTransaction)
 	if err != nil {
 		return
 	}
@@ -849,13 +1532,18 @@ func (b *Bitcoind) GetRawTransaction(txID string) (rawTx *RawTransaction, err er
 
 // GetRawTransactionHex returns raw transaction representation for given transaction id.
 func (b *Bitcoind) GetRawTransactionHex(txID string) (rawTx *string, err error) {
+	return b.GetRawTransactionHexCtx(context.Background(), txID)
+}
+
+// GetRawTransactionHexCtx is GetRawTransactionHex with a context that cancels the request.
+func (b *Bitcoind) GetRawTransactionHexCtx(ctx context.Context, txID string) (rawTx *string, err error) {
 	if txID == "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" {
 		// This is the genesis coinbase transaction and cannot be retrieved in this way.
 		genesisHex := "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
 		return &genesisHex, nil
 	}
 
-	r, err := b.callWithKeyFunc("getrawtransaction", []interface{}{txID, 0}, keyFuncForGetRawTransaction)
+	r, err := b.callWithKeyFunc(ctx, "getrawtransaction", b.rawTransactionParams(txID, 0), keyFuncForGetRawTransaction)
 	if err != nil {
 		return
 	}
@@ -865,28 +1553,21 @@ func (b *Bitcoind) GetRawTransactionHex(txID string) (rawTx *string, err error)
 }
 
 func (b *Bitcoind) GetRawTransactionRest(txid string) (io.ReadCloser, error) {
-	resp, err := http.Get(fmt.Sprintf("%s/rest/tx/%s.bin", b.client.serverAddr, txid))
-	if err != nil {
-		return nil, fmt.Errorf("Could not GET tx: %v", err)
-	}
-
-	if resp.StatusCode != 200 {
-		defer resp.Body.Close()
-
-		data, err := io.ReadAll(resp.Body)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		return nil, fmt.Errorf("ERROR: code %d: %s", resp.StatusCode, data)
-	}
+	return b.GetRawTransactionRestCtx(context.Background(), txid)
+}
 
-	return resp.Body, nil
+// GetRawTransactionRestCtx is GetRawTransactionRest with a context that cancels the request.
+func (b *Bitcoind) GetRawTransactionRestCtx(ctx context.Context, txid string) (io.ReadCloser, error) {
+	return b.Rest().open(ctx, "/tx/"+txid+".bin")
 }
 
 // GetBlockTemplate comment
 func (b *Bitcoind) GetBlockTemplate(includeSegwit bool) (template *BlockTemplate, err error) {
+	return b.GetBlockTemplateCtx(context.Background(), includeSegwit)
+}
+
+// GetBlockTemplateCtx is GetBlockTemplate with a context that cancels the request.
+func (b *Bitcoind) GetBlockTemplateCtx(ctx context.Context, includeSegwit bool) (template *BlockTemplate, err error) {
 	params := gbtParams{}
 	if includeSegwit {
 		params = gbtParams{
@@ -896,7 +1577,7 @@ func (b *Bitcoind) GetBlockTemplate(includeSegwit bool) (template *BlockTemplate
 		}
 	}
 
-	r, err := b.call("getblocktemplate", []interface{}{params})
+	r, err := b.callContext(ctx, "getblocktemplate", []interface{}{params})
 	if err != nil {
 		return nil, err
 	}
@@ -910,8 +1591,13 @@ func (b *Bitcoind) GetBlockTemplate(includeSegwit bool) (template *BlockTemplate
 
 // GetMiningCandidate comment
 func (b *Bitcoind) GetMiningCandidate() (template *MiningCandidate, err error) {
+	return b.GetMiningCandidateCtx(context.Background())
+}
+
+// GetMiningCandidateCtx is GetMiningCandidate with a context that cancels the request.
+func (b *Bitcoind) GetMiningCandidateCtx(ctx context.Context) (template *MiningCandidate, err error) {
 
-	r, err := b.call("getminingcandidate", nil)
+	r, err := b.callContext(ctx, "getminingcandidate", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -925,7 +1611,12 @@ func (b *Bitcoind) GetMiningCandidate() (template *MiningCandidate, err error) {
 
 // SubmitBlock comment
 func (b *Bitcoind) SubmitBlock(hexData string) (result string, err error) {
-	r, err := b.client.call("submitblock", []interface{}{hexData})
+	return b.SubmitBlockCtx(context.Background(), hexData)
+}
+
+// SubmitBlockCtx is SubmitBlock with a context that cancels the request.
+func (b *Bitcoind) SubmitBlockCtx(ctx context.Context, hexData string) (result string, err error) {
+	r, err := b.client.callContext(ctx, "submitblock", []interface{}{hexData})
 	if err != nil || r.Err != nil || string(r.Result) != "null" {
 		msg := fmt.Sprintf("******* BLOCK SUBMIT FAILED with error: %+v and result: %s\n", err, string(r.Result))
 		return "", errors.New(msg)
@@ -936,6 +1627,11 @@ func (b *Bitcoind) SubmitBlock(hexData string) (result string, err error) {
 
 // SubmitMiningSolution comment
 func (b *Bitcoind) SubmitMiningSolution(miningCandidateID string, nonce uint32, coinbase string, time uint32, version uint32) (result string, err error) {
+	return b.SubmitMiningSolutionCtx(context.Background(), miningCandidateID, nonce, coinbase, time, version)
+}
+
+// SubmitMiningSolutionCtx is SubmitMiningSolution with a context that cancels the request.
+func (b *Bitcoind) SubmitMiningSolutionCtx(ctx context.Context, miningCandidateID string, nonce uint32, coinbase string, time uint32, version uint32) (result string, err error) {
 	params := submitMiningSolutionParams{
 		ID:       miningCandidateID,
 		Nonce:    nonce,
@@ -944,7 +1640,7 @@ func (b *Bitcoind) SubmitMiningSolution(miningCandidateID string, nonce uint32,
 		Version:  version,
 	}
 
-	r, err := b.client.call("submitminingsolution", []interface{}{params})
+	r, err := b.client.callContext(ctx, "submitminingsolution", []interface{}{params})
 	if (err != nil && err.Error() != "") || r.Err != nil || (string(r.Result) != "null" && string(r.Result) != "true") {
 		msg := fmt.Sprintf("******* BLOCK SUBMIT FAILED with error: %+v and result: %s\n", err, string(r.Result))
 		return "", errors.New(msg)
@@ -955,7 +1651,12 @@ func (b *Bitcoind) SubmitMiningSolution(miningCandidateID string, nonce uint32,
 
 // GetDifficulty comment
 func (b *Bitcoind) GetDifficulty() (difficulty float64, err error) {
-	r, err := b.call("getdifficulty", nil)
+	return b.GetDifficultyCtx(context.Background())
+}
+
+// GetDifficultyCtx is GetDifficulty with a context that cancels the request.
+func (b *Bitcoind) GetDifficultyCtx(ctx context.Context) (difficulty float64, err error) {
+	r, err := b.callContext(ctx, "getdifficulty", nil)
 	if err != nil {
 		return 0.0, err
 	}
@@ -966,7 +1667,12 @@ func (b *Bitcoind) GetDifficulty() (difficulty float64, err error) {
 
 // DecodeRawTransaction comment
 func (b *Bitcoind) DecodeRawTransaction(txHex string) (string, error) {
-	r, err := b.call("decoderawtransaction", []interface{}{txHex})
+	return b.DecodeRawTransactionCtx(context.Background(), txHex)
+}
+
+// DecodeRawTransactionCtx is DecodeRawTransaction with a context that cancels the request.
+func (b *Bitcoind) DecodeRawTransactionCtx(ctx context.Context, txHex string) (string, error) {
+	r, err := b.callContext(ctx, "decoderawtransaction", []interface{}{txHex})
 	if err != nil {
 		return "", err
 	}
@@ -988,7 +1694,12 @@ func keyFuncForGetTxOut(method string, params []interface{}) string {
 }
 
 func (b *Bitcoind) GetTxOut(txHex string, vout int, includeMempool bool) (res *TXOut, err error) {
-	r, err := b.callWithKeyFunc("gettxout", []interface{}{txHex, vout, includeMempool}, keyFuncForGetTxOut)
+	return b.GetTxOutCtx(context.Background(), txHex, vout, includeMempool)
+}
+
+// GetTxOutCtx is GetTxOut with a context that cancels the request.
+func (b *Bitcoind) GetTxOutCtx(ctx context.Context, txHex string, vout int, includeMempool bool) (res *TXOut, err error) {
+	r, err := b.callWithKeyFunc(ctx, "gettxout", []interface{}{txHex, vout, includeMempool}, keyFuncForGetTxOut)
 	if err != nil {
 		return
 	}
@@ -1000,10 +1711,15 @@ func (b *Bitcoind) GetTxOut(txHex string, vout int, includeMempool bool) (res *T
 
 // ListUnspent comment
 func (b *Bitcoind) ListUnspent(addresses []string) (res []*UnspentTransaction, err error) {
+	return b.ListUnspentCtx(context.Background(), addresses)
+}
+
+// ListUnspentCtx is ListUnspent with a context that cancels the request.
+func (b *Bitcoind) ListUnspentCtx(ctx context.Context, addresses []string) (res []*UnspentTransaction, err error) {
 	var minConf uint32 = 0
 	var maxConf uint32 = 9999999
 
-	r, err := b.call("listunspent", []interface{}{minConf, maxConf, addresses})
+	r, err := b.callContext(ctx, "listunspent", []interface{}{minConf, maxConf, addresses})
 	if err != nil {
 		return
 	}
@@ -1019,9 +1735,312 @@ func (b *Bitcoind) ListUnspent(addresses []string) (res []*UnspentTransaction, e
 	return
 }
 
+// ScanTxOutSet scans the UTXO set for outputs matching the given descriptors. Cancelling the context aborts
+// the scan on the node as well, so an abandoned scan does not keep running.
+func (b *Bitcoind) ScanTxOutSet(ctx context.Context, descriptors []string) (res *ScanTxOutSetResult, err error) {
+	r, err := b.client.callContext(ctx, "scantxoutset", []interface{}{"start", descriptors})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &res)
+	return
+}
+
+// RescanBlockchain rescans the local blockchain for wallet related transactions. Cancelling the context
+// stops the rescan on the node via abortrescan.
+func (b *Bitcoind) RescanBlockchain(ctx context.Context, startHeight, stopHeight int) (res *RescanResult, err error) {
+	params := []interface{}{startHeight}
+	if stopHeight > 0 {
+		params = append(params, stopHeight)
+	}
+
+	r, err := b.client.callContext(ctx, "rescanblockchain", params)
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &res)
+	return
+}
+
+// ImportDescriptors imports descriptors into a descriptor wallet. Cancelling the context aborts the rescan
+// the import triggers.
+func (b *Bitcoind) ImportDescriptors(ctx context.Context, requests []ImportDescriptorRequest) (res []ImportDescriptorResult, err error) {
+	r, err := b.client.callContext(ctx, "importdescriptors", []interface{}{requests})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &res)
+	return
+}
+
+// WalletCreateFundedPSBT creates a PSBT paying the given outputs (address to BTC amount), letting the wallet
+// select inputs and add change. options are passed to the node unchanged.
+func (b *Bitcoind) WalletCreateFundedPSBT(outputs map[string]float64, options map[string]interface{}) (res *FundedPSBT, err error) {
+	return b.WalletCreateFundedPSBTCtx(context.Background(), outputs, options)
+}
+
+// WalletCreateFundedPSBTCtx is WalletCreateFundedPSBT with a context that cancels the request.
+func (b *Bitcoind) WalletCreateFundedPSBTCtx(ctx context.Context, outputs map[string]float64, options map[string]interface{}) (res *FundedPSBT, err error) {
+	r, err := b.client.callContext(ctx, "walletcreatefundedpsbt", []interface{}{[]interface{}{}, outputs, 0, options, true})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &res)
+	return
+}
+
+// CombinePSBT merges the signatures of several copies of the same PSBT.
+func (b *Bitcoind) CombinePSBT(psbts []string) (psbt string, err error) {
+	return b.CombinePSBTCtx(context.Background(), psbts)
+}
+
+// CombinePSBTCtx is CombinePSBT with a context that cancels the request.
+func (b *Bitcoind) CombinePSBTCtx(ctx context.Context, psbts []string) (psbt string, err error) {
+	r, err := b.client.callContext(ctx, "combinepsbt", []interface{}{psbts})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &psbt)
+	return
+}
+
+// FinalizePSBT finalizes the inputs of a PSBT and, if complete, extracts the network serialized transaction.
+func (b *Bitcoind) FinalizePSBT(psbt string) (res *FinalizedPSBT, err error) {
+	return b.FinalizePSBTCtx(context.Background(), psbt)
+}
+
+// FinalizePSBTCtx is FinalizePSBT with a context that cancels the request.
+func (b *Bitcoind) FinalizePSBTCtx(ctx context.Context, psbt string) (res *FinalizedPSBT, err error) {
+	r, err := b.client.callContext(ctx, "finalizepsbt", []interface{}{psbt})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &res)
+	return
+}
+
+// WalletProcessPSBT updates a PSBT with the wallet's data about its inputs and outputs and, unless
+// opts.DontSign is set, signs the inputs the wallet has keys for. A nil opts uses the node's defaults.
+func (b *Bitcoind) WalletProcessPSBT(psbt string, opts *ProcessPSBTOptions) (res *ProcessedPSBT, err error) {
+	return b.WalletProcessPSBTCtx(context.Background(), psbt, opts)
+}
+
+// WalletProcessPSBTCtx is WalletProcessPSBT with a context that cancels the request.
+func (b *Bitcoind) WalletProcessPSBTCtx(ctx context.Context, psbt string, opts *ProcessPSBTOptions) (res *ProcessedPSBT, err error) {
+	if opts == nil {
+		opts = &ProcessPSBTOptions{}
+	}
+
+	sigHashType, err := sigHashName(opts.SigHashType)
+	if err != nil {
+		return
+	}
+
+	r, err := b.client.callContext(ctx, "walletprocesspsbt", []interface{}{psbt, !opts.DontSign, sigHashType, !opts.SkipBIP32Derivs, !opts.DontFinalize})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &res)
+	return
+}
+
+// DescriptorProcessPSBT updates a PSBT with the data of the given descriptors, which need private keys for
+// the inputs to be signed, without using a wallet. opts.DontSign is ignored. A nil opts uses the node's
+// defaults.
+func (b *Bitcoind) DescriptorProcessPSBT(psbt string, descriptors []string, opts *ProcessPSBTOptions) (res *ProcessedPSBT, err error) {
+	return b.DescriptorProcessPSBTCtx(context.Background(), psbt, descriptors, opts)
+}
+
+// DescriptorProcessPSBTCtx is DescriptorProcessPSBT with a context that cancels the request.
+func (b *Bitcoind) DescriptorProcessPSBTCtx(ctx context.Context, psbt string, descriptors []string, opts *ProcessPSBTOptions) (res *ProcessedPSBT, err error) {
+	if opts == nil {
+		opts = &ProcessPSBTOptions{}
+	}
+
+	sigHashType, err := sigHashName(opts.SigHashType)
+	if err != nil {
+		return
+	}
+
+	r, err := b.client.callContext(ctx, "descriptorprocesspsbt", []interface{}{psbt, descriptors, sigHashType, !opts.SkipBIP32Derivs, !opts.DontFinalize})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &res)
+	return
+}
+
+// GetTxOutProof returns a hex-encoded proof that the given transactions were included in a block. If blockHash
+// is empty the node needs -txindex or the transactions must have an unspent output.
+func (b *Bitcoind) GetTxOutProof(txids []string, blockHash string) (proof string, err error) {
+	return b.GetTxOutProofCtx(context.Background(), txids, blockHash)
+}
+
+// GetTxOutProofCtx is GetTxOutProof with a context that cancels the request.
+func (b *Bitcoind) GetTxOutProofCtx(ctx context.Context, txids []string, blockHash string) (proof string, err error) {
+	params := []interface{}{txids}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+
+	r, err := b.callContext(ctx, "gettxoutproof", params)
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &proof)
+	return
+}
+
+// ImportPrunedFunds imports funds into the wallet without a rescan, using the raw transaction and a
+// txoutproof for it. This is how wallets on pruned nodes learn about transactions in pruned blocks.
+func (b *Bitcoind) ImportPrunedFunds(rawTx string, txOutProof string) error {
+	return b.ImportPrunedFundsCtx(context.Background(), rawTx, txOutProof)
+}
+
+// ImportPrunedFundsCtx is ImportPrunedFunds with a context that cancels the request.
+func (b *Bitcoind) ImportPrunedFundsCtx(ctx context.Context, rawTx string, txOutProof string) error {
+	r, err := b.client.callContext(ctx, "importprunedfunds", []interface{}{rawTx, txOutProof})
+	if err != nil {
+		return err
+	}
+
+	if r.Err != nil {
+		return responseError(r.Err)
+	}
+
+	return nil
+}
+
+// RemovePrunedFunds deletes the given transaction from the wallet, the counterpart of ImportPrunedFunds.
+func (b *Bitcoind) RemovePrunedFunds(txid string) error {
+	return b.RemovePrunedFundsCtx(context.Background(), txid)
+}
+
+// RemovePrunedFundsCtx is RemovePrunedFunds with a context that cancels the request.
+func (b *Bitcoind) RemovePrunedFundsCtx(ctx context.Context, txid string) error {
+	r, err := b.client.callContext(ctx, "removeprunedfunds", []interface{}{txid})
+	if err != nil {
+		return err
+	}
+
+	if r.Err != nil {
+		return responseError(r.Err)
+	}
+
+	return nil
+}
+
+// ImportPrunedFundsFrom fetches the given transaction and its txoutproof from an archival node and imports
+// them into the wallet of b, which may be running on a pruned node.
+func (b *Bitcoind) ImportPrunedFundsFrom(archive *Bitcoind, txid string) error {
+	return b.ImportPrunedFundsFromCtx(context.Background(), archive, txid)
+}
+
+// ImportPrunedFundsFromCtx is ImportPrunedFundsFrom with a context that cancels the request.
+func (b *Bitcoind) ImportPrunedFundsFromCtx(ctx context.Context, archive *Bitcoind, txid string) error {
+	tx, err := archive.GetRawTransactionCtx(ctx, txid)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction %s from archival node: %w", txid, err)
+	}
+	if tx == nil || tx.BlockHash == "" {
+		return fmt.Errorf("transaction %s is not confirmed", txid)
+	}
+
+	proof, err := archive.GetTxOutProofCtx(ctx, []string{txid}, tx.BlockHash)
+	if err != nil {
+		return fmt.Errorf("failed to get txoutproof for %s from archival node: %w", txid, err)
+	}
+
+	return b.ImportPrunedFundsCtx(ctx, tx.Hex, proof)
+}
+
+// GetTransaction returns detailed information about an in-wallet transaction.
+func (b *Bitcoind) GetTransaction(txid string) (tx *WalletTransaction, err error) {
+	return b.GetTransactionCtx(context.Background(), txid)
+}
+
+// GetTransactionCtx is GetTransaction with a context that cancels the request.
+func (b *Bitcoind) GetTransactionCtx(ctx context.Context, txid string) (tx *WalletTransaction, err error) {
+	r, err := b.callContext(ctx, "gettransaction", []interface{}{txid})
+	if err != nil {
+		return
+	}
+
+	if r.Err != nil {
+		err = responseError(r.Err)
+		return
+	}
+
+	err = json.Unmarshal(r.Result, &tx)
+	return
+}
+
 // SendToAddress comment
 func (b *Bitcoind) SendToAddress(address string, amount float64) (string, error) {
-	r, err := b.call("sendtoaddress", []interface{}{address, amount})
+	return b.SendToAddressCtx(context.Background(), address, amount)
+}
+
+// SendToAddressCtx is SendToAddress with a context that cancels the request.
+func (b *Bitcoind) SendToAddressCtx(ctx context.Context, address string, amount float64) (string, error) {
+	if err := b.authorizeSend(ctx, "sendtoaddress", WithdrawalOutput{Address: address, Amount: amount}); err != nil {
+		return "", err
+	}
+
+	r, err := b.callContext(ctx, "sendtoaddress", []interface{}{address, amount})
 	if err != nil {
 		return "", err
 	}
@@ -1034,7 +2053,12 @@ func (b *Bitcoind) SendToAddress(address string, amount float64) (string, error)
 
 // Generate for regtest
 func (b *Bitcoind) Generate(amount float64) ([]string, error) {
-	r, err := b.call("generate", []interface{}{amount})
+	return b.GenerateCtx(context.Background(), amount)
+}
+
+// GenerateCtx is Generate with a context that cancels the request.
+func (b *Bitcoind) GenerateCtx(ctx context.Context, amount float64) ([]string, error) {
+	r, err := b.callContext(ctx, "generate", []interface{}{amount})
 	if err != nil {
 		return nil, err
 	}
@@ -1047,7 +2071,12 @@ func (b *Bitcoind) Generate(amount float64) ([]string, error) {
 
 // GenerateToAddress for regtest
 func (b *Bitcoind) GenerateToAddress(amount float64, address string) ([]string, error) {
-	r, err := b.call("generatetoaddress", []interface{}{amount, address})
+	return b.GenerateToAddressCtx(context.Background(), amount, address)
+}
+
+// GenerateToAddressCtx is GenerateToAddress with a context that cancels the request.
+func (b *Bitcoind) GenerateToAddressCtx(ctx context.Context, amount float64, address string) ([]string, error) {
+	r, err := b.callContext(ctx, "generatetoaddress", []interface{}{amount, address})
 	if err != nil {
 		return nil, err
 	}
@@ -1059,7 +2088,12 @@ func (b *Bitcoind) GenerateToAddress(amount float64, address string) ([]string,
 }
 
 func (b *Bitcoind) GetNewAddress() (string, error) {
-	r, err := b.call("getnewaddress", nil)
+	return b.GetNewAddressCtx(context.Background())
+}
+
+// GetNewAddressCtx is GetNewAddress with a context that cancels the request.
+func (b *Bitcoind) GetNewAddressCtx(ctx context.Context) (string, error) {
+	r, err := b.callContext(ctx, "getnewaddress", nil)
 	if err != nil {
 		return "", err
 	}
@@ -1071,7 +2105,12 @@ func (b *Bitcoind) GetNewAddress() (string, error) {
 }
 
 func (b *Bitcoind) DumpPrivKey(address string) (string, error) {
-	r, err := b.call("dumpprivkey", []interface{}{address})
+	return b.DumpPrivKeyCtx(context.Background(), address)
+}
+
+// DumpPrivKeyCtx is DumpPrivKey with a context that cancels the request.
+func (b *Bitcoind) DumpPrivKeyCtx(ctx context.Context, address string) (string, error) {
+	r, err := b.callContext(ctx, "dumpprivkey", []interface{}{address})
 	if err != nil {
 		return "", err
 	}
@@ -1083,23 +2122,32 @@ func (b *Bitcoind) DumpPrivKey(address string) (string, error) {
 }
 
 func (b *Bitcoind) SetAccount(address, account string) error {
-	_, err := b.call("setaccount", []interface{}{address, account})
+	return b.SetAccountCtx(context.Background(), address, account)
+}
+
+// SetAccountCtx is SetAccount with a context that cancels the request.
+func (b *Bitcoind) SetAccountCtx(ctx context.Context, address, account string) error {
+	_, err := b.callContext(ctx, "setaccount", []interface{}{address, account})
 	return err
 }
 
 // GetInfo returns the number of connections to other nodes.
 func (b *Bitcoind) BbGetBlock(hash_or_number string, page uint64) (block *BbBlock, err error) {
+	return b.BbGetBlockCtx(context.Background(), hash_or_number, page)
+}
+
+// BbGetBlockCtx is BbGetBlock with a context that cancels the request.
+func (b *Bitcoind) BbGetBlockCtx(ctx context.Context, hash_or_number string, page uint64) (block *BbBlock, err error) {
 	config := make(map[string]interface{})
 	config["page"] = page
 
-	r, err := b.call("bb_getblock", []interface{}{hash_or_number, config})
+	r, err := b.callContext(ctx, "bb_getblock", []interface{}{hash_or_number, config})
 	if err != nil {
 		return
 	}
 
 	if r.Err != nil {
-		rr := r.Err.(map[string]interface{})
-		err = fmt.Errorf("ERROR %s: %s", rr["code"], rr["message"])
+		err = responseError(r.Err)
 		return
 	}
 
@@ -1110,7 +2158,7 @@ func (b *Bitcoind) BbGetBlock(hash_or_number string, page uint64) (block *BbBloc
 
 	if page < block.TotalPages {
 		txs := append(make([]BbBlockTransaction, 0), block.Txs...)
-		block, err = b.BbGetBlock(hash_or_number, page+1)
+		block, err = b.BbGetBlockCtx(ctx, hash_or_number, page+1)
 		if err != nil {
 			return
 		}
@@ -1,3 +1,8 @@
+//go:build integration
+// +build integration
+
+// The tests in this file call a live regtest node on localhost and fail without one.
+
 package bitcoin
 
 // Force a new commit
@@ -16,7 +21,7 @@ import (
 )
 
 func TestGetBlockChainInfo(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -30,7 +35,7 @@ func TestGetBlockChainInfo(t *testing.T) {
 }
 
 func TestGetConnectionCount(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -44,7 +49,7 @@ func TestGetConnectionCount(t *testing.T) {
 }
 
 func TestGetNetworkInfo(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -59,7 +64,7 @@ func TestGetNetworkInfo(t *testing.T) {
 }
 
 func TestGetNetTotals(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -72,7 +77,7 @@ func TestGetNetTotals(t *testing.T) {
 	t.Logf("%#v", res)
 }
 func TestMiningInfo(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -86,7 +91,7 @@ func TestMiningInfo(t *testing.T) {
 }
 
 func TestUptime(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -100,7 +105,7 @@ func TestUptime(t *testing.T) {
 }
 
 func TestGetPeerInfo(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -114,7 +119,7 @@ func TestGetPeerInfo(t *testing.T) {
 }
 
 func TestGetRawMempoolWithDetails(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -128,7 +133,7 @@ func TestGetRawMempoolWithDetails(t *testing.T) {
 }
 
 func TestGetRawMempoolNoDetails(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -142,7 +147,7 @@ func TestGetRawMempoolNoDetails(t *testing.T) {
 }
 
 func TestGetMempoolInfo(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,7 +161,7 @@ func TestGetMempoolInfo(t *testing.T) {
 }
 
 func TestGetChainTxStats(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -170,7 +175,7 @@ func TestGetChainTxStats(t *testing.T) {
 }
 
 func TestValidateAddress(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -184,7 +189,7 @@ func TestValidateAddress(t *testing.T) {
 }
 
 func TestHelp(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -198,7 +203,7 @@ func TestHelp(t *testing.T) {
 }
 
 func TestGetBestBlockHash(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -212,7 +217,7 @@ func TestGetBestBlockHash(t *testing.T) {
 }
 
 func TestGetBlockHash(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -226,7 +231,7 @@ func TestGetBlockHash(t *testing.T) {
 }
 
 func TestSendRawTransaction2(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -237,7 +242,7 @@ func TestSendRawTransaction2(t *testing.T) {
 }
 
 func TestSendRawTransaction(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -248,7 +253,7 @@ func TestSendRawTransaction(t *testing.T) {
 }
 
 func TestSendRawTransactionWithoutFeeCheckOrScriptCheck(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -259,7 +264,7 @@ func TestSendRawTransactionWithoutFeeCheckOrScriptCheck(t *testing.T) {
 }
 
 func TestGetBlockOverview(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -276,7 +281,7 @@ func TestGetBlockOverview(t *testing.T) {
 }
 
 func TestGetBlock(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -293,7 +298,7 @@ func TestGetBlock(t *testing.T) {
 }
 
 func TestGetBlockByHeight(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -307,7 +312,7 @@ func TestGetBlockByHeight(t *testing.T) {
 }
 
 func TestGetBlockStatsByHeight(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -321,7 +326,7 @@ func TestGetBlockStatsByHeight(t *testing.T) {
 }
 
 func TestGetBlockStats(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -338,7 +343,7 @@ func TestGetBlockStats(t *testing.T) {
 }
 
 func TestGetGenesisBlock(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -355,7 +360,7 @@ func TestGetGenesisBlock(t *testing.T) {
 }
 
 func TestGetBlockHex(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -372,7 +377,7 @@ func TestGetBlockHex(t *testing.T) {
 }
 
 func TestGetBlockHeaderHex(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -389,7 +394,7 @@ func TestGetBlockHeaderHex(t *testing.T) {
 }
 
 func TestGetBlockHeader(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -406,7 +411,7 @@ func TestGetBlockHeader(t *testing.T) {
 }
 
 func TestGetBlockHeaderAndCoinbase(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -423,7 +428,7 @@ func TestGetBlockHeaderAndCoinbase(t *testing.T) {
 }
 
 func TestGetRawTransaction(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -440,7 +445,7 @@ func TestGetRawTransaction(t *testing.T) {
 }
 
 func TestGetRawTransactionHex(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -456,7 +461,7 @@ func TestGetRawTransactionHex(t *testing.T) {
 	t.Logf("%#v", *tx)
 }
 func TestGetDifficulty(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -470,7 +475,7 @@ func TestGetDifficulty(t *testing.T) {
 }
 
 // func TestGetBlockTemplate(t *testing.T) {
-// 	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+// 	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 // 	if err != nil {
 // 		t.Fatal(err)
 // 	}
@@ -486,7 +491,7 @@ func TestGetDifficulty(t *testing.T) {
 // }
 
 // func TestGetMiningCandidate(t *testing.T) {
-// 	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+// 	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 // 	if err != nil {
 // 		t.Fatal(err)
 // 	}
@@ -500,7 +505,7 @@ func TestGetDifficulty(t *testing.T) {
 // }
 
 func TestGetSettings(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -514,7 +519,7 @@ func TestGetSettings(t *testing.T) {
 }
 
 func TestGetTxOut(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -532,7 +537,7 @@ func TestGetTxOut(t *testing.T) {
 }
 
 func TestSubmitBlock(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -543,7 +548,7 @@ func TestSubmitBlock(t *testing.T) {
 }
 
 func TestSubmitMiningSolution(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -562,7 +567,7 @@ func TestSubmitMiningSolution(t *testing.T) {
 }
 
 func TestDecodeRawTransactionHex(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -576,7 +581,7 @@ func TestDecodeRawTransactionHex(t *testing.T) {
 }
 
 func TestListUnspent(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -593,7 +598,7 @@ func TestListUnspent(t *testing.T) {
 }
 
 func TestSendToAddress(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -608,7 +613,7 @@ func TestSendToAddress(t *testing.T) {
 }
 
 func TestGetRawBlock(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -633,7 +638,7 @@ func TestGetRawBlock(t *testing.T) {
 }
 
 func TestGetRawBlockRest(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -659,7 +664,7 @@ func TestGetRawBlockRest(t *testing.T) {
 }
 
 func TestSendToNewAddress(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	require.NoError(t, err)
 
 	addr, err := b.GetNewAddress()
@@ -696,7 +701,7 @@ func TestSendToNewAddress(t *testing.T) {
 }
 
 func TestGetRawTransactionRest(t *testing.T) {
-	b, err := New("localhost", 18332, "bitcoin", "bitcoin", false)
+	b, err := New("localhost", 18332, "", "bitcoin", "bitcoin", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -865,7 +870,7 @@ func TestDNS(t *testing.T) {
 }
 
 func TestErrTimeout(t *testing.T) {
-	b, err := New("localhost", 8332, "bitcoin", "bitcoin", false, WithTimeoutDuration(1*time.Millisecond))
+	b, err := New("localhost", 8332, "", "bitcoin", "bitcoin", false, WithTimeoutDuration(1*time.Millisecond))
 	if err != nil {
 		t.Fatal(err)
 	}
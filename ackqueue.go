@@ -0,0 +1,338 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnknownDelivery is returned when acknowledging a delivery that is not pending, e.g. acknowledged twice.
+var ErrUnknownDelivery = errors.New("unknown delivery")
+
+// Delivery is an event of an AckQueue.
+type Delivery struct {
+	ID       string    `json:"id"`
+	Topic    string    `json:"topic"`
+	Payload  []byte    `json:"payload"`
+	Time     time.Time `json:"time"`     // when it was published
+	Attempts int       `json:"attempts"` // times handed out by Next, including the current one
+}
+
+// DeliveryStore persists the deliveries of an AckQueue until they are acknowledged. SaveDelivery must
+// not return before the delivery is persisted, and replaces an earlier delivery with the same ID.
+type DeliveryStore interface {
+	SaveDelivery(d Delivery) error
+	DeleteDelivery(id string) error
+	LoadDeliveries() ([]Delivery, error)
+}
+
+// AckQueue delivers events at least once: an event handed out by Next is delivered again if it is not
+// acknowledged within AckTimeout, including after a restart, as events stay in the store until acknowledged.
+// Consumers must therefore tolerate duplicates, for example by keying deposits on txid and vout. It is safe
+// for concurrent use.
+type AckQueue struct {
+	AckTimeout time.Duration
+
+	store DeliveryStore
+	clock Clock
+	seq   uint64
+
+	mu      sync.Mutex
+	pending map[string]*pendingDelivery
+	order   []string // ids of pending deliveries, oldest first
+	changed chan struct{}
+}
+
+type pendingDelivery struct {
+	Delivery
+	deadline time.Time // zero while waiting to be handed out
+}
+
+// NewAckQueue returns a queue redelivering after ackTimeout, starting with the unacknowledged deliveries
+// found in store.
+func NewAckQueue(store DeliveryStore, ackTimeout time.Duration) (*AckQueue, error) {
+	deliveries, err := store.LoadDeliveries()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].Time.Before(deliveries[j].Time) })
+
+	q := &AckQueue{
+		AckTimeout: ackTimeout,
+		store:      store,
+		clock:      SystemClock,
+		pending:    make(map[string]*pendingDelivery, len(deliveries)),
+		changed:    make(chan struct{}),
+	}
+
+	for _, d := range deliveries {
+		q.pending[d.ID] = &pendingDelivery{Delivery: d}
+		q.order = append(q.order, d.ID)
+	}
+
+	return q, nil
+}
+
+// Publish stores an event and queues it for delivery.
+func (q *AckQueue) Publish(topic string, payload []byte) (string, error) {
+	now := q.clock.Now()
+	d := Delivery{
+		ID:      fmt.Sprintf("%d-%d", now.UnixNano(), atomic.AddUint64(&q.seq, 1)),
+		Topic:   topic,
+		Payload: payload,
+		Time:    now,
+	}
+
+	if err := q.store.SaveDelivery(d); err != nil {
+		return "", fmt.Errorf("failed to store %s event: %w", topic, err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending[d.ID] = &pendingDelivery{Delivery: d}
+	q.order = append(q.order, d.ID)
+	q.notify()
+
+	return d.ID, nil
+}
+
+// Follow publishes the messages of the given topics of zmq, with the message body as payload.
+func (q *AckQueue) Follow(zmq *ZMQ, topics ...string) error {
+	ch := make(chan ZMQMessage, 100)
+
+	go func() {
+		for m := range ch {
+			if _, err := q.Publish(m.Topic, m.Body); err != nil {
+				zmq.logger.Errorf("AckQueue: %v", err)
+			}
+		}
+	}()
+
+	for _, topic := range topics {
+		if err := zmq.SubscribeRaw(topic, ch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Next returns the oldest delivery that is waiting or whose acknowledgement timed out, waiting for one
+// until the context is done.
+func (q *AckQueue) Next(ctx context.Context) (Delivery, error) {
+	for {
+		q.mu.Lock()
+		now := q.clock.Now()
+
+		var next *pendingDelivery
+		var wake time.Time
+		for _, id := range q.order {
+			p := q.pending[id]
+			if p.deadline.IsZero() || !now.Before(p.deadline) {
+				next = p
+				break
+			}
+			if wake.IsZero() || p.deadline.Before(wake) {
+				wake = p.deadline
+			}
+		}
+
+		if next != nil {
+			next.Attempts++
+			next.deadline = now.Add(q.AckTimeout)
+
+			// The attempt is only counted, failing to store it does not stop the delivery. It is stored
+			// with the queue locked, so that it cannot bring back a delivery acknowledged in the meantime.
+			_ = q.store.SaveDelivery(next.Delivery)
+
+			q.mu.Unlock()
+			return next.Delivery, nil
+		}
+
+		changed := q.changed
+		q.mu.Unlock()
+
+		if err := q.wait(ctx, changed, wake.Sub(now)); err != nil {
+			return Delivery{}, err
+		}
+	}
+}
+
+// wait waits until changed is closed or, if it is positive, d elapsed.
+func (q *AckQueue) wait(ctx context.Context, changed chan struct{}, d time.Duration) error {
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := q.clock.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-changed:
+	case <-timeout:
+	}
+	return nil
+}
+
+// Ack acknowledges a delivery, removing it from the queue and the store.
+func (q *AckQueue) Ack(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, found := q.pending[id]; !found {
+		return fmt.Errorf("%w %s", ErrUnknownDelivery, id)
+	}
+
+	if err := q.store.DeleteDelivery(id); err != nil {
+		return fmt.Errorf("failed to delete delivery %s: %w", id, err)
+	}
+
+	delete(q.pending, id)
+	for i, pending := range q.order {
+		if pending == id {
+			q.order = append(q.order[:i:i], q.order[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// Nack hands a delivery out again straight away instead of after the acknowledgement timeout.
+func (q *AckQueue) Nack(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, found := q.pending[id]
+	if !found {
+		return fmt.Errorf("%w %s", ErrUnknownDelivery, id)
+	}
+
+	p.deadline = time.Time{}
+	q.notify()
+
+	return nil
+}
+
+// Len returns the number of unacknowledged deliveries.
+func (q *AckQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+// notify wakes up Next callers, q.mu must be held.
+func (q *AckQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// MemoryDeliveryStore is a DeliveryStore that keeps deliveries in memory, which is mostly useful for tests.
+type MemoryDeliveryStore struct {
+	mu         sync.Mutex
+	deliveries map[string]Delivery
+}
+
+// NewMemoryDeliveryStore returns an empty MemoryDeliveryStore.
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{deliveries: make(map[string]Delivery)}
+}
+
+func (s *MemoryDeliveryStore) SaveDelivery(d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+func (s *MemoryDeliveryStore) DeleteDelivery(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.deliveries, id)
+	return nil
+}
+
+func (s *MemoryDeliveryStore) LoadDeliveries() ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deliveries := make([]Delivery, 0, len(s.deliveries))
+	for _, d := range s.deliveries {
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+// FileDeliveryStore is a DeliveryStore that keeps each delivery as a JSON file in a directory, replaced
+// atomically like the files of a FileCursorStore.
+type FileDeliveryStore struct {
+	dir string
+}
+
+// NewFileDeliveryStore returns a FileDeliveryStore using the given directory, creating it if needed.
+func NewFileDeliveryStore(dir string) (*FileDeliveryStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create delivery directory: %w", err)
+	}
+
+	return &FileDeliveryStore{dir: dir}, nil
+}
+
+func (s *FileDeliveryStore) SaveDelivery(d Delivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.dir, d.ID+".json", data)
+}
+
+func (s *FileDeliveryStore) DeleteDelivery(id string) error {
+	err := os.Remove(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileDeliveryStore) LoadDeliveries() ([]Delivery, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delivery directory: %w", err)
+	}
+
+	var deliveries []Delivery
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read delivery: %w", err)
+		}
+
+		var d Delivery
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, fmt.Errorf("failed to decode delivery %s: %w", entry.Name(), err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
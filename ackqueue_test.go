@@ -0,0 +1,98 @@
+package bitcoin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAckQueue(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	q, err := NewAckQueue(NewMemoryDeliveryStore(), time.Minute)
+	require.NoError(t, err)
+	q.clock = clock
+
+	ctx := context.Background()
+
+	first, err := q.Publish("rawtx", []byte{1})
+	require.NoError(t, err)
+	second, err := q.Publish("rawtx", []byte{2})
+	require.NoError(t, err)
+
+	d, err := q.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first, d.ID)
+	assert.Equal(t, 1, d.Attempts)
+	require.NoError(t, q.Ack(d.ID))
+	assert.ErrorIs(t, q.Ack(d.ID), ErrUnknownDelivery)
+
+	d, err = q.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, second, d.ID)
+	assert.Equal(t, []byte{2}, d.Payload)
+
+	// The unacknowledged delivery comes back after the timeout
+	done := make(chan Delivery)
+	go func() {
+		d, _ := q.Next(ctx)
+		done <- d
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	d = <-done
+	assert.Equal(t, second, d.ID)
+	assert.Equal(t, 2, d.Attempts)
+
+	// And straight away when it is not acknowledged
+	require.NoError(t, q.Nack(d.ID))
+	d, err = q.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, d.Attempts)
+
+	require.NoError(t, q.Ack(d.ID))
+	assert.Equal(t, 0, q.Len())
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = q.Next(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAckQueueRestart(t *testing.T) {
+	store, err := NewFileDeliveryStore(t.TempDir())
+	require.NoError(t, err)
+
+	q, err := NewAckQueue(store, time.Minute)
+	require.NoError(t, err)
+
+	acked, err := q.Publish("hashblock", []byte("acked"))
+	require.NoError(t, err)
+	unacked, err := q.Publish("hashblock", []byte("unacked"))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := q.Next(context.Background())
+		require.NoError(t, err)
+	}
+	require.NoError(t, q.Ack(acked))
+
+	// Unacknowledged deliveries are handed out again by the next process straight away
+	q, err = NewAckQueue(store, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, q.Len())
+
+	d, err := q.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, unacked, d.ID)
+	assert.Equal(t, "hashblock", d.Topic)
+	assert.Equal(t, []byte("unacked"), d.Payload)
+	assert.Equal(t, 2, d.Attempts)
+
+	require.NoError(t, q.Ack(d.ID))
+	deliveries, err := store.LoadDeliveries()
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
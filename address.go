@@ -0,0 +1,352 @@
+package bitcoin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+// ChainParams are the address encoding and subsidy parameters of a network.
+type ChainParams struct {
+	Name                   string // as reported by getblockchaininfo
+	PubKeyHashPrefix       byte
+	ScriptHashPrefix       byte
+	Bech32HRP              string
+	SubsidyHalvingInterval int64
+}
+
+// Parameters of the networks bitcoind supports. Testnet and signet share their address formats.
+var (
+	MainNetParams  = &ChainParams{Name: "main", PubKeyHashPrefix: 0x00, ScriptHashPrefix: 0x05, Bech32HRP: "bc", SubsidyHalvingInterval: 210000}
+	TestNetParams  = &ChainParams{Name: "test", PubKeyHashPrefix: 0x6f, ScriptHashPrefix: 0xc4, Bech32HRP: "tb", SubsidyHalvingInterval: 210000}
+	TestNet4Params = &ChainParams{Name: "testnet4", PubKeyHashPrefix: 0x6f, ScriptHashPrefix: 0xc4, Bech32HRP: "tb", SubsidyHalvingInterval: 210000}
+	SigNetParams   = &ChainParams{Name: "signet", PubKeyHashPrefix: 0x6f, ScriptHashPrefix: 0xc4, Bech32HRP: "tb", SubsidyHalvingInterval: 210000}
+	RegTestParams  = &ChainParams{Name: "regtest", PubKeyHashPrefix: 0x6f, ScriptHashPrefix: 0xc4, Bech32HRP: "bcrt", SubsidyHalvingInterval: 150}
+)
+
+// ParamsForChain returns the parameters of the chain named as in the chain field of getblockchaininfo.
+func ParamsForChain(chain string) (*ChainParams, error) {
+	for _, params := range []*ChainParams{MainNetParams, TestNetParams, TestNet4Params, SigNetParams, RegTestParams} {
+		if params.Name == chain {
+			return params, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown chain %q", chain)
+}
+
+// Errors returned by the address conversions.
+var (
+	ErrNoAddress      = errors.New("script has no address")
+	ErrInvalidAddress = errors.New("invalid address")
+)
+
+// Script types as named by the type field of decodescript and scriptPubKey objects.
+const (
+	ScriptTypeNonStandard         = "nonstandard"
+	ScriptTypePubKey              = "pubkey"
+	ScriptTypePubKeyHash          = "pubkeyhash"
+	ScriptTypeScriptHash          = "scripthash"
+	ScriptTypeMultisig            = "multisig"
+	ScriptTypeNullData            = "nulldata"
+	ScriptTypeWitnessV0KeyHash    = "witness_v0_keyhash"
+	ScriptTypeWitnessV0ScriptHash = "witness_v0_scripthash"
+	ScriptTypeWitnessV1Taproot    = "witness_v1_taproot"
+	ScriptTypeAnchor              = "anchor"
+	ScriptTypeWitnessUnknown      = "witness_unknown"
+)
+
+// anchorProgram is the witness program of pay-to-anchor outputs, OP_1 <0x4e73>.
+var anchorProgram = []byte{0x4e, 0x73}
+
+// ClassifyScript returns the type of a scriptPubKey the way bitcoind reports it.
+func ClassifyScript(script []byte) string {
+	n := len(script)
+
+	switch {
+	case n == 25 && script[0] == 0x76 && script[1] == 0xa9 && script[2] == 0x14 && script[23] == 0x88 && script[24] == 0xac:
+		return ScriptTypePubKeyHash
+	case n == 23 && script[0] == 0xa9 && script[1] == 0x14 && script[22] == 0x87:
+		return ScriptTypeScriptHash
+	case (n == 35 && script[0] == 33 || n == 67 && script[0] == 65) && script[n-1] == 0xac:
+		return ScriptTypePubKey
+	case n > 0 && script[0] == 0x6a:
+		return ScriptTypeNullData
+	}
+
+	if version, program, ok := witnessProgram(script); ok {
+		switch {
+		case version == 0 && len(program) == 20:
+			return ScriptTypeWitnessV0KeyHash
+		case version == 0 && len(program) == 32:
+			return ScriptTypeWitnessV0ScriptHash
+		case version == 0:
+			return ScriptTypeNonStandard
+		case version == 1 && len(program) == 32:
+			return ScriptTypeWitnessV1Taproot
+		case version == 1 && bytes.Equal(program, anchorProgram):
+			return ScriptTypeAnchor
+		default:
+			return ScriptTypeWitnessUnknown
+		}
+	}
+
+	if isMultisig(script) {
+		return ScriptTypeMultisig
+	}
+
+	return ScriptTypeNonStandard
+}
+
+// witnessProgram splits a segwit scriptPubKey, a version opcode followed by a push of 2 to 40 bytes.
+func witnessProgram(script []byte) (version int, program []byte, ok bool) {
+	if len(script) < 4 || len(script) > 42 || int(script[1]) != len(script)-2 {
+		return 0, nil, false
+	}
+
+	switch {
+	case script[0] == 0x00:
+		return 0, script[2:], true
+	case script[0] >= 0x51 && script[0] <= 0x60:
+		return int(script[0] - 0x50), script[2:], true
+	}
+
+	return 0, nil, false
+}
+
+// isMultisig reports whether script is OP_m <pubkey>... OP_n OP_CHECKMULTISIG with 1 <= m <= n <= 16.
+func isMultisig(script []byte) bool {
+	n := len(script)
+	if n < 3 || script[n-1] != 0xae || script[0] < 0x51 || script[0] > 0x60 || script[n-2] < 0x51 || script[n-2] > 0x60 {
+		return false
+	}
+
+	m, keys := int(script[0]-0x50), 0
+	for i := 1; i < n-2; {
+		size := int(script[i])
+		if (size != 33 && size != 65) || i+1+size > n-2 {
+			return false
+		}
+		i += 1 + size
+		keys++
+	}
+
+	return keys == int(script[n-2]-0x50) && m <= keys
+}
+
+// ScriptToAddress returns the address of a scriptPubKey on the given network. Scripts without an address,
+// such as bare public keys, multisig and OP_RETURN outputs, return ErrNoAddress.
+func ScriptToAddress(script []byte, params *ChainParams) (string, error) {
+	switch ClassifyScript(script) {
+	case ScriptTypePubKeyHash:
+		return base58CheckEncode(params.PubKeyHashPrefix, script[3:23]), nil
+	case ScriptTypeScriptHash:
+		return base58CheckEncode(params.ScriptHashPrefix, script[2:22]), nil
+	case ScriptTypeWitnessV0KeyHash, ScriptTypeWitnessV0ScriptHash, ScriptTypeWitnessV1Taproot, ScriptTypeAnchor, ScriptTypeWitnessUnknown:
+		version, program, _ := witnessProgram(script)
+		return segwitEncode(params.Bech32HRP, version, program)
+	}
+
+	return "", ErrNoAddress
+}
+
+// AddressToScript returns the scriptPubKey paid by an address of the given network, or of any network if
+// params is nil.
+func AddressToScript(address string, params *ChainParams) ([]byte, error) {
+	networks := []*ChainParams{MainNetParams, TestNetParams, RegTestParams}
+	if params != nil {
+		networks = []*ChainParams{params}
+	}
+
+	for _, p := range networks {
+		if strings.HasPrefix(strings.ToLower(address), p.Bech32HRP+"1") {
+			version, program, err := segwitDecode(p.Bech32HRP, address)
+			if err != nil {
+				return nil, err
+			}
+
+			op := byte(0x00)
+			if version > 0 {
+				op = 0x50 + byte(version)
+			}
+			return append([]byte{op, byte(len(program))}, program...), nil
+		}
+	}
+
+	prefix, hash, err := base58CheckDecode(address)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range networks {
+		switch prefix {
+		case p.PubKeyHashPrefix:
+			return append(append([]byte{0x76, 0xa9, 0x14}, hash...), 0x88, 0xac), nil
+		case p.ScriptHashPrefix:
+			return append(append([]byte{0xa9, 0x14}, hash...), 0x87), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s is not an address of this network", ErrInvalidAddress, address)
+}
+
+func base58CheckEncode(prefix byte, hash []byte) string {
+	payload := append([]byte{prefix}, hash...)
+	return cryptolib.EncodeToString(append(payload, cryptolib.Sha256d(payload)[:4]...))
+}
+
+func base58CheckDecode(address string) (byte, []byte, error) {
+	data, err := cryptolib.DecodeString(address)
+	if err != nil || len(data) != 25 {
+		return 0, nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+
+	if !bytes.Equal(cryptolib.Sha256d(data[:21])[:4], data[21:]) {
+		return 0, nil, fmt.Errorf("%w: checksum mismatch in %s", ErrInvalidAddress, address)
+	}
+
+	return data[0], data[1:21], nil
+}
+
+// Bech32 (BIP 173) and bech32m (BIP 350) encoding of segwit addresses.
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// convertBits regroups a sequence of fromBits wide values into toBits wide ones.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, bool) {
+	var acc, bits uint
+	maxv := uint(1)<<toBits - 1
+	out := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+
+	for _, value := range data {
+		if uint(value)>>fromBits != 0 {
+			return nil, false
+		}
+		acc = acc<<fromBits | uint(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, false
+	}
+
+	return out, true
+}
+
+func segwitEncode(hrp string, version int, program []byte) (string, error) {
+	data, _ := convertBits(program, 8, 5, true)
+	data = append([]byte{byte(version)}, data...)
+
+	constant := uint32(bech32Const)
+	if version > 0 {
+		constant = bech32mConst
+	}
+
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ constant
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+
+	return sb.String(), nil
+}
+
+func segwitDecode(hrp, address string) (int, []byte, error) {
+	invalid := func(reason string) (int, []byte, error) {
+		return 0, nil, fmt.Errorf("%w: %s: %s", ErrInvalidAddress, address, reason)
+	}
+
+	if len(address) > 90 {
+		return invalid("too long")
+	}
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return invalid("mixed case")
+	}
+	address = strings.ToLower(address)
+
+	sep := strings.LastIndexByte(address, '1')
+	if sep != len(hrp) || address[:sep] != hrp || len(address)-sep-1 < 7 {
+		return invalid("wrong prefix")
+	}
+
+	data := make([]byte, 0, len(address)-sep-1)
+	for _, c := range address[sep+1:] {
+		d := strings.IndexRune(bech32Charset, c)
+		if d < 0 {
+			return invalid("invalid character")
+		}
+		data = append(data, byte(d))
+	}
+
+	version := int(data[0])
+	if version > 16 {
+		return invalid("invalid witness version")
+	}
+
+	constant := uint32(bech32Const)
+	if version > 0 {
+		constant = bech32mConst
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != constant {
+		return invalid("checksum mismatch")
+	}
+
+	program, ok := convertBits(data[1:len(data)-6], 5, 8, false)
+	if !ok || len(program) < 2 || len(program) > 40 || (version == 0 && len(program) != 20 && len(program) != 32) {
+		return invalid("invalid witness program")
+	}
+
+	return version, program, nil
+}
@@ -0,0 +1,106 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressConversion(t *testing.T) {
+	for _, tc := range []struct {
+		address    string
+		params     *ChainParams
+		script     string
+		scriptType string
+	}{
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", MainNetParams, "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac", ScriptTypePubKeyHash},
+		{"3P14159f73E4gFr7JterCCQh9QjiTjiZrG", MainNetParams, "a914e9c3dd0c07aac76179ebc76a6c78d4d67c6c160a87", ScriptTypeScriptHash},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", MainNetParams, "0014751e76e8199196d454941c45d1b3a323f1433bd6", ScriptTypeWitnessV0KeyHash},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", TestNetParams, "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262", ScriptTypeWitnessV0ScriptHash},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", MainNetParams, "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", ScriptTypeWitnessV1Taproot},
+		{"bc1pfeessrawgf", MainNetParams, "51024e73", ScriptTypeAnchor},
+	} {
+		script, err := AddressToScript(tc.address, tc.params)
+		require.NoError(t, err, tc.address)
+		assert.Equal(t, tc.script, hex.EncodeToString(script), tc.address)
+		assert.Equal(t, tc.scriptType, ClassifyScript(script), tc.address)
+
+		address, err := ScriptToAddress(script, tc.params)
+		require.NoError(t, err, tc.address)
+		assert.Equal(t, tc.address, address)
+
+		// Without params the network is taken from the address
+		script, err = AddressToScript(tc.address, nil)
+		require.NoError(t, err, tc.address)
+		assert.Equal(t, tc.script, hex.EncodeToString(script), tc.address)
+	}
+}
+
+func TestAddressToScriptErrors(t *testing.T) {
+	for _, address := range []string{
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", // checksum
+		"bc1qw508d6qejxtdg4y5r3zarvary0C5xw7kv8f3t4", // mixed case
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb",
+		"",
+	} {
+		_, err := AddressToScript(address, MainNetParams)
+		assert.True(t, errors.Is(err, ErrInvalidAddress), "%s: %v", address, err)
+	}
+
+	// Addresses of other networks
+	_, err := AddressToScript("tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", MainNetParams)
+	assert.True(t, errors.Is(err, ErrInvalidAddress), "%v", err)
+	_, err = AddressToScript("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", RegTestParams)
+	assert.True(t, errors.Is(err, ErrInvalidAddress), "%v", err)
+}
+
+func TestClassifyScript(t *testing.T) {
+	key := "21" + "02" + "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+	for script, scriptType := range map[string]string{
+		key + "ac":                ScriptTypePubKey,
+		"51" + key + key + "52ae": ScriptTypeMultisig,
+		"53" + key + key + "52ae": ScriptTypeNonStandard,
+		"6a0568656c6c6f":          ScriptTypeNullData,
+		"5210" + "0000000000000000000000000000000000000000"[:32]: ScriptTypeWitnessUnknown,
+		"0010" + "0000000000000000000000000000000000000000"[:32]: ScriptTypeNonStandard,
+		"": ScriptTypeNonStandard,
+	} {
+		raw, _ := hex.DecodeString(script)
+		assert.Equal(t, scriptType, ClassifyScript(raw), script)
+
+		if scriptType != ScriptTypeWitnessUnknown {
+			_, err := ScriptToAddress(raw, MainNetParams)
+			assert.True(t, errors.Is(err, ErrNoAddress), script)
+		}
+	}
+}
+
+func TestParamsForChain(t *testing.T) {
+	params, err := ParamsForChain("regtest")
+	require.NoError(t, err)
+	assert.Equal(t, "bcrt", params.Bech32HRP)
+
+	_, err = ParamsForChain("nope")
+	assert.Error(t, err)
+}
+
+func TestDecodeScript(t *testing.T) {
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":{"asm":"1 02aa 02bb 2 OP_CHECKMULTISIG","desc":"raw(5121...)#abcd","type":"nonstandard","p2sh":"3P14159f73E4gFr7JterCCQh9QjiTjiZrG","segwit":{"asm":"0 1863","hex":"00201863","type":"witness_v0_scripthash","address":"bc1q...","p2sh-segwit":"3Ab..."}},"error":null,"id":1}`))
+	})
+	defer done()
+
+	script, err := b.DecodeScript("6a0568656c6c6f")
+	require.NoError(t, err)
+	assert.Equal(t, ScriptTypeNonStandard, script.Type)
+	assert.Equal(t, ScriptTypeNullData, script.LocalType)
+	assert.Equal(t, "3P14159f73E4gFr7JterCCQh9QjiTjiZrG", script.P2SH)
+	require.NotNil(t, script.Segwit)
+	assert.Equal(t, ScriptTypeWitnessV0ScriptHash, script.Segwit.Type)
+	assert.Equal(t, "3Ab...", script.Segwit.P2SHSegwit)
+}
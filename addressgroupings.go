@@ -0,0 +1,88 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AddressGroupingEntry is an address of the wallet in an address grouping, with its balance.
+type AddressGroupingEntry struct {
+	Address  string
+	Amount   float64 // BTC
+	Satoshis uint64  // Amount without going through a float64
+	Label    string  // empty for addresses without a label
+}
+
+// UnmarshalJSON decodes the [address, amount, label] arrays returned by listaddressgroupings, where the label
+// is only present for addresses that have one.
+func (e *AddressGroupingEntry) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if len(fields) < 2 {
+		return fmt.Errorf("address grouping entry has %d fields, expected at least 2", len(fields))
+	}
+
+	if err := json.Unmarshal(fields[0], &e.Address); err != nil {
+		return fmt.Errorf("invalid address in address grouping: %w", err)
+	}
+
+	var amount json.Number
+	if err := json.Unmarshal(fields[1], &amount); err != nil {
+		return fmt.Errorf("invalid amount of %s in address grouping: %w", e.Address, err)
+	}
+	sats, err := NumberSatoshis(amount)
+	if err != nil {
+		return err
+	}
+	if sats < 0 {
+		return fmt.Errorf("negative amount %s of %s in address grouping", amount, e.Address)
+	}
+	e.Satoshis = uint64(sats)
+	e.Amount, _ = amount.Float64()
+
+	if len(fields) > 2 {
+		if err := json.Unmarshal(fields[2], &e.Label); err != nil {
+			return fmt.Errorf("invalid label of %s in address grouping: %w", e.Address, err)
+		}
+	}
+
+	return nil
+}
+
+// AddressGrouping is a group of addresses of the wallet whose common ownership became public, by being
+// spent together as inputs or by receiving change.
+type AddressGrouping []AddressGroupingEntry
+
+// ListAddressGroupings returns the groups of addresses of the wallet whose common ownership is public.
+func (b *Bitcoind) ListAddressGroupings() ([]AddressGrouping, error) {
+	var groupings []AddressGrouping
+	if err := b.uncachedCall("listaddressgroupings", nil, &groupings); err != nil {
+		return nil, err
+	}
+
+	return groupings, nil
+}
+
+// AddressBalance is the balance and label of an address, and the index of its address grouping.
+type AddressBalance struct {
+	Amount   float64 // BTC
+	Satoshis uint64
+	Label    string
+	Group    int
+}
+
+// FlattenAddressGroupings maps the addresses of groupings to their balance, label and grouping,, e.g. to
+// reconcile balances per address against a ledger.
+func FlattenAddressGroupings(groupings []AddressGrouping) map[string]AddressBalance {
+	balances := make(map[string]AddressBalance)
+	for i, grouping := range groupings {
+		for _, e := range grouping {
+			balances[e.Address] = AddressBalance{Amount: e.Amount, Satoshis: e.Satoshis, Label: e.Label, Group: i}
+		}
+	}
+
+	return balances
+}
@@ -0,0 +1,39 @@
+package bitcoin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAddressGroupings(t *testing.T) {
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":[` +
+			`[["bcrt1qaaa",0.5,"deposits"],["bcrt1qchange",0.10000001]],` +
+			`[["bcrt1qbbb",0.00000000,""]]` +
+			`],"error":null,"id":1}`))
+	})
+	defer closeServer()
+
+	groupings, err := b.ListAddressGroupings()
+	require.NoError(t, err)
+	require.Len(t, groupings, 2)
+	assert.Equal(t, AddressGroupingEntry{Address: "bcrt1qaaa", Amount: 0.5, Satoshis: 50000000, Label: "deposits"}, groupings[0][0])
+	assert.Equal(t, AddressGroupingEntry{Address: "bcrt1qchange", Amount: 0.10000001, Satoshis: 10000001}, groupings[0][1])
+
+	balances := FlattenAddressGroupings(groupings)
+	assert.Equal(t, map[string]AddressBalance{
+		"bcrt1qaaa":    {Amount: 0.5, Satoshis: 50000000, Label: "deposits", Group: 0},
+		"bcrt1qchange": {Amount: 0.10000001, Satoshis: 10000001, Group: 0},
+		"bcrt1qbbb":    {Group: 1},
+	}, balances)
+}
+
+func TestAddressGroupingEntryInvalid(t *testing.T) {
+	var e AddressGroupingEntry
+	assert.Error(t, e.UnmarshalJSON([]byte(`["bcrt1qaaa"]`)))
+	assert.Error(t, e.UnmarshalJSON([]byte(`["bcrt1qaaa",0.123456789]`)))
+	assert.Error(t, e.UnmarshalJSON([]byte(`["bcrt1qaaa",-1]`)))
+}
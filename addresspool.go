@@ -0,0 +1,222 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const addressPoolBatchSize = 100
+
+// IndexStore persists the next derivation index of address pools by name.
+type IndexStore interface {
+	SaveIndex(name string, next uint32) error
+	// LoadIndex returns the index stored under name, or false if there is none.
+	LoadIndex(name string) (uint32, bool, error)
+}
+
+// MemoryIndexStore is an IndexStore that keeps indexes in memory, which is mostly useful for tests.
+type MemoryIndexStore struct {
+	mu      sync.Mutex
+	indexes map[string]uint32
+}
+
+// NewMemoryIndexStore returns an empty MemoryIndexStore.
+func NewMemoryIndexStore() *MemoryIndexStore {
+	return &MemoryIndexStore{indexes: make(map[string]uint32)}
+}
+
+func (s *MemoryIndexStore) SaveIndex(name string, next uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.indexes[name] = next
+	return nil
+}
+
+func (s *MemoryIndexStore) LoadIndex(name string) (uint32, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, found := s.indexes[name]
+	return next, found, nil
+}
+
+// FileIndexStore is an IndexStore that keeps each index as a JSON file in a directory, replaced atomically
+// like the files of a FileCursorStore.
+type FileIndexStore struct {
+	dir string
+}
+
+type storedIndex struct {
+	Next uint32 `json:"next"`
+}
+
+// NewFileIndexStore returns a FileIndexStore using the given directory, creating it if needed.
+func NewFileIndexStore(dir string) (*FileIndexStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	return &FileIndexStore{dir: dir}, nil
+}
+
+func (s *FileIndexStore) SaveIndex(name string, next uint32) error {
+	data, err := json.Marshal(storedIndex{Next: next})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.dir, name+".index.json", data)
+}
+
+func (s *FileIndexStore) LoadIndex(name string) (uint32, bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name+".index.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read index file: %w", err)
+	}
+
+	var idx storedIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return 0, false, fmt.Errorf("failed to decode index file: %w", err)
+	}
+
+	return idx.Next, true, nil
+}
+
+// AddressPool hands out receive addresses derived from a ranged descriptor, one at a time and never the same
+// index twice, even across restarts or when shared between goroutines. The next index is persisted in an
+// IndexStore before an address is returned.
+type AddressPool struct {
+	// Watcher, if set, tracks the usage of the pool's addresses. Every derived address is watched as soon as
+	// it is derived, so payments to handed out addresses as well as to the ones about to be handed out are
+	// seen, and candidates the watcher has seen paid are skipped, for example because they were handed out
+	// by an earlier deployment that did not persist its index. It must be set before the first call to Next.
+	Watcher *UTXOMirror
+
+	// IsUsed, if set, is consulted for every candidate address and addresses reported as used are skipped.
+	IsUsed func(address string) (bool, error)
+
+	b          *Bitcoind
+	descriptor string
+	store      IndexStore
+	name       string
+
+	mu     sync.Mutex
+	next   uint32
+	buffer []string // derived addresses starting at index next
+}
+
+// NewAddressPool returns a pool deriving addresses from the given ranged descriptor, resuming at the index
+// stored under name in store.
+func NewAddressPool(b *Bitcoind, descriptor string, store IndexStore, name string) (*AddressPool, error) {
+	info, err := b.GetDescriptorInfo(descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsRange {
+		return nil, fmt.Errorf("descriptor %s is not ranged", info.Descriptor)
+	}
+
+	next, _, err := store.LoadIndex(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddressPool{
+		b:          b,
+		descriptor: info.Descriptor,
+		store:      store,
+		name:       name,
+		next:       next,
+	}, nil
+}
+
+// Next returns the next unused address and its derivation index.
+func (p *AddressPool) Next() (string, uint32, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if len(p.buffer) == 0 {
+			if err := p.derive(); err != nil {
+				return "", 0, err
+			}
+		}
+
+		address, index := p.buffer[0], p.next
+
+		// Persist before handing out the address so a crash never leads to it being issued twice
+		if err := p.store.SaveIndex(p.name, index+1); err != nil {
+			return "", 0, err
+		}
+		p.buffer = p.buffer[1:]
+		p.next++
+
+		used, err := p.used(address)
+		if err != nil {
+			return "", 0, err
+		}
+		if used {
+			continue
+		}
+
+		return address, index, nil
+	}
+}
+
+// derive fills the buffer with the next batch of addresses and adds them to the watcher.
+func (p *AddressPool) derive() error {
+	addresses, err := p.b.DeriveAddresses(p.descriptor, p.next, p.next+addressPoolBatchSize-1)
+	if err != nil {
+		return err
+	}
+
+	if p.Watcher != nil {
+		for _, address := range addresses {
+			script, err := AddressToScript(address, nil)
+			if err != nil {
+				return err
+			}
+			p.Watcher.Watch(hex.EncodeToString(script))
+		}
+	}
+
+	p.buffer = addresses
+	return nil
+}
+
+// used reports whether the watcher or IsUsed consider address used.
+func (p *AddressPool) used(address string) (bool, error) {
+	if p.Watcher != nil {
+		script, err := AddressToScript(address, nil)
+		if err != nil {
+			return false, err
+		}
+		if p.Watcher.Used(hex.EncodeToString(script)) {
+			return true, nil
+		}
+	}
+
+	if p.IsUsed != nil {
+		return p.IsUsed(address)
+	}
+
+	return false, nil
+}
+
+// NextIndex returns the derivation index of the next address Next will consider.
+func (p *AddressPool) NextIndex() uint32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.next
+}
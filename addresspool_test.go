@@ -0,0 +1,201 @@
+package bitcoin
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPoolScript returns the P2WPKH script of the address at the given index of the test descriptor.
+func testPoolScript(index uint32) []byte {
+	script := make([]byte, 22)
+	script[1] = 20
+	binary.BigEndian.PutUint32(script[18:], index+1)
+	return script
+}
+
+func testPoolAddress(index uint32) string {
+	address, _ := ScriptToAddress(testPoolScript(index), RegTestParams)
+	return address
+}
+
+// addressPoolNode derives the test addresses, recording the ranges it was asked for.
+type addressPoolNode struct {
+	mu     sync.Mutex
+	ranges [][2]uint32
+}
+
+func (n *addressPoolNode) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	params := req.Params.([]interface{})
+
+	switch req.Method {
+	case "getdescriptorinfo":
+		fmt.Fprintf(w, `{"result":{"descriptor":%q,"isrange":true},"error":null,"id":1}`, params[0])
+	case "deriveaddresses":
+		r := params[1].([]interface{})
+		begin, end := uint32(r[0].(float64)), uint32(r[1].(float64))
+
+		n.mu.Lock()
+		n.ranges = append(n.ranges, [2]uint32{begin, end})
+		n.mu.Unlock()
+
+		var addresses []string
+		for i := begin; i <= end; i++ {
+			addresses = append(addresses, testPoolAddress(i))
+		}
+		result, _ := json.Marshal(addresses)
+		fmt.Fprintf(w, `{"result":%s,"error":null,"id":1}`, result)
+	}
+}
+
+func (n *addressPoolNode) derived() [][2]uint32 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([][2]uint32(nil), n.ranges...)
+}
+
+func TestAddressPoolRefill(t *testing.T) {
+	node := &addressPoolNode{}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	pool, err := NewAddressPool(b, "wpkh(tpub/0/*)", NewMemoryIndexStore(), "receive")
+	require.NoError(t, err)
+
+	for i := uint32(0); i < 250; i++ {
+		address, index, err := pool.Next()
+		require.NoError(t, err)
+		assert.Equal(t, i, index)
+		assert.Equal(t, testPoolAddress(i), address)
+	}
+
+	assert.Equal(t, [][2]uint32{{0, 99}, {100, 199}, {200, 299}}, node.derived())
+	assert.Equal(t, uint32(250), pool.NextIndex())
+}
+
+func TestAddressPoolSkipsUsed(t *testing.T) {
+	b, done := newTestNode(t, (&addressPoolNode{}).handle)
+	defer done()
+
+	mirror := NewUTXOMirror(6)
+	pool, err := NewAddressPool(b, "wpkh(tpub/0/*)", NewMemoryIndexStore(), "receive")
+	require.NoError(t, err)
+	pool.Watcher = mirror
+	pool.IsUsed = func(address string) (bool, error) {
+		return address == testPoolAddress(4), nil
+	}
+
+	_, index, err := pool.Next()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), index)
+
+	// Derived addresses are watched before they are handed out
+	for i := uint32(0); i < addressPoolBatchSize; i++ {
+		assert.True(t, mirror.Watches(hex.EncodeToString(testPoolScript(i))))
+	}
+
+	// Payments to the next addresses, one of them spent again, make the pool skip them
+	funding := testTx([]Outpoint{{TxID: zeroHash, Vout: 0}}, hex.EncodeToString(testPoolScript(1)), 1000)
+	require.NoError(t, mirror.ApplyMempoolTx(funding))
+	fundingTx, _ := parseTx(funding)
+	spending := testTx([]Outpoint{{TxID: fundingTx.TxID, Vout: 0}}, "51", 900)
+	require.NoError(t, mirror.ApplyMempoolTx(spending))
+	require.NoError(t, mirror.ApplyMempoolTx(testTx([]Outpoint{{TxID: zeroHash, Vout: 1}}, hex.EncodeToString(testPoolScript(2)), 1000)))
+
+	_, index, err = pool.Next()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), index)
+
+	// IsUsed is consulted as well
+	_, index, err = pool.Next()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), index)
+
+	// Handed out addresses stay watched, so payments to them are tracked
+	require.NoError(t, mirror.ApplyMempoolTx(testTx([]Outpoint{{TxID: zeroHash, Vout: 2}}, hex.EncodeToString(testPoolScript(0)), 1000)))
+	assert.True(t, mirror.Used(hex.EncodeToString(testPoolScript(0))))
+}
+
+func TestAddressPoolRestart(t *testing.T) {
+	node := &addressPoolNode{}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	store, err := NewFileIndexStore(t.TempDir())
+	require.NoError(t, err)
+
+	pool, err := NewAddressPool(b, "wpkh(tpub/0/*)", store, "receive")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, _, err := pool.Next()
+		require.NoError(t, err)
+	}
+
+	// A pool created with the same store resumes after the addresses handed out before
+	restarted, err := NewAddressPool(b, "wpkh(tpub/0/*)", store, "receive")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), restarted.NextIndex())
+
+	address, index, err := restarted.Next()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), index)
+	assert.Equal(t, testPoolAddress(3), address)
+	assert.Equal(t, [2]uint32{3, 102}, node.derived()[1])
+
+	// Pools of other names are independent
+	other, err := NewAddressPool(b, "wpkh(tpub/1/*)", store, "change")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), other.NextIndex())
+}
+
+func TestAddressPoolConcurrentNext(t *testing.T) {
+	b, done := newTestNode(t, (&addressPoolNode{}).handle)
+	defer done()
+
+	store := NewMemoryIndexStore()
+	pool, err := NewAddressPool(b, "wpkh(tpub/0/*)", store, "receive")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	seen := make(map[uint32]string)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 15; i++ {
+				address, index, err := pool.Next()
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				mu.Lock()
+				_, dup := seen[index]
+				assert.False(t, dup, "index %d handed out twice", index)
+				seen[index] = address
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, seen, 300)
+	for index, address := range seen {
+		assert.Equal(t, testPoolAddress(index), address)
+	}
+
+	next, found, err := store.LoadIndex("receive")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, uint32(300), next)
+}
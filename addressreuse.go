@@ -0,0 +1,135 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrAddressReused is returned by AddressReuseChecker.Check for used addresses under ReuseReject.
+var ErrAddressReused = errors.New("address already used")
+
+// ReusePolicy is what an AddressReuseChecker does about an address that was used before.
+type ReusePolicy int
+
+const (
+	ReuseAllow  ReusePolicy = iota // only report the reuse to OnReuse
+	ReuseWarn                      // also log a warning
+	ReuseReject                    // also fail the check with ErrAddressReused
+)
+
+// AddressUsage is what an AddressReuseChecker found out about an address.
+type AddressUsage struct {
+	Address  string
+	Used     bool
+	IsMine   bool    // the wallet has the keys of the address, or watches it
+	Received float64 // BTC received according to the wallet, 0 for addresses that are not the wallet's
+	InIndex  bool    // the local index has seen the address
+}
+
+// AddressReuseChecker checks whether a prospective receive address was used before, through the wallet,
+// which knows what its own addresses received, and an optional local index for addresses it does not
+// have. Nodes without a wallet are only checked against the index.
+type AddressReuseChecker struct {
+	Policy  ReusePolicy
+	MinConf int // confirmations for getreceivedbyaddress, 0 counts payments still in the mempool
+	// LocalIndex, if set, reports whether an address was seen on chain, e.g. from an AddressClusterer:
+	//   func(address string) (bool, error) { _, found, err := clusterer.ClusterID(address); return found, err }
+	LocalIndex func(address string) (bool, error)
+	// OnReuse is called for every used address, whatever the policy.
+	OnReuse func(AddressUsage)
+
+	b *Bitcoind
+}
+
+// NewAddressReuseChecker returns a checker applying policy, counting payments still in the mempool.
+func NewAddressReuseChecker(b *Bitcoind, policy ReusePolicy) *AddressReuseChecker {
+	return &AddressReuseChecker{
+		Policy: policy,
+		b:      b,
+	}
+}
+
+// Usage looks up whether address was used, without applying the policy.
+func (c *AddressReuseChecker) Usage(address string) (AddressUsage, error) {
+	usage := AddressUsage{Address: address}
+
+	hasWallet, err := c.walletUsage(&usage)
+	if err != nil {
+		return usage, err
+	}
+
+	if c.LocalIndex != nil {
+		if usage.InIndex, err = c.LocalIndex(address); err != nil {
+			return usage, err
+		}
+	} else if !hasWallet {
+		return usage, fmt.Errorf("cannot check %s for reuse without a wallet or a local index", address)
+	}
+
+	usage.Used = usage.Received > 0 || usage.InIndex
+	return usage, nil
+}
+
+// walletUsage fills in what the wallet knows about an address, and returns false if the node has no wallet.
+func (c *AddressReuseChecker) walletUsage(usage *AddressUsage) (bool, error) {
+	r, err := c.b.client.call("getaddressinfo", []interface{}{usage.Address})
+	if err != nil {
+		return false, err
+	}
+
+	if r.Err != nil {
+		// Nodes built without a wallet, or without a wallet loaded
+		if code, _ := errorCode(r.Err); code == RPCMethodNotFound || code == RPCWalletNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w (getaddressinfo)", responseError(r.Err))
+	}
+
+	var info struct {
+		IsMine      bool `json:"ismine"`
+		IsWatchOnly bool `json:"iswatchonly"`
+	}
+	if err := json.Unmarshal(r.Result, &info); err != nil {
+		return false, err
+	}
+
+	usage.IsMine = info.IsMine || info.IsWatchOnly
+	if !usage.IsMine {
+		return true, nil
+	}
+
+	return true, c.b.uncachedCall("getreceivedbyaddress", []interface{}{usage.Address, c.MinConf}, &usage.Received)
+}
+
+// Check looks up whether address was used and applies the policy to used addresses.
+func (c *AddressReuseChecker) Check(address string) (AddressUsage, error) {
+	usage, err := c.Usage(address)
+	if err != nil || !usage.Used {
+		return usage, err
+	}
+
+	if c.OnReuse != nil {
+		c.OnReuse(usage)
+	}
+
+	switch c.Policy {
+	case ReuseWarn:
+		c.b.client.logger.Warnf("Address %s was already used, received %v BTC", address, usage.Received)
+	case ReuseReject:
+		return usage, fmt.Errorf("%w: %s", ErrAddressReused, address)
+	}
+
+	return usage, nil
+}
+
+// IsUsed reports whether address was used, applying the policy, so that an AddressPool with IsUsed set
+// to it skips used addresses. ErrAddressReused is not returned, the pool skips the address instead.
+func (c *AddressReuseChecker) IsUsed(address string) (bool, error) {
+	usage, err := c.Check(address)
+	if errors.Is(err, ErrAddressReused) {
+		return true, nil
+	}
+
+	return usage.Used, err
+}
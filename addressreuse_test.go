@@ -0,0 +1,82 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressReuseChecker(t *testing.T) {
+	wallet := true
+
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		address := req.Params.([]interface{})[0]
+
+		switch {
+		case !wallet:
+			fmt.Fprint(w, `{"result":null,"error":{"code":-18,"message":"No wallet is loaded."},"id":1}`)
+		case req.Method == "getaddressinfo":
+			fmt.Fprintf(w, `{"result":{"address":%q,"ismine":%t},"error":null,"id":1}`, address, address != "external")
+		case address == "used":
+			fmt.Fprint(w, `{"result":0.5,"error":null,"id":1}`)
+		default:
+			fmt.Fprint(w, `{"result":0,"error":null,"id":1}`)
+		}
+	})
+	defer closeServer()
+
+	var reused []AddressUsage
+	checker := NewAddressReuseChecker(b, ReuseReject)
+	checker.OnReuse = func(u AddressUsage) { reused = append(reused, u) }
+
+	usage, err := checker.Check("fresh")
+	require.NoError(t, err)
+	assert.Equal(t, AddressUsage{Address: "fresh", IsMine: true}, usage)
+
+	usage, err = checker.Check("used")
+	assert.ErrorIs(t, err, ErrAddressReused)
+	assert.Equal(t, AddressUsage{Address: "used", Used: true, IsMine: true, Received: 0.5}, usage)
+
+	used, err := checker.IsUsed("used")
+	require.NoError(t, err)
+	assert.True(t, used)
+
+	// Unknown to the wallet, only the index can tell
+	usage, err = checker.Check("external")
+	require.NoError(t, err)
+	assert.False(t, usage.Used)
+
+	checker.Policy = ReuseWarn
+	checker.LocalIndex = func(address string) (bool, error) { return address == "external", nil }
+	usage, err = checker.Check("external")
+	require.NoError(t, err)
+	assert.True(t, usage.Used && usage.InIndex && !usage.IsMine)
+
+	assert.Len(t, reused, 3)
+
+	wallet = false
+	used, err = checker.IsUsed("external")
+	require.NoError(t, err)
+	assert.True(t, used)
+
+	checker.LocalIndex = nil
+	_, err = checker.Check("external")
+	assert.Error(t, err)
+}
+
+func TestAddressReuseCheckerStringError(t *testing.T) {
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		// Proxies in front of the node have been seen to send plain string errors
+		fmt.Fprint(w, `{"result":null,"error":"upstream unavailable","id":1}`)
+	})
+	defer closeServer()
+
+	_, err := NewAddressReuseChecker(b, ReuseReject).Check("fresh")
+	assert.EqualError(t, err, "ERROR: upstream unavailable (getaddressinfo)")
+}
@@ -0,0 +1,158 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AddedNodeAddress is an address of an added node and the direction it is connected in, if at all.
+type AddedNodeAddress struct {
+	Address   string `json:"address"`
+	Connected string `json:"connected"` // inbound or outbound
+}
+
+// AddedNode is a peer added with addnode, as returned by getaddednodeinfo.
+type AddedNode struct {
+	AddedNode string             `json:"addednode"` // as given to addnode
+	Connected bool               `json:"connected"`
+	Addresses []AddedNodeAddress `json:"addresses"` // only of connected nodes
+}
+
+// GetAddedNodeInfo returns the peers added with addnode, not those of -addnode or -connect, and whether
+// they are connected.
+func (b *Bitcoind) GetAddedNodeInfo() ([]AddedNode, error) {
+	// Uncached, the result changes as peers connect and disconnect
+	r, err := b.client.call("getaddednodeinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Err != nil {
+		return nil, responseError(r.Err)
+	}
+
+	var nodes []AddedNode
+	if err := json.Unmarshal(r.Result, &nodes); err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// AddNode adds node (host:port) to the peers the node keeps connected to, removes it, or connects to it once,
+// with command "add", "remove" or "onetry". Added peers are not kept across restarts of the node unless they
+// are in its configuration.
+func (b *Bitcoind) AddNode(node string, command string) error {
+	r, err := b.client.call("addnode", []interface{}{node, command})
+	if err != nil {
+		return err
+	}
+
+	if r.Err != nil {
+		return responseError(r.Err)
+	}
+
+	return nil
+}
+
+// AnchorStatus is the state of an anchor peer after AnchorPeers.Ensure.
+type AnchorStatus struct {
+	Peer      string
+	Readded   bool // it was missing from the added peers and added again
+	Connected bool
+	Err       error // of adding or connecting it
+}
+
+// AnchorPeers keeps a set of peers added to the node, e.g. the nodes of an exchange or partner the node is
+// intentionally peered with. Added peers are forgotten when the node restarts or when someone removes them;
+// Ensure adds them again and asks the node to connect to those it is not connected to.
+type AnchorPeers struct {
+	Peers []string // host:port, as given to addnode
+	// OnReadded is called for every peer that had to be added again.
+	OnReadded func(peer string)
+
+	b  *Bitcoind
+	mu sync.Mutex
+}
+
+// NewAnchorPeers returns anchors for peers, which Ensure has not added yet.
+func NewAnchorPeers(b *Bitcoind, peers ...string) *AnchorPeers {
+	return &AnchorPeers{Peers: peers, b: b}
+}
+
+// Ensure adds the anchor peers missing from the added peers of the node, and connects once to the added
+// ones that are not connected rather than waiting for the node to retry them. It returns the status of every
+// anchor in the order of Peers, failing only if the added peers cannot be listed.
+func (a *AnchorPeers) Ensure() ([]AnchorStatus, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	nodes, err := a.b.GetAddedNodeInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	added := make(map[string]AddedNode, len(nodes))
+	for _, n := range nodes {
+		added[strings.ToLower(n.AddedNode)] = n
+	}
+
+	statuses := make([]AnchorStatus, len(a.Peers))
+	for i, peer := range a.Peers {
+		s := AnchorStatus{Peer: peer}
+
+		node, ok := added[strings.ToLower(peer)]
+		switch {
+		case !ok:
+			s.Err = a.b.AddNode(peer, "add")
+			// Added in between by someone else
+			if HasErrorCode(s.Err, RPCClientNodeAlreadyAdded) {
+				s.Err = nil
+			}
+			if s.Err == nil {
+				s.Readded = true
+				a.b.client.logger.Infof("AnchorPeers: added %s again", peer)
+				if a.OnReadded != nil {
+					a.OnReadded(peer)
+				}
+			}
+		case node.Connected:
+			s.Connected = true
+		default:
+			s.Err = a.b.AddNode(peer, "onetry")
+		}
+
+		if s.Err != nil {
+			a.b.client.logger.Warnf("AnchorPeers: failed to connect to %s: %v", peer, s.Err)
+		}
+		statuses[i] = s
+	}
+
+	return statuses, nil
+}
+
+// Run calls Ensure right away and then every interval until the context is done. The node retries added
+// peers by itself, so interval is mostly how quickly anchors are added again after a restart of the node.
+func (a *AnchorPeers) Run(ctx context.Context, interval time.Duration) {
+	if _, err := a.Ensure(); err != nil {
+		a.b.client.logger.Warnf("AnchorPeers: failed to list added peers: %v", err)
+	}
+
+	ticker := a.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+
+		if _, err := a.Ensure(); err != nil {
+			a.b.client.logger.Warnf("AnchorPeers: failed to list added peers: %v", err)
+		}
+	}
+}
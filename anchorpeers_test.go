@@ -0,0 +1,60 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnchorPeersEnsure(t *testing.T) {
+	var mu sync.Mutex
+	var addnode []string
+
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "getaddednodeinfo":
+			_, _ = w.Write([]byte(`{"result":[
+				{"addednode":"10.0.0.1:8333","connected":true,"addresses":[{"address":"10.0.0.1:8333","connected":"outbound"}]},
+				{"addednode":"10.0.0.2:8333","connected":false,"addresses":[]}
+			],"error":null,"id":1}`))
+		case "addnode":
+			mu.Lock()
+			addnode = append(addnode, fmt.Sprintf("%v %v", req.Params[0], req.Params[1]))
+			mu.Unlock()
+			if req.Params[0] == "10.0.0.4:8333" {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"result":null,"error":{"code":-23,"message":"Error: Node already added"},"id":1}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"result":null,"error":null,"id":1}`))
+		}
+	}, WithOptionalLogger(&countingLogger{}))
+	defer closeServer()
+
+	anchors := NewAnchorPeers(b, "10.0.0.1:8333", "10.0.0.2:8333", "10.0.0.3:8333", "10.0.0.4:8333")
+	var readded []string
+	anchors.OnReadded = func(peer string) { readded = append(readded, peer) }
+
+	statuses, err := anchors.Ensure()
+	require.NoError(t, err)
+
+	assert.Equal(t, []AnchorStatus{
+		{Peer: "10.0.0.1:8333", Connected: true},
+		{Peer: "10.0.0.2:8333"},
+		{Peer: "10.0.0.3:8333", Readded: true},
+		{Peer: "10.0.0.4:8333", Readded: true},
+	}, statuses)
+	assert.Equal(t, []string{"10.0.0.2:8333 onetry", "10.0.0.3:8333 add", "10.0.0.4:8333 add"}, addnode)
+	assert.Equal(t, []string{"10.0.0.3:8333", "10.0.0.4:8333"}, readded)
+}
@@ -0,0 +1,113 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChainBackend is the read and broadcast surface shared by the node's JSON-RPC and REST interfaces and
+// Esplora servers, so that applications can be written against it and switch backends by configuration.
+// Operations a backend cannot serve return ErrNotSupported.
+type ChainBackend interface {
+	// GetBestBlockHash returns the hash of the tip of the active chain.
+	GetBestBlockHash(ctx context.Context) (string, error)
+	// GetBlockHash returns the hash of the block at the given height of the active chain.
+	GetBlockHash(ctx context.Context, height int) (string, error)
+	// GetRawBlock returns the serialized block with the given hash.
+	GetRawBlock(ctx context.Context, hash string) ([]byte, error)
+	// GetTransactionHex returns the serialized transaction with the given txid.
+	GetTransactionHex(ctx context.Context, txid string) (string, error)
+	// GetMempoolTxIDs returns the txids of all transactions in the mempool.
+	GetMempoolTxIDs(ctx context.Context) ([]string, error)
+	// EstimateFeeRate returns the fee rate in sat/vB for confirmation within confTarget blocks.
+	EstimateFeeRate(ctx context.Context, confTarget int) (float64, error)
+	// Broadcast sends a signed transaction and returns its txid.
+	Broadcast(ctx context.Context, txHex string) (string, error)
+}
+
+// Backends implementing ChainBackend
+var (
+	_ ChainBackend = (*nodeBackend)(nil)
+	_ ChainBackend = (*RestClient)(nil)
+	_ ChainBackend = (*EsploraClient)(nil)
+)
+
+// nodeBackend is the ChainBackend of the JSON-RPC interface.
+type nodeBackend struct {
+	b *Bitcoind
+}
+
+// Backend returns b as a ChainBackend.
+func (b *Bitcoind) Backend() ChainBackend {
+	return &nodeBackend{b: b}
+}
+
+func (n *nodeBackend) GetBestBlockHash(ctx context.Context) (string, error) {
+	return n.b.GetBestBlockHash()
+}
+
+func (n *nodeBackend) GetBlockHash(ctx context.Context, height int) (string, error) {
+	return n.b.GetBlockHash(height)
+}
+
+func (n *nodeBackend) GetRawBlock(ctx context.Context, hash string) ([]byte, error) {
+	return n.b.GetRawBlock(hash)
+}
+
+func (n *nodeBackend) GetTransactionHex(ctx context.Context, txid string) (string, error) {
+	result, err := n.b.RawCall("getrawtransaction", n.b.rawTransactionParams(txid, 0)...)
+	if err != nil {
+		return "", err
+	}
+
+	var txHex string
+	err = json.Unmarshal(result, &txHex)
+	return txHex, err
+}
+
+func (n *nodeBackend) GetMempoolTxIDs(ctx context.Context) ([]string, error) {
+	raw, err := n.b.GetRawMempool(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var txids []string
+	err = json.Unmarshal(raw, &txids)
+	return txids, err
+}
+
+func (n *nodeBackend) EstimateFeeRate(ctx context.Context, confTarget int) (float64, error) {
+	estimate, err := n.b.EstimateSmartFee(confTarget, "")
+	if err != nil {
+		return 0, err
+	}
+
+	if estimate.FeeRate == 0 {
+		return 0, fmt.Errorf("no fee estimate for %d blocks: %s", confTarget, strings.Join(estimate.Errors, ", "))
+	}
+
+	return estimate.FeeRate * 1e5, nil // BTC/kvB to sat/vB
+}
+
+func (n *nodeBackend) Broadcast(ctx context.Context, txHex string) (string, error) {
+	if _, err := hex.DecodeString(txHex); err != nil {
+		return "", fmt.Errorf("invalid transaction hex: %w", err)
+	}
+
+	result, err := n.b.RawCall("sendrawtransaction", txHex)
+	if err != nil {
+		return "", err
+	}
+
+	var txid string
+	err = json.Unmarshal(result, &txid)
+	return txid, err
+}
+
+// AddressHistory is not supported by the node, which keeps no address index.
+func (n *nodeBackend) AddressHistory(ctx context.Context, address string) ([]AddressTx, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,274 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBackendTip  = "00000000000000000001aaaa"
+	testBackendTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33c"
+)
+
+// testBackendNode serves the JSON-RPC and REST interfaces of a node.
+func testBackendNode(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/rest/chaininfo.json":
+		_, _ = io.WriteString(w, `{"chain":"main","bestblockhash":"`+testBackendTip+`"}`)
+		return
+	case "/rest/blockhashbyheight/7.hex":
+		_, _ = io.WriteString(w, testBackendTip+"\n")
+		return
+	case "/rest/block/" + testBackendTip + ".bin":
+		_, _ = w.Write([]byte{1, 2, 3})
+		return
+	case "/rest/tx/" + testBackendTxID + ".bin":
+		_, _ = w.Write([]byte{1, 0})
+		return
+	case "/rest/mempool/contents.json":
+		_, _ = io.WriteString(w, `{"`+testBackendTxID+`":{"vsize":100}}`)
+		return
+	case "/rest/tx/unknown.bin":
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "unknown: No such mempool transaction. Use -txindex\r\n")
+		return
+	}
+
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	reply := func(result string) {
+		_, _ = io.WriteString(w, `{"result":`+result+`,"error":null,"id":1}`)
+	}
+
+	switch req.Method {
+	case "getbestblockhash", "getblockhash":
+		reply(`"` + testBackendTip + `"`)
+	case "getblock":
+		reply(`"010203"`)
+	case "getrawtransaction":
+		reply(`"0100"`)
+	case "getrawmempool":
+		reply(`["` + testBackendTxID + `"]`)
+	case "estimatesmartfee":
+		reply(`{"feerate":0.0002,"blocks":6}`)
+	case "sendrawtransaction":
+		reply(`"` + testBackendTxID + `"`)
+	}
+}
+
+func TestChainBackends(t *testing.T) {
+	b, done := newTestNode(t, testBackendNode)
+	defer done()
+
+	esplora := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /blocks/tip/hash", "GET /block-height/7":
+			_, _ = io.WriteString(w, testBackendTip)
+		case "GET /block/" + testBackendTip + "/raw":
+			_, _ = w.Write([]byte{1, 2, 3})
+		case "GET /tx/" + testBackendTxID + "/hex":
+			_, _ = io.WriteString(w, "0100")
+		case "GET /mempool/txids":
+			_, _ = io.WriteString(w, `["`+testBackendTxID+`"]`)
+		case "GET /fee-estimates":
+			_, _ = io.WriteString(w, `{"1":50.5,"3":30,"6":20,"144":1.2}`)
+		case "POST /tx":
+			_, _ = io.WriteString(w, testBackendTxID)
+		}
+	}))
+	defer esplora.Close()
+
+	backends := map[string]ChainBackend{
+		"rpc":     b.Backend(),
+		"rest":    b.Rest(),
+		"esplora": NewEsploraClient(esplora.URL),
+	}
+
+	ctx := context.Background()
+
+	for name, backend := range backends {
+		tip, err := backend.GetBestBlockHash(ctx)
+		require.NoError(t, err, name)
+		assert.Equal(t, testBackendTip, tip, name)
+
+		hash, err := backend.GetBlockHash(ctx, 7)
+		require.NoError(t, err, name)
+		assert.Equal(t, testBackendTip, hash, name)
+
+		block, err := backend.GetRawBlock(ctx, testBackendTip)
+		require.NoError(t, err, name)
+		assert.Equal(t, []byte{1, 2, 3}, block, name)
+
+		txHex, err := backend.GetTransactionHex(ctx, testBackendTxID)
+		require.NoError(t, err, name)
+		assert.Equal(t, "0100", txHex, name)
+
+		txids, err := backend.GetMempoolTxIDs(ctx)
+		require.NoError(t, err, name)
+		assert.Equal(t, []string{testBackendTxID}, txids, name)
+
+		rate, err := backend.EstimateFeeRate(ctx, 6)
+		txid, broadcastErr := backend.Broadcast(ctx, "0100")
+		if name == "rest" {
+			assert.ErrorIs(t, err, ErrNotSupported)
+			assert.ErrorIs(t, broadcastErr, ErrNotSupported)
+			continue
+		}
+		require.NoError(t, err, name)
+		assert.InDelta(t, 20, rate, 1e-9, name)
+		require.NoError(t, broadcastErr, name)
+		assert.Equal(t, testBackendTxID, txid, name)
+	}
+
+	_, err := b.Rest().GetTransactionHex(ctx, "unknown")
+	assert.EqualError(t, err, "ERROR: code 404: unknown: No such mempool transaction. Use -txindex")
+
+	rate, err := backends["esplora"].EstimateFeeRate(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, rate)
+}
+
+func TestNewRestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(testBackendNode))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	tip, err := NewRestClient(u.Hostname(), port, false).GetBestBlockHash(context.Background())
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(tip, "0000"))
+}
+
+func TestRestClientGetHeaders(t *testing.T) {
+	// A chain of headers, each committing to the one before
+	var chain [][]byte
+	index := make(map[string]int)
+	prev := make([]byte, 32)
+	for i := 0; i < 4500; i++ {
+		header := make([]byte, 80)
+		binary.LittleEndian.PutUint32(header[0:4], 0x20000000)
+		copy(header[4:36], prev)
+		binary.LittleEndian.PutUint32(header[68:72], uint32(1600000000+i))
+		binary.LittleEndian.PutUint32(header[76:80], uint32(i))
+
+		index[hashString(header)] = i
+		chain = append(chain, header)
+		prev = cryptolib.Sha256d(header)
+	}
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+
+		start, found := index[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/headers/"), ".bin")]
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		if !found || count > 2000 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		for i := start; i < start+count && i < len(chain); i++ {
+			_, _ = w.Write(chain[i])
+		}
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	rest := NewRestClient(u.Hostname(), port, false)
+
+	genesis := hashString(chain[0])
+	headers, err := rest.GetHeaders(context.Background(), genesis, 4200)
+	require.NoError(t, err)
+	require.Len(t, headers, 4200)
+	assert.Equal(t, genesis, headers[0].Hash)
+	assert.Equal(t, hashString(chain[4199]), headers[4199].Hash)
+	assert.Equal(t, headers[2000].Hash, headers[2001].PrevHash)
+	assert.Equal(t, uint32(1600004199), headers[4199].Time)
+	assert.Equal(t, int32(0x20000000), headers[4199].Version)
+	assert.Equal(t, chain[10], headers[10].Raw)
+	assert.Equal(t, []string{
+		"/rest/headers/" + genesis + ".bin?count=2000",
+		"/rest/headers/" + headers[1999].Hash + ".bin?count=2000",
+		"/rest/headers/" + headers[3998].Hash + ".bin?count=202",
+	}, requests)
+
+	// The chain ends before count headers
+	headers, err = rest.GetHeaders(context.Background(), hashString(chain[4000]), 3000)
+	require.NoError(t, err)
+	assert.Len(t, headers, 500)
+
+	_, err = rest.GetHeaders(context.Background(), "unknown", 10)
+	assert.Error(t, err)
+}
+
+func TestRestClientFormats(t *testing.T) {
+	block := []byte{1, 2, 3, 4}
+	var tenants []string
+
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		tenants = append(tenants, r.Header.Get("X-Tenant"))
+
+		switch r.URL.RequestURI() {
+		case "/rest/block/00ff.bin":
+			_, _ = w.Write(block)
+		case "/rest/block/00ff.hex":
+			_, _ = w.Write([]byte("01020304\n"))
+		case "/rest/block/notxdetails/00ff.json":
+			_, _ = w.Write([]byte(`{"hash":"00ff","tx":[]}`))
+		case "/rest/tx/aa.hex":
+			_, _ = w.Write([]byte("0100\n"))
+		case "/rest/headers/00ff.json?count=1":
+			_, _ = w.Write([]byte(`[{"hash":"00ff"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(r.URL.Path + " not found\r\n"))
+		}
+	}, WithHeader("X-Tenant", "a"))
+	defer closeServer()
+
+	rest := b.Rest()
+	ctx := context.Background()
+
+	data, err := rest.GetBlock(ctx, "00ff", RestHex)
+	require.NoError(t, err)
+	assert.Equal(t, "01020304", string(data))
+
+	data, err = rest.Get(ctx, "block/notxdetails/00ff", RestJSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"hash":"00ff","tx":[]}`, string(data))
+
+	data, err = rest.Get(ctx, "/headers/00ff?count=1", RestJSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"hash":"00ff"}]`, string(data))
+
+	data, err = rest.GetTransaction(ctx, "aa", RestHex)
+	require.NoError(t, err)
+	assert.Equal(t, "0100", string(data))
+
+	body, err := rest.StreamBlock(ctx, "00ff")
+	require.NoError(t, err)
+	data, err = io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, block, data)
+
+	_, err = rest.StreamBlock(ctx, "ffff")
+	assert.EqualError(t, err, "ERROR: code 404: /rest/block/ffff.bin not found")
+
+	assert.Equal(t, []string{"a", "a", "a", "a", "a", "a"}, tenants)
+}
@@ -0,0 +1,269 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	backfillMaxAttempts = 3
+	backfillRetryDelay  = time.Second
+)
+
+// BackfillBlock is a downloaded and verified block delivered by Backfill.
+type BackfillBlock struct {
+	Height   uint64
+	Hash     string
+	PrevHash string
+	Raw      []byte
+}
+
+// Cursor returns the position of this block, suitable for persisting with a CursorStore.
+func (bb *BackfillBlock) Cursor() Cursor {
+	return Cursor{Height: bb.Height, Hash: bb.Hash}
+}
+
+type backfillResult struct {
+	block *BackfillBlock
+	err   error
+}
+
+// Backfill downloads the blocks in the height range [from, to] using the given number of concurrent workers
+// and calls fn for each block in height order. Before a block is handed to fn its hash, merkle root and link
+// to the previous block are verified locally. Failed or corrupt downloads are retried; if a block still cannot
+// be fetched, or fn returns an error, Backfill stops and returns the error.
+func (b *Bitcoind) Backfill(from, to uint64, workers int, fn func(*BackfillBlock) error) error {
+	if to < from {
+		return fmt.Errorf("invalid backfill range %d-%d", from, to)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var prevHash string
+	if from > 0 {
+		h, err := b.GetBlockHash(int(from - 1))
+		if err != nil {
+			return err
+		}
+		prevHash = h
+	}
+
+	g, ctx := errgroup.WithContext(context.Background())
+
+	// Results are queued in height order, which bounds the number of blocks held in memory
+	pending := make(chan chan backfillResult, workers*2)
+	sem := make(chan struct{}, workers)
+
+	g.Go(func() error {
+		defer close(pending)
+
+		for height := from; ; height++ {
+			ch := make(chan backfillResult, 1)
+
+			select {
+			case pending <- ch:
+			case <-ctx.Done():
+				return nil
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil
+			}
+
+			go func(height uint64) {
+				defer func() { <-sem }()
+
+				block, err := b.fetchVerifiedBlock(ctx, height)
+				ch <- backfillResult{block, err}
+			}(height)
+
+			if height == to {
+				return nil
+			}
+		}
+	})
+
+	g.Go(func() error {
+		for ch := range pending {
+			var res backfillResult
+			select {
+			case res = <-ch:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if res.err != nil {
+				return res.err
+			}
+
+			if res.block.Height > 0 && res.block.PrevHash != prevHash {
+				return fmt.Errorf("block %s at height %d does not link to previous block %s", res.block.Hash, res.block.Height, prevHash)
+			}
+
+			if err := fn(res.block); err != nil {
+				return err
+			}
+
+			prevHash = res.block.Hash
+		}
+
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// Backfiller runs Backfill in steps, each resuming after the last block handed to fn, e.g. to backfill up
+// to the tip and then follow it. It implements Checkpointer, so that a backfill interrupted by a crash
+// continues where it stopped once its cursor is restored.
+type Backfiller struct {
+	// Workers is the number of blocks downloaded concurrently.
+	Workers int
+
+	b      *Bitcoind
+	mu     sync.Mutex
+	next   uint64
+	cursor Cursor
+}
+
+// NewBackfiller returns a Backfiller starting at height from.
+func NewBackfiller(b *Bitcoind, from uint64, workers int) *Backfiller {
+	return &Backfiller{Workers: workers, b: b, next: from}
+}
+
+// Run backfills the blocks after the cursor up to height to, calling fn for each of them in height order.
+// Run must not be called concurrently.
+func (bf *Backfiller) Run(to uint64, fn func(*BackfillBlock) error) error {
+	bf.mu.Lock()
+	from := bf.next
+	bf.mu.Unlock()
+
+	if from > to {
+		return nil
+	}
+
+	return bf.b.Backfill(from, to, bf.Workers, func(block *BackfillBlock) error {
+		if err := fn(block); err != nil {
+			return err
+		}
+
+		bf.mu.Lock()
+		bf.cursor = block.Cursor()
+		bf.next = block.Height + 1
+		bf.mu.Unlock()
+
+		return nil
+	})
+}
+
+// Cursor returns the last block handed to fn, or an empty cursor before the first one.
+func (bf *Backfiller) Cursor() Cursor {
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+
+	return bf.cursor
+}
+
+// SetCursor makes the next Run start with the block after c.
+func (bf *Backfiller) SetCursor(c Cursor) error {
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+
+	bf.cursor = c
+	bf.next = c.Height + 1
+	return nil
+}
+
+// fetchVerifiedBlock downloads the block at the given height, retrying until it passes verification.
+func (b *Bitcoind) fetchVerifiedBlock(ctx context.Context, height uint64) (*BackfillBlock, error) {
+	var err error
+
+	for attempt := 1; attempt <= backfillMaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-b.client.clock.After(time.Duration(attempt-1) * backfillRetryDelay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		var block *BackfillBlock
+		block, err = b.fetchBlockAtHeight(ctx, height)
+		if err == nil {
+			return block, nil
+		}
+
+		// The group was cancelled, e.g. because another worker failed
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		// Errors of the node, e.g. for blocks that were pruned, are not fixed by fetching again
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			return nil, fmt.Errorf("failed to fetch block at height %d: %w", height, err)
+		}
+
+		b.client.loggerFor(ctx).Warnf("Backfill: attempt %d for block at height %d failed: %v", attempt, height, err)
+	}
+
+	return nil, fmt.Errorf("failed to fetch block at height %d after %d attempts: %w", height, backfillMaxAttempts, err)
+}
+
+func (b *Bitcoind) fetchBlockAtHeight(ctx context.Context, height uint64) (*BackfillBlock, error) {
+	hash, err := b.GetBlockHashCtx(ctx, int(height))
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks are fetched without going through the response cache, a backfill would only fill it with data
+	// that is never read again.
+	r, err := b.client.callContext(ctx, "getblock", []interface{}{hash, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Err != nil {
+		return nil, responseError(r.Err)
+	}
+
+	var rawHex string
+	if err := json.Unmarshal(r.Result, &rawHex); err != nil {
+		return nil, err
+	}
+
+	raw, err := hex.DecodeString(rawHex)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := parseBlock(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Hash != hash {
+		return nil, fmt.Errorf("block hash mismatch, expected %s but data hashes to %s", hash, block.Hash)
+	}
+
+	if err := block.verify(); err != nil {
+		return nil, err
+	}
+
+	return &BackfillBlock{
+		Height:   height,
+		Hash:     hash,
+		PrevHash: block.PrevHash,
+		Raw:      raw,
+	}, nil
+}
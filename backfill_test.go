@@ -0,0 +1,272 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChain returns n blocks on top of genesis with valid merkle roots, each with a single transaction of
+// the given seed, and their hashes.
+func testChain(n int, seed uint64) ([][]byte, []string) {
+	blocks := make([][]byte, n)
+	hashes := make([]string, n)
+
+	prev := zeroHash
+	for h := range blocks {
+		tx := testTx([]Outpoint{{TxID: zeroHash, Vout: 0xffffffff}}, "51", seed+uint64(h))
+		parsed, _ := parseTx(tx)
+		root, _ := hex.DecodeString(parsed.TxID)
+
+		block := testBlock(prev, uint32(h), tx)
+		copy(block[36:68], cryptolib.ReverseBytes(root))
+
+		blocks[h] = block
+		hashes[h] = hashString(block[:80])
+		prev = hashes[h]
+	}
+
+	return blocks, hashes
+}
+
+// backfillNode serves the given chain, where serve, if set, returns the getblock response for the attempt-th
+// request of the block at height, or "" for the block itself.
+type backfillNode struct {
+	blocks [][]byte
+	hashes []string
+	serve  func(height, attempt int) string
+
+	mu       sync.Mutex
+	attempts map[int]int
+}
+
+func (n *backfillNode) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	params := req.Params.([]interface{})
+
+	switch req.Method {
+	case "getblockhash":
+		_, _ = fmt.Fprintf(w, `{"result":%q,"error":null,"id":1}`, n.hashes[int(params[0].(float64))])
+	case "getblock":
+		height := -1
+		for h, hash := range n.hashes {
+			if hash == params[0] {
+				height = h
+			}
+		}
+
+		n.mu.Lock()
+		if n.attempts == nil {
+			n.attempts = make(map[int]int)
+		}
+		n.attempts[height]++
+		attempt := n.attempts[height]
+		n.mu.Unlock()
+
+		if n.serve != nil {
+			if resp := n.serve(height, attempt); resp != "" {
+				_, _ = w.Write([]byte(resp))
+				return
+			}
+		}
+		_, _ = fmt.Fprintf(w, `{"result":"%x","error":null,"id":1}`, n.blocks[height])
+	}
+}
+
+func TestBackfillOrder(t *testing.T) {
+	blocks, hashes := testChain(8, 0)
+
+	// Later blocks are served first
+	node := &backfillNode{blocks: blocks, hashes: hashes, serve: func(height, attempt int) string {
+		time.Sleep(time.Duration(len(blocks)-height) * 5 * time.Millisecond)
+		return ""
+	}}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	var heights []uint64
+	err := b.Backfill(0, 7, 4, func(block *BackfillBlock) error {
+		heights = append(heights, block.Height)
+		assert.Equal(t, hashes[block.Height], block.Hash)
+		assert.Equal(t, blocks[block.Height], block.Raw)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4, 5, 6, 7}, heights)
+}
+
+func TestBackfillRetriesCorruptBlock(t *testing.T) {
+	blocks, hashes := testChain(4, 0)
+
+	node := &backfillNode{blocks: blocks, hashes: hashes, serve: func(height, attempt int) string {
+		if height == 2 && attempt == 1 {
+			corrupt := append([]byte(nil), blocks[2]...)
+			corrupt[len(corrupt)-5] ^= 1
+			return fmt.Sprintf(`{"result":"%x","error":null,"id":1}`, corrupt)
+		}
+		return ""
+	}}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	var heights []uint64
+	err := b.Backfill(1, 3, 2, func(block *BackfillBlock) error {
+		heights = append(heights, block.Height)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, heights)
+}
+
+func TestBackfillNodeError(t *testing.T) {
+	blocks, hashes := testChain(3, 0)
+
+	var calls int
+	node := &backfillNode{blocks: blocks, hashes: hashes, serve: func(height, attempt int) string {
+		if height == 1 {
+			calls++
+			return `{"result":null,"error":{"code":-1,"message":"Block not available (pruned data)"},"id":1}`
+		}
+		return ""
+	}}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	err := b.Backfill(0, 2, 1, func(*BackfillBlock) error { return nil })
+	assert.True(t, HasErrorCode(err, RPCMiscError))
+	assert.Contains(t, err.Error(), "pruned data")
+	assert.Equal(t, 1, calls)
+}
+
+func TestBackfillPrevHashMismatch(t *testing.T) {
+	blocks, hashes := testChain(4, 0)
+
+	// The node switched to another chain while the backfill ran
+	other, otherHashes := testChain(4, 100)
+	blocks[3], hashes[3] = other[3], otherHashes[3]
+
+	b, done := newTestNode(t, (&backfillNode{blocks: blocks, hashes: hashes}).handle)
+	defer done()
+
+	var heights []uint64
+	err := b.Backfill(0, 3, 2, func(block *BackfillBlock) error {
+		heights = append(heights, block.Height)
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not link")
+	assert.Equal(t, []uint64{0, 1, 2}, heights)
+}
+
+func TestBackfillFnError(t *testing.T) {
+	blocks, hashes := testChain(50, 0)
+
+	var mu sync.Mutex
+	var fetched int
+	node := &backfillNode{blocks: blocks, hashes: hashes, serve: func(int, int) string {
+		mu.Lock()
+		fetched++
+		mu.Unlock()
+		return ""
+	}}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	errStop := errors.New("stop")
+	var heights []uint64
+	err := b.Backfill(0, 49, 2, func(block *BackfillBlock) error {
+		heights = append(heights, block.Height)
+		if block.Height == 2 {
+			return errStop
+		}
+		return nil
+	})
+	assert.True(t, errors.Is(err, errStop))
+	assert.Equal(t, []uint64{0, 1, 2}, heights)
+
+	// The workers stop rather than fetching the rest of the range
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Less(t, fetched, len(blocks))
+}
+
+func TestBackfillCancelsFetches(t *testing.T) {
+	blocks, hashes := testChain(2, 0)
+
+	var mu sync.Mutex
+	var attempts int
+	aborted := make(chan struct{})
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params := req.Params.([]interface{})
+
+		switch {
+		case req.Method == "getblockhash":
+			_, _ = fmt.Fprintf(w, `{"result":%q,"error":null,"id":1}`, hashes[int(params[0].(float64))])
+		case params[0] == hashes[0]:
+			_, _ = fmt.Fprintf(w, `{"result":"%x","error":null,"id":1}`, blocks[0])
+		default:
+			// The block at height 1 is only sent once the backfill gave up on it
+			mu.Lock()
+			attempts++
+			mu.Unlock()
+
+			<-r.Context().Done()
+			close(aborted)
+		}
+	})
+	defer done()
+
+	errStop := errors.New("stop")
+	err := b.Backfill(0, 1, 2, func(block *BackfillBlock) error {
+		return errStop
+	})
+	assert.True(t, errors.Is(err, errStop))
+
+	// The failing fn cancelled the request in flight, which was not retried
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request for block 1 was not cancelled")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, attempts)
+}
+
+func TestBackfiller(t *testing.T) {
+	blocks, hashes := testChain(6, 0)
+
+	b, done := newTestNode(t, (&backfillNode{blocks: blocks, hashes: hashes}).handle)
+	defer done()
+
+	var heights []uint64
+	fn := func(block *BackfillBlock) error {
+		heights = append(heights, block.Height)
+		return nil
+	}
+
+	bf := NewBackfiller(b, 1, 2)
+	require.NoError(t, bf.Run(3, fn))
+	assert.Equal(t, Cursor{Height: 3, Hash: hashes[3]}, bf.Cursor())
+
+	// A restored backfiller resumes after the cursor
+	resumed := NewBackfiller(b, 0, 2)
+	require.NoError(t, resumed.SetCursor(bf.Cursor()))
+	require.NoError(t, resumed.Run(5, fn))
+	require.NoError(t, resumed.Run(5, fn))
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, heights)
+}
@@ -0,0 +1,126 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// BandwidthSample is a point in time measurement of the node's network usage.
+type BandwidthSample struct {
+	Time           time.Time `json:"time"`
+	TotalBytesRecv int       `json:"totalbytesrecv"`
+	TotalBytesSent int       `json:"totalbytessent"`
+	RecvRate       float64   `json:"recvrate"` // bytes per second since the previous sample
+	SendRate       float64   `json:"sendrate"` // bytes per second since the previous sample
+	// Upload target (-maxuploadtarget) state, all zero when no target is configured
+	UploadTarget     int     `json:"uploadtarget"`
+	TargetReached    bool    `json:"targetreached"`
+	BytesLeftInCycle int     `json:"bytesleftincycle"`
+	TimeLeftInCycle  int     `json:"timeleftincycle"`
+	TargetUsage      float64 `json:"targetusage"` // fraction of the upload target used in the current cycle
+}
+
+// WithBandwidthHook registers a function that is called with every sample a BandwidthMonitor of the client
+// takes, e.g. to export the node's network usage as metrics. The traffic of the client's own calls is
+// reported to the hooks registered with WithCallHook.
+func WithBandwidthHook(hook func(BandwidthSample)) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.bandwidthHooks = append(p.bandwidthHooks[:len(p.bandwidthHooks):len(p.bandwidthHooks)], hook)
+	}
+}
+
+// BandwidthMonitor polls getnettotals and derives upload/download rates and upload target usage. Every sample
+// is passed to the hooks registered with WithBandwidthHook.
+type BandwidthMonitor struct {
+	b    *Bitcoind
+	mu   sync.Mutex
+	last *NetTotals
+}
+
+// NewBandwidthMonitor returns a BandwidthMonitor for the given node.
+func NewBandwidthMonitor(b *Bitcoind) *BandwidthMonitor {
+	return &BandwidthMonitor{b: b}
+}
+
+// Sample takes a measurement. Rates are computed against the previous sample, so they are zero the first time.
+func (m *BandwidthMonitor) Sample() (BandwidthSample, error) {
+	return m.SampleCtx(context.Background())
+}
+
+// SampleCtx is Sample with a context that cancels the request.
+func (m *BandwidthMonitor) SampleCtx(ctx context.Context) (BandwidthSample, error) {
+	// getnettotals is called without the response cache, otherwise consecutive samples could see the same totals
+	r, err := m.b.client.callContext(ctx, "getnettotals", nil)
+	if err != nil {
+		return BandwidthSample{}, err
+	}
+
+	if r.Err != nil {
+		return BandwidthSample{}, responseError(r.Err)
+	}
+
+	var totals NetTotals
+	if err := json.Unmarshal(r.Result, &totals); err != nil {
+		return BandwidthSample{}, err
+	}
+
+	s := m.add(totals)
+	for _, hook := range m.b.client.bandwidthHooks {
+		hook(s)
+	}
+
+	return s, nil
+}
+
+// add computes the sample of the given totals and records them for the next one.
+func (m *BandwidthMonitor) add(totals NetTotals) BandwidthSample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := BandwidthSample{
+		Time:             time.UnixMilli(int64(totals.TimeMillis)),
+		TotalBytesRecv:   totals.TotalBytesRecv,
+		TotalBytesSent:   totals.TotalBytesSent,
+		UploadTarget:     totals.UploadTarget.Target,
+		TargetReached:    totals.UploadTarget.TargetReached,
+		BytesLeftInCycle: totals.UploadTarget.BytesLeftInCycle,
+		TimeLeftInCycle:  totals.UploadTarget.TimeLeftInCycle,
+	}
+
+	if s.UploadTarget > 0 {
+		s.TargetUsage = float64(s.UploadTarget-s.BytesLeftInCycle) / float64(s.UploadTarget)
+	}
+
+	if m.last != nil {
+		elapsed := float64(totals.TimeMillis-m.last.TimeMillis) / 1000
+		// Totals go backwards when the node restarts
+		if elapsed > 0 && totals.TotalBytesRecv >= m.last.TotalBytesRecv && totals.TotalBytesSent >= m.last.TotalBytesSent {
+			s.RecvRate = float64(totals.TotalBytesRecv-m.last.TotalBytesRecv) / elapsed
+			s.SendRate = float64(totals.TotalBytesSent-m.last.TotalBytesSent) / elapsed
+		}
+	}
+	m.last = &totals
+
+	return s
+}
+
+// Run samples every interval until the context is done. Errors are logged.
+func (m *BandwidthMonitor) Run(ctx context.Context, interval time.Duration) {
+	logger := m.b.client.loggerFor(ctx)
+	ticker := m.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.SampleCtx(ctx); err != nil {
+			logger.Errorf("BandwidthMonitor: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
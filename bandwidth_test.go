@@ -0,0 +1,193 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bandwidthNode answers getnettotals with the given responses in turn and records the samples its
+// bandwidth hook receives.
+type bandwidthNode struct {
+	totals []string
+
+	mu      sync.Mutex
+	calls   int
+	samples []BandwidthSample
+}
+
+func (n *bandwidthNode) handle(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	fmt.Fprintf(w, `{"result":%s,"error":null,"id":1}`, n.totals[n.calls])
+	n.calls++
+}
+
+func (n *bandwidthNode) hook(s BandwidthSample) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.samples = append(n.samples, s)
+}
+
+func (n *bandwidthNode) hooked() []BandwidthSample {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]BandwidthSample(nil), n.samples...)
+}
+
+func netTotals(timeMillis, recv, sent int) string {
+	return fmt.Sprintf(`{"totalbytesrecv":%d,"totalbytessent":%d,"timemillis":%d,"uploadtarget":{"timeframe":86400,"target":0}}`, recv, sent, timeMillis)
+}
+
+func TestBandwidthMonitorRates(t *testing.T) {
+	node := &bandwidthNode{totals: []string{
+		netTotals(1000000, 5000, 2000),
+		netTotals(1002000, 9000, 3000),
+		netTotals(1006000, 9000, 23000),
+	}}
+	b, done := newTestNode(t, node.handle, WithBandwidthHook(node.hook))
+	defer done()
+
+	m := NewBandwidthMonitor(b)
+
+	first, err := m.Sample()
+	require.NoError(t, err)
+	assert.Equal(t, time.UnixMilli(1000000), first.Time)
+	assert.Equal(t, 5000, first.TotalBytesRecv)
+	assert.Zero(t, first.RecvRate)
+	assert.Zero(t, first.SendRate)
+
+	second, err := m.Sample()
+	require.NoError(t, err)
+	assert.Equal(t, 2000.0, second.RecvRate)
+	assert.Equal(t, 500.0, second.SendRate)
+
+	third, err := m.Sample()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, third.RecvRate)
+	assert.Equal(t, 5000.0, third.SendRate)
+
+	// Every sample reached the hooks
+	assert.Equal(t, []BandwidthSample{first, second, third}, node.hooked())
+}
+
+func TestBandwidthMonitorNodeRestart(t *testing.T) {
+	node := &bandwidthNode{totals: []string{
+		netTotals(1000000, 500000, 800000),
+		netTotals(1010000, 1000, 4000),
+		netTotals(1011000, 3000, 5000),
+	}}
+	b, done := newTestNode(t, node.handle, WithBandwidthHook(node.hook))
+	defer done()
+
+	m := NewBandwidthMonitor(b)
+
+	for i := 0; i < 3; i++ {
+		_, err := m.Sample()
+		require.NoError(t, err)
+	}
+
+	samples := node.hooked()
+	require.Len(t, samples, 3)
+
+	// The counters restarted from zero, the negative delta gives no rate rather than a negative one
+	assert.Equal(t, 0.0, samples[1].RecvRate)
+	assert.Equal(t, 0.0, samples[1].SendRate)
+
+	// Rates resume against the totals after the restart
+	assert.Equal(t, 2000.0, samples[2].RecvRate)
+	assert.Equal(t, 1000.0, samples[2].SendRate)
+}
+
+func TestBandwidthMonitorTargetUsage(t *testing.T) {
+	node := &bandwidthNode{totals: []string{
+		netTotals(1000000, 0, 0),
+		`{"totalbytesrecv":0,"totalbytessent":0,"timemillis":1000000,"uploadtarget":{"timeframe":86400,"target":1000000,"target_reached":false,"bytes_left_in_cycle":250000,"time_left_in_cycle":3600}}`,
+		`{"totalbytesrecv":0,"totalbytessent":0,"timemillis":1000000,"uploadtarget":{"timeframe":86400,"target":1000000,"target_reached":true,"bytes_left_in_cycle":0,"time_left_in_cycle":60}}`,
+	}}
+	b, done := newTestNode(t, node.handle, WithBandwidthHook(node.hook))
+	defer done()
+
+	m := NewBandwidthMonitor(b)
+
+	// Without -maxuploadtarget
+	s, err := m.Sample()
+	require.NoError(t, err)
+	assert.Zero(t, s.UploadTarget)
+	assert.Zero(t, s.TargetUsage)
+	assert.False(t, s.TargetReached)
+
+	s, err = m.Sample()
+	require.NoError(t, err)
+	assert.Equal(t, 1000000, s.UploadTarget)
+	assert.Equal(t, 250000, s.BytesLeftInCycle)
+	assert.Equal(t, 3600, s.TimeLeftInCycle)
+	assert.Equal(t, 0.75, s.TargetUsage)
+	assert.False(t, s.TargetReached)
+
+	s, err = m.Sample()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, s.TargetUsage)
+	assert.True(t, s.TargetReached)
+}
+
+func TestBandwidthMonitorError(t *testing.T) {
+	var hooked int
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":null,"error":{"code":-1,"message":"boom"},"id":1}`)
+	}, WithBandwidthHook(func(s BandwidthSample) {
+		hooked++
+	}))
+	defer done()
+
+	_, err := NewBandwidthMonitor(b).Sample()
+	assert.True(t, HasErrorCode(err, RPCMiscError))
+	assert.Zero(t, hooked)
+}
+
+// errorTraceLogger is a ContextLogger recording its errors with the trace ID of the context.
+type errorTraceLogger struct {
+	countingLogger
+	traceID string
+	errors  chan string
+}
+
+func (l *errorTraceLogger) WithContext(ctx context.Context) Logger {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return &errorTraceLogger{traceID: traceID, errors: l.errors}
+}
+
+func (l *errorTraceLogger) Errorf(format string, args ...interface{}) {
+	l.errors <- l.traceID + ": " + fmt.Sprintf(format, args...)
+}
+
+func TestBandwidthMonitorRunLogsWithContext(t *testing.T) {
+	logger := &errorTraceLogger{errors: make(chan string, 1)}
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":null,"error":{"code":-1,"message":"boom"},"id":1}`)
+	}, WithOptionalLogger(logger))
+	defer done()
+
+	m := NewBandwidthMonitor(b)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), traceIDKey{}, "trace-1"))
+	finished := make(chan struct{})
+	go func() {
+		m.Run(ctx, time.Hour)
+		close(finished)
+	}()
+
+	assert.Equal(t, "trace-1: BandwidthMonitor: ERROR -1: boom", <-logger.errors)
+
+	cancel()
+	<-finished
+}
@@ -0,0 +1,63 @@
+package bitcoin
+
+import (
+	"fmt"
+	"time"
+)
+
+// SyncBans copies the ban list of the source node to each of the targets. Bans are applied with the same
+// expiry time as on the source, bans that have already expired or exist on a target are skipped. It returns
+// the number of bans added. The ban lists are read bypassing the cache, so bans added or removed just before
+// are taken into account.
+func SyncBans(source *Bitcoind, targets ...*Bitcoind) (int, error) {
+	var banned []BannedNode
+	if err := source.uncachedCall("listbanned", nil, &banned); err != nil {
+		return 0, fmt.Errorf("failed to list bans of source node: %w", err)
+	}
+
+	added := 0
+	now := time.Now().Unix()
+
+	for _, target := range targets {
+		var existing []BannedNode
+		if err := target.uncachedCall("listbanned", nil, &existing); err != nil {
+			return added, fmt.Errorf("failed to list bans of target node: %w", err)
+		}
+
+		known := make(map[string]bool, len(existing))
+		for _, ban := range existing {
+			known[ban.Address] = true
+		}
+
+		for _, ban := range banned {
+			if known[ban.Address] || ban.BannedUntil <= now {
+				continue
+			}
+
+			if err := target.SetBan(ban.Address, "add", ban.BannedUntil, true); err != nil {
+				return added, fmt.Errorf("failed to ban %s: %w", ban.Address, err)
+			}
+
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+// SyncBans copies the ban list of the named node to all other nodes of the fleet.
+func (f *Fleet) SyncBans(source string) (int, error) {
+	src, found := f.nodes[source]
+	if !found {
+		return 0, fmt.Errorf("unknown node %q", source)
+	}
+
+	var targets []*Bitcoind
+	for _, name := range f.names {
+		if name != source {
+			targets = append(targets, f.nodes[name])
+		}
+	}
+
+	return SyncBans(src, targets...)
+}
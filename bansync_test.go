@@ -0,0 +1,109 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// banNode is a node keeping a ban list that listbanned and setban operate on.
+type banNode struct {
+	mu   sync.Mutex
+	bans map[string]int64
+}
+
+func (n *banNode) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch req.Method {
+	case "listbanned":
+		list := []BannedNode{}
+		for address, until := range n.bans {
+			list = append(list, BannedNode{Address: address, BannedUntil: until})
+		}
+		result, _ := json.Marshal(list)
+		fmt.Fprintf(w, `{"result":%s,"error":null,"id":1}`, result)
+	case "setban":
+		params := req.Params.([]interface{})
+		address := params[0].(string)
+		if params[1] == "add" {
+			n.bans[address] = int64(params[2].(float64))
+		} else {
+			delete(n.bans, address)
+		}
+		fmt.Fprint(w, `{"result":null,"error":null,"id":1}`)
+	}
+}
+
+// banned returns the banned addresses in order.
+func (n *banNode) banned() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var addresses []string
+	for address := range n.bans {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func TestSyncBans(t *testing.T) {
+	until := time.Now().Add(time.Hour).Unix()
+
+	source, doneSource := newTestNode(t, (&banNode{bans: map[string]int64{
+		"10.0.0.1/32": until,
+		"10.0.0.2/32": until,
+		"10.0.0.3/32": time.Now().Add(-time.Minute).Unix(),
+	}}).handle)
+	defer doneSource()
+
+	targetNode := &banNode{bans: map[string]int64{"10.0.0.2/32": until}}
+	target, doneTarget := newTestNode(t, targetNode.handle)
+	defer doneTarget()
+
+	// Ban lists cached before must not hide later changes
+	_, err := source.ListBanned()
+	require.NoError(t, err)
+	_, err = target.ListBanned()
+	require.NoError(t, err)
+
+	added, err := SyncBans(source, target)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, []string{"10.0.0.1/32", "10.0.0.2/32"}, targetNode.banned())
+
+	// A ban removed on the target is added again
+	require.NoError(t, target.SetBan("10.0.0.1/32", "remove", 0, false))
+
+	added, err = SyncBans(source, target)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, []string{"10.0.0.1/32", "10.0.0.2/32"}, targetNode.banned())
+
+	// A ban removed on the source is not copied, a new one is
+	require.NoError(t, source.SetBan("10.0.0.1/32", "remove", 0, false))
+	require.NoError(t, source.SetBan("10.0.0.4/32", "add", until, true))
+	require.NoError(t, target.SetBan("10.0.0.1/32", "remove", 0, false))
+
+	added, err = SyncBans(source, target)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, []string{"10.0.0.2/32", "10.0.0.4/32"}, targetNode.banned())
+
+	// Nothing left to copy
+	added, err = SyncBans(source, target)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+}
@@ -0,0 +1,128 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// BatchItem is the outcome of one item of a batch operation.
+type BatchItem struct {
+	Index int         // position of the item in the request
+	Value interface{} // result of the item, nil if it failed; its type is documented by the operation
+	Err   error
+}
+
+// BatchResult carries the outcome of every item of a batch operation, so that the items that succeeded
+// can be used when others failed. Operations return a BatchResult together with an error only for failures
+// of the batch as a whole, e.g. the node being unreachable.
+type BatchResult struct {
+	Items []BatchItem
+}
+
+func newBatchResult(n int) *BatchResult {
+	r := &BatchResult{Items: make([]BatchItem, n)}
+	for i := range r.Items {
+		r.Items[i].Index = i
+	}
+
+	return r
+}
+
+// OK reports whether all items succeeded.
+func (r *BatchResult) OK() bool {
+	return r.FirstError() == nil
+}
+
+// FirstError returns the error of the first failed item, or nil.
+func (r *BatchResult) FirstError() error {
+	for _, item := range r.Items {
+		if item.Err != nil {
+			return item.Err
+		}
+	}
+
+	return nil
+}
+
+// Successes returns the items that succeeded, in request order.
+func (r *BatchResult) Successes() []BatchItem {
+	var items []BatchItem
+	for _, item := range r.Items {
+		if item.Err == nil {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Failed returns the indices of the items that failed, e.g. to retry them.
+func (r *BatchResult) Failed() []int {
+	var indices []int
+	for _, item := range r.Items {
+		if item.Err != nil {
+			indices = append(indices, item.Index)
+		}
+	}
+
+	return indices
+}
+
+// BatchCall sends one call to method per element of params in a single JSON-RPC batch. The values of the
+// items are the json.RawMessage results; the errors reported by the node are formatted like those of the
+// wrappers.
+func (b *Bitcoind) BatchCall(method string, params [][]interface{}) (*BatchResult, error) {
+	return b.BatchCallCtx(context.Background(), method, params)
+}
+
+// BatchCallCtx is BatchCall with a context that cancels the request.
+func (b *Bitcoind) BatchCallCtx(ctx context.Context, method string, params [][]interface{}) (*BatchResult, error) {
+	responses, err := b.client.batch(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newBatchResult(len(responses))
+	for i, r := range responses {
+		if r.Err != nil {
+			result.Items[i].Err = responseError(r.Err)
+			continue
+		}
+		result.Items[i].Value = r.Result
+	}
+
+	return result, nil
+}
+
+// GetRawTransactionsHex fetches many raw transactions in one JSON-RPC batch. The values of the items are
+// the transactions as hex strings. Without -txindex only mempool transactions, and those in a TxIndex set
+// with UseTxIndex, are found.
+func (b *Bitcoind) GetRawTransactionsHex(txids []string) (*BatchResult, error) {
+	params := make([][]interface{}, len(txids))
+	for i, txid := range txids {
+		params[i] = b.rawTransactionParams(txid, 0)
+	}
+
+	result, err := b.BatchCall("getrawtransaction", params)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.Err != nil {
+			item.Err = fmt.Errorf("%w (txid %s)", item.Err, txids[i])
+			continue
+		}
+
+		var txHex string
+		if err := json.Unmarshal(item.Value.(json.RawMessage), &txHex); err != nil {
+			item.Value, item.Err = nil, err
+			continue
+		}
+		item.Value = txHex
+	}
+
+	return result, nil
+}
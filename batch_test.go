@@ -0,0 +1,61 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRawTransactionsHex(t *testing.T) {
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var reqs []rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&reqs)
+
+		responses := make([]string, len(reqs))
+		for i, req := range reqs {
+			txid := req.Params.([]interface{})[0].(string)
+			if strings.HasPrefix(txid, "missing") {
+				responses[i] = fmt.Sprintf(`{"result":null,"error":{"code":-5,"message":"No such mempool transaction"},"id":%d}`, req.ID)
+			} else {
+				responses[i] = fmt.Sprintf(`{"result":"%s00","error":null,"id":%d}`, txid, req.ID)
+			}
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+	})
+	defer closeServer()
+
+	result, err := b.GetRawTransactionsHex([]string{"aa", "missing1", "bb", "missing2"})
+	require.NoError(t, err)
+
+	assert.False(t, result.OK())
+	assert.Equal(t, []int{1, 3}, result.Failed())
+	assert.EqualError(t, result.FirstError(), "ERROR -5: No such mempool transaction (txid missing1)")
+
+	successes := result.Successes()
+	require.Len(t, successes, 2)
+	assert.Equal(t, BatchItem{Index: 2, Value: "bb00"}, successes[1])
+
+	result, err = b.GetRawTransactionsHex([]string{"cc"})
+	require.NoError(t, err)
+	assert.True(t, result.OK())
+	assert.Nil(t, result.Failed())
+}
+
+func TestPayoutResultsBatch(t *testing.T) {
+	failed := errors.New("broadcast failed")
+
+	result := PayoutResults{
+		{Payout: Payout{ID: "a"}, TxID: "tx"},
+		{Payout: Payout{ID: "b"}, TxID: "tx", Err: failed},
+	}.Batch()
+
+	assert.Equal(t, []int{1}, result.Failed())
+	assert.Equal(t, failed, result.FirstError())
+	assert.Equal(t, "a", result.Successes()[0].Value.(PayoutResult).ID)
+}
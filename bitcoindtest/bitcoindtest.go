@@ -0,0 +1,229 @@
+// Package bitcoindtest runs bitcoind in Docker for integration tests. Start launches a regtest node in a
+// throwaway container, waits until its RPC interface is up and returns a client authenticated with the
+// node's cookie. The container is removed when the test finishes. Tests are skipped when Docker is not
+// available, so the same suite runs on machines without it. For tests against public signet or testnet4
+// nodes, Fund pays a wallet address from configured faucets and waits for the payment to confirm.
+//
+// The docker command line is used directly rather than a container library, so that the module does not
+// pull in a Docker client for every consumer.
+package bitcoindtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+const (
+	rpcPort  = 18443
+	zmqPort  = 28332
+	dataDir  = "/home/bitcoin/.bitcoin"
+	pollWait = 250 * time.Millisecond
+)
+
+// Options configure the node started by Start.
+type Options struct {
+	Image        string        // Docker image, "bitcoin/bitcoin" by default
+	Version      string        // image tag, "latest" by default
+	Args         []string      // additional bitcoind arguments, e.g. "-txindex"
+	StartTimeout time.Duration // how long to wait for the RPC interface, 60 seconds by default
+	ClientOpts   []bitcoin.Option
+}
+
+// Node is a running bitcoind container.
+type Node struct {
+	*bitcoin.Bitcoind
+
+	ContainerID string
+	Host        string
+	RPCPort     int
+	ZMQPort     int // rawblock, rawtx, hashblock, hashtx and sequence are all published on this port
+	User        string
+	Password    string
+}
+
+// ZMQ returns a ZMQ subscriber connected to the node.
+func (n *Node) ZMQ() *bitcoin.ZMQ {
+	return bitcoin.NewZMQ(n.Host, n.ZMQPort)
+}
+
+// Start runs a regtest node and returns it once it answers RPC calls. It fails the test if the node
+// does not come up, and skips it if Docker is not available.
+func Start(t testing.TB, opts Options) *Node {
+	t.Helper()
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("bitcoindtest: docker is not installed")
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skip("bitcoindtest: docker is not available")
+	}
+
+	image := opts.Image
+	if image == "" {
+		image = "bitcoin/bitcoin"
+	}
+	version := opts.Version
+	if version == "" {
+		version = "latest"
+	}
+	timeout := opts.StartTimeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	args := []string{
+		"run", "--detach", "--rm",
+		"--publish", fmt.Sprintf("127.0.0.1::%d", rpcPort),
+		"--publish", fmt.Sprintf("127.0.0.1::%d", zmqPort),
+		image + ":" + version,
+		"-regtest",
+		"-server",
+		"-printtoconsole",
+		"-fallbackfee=0.0001",
+		"-rpcbind=0.0.0.0",
+		"-rpcallowip=0.0.0.0/0",
+		fmt.Sprintf("-rpcport=%d", rpcPort),
+	}
+	for _, topic := range []string{"rawblock", "rawtx", "hashblock", "hashtx", "sequence"} {
+		args = append(args, fmt.Sprintf("-zmqpub%s=tcp://0.0.0.0:%d", topic, zmqPort))
+	}
+	args = append(args, opts.Args...)
+
+	id, err := docker(args...)
+	if err != nil {
+		t.Fatalf("bitcoindtest: starting %s:%s: %v", image, version, err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := docker("rm", "--force", id); err != nil {
+			t.Logf("bitcoindtest: removing container %s: %v", id, err)
+		}
+	})
+
+	n := &Node{ContainerID: id, Host: "127.0.0.1"}
+
+	if n.RPCPort, err = mappedPort(id, rpcPort); err != nil {
+		t.Fatalf("bitcoindtest: %v", err)
+	}
+	if n.ZMQPort, err = mappedPort(id, zmqPort); err != nil {
+		t.Fatalf("bitcoindtest: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := n.waitReady(ctx, opts.ClientOpts); err != nil {
+		logs, _ := docker("logs", "--tail", "50", id)
+		t.Fatalf("bitcoindtest: node did not start: %v\n%s", err, logs)
+	}
+
+	return n
+}
+
+// waitReady reads the cookie once the node has written it and polls until RPC calls succeed.
+func (n *Node) waitReady(ctx context.Context, clientOpts []bitcoin.Option) error {
+	var lastErr error
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, last error: %v", ctx.Err(), lastErr)
+		case <-time.After(pollWait):
+		}
+
+		if n.Bitcoind == nil {
+			cookie, err := docker("exec", n.ContainerID, "cat", dataDir+"/regtest/.cookie")
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			user, password, found := cut(cookie, ":")
+			if !found {
+				lastErr = fmt.Errorf("malformed cookie %q", cookie)
+				continue
+			}
+			n.User, n.Password = user, password
+
+			if n.Bitcoind, err = bitcoin.New(n.Host, n.RPCPort, "", user, password, false, clientOpts...); err != nil {
+				return err
+			}
+		}
+
+		// The node answers with an error while it is still warming up
+		if _, err := n.GetBestBlockHash(); err != nil {
+			lastErr = err
+			continue
+		}
+
+		return nil
+	}
+}
+
+// Mine mines blocks to a fresh address of the node's default wallet, creating the wallet if needed, and
+// returns their hashes.
+func (n *Node) Mine(blocks int) ([]string, error) {
+	address, err := n.GetNewAddress()
+	if err != nil {
+		if !strings.Contains(err.Error(), "No wallet") {
+			return nil, err
+		}
+
+		if _, err := docker("exec", n.ContainerID, "bitcoin-cli", "-regtest", fmt.Sprintf("-rpcport=%d", rpcPort), "createwallet", "default"); err != nil {
+			return nil, err
+		}
+
+		if address, err = n.GetNewAddress(); err != nil {
+			return nil, err
+		}
+	}
+
+	return n.GenerateToAddress(float64(blocks), address)
+}
+
+// mappedPort returns the host port a container port is published on.
+func mappedPort(id string, port int) (int, error) {
+	out, err := docker("port", id, fmt.Sprintf("%d/tcp", port))
+	if err != nil {
+		return 0, err
+	}
+
+	// One line per address family, e.g. "127.0.0.1:49154"
+	line := strings.SplitN(out, "\n", 2)[0]
+	_, p, err := net.SplitHostPort(line)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected docker port output %q: %w", out, err)
+	}
+
+	return strconv.Atoi(p)
+}
+
+func docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
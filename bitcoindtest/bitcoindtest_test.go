@@ -0,0 +1,35 @@
+package bitcoindtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts a container")
+	}
+
+	n := Start(t, Options{})
+
+	hashes, err := n.Mine(101)
+	require.NoError(t, err)
+	assert.Len(t, hashes, 101)
+
+	info, err := n.GetBlockchainInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "regtest", info.Chain)
+	assert.Equal(t, "__cookie__", n.User)
+}
+
+func TestCut(t *testing.T) {
+	user, password, found := cut("__cookie__:abc:def", ":")
+	assert.True(t, found)
+	assert.Equal(t, "__cookie__", user)
+	assert.Equal(t, "abc:def", password)
+
+	_, _, found = cut("nocolon", ":")
+	assert.False(t, found)
+}
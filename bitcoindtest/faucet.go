@@ -0,0 +1,155 @@
+package bitcoindtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+// ErrFaucetUnavailable is returned when no faucet paid out, e.g. because all of them are rate limiting.
+var ErrFaucetUnavailable = errors.New("no faucet paid out")
+
+// faucetPoll is how often Fund checks the confirmations of the faucet's payment.
+var faucetPoll = 15 * time.Second
+
+var txidPattern = regexp.MustCompile(`\b[0-9a-fA-F]{64}\b`)
+
+// Faucet requests coins from a public signet or testnet faucet over HTTP. Faucets differ in their
+// interfaces and come and go, so none are built in: configure the ones a test environment relies on.
+type Faucet struct {
+	Name string
+	URL  string
+	// NewRequest builds the request for a payment of amount BTC to address. If nil, a form with the
+	// address and amount fields is posted to URL.
+	NewRequest func(ctx context.Context, f *Faucet, address string, amount float64) (*http.Request, error)
+	// TxID extracts the txid of the payment from the response body. If nil, the first 64 hex digit word of
+	// the body is taken.
+	TxID       func(body []byte) (string, error)
+	HTTPClient *http.Client // http.DefaultClient if nil
+}
+
+// Request asks the faucet for amount BTC to address and returns the txid of its payment.
+func (f *Faucet) Request(ctx context.Context, address string, amount float64) (string, error) {
+	newRequest := f.NewRequest
+	if newRequest == nil {
+		newRequest = postForm
+	}
+
+	req, err := newRequest(ctx, f, address, amount)
+	if err != nil {
+		return "", err
+	}
+
+	client := f.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("faucet %s: %w", f.Name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return "", fmt.Errorf("faucet %s: %w", f.Name, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("faucet %s: %s: %s", f.Name, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if f.TxID != nil {
+		return f.TxID(body)
+	}
+
+	txid := txidPattern.Find(body)
+	if txid == nil {
+		return "", fmt.Errorf("faucet %s: no txid in response %q", f.Name, body)
+	}
+
+	return strings.ToLower(string(txid)), nil
+}
+
+func postForm(ctx context.Context, f *Faucet, address string, amount float64) (*http.Request, error) {
+	form := url.Values{
+		"address": {address},
+		"amount":  {strconv.FormatFloat(amount, 'f', -1, 64)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+// Fund requests amount BTC to address from the faucets in turn until one pays out, then waits until the
+// payment has minConf confirmations and returns its txid. The address must belong to the wallet of b,
+// which is used to follow the payment. Confirmations on public test networks take minutes, so ctx should
+// allow for that.
+func Fund(ctx context.Context, b *bitcoin.Bitcoind, address string, amount float64, minConf int, faucets ...*Faucet) (string, error) {
+	var txid string
+	var errs []string
+	for _, f := range faucets {
+		var err error
+		if txid, err = f.Request(ctx, address, amount); err == nil {
+			break
+		}
+		errs = append(errs, err.Error())
+	}
+
+	if txid == "" {
+		return "", fmt.Errorf("%w: %s", ErrFaucetUnavailable, strings.Join(errs, "; "))
+	}
+
+	for {
+		// gettransaction directly, the cached wrapper would not see new confirmations
+		result, err := b.RawCall("gettransaction", txid)
+		if err == nil {
+			var tx bitcoin.WalletTransaction
+			if err := json.Unmarshal(result, &tx); err != nil {
+				return txid, err
+			}
+			if tx.Confirmations >= int64(minConf) {
+				return txid, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return txid, fmt.Errorf("waiting for %d confirmations of %s: %w", minConf, txid, ctx.Err())
+		case <-time.After(faucetPoll):
+		}
+	}
+}
+
+// FundT is Fund for tests: it skips the test if no faucet paid out, and fails it if the payment does not
+// confirm in time.
+func FundT(t testing.TB, ctx context.Context, b *bitcoin.Bitcoind, address string, amount float64, minConf int, faucets ...*Faucet) string {
+	t.Helper()
+
+	txid, err := Fund(ctx, b, address, amount, minConf, faucets...)
+	if errors.Is(err, ErrFaucetUnavailable) {
+		t.Skipf("bitcoindtest: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("bitcoindtest: %v", err)
+	}
+
+	return txid
+}
@@ -0,0 +1,68 @@
+package bitcoindtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+const faucetTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+func TestFund(t *testing.T) {
+	faucetPoll = time.Millisecond
+	defer func() { faucetPoll = 15 * time.Second }()
+
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "try again tomorrow", http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+
+	var form url.Values
+	paying := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = r.PostForm
+		fmt.Fprintf(w, "Payment of 0.01 sent with txid %s", strings.ToUpper(faucetTxID))
+	}))
+	defer paying.Close()
+
+	var polls int32
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		confirmations := atomic.AddInt32(&polls, 1) - 1
+		fmt.Fprintf(w, `{"result":{"txid":%q,"confirmations":%d},"error":null,"id":1}`, faucetTxID, confirmations)
+	}))
+	defer node.Close()
+
+	u, _ := url.Parse(node.URL)
+	port, _ := strconv.Atoi(u.Port())
+	b, err := bitcoin.New(u.Hostname(), port, "", "user", "pass", false)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	txid, err := Fund(ctx, b, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", 0.01, 2,
+		&Faucet{Name: "limited", URL: limited.URL},
+		&Faucet{Name: "paying", URL: paying.URL},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, faucetTxID, txid)
+	assert.Equal(t, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", form.Get("address"))
+	assert.Equal(t, "0.01", form.Get("amount"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+
+	_, err = Fund(ctx, b, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", 0.01, 1, &Faucet{Name: "limited", URL: limited.URL})
+	assert.ErrorIs(t, err, ErrFaucetUnavailable)
+	assert.Contains(t, err.Error(), "try again tomorrow")
+}
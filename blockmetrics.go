@@ -0,0 +1,175 @@
+package bitcoin
+
+import (
+	"fmt"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+// MaxBlockWeight is the consensus limit on the weight of a block (BIP141).
+const MaxBlockWeight = 4000000
+
+// BlockMetrics describes the use of the block space of a single block. Shares are fractions between 0 and
+// 1 of the inputs or outputs of the block, not counting the coinbase input.
+type BlockMetrics struct {
+	Height             uint64  `json:"height"`
+	Hash               string  `json:"hash"`
+	Time               uint32  `json:"time"`
+	Txs                int     `json:"txs"`
+	Size               int     `json:"size"`
+	StrippedSize       int     `json:"strippedsize"`
+	Weight             int     `json:"weight"`
+	WeightUtilization  float64 `json:"weight_utilization"` // weight as a fraction of MaxBlockWeight
+	SegwitTxs          int     `json:"segwit_txs"`
+	Inputs             int     `json:"inputs"`
+	SegwitInputs       int     `json:"segwit_inputs"`
+	TaprootInputs      int     `json:"taproot_inputs"`
+	Outputs            int     `json:"outputs"`
+	SegwitOutputs      int     `json:"segwit_outputs"`
+	TaprootOutputs     int     `json:"taproot_outputs"`
+	SegwitInputShare   float64 `json:"segwit_input_share"`
+	TaprootInputShare  float64 `json:"taproot_input_share"`
+	SegwitOutputShare  float64 `json:"segwit_output_share"`
+	TaprootOutputShare float64 `json:"taproot_output_share"`
+	Fees               uint64  `json:"fees"`        // satoshis, coinbase reward above the subsidy
+	AvgFeeRate         float64 `json:"avg_feerate"` // sat/vB over all transactions but the coinbase
+}
+
+// AnalyzeBlock computes the metrics of a serialized block at height, using params for the block subsidy;
+// nil means MainNetParams. Taproot inputs are recognized by their witness as prevouts are not part of the
+// block, so script path spends of P2WSH outputs that happen to look like taproot spends are counted as
+// taproot.
+func AnalyzeBlock(raw []byte, height uint64, params *ChainParams) (*BlockMetrics, error) {
+	if params == nil {
+		params = MainNetParams
+	}
+
+	block, err := parseBlock(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(block.Txs) == 0 {
+		return nil, fmt.Errorf("block %s has no transactions", block.Hash)
+	}
+
+	overhead := len(block.Header) + len(cryptolib.VarInt(uint64(len(block.Txs))))
+	m := &BlockMetrics{
+		Height: height,
+		Hash:   block.Hash,
+		Time:   block.Time,
+		Txs:    len(block.Txs),
+		Size:   overhead,
+		Weight: overhead * 4,
+	}
+
+	var reward uint64
+	vsize := 0
+	for i, tx := range block.Txs {
+		weight := tx.weight()
+		m.Size += len(tx.Raw)
+		m.Weight += weight
+
+		if i == 0 {
+			for _, out := range tx.Outputs {
+				reward += out.Value
+			}
+		} else {
+			vsize += (weight + 3) / 4
+		}
+
+		segwit := false
+		for _, in := range tx.Inputs {
+			if len(in.Witness) > 0 {
+				segwit = true
+			}
+			if i == 0 {
+				continue
+			}
+
+			m.Inputs++
+			if len(in.Witness) > 0 {
+				m.SegwitInputs++
+				if isTaprootWitness(in.Witness) {
+					m.TaprootInputs++
+				}
+			}
+		}
+		if segwit && i > 0 {
+			m.SegwitTxs++
+		}
+
+		for _, out := range tx.Outputs {
+			m.Outputs++
+			if version, _, ok := witnessProgram(out.ScriptPubKey); ok {
+				m.SegwitOutputs++
+				if version == 1 && len(out.ScriptPubKey) == 34 {
+					m.TaprootOutputs++
+				}
+			}
+		}
+	}
+
+	m.StrippedSize = (m.Weight - m.Size) / 3
+	m.WeightUtilization = float64(m.Weight) / MaxBlockWeight
+
+	if m.Inputs > 0 {
+		m.SegwitInputShare = float64(m.SegwitInputs) / float64(m.Inputs)
+		m.TaprootInputShare = float64(m.TaprootInputs) / float64(m.Inputs)
+	}
+	if m.Outputs > 0 {
+		m.SegwitOutputShare = float64(m.SegwitOutputs) / float64(m.Outputs)
+		m.TaprootOutputShare = float64(m.TaprootOutputs) / float64(m.Outputs)
+	}
+
+	if subsidy := BlockSubsidy(int64(height), params); reward > subsidy {
+		m.Fees = reward - subsidy
+	}
+	if vsize > 0 {
+		m.AvgFeeRate = float64(m.Fees) / float64(vsize)
+	}
+
+	return m, nil
+}
+
+// StreamBlockMetrics computes the metrics of the blocks in the height range [from, to] and calls fn for
+// each of them in height order. Blocks are downloaded by Backfill with the given number of workers.
+func (b *Bitcoind) StreamBlockMetrics(from, to uint64, workers int, fn func(*BlockMetrics) error) error {
+	chainInfo, err := b.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+
+	params, err := ParamsForChain(chainInfo.Chain)
+	if err != nil {
+		return err
+	}
+
+	return b.Backfill(from, to, workers, func(block *BackfillBlock) error {
+		m, err := AnalyzeBlock(block.Raw, block.Height, params)
+		if err != nil {
+			return err
+		}
+		return fn(m)
+	})
+}
+
+// isTaprootWitness reports whether a witness stack looks like a taproot spend: a single 64 or 65 byte
+// signature for a key path spend, or a script path spend ending in a control block, each optionally
+// followed by an annex.
+func isTaprootWitness(witness [][]byte) bool {
+	if len(witness) >= 2 {
+		if annex := witness[len(witness)-1]; len(annex) > 0 && annex[0] == 0x50 {
+			witness = witness[:len(witness)-1]
+		}
+	}
+
+	switch n := len(witness); {
+	case n == 1:
+		return len(witness[0]) == 64 || len(witness[0]) == 65
+	case n >= 2:
+		control := witness[n-1]
+		return len(control) >= 33 && (len(control)-33)%32 == 0 && (len(control)-33)/32 <= 128 && control[0]&0xfe == 0xc0
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withWitness adds marker, flag and one witness stack per input to a serialized transaction.
+func withWitness(raw []byte, witnesses ...[][]byte) []byte {
+	tx := append([]byte{}, raw[:4]...)
+	tx = append(tx, 0x00, 0x01)
+	tx = append(tx, raw[4:len(raw)-4]...)
+	for _, stack := range witnesses {
+		tx = append(tx, byte(len(stack)))
+		for _, item := range stack {
+			tx = append(tx, byte(len(item)))
+			tx = append(tx, item...)
+		}
+	}
+	return append(tx, raw[len(raw)-4:]...)
+}
+
+func TestAnalyzeBlock(t *testing.T) {
+	p2wpkh, _ := hex.DecodeString("0014751e76e8199196d454941c45d1b3a323f1433bd6")
+	p2tr, _ := hex.DecodeString("512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	p2pkh, _ := hex.DecodeString("76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac")
+
+	coinbase := &transaction{
+		Version: 2,
+		Inputs:  []input{{index: 0xffffffff, unlockingScript: []byte{0x03, 0x40, 0xd1, 0x0c}, sequence: SequenceFinal}},
+		Outputs: []output{{value: 312500000 + 10000, lockingScript: p2wpkh}},
+	}
+
+	// Three inputs: a legacy one, a P2WPKH and a taproot key path spend
+	spend := &transaction{
+		Version: 2,
+		Inputs:  []input{{index: 0, sequence: SequenceFinal}, {index: 1, sequence: SequenceFinal}, {index: 2, sequence: SequenceFinal}},
+		Outputs: []output{{value: 1000, lockingScript: p2tr}, {value: 1000, lockingScript: p2pkh}},
+	}
+	spendTx := withWitness(spend.ToHex(), nil, [][]byte{make([]byte, 72), make([]byte, 33)}, [][]byte{make([]byte, 64)})
+
+	raw := make([]byte, 80)
+	raw[3] = 0x20
+	raw = append(raw, 2)
+	raw = append(raw, coinbase.ToHex()...)
+	raw = append(raw, spendTx...)
+
+	m, err := AnalyzeBlock(raw, 840000, nil)
+	require.NoError(t, err)
+
+	parsed, _ := parseTx(spendTx)
+
+	assert.Equal(t, 2, m.Txs)
+	assert.Equal(t, len(raw), m.Size)
+	assert.Equal(t, 81*4+len(coinbase.ToHex())*4+parsed.weight(), m.Weight)
+	assert.Equal(t, len(raw)-(len(spendTx)-len(spend.ToHex())), m.StrippedSize)
+	assert.InDelta(t, float64(m.Weight)/4000000, m.WeightUtilization, 1e-12)
+	assert.Equal(t, 1, m.SegwitTxs)
+	assert.Equal(t, 3, m.Inputs)
+	assert.Equal(t, 2, m.SegwitInputs)
+	assert.Equal(t, 1, m.TaprootInputs)
+	assert.Equal(t, 3, m.Outputs)
+	assert.Equal(t, 2, m.SegwitOutputs)
+	assert.Equal(t, 1, m.TaprootOutputs)
+	assert.InDelta(t, 1.0/3, m.TaprootInputShare, 1e-12)
+	assert.InDelta(t, 2.0/3, m.SegwitOutputShare, 1e-12)
+	assert.Equal(t, uint64(10000), m.Fees)
+	assert.InDelta(t, 10000/float64(parsed.vsize()), m.AvgFeeRate, 1e-12)
+}
+
+func TestIsTaprootWitness(t *testing.T) {
+	control := append([]byte{0xc1}, make([]byte, 64)...)
+
+	assert.True(t, isTaprootWitness([][]byte{make([]byte, 65)}))
+	assert.True(t, isTaprootWitness([][]byte{make([]byte, 64), {0x50, 0x01}}))
+	assert.True(t, isTaprootWitness([][]byte{{0x01}, {0x51}, control}))
+	assert.False(t, isTaprootWitness([][]byte{make([]byte, 72), make([]byte, 33)}))
+	assert.False(t, isTaprootWitness([][]byte{{0x51}, control[:40]}))
+	assert.False(t, isTaprootWitness(nil))
+}
@@ -0,0 +1,348 @@
+package bitcoin
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+var errShortBuffer = errors.New("unexpected end of data")
+
+// byteReader reads bitcoin wire encoded values from a byte slice. The first error is sticky.
+type byteReader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *byteReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.b) {
+		r.err = errShortBuffer
+		return nil
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *byteReader) uint32() uint32 {
+	b := r.read(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *byteReader) uint64() uint64 {
+	b := r.read(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (r *byteReader) varInt() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.b) {
+		r.err = errShortBuffer
+		return 0
+	}
+	var size int
+	switch r.b[r.pos] {
+	case 0xfd:
+		size = 3
+	case 0xfe:
+		size = 5
+	case 0xff:
+		size = 9
+	default:
+		size = 1
+	}
+	if r.pos+size > len(r.b) {
+		r.err = errShortBuffer
+		return 0
+	}
+	v, _ := cryptolib.DecodeVarInt(r.b[r.pos:])
+	r.pos += size
+	return v
+}
+
+// count reads a varint element count and checks it against the remaining data,
+// where each element occupies at least minSize bytes.
+func (r *byteReader) count(minSize int) int {
+	n := r.varInt()
+	if r.err == nil && n > uint64((len(r.b)-r.pos)/minSize) {
+		r.err = fmt.Errorf("element count %d exceeds remaining data", n)
+		return 0
+	}
+	return int(n)
+}
+
+// hashString returns the conventional (byte reversed) hex representation of a double sha256 hash.
+func hashString(b []byte) string {
+	return hex.EncodeToString(cryptolib.ReverseBytes(cryptolib.Sha256d(b)))
+}
+
+type parsedInput struct {
+	PrevTxID  string
+	PrevIndex uint32
+	ScriptSig []byte
+	Sequence  uint32
+	Witness   [][]byte
+}
+
+type parsedOutput struct {
+	Value        uint64
+	ScriptPubKey []byte
+}
+
+// parsedTx is a transaction decoded from its wire serialization.
+type parsedTx struct {
+	TxID     string
+	WTxID    string // hash of the full serialization, equal to TxID without witness data
+	Version  int32
+	Inputs   []parsedInput
+	Outputs  []parsedOutput
+	LockTime uint32
+	Raw      []byte // full serialization including any witness data
+}
+
+// parsedBlock is a block decoded from its wire serialization.
+type parsedBlock struct {
+	Hash       string
+	Version    int32
+	PrevHash   string
+	MerkleRoot string
+	Time       uint32
+	Bits       uint32
+	Nonce      uint32
+	Header     []byte
+	Txs        []*parsedTx
+}
+
+func readTx(r *byteReader) *parsedTx {
+	start := r.pos
+	tx := &parsedTx{Version: int32(r.uint32())}
+	if r.err != nil {
+		return nil
+	}
+
+	// Segwit serialization: a zero marker byte followed by a flag of 1
+	segwit := false
+	if r.err == nil && r.pos+1 < len(r.b) && r.b[r.pos] == 0x00 && r.b[r.pos+1] == 0x01 {
+		segwit = true
+		r.pos += 2
+	}
+
+	// stripped holds the serialization without marker, flag and witnesses, which is what the txid commits to.
+	stripped := append([]byte{}, r.b[start:start+4]...)
+	ioStart := r.pos
+
+	nIn := r.count(41)
+	tx.Inputs = make([]parsedInput, nIn)
+	for i := range tx.Inputs {
+		in := &tx.Inputs[i]
+		in.PrevTxID = hex.EncodeToString(cryptolib.ReverseBytes(r.read(32)))
+		in.PrevIndex = r.uint32()
+		in.ScriptSig = r.read(int(r.varInt()))
+		in.Sequence = r.uint32()
+	}
+
+	nOut := r.count(9)
+	tx.Outputs = make([]parsedOutput, nOut)
+	for i := range tx.Outputs {
+		out := &tx.Outputs[i]
+		out.Value = r.uint64()
+		out.ScriptPubKey = r.read(int(r.varInt()))
+	}
+
+	if r.err != nil {
+		return nil
+	}
+	stripped = append(stripped, r.b[ioStart:r.pos]...)
+
+	if segwit {
+		for i := range tx.Inputs {
+			items := r.count(1)
+			for j := 0; j < items; j++ {
+				tx.Inputs[i].Witness = append(tx.Inputs[i].Witness, r.read(int(r.varInt())))
+			}
+		}
+	}
+
+	lockTime := r.read(4)
+	if r.err != nil {
+		return nil
+	}
+	tx.LockTime = binary.LittleEndian.Uint32(lockTime)
+	stripped = append(stripped, lockTime...)
+
+	tx.Raw = r.b[start:r.pos]
+	tx.TxID = hashString(stripped)
+	tx.WTxID = hashString(tx.Raw)
+
+	return tx
+}
+
+// vsize returns the virtual size of the transaction, a quarter of its weight rounded up.
+func (tx *parsedTx) vsize() int {
+	return (tx.weight() + 3) / 4
+}
+
+// weight returns the weight of the transaction, its size without witness data times three plus its full
+// size.
+func (tx *parsedTx) weight() int {
+	witness := 0
+	for _, in := range tx.Inputs {
+		if len(in.Witness) == 0 {
+			continue
+		}
+		witness += len(cryptolib.VarInt(uint64(len(in.Witness))))
+		for _, item := range in.Witness {
+			witness += len(cryptolib.VarInt(uint64(len(item)))) + len(item)
+		}
+	}
+
+	// Segwit serializations carry a marker and flag byte, and an empty stack for inputs without witness
+	if witness > 0 {
+		for _, in := range tx.Inputs {
+			if len(in.Witness) == 0 {
+				witness++
+			}
+		}
+		witness += 2
+	}
+
+	return (len(tx.Raw)-witness)*4 + witness
+}
+
+// parseTx decodes a single serialized transaction.
+func parseTx(b []byte) (*parsedTx, error) {
+	r := &byteReader{b: b}
+	tx := readTx(r)
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", r.err)
+	}
+	if r.pos != len(b) {
+		return nil, fmt.Errorf("failed to parse transaction: %d trailing bytes", len(b)-r.pos)
+	}
+	return tx, nil
+}
+
+// TransactionIDs computes the txid and wtxid of a serialized transaction. Unlike the txid, the wtxid
+// commits to the witness data, so a third party changing a witness without invalidating it changes the
+// wtxid only; the two identify the same transaction for confirmation tracking, but only the wtxid
+// identifies the exact bytes relayed.
+func TransactionIDs(txHex string) (txid, wtxid string, err error) {
+	raw, err := hex.DecodeString(txHex)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid transaction hex: %w", err)
+	}
+
+	tx, err := parseTx(raw)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tx.TxID, tx.WTxID, nil
+}
+
+// parseBlockHeader decodes an 80 byte block header.
+func parseBlockHeader(b []byte) (*parsedBlock, error) {
+	if len(b) < 80 {
+		return nil, fmt.Errorf("block header too short: %d bytes", len(b))
+	}
+	h := b[:80]
+
+	return &parsedBlock{
+		Hash:       hashString(h),
+		Version:    int32(binary.LittleEndian.Uint32(h[0:4])),
+		PrevHash:   hex.EncodeToString(cryptolib.ReverseBytes(h[4:36])),
+		MerkleRoot: hex.EncodeToString(cryptolib.ReverseBytes(h[36:68])),
+		Time:       binary.LittleEndian.Uint32(h[68:72]),
+		Bits:       binary.LittleEndian.Uint32(h[72:76]),
+		Nonce:      binary.LittleEndian.Uint32(h[76:80]),
+		Header:     h,
+	}, nil
+}
+
+// parseBlock decodes a serialized block including all of its transactions.
+func parseBlock(b []byte) (*parsedBlock, error) {
+	block, err := parseBlockHeader(b)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &byteReader{b: b, pos: 80}
+	n := r.count(60)
+	block.Txs = make([]*parsedTx, 0, n)
+	for i := 0; i < n; i++ {
+		tx := readTx(r)
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to parse transaction %d of block %s: %w", i, block.Hash, r.err)
+		}
+		block.Txs = append(block.Txs, tx)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse block %s: %w", block.Hash, r.err)
+	}
+
+	return block, nil
+}
+
+// merkleRoot computes the merkle root of a block from its txids, returning it in the conventional hex representation.
+func merkleRoot(txids []string) (string, error) {
+	if len(txids) == 0 {
+		return "", errors.New("no transactions")
+	}
+
+	level := make([][]byte, len(txids))
+	for i, txid := range txids {
+		h, err := hex.DecodeString(txid)
+		if err != nil || len(h) != 32 {
+			return "", fmt.Errorf("invalid txid %q", txid)
+		}
+		level[i] = cryptolib.ReverseBytes(h)
+	}
+
+	for len(level) > 1 {
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+
+		next := make([][]byte, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, cryptolib.Sha256d(append(append([]byte{}, level[i]...), level[i+1]...)))
+		}
+		level = next
+	}
+
+	return hex.EncodeToString(cryptolib.ReverseBytes(level[0])), nil
+}
+
+// verify checks that the merkle root in the header commits to the parsed transactions.
+func (block *parsedBlock) verify() error {
+	txids := make([]string, len(block.Txs))
+	for i, tx := range block.Txs {
+		txids[i] = tx.TxID
+	}
+
+	root, err := merkleRoot(txids)
+	if err != nil {
+		return fmt.Errorf("block %s: %w", block.Hash, err)
+	}
+
+	if root != block.MerkleRoot {
+		return fmt.Errorf("block %s: merkle root mismatch, header has %s but transactions hash to %s", block.Hash, block.MerkleRoot, root)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseBlockVerify(t *testing.T) {
+	raw, _ := hex.DecodeString(genesisBlockHex)
+
+	block, err := parseBlock(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := block.verify(); err != nil {
+		t.Error(err)
+	}
+
+	// Flip a bit in the coinbase output value
+	raw[len(raw)-80] ^= 0x01
+
+	block, err = parseBlock(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := block.verify(); err == nil {
+		t.Error("Expected merkle root mismatch for corrupted block")
+	}
+}
+
+func TestTransactionIDs(t *testing.T) {
+	genesisCoinbase := "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+	txid, wtxid, err := TransactionIDs(genesisCoinbase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if txid != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" || wtxid != txid {
+		t.Errorf("unexpected ids %s %s", txid, wtxid)
+	}
+
+	// The same transaction with marker, flag and a witness of one item
+	witnessTx := genesisCoinbase[:8] + "0001" + genesisCoinbase[8:len(genesisCoinbase)-8] + "0102abcd" + genesisCoinbase[len(genesisCoinbase)-8:]
+
+	segwitTxID, segwitWTxID, err := TransactionIDs(witnessTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := hex.DecodeString(witnessTx)
+	if segwitTxID != txid || segwitWTxID != hashString(raw) {
+		t.Errorf("unexpected segwit ids %s %s", segwitTxID, segwitWTxID)
+	}
+
+	if _, _, err := TransactionIDs("0100"); err == nil {
+		t.Error("expected an error for a truncated transaction")
+	}
+}
@@ -0,0 +1,201 @@
+package bitcoin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// medianTimeSpan is the number of blocks whose timestamps the median time past of a block is taken over.
+const medianTimeSpan = 11
+
+// blockTimeCacheDepth is the number of confirmations after which BlockTimes caches a block, deep enough not
+// to be reorged out.
+const blockTimeCacheDepth = 6
+
+// targetBlockInterval is the block interval the network adjusts the difficulty for.
+const targetBlockInterval = 10 * time.Minute
+
+// ErrBeforeGenesis is returned by BlockTimes.HeightAt for times before the genesis block.
+var ErrBeforeGenesis = errors.New("time is before the genesis block")
+
+// BlockTime is the timestamp and median time past of a block of the active chain.
+type BlockTime struct {
+	Height     int
+	Hash       string
+	Time       time.Time // the timestamp set by the miner, which need not increase from block to block
+	MedianTime time.Time // median time past, which never decreases
+}
+
+// MedianTimePast returns the median of the last 11 of timestamps, in chain order, as the node computes the
+// median time past of a block from its own timestamp and those of the 10 blocks before it. Time locks of
+// transactions in the next block are checked against the median time past of the tip.
+func MedianTimePast(timestamps []time.Time) time.Time {
+	if len(timestamps) == 0 {
+		return time.Time{}
+	}
+	if len(timestamps) > medianTimeSpan {
+		timestamps = timestamps[len(timestamps)-medianTimeSpan:]
+	}
+
+	sorted := append([]time.Time(nil), timestamps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	return sorted[len(sorted)/2]
+}
+
+// BlockTimes translates between heights and times of the active chain. Times are compared with the median
+// time past of blocks, which, unlike their timestamps, never decreases, so that heights can be found by
+// binary search and agree with how time locks are checked. Blocks deep enough not to be reorged out are
+// cached, making repeated lookups cheap.
+type BlockTimes struct {
+	b     *Bitcoind
+	mu    sync.Mutex
+	cache map[int]BlockTime
+}
+
+// NewBlockTimes returns a BlockTimes with an empty cache.
+func NewBlockTimes(b *Bitcoind) *BlockTimes {
+	return &BlockTimes{b: b, cache: make(map[int]BlockTime)}
+}
+
+// Tip returns the block time of the tip.
+func (t *BlockTimes) Tip() (BlockTime, error) {
+	info, err := t.b.GetBlockchainInfo()
+	if err != nil {
+		return BlockTime{}, err
+	}
+
+	return t.At(int(info.Blocks))
+}
+
+// At returns the block time of the block at height.
+func (t *BlockTimes) At(height int) (BlockTime, error) {
+	t.mu.Lock()
+	bt, ok := t.cache[height]
+	t.mu.Unlock()
+	if ok {
+		return bt, nil
+	}
+
+	hash, err := t.b.GetBlockHash(height)
+	if err != nil {
+		return BlockTime{}, err
+	}
+	if hash == "" {
+		return BlockTime{}, fmt.Errorf("no block at height %d", height)
+	}
+
+	header, err := t.b.GetBlockHeader(hash)
+	if err != nil {
+		return BlockTime{}, err
+	}
+	if header == nil {
+		return BlockTime{}, fmt.Errorf("block %s not found", hash)
+	}
+
+	bt = BlockTime{
+		Height:     height,
+		Hash:       hash,
+		Time:       time.Unix(int64(header.Time), 0),
+		MedianTime: time.Unix(int64(header.MedianTime), 0),
+	}
+
+	if header.Confirmations >= blockTimeCacheDepth {
+		t.mu.Lock()
+		t.cache[height] = bt
+		t.mu.Unlock()
+	}
+
+	return bt, nil
+}
+
+// HeightAt returns the height of the last block whose median time past is not after ts, the tip for times
+// after its median time past, and ErrBeforeGenesis for times before the genesis block.
+func (t *BlockTimes) HeightAt(ts time.Time) (int, error) {
+	tip, err := t.Tip()
+	if err != nil {
+		return 0, err
+	}
+	if !tip.MedianTime.After(ts) {
+		return tip.Height, nil
+	}
+
+	// The last height whose median time past is not after ts lies in [lo, hi), once known to exist
+	lo, hi := -1, tip.Height
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+
+		bt, err := t.At(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if bt.MedianTime.After(ts) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	if lo < 0 {
+		return 0, fmt.Errorf("%w: %s", ErrBeforeGenesis, ts.UTC().Format(time.RFC3339))
+	}
+
+	return lo, nil
+}
+
+// EstimateHeightAt returns the height of the first block whose median time past will be at or after ts,
+// assuming 10 minute blocks, e.g. to report the height by which a time lock of ts will have expired. For
+// times up to the median time past of the tip it is the height HeightAt finds, plus one if that block's
+// median time past is before ts.
+func (t *BlockTimes) EstimateHeightAt(ts time.Time) (int, error) {
+	tip, err := t.Tip()
+	if err != nil {
+		return 0, err
+	}
+
+	if ts.After(tip.MedianTime) {
+		blocks := (ts.Sub(tip.MedianTime) + targetBlockInterval - 1) / targetBlockInterval
+		return tip.Height + int(blocks), nil
+	}
+
+	height, err := t.HeightAt(ts)
+	if errors.Is(err, ErrBeforeGenesis) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	bt, err := t.At(height)
+	if err != nil {
+		return 0, err
+	}
+	if bt.MedianTime.Before(ts) {
+		height++
+	}
+
+	return height, nil
+}
+
+// EstimateTimeAt returns when the block at height was mined, its timestamp, or for heights beyond the tip
+// when it will be, assuming 10 minute blocks from now.
+func (t *BlockTimes) EstimateTimeAt(height int) (time.Time, error) {
+	tip, err := t.Tip()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if height <= tip.Height {
+		bt, err := t.At(height)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return bt.Time, nil
+	}
+
+	return t.b.client.clock.Now().Add(time.Duration(height-tip.Height) * targetBlockInterval), nil
+}
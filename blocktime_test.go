@@ -0,0 +1,125 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMedianTimePast(t *testing.T) {
+	var timestamps []time.Time
+	for _, s := range []int64{100, 90, 130, 120, 110, 150, 140, 160, 170, 180, 200, 190} {
+		timestamps = append(timestamps, time.Unix(s, 0))
+	}
+
+	// The median of the last 11, 90 to 200 without 100
+	assert.Equal(t, time.Unix(150, 0), MedianTimePast(timestamps))
+	assert.Equal(t, time.Unix(100, 0), MedianTimePast(timestamps[:2]))
+	assert.True(t, MedianTimePast(nil).IsZero())
+}
+
+func TestBlockTimes(t *testing.T) {
+	const tip = 100
+	genesis := time.Unix(1600000000, 0)
+
+	// Blocks every 10 minutes, with the timestamp of every third block 15 minutes early
+	timestamps := make([]time.Time, tip+1)
+	medianTimes := make([]time.Time, tip+1)
+	for h := range timestamps {
+		timestamps[h] = genesis.Add(time.Duration(h) * 10 * time.Minute)
+		if h%3 == 1 {
+			timestamps[h] = timestamps[h].Add(-15 * time.Minute)
+		}
+		medianTimes[h] = MedianTimePast(timestamps[:h+1])
+	}
+
+	var headers int32
+	clock := NewFakeClock(genesis.Add(1000 * time.Minute))
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		reply := func(result string) {
+			_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+		}
+
+		switch req.Method {
+		case "getblockchaininfo":
+			reply(fmt.Sprintf(`{"blocks":%d,"mediantime":%d}`, tip, medianTimes[tip].Unix()))
+		case "getblockhash":
+			reply(fmt.Sprintf(`"%064s"`, string(req.Params[0])))
+		case "getblockheader":
+			atomic.AddInt32(&headers, 1)
+			var hash string
+			_ = json.Unmarshal(req.Params[0], &hash)
+			h, _ := strconv.Atoi(hash[len(hash)-3:])
+			reply(fmt.Sprintf(`{"hash":"%s","height":%d,"confirmations":%d,"time":%d,"mediantime":%d}`, hash, h, tip-h+1,
+				timestamps[h].Unix(), medianTimes[h].Unix()))
+		}
+	}, WithClock(clock))
+	defer closeServer()
+
+	bt := NewBlockTimes(b)
+
+	block, err := bt.At(42)
+	require.NoError(t, err)
+	assert.Equal(t, BlockTime{Height: 42, Hash: fmt.Sprintf("%064d", 42), Time: timestamps[42], MedianTime: medianTimes[42]}, block)
+
+	// The last of the blocks sharing a median time is found for it, the one before them just before it
+	for h := 1; h <= tip; h++ {
+		last := h
+		for last < tip && medianTimes[last+1].Equal(medianTimes[h]) {
+			last++
+		}
+
+		height, err := bt.HeightAt(medianTimes[h])
+		require.NoError(t, err)
+		assert.Equal(t, last, height)
+
+		if medianTimes[h].After(medianTimes[h-1]) {
+			height, err = bt.HeightAt(medianTimes[h].Add(-time.Second))
+			require.NoError(t, err)
+			assert.Equal(t, h-1, height)
+
+			height, err = bt.EstimateHeightAt(medianTimes[h].Add(-time.Second))
+			require.NoError(t, err)
+			assert.Equal(t, h, height)
+		}
+	}
+
+	_, err = bt.HeightAt(genesis.Add(-time.Second))
+	assert.ErrorIs(t, err, ErrBeforeGenesis)
+
+	height, err := bt.HeightAt(genesis.Add(time.Hour * 1000))
+	require.NoError(t, err)
+	assert.Equal(t, tip, height)
+
+	// Deep blocks are cached, the ones near the tip looked up again
+	before := atomic.LoadInt32(&headers)
+	_, err = bt.HeightAt(medianTimes[50])
+	require.NoError(t, err)
+	assert.LessOrEqual(t, atomic.LoadInt32(&headers)-before, int32(1)) // the tip
+
+	// In the future, at 10 minutes a block
+	height, err = bt.EstimateHeightAt(medianTimes[tip].Add(25 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, tip+3, height)
+
+	at, err := bt.EstimateTimeAt(tip + 6)
+	require.NoError(t, err)
+	assert.Equal(t, clock.Now().Add(time.Hour), at)
+
+	at, err = bt.EstimateTimeAt(10)
+	require.NoError(t, err)
+	assert.Equal(t, timestamps[10], at)
+}
@@ -0,0 +1,182 @@
+package bitcoin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrChaosConnectionDropped is returned for requests whose connection a ChaosTransport drops.
+var ErrChaosConnectionDropped = errors.New("chaos: connection dropped")
+
+// chaosDefaultHang is how long a timed out request hangs when its context has no deadline.
+const chaosDefaultHang = 5 * time.Minute
+
+// ChaosStats counts the faults injected by a ChaosTransport.
+type ChaosStats struct {
+	Requests      int
+	Timeouts      int
+	ServerErrors  int
+	Malformed     int
+	Dropped       int
+	LostResponses int
+}
+
+// ChaosTransport is an http.RoundTripper injecting faults into a share of the requests, for testing retry
+// and failover logic against a real or mocked node in CI. Install it with WithTransport. Rates are
+// fractions between 0 and 1; every request gets at most one fault, tried in the order of the fields.
+type ChaosTransport struct {
+	Transport http.RoundTripper // the transport faults are injected into, nil means http.DefaultTransport
+
+	Latency time.Duration // added to every request
+	Jitter  time.Duration // up to this much is added on top of Latency
+
+	TimeoutRate      float64 // the request hangs until its context is done or Hang has passed
+	ServerErrorRate  float64 // a 500 Internal Server Error is returned without reaching the node
+	MalformedRate    float64 // the node's response is cut in half, leaving invalid JSON
+	DropRate         float64 // the connection drops before the request reaches the node
+	LostResponseRate float64 // the connection drops after the node processed the request
+
+	Hang time.Duration // 0 means 5 minutes
+	Seed int64         // seeds the choice of faults, so that a failing run can be reproduced
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	stats ChaosStats
+}
+
+// Stats returns the number of requests seen and of faults injected so far.
+func (c *ChaosTransport) Stats() ChaosStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+type chaosFault int
+
+const (
+	chaosNone chaosFault = iota
+	chaosTimeout
+	chaosServerError
+	chaosMalformed
+	chaosDrop
+	chaosLostResponse
+)
+
+// pick decides the fault and delay of the next request.
+func (c *ChaosTransport) pick() (chaosFault, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rnd == nil {
+		c.rnd = rand.New(rand.NewSource(c.Seed))
+	}
+
+	c.stats.Requests++
+
+	delay := c.Latency
+	if c.Jitter > 0 {
+		delay += time.Duration(c.rnd.Int63n(int64(c.Jitter)))
+	}
+
+	faults := []struct {
+		rate  float64
+		fault chaosFault
+		count *int
+	}{
+		{c.TimeoutRate, chaosTimeout, &c.stats.Timeouts},
+		{c.ServerErrorRate, chaosServerError, &c.stats.ServerErrors},
+		{c.MalformedRate, chaosMalformed, &c.stats.Malformed},
+		{c.DropRate, chaosDrop, &c.stats.Dropped},
+		{c.LostResponseRate, chaosLostResponse, &c.stats.LostResponses},
+	}
+
+	// Draw once so that the rates add up instead of shadowing each other
+	x := c.rnd.Float64()
+	for _, f := range faults {
+		if x < f.rate {
+			*f.count++
+			return f.fault, delay
+		}
+		x -= f.rate
+	}
+
+	return chaosNone, delay
+}
+
+// RoundTrip implements http.RoundTripper.
+func (c *ChaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault, delay := c.pick()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	switch fault {
+	case chaosTimeout:
+		hang := c.Hang
+		if hang == 0 {
+			hang = chaosDefaultHang
+		}
+		select {
+		case <-time.After(hang):
+			return nil, ErrTimeout
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+	case chaosServerError:
+		return &http.Response{
+			Status:        "500 Internal Server Error",
+			StatusCode:    http.StatusInternalServerError,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"text/plain"}},
+			Body:          io.NopCloser(bytes.NewReader(nil)),
+			ContentLength: 0,
+			Request:       req,
+		}, nil
+
+	case chaosDrop:
+		return nil, ErrChaosConnectionDropped
+	}
+
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fault {
+	case chaosMalformed:
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		data = data[:len(data)/2]
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		resp.ContentLength = int64(len(data))
+		resp.Header.Del("Content-Length")
+
+	case chaosLostResponse:
+		resp.Body.Close()
+		return nil, ErrChaosConnectionDropped
+	}
+
+	return resp, nil
+}
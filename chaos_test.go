@@ -0,0 +1,88 @@
+package bitcoin
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosTransport(t *testing.T) {
+	var hits int32
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = w.Write([]byte(`{"result":42,"error":null,"id":1}`))
+	})
+	defer done()
+
+	tests := []struct {
+		name    string
+		chaos   *ChaosTransport
+		reached bool
+		check   func(t *testing.T, err error)
+	}{
+		{"none", &ChaosTransport{}, true, func(t *testing.T, err error) {
+			assert.NoError(t, err)
+		}},
+		{"timeout", &ChaosTransport{TimeoutRate: 1, Hang: 10 * time.Millisecond}, false, func(t *testing.T, err error) {
+			assert.True(t, errors.Is(err, ErrTimeout))
+		}},
+		{"server error", &ChaosTransport{ServerErrorRate: 1}, false, func(t *testing.T, err error) {
+			assert.EqualError(t, err, "unexpected response code 500: HTTP error: 500 Internal Server Error")
+		}},
+		{"malformed", &ChaosTransport{MalformedRate: 1}, true, func(t *testing.T, err error) {
+			assert.Contains(t, err.Error(), "failed to unmarshal response")
+		}},
+		{"drop", &ChaosTransport{DropRate: 1}, false, func(t *testing.T, err error) {
+			assert.True(t, errors.Is(err, ErrChaosConnectionDropped))
+		}},
+		{"lost response", &ChaosTransport{LostResponseRate: 1}, true, func(t *testing.T, err error) {
+			assert.True(t, errors.Is(err, ErrChaosConnectionDropped))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := atomic.LoadInt32(&hits)
+
+			_, err := c.derive(WithTransport(tt.chaos)).call("getblockcount", nil)
+			tt.check(t, err)
+
+			assert.Equal(t, tt.reached, atomic.LoadInt32(&hits) > before)
+			assert.Equal(t, 1, tt.chaos.Stats().Requests)
+		})
+	}
+
+	// The client the transports were derived from is unaffected
+	_, err := c.call("getblockcount", nil)
+	assert.NoError(t, err)
+}
+
+func TestChaosTransportSeed(t *testing.T) {
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":42,"error":null,"id":1}`))
+	})
+	defer done()
+
+	run := func() ChaosStats {
+		chaos := &ChaosTransport{ServerErrorRate: 0.2, DropRate: 0.2, MalformedRate: 0.1, Seed: 7}
+		client := c.derive(WithTransport(chaos))
+		for i := 0; i < 200; i++ {
+			_, _ = client.call("getblockcount", nil)
+		}
+		return chaos.Stats()
+	}
+
+	stats := run()
+	require.Equal(t, stats, run())
+
+	assert.Equal(t, 200, stats.Requests)
+	assert.InDelta(t, 40, stats.ServerErrors, 20)
+	assert.InDelta(t, 40, stats.Dropped, 20)
+	assert.InDelta(t, 20, stats.Malformed, 15)
+	assert.Zero(t, stats.Timeouts)
+}
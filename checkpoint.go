@@ -0,0 +1,191 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cursor records how far a sync component has progressed through the chain.
+type Cursor struct {
+	Height uint64 `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// Checkpointer is implemented by long-running sync components, Backfiller, TxIndex and WalletTxStream, so
+// that their progress can be persisted and restored after a crash. Cursor returns the last block that was
+// fully processed and SetCursor makes the component resume with the block following the given cursor.
+type Checkpointer interface {
+	Cursor() Cursor
+	SetCursor(c Cursor) error
+}
+
+// CursorStore persists named cursors.
+type CursorStore interface {
+	SaveCursor(name string, c Cursor) error
+	// LoadCursor returns the cursor stored under name, or false if there is none.
+	LoadCursor(name string) (Cursor, bool, error)
+}
+
+// MemoryCursorStore is a CursorStore that keeps cursors in memory, which is mostly useful for tests.
+type MemoryCursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]Cursor
+}
+
+// NewMemoryCursorStore returns an empty MemoryCursorStore.
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{cursors: make(map[string]Cursor)}
+}
+
+func (s *MemoryCursorStore) SaveCursor(name string, c Cursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursors[name] = c
+	return nil
+}
+
+func (s *MemoryCursorStore) LoadCursor(name string) (Cursor, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, found := s.cursors[name]
+	return c, found, nil
+}
+
+// FileCursorStore is a CursorStore that keeps each cursor as a JSON file in a directory. Files are
+// replaced atomically so a crash during SaveCursor never leaves a corrupt cursor behind.
+type FileCursorStore struct {
+	dir string
+}
+
+// NewFileCursorStore returns a FileCursorStore using the given directory, creating it if needed.
+func NewFileCursorStore(dir string) (*FileCursorStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cursor directory: %w", err)
+	}
+
+	return &FileCursorStore{dir: dir}, nil
+}
+
+func (s *FileCursorStore) SaveCursor(name string, c Cursor) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.dir, name+".json", data)
+}
+
+// writeFileAtomic replaces the file name in dir with data, so that a crash leaves either the old or the
+// new contents behind.
+func writeFileAtomic(dir, name string, data []byte) error {
+	f, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync %s: %w", name, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", name, err)
+	}
+
+	return os.Rename(f.Name(), filepath.Join(dir, name))
+}
+
+func (s *FileCursorStore) LoadCursor(name string) (Cursor, bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Cursor{}, false, nil
+	}
+	if err != nil {
+		return Cursor{}, false, fmt.Errorf("failed to read cursor file: %w", err)
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, false, fmt.Errorf("failed to decode cursor file: %w", err)
+	}
+
+	return c, true, nil
+}
+
+// Checkpoint saves the progress of a component under the given name. Nothing is saved before the component
+// processed its first block.
+func Checkpoint(store CursorStore, name string, cp Checkpointer) error {
+	c := cp.Cursor()
+	if c.Hash == "" {
+		return nil
+	}
+
+	return store.SaveCursor(name, c)
+}
+
+// Restore loads the cursor stored under the given name, checks it against the node's active chain and
+// hands it to the component. If the stored block was reorged out while the component was down, the
+// component is rewound to the last block that is still part of the active chain, so no block is missed.
+func (b *Bitcoind) Restore(store CursorStore, name string, cp Checkpointer) error {
+	c, found, err := store.LoadCursor(name)
+	if err != nil || !found {
+		return err
+	}
+
+	c, err = b.ResolveCursor(c)
+	if err != nil {
+		return err
+	}
+
+	return cp.SetCursor(c)
+}
+
+// ResolveCursor returns the given cursor if its block is still on the active chain. Otherwise it walks
+// back through the cursor's branch and returns the highest of its blocks that is on the active chain, the
+// fork point, or the tip if the node's chain is shorter than the cursor, e.g. after it was rolled back. It
+// fails if no block of the branch is on the active chain.
+func (b *Bitcoind) ResolveCursor(c Cursor) (Cursor, error) {
+	// The active chain may have changed since it was last read, so its hashes are not taken from the cache
+	var tip uint64
+	if err := b.uncachedCall("getblockcount", nil, &tip); err != nil {
+		return Cursor{}, err
+	}
+
+	start := c
+	for {
+		if c.Height <= tip {
+			var hash string
+			if err := b.uncachedCall("getblockhash", []interface{}{c.Height}, &hash); err != nil {
+				return Cursor{}, err
+			}
+
+			if hash == c.Hash {
+				return c, nil
+			}
+		}
+
+		header, err := b.GetBlockHeader(c.Hash)
+		if err != nil {
+			return Cursor{}, fmt.Errorf("cursor block %s is not on the active chain and could not be resolved: %w", c.Hash, err)
+		}
+		if header == nil {
+			return Cursor{}, fmt.Errorf("cursor block %s is unknown to the node", c.Hash)
+		}
+
+		if c.Height == 0 || header.PreviousBlockHash == "" {
+			return Cursor{}, fmt.Errorf("no block of the branch of cursor block %s is on the active chain", start.Hash)
+		}
+
+		c = Cursor{Height: c.Height - 1, Hash: header.PreviousBlockHash}
+	}
+}
@@ -0,0 +1,163 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCursorStore(t *testing.T) {
+	store, err := NewFileCursorStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, found, err := store.LoadCursor("blocks")
+	if err != nil || found {
+		t.Fatalf("Expected no cursor, got found=%v err=%v", found, err)
+	}
+
+	expected := Cursor{Height: 100, Hash: "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"}
+	if err := store.SaveCursor("blocks", expected); err != nil {
+		t.Fatal(err)
+	}
+
+	c, found, err := store.LoadCursor("blocks")
+	if err != nil || !found {
+		t.Fatalf("Expected cursor, got found=%v err=%v", found, err)
+	}
+
+	if c != expected {
+		t.Errorf("Expected %+v, got %+v", expected, c)
+	}
+}
+
+// handleReorgNode serves a chain whose active part is a0-a3, with the stale branch b2-b4 forking off after a1.
+// a4 and a5 extend the active chain but were rolled back, x0-x1 are the blocks of another chain.
+func handleReorgNode(w http.ResponseWriter, r *http.Request) {
+	active := []string{"a0", "a1", "a2", "a3"}
+	parents := map[string]string{"a1": "a0", "a2": "a1", "a3": "a2", "a4": "a3", "a5": "a4", "b2": "a1", "b3": "b2", "b4": "b3", "x1": "x0"}
+	heights := map[string]int{"a0": 0, "a1": 1, "a2": 2, "a3": 3, "a4": 4, "a5": 5, "b2": 2, "b3": 3, "b4": 4, "x0": 0, "x1": 1}
+
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	params, _ := req.Params.([]interface{})
+
+	switch req.Method {
+	case "getblockcount":
+		_, _ = fmt.Fprintf(w, `{"result":%d,"error":null,"id":1}`, len(active)-1)
+	case "getblockhash":
+		if h := int(params[0].(float64)); h < len(active) {
+			_, _ = fmt.Fprintf(w, `{"result":%q,"error":null,"id":1}`, active[h])
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":null,"error":{"code":-8,"message":"Block height out of range"},"id":1}`))
+	case "getblockheader":
+		hash := params[0].(string)
+		if h, found := heights[hash]; found {
+			_, _ = fmt.Fprintf(w, `{"result":{"hash":%q,"height":%d,"previousblockhash":%q},"error":null,"id":1}`, hash, h, parents[hash])
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":null,"error":{"code":-5,"message":"Block not found"},"id":1}`))
+	}
+}
+
+func TestResolveCursor(t *testing.T) {
+	b, done := newTestNode(t, handleReorgNode)
+	defer done()
+
+	tests := []struct {
+		cursor   Cursor
+		expected Cursor
+	}{
+		{Cursor{Height: 3, Hash: "a3"}, Cursor{Height: 3, Hash: "a3"}},
+		{Cursor{Height: 0, Hash: "a0"}, Cursor{Height: 0, Hash: "a0"}},
+		// Reorged out, rewound to the fork point
+		{Cursor{Height: 3, Hash: "b3"}, Cursor{Height: 1, Hash: "a1"}},
+		// On a stale branch longer than the active chain
+		{Cursor{Height: 4, Hash: "b4"}, Cursor{Height: 1, Hash: "a1"}},
+		// Above the tip of a node that was rolled back
+		{Cursor{Height: 5, Hash: "a5"}, Cursor{Height: 3, Hash: "a3"}},
+	}
+
+	for _, test := range tests {
+		c, err := b.ResolveCursor(test.cursor)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, c, "cursor %+v", test.cursor)
+	}
+
+	_, err := b.ResolveCursor(Cursor{Height: 2, Hash: "unknown"})
+	assert.True(t, HasErrorCode(err, RPCInvalidAddressOrKey))
+
+	// No block of another chain matches
+	_, err = b.ResolveCursor(Cursor{Height: 1, Hash: "x1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no block of the branch of cursor block x1 is on the active chain")
+}
+
+func TestResolveCursorUncached(t *testing.T) {
+	var mu sync.Mutex
+	active := []string{"a0", "a1", "a2"}
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params, _ := req.Params.([]interface{})
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch req.Method {
+		case "getblockcount":
+			_, _ = fmt.Fprintf(w, `{"result":%d,"error":null,"id":1}`, len(active)-1)
+		case "getblockhash":
+			_, _ = fmt.Fprintf(w, `{"result":%q,"error":null,"id":1}`, active[int(params[0].(float64))])
+		case "getblockheader":
+			_, _ = fmt.Fprint(w, `{"result":{"hash":"a2","height":2,"previousblockhash":"a1"},"error":null,"id":1}`)
+		}
+	})
+	defer done()
+
+	// The hash at height 2 is cached before a2 is reorged out
+	_, err := b.GetBlockHash(2)
+	require.NoError(t, err)
+
+	mu.Lock()
+	active = []string{"a0", "a1", "b2", "b3"}
+	mu.Unlock()
+
+	resolved, err := b.ResolveCursor(Cursor{Height: 2, Hash: "a2"})
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{Height: 1, Hash: "a1"}, resolved)
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	b, done := newTestNode(t, handleReorgNode)
+	defer done()
+
+	store := NewMemoryCursorStore()
+
+	// Nothing is saved before the first block
+	bf := NewBackfiller(b, 0, 1)
+	require.NoError(t, Checkpoint(store, "backfill", bf))
+	_, found, _ := store.LoadCursor("backfill")
+	assert.False(t, found)
+
+	require.NoError(t, bf.SetCursor(Cursor{Height: 3, Hash: "b3"}))
+	require.NoError(t, Checkpoint(store, "backfill", bf))
+
+	// b3 was reorged out while the application was down
+	restored := NewBackfiller(b, 0, 1)
+	require.NoError(t, b.Restore(store, "backfill", restored))
+	assert.Equal(t, Cursor{Height: 1, Hash: "a1"}, restored.Cursor())
+
+	// Components without a stored cursor are left alone
+	s := NewWalletTxStream(b, Cursor{}, 6)
+	require.NoError(t, b.Restore(store, "wallet", s))
+	assert.Equal(t, Cursor{}, s.Cursor())
+}
@@ -0,0 +1,237 @@
+package bitcoin
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownClient is returned by ClientManager.Get when Lookup has no configuration for a key.
+var ErrUnknownClient = errors.New("no client configured")
+
+// ClientConfig is the configuration of the node of a ClientManager key.
+type ClientConfig struct {
+	Host     string
+	Port     int
+	Path     string
+	User     string
+	Password string
+	UseSSL   bool
+	Options  []Option
+}
+
+// ClientHealth is the health of a client of a ClientManager, judged by its calls. Errors returned by the
+// node do not count as failures, only calls that did not get an answer.
+type ClientHealth struct {
+	Key                 string
+	Healthy             bool // no failure since the last successful call
+	ConsecutiveFailures int
+	LastErr             error
+	LastSuccess         time.Time
+	LastFailure         time.Time
+}
+
+// ClientManager keeps a client per key, e.g. per tenant or per tenant and chain, for platforms serving
+// many customers each with their own node. Clients are created on first use from the configuration
+// returned by Lookup and share one transport, which bounds the number of connections of all clients
+// together. Remove drops a client, e.g. after its credentials changed.
+type ClientManager struct {
+	// Lookup returns the configuration of a key, or an error wrapping ErrUnknownClient.
+	Lookup func(key string) (ClientConfig, error)
+
+	transport *http.Transport
+	sem       chan struct{}
+
+	mu      sync.Mutex
+	clients map[string]*managedClient
+}
+
+type managedClient struct {
+	b      *Bitcoind
+	ready  chan struct{} // closed once b or err is set
+	err    error
+	health ClientHealth
+}
+
+// NewClientManager returns a manager allowing at most maxConns requests, and so connections, at a time
+// across all its clients; 0 means no limit. Like New, it does not verify TLS certificates.
+func NewClientManager(lookup func(key string) (ClientConfig, error), maxConns int) *ClientManager {
+	m := &ClientManager{
+		Lookup: lookup,
+		transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			IdleConnTimeout: 90 * time.Second,
+		},
+		clients: make(map[string]*managedClient),
+	}
+
+	if maxConns > 0 {
+		m.sem = make(chan struct{}, maxConns)
+		m.transport.MaxIdleConns = maxConns
+	}
+
+	return m
+}
+
+// Get returns the client of key, creating it on first use. Concurrent first calls for the same key
+// create a single client; a failed creation is retried by the next call.
+func (m *ClientManager) Get(key string) (*Bitcoind, error) {
+	m.mu.Lock()
+	mc, found := m.clients[key]
+	if !found {
+		mc = &managedClient{ready: make(chan struct{}), health: ClientHealth{Key: key, Healthy: true}}
+		m.clients[key] = mc
+	}
+	m.mu.Unlock()
+
+	if found {
+		<-mc.ready
+		if mc.err == nil {
+			return mc.b, nil
+		}
+		// The creation failed, the key has been removed for the next call to retry
+		return nil, mc.err
+	}
+
+	mc.b, mc.err = m.create(key, mc)
+	close(mc.ready)
+
+	if mc.err != nil {
+		m.mu.Lock()
+		if m.clients[key] == mc {
+			delete(m.clients, key)
+		}
+		m.mu.Unlock()
+	}
+
+	return mc.b, mc.err
+}
+
+func (m *ClientManager) create(key string, mc *managedClient) (*Bitcoind, error) {
+	if m.Lookup == nil {
+		return nil, fmt.Errorf("%w for %q", ErrUnknownClient, key)
+	}
+
+	cfg, err := m.Lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := append(cfg.Options[:len(cfg.Options):len(cfg.Options)],
+		WithTransport(&limitedTransport{rt: m.transport, sem: m.sem}),
+		WithAfterHook(func(method string, dur time.Duration, err error) {
+			m.record(mc, err)
+		}),
+	)
+
+	b, err := New(cfg.Host, cfg.Port, cfg.Path, cfg.User, cfg.Password, cfg.UseSSL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client %q: %w", key, err)
+	}
+
+	return b, nil
+}
+
+func (m *ClientManager) record(mc *managedClient, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if err != nil {
+		mc.health.Healthy = false
+		mc.health.ConsecutiveFailures++
+		mc.health.LastErr = err
+		mc.health.LastFailure = now
+		return
+	}
+
+	mc.health.Healthy = true
+	mc.health.ConsecutiveFailures = 0
+	mc.health.LastSuccess = now
+}
+
+// Remove drops the client of key, so that the next Get creates it again from a fresh configuration.
+// Calls in progress on the old client complete.
+func (m *ClientManager) Remove(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.clients, key)
+}
+
+// Health returns the health of the client of key, or false if it has not been created.
+func (m *ClientManager) Health(key string) (ClientHealth, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mc, found := m.clients[key]
+	if !found {
+		return ClientHealth{}, false
+	}
+
+	return mc.health, true
+}
+
+// Status returns the health of all created clients, sorted by key.
+func (m *ClientManager) Status() []ClientHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := make([]ClientHealth, 0, len(m.clients))
+	for _, mc := range m.clients {
+		status = append(status, mc.health)
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Key < status[j].Key })
+
+	return status
+}
+
+// CloseIdleConnections closes the idle connections of all clients.
+func (m *ClientManager) CloseIdleConnections() {
+	m.transport.CloseIdleConnections()
+}
+
+// limitedTransport holds a slot of sem from sending a request until its response body is closed.
+type limitedTransport struct {
+	rt  http.RoundTripper
+	sem chan struct{}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.sem == nil {
+		return t.rt.RoundTrip(req)
+	}
+
+	select {
+	case t.sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		<-t.sem
+		return nil, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() { <-t.sem }}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,104 @@
+package bitcoin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientManager(t *testing.T) {
+	var inFlight, maxInFlight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		user, _, _ := r.BasicAuth()
+		fmt.Fprintf(w, `{"result":%q,"error":null,"id":1}`, user)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	lookups := map[string]int{}
+	var mu sync.Mutex
+	m := NewClientManager(func(key string) (ClientConfig, error) {
+		mu.Lock()
+		lookups[key]++
+		mu.Unlock()
+
+		switch key {
+		case "alice", "bob":
+			return ClientConfig{Host: u.Hostname(), Port: port, User: key, Password: "secret"}, nil
+		case "down":
+			return ClientConfig{Host: "127.0.0.1", Port: 1, User: key}, nil
+		}
+		return ClientConfig{}, fmt.Errorf("%w for %q", ErrUnknownClient, key)
+	}, 2)
+	defer m.CloseIdleConnections()
+
+	_, err := m.Get("mallory")
+	assert.ErrorIs(t, err, ErrUnknownClient)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		key := []string{"alice", "bob"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			b, err := m.Get(key)
+			if !assert.NoError(t, err) {
+				return
+			}
+			user, err := b.client.call("whoami", nil)
+			assert.NoError(t, err)
+			assert.Equal(t, `"`+key+`"`, string(user.Result))
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+	assert.Equal(t, map[string]int{"mallory": 1, "alice": 1, "bob": 1}, lookups)
+
+	down, err := m.Get("down")
+	require.NoError(t, err)
+	_, err = down.client.call("getblockcount", nil)
+	require.Error(t, err)
+
+	health, found := m.Health("down")
+	require.True(t, found)
+	assert.False(t, health.Healthy)
+	assert.Equal(t, 1, health.ConsecutiveFailures)
+	assert.Error(t, health.LastErr)
+
+	status := m.Status()
+	require.Len(t, status, 3)
+	assert.Equal(t, "alice", status[0].Key)
+	assert.True(t, status[0].Healthy)
+	assert.False(t, status[0].LastSuccess.IsZero())
+
+	// Rotated credentials are picked up after Remove
+	m.Remove("alice")
+	_, found = m.Health("alice")
+	assert.False(t, found)
+	_, err = m.Get("alice")
+	require.NoError(t, err)
+	assert.Equal(t, 2, lookups["alice"])
+}
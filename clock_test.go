@@ -0,0 +1,77 @@
+package bitcoin
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	timer := clock.NewTimer(time.Minute)
+	ticker := clock.NewTicker(20 * time.Second)
+	stopped := clock.NewTimer(time.Second)
+	assert.Equal(t, 3, clock.Waiters())
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, start.Add(30*time.Second), clock.Now())
+	assert.Equal(t, start.Add(20*time.Second), <-ticker.C())
+
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, start.Add(time.Minute), <-timer.C())
+	// The tick at 60s was dropped, the one at 40s had not been received yet
+	assert.Equal(t, start.Add(40*time.Second), <-ticker.C())
+	assert.Equal(t, 1, clock.Waiters())
+
+	ticker.Stop()
+	assert.Equal(t, 0, clock.Waiters())
+
+	// A zero duration fires immediately
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("zero timer did not fire")
+	}
+}
+
+func TestFakeClockRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`{"result":42,"error":null,"id":1}`))
+	})
+	defer done()
+	defer close(release)
+
+	clock := NewFakeClock(time.Now())
+	c = c.derive(WithClock(clock), WithTimeoutDuration(time.Hour))
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.call("getblockcount", nil)
+		errs <- err
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+
+	select {
+	case err := <-errs:
+		assert.True(t, errors.Is(err, ErrTimeout))
+	case <-time.After(5 * time.Second):
+		t.Fatal("call did not time out")
+	}
+}
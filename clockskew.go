@@ -0,0 +1,169 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MaxFutureBlockTime is how far ahead of the network adjusted time a block timestamp may be.
+const MaxFutureBlockTime = 2 * time.Hour
+
+// ClockSkew compares the local clock with the node's and the chain's. Warnings lists the differences above
+// the tolerated skew; locktimes and expiry times computed from a skewed clock are off by as much.
+type ClockSkew struct {
+	// NodeOffset is the node's clock minus the local clock, measured with the Date header of an RPC
+	// response and so to the second. Zero if the node sent no Date header.
+	NodeOffset time.Duration
+	// PeerOffset is the median of the clocks of the node's peers minus the node's clock (timeoffset).
+	PeerOffset time.Duration
+	TipTime    time.Time
+	MedianTime time.Time // median time past of the tip
+	Warnings   []string
+}
+
+// Skewed reports whether any clock difference exceeded the tolerated skew.
+func (s *ClockSkew) Skewed() bool {
+	return len(s.Warnings) > 0
+}
+
+// dateRecorder records the Date header of the responses it passes through.
+type dateRecorder struct {
+	rt    http.RoundTripper
+	clock Clock
+
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+func (d *dateRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := d.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		// The header has a resolution of a second and is written before the response is sent
+		now := d.clock.Now().Truncate(time.Second)
+
+		d.mu.Lock()
+		d.offset = date.Sub(now)
+		d.mu.Unlock()
+	}
+
+	return resp, nil
+}
+
+// CheckClockSkew compares the local clock with the node's, the node's with its peers' and the local clock
+// with the timestamps of the chain tip, and warns about differences greater than maxSkew. The local clock
+// can only be shown to be behind by the chain: block timestamps may be up to MaxFutureBlockTime ahead, but
+// the median time past never is.
+func (b *Bitcoind) CheckClockSkew(maxSkew time.Duration) (*ClockSkew, error) {
+	rt := b.client.httpClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	recorder := &dateRecorder{rt: rt, clock: b.client.clock}
+	d := &Bitcoind{client: b.client.derive(WithTransport(recorder))}
+
+	var network struct {
+		TimeOffset int64 `json:"timeoffset"`
+	}
+	if err := d.uncachedCall("getnetworkinfo", nil, &network); err != nil {
+		return nil, err
+	}
+
+	var chain BlockchainInfo
+	if err := d.uncachedCall("getblockchaininfo", nil, &chain); err != nil {
+		return nil, err
+	}
+
+	var tip BlockHeader
+	if err := d.uncachedCall("getblockheader", []interface{}{chain.BestBlockHash}, &tip); err != nil {
+		return nil, err
+	}
+
+	recorder.mu.Lock()
+	skew := &ClockSkew{
+		NodeOffset: recorder.offset,
+		PeerOffset: time.Duration(network.TimeOffset) * time.Second,
+		TipTime:    time.Unix(int64(tip.Time), 0),
+		MedianTime: time.Unix(chain.MedianTime, 0),
+	}
+	recorder.mu.Unlock()
+
+	now := b.client.clock.Now()
+
+	if abs(skew.NodeOffset) > maxSkew {
+		skew.Warnings = append(skew.Warnings, fmt.Sprintf("the node's clock differs from the local clock by %s", skew.NodeOffset))
+	}
+	if abs(skew.PeerOffset) > maxSkew {
+		skew.Warnings = append(skew.Warnings, fmt.Sprintf("the node's clock differs from its peers' by %s", skew.PeerOffset))
+	}
+	if behind := skew.MedianTime.Sub(now); behind > maxSkew {
+		skew.Warnings = append(skew.Warnings, fmt.Sprintf("the local clock is %s behind the median time past of the tip", behind))
+	} else if behind := skew.TipTime.Sub(now) - MaxFutureBlockTime; behind > maxSkew {
+		skew.Warnings = append(skew.Warnings, fmt.Sprintf("the local clock is at least %s behind the timestamp of the tip", behind))
+	}
+
+	return skew, nil
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// ClockSkewMonitor checks the clocks periodically and reports skew above MaxSkew.
+type ClockSkewMonitor struct {
+	MaxSkew time.Duration
+	// OnSkew is called for every check finding skew, after the warnings have been logged.
+	OnSkew func(*ClockSkew)
+
+	b *Bitcoind
+}
+
+// NewClockSkewMonitor returns a monitor tolerating maxSkew.
+func NewClockSkewMonitor(b *Bitcoind, maxSkew time.Duration) *ClockSkewMonitor {
+	return &ClockSkewMonitor{MaxSkew: maxSkew, b: b}
+}
+
+// Check compares the clocks once, logging a warning for every difference above MaxSkew.
+func (m *ClockSkewMonitor) Check() (*ClockSkew, error) {
+	skew, err := m.b.CheckClockSkew(m.MaxSkew)
+	if err != nil || !skew.Skewed() {
+		return skew, err
+	}
+
+	for _, warning := range skew.Warnings {
+		m.b.client.logger.Warnf("Clock skew: %s", warning)
+	}
+
+	if m.OnSkew != nil {
+		m.OnSkew(skew)
+	}
+
+	return skew, nil
+}
+
+// Run checks the clocks every interval until the context is done.
+func (m *ClockSkewMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := m.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+
+		if _, err := m.Check(); err != nil {
+			m.b.client.logger.Errorf("ClockSkewMonitor: %v", err)
+		}
+	}
+}
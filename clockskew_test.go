@@ -0,0 +1,72 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckClockSkew(t *testing.T) {
+	local := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	nodeTime := local.Add(-10 * time.Minute)
+	tipTime := local.Add(-5 * time.Minute)
+	medianTime := local.Add(-40 * time.Minute)
+	timeOffset := 0
+
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		w.Header().Set("Date", nodeTime.Format(http.TimeFormat))
+		switch req.Method {
+		case "getnetworkinfo":
+			fmt.Fprintf(w, `{"result":{"version":270000,"timeoffset":%d},"error":null,"id":1}`, timeOffset)
+		case "getblockchaininfo":
+			fmt.Fprintf(w, `{"result":{"bestblockhash":"00ab","mediantime":%d},"error":null,"id":1}`, medianTime.Unix())
+		case "getblockheader":
+			fmt.Fprintf(w, `{"result":{"hash":"00ab","time":%d},"error":null,"id":1}`, tipTime.Unix())
+		}
+	}, WithClock(NewFakeClock(local)))
+	defer closeServer()
+
+	var reported []*ClockSkew
+	monitor := NewClockSkewMonitor(b, time.Minute)
+	monitor.OnSkew = func(s *ClockSkew) { reported = append(reported, s) }
+
+	skew, err := monitor.Check()
+	require.NoError(t, err)
+	assert.Equal(t, -10*time.Minute, skew.NodeOffset)
+	assert.Equal(t, tipTime, skew.TipTime.UTC())
+	assert.Equal(t, []string{"the node's clock differs from the local clock by -10m0s"}, skew.Warnings)
+	assert.Len(t, reported, 1)
+
+	// The local clock is an hour behind
+	nodeTime, timeOffset = local.Add(time.Hour), 90
+	tipTime, medianTime = local.Add(3*time.Hour+55*time.Minute), local.Add(20*time.Minute)
+
+	skew, err = b.CheckClockSkew(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, skew.PeerOffset)
+	assert.Equal(t, []string{
+		"the node's clock differs from the local clock by 1h0m0s",
+		"the node's clock differs from its peers' by 1m30s",
+		"the local clock is 20m0s behind the median time past of the tip",
+	}, skew.Warnings)
+
+	medianTime = local.Add(-time.Hour)
+	skew, err = b.CheckClockSkew(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "the local clock is at least 1h55m0s behind the timestamp of the tip", skew.Warnings[2])
+
+	nodeTime, timeOffset = local, 0
+	tipTime, medianTime = local.Add(-time.Minute), local.Add(-time.Hour)
+	skew, err = monitor.Check()
+	require.NoError(t, err)
+	assert.False(t, skew.Skewed())
+	assert.Len(t, reported, 1)
+}
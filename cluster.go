@@ -0,0 +1,320 @@
+package bitcoin
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ClusterStore persists the state of an AddressClusterer: the clusters as a forest of addresses, and the
+// addresses paid by unspent outputs so that inputs can be attributed.
+type ClusterStore interface {
+	// Parent returns the parent of address in the cluster forest, itself for the root of a cluster, or
+	// false if the address was never seen.
+	Parent(address string) (string, bool, error)
+	SetParent(address, parent string) error
+	// SaveOutput records the address paid by an outpoint, given as txid:vout.
+	SaveOutput(outpoint, address string) error
+	// SpendOutput returns and forgets the address paid by an outpoint, or false if it is unknown.
+	SpendOutput(outpoint string) (string, bool, error)
+}
+
+// MemoryClusterStore is a ClusterStore that keeps everything in memory, which is mostly useful for tests
+// and small ranges of blocks.
+type MemoryClusterStore struct {
+	mu      sync.Mutex
+	parents map[string]string
+	outputs map[string]string
+}
+
+// NewMemoryClusterStore returns an empty MemoryClusterStore.
+func NewMemoryClusterStore() *MemoryClusterStore {
+	return &MemoryClusterStore{
+		parents: make(map[string]string),
+		outputs: make(map[string]string),
+	}
+}
+
+func (s *MemoryClusterStore) Parent(address string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parent, found := s.parents[address]
+	return parent, found, nil
+}
+
+func (s *MemoryClusterStore) SetParent(address, parent string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.parents[address] = parent
+	return nil
+}
+
+func (s *MemoryClusterStore) SaveOutput(outpoint, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outputs[outpoint] = address
+	return nil
+}
+
+func (s *MemoryClusterStore) SpendOutput(outpoint string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	address, found := s.outputs[outpoint]
+	delete(s.outputs, outpoint)
+	return address, found, nil
+}
+
+// AddressClusterer groups addresses that are likely controlled by the same entity, using heuristics over
+// the transactions of the blocks it is given:
+//
+// Common input ownership: all addresses spent from by a transaction belong to one cluster, as the
+// spender had to hold the keys of all of them. Transactions that look like CoinJoins, several inputs and
+// several outputs of the same value, are skipped unless ClusterCoinJoins is set.
+//
+// Change detection, if DetectChange is set: when exactly one output of a transaction pays an address
+// never seen before, or exactly one output has the script type of all inputs, that output is taken to be
+// change and its address joins the cluster of the inputs.
+//
+// Blocks have to be added in chain order, starting at a height below the outputs of interest: inputs
+// spending outputs the clusterer has not seen are ignored. A ClusterID is the address at the root of the
+// cluster and may change as clusters are merged.
+type AddressClusterer struct {
+	DetectChange     bool
+	ClusterCoinJoins bool
+	Params           *ChainParams // for the addresses of outputs, nil means MainNetParams
+
+	mu    sync.Mutex
+	store ClusterStore
+}
+
+// NewAddressClusterer returns a clusterer keeping its state in store, with change detection enabled.
+func NewAddressClusterer(store ClusterStore) *AddressClusterer {
+	return &AddressClusterer{
+		DetectChange: true,
+		store:        store,
+	}
+}
+
+// AddBlock applies the heuristics to the transactions of a serialized block. It can be called from the
+// function passed to Backfill to cluster a range of blocks.
+func (c *AddressClusterer) AddBlock(raw []byte) error {
+	block, err := parseBlock(raw)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, tx := range block.Txs {
+		if err := c.addTx(tx, i == 0); err != nil {
+			return fmt.Errorf("failed to cluster transaction %s of block %s: %w", tx.TxID, block.Hash, err)
+		}
+	}
+
+	return nil
+}
+
+// ClusterID returns the ID of the cluster address belongs to, or false if the address was never seen.
+func (c *AddressClusterer) ClusterID(address string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, found, err := c.store.Parent(address); err != nil || !found {
+		return "", false, err
+	}
+
+	root, err := c.find(address)
+	return root, err == nil, err
+}
+
+func (c *AddressClusterer) addTx(tx *parsedTx, coinbase bool) error {
+	params := c.Params
+	if params == nil {
+		params = MainNetParams
+	}
+
+	var inputs []string
+	inputTypes := make(map[string]bool)
+	if !coinbase {
+		for _, in := range tx.Inputs {
+			address, found, err := c.store.SpendOutput(fmt.Sprintf("%s:%d", in.PrevTxID, in.PrevIndex))
+			if err != nil {
+				return err
+			}
+			if found {
+				inputs = append(inputs, address)
+				script, _ := AddressToScript(address, params)
+				inputTypes[ClassifyScript(script)] = true
+			}
+		}
+	}
+
+	outputs := make([]string, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		outputs[i], _ = ScriptToAddress(out.ScriptPubKey, params)
+	}
+
+	if len(inputs) > 0 && (c.ClusterCoinJoins || !isCoinJoin(tx)) {
+		for _, address := range inputs[1:] {
+			if err := c.union(inputs[0], address); err != nil {
+				return err
+			}
+		}
+
+		if c.DetectChange {
+			change, err := c.change(tx, inputs, inputTypes, outputs)
+			if err != nil {
+				return err
+			}
+			if change != "" {
+				if err := c.ensure(change); err != nil {
+					return err
+				}
+				if err := c.union(inputs[0], change); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for i, address := range outputs {
+		if address == "" {
+			continue
+		}
+		if err := c.ensure(address); err != nil {
+			return err
+		}
+		if err := c.store.SaveOutput(fmt.Sprintf("%s:%d", tx.TxID, i), address); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// change returns the address of the change output of a transaction, or "" if the heuristics do not single
+// out one output. Transactions paying one of their input addresses are not analyzed, the change is
+// already known to go back to the sender.
+func (c *AddressClusterer) change(tx *parsedTx, inputs []string, inputTypes map[string]bool, outputs []string) (string, error) {
+	if len(outputs) < 2 {
+		return "", nil
+	}
+
+	spent := make(map[string]bool, len(inputs))
+	for _, address := range inputs {
+		spent[address] = true
+	}
+
+	var fresh, sameType []string
+	for i, address := range outputs {
+		if address == "" {
+			continue
+		}
+		if spent[address] {
+			return "", nil
+		}
+
+		_, seen, err := c.store.Parent(address)
+		if err != nil {
+			return "", err
+		}
+		if !seen {
+			fresh = append(fresh, address)
+		}
+
+		if len(inputTypes) == 1 && inputTypes[ClassifyScript(tx.Outputs[i].ScriptPubKey)] {
+			sameType = append(sameType, address)
+		}
+	}
+
+	switch {
+	case len(fresh) == 1:
+		return fresh[0], nil
+	case len(sameType) == 1:
+		return sameType[0], nil
+	}
+
+	return "", nil
+}
+
+// isCoinJoin reports whether a transaction has at least two inputs and two or more outputs of the same
+// value, the shape of a CoinJoin.
+func isCoinJoin(tx *parsedTx) bool {
+	if len(tx.Inputs) < 2 {
+		return false
+	}
+
+	values := make(map[uint64]int)
+	for _, out := range tx.Outputs {
+		values[out.Value]++
+		if values[out.Value] >= 2 && out.Value > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ensure adds address as a cluster of its own if it was never seen.
+func (c *AddressClusterer) ensure(address string) error {
+	_, found, err := c.store.Parent(address)
+	if err != nil || found {
+		return err
+	}
+
+	return c.store.SetParent(address, address)
+}
+
+// find returns the root of the cluster of address, pointing the addresses on the way directly to it.
+func (c *AddressClusterer) find(address string) (string, error) {
+	root := address
+	for {
+		parent, found, err := c.store.Parent(root)
+		if err != nil {
+			return "", err
+		}
+		if !found || parent == root {
+			break
+		}
+		root = parent
+	}
+
+	for address != root {
+		parent, _, err := c.store.Parent(address)
+		if err != nil {
+			return "", err
+		}
+		if err := c.store.SetParent(address, root); err != nil {
+			return "", err
+		}
+		address = parent
+	}
+
+	return root, nil
+}
+
+// union merges the clusters of two addresses. The smaller root becomes the root of the merged cluster so
+// that cluster IDs do not depend on the order of the merges.
+func (c *AddressClusterer) union(a, b string) error {
+	rootA, err := c.find(a)
+	if err != nil {
+		return err
+	}
+	rootB, err := c.find(b)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case rootA < rootB:
+		return c.store.SetParent(rootB, rootA)
+	case rootB < rootA:
+		return c.store.SetParent(rootA, rootB)
+	}
+
+	return nil
+}
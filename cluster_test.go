@@ -0,0 +1,92 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spending returns an input spending output vout of tx.
+func spending(tx *transaction, vout uint32) input {
+	var hash [32]byte
+	copy(hash[:], cryptolib.ReverseBytes(cryptolib.Sha256d(tx.ToHex())))
+	return input{hash: hash, index: vout, sequence: SequenceFinal}
+}
+
+func TestAddressClusterer(t *testing.T) {
+	p2wpkh := func(b byte) []byte { return append([]byte{0x00, 0x14}, bytes.Repeat([]byte{b}, 20)...) }
+	p2pkh := func(b byte) []byte {
+		return append(append([]byte{0x76, 0xa9, 0x14}, bytes.Repeat([]byte{b}, 20)...), 0x88, 0xac)
+	}
+	address := func(script []byte) string {
+		a, err := ScriptToAddress(script, MainNetParams)
+		require.NoError(t, err)
+		return a
+	}
+
+	a, b, d, e, f, g, h, i := p2wpkh(1), p2wpkh(2), p2pkh(4), p2pkh(5), p2wpkh(6), p2pkh(7), p2wpkh(8), p2wpkh(9)
+
+	coinbase := &transaction{
+		Version: 2,
+		Inputs:  []input{{index: 0xffffffff, unlockingScript: []byte{0x01, 0x01}, sequence: SequenceFinal}},
+		Outputs: []output{{value: 1000, lockingScript: a}, {value: 1000, lockingScript: b}, {value: 1000, lockingScript: d}},
+	}
+
+	// a and b are spent together, e is a fresh address while d was seen before
+	tx1 := &transaction{
+		Version: 2,
+		Inputs:  []input{spending(coinbase, 0), spending(coinbase, 1)},
+		Outputs: []output{{value: 1500, lockingScript: e}, {value: 400, lockingScript: d}},
+	}
+	// Both outputs are fresh, g has the script type of the input
+	tx2 := &transaction{
+		Version: 2,
+		Inputs:  []input{spending(coinbase, 2)},
+		Outputs: []output{{value: 500, lockingScript: f}, {value: 400, lockingScript: g}},
+	}
+	// A CoinJoin of e and f
+	tx3 := &transaction{
+		Version: 2,
+		Inputs:  []input{spending(tx1, 0), spending(tx2, 0)},
+		Outputs: []output{{value: 900, lockingScript: h}, {value: 900, lockingScript: i}},
+	}
+
+	c := NewAddressClusterer(NewMemoryClusterStore())
+	genesis := strings.Repeat("00", 32)
+	require.NoError(t, c.AddBlock(testBlock(genesis, 1, coinbase.ToHex())))
+	require.NoError(t, c.AddBlock(testBlock(genesis, 2, (&transaction{Version: 2, Inputs: coinbase.Inputs}).ToHex(), tx1.ToHex(), tx2.ToHex(), tx3.ToHex())))
+
+	cluster := func(script []byte) string {
+		id, found, err := c.ClusterID(address(script))
+		require.NoError(t, err)
+		require.True(t, found, hex.EncodeToString(script))
+		return id
+	}
+
+	assert.Equal(t, cluster(a), cluster(b))
+	assert.Equal(t, cluster(a), cluster(e))
+	assert.NotEqual(t, cluster(a), cluster(d))
+	assert.Equal(t, cluster(d), cluster(g))
+	assert.NotEqual(t, cluster(d), cluster(f))
+	assert.NotEqual(t, cluster(e), cluster(f), "CoinJoin inputs are not clustered")
+	assert.NotEqual(t, cluster(h), cluster(i))
+
+	// The ID is the smallest address of the cluster
+	ids := []string{address(a), address(b), address(e)}
+	smallest := ids[0]
+	for _, id := range ids {
+		if id < smallest {
+			smallest = id
+		}
+	}
+	assert.Equal(t, smallest, cluster(e))
+
+	_, found, err := c.ClusterID("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
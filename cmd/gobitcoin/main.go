@@ -0,0 +1,198 @@
+// Command gobitcoin is a bitcoin-cli style client built on github.com/shuber/go-bitcoin.
+//
+//	gobitcoin [flags] <method> [params...]
+//	gobitcoin [flags] -batch < commands
+//
+// Every RPC method can be called. Params that are valid JSON are sent as such, anything else as a
+// string; quote a string that looks like a number, e.g. '"1234"'. In batch mode every line of stdin is
+// a call, either as whitespace separated words or as a JSON array ["method", params...]; empty lines and
+// lines starting with # are skipped.
+//
+// Nodes are configured as profiles, see Config, and selected with -profile. Without a profiles file the
+// client connects to a local mainnet node with cookie authentication.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gobitcoin", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var (
+		configPath = flags.String("config", "", "profiles file (default "+defaultConfigPath()+")")
+		profile    = flags.String("profile", "", "profile to use, the file's default if empty")
+		rpcURL     = flags.String("url", "", "node URL, overrides the profile's")
+		user       = flags.String("user", "", "RPC user, overrides the profile's")
+		password   = flags.String("password", "", "RPC password, overrides the profile's")
+		wallet     = flags.String("wallet", "", "wallet to address, overrides the profile's")
+		format     = flags.String("format", formatJSON, "output format: json, table or raw")
+		typed      = flags.Bool("typed", false, "decode results into the typed structs of the client where available")
+		batch      = flags.Bool("batch", false, "read calls from stdin, one per line")
+		timeout    = flags.Duration("timeout", 2*time.Minute, "timeout per call")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gobitcoin [flags] <method> [params...]\n       gobitcoin [flags] -batch < commands")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if !*batch && flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	path := *configPath
+	config, err := loadConfig(firstNonEmpty(path, defaultConfigPath()), path != "")
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	p, err := config.profile(*profile)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *rpcURL != "" {
+		p.URL = *rpcURL
+	}
+	if *user != "" {
+		p.User, p.Password = *user, *password
+	}
+	if *wallet != "" {
+		p.Wallet = *wallet
+	}
+
+	b, err := p.connect(bitcoin.WithTimeoutDuration(*timeout))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if !*batch {
+		if err := call(b, flags.Args(), stdout, *format, *typed); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	failed := false
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // raw transactions make for long lines
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words, err := splitLine(line)
+		if err == nil {
+			err = call(b, words, stdout, *format, *typed)
+		}
+
+		if err != nil {
+			fmt.Fprintf(stderr, "line %d: %v\n", n, err)
+			failed = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// call sends one call given as method and params in command line form and prints the result.
+func call(b *bitcoin.Bitcoind, words []string, w io.Writer, format string, typed bool) error {
+	method := words[0]
+	params := make([]interface{}, len(words)-1)
+	for i, word := range words[1:] {
+		params[i] = parseParam(word)
+	}
+
+	result, err := b.RawCall(method, params...)
+	if err != nil {
+		return err
+	}
+
+	return render(w, method, result, format, typed)
+}
+
+// parseParam returns word decoded as JSON if it is valid JSON, otherwise word itself.
+func parseParam(word string) interface{} {
+	d := json.NewDecoder(strings.NewReader(word))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil || d.More() {
+		return word
+	}
+
+	return v
+}
+
+// splitLine splits a batch line into the method and its params.
+func splitLine(line string) ([]string, error) {
+	if !strings.HasPrefix(line, "[") {
+		return strings.Fields(line), nil
+	}
+
+	var call []json.RawMessage
+	if err := json.Unmarshal([]byte(line), &call); err != nil {
+		return nil, err
+	}
+
+	if len(call) == 0 {
+		return nil, errors.New("empty call")
+	}
+
+	words := make([]string, len(call))
+	for i, raw := range call {
+		words[i] = string(bytes.TrimSpace(raw))
+	}
+
+	// The method is a JSON string
+	if err := json.Unmarshal(call[0], &words[0]); err != nil {
+		return nil, fmt.Errorf("method must be a string: %w", err)
+	}
+
+	return words, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
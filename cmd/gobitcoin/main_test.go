@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testNode(t *testing.T) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "getblockcount":
+			_, _ = w.Write([]byte(`{"result":812345,"error":null,"id":1}`))
+		case "getbestblockhash":
+			_, _ = w.Write([]byte(`{"result":"00000000000000000002a7c4","error":null,"id":1}`))
+		case "echo":
+			params, _ := json.Marshal(req.Params)
+			_, _ = w.Write([]byte(`{"result":` + string(params) + `,"error":null,"id":1}`))
+		case "getblockchaininfo":
+			_, _ = w.Write([]byte(`{"result":{"chain":"regtest","blocks":101,"headers":101,"extra":true},"error":null,"id":1}`))
+		case "listbanned":
+			_, _ = w.Write([]byte(`{"result":[{"address":"10.0.0.1/32","ban_created":1,"banned_until":2},{"address":"10.0.0.2/32","ban_created":3}],"error":null,"id":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":1}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return strings.Replace(srv.URL, "http://", "http://user:pass@", 1)
+}
+
+func TestRun(t *testing.T) {
+	url := testNode(t)
+	t.Setenv("GOBITCOIN_CONFIG", filepath.Join(t.TempDir(), "profiles.json"))
+
+	runCLI := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"-url", url}, args...), strings.NewReader(stdin), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, out, _ := runCLI("", "getblockcount")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "812345\n", out)
+
+	code, out, _ = runCLI("", "getbestblockhash")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "00000000000000000002a7c4\n", out)
+
+	code, out, _ = runCLI("", "-format", "raw", "echo", "1", "true", `{"a":[1]}`, "abc", `"1234"`)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `[1,true,{"a":[1]},"abc","1234"]`+"\n", out)
+
+	// Typed output drops the fields the client does not know
+	code, out, _ = runCLI("", "-typed", "-format", "raw", "getblockchaininfo")
+	assert.Equal(t, 0, code)
+	assert.NotContains(t, out, "extra")
+	assert.Contains(t, out, `"chain":"regtest"`)
+
+	code, out, _ = runCLI("", "-format", "table", "listbanned")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "address      ban_created  banned_until\n10.0.0.1/32  1            2\n10.0.0.2/32  3            \n", out)
+
+	code, _, errOut := runCLI("", "nosuchmethod")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "Method not found")
+
+	code, out, errOut = runCLI("# comment\ngetblockcount\n\n[\"echo\", \"1234\", 5]\nnosuchmethod\n", "-batch", "-format", "raw")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "812345\n[\"1234\",5]\n", out)
+	assert.Contains(t, errOut, "line 5:")
+
+	code, _, _ = runCLI("")
+	assert.Equal(t, 2, code)
+}
+
+func TestRenderTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := render(&buf, "getnettotals", json.RawMessage(`{"totalbytesrecv":10,"totalbytessent":20,"uploadtarget":{"target":0}}`), formatTable, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "totalbytesrecv  10\ntotalbytessent  20\nuploadtarget    {\"target\":0}\n", buf.String())
+}
+
+func TestProfiles(t *testing.T) {
+	url := testNode(t)
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	config := `{"default":"a","profiles":{"a":{"url":"http://127.0.0.1:1"},"b":{"url":"` + url + `"}}}`
+	assert.NoError(t, os.WriteFile(path, []byte(config), 0600))
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"-config", path, "-profile", "b", "getblockcount"}, nil, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "812345\n", stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, 1, run([]string{"-config", path, "getblockcount"}, nil, &stdout, &stderr))
+	assert.Equal(t, 1, run([]string{"-config", path, "-profile", "c", "getblockcount"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `unknown profile "c"`)
+}
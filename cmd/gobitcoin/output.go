@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+// Output formats.
+const (
+	formatJSON  = "json"
+	formatRaw   = "raw"
+	formatTable = "table"
+)
+
+// render writes the result of a call to w in the given format. With typed set, results of methods with a
+// typed wrapper are decoded into its struct first, showing the result the way a Go program sees it.
+func render(w io.Writer, method string, result json.RawMessage, format string, typed bool) error {
+	if typed {
+		if v, err := bitcoin.DecodeResult(method, result); err == nil {
+			if result, err = json.Marshal(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch format {
+	case formatRaw:
+		_, err := fmt.Fprintf(w, "%s\n", result)
+		return err
+
+	case formatJSON:
+		// Strings are printed unquoted like bitcoin-cli does
+		var s string
+		if json.Unmarshal(result, &s) == nil {
+			_, err := fmt.Fprintln(w, s)
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, result, "", "  "); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%s\n", buf.Bytes())
+		return err
+
+	case formatTable:
+		d := json.NewDecoder(bytes.NewReader(result))
+		d.UseNumber()
+
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return err
+		}
+		return renderTable(w, v)
+	}
+
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// renderTable prints objects as key value pairs, arrays of objects as a table with a column per key and
+// other values one per line.
+func renderTable(w io.Writer, v interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			fmt.Fprintf(tw, "%s\t%s\n", key, cell(v[key]))
+		}
+
+	case []interface{}:
+		columns := make(map[string]bool)
+		for _, elem := range v {
+			obj, ok := elem.(map[string]interface{})
+			if !ok {
+				columns = nil
+				break
+			}
+			for key := range obj {
+				columns[key] = true
+			}
+		}
+
+		if len(columns) == 0 {
+			for _, elem := range v {
+				fmt.Fprintln(tw, cell(elem))
+			}
+			break
+		}
+
+		header := sortedKeys(columns)
+		for i, key := range header {
+			fmt.Fprint(tw, key)
+			if i < len(header)-1 {
+				fmt.Fprint(tw, "\t")
+			}
+		}
+		fmt.Fprintln(tw)
+
+		for _, elem := range v {
+			obj := elem.(map[string]interface{})
+			for i, key := range header {
+				fmt.Fprint(tw, cell(obj[key]))
+				if i < len(header)-1 {
+					fmt.Fprint(tw, "\t")
+				}
+			}
+			fmt.Fprintln(tw)
+		}
+
+	default:
+		fmt.Fprintln(tw, cell(v))
+	}
+
+	return tw.Flush()
+}
+
+// cell renders a value for a table cell, nested values as compact JSON.
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	}
+
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]interface{}:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]bool:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+// Profile holds the connection settings of a node.
+type Profile struct {
+	URL        string `json:"url"` // e.g. http://127.0.0.1:8332, credentials may be included
+	User       string `json:"user,omitempty"`
+	Password   string `json:"password,omitempty"`
+	CookieFile string `json:"cookiefile,omitempty"` // used when no user is set
+	Wallet     string `json:"wallet,omitempty"`
+}
+
+// Config is the profiles file, by default ~/.config/gobitcoin/profiles.json:
+//
+//	{
+//	  "default": "mainnet",
+//	  "profiles": {
+//	    "mainnet": {"url": "http://127.0.0.1:8332", "cookiefile": "~/.bitcoin/.cookie"},
+//	    "regtest": {"url": "http://127.0.0.1:18443", "user": "test", "password": "test", "wallet": "miner"}
+//	  }
+//	}
+type Config struct {
+	Default  string             `json:"default"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("GOBITCOIN_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "gobitcoin", "profiles.json")
+}
+
+// loadConfig reads the profiles file. A missing file at the default location is not an error.
+func loadConfig(path string, explicit bool) (*Config, error) {
+	c := &Config{Profiles: make(map[string]Profile)}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// profile returns the named profile, or the default one if name is empty.
+func (c *Config) profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+
+	if name == "" {
+		return Profile{URL: "http://127.0.0.1:8332", CookieFile: "~/.bitcoin/.cookie"}, nil
+	}
+
+	p, found := c.Profiles[name]
+	if !found {
+		return Profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+
+	return p, nil
+}
+
+// connect creates a client for the profile.
+func (p Profile) connect(opts ...bitcoin.Option) (*bitcoin.Bitcoind, error) {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", p.URL, err)
+	}
+
+	user := u.User.Username()
+	password, _ := u.User.Password()
+	if p.User != "" {
+		user, password = p.User, p.Password
+	}
+
+	if user == "" && p.CookieFile != "" {
+		cookie, err := os.ReadFile(expandHome(p.CookieFile))
+		if err != nil {
+			return nil, fmt.Errorf("reading cookie: %w", err)
+		}
+
+		parts := strings.SplitN(strings.TrimSpace(string(cookie)), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed cookie file %s", p.CookieFile)
+		}
+		user, password = parts[0], parts[1]
+	}
+
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		port = 8332
+	}
+
+	path := u.Path
+	if p.Wallet != "" {
+		path = "wallet/" + p.Wallet
+	}
+
+	return bitcoin.New(u.Hostname(), port, path, user, password, u.Scheme == "https", opts...)
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, path[2:])
+}
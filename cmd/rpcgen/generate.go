@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// words are the words RPC method names are made of, for splitting them into Go names, in their Go spelling.
+// Names are split greedily by the longest word at each position.
+var words = map[string]string{
+	"abandon": "Abandon", "abort": "Abort", "accept": "Accept", "active": "Active", "activity": "Activity",
+	"add": "Add", "addr": "Addr", "addrman": "Addrman", "address": "Address", "addresses": "Addresses",
+	"all": "All", "analyze": "Analyze", "ancestors": "Ancestors", "backup": "Backup", "balance": "Balance",
+	"balances": "Balances", "ban": "Ban", "banned": "Banned", "best": "Best", "block": "Block",
+	"blockchain": "Blockchain", "blocks": "Blocks", "bump": "Bump", "by": "By", "chain": "Chain",
+	"change": "Change", "clear": "Clear", "combine": "Combine", "connection": "Connection", "convert": "Convert",
+	"count": "Count", "create": "Create", "decode": "Decode", "deployment": "Deployment", "derive": "Derive",
+	"descendants": "Descendants", "descriptor": "Descriptor", "descriptors": "Descriptors",
+	"difficulty": "Difficulty", "dir": "Dir", "disconnect": "Disconnect", "display": "Display", "dump": "Dump",
+	"echo": "Echo", "encrypt": "Encrypt", "entry": "Entry", "enumerate": "Enumerate", "estimate": "Estimate",
+	"fee": "Fee", "filter": "Filter", "finalize": "Finalize", "flag": "Flag", "for": "For", "from": "From",
+	"fund": "Fund", "funded": "Funded", "funds": "Funds", "generate": "Generate", "get": "Get",
+	"groupings": "Groupings", "hash": "Hash", "hashps": "HashPS", "hd": "HD", "header": "Header",
+	"height": "Height", "help": "Help", "import": "Import", "index": "Index", "info": "Info",
+	"interface": "Interface", "invalidate": "Invalidate", "join": "Join", "json": "JSON", "key": "Key",
+	"keypool": "Keypool", "keys": "Keys", "label": "Label", "labels": "Labels", "list": "List", "load": "Load",
+	"lock": "Lock", "logging": "Logging", "many": "Many", "memory": "Memory", "mempool": "Mempool",
+	"message": "Message", "migrate": "Migrate", "mining": "Mining", "mock": "Mock", "msg": "Msg",
+	"multi": "Multi", "multisig": "Multisig", "net": "Net", "network": "Network", "new": "New", "node": "Node",
+	"nodes": "Nodes", "nonfinal": "NonFinal", "notifications": "Notifications", "orphan": "Orphan",
+	"package": "Package", "passphrase": "Passphrase", "peer": "Peer", "ping": "Ping", "precious": "Precious",
+	"prevout": "Prevout", "prioritise": "Prioritise", "prioritised": "Prioritised", "priv": "Priv",
+	"privkey": "PrivKey", "process": "Process", "proof": "Proof", "pruned": "Pruned", "psbt": "PSBT",
+	"psbts": "PSBTs", "pubkey": "PubKey", "queue": "Queue", "raw": "Raw", "received": "Received",
+	"reconsider": "Reconsider", "refill": "Refill", "remove": "Remove", "rescan": "Rescan", "restore": "Restore",
+	"rpc": "RPC", "save": "Save", "scan": "Scan", "scheduler": "Scheduler", "send": "Send", "set": "Set",
+	"settings": "Settings", "sign": "Sign", "signers": "Signers", "simulate": "Simulate", "since": "Since",
+	"smart": "Smart", "spending": "Spending", "states": "States", "stats": "Stats", "stop": "Stop",
+	"submit": "Submit", "sync": "Sync", "template": "Template", "test": "Test", "tips": "Tips", "to": "To",
+	"totals": "Totals", "transaction": "Transaction", "transactions": "Transactions", "tx": "Tx", "txout": "TxOut",
+	"txs": "Txs", "unconfirmed": "Unconfirmed", "unload": "Unload", "unspent": "Unspent", "update": "Update",
+	"upgrade": "Upgrade", "uptime": "Uptime", "utxo": "UTXO", "validate": "Validate", "validation": "Validation",
+	"verify": "Verify", "wait": "Wait", "wallet": "Wallet", "wallets": "Wallets", "with": "With", "zmq": "ZMQ",
+}
+
+// goName returns the Go name of an RPC method, e.g. GetBlockHash for getblockhash. Parts that are not
+// words are capitalized as they are.
+func goName(method string) string {
+	var b strings.Builder
+
+	for rest := strings.ToLower(method); rest != ""; {
+		if n := wordAt(rest); n > 0 {
+			b.WriteString(words[rest[:n]])
+			rest = rest[n:]
+			continue
+		}
+
+		// Up to the next word
+		n := 1
+		for n < len(rest) && wordAt(rest[n:]) == 0 {
+			n++
+		}
+		b.WriteString(strings.ToUpper(rest[:1]) + rest[1:n])
+		rest = rest[n:]
+	}
+
+	return b.String()
+}
+
+// wordAt returns the length of the longest word s starts with, 0 if none.
+func wordAt(s string) int {
+	for i := len(s); i > 0; i-- {
+		if _, found := words[s[:i]]; found {
+			return i
+		}
+	}
+	return 0
+}
+
+// fieldName returns the Go name of an argument, e.g. ConfTarget for conf_target.
+func fieldName(arg string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(arg, "_") {
+		if part == "" {
+			continue
+		}
+		if w, found := words[part]; found {
+			b.WriteString(w)
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// paramName returns the Go name of a required argument, e.g. confTarget for conf_target, which must not be
+// a keyword or clash with the names of the wrapper.
+func paramName(arg string) string {
+	parts := strings.SplitN(arg, "_", 2)
+	name := parts[0]
+	if len(parts) == 2 {
+		name += fieldName(parts[1])
+	}
+	if token.IsKeyword(name) || name == "b" || name == "params" || name == "opts" || name == "err" {
+		name += "Arg"
+	}
+	return name
+}
+
+// amountHints mark numeric arguments and results that are amounts or rates rather than counts.
+var amountHints = []string{"amount", "fee", "rate", "threshold", "BTC", "sat/vB", "/kvB"}
+
+// goType returns the Go type of an argument or result of the given JSON type.
+func goType(jsonType, name, description string) string {
+	switch jsonType {
+	case "string":
+		return "string"
+	case "boolean":
+		return "bool"
+	case "numeric":
+		for _, hint := range amountHints {
+			if strings.Contains(name, hint) || strings.Contains(description, hint) {
+				return "float64"
+			}
+		}
+		return "int64"
+	case "json array":
+		return "[]interface{}"
+	case "json object":
+		return "map[string]interface{}"
+	}
+
+	return "interface{}"
+}
+
+// wrappedMethods returns the methods called with a string literal by the non generated Go files of dir, and
+// the names of the methods of Bitcoind declared in them.
+func wrappedMethods(dir, out string) (called map[string]bool, declared map[string]bool, err error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != filepath.Base(out)
+	}, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	called = make(map[string]bool)
+	declared = make(map[string]bool)
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncDecl:
+					if n.Recv != nil && len(n.Recv.List) == 1 {
+						if star, ok := n.Recv.List[0].Type.(*ast.StarExpr); ok {
+							if ident, ok := star.X.(*ast.Ident); ok && ident.Name == "Bitcoind" {
+								declared[n.Name.Name] = true
+							}
+						}
+					}
+				case *ast.CallExpr:
+					for _, arg := range n.Args {
+						if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							if s, err := strconv.Unquote(lit.Value); err == nil {
+								called[s] = true
+							}
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	return called, declared, nil
+}
+
+// generate returns the source of the wrappers of the methods of api that are not wrapped yet.
+func generate(api *API, pkg string, called, declared map[string]bool) ([]byte, error) {
+	methods := make([]Method, 0, len(api.Methods))
+	for _, m := range api.Methods {
+		if !called[m.Name] && !declared[goName(m.Name)] {
+			methods = append(methods, m)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+
+	var body bytes.Buffer
+	for _, m := range methods {
+		writeMethod(&body, m)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by rpcgen from the help output of a node. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	if len(methods) > 0 {
+		fmt.Fprintf(&b, "import (\n\t\"context\"\n")
+		if bytes.Contains(body.Bytes(), []byte("json.RawMessage")) {
+			fmt.Fprintf(&b, "\t\"encoding/json\"\n")
+		}
+		fmt.Fprintf(&b, ")\n\n")
+	}
+	b.Write(body.Bytes())
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w\n%s", err, b.Bytes())
+	}
+
+	return src, nil
+}
+
+func writeMethod(b *bytes.Buffer, m Method) {
+	name := goName(m.Name)
+
+	var required, optional []Arg
+	for _, arg := range m.Args {
+		// Once an argument is optional, the ones after it can only be passed with it
+		if arg.Optional || len(optional) > 0 {
+			optional = append(optional, arg)
+		} else {
+			required = append(required, arg)
+		}
+	}
+
+	if len(optional) > 0 {
+		fmt.Fprintf(b, "// %sOptions are the optional arguments of %s, nil fields are not sent.\n", name, name)
+		fmt.Fprintf(b, "type %sOptions struct {\n", name)
+		for _, arg := range optional {
+			t := goType(arg.Type, arg.Name, arg.Description)
+			if !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map") && t != "interface{}" {
+				t = "*" + t
+			}
+			fmt.Fprintf(b, "\t%s %s %s\n", fieldName(arg.Name), t, comment(arg.Description))
+		}
+		fmt.Fprintf(b, "}\n\n")
+	}
+
+	var params, values []string
+	for _, arg := range required {
+		params = append(params, paramName(arg.Name)+" "+goType(arg.Type, arg.Name, arg.Description))
+		values = append(values, paramName(arg.Name))
+	}
+	if len(optional) > 0 {
+		params = append(params, "opts *"+name+"Options")
+	}
+
+	result := goType(m.Result, "", "")
+	switch m.Result {
+	case "json null":
+		result = ""
+	case "string", "boolean", "numeric":
+		// a scalar
+	default:
+		result = "json.RawMessage"
+	}
+
+	for _, line := range strings.Split(docComment(name, m), "\n") {
+		fmt.Fprintf(b, "// %s\n", line)
+	}
+
+	// The wrapper calls its Ctx variant with the background context
+	args := append([]string{"context.Background()"}, values...)
+	if len(optional) > 0 {
+		args = append(args, "opts")
+	}
+	ctxParams := append([]string{"ctx context.Context"}, params...)
+
+	if result == "" {
+		fmt.Fprintf(b, "func (b *Bitcoind) %s(%s) error {\n", name, strings.Join(params, ", "))
+	} else {
+		fmt.Fprintf(b, "func (b *Bitcoind) %s(%s) (result %s, err error) {\n", name, strings.Join(params, ", "), result)
+	}
+	fmt.Fprintf(b, "\treturn b.%sCtx(%s)\n", name, strings.Join(args, ", "))
+	fmt.Fprintf(b, "}\n\n")
+
+	fmt.Fprintf(b, "// %sCtx is %s with a context that cancels the request.\n", name, name)
+	if result == "" {
+		fmt.Fprintf(b, "func (b *Bitcoind) %sCtx(%s) error {\n", name, strings.Join(ctxParams, ", "))
+	} else {
+		fmt.Fprintf(b, "func (b *Bitcoind) %sCtx(%s) (result %s, err error) {\n", name, strings.Join(ctxParams, ", "), result)
+	}
+
+	fmt.Fprintf(b, "\tparams := []interface{}{%s}\n", strings.Join(values, ", "))
+	if len(optional) > 0 {
+		fmt.Fprintf(b, "\tif opts != nil {\n")
+		var fields []string
+		for _, arg := range optional {
+			fields = append(fields, "opts."+fieldName(arg.Name))
+		}
+		fmt.Fprintf(b, "\t\tparams = append(params, %s)\n", strings.Join(fields, ", "))
+		fmt.Fprintf(b, "\t}\n")
+	}
+
+	if result == "" {
+		fmt.Fprintf(b, "\treturn b.callGenerated(ctx, %q, params, nil)\n", m.Name)
+	} else {
+		fmt.Fprintf(b, "\terr = b.callGenerated(ctx, %q, params, &result)\n", m.Name)
+		fmt.Fprintf(b, "\treturn\n")
+	}
+	fmt.Fprintf(b, "}\n\n")
+}
+
+// docComment returns the doc comment of a wrapper: its name and method followed by the first sentence of
+// the method's description.
+func docComment(name string, m Method) string {
+	doc := fmt.Sprintf("%s calls %s.", name, m.Name)
+
+	description := strings.Join(strings.Fields(strings.SplitN(m.Description, "\n\n", 2)[0]), " ")
+	if description != "" {
+		doc += " " + description
+	}
+
+	return wrap(doc, 100)
+}
+
+func comment(description string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if description == "" {
+		return ""
+	}
+	return "// " + description
+}
+
+func wrap(text string, width int) string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return strings.Join(append(lines, line), "\n")
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// API describes the RPC methods of a node, as parsed from its help output.
+type API struct {
+	Version int      `json:"version"` // as reported by getnetworkinfo, 0 if unknown
+	Methods []Method `json:"methods"`
+}
+
+// Method is an RPC method. Result is the JSON type of its result, e.g. "string" or "json object", and empty
+// when the result depends on the arguments.
+type Method struct {
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	Args        []Arg  `json:"args"`
+	Result      string `json:"result"`
+}
+
+// Arg is a positional argument of a method. Type is its JSON type as given by the help output, e.g.
+// "numeric" or "string or numeric".
+type Arg struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Optional    bool   `json:"optional"`
+	Description string `json:"description"`
+}
+
+// parseIndex returns the methods by category of the output of help without arguments:
+//
+//	== Blockchain ==
+//	getbestblockhash
+//	getblock "blockhash" ( verbosity )
+func parseIndex(text string) map[string]string {
+	categories := make(map[string]string)
+
+	var category string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "== ") && strings.HasSuffix(line, " =="):
+			category = strings.TrimSpace(strings.Trim(line, "="))
+		default:
+			categories[strings.Fields(line)[0]] = category
+		}
+	}
+
+	return categories
+}
+
+var (
+	argLine    = regexp.MustCompile(`^(\d+)\.\s+"?([A-Za-z0-9_]+)"?\s+\(([^)]*)\)\s*(.*)$`)
+	resultLine = regexp.MustCompile(`\((string|numeric|boolean|json object|json array|json null|string or numeric)\b`)
+)
+
+// parseMethod parses the output of help for a single method:
+//
+//	getblockhash height
+//
+//	Returns hash of block in best-block-chain at height provided.
+//
+//	Arguments:
+//	1. height    (numeric, required) The height index
+//
+//	Result:
+//	"hex"    (string) The block hash
+func parseMethod(name, category, text string) (Method, error) {
+	m := Method{Name: name, Category: category}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) == 0 || !strings.HasPrefix(strings.TrimSpace(lines[0]), name) {
+		return m, fmt.Errorf("help for %s does not start with its synopsis", name)
+	}
+
+	var description []string
+	section := "description"
+	results := 0
+
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "Arguments:":
+			section = "arguments"
+			continue
+		case trimmed == "Result:" || strings.HasPrefix(trimmed, "Result ("):
+			section = "result"
+			results++
+			continue
+		case trimmed == "Examples:":
+			section = "examples"
+			continue
+		}
+
+		switch section {
+		case "description":
+			if trimmed != "" || len(description) > 0 {
+				description = append(description, trimmed)
+			}
+		case "arguments":
+			// Members of object and array arguments are indented
+			match := argLine.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+
+			arg := Arg{Name: match[2], Description: strings.TrimSpace(match[4])}
+			for j, part := range strings.Split(match[3], ",") {
+				part = strings.TrimSpace(part)
+				if j == 0 {
+					arg.Type = part
+				} else if strings.HasPrefix(part, "optional") {
+					arg.Optional = true
+				}
+			}
+			m.Args = append(m.Args, arg)
+		case "result":
+			if results == 1 && m.Result == "" && trimmed != "" {
+				if match := resultLine.FindStringSubmatch(trimmed); match != nil {
+					m.Result = match[1]
+				} else if trimmed == "null" {
+					m.Result = "json null"
+				}
+			}
+		}
+	}
+
+	// Results differing by argument, e.g. by verbosity, are left raw
+	if results > 1 {
+		m.Result = ""
+	}
+
+	m.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return m, nil
+}
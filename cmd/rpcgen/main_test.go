@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const helpIndex = `== Blockchain ==
+getbestblockhash
+getblockfilter "blockhash" ( "filtertype" )
+
+== Wallet ==
+abandontransaction "txid"
+walletlock
+`
+
+const helpGetBlockFilter = `getblockfilter "blockhash" ( "filtertype" )
+
+Retrieve a BIP 157 content filter for a particular block.
+
+Arguments:
+1. blockhash     (string, required) The hash of the block
+2. filtertype    (string, optional, default="basic") The type name of the filter
+
+Result:
+{                (json object)
+  "filter" : "hex",    (string) the hex-encoded filter data
+  "header" : "hex"     (string) the hex-encoded filter header
+}
+
+Examples:
+> bitcoin-cli getblockfilter "00000000c937983704a73af28acdec37b049d214adbda81d7e2a3dd146f6ed09" "basic"
+`
+
+const helpSetTxFee = `settxfee amount
+
+Set the transaction fee rate in BTC/kvB for this wallet. Overrides the global -paytxfee command line parameter.
+Can be deactivated by passing 0 as the fee. In that case automatic fee selection will be used by default.
+
+Arguments:
+1. amount    (numeric or string, required) The transaction fee rate in BTC/kvB
+
+Result:
+true|false    (boolean) Returns true if successful
+
+Examples:
+> bitcoin-cli settxfee 0.00001
+`
+
+const helpGetRawChangeAddress = `getrawchangeaddress ( "address_type" )
+
+Returns a new Bitcoin address, for receiving change.
+This is for use with raw transactions, NOT normal use.
+
+Arguments:
+1. address_type    (string, optional, default=set by -changetype) The address type to use. Options are "legacy", "p2sh-segwit", "bech32", and "bech32m".
+
+Result:
+"str"    (string) The address
+`
+
+const helpWaitForNewBlock = `waitfornewblock ( timeout )
+
+Waits for a specific new block and returns useful info about it.
+
+Arguments:
+1. timeout    (numeric, optional, default=0) Time in milliseconds to wait for a response. 0 indicates no timeout.
+
+Result:
+{                       (json object)
+  "hash" : "hex",       (string) The blockhash
+  "height" : n          (numeric) Block height
+}
+`
+
+const helpWalletLock = `walletlock
+
+Removes the wallet encryption key from memory, locking the wallet.
+
+Result:
+null    (json null)
+`
+
+func TestParseHelp(t *testing.T) {
+	categories := parseIndex(helpIndex)
+	assert.Equal(t, map[string]string{
+		"getbestblockhash":   "Blockchain",
+		"getblockfilter":     "Blockchain",
+		"abandontransaction": "Wallet",
+		"walletlock":         "Wallet",
+	}, categories)
+
+	m, err := parseMethod("getblockfilter", "Blockchain", helpGetBlockFilter)
+	require.NoError(t, err)
+	assert.Equal(t, "Retrieve a BIP 157 content filter for a particular block.", m.Description)
+	assert.Equal(t, "json object", m.Result)
+	assert.Equal(t, []Arg{
+		{Name: "blockhash", Type: "string", Description: "The hash of the block"},
+		{Name: "filtertype", Type: "string", Optional: true, Description: "The type name of the filter"},
+	}, m.Args)
+
+	m, err = parseMethod("settxfee", "Wallet", helpSetTxFee)
+	require.NoError(t, err)
+	assert.Equal(t, "boolean", m.Result)
+	assert.Equal(t, "numeric or string", m.Args[0].Type)
+
+	m, err = parseMethod("walletlock", "Wallet", helpWalletLock)
+	require.NoError(t, err)
+	assert.Equal(t, "json null", m.Result)
+	assert.Empty(t, m.Args)
+
+	_, err = parseMethod("getblockfilter", "Blockchain", helpWalletLock)
+	assert.Error(t, err)
+}
+
+func TestGoName(t *testing.T) {
+	for method, name := range map[string]string{
+		"getblockchaininfo":            "GetBlockchainInfo",
+		"getnettotals":                 "GetNetTotals",
+		"gettxoutsetinfo":              "GetTxOutSetInfo",
+		"walletcreatefundedpsbt":       "WalletCreateFundedPSBT",
+		"listaddressgroupings":         "ListAddressGroupings",
+		"getnetworkhashps":             "GetNetworkHashPS",
+		"signrawtransactionwithwallet": "SignRawTransactionWithWallet",
+		"getzmqnotifications":          "GetZMQNotifications",
+		"getfooinfo":                   "GetFooInfo",
+	} {
+		assert.Equal(t, name, goName(method), method)
+	}
+
+	assert.Equal(t, "ConfTarget", fieldName("conf_target"))
+	assert.Equal(t, "confTarget", paramName("conf_target"))
+	assert.Equal(t, "psbt", paramName("psbt"))
+	assert.Equal(t, "typeArg", paramName("type"))
+}
+
+func TestGenerate(t *testing.T) {
+	api := &API{}
+	for name, text := range map[string]string{
+		"getblockfilter":      helpGetBlockFilter,
+		"settxfee":            helpSetTxFee,
+		"getrawchangeaddress": helpGetRawChangeAddress,
+		"waitfornewblock":     helpWaitForNewBlock,
+		"walletlock":          helpWalletLock,
+	} {
+		m, err := parseMethod(name, "", text)
+		require.NoError(t, err)
+		api.Methods = append(api.Methods, m)
+	}
+
+	src, err := generate(api, "bitcoin", map[string]bool{"waitfornewblock": true}, map[string]bool{"WalletLock": true})
+	require.NoError(t, err)
+	code := string(src)
+
+	assert.Contains(t, code, "// Code generated by rpcgen from the help output of a node. DO NOT EDIT.")
+	assert.Contains(t, code, "import (\n\t\"context\"\n\t\"encoding/json\"\n)")
+
+	assert.Contains(t, code, "// GetBlockFilter calls getblockfilter. Retrieve a BIP 157 content filter for a particular block.\n")
+	assert.Contains(t, code, "func (b *Bitcoind) GetBlockFilter(blockhash string, opts *GetBlockFilterOptions) (result json.RawMessage, err error) {")
+	assert.Contains(t, code, "Filtertype *string // The type name of the filter")
+	assert.Contains(t, code, "params = append(params, opts.Filtertype)")
+	assert.Contains(t, code, "\treturn b.GetBlockFilterCtx(context.Background(), blockhash, opts)\n")
+	assert.Contains(t, code, "func (b *Bitcoind) GetBlockFilterCtx(ctx context.Context, blockhash string, opts *GetBlockFilterOptions) (result json.RawMessage, err error) {")
+	assert.Contains(t, code, `err = b.callGenerated(ctx, "getblockfilter", params, &result)`)
+
+	assert.Contains(t, code, "func (b *Bitcoind) SetTxFee(amount interface{}) (result bool, err error) {")
+	assert.Contains(t, code, "func (b *Bitcoind) GetRawChangeAddress(opts *GetRawChangeAddressOptions) (result string, err error) {")
+	assert.Contains(t, code, "AddressType *string")
+
+	// Wrapped by hand
+	assert.NotContains(t, code, "WaitForNewBlock")
+	assert.NotContains(t, code, "walletlock")
+}
+
+func TestGenerateFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rpc.go"), []byte(`package bitcoin
+
+type Bitcoind struct{}
+
+func (b *Bitcoind) call(method string, params []interface{}) {}
+
+func (b *Bitcoind) GetBlockFilter() { b.call("getblockfilter", nil) }
+`), 0o644))
+
+	m, err := parseMethod("walletlock", "Wallet", helpWalletLock)
+	require.NoError(t, err)
+	data, err := json.Marshal(API{Methods: []Method{m}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api.json"), data, 0o644))
+
+	out := filepath.Join(dir, "rpc_generated.go")
+	var stderr strings.Builder
+	require.Equal(t, 0, run([]string{"-api", filepath.Join(dir, "api.json"), "-out", out}, &stderr), stderr.String())
+
+	src, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "func (b *Bitcoind) WalletLock() error {")
+	assert.NotContains(t, string(src), "encoding/json")
+
+	assert.Equal(t, 1, run([]string{"-api", filepath.Join(dir, "missing.json"), "-out", out}, &stderr))
+	assert.Contains(t, stderr.String(), "rpcgen -dump")
+}
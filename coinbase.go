@@ -0,0 +1,208 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// witnessCommitmentHeader starts the OP_RETURN output committing to the witness data of a block (BIP141).
+var witnessCommitmentHeader = []byte{0x6a, 0x24, 0xaa, 0x21, 0xa9, 0xed}
+
+// PoolTag maps a string found in coinbase scripts to the mining pool using it.
+type PoolTag struct {
+	Tag  string
+	Pool string
+}
+
+// KnownPoolTags are the coinbase tags AnalyzeCoinbase uses to identify pools, matched case-insensitively in
+// order. Applications can append their own tags or replace the list.
+var KnownPoolTags = []PoolTag{
+	{"Foundry USA", "Foundry USA"},
+	{"AntPool", "AntPool"},
+	{"F2Pool", "F2Pool"},
+	{"ViaBTC", "ViaBTC"},
+	{"MARA Pool", "MARA Pool"},
+	{"SpiderPool", "SpiderPool"},
+	{"Binance", "Binance Pool"},
+	{"/slush/", "Braiins Pool"},
+	{"Luxor", "Luxor"},
+	{"SECPOOL", "SECPOOL"},
+	{"OCEAN.XYZ", "OCEAN"},
+	{"poolin", "Poolin"},
+	{"BTC.COM", "BTC.com"},
+}
+
+// minCoinbaseTagLength is the shortest run of printable characters reported as a tag.
+const minCoinbaseTagLength = 4
+
+// CoinbaseInfo is what AnalyzeCoinbase finds in the coinbase transaction of a block.
+type CoinbaseInfo struct {
+	BlockHash string
+	TxID      string
+	// Height is the block height encoded in the coinbase script (BIP34), -1 for blocks before version 2
+	// or if the script does not start with a height.
+	Height int64
+	// Tags are the runs of printable characters in the coinbase script, such as pool names and messages.
+	Tags []string
+	// Pool is the pool identified from the tags using KnownPoolTags, empty if unknown.
+	Pool string
+	// WitnessCommitment is the hex encoded witness commitment of the block, empty if the block has none.
+	WitnessCommitment string
+	PayoutAddresses   []string // addresses of the outputs paying the reward
+	Reward            uint64   // satoshis, sum of the coinbase outputs
+	Subsidy           uint64   // satoshis, the block subsidy at Height, 0 if Height is unknown
+	Fees              uint64   // satoshis, the part of the reward above the subsidy
+}
+
+// AnalyzeCoinbase parses the coinbase transaction of a serialized block. Addresses and the subsidy are
+// derived with params, nil means MainNetParams.
+func AnalyzeCoinbase(rawBlock []byte, params *ChainParams) (*CoinbaseInfo, error) {
+	if params == nil {
+		params = MainNetParams
+	}
+
+	block, err := parseBlock(rawBlock)
+	if err != nil {
+		return nil, err
+	}
+	if len(block.Txs) == 0 || len(block.Txs[0].Inputs) != 1 {
+		return nil, fmt.Errorf("block %s has no coinbase transaction", block.Hash)
+	}
+
+	coinbase := block.Txs[0]
+	script := coinbase.Inputs[0].ScriptSig
+
+	// BIP34 heights are only found in blocks of version 2 and later
+	height, n := int64(-1), 0
+	if block.Version >= 2 {
+		height, n = coinbaseHeight(script)
+	}
+
+	info := &CoinbaseInfo{
+		BlockHash: block.Hash,
+		TxID:      coinbase.TxID,
+		Height:    height,
+		Tags:      coinbaseTags(script[n:]),
+	}
+
+	lower := strings.ToLower(string(script))
+	for _, t := range KnownPoolTags {
+		if strings.Contains(lower, strings.ToLower(t.Tag)) {
+			info.Pool = t.Pool
+			break
+		}
+	}
+
+	for _, out := range coinbase.Outputs {
+		info.Reward += out.Value
+
+		// The last commitment counts if there are several
+		if len(out.ScriptPubKey) >= 38 && bytes.HasPrefix(out.ScriptPubKey, witnessCommitmentHeader) {
+			info.WitnessCommitment = hex.EncodeToString(out.ScriptPubKey[6:38])
+			continue
+		}
+
+		if out.Value > 0 {
+			if address, err := ScriptToAddress(out.ScriptPubKey, params); err == nil {
+				info.PayoutAddresses = append(info.PayoutAddresses, address)
+			}
+		}
+	}
+
+	if info.Height >= 0 {
+		info.Subsidy = BlockSubsidy(info.Height, params)
+		// Miners may claim less than they are allowed to
+		if info.Reward > info.Subsidy {
+			info.Fees = info.Reward - info.Subsidy
+		}
+	}
+
+	return info, nil
+}
+
+// AnalyzeCoinbase fetches the block with the given hash and analyzes its coinbase transaction using the
+// parameters of the node's chain.
+func (b *Bitcoind) AnalyzeCoinbase(blockHash string) (*CoinbaseInfo, error) {
+	chainInfo, err := b.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := ParamsForChain(chainInfo.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := b.GetRawBlock(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return AnalyzeCoinbase(raw, params)
+}
+
+// BlockSubsidy returns the newly created coins in satoshis a block at height may claim.
+func BlockSubsidy(height int64, params *ChainParams) uint64 {
+	halvings := height / params.SubsidyHalvingInterval
+	if halvings >= 64 {
+		return 0
+	}
+	return (50 * 1e8) >> uint(halvings)
+}
+
+// coinbaseHeight decodes the BIP34 height at the start of a coinbase script and returns it with the
+// number of bytes it takes, or -1 and 0 if there is none.
+func coinbaseHeight(script []byte) (int64, int) {
+	if len(script) == 0 {
+		return -1, 0
+	}
+
+	switch op := script[0]; {
+	case op == 0x00:
+		return 0, 1
+	case op >= 0x51 && op <= 0x60:
+		return int64(op - 0x50), 1
+	case op >= 1 && op <= 8 && len(script) > int(op) && script[op]&0x80 == 0: // script numbers are signed
+		var height int64
+		for i := int(op); i >= 1; i-- {
+			height = height<<8 | int64(script[i])
+		}
+		return height, int(op) + 1
+	}
+
+	return -1, 0
+}
+
+// coinbaseTags returns the runs of printable ASCII in script of at least minCoinbaseTagLength characters,
+// split at slashes as pools use them as separators.
+func coinbaseTags(script []byte) []string {
+	var tags []string
+	add := func(run string) {
+		for _, tag := range strings.Split(run, "/") {
+			if tag = strings.TrimSpace(tag); len(tag) >= minCoinbaseTagLength {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	start := -1
+	for i, c := range script {
+		if c >= 0x20 && c <= 0x7e {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			add(string(script[start:i]))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		add(string(script[start:]))
+	}
+
+	return tags
+}
@@ -0,0 +1,71 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCoinbaseBlock returns a version 0x20000000 block holding only a coinbase transaction with the given
+// script and outputs.
+func testCoinbaseBlock(script []byte, outputs ...output) []byte {
+	tx := &transaction{
+		Version: 2,
+		Inputs:  []input{{index: 0xffffffff, unlockingScript: script, sequence: SequenceFinal}},
+		Outputs: outputs,
+	}
+
+	header := make([]byte, 80)
+	header[3] = 0x20
+	return append(append(header, 1), tx.ToHex()...)
+}
+
+func TestAnalyzeCoinbase(t *testing.T) {
+	script := append([]byte{0x03, 0x40, 0xd1, 0x0c}, []byte("\x1b/Foundry USA Pool #dropgold/\x00\x01\x02\x03")...)
+	payout, _ := hex.DecodeString("0014751e76e8199196d454941c45d1b3a323f1433bd6")
+	commitment, _ := hex.DecodeString("6a24aa21a9ed" + "e2f61c3f71d1defd3fa999dfa36953755c690689799962b48bebd836974e8cf9")
+
+	info, err := AnalyzeCoinbase(testCoinbaseBlock(script, output{value: 312500000 + 12345678, lockingScript: payout}, output{lockingScript: commitment}), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(840000), info.Height)
+	assert.Equal(t, []string{"Foundry USA Pool #dropgold"}, info.Tags)
+	assert.Equal(t, "Foundry USA", info.Pool)
+	assert.Equal(t, "e2f61c3f71d1defd3fa999dfa36953755c690689799962b48bebd836974e8cf9", info.WitnessCommitment)
+	assert.Equal(t, []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}, info.PayoutAddresses)
+	assert.Equal(t, uint64(312500000), info.Subsidy)
+	assert.Equal(t, uint64(12345678), info.Fees)
+
+	// Regtest heights below 17 are pushed as small integers
+	info, err = AnalyzeCoinbase(testCoinbaseBlock([]byte{0x5c, 0x00}, output{value: 2500000000, lockingScript: payout}), RegTestParams)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), info.Height)
+	assert.Equal(t, []string{"bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"}, info.PayoutAddresses)
+	assert.Equal(t, uint64(5000000000), info.Subsidy)
+	assert.Equal(t, uint64(0), info.Fees)
+	assert.Empty(t, info.Pool)
+}
+
+func TestAnalyzeGenesisCoinbase(t *testing.T) {
+	raw, _ := hex.DecodeString(genesisBlockHex)
+
+	info, err := AnalyzeCoinbase(raw, MainNetParams)
+	require.NoError(t, err)
+
+	// Version 1 blocks carry no height and the genesis output is a bare public key
+	assert.Equal(t, int64(-1), info.Height)
+	assert.Equal(t, "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", info.TxID)
+	assert.Contains(t, info.Tags, "2009 Chancellor on brink of second bailout for banks")
+	assert.Empty(t, info.PayoutAddresses)
+	assert.Equal(t, uint64(5000000000), info.Reward)
+	assert.Equal(t, uint64(0), info.Subsidy)
+}
+
+func TestBlockSubsidy(t *testing.T) {
+	assert.Equal(t, uint64(5000000000), BlockSubsidy(209999, MainNetParams))
+	assert.Equal(t, uint64(2500000000), BlockSubsidy(210000, MainNetParams))
+	assert.Equal(t, uint64(2500000000), BlockSubsidy(150, RegTestParams))
+	assert.Equal(t, uint64(0), BlockSubsidy(64*210000, MainNetParams))
+}
@@ -0,0 +1,166 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// changeDustLimit is the smallest change output CreateFundedRawTransaction adds, smaller change goes to
+// the fee.
+const changeDustLimit = 546
+
+// ErrInsufficientFunds is returned by CoinSelector when the outputs cannot cover the target and the fee.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// value returns the value of the output in satoshis.
+func (u *UnspentTransaction) value() uint64 {
+	if u.Satoshis > 0 {
+		return u.Satoshis
+	}
+	return uint64(math.Round(u.Amount * 1e8))
+}
+
+// CoinAge returns the age of the output weighted by its value, its confirmations times its value in
+// satoshis. Spending old coins first keeps the coin age of the wallet low.
+func (u *UnspentTransaction) CoinAge() uint64 {
+	return uint64(u.Confirmations) * u.value()
+}
+
+// CoinSelectionStrategy is the order in which CoinSelector considers outputs.
+type CoinSelectionStrategy int
+
+// Coin selection strategies.
+const (
+	// LargestFirst spends the fewest, largest outputs.
+	LargestFirst CoinSelectionStrategy = iota
+	// OldestFirst spends the outputs with the highest CoinAge first.
+	OldestFirst
+	// SmallestFirst spends small outputs first, consolidating the wallet at the cost of higher fees.
+	SmallestFirst
+)
+
+// CoinSelector picks wallet outputs to fund a transaction locally instead of leaving it to the wallet.
+type CoinSelector struct {
+	Strategy         CoinSelectionStrategy
+	FeeRate          float64 // sat/vB, the fee of every selected input is added to the target
+	InputVSize       int     // virtual size of an input, 0 means P2WPKH
+	MinConfirmations uint32
+	// AvoidAddressReuse spends all outputs of an address together, so that no funds stay on an address
+	// that was spent from and later spends do not link to it.
+	AvoidAddressReuse bool
+}
+
+// CoinSelection is the result of CoinSelector.Select.
+type CoinSelection struct {
+	Inputs   []*UnspentTransaction
+	Value    uint64 // satoshis, sum of the inputs
+	InputFee uint64 // satoshis, fee of the inputs at the selector's fee rate
+}
+
+// Select picks outputs worth at least target satoshis plus the fee of spending them. Outputs that are
+// unspendable, unsafe, below MinConfirmations or cost more to spend than they are worth are skipped.
+func (s *CoinSelector) Select(utxos []*UnspentTransaction, target uint64) (*CoinSelection, error) {
+	inputVSize := s.InputVSize
+	if inputVSize == 0 {
+		inputVSize = sweepInputVSize
+	}
+	inputFee := s.FeeRate * float64(inputVSize)
+
+	// Groups of outputs spent together, a single output each unless address reuse is avoided
+	var groups [][]*UnspentTransaction
+	byAddress := make(map[string]int)
+
+	for _, utxo := range utxos {
+		if !utxo.Spendable || !utxo.Safe || utxo.Confirmations < s.MinConfirmations || float64(utxo.value()) <= inputFee {
+			continue
+		}
+
+		if s.AvoidAddressReuse && utxo.Address != "" {
+			if i, found := byAddress[utxo.Address]; found {
+				groups[i] = append(groups[i], utxo)
+				continue
+			}
+			byAddress[utxo.Address] = len(groups)
+		}
+
+		groups = append(groups, []*UnspentTransaction{utxo})
+	}
+
+	sum := func(group []*UnspentTransaction, f func(*UnspentTransaction) uint64) (total uint64) {
+		for _, utxo := range group {
+			total += f(utxo)
+		}
+		return
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		switch s.Strategy {
+		case OldestFirst:
+			return sum(groups[i], (*UnspentTransaction).CoinAge) > sum(groups[j], (*UnspentTransaction).CoinAge)
+		case SmallestFirst:
+			return sum(groups[i], (*UnspentTransaction).value) < sum(groups[j], (*UnspentTransaction).value)
+		default:
+			return sum(groups[i], (*UnspentTransaction).value) > sum(groups[j], (*UnspentTransaction).value)
+		}
+	})
+
+	selection := &CoinSelection{}
+	for _, group := range groups {
+		selection.Inputs = append(selection.Inputs, group...)
+		selection.Value += sum(group, (*UnspentTransaction).value)
+		selection.InputFee = uint64(math.Ceil(inputFee * float64(len(selection.Inputs))))
+
+		if selection.Value >= target+selection.InputFee {
+			return selection, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %d satoshis available, %d needed", ErrInsufficientFunds, selection.Value, target+selection.InputFee)
+}
+
+// CreateFundedRawTransaction builds an unsigned transaction paying outputs (address to BTC amount) from
+// wallet outputs picked by selector, with change to changeAddress unless it would be dust. The fee is
+// selector.FeeRate times the estimated size of the transaction with P2WPKH outputs.
+func (b *Bitcoind) CreateFundedRawTransaction(outputs map[string]float64, changeAddress string, selector *CoinSelector) (string, *CoinSelection, error) {
+	var utxos []*UnspentTransaction
+	if err := b.uncachedCall("listunspent", []interface{}{0}, &utxos); err != nil {
+		return "", nil, err
+	}
+
+	var total uint64
+	amounts := make(map[string]interface{}, len(outputs)+1)
+	for address, amount := range outputs {
+		total += uint64(math.Round(amount * 1e8))
+		amounts[address] = json.Number(fmt.Sprintf("%.8f", amount))
+	}
+
+	// The fixed part of the fee, including a change output
+	fee := uint64(math.Ceil(selector.FeeRate * float64(consolidationOverheadVSize+consolidationOutputVSize*(len(outputs)+1))))
+
+	selection, err := selector.Select(utxos, total+fee)
+	if err != nil {
+		return "", nil, err
+	}
+
+	inputs := make([]map[string]interface{}, len(selection.Inputs))
+	for i, utxo := range selection.Inputs {
+		inputs[i] = map[string]interface{}{"txid": utxo.TXID, "vout": utxo.Vout}
+	}
+
+	if change := selection.Value - total - fee - selection.InputFee; change >= changeDustLimit {
+		if _, found := amounts[changeAddress]; found {
+			return "", nil, fmt.Errorf("change address %s is also a destination", changeAddress)
+		}
+		amounts[changeAddress] = json.Number(fmt.Sprintf("%d.%08d", change/1e8, change%1e8))
+	}
+
+	var txHex string
+	if err := b.uncachedCall("createrawtransaction", []interface{}{inputs, amounts}, &txHex); err != nil {
+		return "", nil, err
+	}
+
+	return txHex, selection, nil
+}
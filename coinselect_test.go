@@ -0,0 +1,100 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCoins() []*UnspentTransaction {
+	return []*UnspentTransaction{
+		{TXID: "young-large", Address: "a1", Amount: 0.5, Confirmations: 1, Spendable: true, Safe: true},
+		{TXID: "old-small", Address: "a2", Amount: 0.1, Confirmations: 1000, Spendable: true, Safe: true},
+		{TXID: "mid", Address: "a3", Amount: 0.2, Confirmations: 100, Spendable: true, Safe: true},
+		{TXID: "reused", Address: "a2", Amount: 0.01, Confirmations: 10, Spendable: true, Safe: true},
+		{TXID: "dust", Address: "a4", Amount: 0.00000500, Confirmations: 5000, Spendable: true, Safe: true},
+		{TXID: "unsafe", Address: "a5", Amount: 1, Confirmations: 0, Spendable: true, Safe: false},
+	}
+}
+
+func txids(utxos []*UnspentTransaction) []string {
+	var ids []string
+	for _, utxo := range utxos {
+		ids = append(ids, utxo.TXID)
+	}
+	return ids
+}
+
+func TestCoinAge(t *testing.T) {
+	assert.Equal(t, uint64(10000000000), testCoins()[1].CoinAge())
+	assert.Equal(t, uint64(0), (&UnspentTransaction{Amount: 1}).CoinAge())
+}
+
+func TestCoinSelector(t *testing.T) {
+	s := &CoinSelector{FeeRate: 10}
+
+	selection, err := s.Select(testCoins(), 60000000)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"young-large", "mid"}, txids(selection.Inputs))
+	assert.Equal(t, uint64(70000000), selection.Value)
+	assert.Equal(t, uint64(1360), selection.InputFee)
+
+	// The dust output costs more to spend than it is worth
+	s.Strategy = OldestFirst
+	selection, err = s.Select(testCoins(), 25000000)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old-small", "mid"}, txids(selection.Inputs))
+
+	s.Strategy = SmallestFirst
+	selection, err = s.Select(testCoins(), 25000000)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reused", "old-small", "mid"}, txids(selection.Inputs))
+
+	// Both outputs of a2 are spent together
+	s.AvoidAddressReuse = true
+	selection, err = s.Select(testCoins(), 5000000)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old-small", "reused"}, txids(selection.Inputs))
+
+	s.MinConfirmations = 50
+	_, err = s.Select(testCoins(), 50000000)
+	assert.True(t, errors.Is(err, ErrInsufficientFunds), "%v", err)
+}
+
+func TestCreateFundedRawTransaction(t *testing.T) {
+	var inputs, outputs string
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		result := `null`
+		switch req.Method {
+		case "listunspent":
+			data, _ := json.Marshal(testCoins())
+			result = string(data)
+		case "createrawtransaction":
+			params := req.Params.([]interface{})
+			inputs = fmt.Sprint(params[0])
+			outputs = fmt.Sprint(params[1])
+			result = `"unsigned"`
+		}
+
+		_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+	})
+	defer done()
+
+	txHex, selection, err := b.CreateFundedRawTransaction(map[string]float64{"dest": 0.15}, "change", &CoinSelector{Strategy: OldestFirst, FeeRate: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "unsigned", txHex)
+	assert.Equal(t, []string{"old-small", "mid"}, txids(selection.Inputs))
+	assert.Equal(t, "[map[txid:old-small vout:0] map[txid:mid vout:0]]", inputs)
+
+	// 11 + 2*31 vB of outputs and 2*68 vB of inputs at 2 sat/vB
+	assert.Equal(t, "map[change:0.14999582 dest:0.15]", outputs)
+}
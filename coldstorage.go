@@ -0,0 +1,182 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// psbtMagic starts every binary PSBT (BIP 174).
+var psbtMagic = []byte("psbt\xff")
+
+// coldStorageRange is the range of addresses imported for ranged descriptors.
+const coldStorageRange = 1000
+
+// ColdStorage packages the air gapped signing round trip for funds whose keys never touch this machine:
+//
+//  1. Watch imports the public descriptors of the cold wallet into a watch-only descriptor wallet.
+//  2. UTXOs and CreatePSBT select coins and build an unsigned PSBT.
+//  3. The PSBT is exported with WritePSBTFile, as base64 or as QR code chunks with PSBTQRChunks, and
+//     signed offline.
+//  4. The signed PSBT is read back with ReadPSBTFile or as base64 and passed to Broadcast.
+//
+// The Bitcoind must address the watch-only wallet, e.g. by using the path wallet/<name> when creating it.
+type ColdStorage struct {
+	b *Bitcoind
+}
+
+// NewColdStorage returns a ColdStorage using the given watch-only wallet.
+func NewColdStorage(watchOnly *Bitcoind) *ColdStorage {
+	return &ColdStorage{b: watchOnly}
+}
+
+// Watch imports descriptors, with or without checksum, into the watch-only wallet. Ranged descriptors are
+// imported as active so the wallet hands out their addresses. The wallet rescans the chain from rescanFrom,
+// or only watches new transactions when it is zero. Cancelling the context aborts the rescan.
+func (c *ColdStorage) Watch(ctx context.Context, rescanFrom time.Time, descriptors ...string) error {
+	var timestamp interface{} = "now"
+	if !rescanFrom.IsZero() {
+		timestamp = rescanFrom.Unix()
+	}
+
+	requests := make([]ImportDescriptorRequest, len(descriptors))
+	for i, desc := range descriptors {
+		info, err := c.b.GetDescriptorInfo(desc)
+		if err != nil {
+			return err
+		}
+
+		if info.HasPrivateKeys {
+			return fmt.Errorf("descriptor %d contains private keys", i)
+		}
+
+		requests[i] = ImportDescriptorRequest{Desc: info.Descriptor, Timestamp: timestamp}
+		if info.IsRange {
+			requests[i].Active = true
+			requests[i].Range = []int{0, coldStorageRange - 1}
+			// By convention the change branch of a BIP 44 style descriptor is /1/*
+			requests[i].Internal = strings.Contains(info.Descriptor, "/1/*")
+		}
+	}
+
+	res, err := c.b.ImportDescriptors(ctx, requests)
+	if err != nil {
+		return err
+	}
+
+	for i, r := range res {
+		if !r.Success {
+			msg := "unknown error"
+			if r.Error != nil {
+				msg = r.Error.Message
+			}
+			return fmt.Errorf("could not import descriptor %d: %s", i, msg)
+		}
+	}
+
+	return nil
+}
+
+// UTXOs returns the confirmed unspent outputs of the cold wallet.
+func (c *ColdStorage) UTXOs() ([]*UnspentTransaction, error) {
+	var utxos []*UnspentTransaction
+	err := c.b.uncachedCall("listunspent", []interface{}{1}, &utxos)
+	return utxos, err
+}
+
+// CreatePSBT builds an unsigned, replaceable PSBT paying the given outputs (address to BTC amount) at
+// feeRate (sat/vB), with change back to the cold wallet. The PSBT includes the BIP 32 derivation paths
+// the signing device needs.
+func (c *ColdStorage) CreatePSBT(outputs map[string]float64, feeRate float64) (*FundedPSBT, error) {
+	return c.b.WalletCreateFundedPSBT(outputs, map[string]interface{}{
+		"fee_rate":        feeRate,
+		"includeWatching": true,
+		"replaceable":     true,
+	})
+}
+
+// Broadcast combines the signed copies of a PSBT, finalizes it and sends the transaction, returning its txid.
+func (c *ColdStorage) Broadcast(signed ...string) (string, error) {
+	if len(signed) == 0 {
+		return "", errors.New("no signed PSBT")
+	}
+
+	psbt := signed[0]
+	if len(signed) > 1 {
+		var err error
+		if psbt, err = c.b.CombinePSBT(signed); err != nil {
+			return "", err
+		}
+	}
+
+	final, err := c.b.FinalizePSBT(psbt)
+	if err != nil {
+		return "", err
+	}
+
+	if !final.Complete {
+		return "", errors.New("PSBT is not fully signed")
+	}
+
+	return c.b.broadcast(final.Hex)
+}
+
+// WritePSBTFile writes a base64 encoded PSBT to a file in the binary format signing devices expect.
+func WritePSBTFile(path string, psbt string) error {
+	raw, err := base64.StdEncoding.DecodeString(psbt)
+	if err != nil {
+		return fmt.Errorf("invalid PSBT: %w", err)
+	}
+
+	if !bytes.HasPrefix(raw, psbtMagic) {
+		return errors.New("invalid PSBT: missing magic bytes")
+	}
+
+	return os.WriteFile(path, raw, 0600)
+}
+
+// ReadPSBTFile reads a PSBT file in binary or base64 format and returns it base64 encoded.
+func ReadPSBTFile(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.HasPrefix(raw, psbtMagic) {
+		return base64.StdEncoding.EncodeToString(raw), nil
+	}
+
+	psbt := strings.TrimSpace(string(raw))
+	if decoded, err := base64.StdEncoding.DecodeString(psbt); err != nil || !bytes.HasPrefix(decoded, psbtMagic) {
+		return "", fmt.Errorf("%s does not contain a PSBT", path)
+	}
+
+	return psbt, nil
+}
+
+// PSBTQRChunks splits a base64 encoded PSBT into parts of at most maxLen characters of payload for display
+// as a sequence of QR codes, in the "pMofN payload" format read by several signing devices. A PSBT that
+// fits into one part is returned unprefixed. Rendering the QR codes is left to the caller.
+func PSBTQRChunks(psbt string, maxLen int) []string {
+	if maxLen <= 0 || len(psbt) <= maxLen {
+		return []string{psbt}
+	}
+
+	n := (len(psbt) + maxLen - 1) / maxLen
+	chunks := make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		end := (i + 1) * maxLen
+		if end > len(psbt) {
+			end = len(psbt)
+		}
+		chunks = append(chunks, fmt.Sprintf("p%dof%d %s", i+1, n, psbt[i*maxLen:end]))
+	}
+
+	return chunks
+}
@@ -0,0 +1,226 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPSBTFiles(t *testing.T) {
+	psbt := base64.StdEncoding.EncodeToString(append([]byte("psbt\xff"), make([]byte, 40)...))
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "unsigned.psbt")
+	if err := WritePSBTFile(path, psbt); err != nil {
+		t.Fatal(err)
+	}
+
+	if raw, _ := os.ReadFile(path); !strings.HasPrefix(string(raw), "psbt\xff") {
+		t.Errorf("expected a binary PSBT file")
+	}
+
+	// Signing devices return either format
+	b64 := filepath.Join(dir, "signed.txt")
+	_ = os.WriteFile(b64, []byte(psbt+"\n"), 0600)
+
+	for _, p := range []string{path, b64} {
+		if got, err := ReadPSBTFile(p); err != nil || got != psbt {
+			t.Errorf("%s: got %q, %v", p, got, err)
+		}
+	}
+
+	if err := WritePSBTFile(path, base64.StdEncoding.EncodeToString([]byte("not a psbt"))); err == nil {
+		t.Error("expected an error for data without magic bytes")
+	}
+
+	chunks := PSBTQRChunks(psbt, 30)
+	if len(chunks) != 2 || !strings.HasPrefix(chunks[0], "p1of2 ") || !strings.HasPrefix(chunks[1], "p2of2 ") {
+		t.Errorf("unexpected chunks %v", chunks)
+	}
+
+	if chunks := PSBTQRChunks(psbt, 1000); len(chunks) != 1 || chunks[0] != psbt {
+		t.Errorf("unexpected single chunk %v", chunks)
+	}
+}
+
+func TestColdStorageBroadcast(t *testing.T) {
+	var methods []string
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		methods = append(methods, req.Method)
+
+		result := map[string]string{
+			"combinepsbt":        `"combined"`,
+			"finalizepsbt":       `{"hex":"0200","complete":true}`,
+			"sendrawtransaction": `"txid"`,
+		}[req.Method]
+
+		_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+	})
+	defer done()
+
+	txid, err := NewColdStorage(b).Broadcast("signer1", "signer2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if txid != "txid" || strings.Join(methods, ",") != "combinepsbt,finalizepsbt,sendrawtransaction" {
+		t.Errorf("unexpected txid %q after %v", txid, methods)
+	}
+}
+
+func TestColdStorageCreatePSBT(t *testing.T) {
+	var method string
+	var params []interface{}
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		method, params = req.Method, req.Params.([]interface{})
+
+		_, _ = w.Write([]byte(`{"result":{"psbt":"cHNidP8B","fee":0.0000141,"changepos":1},"error":null,"id":1}`))
+	})
+	defer done()
+
+	psbt, err := NewColdStorage(b).CreatePSBT(map[string]float64{"bc1qdest": 0.5}, 12.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if psbt.PSBT != "cHNidP8B" || psbt.Fee != 0.0000141 || psbt.ChangePos != 1 {
+		t.Errorf("unexpected PSBT %+v", psbt)
+	}
+
+	// No inputs, letting the wallet select watched coins, no locktime, and BIP 32 derivation paths included
+	expected := `[[],{"bc1qdest":0.5},0,{"fee_rate":12.5,"includeWatching":true,"replaceable":true},true]`
+	if got, _ := json.Marshal(params); method != "walletcreatefundedpsbt" || string(got) != expected {
+		t.Errorf("unexpected call %s %s", method, got)
+	}
+}
+
+// descriptorNode resolves descriptors containing "*" as ranged and those containing "xprv" as having private
+// keys, and records the params of importdescriptors, failing the import of descriptors containing "fail".
+type descriptorNode struct {
+	imported []interface{}
+}
+
+func (n *descriptorNode) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	params := req.Params.([]interface{})
+
+	var result interface{}
+	switch req.Method {
+	case "getdescriptorinfo":
+		desc := params[0].(string)
+		if i := strings.Index(desc, "#"); i >= 0 {
+			desc = desc[:i]
+		}
+		result = map[string]interface{}{
+			"descriptor":     desc + "#checksum",
+			"isrange":        strings.Contains(desc, "*"),
+			"hasprivatekeys": strings.Contains(desc, "xprv"),
+		}
+	case "importdescriptors":
+		n.imported = params
+		var res []interface{}
+		for _, req := range params[0].([]interface{}) {
+			if strings.Contains(req.(map[string]interface{})["desc"].(string), "fail") {
+				res = append(res, map[string]interface{}{"success": false, "error": map[string]interface{}{"code": -5, "message": "Invalid descriptor"}})
+			} else {
+				res = append(res, map[string]interface{}{"success": true})
+			}
+		}
+		result = res
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"result": result, "error": nil, "id": 1})
+	_, _ = w.Write(data)
+}
+
+func TestColdStorageWatch(t *testing.T) {
+	node := &descriptorNode{}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	c := NewColdStorage(b)
+
+	err := c.Watch(context.Background(), time.Unix(1700000000, 0), "wpkh(xpub/0/*)", "wpkh(xpub/1/*)#oldsum", "addr(bc1qsingle)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ranged descriptors are active, the /1/* branch is the change branch, and all rescan from the given time
+	expected := `[[` +
+		`{"active":true,"desc":"wpkh(xpub/0/*)#checksum","range":[0,999],"timestamp":1700000000},` +
+		`{"active":true,"desc":"wpkh(xpub/1/*)#checksum","internal":true,"range":[0,999],"timestamp":1700000000},` +
+		`{"desc":"addr(bc1qsingle)#checksum","timestamp":1700000000}]]`
+	if got, _ := json.Marshal(node.imported); string(got) != expected {
+		t.Errorf("unexpected importdescriptors params %s", got)
+	}
+
+	// Without a rescan only new transactions are watched
+	if err := c.Watch(context.Background(), time.Time{}, "addr(bc1qsingle)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := json.Marshal(node.imported); string(got) != `[[{"desc":"addr(bc1qsingle)#checksum","timestamp":"now"}]]` {
+		t.Errorf("unexpected importdescriptors params %s", got)
+	}
+
+	// Private keys never reach the watch-only wallet
+	node.imported = nil
+	if err := c.Watch(context.Background(), time.Time{}, "addr(bc1qsingle)", "wpkh(xprv/0/*)"); err == nil || node.imported != nil {
+		t.Errorf("expected an error without importing, got %v after importing %v", err, node.imported)
+	}
+
+	if err := c.Watch(context.Background(), time.Time{}, "addr(bc1qsingle)", "addr(fail)"); err == nil || !strings.Contains(err.Error(), "descriptor 1: Invalid descriptor") {
+		t.Errorf("expected the failed import, got %v", err)
+	}
+}
+
+func TestWalletProcessPSBT(t *testing.T) {
+	var params []interface{}
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+
+		_, _ = w.Write([]byte(`{"result":{"psbt":"cHNidP8B","complete":false},"error":null,"id":1}`))
+	})
+	defer done()
+
+	res, err := b.WalletProcessPSBT("cHNidP8A", &ProcessPSBTOptions{SigHashType: SigHashSingle | SigHashAnyoneCanPay, DontFinalize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `["cHNidP8A",true,"SINGLE|ANYONECANPAY",true,false]`
+	if got, _ := json.Marshal(params); string(got) != expected || res.PSBT != "cHNidP8B" || res.Complete {
+		t.Errorf("unexpected params %s and result %+v", got, res)
+	}
+
+	if _, err := b.DescriptorProcessPSBT("cHNidP8A", []string{"wpkh(xprv/0/*)"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `["cHNidP8A",["wpkh(xprv/0/*)"],"DEFAULT",true,true]`
+	if got, _ := json.Marshal(params); string(got) != expected {
+		t.Errorf("unexpected params %s", got)
+	}
+
+	if _, err := b.WalletProcessPSBT("cHNidP8A", &ProcessPSBTOptions{SigHashType: 0x04}); err == nil {
+		t.Error("expected an error for an unknown hash type")
+	}
+}
@@ -0,0 +1,225 @@
+package bitcoin
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+// shortIDSize is the size in bytes of the short transaction IDs of a compact block.
+const shortIDSize = 6
+
+// PrefilledTx is a transaction sent in full with a compact block, usually the coinbase, and ones the sender
+// expects the receiver not to have.
+type PrefilledTx struct {
+	Index int // in the block
+	TxID  string
+	WTxID string
+	Raw   []byte
+}
+
+// CompactBlock is a BIP152 cmpctblock message: the header of a block, a short ID for every transaction the
+// receiver is expected to have in its mempool and the other transactions in full. The short IDs are
+// computed from the wtxids of the transactions for version 2 compact blocks, which nodes supporting segwit
+// use, and from their txids for version 1.
+type CompactBlock struct {
+	Hash         string
+	PrevHash     string
+	MerkleRoot   string
+	Time         uint32
+	Bits         uint32
+	Nonce        uint64 // of the compact block, keying the short IDs, not the nonce of the header
+	Header       []byte
+	ShortIDs     []uint64 // of the transactions not prefilled, in block order
+	PrefilledTxs []PrefilledTx
+
+	k0, k1 uint64
+}
+
+// DecodeCompactBlock decodes the payload of a cmpctblock message, e.g. as published by a P2P bridge.
+func DecodeCompactBlock(raw []byte) (*CompactBlock, error) {
+	header, err := parseBlockHeader(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	cb := &CompactBlock{
+		Hash:       header.Hash,
+		PrevHash:   header.PrevHash,
+		MerkleRoot: header.MerkleRoot,
+		Time:       header.Time,
+		Bits:       header.Bits,
+		Header:     header.Header,
+	}
+
+	r := &byteReader{b: raw, pos: 80}
+	cb.Nonce = r.uint64()
+
+	n := r.count(shortIDSize)
+	cb.ShortIDs = make([]uint64, n)
+	for i := range cb.ShortIDs {
+		id := r.read(shortIDSize)
+		if id == nil {
+			break
+		}
+		cb.ShortIDs[i] = uint64(binary.LittleEndian.Uint32(id)) | uint64(binary.LittleEndian.Uint16(id[4:]))<<32
+	}
+
+	// Indexes are differentially encoded, each relative to the one before plus one
+	n = r.count(61)
+	cb.PrefilledTxs = make([]PrefilledTx, 0, n)
+	last := -1
+	for i := 0; i < n; i++ {
+		diff := r.varInt()
+		if r.err == nil && diff > uint64(n+len(cb.ShortIDs)) {
+			return nil, fmt.Errorf("failed to decode compact block %s: prefilled transaction index out of range", cb.Hash)
+		}
+		index := last + 1 + int(diff)
+
+		tx := readTx(r)
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to decode prefilled transaction %d of compact block %s: %w", i, cb.Hash, r.err)
+		}
+
+		cb.PrefilledTxs = append(cb.PrefilledTxs, PrefilledTx{Index: index, TxID: tx.TxID, WTxID: tx.WTxID, Raw: tx.Raw})
+		last = index
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to decode compact block %s: %w", cb.Hash, r.err)
+	}
+	if r.pos != len(raw) {
+		return nil, fmt.Errorf("failed to decode compact block %s: %d trailing bytes", cb.Hash, len(raw)-r.pos)
+	}
+	if last >= cb.TxCount() {
+		return nil, fmt.Errorf("failed to decode compact block %s: prefilled transaction index %d out of range", cb.Hash, last)
+	}
+
+	cb.k0, cb.k1 = shortIDKeys(cb.Header, cb.Nonce)
+
+	return cb, nil
+}
+
+// TxCount returns the number of transactions in the block.
+func (cb *CompactBlock) TxCount() int {
+	return len(cb.ShortIDs) + len(cb.PrefilledTxs)
+}
+
+// ShortID returns the short ID of the transaction with the given wtxid, or txid for version 1 compact
+// blocks, in this compact block.
+func (cb *CompactBlock) ShortID(hash string) (uint64, error) {
+	h, err := hex.DecodeString(hash)
+	if err != nil || len(h) != 32 {
+		return 0, fmt.Errorf("invalid transaction hash %q", hash)
+	}
+
+	return sipHash24(cb.k0, cb.k1, cryptolib.ReverseBytes(h)) & (1<<(8*shortIDSize) - 1), nil
+}
+
+// Match places the transactions with the given wtxids (txids for version 1), e.g. those of a mempool, in the
+// block, as a receiving node reconstructs it. It returns the matched hashes by index in the block and the
+// indexes of the transactions that would have to be requested with getblocktxn, which includes those whose
+// short ID matches more than one of hashes.
+func (cb *CompactBlock) Match(hashes []string) (map[int]string, []int, error) {
+	candidates := make(map[uint64][]string, len(hashes))
+	for _, hash := range hashes {
+		id, err := cb.ShortID(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		candidates[id] = append(candidates[id], hash)
+	}
+
+	prefilled := make(map[int]bool, len(cb.PrefilledTxs))
+	for _, tx := range cb.PrefilledTxs {
+		prefilled[tx.Index] = true
+	}
+
+	matched := make(map[int]string)
+	var missing []int
+
+	next := 0
+	for index := 0; index < cb.TxCount(); index++ {
+		if prefilled[index] {
+			continue
+		}
+
+		found := candidates[cb.ShortIDs[next]]
+		next++
+
+		if len(found) == 1 {
+			matched[index] = found[0]
+		} else {
+			missing = append(missing, index)
+		}
+	}
+
+	return matched, missing, nil
+}
+
+// shortIDKeys returns the SipHash keys of the short IDs of a compact block, the first two little endian
+// 64 bit words of the single SHA256 of its header and nonce.
+func shortIDKeys(header []byte, nonce uint64) (uint64, uint64) {
+	data := make([]byte, 88)
+	copy(data, header)
+	binary.LittleEndian.PutUint64(data[80:], nonce)
+
+	h := sha256.Sum256(data)
+	return binary.LittleEndian.Uint64(h[0:8]), binary.LittleEndian.Uint64(h[8:16])
+}
+
+// sipHash24 returns the SipHash-2-4 of msg with the key k0, k1.
+func sipHash24(k0, k1 uint64, msg []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+
+	compress := func(m uint64) {
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	length := len(msg)
+	for ; len(msg) >= 8; msg = msg[8:] {
+		compress(binary.LittleEndian.Uint64(msg))
+	}
+
+	// The last block holds the remaining bytes and the length of the message in its top byte
+	last := uint64(length) << 56
+	for i, b := range msg {
+		last |= uint64(b) << (8 * i)
+	}
+	compress(last)
+
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
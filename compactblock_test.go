@@ -0,0 +1,87 @@
+package bitcoin
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSipHash24(t *testing.T) {
+	// Test vectors of the SipHash paper, key 00 01 .. 0f
+	k0, k1 := uint64(0x0706050403020100), uint64(0x0f0e0d0c0b0a0908)
+	msg := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+
+	assert.Equal(t, uint64(0x726fdb47dd0e0e31), sipHash24(k0, k1, nil))
+	assert.Equal(t, uint64(0xa129ca6149be45e5), sipHash24(k0, k1, msg))
+}
+
+func TestDecodeCompactBlock(t *testing.T) {
+	coinbase := testTx([]Outpoint{{TxID: "0000000000000000000000000000000000000000000000000000000000000000", Vout: 0xffffffff}}, "51", 5000000000)
+	txs := [][]byte{
+		testTx([]Outpoint{{TxID: "1111111111111111111111111111111111111111111111111111111111111111", Vout: 0}}, "51", 1000),
+		testTx([]Outpoint{{TxID: "2222222222222222222222222222222222222222222222222222222222222222", Vout: 1}}, "51", 2000),
+		testTx([]Outpoint{{TxID: "3333333333333333333333333333333333333333333333333333333333333333", Vout: 2}}, "51", 3000),
+	}
+	wtxids := make([]string, len(txs))
+	for i, tx := range txs {
+		wtxid, err := ComputeWTxID(tx)
+		require.NoError(t, err)
+		wtxids[i] = wtxid
+	}
+
+	header := testBlock("0000000000000000000000000000000000000000000000000000000000000000", 7)[:80]
+	const nonce = 0x0123456789abcdef
+
+	// The coinbase at index 0 and the third transaction at index 3, with short IDs for the others
+	template := &CompactBlock{}
+	template.k0, template.k1 = shortIDKeys(header, nonce)
+
+	raw := append([]byte{}, header...)
+	raw = append(raw, le64(nonce)...)
+	raw = append(raw, 2)
+	for _, wtxid := range wtxids[:2] {
+		id, err := template.ShortID(wtxid)
+		require.NoError(t, err)
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], id)
+		raw = append(raw, b[:shortIDSize]...)
+	}
+	raw = append(raw, 2)
+	raw = append(raw, 0)
+	raw = append(raw, coinbase...)
+	raw = append(raw, 2) // index 0+1+2
+	raw = append(raw, txs[2]...)
+
+	cb, err := DecodeCompactBlock(raw)
+	require.NoError(t, err)
+
+	parsed, err := parseBlockHeader(header)
+	require.NoError(t, err)
+	assert.Equal(t, parsed.Hash, cb.Hash)
+	assert.Equal(t, uint64(nonce), cb.Nonce)
+	assert.Equal(t, 4, cb.TxCount())
+	require.Len(t, cb.PrefilledTxs, 2)
+	assert.Equal(t, 0, cb.PrefilledTxs[0].Index)
+	assert.Equal(t, 3, cb.PrefilledTxs[1].Index)
+	assert.Equal(t, wtxids[2], cb.PrefilledTxs[1].WTxID)
+
+	for i, wtxid := range wtxids[:2] {
+		id, err := cb.ShortID(wtxid)
+		require.NoError(t, err)
+		assert.Equal(t, cb.ShortIDs[i], id)
+		assert.Less(t, id, uint64(1)<<48)
+	}
+
+	// A mempool holding only the second transaction
+	matched, missing, err := cb.Match([]string{wtxids[1], "4444444444444444444444444444444444444444444444444444444444444444"})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]string{2: wtxids[1]}, matched)
+	assert.Equal(t, []int{1}, missing)
+
+	_, err = DecodeCompactBlock(raw[:len(raw)-1])
+	assert.Error(t, err)
+	_, err = DecodeCompactBlock(append(raw, 0))
+	assert.EqualError(t, err, "failed to decode compact block "+cb.Hash+": 1 trailing bytes")
+}
@@ -0,0 +1,242 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CompatibilityReport lists the differences between a stored node response and the typed struct it is
+// decoded into. Field paths are dotted, with [] standing for the elements of an array, e.g.
+// "vout[].scriptPubKey.addresses".
+type CompatibilityReport struct {
+	Version string // directory the response was read from, empty for CheckCompatibility
+	Method  string
+	Unknown []string // fields sent by the node that the struct ignores, e.g. added or renamed ones
+	Missing []string // fields of the struct, other than omitempty ones, the node did not send
+	Err     error    // the response could not be decoded at all
+}
+
+// OK reports whether the response matches the struct exactly.
+func (r CompatibilityReport) OK() bool {
+	return r.Err == nil && len(r.Unknown) == 0 && len(r.Missing) == 0
+}
+
+func (r CompatibilityReport) String() string {
+	name := r.Method
+	if r.Version != "" {
+		name = r.Version + "/" + r.Method
+	}
+
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s: %v", name, r.Err)
+	case r.OK():
+		return name + ": ok"
+	default:
+		return fmt.Sprintf("%s: unknown %v, missing %v", name, r.Unknown, r.Missing)
+	}
+}
+
+// CheckCompatibility compares a stored response body of the given method with the struct DecodeResult
+// decodes it into.
+func CheckCompatibility(method string, body []byte) CompatibilityReport {
+	report := CompatibilityReport{Method: method}
+
+	newValue, found := responseTypes[method]
+	if !found {
+		report.Err = fmt.Errorf("%w %q", ErrUnknownResponseType, method)
+		return report
+	}
+
+	if _, err := DecodeResponse(method, body); err != nil {
+		report.Err = err
+		return report
+	}
+
+	var r struct {
+		Result interface{} `json:"result"`
+	}
+	if err := unmarshal(body, &r); err != nil {
+		report.Err = err
+		return report
+	}
+
+	unknown := make(map[string]bool)
+	missing := make(map[string]bool)
+	compareFields(reflect.TypeOf(newValue()), r.Result, "", unknown, missing)
+
+	report.Unknown = sortedKeys(unknown)
+	report.Missing = sortedKeys(missing)
+
+	return report
+}
+
+// CheckCompatibilityDir checks the responses stored in dir as <version>/<method>.json, e.g. v25/getblock.json,
+// and returns a report per file. Each file holds a full JSON-RPC response body, such as one captured with curl
+// from a node of the version a deployment runs.
+func CheckCompatibilityDir(dir string) ([]CompatibilityReport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	reports := make([]CompatibilityReport, 0, len(files))
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		report := CheckCompatibility(strings.TrimSuffix(filepath.Base(file), ".json"), body)
+		report.Version = filepath.Base(filepath.Dir(file))
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// compareFields walks a decoded JSON value alongside the type it is decoded into, collecting the paths of
+// unknown and missing fields.
+func compareFields(t reflect.Type, v interface{}, path string, unknown, missing map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Types decoding themselves may map fields in any way
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		fields := jsonFields(t)
+
+		for key, value := range obj {
+			f, found := fields[key]
+			if !found {
+				// encoding/json falls back to a case insensitive match
+				for name, candidate := range fields {
+					if strings.EqualFold(name, key) {
+						f, found = candidate, true
+						break
+					}
+				}
+			}
+
+			if !found {
+				unknown[joinPath(path, key)] = true
+				continue
+			}
+
+			compareFields(f.typ, value, joinPath(path, key), unknown, missing)
+		}
+
+		for name, f := range fields {
+			if _, sent := obj[name]; !sent && !f.omitempty {
+				missing[joinPath(path, name)] = true
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return
+		}
+
+		for _, elem := range arr {
+			compareFields(t.Elem(), elem, path+"[]", unknown, missing)
+		}
+
+	case reflect.Map:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		for _, value := range obj {
+			compareFields(t.Elem(), value, path+".*", unknown, missing)
+		}
+	}
+}
+
+type jsonField struct {
+	typ       reflect.Type
+	omitempty bool
+}
+
+// jsonFields returns the fields of a struct type by their JSON name, including those of embedded structs.
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := make(map[string]jsonField)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.SplitN(tag, ",", 2)
+		name, opts := parts[0], ""
+		if len(parts) == 2 {
+			opts = parts[1]
+		}
+
+		if f.Anonymous && name == "" {
+			et := f.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				for n, ef := range jsonFields(et) {
+					if _, found := fields[n]; !found {
+						fields[n] = ef
+					}
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields[name] = jsonField{typ: f.Type, omitempty: strings.Contains(","+opts+",", ",omitempty,")}
+	}
+
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,55 @@
+package bitcoin
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	r := CheckCompatibility("getblockheader", []byte(`{"result":{"hash":"00","height":1,"extra":{"a":1}},"error":null,"id":1}`))
+	require.NoError(t, r.Err)
+	assert.Equal(t, []string{"extra"}, r.Unknown)
+	assert.Contains(t, r.Missing, "merkleroot")
+	assert.NotContains(t, r.Missing, "hash")
+	assert.False(t, r.OK())
+
+	// Nested structs and arrays
+	r = CheckCompatibility("getrawtransaction", []byte(`{"result":{"txid":"00","vout":[{"value":1,"n":0,"scriptPubKey":{"hex":"51","type":"nonstandard","new":true}}]},"error":null,"id":1}`))
+	require.NoError(t, r.Err)
+	assert.Equal(t, []string{"vout[].scriptPubKey.new"}, r.Unknown)
+	assert.Contains(t, r.Missing, "vout[].scriptPubKey.asm")
+	assert.NotContains(t, r.Missing, "vout[].scriptPubKey.addresses")
+
+	r = CheckCompatibility("getblockheader", []byte(`{"result":null,"error":{"code":-5,"message":"Block not found"},"id":1}`))
+	assert.EqualError(t, r.Err, "ERROR -5: Block not found")
+
+	r = CheckCompatibility("nosuchmethod", []byte(`{}`))
+	assert.ErrorIs(t, r.Err, ErrUnknownResponseType)
+}
+
+// The responses in testdata/compat are hand-written from the RPC help of each version, not recorded from
+// nodes, and only cover the fields whose changes the assertions below pin down.
+func TestCheckCompatibilityDir(t *testing.T) {
+	reports, err := CheckCompatibilityDir(filepath.Join("testdata", "compat"))
+	require.NoError(t, err)
+	require.Len(t, reports, 5)
+
+	byName := make(map[string]CompatibilityReport)
+	for _, r := range reports {
+		require.NoError(t, r.Err, r.String())
+		byName[r.Version+"/"+r.Method] = r
+	}
+
+	// Newer nodes only report the fees object, the deprecated top level fee fields are gone
+	assert.NotContains(t, byName["v22/getmempoolentry"].Missing, "fee")
+	assert.Contains(t, byName["v25/getmempoolentry"].Missing, "fee")
+	assert.Contains(t, byName["v27/getmempoolentry"].Missing, "modifiedfee")
+
+	// softforks moved to getdeploymentinfo
+	assert.Contains(t, byName["v22/getblockchaininfo"].Unknown, "softforks")
+	assert.NotContains(t, byName["v27/getblockchaininfo"].Unknown, "softforks")
+	assert.Contains(t, byName["v27/getblockchaininfo"].Unknown, "time")
+}
@@ -0,0 +1,184 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	confirmationsBatchSize   = 500
+	confirmationsParallelism = 4
+	blockHeightCacheDuration = time.Hour
+)
+
+// TxConfirmations is the confirmation state of a transaction as returned by ConfirmationsForTxids.
+type TxConfirmations struct {
+	Found         bool   // false when the node does not know the transaction
+	Confirmations int    // 0 while in the mempool
+	BlockHash     string // empty while in the mempool
+	BlockHeight   int
+	Confirmed     bool // Confirmations >= minConf
+}
+
+// ConfirmationsForTxids returns the confirmation state of many transactions at once. Lookups are sent as
+// JSON-RPC batches, several in parallel, and the heights of the containing blocks are cached, so that
+// checking thousands of deposits per cycle costs a handful of round trips. All confirmation counts are
+// computed against the same chain tip. Without -txindex only mempool transactions, and those in a TxIndex
+// set with UseTxIndex, are found.
+func (b *Bitcoind) ConfirmationsForTxids(txids []string, minConf int) (map[string]TxConfirmations, error) {
+	info, err := b.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	tip := int(info.Blocks)
+
+	found := make([]bool, len(txids))
+	blockHashes := make([]string, len(txids))
+
+	g, ctx := errgroup.WithContext(context.Background())
+	sem := make(chan struct{}, confirmationsParallelism)
+
+	for start := 0; start < len(txids); start += confirmationsBatchSize {
+		end := start + confirmationsBatchSize
+		if end > len(txids) {
+			end = len(txids)
+		}
+
+		start := start
+		params := make([][]interface{}, 0, end-start)
+		for _, txid := range txids[start:end] {
+			params = append(params, b.rawTransactionParams(txid, 1))
+		}
+
+		sem <- struct{}{}
+		g.Go(func() error {
+			defer func() { <-sem }()
+
+			responses, err := b.client.batch(ctx, "getrawtransaction", params)
+			if err != nil {
+				return err
+			}
+
+			for i, r := range responses {
+				if r.Err != nil {
+					// The transaction is unknown
+					if code, _ := errorCode(r.Err); code == RPCInvalidAddressOrKey {
+						continue
+					}
+					return fmt.Errorf("%w (txid %s)", responseError(r.Err), txids[start+i])
+				}
+
+				var tx struct {
+					BlockHash string `json:"blockhash"`
+				}
+				if err := json.Unmarshal(r.Result, &tx); err != nil {
+					return err
+				}
+
+				found[start+i] = true
+				blockHashes[start+i] = tx.BlockHash
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	heights, err := b.blockHeights(blockHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]TxConfirmations, len(txids))
+
+	for i, txid := range txids {
+		if !found[i] {
+			res[txid] = TxConfirmations{}
+			continue
+		}
+
+		c := TxConfirmations{Found: true, BlockHash: blockHashes[i]}
+		if c.BlockHash != "" {
+			c.BlockHeight = heights[c.BlockHash]
+			c.Confirmations = tip - c.BlockHeight + 1
+			if c.Confirmations < 1 {
+				// The block was connected after the tip was read
+				c.Confirmations = 1
+			}
+		}
+		c.Confirmed = c.Confirmations >= minConf
+
+		res[txid] = c
+	}
+
+	return res, nil
+}
+
+// blockHeights resolves the heights of the given blocks, batching the lookups of blocks not in the cache.
+// Empty hashes are skipped.
+func (b *Bitcoind) blockHeights(blockHashes []string) (map[string]int, error) {
+	heights := make(map[string]int)
+	var missing []string
+
+	for _, hash := range blockHashes {
+		if hash == "" {
+			continue
+		}
+
+		if _, found := heights[hash]; found {
+			continue
+		}
+
+		if height, found := b.Storage.Get("blockheight|" + hash); found {
+			heights[hash] = height.(int)
+			continue
+		}
+
+		heights[hash] = -1
+		missing = append(missing, hash)
+	}
+
+	for start := 0; start < len(missing); start += confirmationsBatchSize {
+		end := start + confirmationsBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		params := make([][]interface{}, 0, end-start)
+		for _, hash := range missing[start:end] {
+			params = append(params, []interface{}{hash, true})
+		}
+
+		responses, err := b.client.batch(context.Background(), "getblockheader", params)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, r := range responses {
+			if r.Err != nil {
+				return nil, fmt.Errorf("%w (block %s)", responseError(r.Err), missing[start+i])
+			}
+
+			var header struct {
+				Height int `json:"height"`
+			}
+			if err := json.Unmarshal(r.Result, &header); err != nil {
+				return nil, err
+			}
+
+			hash := missing[start+i]
+			heights[hash] = header.Height
+			// A block hash always maps to the same height, so this only expires to bound memory
+			b.Storage.Set("blockheight|"+hash, header.Height, blockHeightCacheDuration)
+		}
+	}
+
+	return heights, nil
+}
@@ -0,0 +1,85 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConfirmationsForTxids(t *testing.T) {
+	headerLookups := 0
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var reqs []rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			// Not a batch
+			_, _ = w.Write([]byte(`{"result":{"blocks":100},"error":null,"id":1}`))
+			return
+		}
+
+		var parts []string
+		for _, req := range reqs {
+			param := req.Params.([]interface{})[0].(string)
+
+			var result string
+			switch {
+			case req.Method == "getblockheader":
+				headerLookups++
+				result = `{"result":{"height":95},"error":null`
+			case param == "confirmed":
+				result = `{"result":{"blockhash":"block95"},"error":null`
+			case param == "mempool":
+				result = `{"result":{},"error":null`
+			default:
+				result = `{"result":null,"error":{"code":-5,"message":"No such mempool or blockchain transaction"}`
+			}
+			parts = append(parts, fmt.Sprintf(`%s,"id":%d}`, result, req.ID))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(parts, ",") + "]"))
+	})
+	defer done()
+
+	for i := 0; i < 2; i++ {
+		res, err := b.ConfirmationsForTxids([]string{"confirmed", "mempool", "unknown"}, 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c := res["confirmed"]; !c.Found || c.Confirmations != 6 || !c.Confirmed || c.BlockHeight != 95 {
+			t.Errorf("unexpected result for confirmed: %+v", c)
+		}
+
+		if c := res["mempool"]; !c.Found || c.Confirmations != 0 || c.Confirmed {
+			t.Errorf("unexpected result for mempool: %+v", c)
+		}
+
+		if c := res["unknown"]; c.Found {
+			t.Errorf("unexpected result for unknown: %+v", c)
+		}
+	}
+
+	if headerLookups != 1 {
+		t.Errorf("expected the block height to be cached, got %d lookups", headerLookups)
+	}
+}
+
+func TestConfirmationsForTxidsStringError(t *testing.T) {
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var reqs []rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			_, _ = w.Write([]byte(`{"result":{"blocks":100},"error":null,"id":1}`))
+			return
+		}
+
+		// Proxies in front of the node have been seen to send plain string errors
+		_, _ = fmt.Fprintf(w, `[{"result":null,"error":"upstream unavailable","id":%d}]`, reqs[0].ID)
+	})
+	defer done()
+
+	_, err := b.ConfirmationsForTxids([]string{"aa"}, 6)
+	if err == nil || !strings.Contains(err.Error(), "upstream unavailable") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
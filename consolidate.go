@@ -0,0 +1,219 @@
+package bitcoin
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// Virtual sizes used to estimate the fee of a consolidation transaction with a single P2WPKH output.
+const (
+	consolidationOverheadVSize = 11
+	consolidationOutputVSize   = 31
+)
+
+// ConsolidationPolicy configures when and how PlanConsolidation merges small wallet outputs.
+type ConsolidationPolicy struct {
+	MaxFeeRate     float64 // sat/vB; nothing is planned while the economical fee rate is higher
+	SmallUTXOValue uint64  // satoshis; outputs below this value are candidates
+	MinUTXOs       int     // nothing is planned while there are fewer candidates
+	MaxInputs      int     // inputs per consolidation transaction
+	MaxFee         uint64  // satoshis; total fee budget of a plan, 0 means no limit
+	InputVSize     int     // virtual size of an input, 0 means P2WPKH
+	ConfTarget     int     // confirmation target used to estimate the current fee rate, 0 means 144 blocks
+}
+
+// DefaultConsolidationPolicy consolidates at least 50 outputs below 0.001 BTC while fees are at most 3 sat/vB.
+var DefaultConsolidationPolicy = ConsolidationPolicy{
+	MaxFeeRate:     3,
+	SmallUTXOValue: 100000,
+	MinUTXOs:       50,
+	MaxInputs:      200,
+	MaxFee:         50000,
+}
+
+// ConsolidationTx is a planned transaction merging its inputs into a single output.
+type ConsolidationTx struct {
+	Inputs []*UnspentTransaction
+	Value  uint64 // satoshis, sum of the inputs
+	Fee    uint64 // satoshis, estimated
+}
+
+// ConsolidationPlan is the result of PlanConsolidation. It has no transactions when consolidating is not
+// worthwhile at the current fee rate.
+type ConsolidationPlan struct {
+	FeeRate  float64 // sat/vB
+	Txs      []ConsolidationTx
+	TotalFee uint64
+}
+
+// PlanConsolidation groups small outputs into consolidation transactions at the given fee rate (sat/vB),
+// smallest outputs first, keeping the total fee within the policy's budget. Outputs that cost more to
+// spend than they are worth are never included.
+func PlanConsolidation(utxos []*UnspentTransaction, feeRate float64, policy ConsolidationPolicy) *ConsolidationPlan {
+	plan := &ConsolidationPlan{FeeRate: feeRate}
+
+	if feeRate > policy.MaxFeeRate {
+		return plan
+	}
+
+	inputVSize := policy.InputVSize
+	if inputVSize == 0 {
+		inputVSize = sweepInputVSize
+	}
+
+	maxInputs := policy.MaxInputs
+	if maxInputs < 2 {
+		maxInputs = 2
+	}
+
+	inputFee := feeRate * float64(inputVSize)
+
+	var candidates []*UnspentTransaction
+	for _, utxo := range utxos {
+		value := uint64(math.Round(utxo.Amount * 1e8))
+		if utxo.Spendable && utxo.Safe && value < policy.SmallUTXOValue && float64(value) > inputFee {
+			candidates = append(candidates, utxo)
+		}
+	}
+
+	if len(candidates) < policy.MinUTXOs {
+		return plan
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Amount < candidates[j].Amount
+	})
+
+	for len(candidates) >= 2 {
+		n := maxInputs
+		if n > len(candidates) {
+			n = len(candidates)
+		}
+
+		tx := ConsolidationTx{Inputs: candidates[:n]}
+		for _, utxo := range tx.Inputs {
+			tx.Value += uint64(math.Round(utxo.Amount * 1e8))
+		}
+		tx.Fee = uint64(math.Ceil(feeRate * float64(consolidationOverheadVSize+consolidationOutputVSize+n*inputVSize)))
+
+		if policy.MaxFee > 0 && plan.TotalFee+tx.Fee > policy.MaxFee {
+			break
+		}
+
+		plan.Txs = append(plan.Txs, tx)
+		plan.TotalFee += tx.Fee
+		candidates = candidates[n:]
+	}
+
+	return plan
+}
+
+// PlanConsolidation plans the consolidation of the wallet's small outputs at the current economical fee rate.
+func (b *Bitcoind) PlanConsolidation(policy ConsolidationPolicy) (*ConsolidationPlan, error) {
+	confTarget := policy.ConfTarget
+	if confTarget == 0 {
+		confTarget = 144
+	}
+
+	estimate, err := b.EstimateSmartFee(confTarget, "economical")
+	if err != nil {
+		return nil, err
+	}
+
+	// Without an estimate, fall back to the minimum relay fee rate of 1 sat/vB
+	feeRate := 1.0
+	if estimate.FeeRate > 0 {
+		feeRate = estimate.FeeRate * 1e5
+	}
+
+	var utxos []*UnspentTransaction
+	if err := b.uncachedCall("listunspent", []interface{}{1}, &utxos); err != nil {
+		return nil, err
+	}
+
+	return PlanConsolidation(utxos, feeRate, policy), nil
+}
+
+// ExecuteConsolidation sends the transactions of a plan, each to a new address of the wallet, and returns
+// the txids of the transactions sent. On error the txids sent so far are returned with it.
+func (b *Bitcoind) ExecuteConsolidation(plan *ConsolidationPlan) ([]string, error) {
+	var txids []string
+
+	for _, tx := range plan.Txs {
+		var address string
+		if err := b.uncachedCall("getnewaddress", nil, &address); err != nil {
+			return txids, err
+		}
+
+		txid, err := b.spendInputsTo(tx.Inputs, address, plan.FeeRate)
+		if err != nil {
+			return txids, err
+		}
+
+		txids = append(txids, txid)
+	}
+
+	return txids, nil
+}
+
+// Consolidator plans and executes consolidations on a schedule.
+type Consolidator struct {
+	Policy ConsolidationPolicy
+	// DryRun only plans, without sending anything.
+	DryRun bool
+	// OnPlan is called after every run with the plan and the txids sent, if any.
+	OnPlan func(plan *ConsolidationPlan, txids []string)
+
+	b *Bitcoind
+}
+
+// NewConsolidator returns a Consolidator for the given wallet using DefaultConsolidationPolicy.
+func NewConsolidator(b *Bitcoind) *Consolidator {
+	return &Consolidator{Policy: DefaultConsolidationPolicy, b: b}
+}
+
+// RunOnce plans a consolidation and, unless DryRun is set, executes it.
+func (c *Consolidator) RunOnce() (*ConsolidationPlan, []string, error) {
+	plan, err := c.b.PlanConsolidation(c.Policy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.DryRun || len(plan.Txs) == 0 {
+		return plan, nil, nil
+	}
+
+	txids, err := c.b.ExecuteConsolidation(plan)
+	return plan, txids, err
+}
+
+// Run calls RunOnce every interval until the context is done.
+func (c *Consolidator) Run(ctx context.Context, interval time.Duration) {
+	ticker := c.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		plan, txids, err := c.RunOnce()
+		if err != nil {
+			c.b.client.logger.Errorf("Consolidator: %v", err)
+		}
+
+		if plan != nil {
+			if len(plan.Txs) > 0 {
+				c.b.client.logger.Infof("Consolidator: %d transactions at %.1f sat/vB, fee %d, sent %v", len(plan.Txs), plan.FeeRate, plan.TotalFee, txids)
+			}
+
+			if c.OnPlan != nil {
+				c.OnPlan(plan, txids)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
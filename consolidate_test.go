@@ -0,0 +1,269 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+)
+
+func TestPlanConsolidation(t *testing.T) {
+	var utxos []*UnspentTransaction
+	for i := 0; i < 10; i++ {
+		utxos = append(utxos, &UnspentTransaction{TXID: fmt.Sprint(i), Amount: float64(i+1) * 0.0001, Spendable: true, Safe: true})
+	}
+
+	// Dust at 10 sat/vB (a 68 vB input costs 680 sats) and a large output, neither of which qualifies
+	utxos = append(utxos,
+		&UnspentTransaction{TXID: "dust", Amount: 0.00000600, Spendable: true, Safe: true},
+		&UnspentTransaction{TXID: "large", Amount: 1, Spendable: true, Safe: true},
+	)
+
+	policy := ConsolidationPolicy{
+		MaxFeeRate:     10,
+		SmallUTXOValue: 100000,
+		MinUTXOs:       5,
+		MaxInputs:      4,
+		MaxFee:         6000,
+	}
+
+	plan := PlanConsolidation(utxos, 10, policy)
+
+	// Each 4 input transaction costs 10 * (11 + 31 + 4*68) = 3140 sats, so the budget allows only one
+	if len(plan.Txs) != 1 || plan.TotalFee != 3140 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+
+	if tx := plan.Txs[0]; len(tx.Inputs) != 4 || tx.Inputs[0].TXID != "0" || tx.Value != 100000 {
+		t.Errorf("expected the four smallest outputs, got %+v", tx)
+	}
+
+	policy.MaxFee = 0
+	// 9 candidates, the output of exactly 0.001 BTC is not small, leaving one that cannot be merged on its own
+	if plan := PlanConsolidation(utxos, 10, policy); len(plan.Txs) != 2 || len(plan.Txs[1].Inputs) != 4 {
+		t.Errorf("expected 2 transactions without a fee budget, got %+v", plan)
+	}
+
+	if plan := PlanConsolidation(utxos, 11, policy); len(plan.Txs) != 0 {
+		t.Errorf("expected no transactions above the maximum fee rate, got %+v", plan)
+	}
+
+	policy.MinUTXOs = 10
+	if plan := PlanConsolidation(utxos, 10, policy); len(plan.Txs) != 0 {
+		t.Errorf("expected no transactions with too few candidates, got %+v", plan)
+	}
+}
+
+// consolidationNode serves a wallet with the given unspent outputs and fee estimate, and records the
+// parameters of every call.
+type consolidationNode struct {
+	mu       sync.Mutex
+	feeRate  float64 // BTC/kvB, 0 means no estimate
+	unspent  string
+	calls    []string
+	sent     int
+	rejectAt int // sendrawtransaction call failing, counting from 1, 0 means none
+}
+
+func (n *consolidationNode) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.calls = append(n.calls, fmt.Sprint(req.Method, " ", req.Params))
+
+	result := `null`
+	switch req.Method {
+	case "estimatesmartfee":
+		if n.feeRate > 0 {
+			result = fmt.Sprintf(`{"feerate":%v,"blocks":144}`, n.feeRate)
+		} else {
+			result = `{"errors":["Insufficient data or no feerate found"],"blocks":0}`
+		}
+	case "listunspent":
+		result = n.unspent
+	case "getnewaddress":
+		result = fmt.Sprintf(`"bc1qnew%d"`, n.sent)
+	case "createrawtransaction":
+		result = `"unfunded"`
+	case "fundrawtransaction":
+		result = `{"hex":"funded","fee":0.00001,"changepos":-1}`
+	case "signrawtransactionwithwallet":
+		result = `{"hex":"signed","complete":true}`
+	case "sendrawtransaction":
+		n.sent++
+		if n.sent == n.rejectAt {
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-26,"message":"min relay fee not met"},"id":1}`))
+			return
+		}
+		result = fmt.Sprintf(`"txid%d"`, n.sent)
+	}
+
+	_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+}
+
+func TestBitcoindPlanConsolidation(t *testing.T) {
+	node := &consolidationNode{
+		feeRate: 0.00005,
+		unspent: `[
+			{"txid":"e","vout":4,"amount":0.0005,"spendable":true,"safe":true},
+			{"txid":"a","vout":0,"amount":0.0001,"spendable":true,"safe":true},
+			{"txid":"unsafe","vout":0,"amount":0.00005,"spendable":true,"safe":false},
+			{"txid":"c","vout":2,"amount":0.0003,"spendable":true,"safe":true},
+			{"txid":"watchonly","vout":0,"amount":0.00005,"spendable":false,"safe":true},
+			{"txid":"large","vout":0,"amount":1,"spendable":true,"safe":true},
+			{"txid":"dust","vout":0,"amount":0.000003,"spendable":true,"safe":true},
+			{"txid":"b","vout":1,"amount":0.0002,"spendable":true,"safe":true},
+			{"txid":"d","vout":3,"amount":0.0004,"spendable":true,"safe":true}
+		]`,
+	}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	// The fee estimate changes between calls
+	b.Storage = cache.New(time.Nanosecond, time.Minute)
+
+	policy := ConsolidationPolicy{
+		MaxFeeRate:     10,
+		SmallUTXOValue: 100000,
+		MinUTXOs:       5,
+		MaxInputs:      3,
+	}
+
+	plan, err := b.PlanConsolidation(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fmt.Sprint(node.calls); got != "[estimatesmartfee [144 economical] listunspent [1]]" {
+		t.Errorf("unexpected calls %s", got)
+	}
+
+	if math.Abs(plan.FeeRate-5) > 1e-9 {
+		t.Errorf("expected 5 sat/vB, got %v", plan.FeeRate)
+	}
+
+	// The dust output costs 5 * 68 = 340 sats to spend, more than it is worth, and the unsafe, unspendable
+	// and large outputs never qualify, leaving the five outputs a to e, smallest first
+	var txids [][]string
+	for _, tx := range plan.Txs {
+		var ids []string
+		for _, utxo := range tx.Inputs {
+			ids = append(ids, utxo.TXID)
+		}
+		txids = append(txids, ids)
+	}
+	if got := fmt.Sprint(txids); got != "[[a b c] [d e]]" {
+		t.Errorf("unexpected inputs %s", got)
+	}
+
+	// 5 * (11 + 31 + 3*68) = 1230 and 5 * (11 + 31 + 2*68) = 890 sats
+	if len(plan.Txs) == 2 && (plan.Txs[0].Value != 60000 || plan.Txs[0].Fee != 1230 || plan.Txs[1].Value != 90000 || plan.Txs[1].Fee != 890 || plan.TotalFee != 2120) {
+		t.Errorf("unexpected values and fees %+v", plan)
+	}
+
+	// Too few candidates
+	policy.MinUTXOs = 6
+	if plan, err := b.PlanConsolidation(policy); err != nil || len(plan.Txs) != 0 {
+		t.Errorf("expected no transactions with too few candidates, got %+v, %v", plan, err)
+	}
+	policy.MinUTXOs = 5
+
+	// 11 sat/vB is above the threshold, the configured confirmation target is used
+	node.mu.Lock()
+	node.feeRate = 0.00011
+	node.calls = nil
+	node.mu.Unlock()
+
+	policy.ConfTarget = 6
+	plan, err = b.PlanConsolidation(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Txs) != 0 || math.Abs(plan.FeeRate-11) > 1e-9 {
+		t.Errorf("expected no transactions above the maximum fee rate, got %+v", plan)
+	}
+
+	if got := fmt.Sprint(node.calls); got != "[estimatesmartfee [6 economical] listunspent [1]]" {
+		t.Errorf("unexpected calls %s", got)
+	}
+
+	// Without an estimate the minimum relay fee rate of 1 sat/vB is used, making the dust output worth it
+	node.mu.Lock()
+	node.feeRate = 0
+	node.mu.Unlock()
+
+	policy.MaxInputs = 10
+	plan, err = b.PlanConsolidation(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.FeeRate != 1 || len(plan.Txs) != 1 || len(plan.Txs[0].Inputs) != 6 || plan.Txs[0].Inputs[0].TXID != "dust" {
+		t.Errorf("expected one transaction including the dust output at 1 sat/vB, got %+v", plan)
+	}
+}
+
+func TestExecuteConsolidation(t *testing.T) {
+	node := &consolidationNode{}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	plan := &ConsolidationPlan{
+		FeeRate: 5,
+		Txs: []ConsolidationTx{
+			{Inputs: []*UnspentTransaction{{TXID: "a", Vout: 0, Amount: 0.0001}, {TXID: "b", Vout: 1, Amount: 0.0002}}},
+			{Inputs: []*UnspentTransaction{{TXID: "c", Vout: 2, Amount: 1.5}}},
+		},
+	}
+
+	txids, err := b.ExecuteConsolidation(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fmt.Sprint(txids); got != "[txid1 txid2]" {
+		t.Errorf("unexpected txids %s", got)
+	}
+
+	// Each transaction sends the whole value of its inputs to a new address, paying the fee from it
+	expected := []string{
+		"getnewaddress <nil>",
+		"createrawtransaction [[map[txid:a vout:0] map[txid:b vout:1]] map[bc1qnew0:0.0003]]",
+		"fundrawtransaction [unfunded map[add_inputs:false fee_rate:5 subtractFeeFromOutputs:[0]]]",
+		"signrawtransactionwithwallet [funded]",
+		"sendrawtransaction [signed]",
+		"getnewaddress <nil>",
+		"createrawtransaction [[map[txid:c vout:2]] map[bc1qnew1:1.5]]",
+		"fundrawtransaction [unfunded map[add_inputs:false fee_rate:5 subtractFeeFromOutputs:[0]]]",
+		"signrawtransactionwithwallet [funded]",
+		"sendrawtransaction [signed]",
+	}
+	if got, want := strings.Join(node.calls, "\n"), strings.Join(expected, "\n"); got != want {
+		t.Errorf("unexpected calls\n%s\nexpected\n%s", got, want)
+	}
+
+	// The txids sent before a failure are returned with the error
+	node.mu.Lock()
+	node.sent = 0
+	node.rejectAt = 2
+	node.mu.Unlock()
+
+	txids, err = b.ExecuteConsolidation(plan)
+	if err == nil || !HasErrorCode(err, -26) {
+		t.Errorf("expected the rejection, got %v", err)
+	}
+
+	if got := fmt.Sprint(txids); got != "[txid1]" {
+		t.Errorf("unexpected txids %s", got)
+	}
+}
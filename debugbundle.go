@@ -0,0 +1,215 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentErrorsKept is the number of recent errors kept for the error stats of a client.
+const recentErrorsKept = 20
+
+// ClientError is a failed call recorded in the error stats of a client.
+type ClientError struct {
+	Time    time.Time `json:"time"`
+	Method  string    `json:"method"`
+	Message string    `json:"message"`
+}
+
+// ClientErrorStats counts the calls of a client and their failures since it was created, both failed
+// requests and errors returned by the node.
+type ClientErrorStats struct {
+	Calls    uint64            `json:"calls"`
+	Errors   uint64            `json:"errors"`
+	ByMethod map[string]uint64 `json:"errors_by_method,omitempty"`
+	Recent   []ClientError     `json:"recent,omitempty"` // oldest first
+}
+
+// callStats records the outcome of calls, shared by derived clients.
+type callStats struct {
+	mu       sync.Mutex
+	calls    uint64
+	errors   uint64
+	byMethod map[string]uint64
+	recent   []ClientError
+}
+
+func (s *callStats) record(now time.Time, method string, rr rpcResponse, err error) {
+	if s == nil {
+		return
+	}
+
+	if err == nil && rr.Err != nil {
+		err = responseError(rr.Err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if err == nil {
+		return
+	}
+
+	s.errors++
+	if s.byMethod == nil {
+		s.byMethod = make(map[string]uint64)
+	}
+	s.byMethod[method]++
+
+	if len(s.recent) == recentErrorsKept {
+		s.recent = append(s.recent[:0], s.recent[1:]...)
+	}
+	s.recent = append(s.recent, ClientError{Time: now, Method: method, Message: err.Error()})
+}
+
+// ErrorStats returns the calls and errors of the client and of the clients derived from it.
+func (b *Bitcoind) ErrorStats() ClientErrorStats {
+	s := b.client.stats
+	if s == nil {
+		return ClientErrorStats{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := ClientErrorStats{
+		Calls:    s.calls,
+		Errors:   s.errors,
+		ByMethod: make(map[string]uint64, len(s.byMethod)),
+		Recent:   append([]ClientError(nil), s.recent...),
+	}
+	for method, n := range s.byMethod {
+		stats.ByMethod[method] = n
+	}
+
+	return stats
+}
+
+// PeerSummary describes the peers of a node without their addresses.
+type PeerSummary struct {
+	Total            int            `json:"total"`
+	Inbound          int            `json:"inbound"`
+	ByNetwork        map[string]int `json:"by_network,omitempty"`
+	ByConnectionType map[string]int `json:"by_connection_type,omitempty"`
+	ByUserAgent      map[string]int `json:"by_user_agent,omitempty"`
+	MedianPing       float64        `json:"median_ping,omitempty"` // seconds
+}
+
+// DebugBundle is the state of a node and of the client, for attaching to support tickets. It contains no
+// addresses of the node or its peers, no credentials and no wallet data.
+type DebugBundle struct {
+	CreatedAt   time.Time         `json:"created_at"`
+	Blockchain  json.RawMessage   `json:"blockchain,omitempty"`
+	Network     json.RawMessage   `json:"network,omitempty"`
+	Mempool     json.RawMessage   `json:"mempool,omitempty"`
+	Peers       *PeerSummary      `json:"peers,omitempty"`
+	Memory      json.RawMessage   `json:"memory,omitempty"`
+	Logging     json.RawMessage   `json:"logging,omitempty"`
+	ClientStats ClientErrorStats  `json:"client_stats"`
+	Errors      map[string]string `json:"errors,omitempty"` // of the calls that failed while collecting, by method
+}
+
+// ipAddress matches IPv4 addresses and bracketed IPv6 addresses, with an optional port.
+var ipAddress = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b|\[[0-9a-fA-F:]+\](:\d+)?`)
+
+// CollectDebugBundle gathers the chain, network, mempool, memory and logging state of the node, a summary of
+// its peers and the error stats of the client into one document. Calls that fail are reported in the
+// bundle's Errors rather than failing the collection, so that a bundle can be collected from a node in
+// trouble. The local addresses of the node are left out and IP addresses in error messages masked.
+func (b *Bitcoind) CollectDebugBundle(ctx context.Context) *DebugBundle {
+	bundle := &DebugBundle{CreatedAt: b.client.clock.Now().UTC(), Errors: make(map[string]string)}
+
+	call := func(method string) json.RawMessage {
+		result, err := b.RawCallContext(ctx, method)
+		if err != nil {
+			bundle.Errors[method] = b.sanitize(err.Error())
+			return nil
+		}
+		return result
+	}
+
+	bundle.Blockchain = call("getblockchaininfo")
+	bundle.Mempool = call("getmempoolinfo")
+	bundle.Memory = call("getmemoryinfo")
+	bundle.Logging = call("logging")
+
+	if network := call("getnetworkinfo"); network != nil {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(network, &fields); err != nil {
+			bundle.Errors["getnetworkinfo"] = err.Error()
+		} else {
+			delete(fields, "localaddresses")
+			bundle.Network, _ = json.Marshal(fields)
+		}
+	}
+
+	if peers := call("getpeerinfo"); peers != nil {
+		summary, err := summarizePeers(peers)
+		if err != nil {
+			bundle.Errors["getpeerinfo"] = err.Error()
+		}
+		bundle.Peers = summary
+	}
+
+	bundle.ClientStats = b.ErrorStats()
+	for i, e := range bundle.ClientStats.Recent {
+		bundle.ClientStats.Recent[i].Message = b.sanitize(e.Message)
+	}
+
+	if len(bundle.Errors) == 0 {
+		bundle.Errors = nil
+	}
+
+	return bundle
+}
+
+// sanitize masks the node's URL and IP addresses in an error message.
+func (b *Bitcoind) sanitize(message string) string {
+	message = strings.ReplaceAll(message, b.client.serverAddr, "<node>")
+	return ipAddress.ReplaceAllString(message, "<address>")
+}
+
+func summarizePeers(data json.RawMessage) (*PeerSummary, error) {
+	var peers []struct {
+		Inbound        bool    `json:"inbound"`
+		Network        string  `json:"network"`
+		ConnectionType string  `json:"connection_type"`
+		Subver         string  `json:"subver"`
+		PingTime       float64 `json:"pingtime"`
+	}
+	if err := json.Unmarshal(data, &peers); err != nil {
+		return nil, err
+	}
+
+	summary := &PeerSummary{
+		Total:            len(peers),
+		ByNetwork:        make(map[string]int),
+		ByConnectionType: make(map[string]int),
+		ByUserAgent:      make(map[string]int),
+	}
+
+	var pings []float64
+	for _, p := range peers {
+		if p.Inbound {
+			summary.Inbound++
+		}
+		if p.Network != "" {
+			summary.ByNetwork[p.Network]++
+		}
+		if p.ConnectionType != "" {
+			summary.ByConnectionType[p.ConnectionType]++
+		}
+		summary.ByUserAgent[p.Subver]++
+		if p.PingTime > 0 {
+			pings = append(pings, p.PingTime)
+		}
+	}
+
+	summary.MedianPing = median(pings)
+
+	return summary, nil
+}
@@ -0,0 +1,91 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectDebugBundle(t *testing.T) {
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		reply := func(result string) {
+			_, _ = io.WriteString(w, `{"result":`+result+`,"error":null,"id":1}`)
+		}
+
+		switch req.Method {
+		case "getblockchaininfo":
+			reply(`{"chain":"main","blocks":800000}`)
+		case "getnetworkinfo":
+			reply(`{"version":270000,"localaddresses":[{"address":"203.0.113.7","port":8333,"score":1}]}`)
+		case "getmempoolinfo":
+			reply(`{"size":12}`)
+		case "getmemoryinfo":
+			reply(`{"locked":{"used":1}}`)
+		case "getpeerinfo":
+			reply(`[{"addr":"198.51.100.1:8333","inbound":false,"network":"ipv4","connection_type":"outbound-full-relay","subver":"/Satoshi:27.0.0/","pingtime":0.1},
+				{"addr":"[2001:db8::1]:8333","inbound":true,"network":"ipv6","connection_type":"inbound","subver":"/Satoshi:27.0.0/","pingtime":0.3},
+				{"addr":"abc.onion:8333","inbound":true,"network":"onion","connection_type":"inbound","subver":"/Satoshi:26.0.0/","pingtime":0.2}]`)
+		default:
+			_, _ = io.WriteString(w, `{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":1}`)
+		}
+	})
+	defer closeServer()
+
+	// A failed request, whose message names the node
+	_, err := b.WithOptions(func(c *rpcClient) { c.serverAddr = "http://192.0.2.1:1" }).RawCall("getblockcount")
+	require.Error(t, err)
+
+	bundle := b.CollectDebugBundle(context.Background())
+
+	assert.JSONEq(t, `{"chain":"main","blocks":800000}`, string(bundle.Blockchain))
+	assert.JSONEq(t, `{"version":270000}`, string(bundle.Network))
+	assert.JSONEq(t, `{"size":12}`, string(bundle.Mempool))
+	assert.Equal(t, &PeerSummary{
+		Total:            3,
+		Inbound:          2,
+		ByNetwork:        map[string]int{"ipv4": 1, "ipv6": 1, "onion": 1},
+		ByConnectionType: map[string]int{"outbound-full-relay": 1, "inbound": 2},
+		ByUserAgent:      map[string]int{"/Satoshi:27.0.0/": 2, "/Satoshi:26.0.0/": 1},
+		MedianPing:       0.2,
+	}, bundle.Peers)
+
+	assert.Nil(t, bundle.Logging)
+	assert.Equal(t, map[string]string{"logging": "ERROR -32601: Method not found"}, bundle.Errors)
+
+	stats := bundle.ClientStats
+	assert.Equal(t, uint64(7), stats.Calls)
+	assert.Equal(t, uint64(2), stats.Errors)
+	assert.Equal(t, map[string]uint64{"getblockcount": 1, "logging": 1}, stats.ByMethod)
+	require.Len(t, stats.Recent, 2)
+	assert.Equal(t, "getblockcount", stats.Recent[0].Method)
+
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	for _, address := range []string{"203.0.113.7", "198.51.100.1", "2001:db8::1", "192.0.2.1", "abc.onion"} {
+		assert.False(t, strings.Contains(string(data), address), address)
+	}
+}
+
+func TestErrorStatsRecent(t *testing.T) {
+	s := &callStats{}
+	for i := 0; i < recentErrorsKept+5; i++ {
+		s.record(time.Time{}, "getblock", rpcResponse{Err: map[string]interface{}{"code": -5, "message": "Block not found"}}, nil)
+	}
+	s.record(time.Time{}, "getblockcount", rpcResponse{}, nil)
+
+	b := &Bitcoind{client: &rpcClient{stats: s}}
+	stats := b.ErrorStats()
+	assert.Equal(t, uint64(recentErrorsKept+6), stats.Calls)
+	assert.Len(t, stats.Recent, recentErrorsKept)
+	assert.Equal(t, "ERROR -5: Block not found", stats.Recent[0].Message)
+}
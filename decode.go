@@ -0,0 +1,107 @@
+package bitcoin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// responseTypes maps RPC methods to the type their result is decoded into by the wrappers. Methods
+// returning different shapes depending on their params map to the shape of the default, verbose call.
+var responseTypes = map[string]func() interface{}{
+	"descriptorprocesspsbt":  func() interface{} { return &ProcessedPSBT{} },
+	"estimaterawfee":         func() interface{} { return &RawFeeEstimate{} },
+	"estimatesmartfee":       func() interface{} { return &SmartFeeEstimate{} },
+	"finalizepsbt":           func() interface{} { return &FinalizedPSBT{} },
+	"getblock":               func() interface{} { return &Block{} },
+	"getblockchaininfo":      func() interface{} { return &BlockchainInfo{} },
+	"getblockheader":         func() interface{} { return &BlockHeader{} },
+	"getblockstats":          func() interface{} { return &BlockStats{} },
+	"getblocktemplate":       func() interface{} { return &BlockTemplate{} },
+	"getchaintips":           func() interface{} { return &ChainTips{} },
+	"getchaintxstats":        func() interface{} { return &ChainTXStats{} },
+	"getdescriptorinfo":      func() interface{} { return &DescriptorInfo{} },
+	"getinfo":                func() interface{} { return &GetInfo{} },
+	"getmempoolentry":        func() interface{} { return &MempoolEntry{} },
+	"getmempoolinfo":         func() interface{} { return &MempoolInfo{} },
+	"getminingcandidate":     func() interface{} { return &MiningCandidate{} },
+	"getmininginfo":          func() interface{} { return &MiningInfo{} },
+	"getnettotals":           func() interface{} { return &NetTotals{} },
+	"getnetworkinfo":         func() interface{} { return &NetworkInfo{} },
+	"getorphantxs":           func() interface{} { return &[]OrphanTx{} },
+	"getpeerinfo":            func() interface{} { return &PeerInfo{} },
+	"getrawtransaction":      func() interface{} { return &RawTransaction{} },
+	"getsettings":            func() interface{} { return &Settings{} },
+	"gettransaction":         func() interface{} { return &WalletTransaction{} },
+	"gettxspendingprevout":   func() interface{} { return &[]TxSpendingPrevout{} },
+	"importdescriptors":      func() interface{} { return &[]ImportDescriptorResult{} },
+	"listbanned":             func() interface{} { return &[]BannedNode{} },
+	"listunspent":            func() interface{} { return &[]*UnspentTransaction{} },
+	"scantxoutset":           func() interface{} { return &ScanTxOutSetResult{} },
+	"signrawtransaction":     func() interface{} { return &SignRawTransactionResponse{} },
+	"validateaddress":        func() interface{} { return &Address{} },
+	"walletcreatefundedpsbt": func() interface{} { return &FundedPSBT{} },
+	"walletprocesspsbt":      func() interface{} { return &ProcessedPSBT{} },
+}
+
+// ErrUnknownResponseType is returned by DecodeResult for methods without a typed result.
+var ErrUnknownResponseType = errors.New("no typed result for method")
+
+// DecodableMethods returns the methods DecodeResult decodes, sorted.
+func DecodableMethods() []string {
+	methods := make([]string, 0, len(responseTypes))
+	for method := range responseTypes {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	return methods
+}
+
+// DecodeResult decodes the result of a call to method into the type its wrapper returns, e.g. a
+// *BlockchainInfo for getblockchaininfo. It never panics, whatever the input, which makes it suitable
+// as the target of a fuzzing harness.
+func DecodeResult(method string, result []byte) (interface{}, error) {
+	newValue, found := responseTypes[method]
+	if !found {
+		return nil, fmt.Errorf("%w %q", ErrUnknownResponseType, method)
+	}
+
+	v := newValue()
+	if err := unmarshal(result, v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// DecodeResponse decodes a complete JSON-RPC response body, as returned by the node for a call to method.
+// An error reported by the node is returned as an error in the format used by the wrappers.
+func DecodeResponse(method string, body []byte) (interface{}, error) {
+	var r rpcResponse
+	if err := unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+
+	if r.Err != nil {
+		return nil, responseError(r.Err)
+	}
+
+	return DecodeResult(method, r.Result)
+}
+
+// responseError returns the error member of a response as an *RPCError. Nodes send an object with code and
+// message, but proxies in between have been seen to send plain strings.
+func responseError(e interface{}) error {
+	rr, ok := e.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("ERROR: %v", e)
+	}
+
+	code, ok := errorCode(rr)
+	if !ok {
+		return fmt.Errorf("ERROR %v: %v", rr["code"], rr["message"])
+	}
+
+	return &RPCError{Code: code, Message: fmt.Sprint(rr["message"])}
+}
@@ -0,0 +1,45 @@
+//go:build go1.18
+// +build go1.18
+
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// FuzzDecodeResponse feeds mutated node responses to the typed decoders, e.g.
+//
+//	go test -run none -fuzz FuzzDecodeResponse
+func FuzzDecodeResponse(f *testing.F) {
+	for method, body := range responseCorpus(f) {
+		f.Add(method, body)
+	}
+
+	methods := DecodableMethods()
+
+	f.Fuzz(func(t *testing.T, method string, body []byte) {
+		if _, err := DecodeResponse(method, body); err != nil {
+			return
+		}
+
+		// A body decoding for one method goes through the decoders of all the others too
+		for _, m := range methods {
+			_, _ = DecodeResponse(m, body)
+		}
+	})
+}
+
+// FuzzParseBlock checks that malformed raw blocks and transactions are rejected without panicking.
+func FuzzParseBlock(f *testing.F) {
+	f.Add([]byte{})
+	genesis, _ := hex.DecodeString(genesisBlockHex)
+	f.Add(genesis)
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		if block, err := parseBlock(raw); err == nil {
+			_ = block.verify()
+		}
+		_, _ = parseTx(raw)
+	})
+}
@@ -0,0 +1,68 @@
+package bitcoin
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// responseCorpus returns the stored node responses in testdata/responses by the method they answer.
+func responseCorpus(t testing.TB) map[string][]byte {
+	files, err := filepath.Glob(filepath.Join("testdata", "responses", "*.json"))
+	require.NoError(t, err)
+
+	corpus := make(map[string][]byte)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		corpus[strings.TrimSuffix(filepath.Base(file), ".json")] = data
+	}
+
+	return corpus
+}
+
+func TestDecodeResponse(t *testing.T) {
+	corpus := responseCorpus(t)
+
+	v, err := DecodeResponse("getblockchaininfo", corpus["getblockchaininfo"])
+	require.NoError(t, err)
+	info := v.(*BlockchainInfo)
+	assert.Equal(t, "main", info.Chain)
+	assert.Equal(t, int32(812345), info.Blocks)
+
+	v, err = DecodeResponse("getmempoolentry", corpus["getmempoolentry"])
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, v.(*MempoolEntry).FeeRate())
+
+	for method, body := range corpus {
+		if _, found := responseTypes[method]; !found {
+			continue
+		}
+		_, err := DecodeResponse(method, body)
+		assert.NoError(t, err, method)
+	}
+
+	_, err = DecodeResponse("getrawtransaction", corpus["error"])
+	assert.EqualError(t, err, "ERROR -5: No such mempool or blockchain transaction. Use gettransaction for wallet transactions.")
+
+	_, err = DecodeResponse("getrawtransaction", []byte(`{"result":null,"error":"Bad Gateway","id":1}`))
+	assert.EqualError(t, err, "ERROR: Bad Gateway")
+
+	_, err = DecodeResult("nosuchmethod", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrUnknownResponseType)
+
+	_, err = DecodeResult("getblockheader", []byte(`{"height":-1}`))
+	assert.Error(t, err)
+}
+
+func TestDecodableMethods(t *testing.T) {
+	methods := DecodableMethods()
+	assert.Len(t, methods, len(responseTypes))
+	assert.Contains(t, methods, "getblockchaininfo")
+	assert.True(t, sort.StringsAreSorted(methods))
+}
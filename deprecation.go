@@ -0,0 +1,162 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// DeprecationWarning describes the use of a deprecated method, see WithDeprecationWarnings.
+type DeprecationWarning struct {
+	Method      string
+	NodeVersion int // version of the node as reported by getnetworkinfo, 0 if not known yet
+	Message     string
+	FromNode    bool // reported by the node, rather than known from the deprecation table
+}
+
+// deprecatedMethods lists the methods deprecated by Bitcoin Core, by the version deprecating them.
+var deprecatedMethods = map[string]struct {
+	since   int
+	message string
+}{
+	"estimatefee":           {150000, "removed in v0.17, use estimatesmartfee"},
+	"getinfo":               {140000, "removed in v0.16, use getblockchaininfo, getnetworkinfo and getwalletinfo"},
+	"generate":              {180000, "removed in v0.19, use generatetoaddress"},
+	"signrawtransaction":    {170000, "removed in v0.18, use signrawtransactionwithkey or signrawtransactionwithwallet"},
+	"getaccount":            {170000, "accounts are removed in v0.18, use labels"},
+	"getaccountaddress":     {170000, "accounts are removed in v0.18, use labels"},
+	"getaddressesbyaccount": {170000, "accounts are removed in v0.18, use getaddressesbylabel"},
+	"getreceivedbyaccount":  {170000, "accounts are removed in v0.18, use getreceivedbylabel"},
+	"listaccounts":          {170000, "accounts are removed in v0.18, use listlabels"},
+	"move":                  {170000, "accounts are removed in v0.18"},
+	"sendfrom":              {170000, "accounts are removed in v0.18, use sendtoaddress"},
+	"setaccount":            {170000, "accounts are removed in v0.18, use setlabel"},
+}
+
+// deprecationState is shared by the clients derived with WithOptions, which talk to the same node.
+type deprecationState struct {
+	callback func(DeprecationWarning)
+
+	mu      sync.Mutex
+	version int             // 0 until getnetworkinfo has been seen
+	warned  map[string]bool // method and message of the warnings already emitted
+}
+
+// WithDeprecationWarnings logs a warning the first time a deprecated method is called, either because
+// the deprecation table lists it as deprecated for the version of the node, or because the node says so:
+// by refusing a method only enabled with -deprecatedrpc, or with a deprecation notice in the warnings of
+// a result. If callback is not nil it is also called with the warning.
+//
+// The node version is read from the first getnetworkinfo response, which is requested before the first
+// call to a method of the table if the application did not call it.
+func WithDeprecationWarnings(callback func(DeprecationWarning)) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.deprecations = &deprecationState{callback: callback, warned: make(map[string]bool)}
+	}
+}
+
+// warnKnownDeprecation warns about a call to a method of the deprecation table, before it is sent.
+func (c *rpcClient) warnKnownDeprecation(method string) {
+	d, found := deprecatedMethods[method]
+	if c.deprecations == nil || !found {
+		return
+	}
+
+	version := c.deprecations.nodeVersion()
+	if version == 0 {
+		// Learned by checkDeprecation from the response
+		if _, err := c.call("getnetworkinfo", nil); err != nil {
+			return
+		}
+		if version = c.deprecations.nodeVersion(); version == 0 {
+			return
+		}
+	}
+
+	if version >= d.since {
+		c.warnDeprecated(DeprecationWarning{Method: method, NodeVersion: version, Message: d.message})
+	}
+}
+
+// checkDeprecation records the node version from getnetworkinfo responses and warns about deprecations
+// reported by the node.
+func (c *rpcClient) checkDeprecation(method string, rr rpcResponse) {
+	if c.deprecations == nil {
+		return
+	}
+
+	if rr.Err != nil {
+		e, ok := rr.Err.(map[string]interface{})
+		if code, _ := errorCode(e); ok && code == RPCMethodDeprecated {
+			message, _ := e["message"].(string)
+			c.warnDeprecated(DeprecationWarning{Method: method, NodeVersion: c.deprecations.nodeVersion(), Message: message, FromNode: true})
+		}
+		return
+	}
+
+	if method == "getnetworkinfo" {
+		var info struct {
+			Version int `json:"version"`
+		}
+		if json.Unmarshal(rr.Result, &info) == nil && info.Version > 0 {
+			c.deprecations.mu.Lock()
+			c.deprecations.version = info.Version
+			c.deprecations.mu.Unlock()
+		}
+	}
+
+	if !bytes.Contains(rr.Result, []byte(`"warning`)) {
+		return
+	}
+
+	// Results carry notices in a warning string or a warnings string or array, depending on the method
+	var result struct {
+		Warning  string          `json:"warning"`
+		Warnings json.RawMessage `json:"warnings"`
+	}
+	if json.Unmarshal(rr.Result, &result) != nil {
+		return
+	}
+
+	notices := []string{result.Warning}
+	var warnings []string
+	if json.Unmarshal(result.Warnings, &warnings) != nil {
+		var warning string
+		_ = json.Unmarshal(result.Warnings, &warning)
+		warnings = []string{warning}
+	}
+
+	for _, notice := range append(notices, warnings...) {
+		if strings.Contains(strings.ToLower(notice), "deprecat") {
+			c.warnDeprecated(DeprecationWarning{Method: method, NodeVersion: c.deprecations.nodeVersion(), Message: notice, FromNode: true})
+		}
+	}
+}
+
+// warnDeprecated emits a warning unless the same one was emitted before.
+func (c *rpcClient) warnDeprecated(w DeprecationWarning) {
+	key := w.Method + "|" + w.Message
+
+	c.deprecations.mu.Lock()
+	warned := c.deprecations.warned[key]
+	c.deprecations.warned[key] = true
+	c.deprecations.mu.Unlock()
+
+	if warned {
+		return
+	}
+
+	c.logger.Warnf("Deprecated RPC %s: %s", w.Method, w.Message)
+
+	if c.deprecations.callback != nil {
+		c.deprecations.callback(w)
+	}
+}
+
+func (d *deprecationState) nodeVersion() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.version
+}
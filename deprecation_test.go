@@ -0,0 +1,68 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecationWarnings(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+
+	c, closeServer := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		calls = append(calls, req.Method)
+		mu.Unlock()
+
+		switch req.Method {
+		case "getnetworkinfo":
+			fmt.Fprint(w, `{"result":{"version":170100},"error":null,"id":1}`)
+		case "getaccount":
+			fmt.Fprint(w, `{"result":"","error":null,"id":1}`)
+		case "getaddressinfo":
+			fmt.Fprint(w, `{"result":null,"error":{"code":-32,"message":"getaddressinfo is deprecated, restart bitcoind with -deprecatedrpc=getaddressinfo"},"id":1}`)
+		case "createwallet":
+			fmt.Fprint(w, `{"result":{"name":"w","warnings":["Wallet created successfully. The legacy wallet type is being deprecated."]},"error":null,"id":1}`)
+		default:
+			fmt.Fprint(w, `{"result":{"warnings":"Unknown new rules activated"},"error":null,"id":1}`)
+		}
+	})
+	defer closeServer()
+
+	var warnings []DeprecationWarning
+	WithDeprecationWarnings(func(w DeprecationWarning) {
+		warnings = append(warnings, w)
+	})(c)
+
+	_, err := c.call("getaccount", []interface{}{"addr"})
+	require.NoError(t, err)
+	_, err = c.call("getaccount", []interface{}{"addr"})
+	require.NoError(t, err)
+	_, err = c.call("getaddressinfo", []interface{}{"addr"})
+	require.NoError(t, err)
+	_, err = c.call("createwallet", []interface{}{"w"})
+	require.NoError(t, err)
+	_, err = c.call("getblockchaininfo", nil)
+	require.NoError(t, err)
+	// Not deprecated for this node version
+	_, err = c.call("generate", []interface{}{1})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"getnetworkinfo", "getaccount", "getaccount", "getaddressinfo", "createwallet", "getblockchaininfo", "generate"}, calls)
+
+	require.Len(t, warnings, 3)
+	assert.Equal(t, DeprecationWarning{Method: "getaccount", NodeVersion: 170100, Message: deprecatedMethods["getaccount"].message}, warnings[0])
+	assert.True(t, warnings[1].FromNode)
+	assert.Equal(t, "getaddressinfo", warnings[1].Method)
+	assert.Equal(t, "createwallet", warnings[2].Method)
+	assert.Contains(t, warnings[2].Message, "legacy wallet")
+}
@@ -0,0 +1,116 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DumpJSON renders a result, e.g. a *BlockchainInfo, as canonical indented JSON for golden files: fields in
+// the order of their struct, map keys sorted, and numbers in plain decimal notation, so that an amount of
+// 1 satoshi reads 0.00000001 rather than 1e-08. The output ends with a newline.
+func DumpJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	compact, err := canonicalJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact, "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+
+	return out.Bytes(), nil
+}
+
+// PrettyPrint returns DumpJSON of v for debugging, or the error if v cannot be rendered.
+func PrettyPrint(v interface{}) string {
+	data, err := DumpJSON(v)
+	if err != nil {
+		return fmt.Sprintf("<%T: %v>", v, err)
+	}
+
+	return string(data)
+}
+
+// canonicalJSON rewrites compact JSON with its numbers in plain decimal notation.
+func canonicalJSON(data []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var buf bytes.Buffer
+
+	// Per open container whether it is an object and how many tokens it holds so far
+	type container struct {
+		object bool
+		tokens int
+	}
+	var stack []container
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			buf.WriteByte(byte(delim))
+			continue
+		}
+
+		if n := len(stack); n > 0 {
+			top := &stack[n-1]
+			switch {
+			case top.object && top.tokens%2 == 1:
+				buf.WriteByte(':')
+			case top.tokens > 0:
+				buf.WriteByte(',')
+			}
+			top.tokens++
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			stack = append(stack, container{object: t == '{'})
+			buf.WriteByte(byte(t))
+		case json.Number:
+			buf.WriteString(plainNumber(t))
+		default:
+			encoded, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(encoded)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// plainNumber returns n without an exponent.
+func plainNumber(n json.Number) string {
+	s := n.String()
+	if !strings.ContainsAny(s, "eE") {
+		return s
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
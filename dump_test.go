@@ -0,0 +1,35 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpJSON(t *testing.T) {
+	data, err := DumpJSON(&FundedPSBT{PSBT: "cHNidP8=", Fee: 0.00000001, ChangePos: -1})
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "psbt": "cHNidP8=",
+  "fee": 0.00000001,
+  "changepos": -1
+}
+`, string(data))
+
+	data, err = DumpJSON(map[string]interface{}{"b": []interface{}{1e-7, "1e-7", nil, true}, "a": map[string]int{}})
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "a": {},
+  "b": [
+    0.0000001,
+    "1e-7",
+    null,
+    true
+  ]
+}
+`, string(data))
+
+	assert.Equal(t, "[]\n", PrettyPrint([]int{}))
+	assert.Contains(t, PrettyPrint(make(chan int)), "<chan int: json: unsupported type")
+}
@@ -0,0 +1,153 @@
+package bitcoin
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+// ElectrumClient is a TxSource backed by an ElectrumX or Electrs server speaking the Electrum protocol.
+// Every call opens a new connection, which suits its use as a fallback.
+type ElectrumClient struct {
+	Address   string      // host:port
+	TLSConfig *tls.Config // nil for a plaintext connection
+	Timeout   time.Duration
+
+	// AddressToScript converts an address to its scriptPubKey, which the protocol identifies addresses by.
+	// The default handles the addresses of every network.
+	AddressToScript func(address string) ([]byte, error)
+}
+
+// NewElectrumClient returns a client for the Electrum server at address, using TLS if tlsConfig is not nil.
+func NewElectrumClient(address string, tlsConfig *tls.Config) *ElectrumClient {
+	return &ElectrumClient{
+		Address:   address,
+		TLSConfig: tlsConfig,
+		Timeout:   30 * time.Second,
+		AddressToScript: func(address string) ([]byte, error) {
+			return AddressToScript(address, nil)
+		},
+	}
+}
+
+type electrumResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call negotiates the protocol version, which servers require before other requests, and sends one request.
+func (c *ElectrumClient) call(ctx context.Context, method string, params []interface{}, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.Address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+	defer conn.Close()
+
+	if c.TLSConfig != nil {
+		config := c.TLSConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(c.Address)
+		}
+		conn = tls.Client(conn, config)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	enc := json.NewEncoder(conn)
+	for id, req := range []map[string]interface{}{
+		{"jsonrpc": "2.0", "id": 0, "method": "server.version", "params": []interface{}{"go-bitcoin", "1.4"}},
+		{"jsonrpc": "2.0", "id": 1, "method": method, "params": params},
+	} {
+		if err := enc.Encode(req); err != nil {
+			return fmt.Errorf("%w: electrum request %d: %v", ErrSourceUnavailable, id, err)
+		}
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 32*1024*1024)
+
+	for scanner.Scan() {
+		var resp electrumResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			return fmt.Errorf("electrum: %w", err)
+		}
+
+		if resp.Error != nil {
+			return fmt.Errorf("electrum %s: %d: %s", method, resp.Error.Code, resp.Error.Message)
+		}
+
+		if resp.ID == 1 {
+			return json.Unmarshal(resp.Result, v)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+	return fmt.Errorf("%w: electrum server closed the connection", ErrSourceUnavailable)
+}
+
+// GetTransactionHex implements TxSource.
+func (c *ElectrumClient) GetTransactionHex(ctx context.Context, txid string) (txHex string, err error) {
+	err = c.call(ctx, "blockchain.transaction.get", []interface{}{txid}, &txHex)
+	return
+}
+
+// AddressHistory implements TxSource.
+func (c *ElectrumClient) AddressHistory(ctx context.Context, address string) ([]AddressTx, error) {
+	script, err := c.AddressToScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []struct {
+		TxHash string `json:"tx_hash"`
+		Height int    `json:"height"`
+	}
+	if err := c.call(ctx, "blockchain.scripthash.get_history", []interface{}{ElectrumScriptHash(script)}, &history); err != nil {
+		return nil, err
+	}
+
+	txs := make([]AddressTx, len(history))
+	for i, h := range history {
+		txs[i] = AddressTx{TxID: h.TxHash, Height: h.Height}
+
+		// -1 marks mempool transactions with unconfirmed parents
+		if h.Height < 0 {
+			txs[i].Height = 0
+		}
+	}
+
+	return txs, nil
+}
+
+// Broadcast implements TxSource.
+func (c *ElectrumClient) Broadcast(ctx context.Context, txHex string) (txid string, err error) {
+	err = c.call(ctx, "blockchain.transaction.broadcast", []interface{}{txHex}, &txid)
+	return
+}
+
+// ElectrumScriptHash returns the script hash the Electrum protocol identifies a scriptPubKey by, the
+// reversed SHA256 of the script.
+func ElectrumScriptHash(script []byte) string {
+	hash := sha256.Sum256(script)
+	return hex.EncodeToString(cryptolib.ReverseBytes(hash[:]))
+}
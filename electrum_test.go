@@ -0,0 +1,93 @@
+package bitcoin
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElectrumClient(t *testing.T) {
+	const address = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+
+	script, err := cryptolib.AddressToScript(address)
+	require.NoError(t, err)
+	scriptHash := ElectrumScriptHash(script)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				scanner := bufio.NewScanner(conn)
+				enc := json.NewEncoder(conn)
+
+				for scanner.Scan() {
+					var req struct {
+						ID     int           `json:"id"`
+						Method string        `json:"method"`
+						Params []interface{} `json:"params"`
+					}
+					_ = json.Unmarshal(scanner.Bytes(), &req)
+
+					resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+					switch req.Method {
+					case "server.version":
+						resp["result"] = []string{"ElectrumX 1.16", "1.4"}
+					case "blockchain.transaction.get":
+						resp["result"] = "0100"
+					case "blockchain.scripthash.get_history":
+						if req.Params[0] != scriptHash {
+							resp["error"] = map[string]interface{}{"code": 1, "message": "invalid scripthash"}
+							break
+						}
+						resp["result"] = []map[string]interface{}{{"tx_hash": "a", "height": 100}, {"tx_hash": "b", "height": -1}}
+					case "blockchain.transaction.broadcast":
+						resp["error"] = map[string]interface{}{"code": 1, "message": "the transaction was rejected by network rules"}
+					}
+					_ = enc.Encode(resp)
+				}
+			}()
+		}
+	}()
+
+	c := NewElectrumClient(l.Addr().String(), nil)
+	ctx := context.Background()
+
+	txHex, err := c.GetTransactionHex(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "0100", txHex)
+
+	history, err := c.AddressHistory(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, []AddressTx{{TxID: "a", Height: 100}, {TxID: "b"}}, history)
+
+	_, err = c.Broadcast(ctx, "0100")
+	assert.EqualError(t, err, "electrum blockchain.transaction.broadcast: 1: the transaction was rejected by network rules")
+	assert.NotErrorIs(t, err, ErrSourceUnavailable)
+
+	l.Close()
+	_, err = c.GetTransactionHex(ctx, "abc")
+	assert.ErrorIs(t, err, ErrSourceUnavailable)
+}
+
+func TestElectrumScriptHash(t *testing.T) {
+	// Example from the Electrum protocol documentation
+	script, _ := hex.DecodeString("76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac")
+	assert.Equal(t, "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161", ElectrumScriptHash(script))
+}
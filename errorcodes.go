@@ -0,0 +1,207 @@
+package bitcoin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RPCErrorCode is the code of an error returned by bitcoind, as defined in src/rpc/protocol.h.
+type RPCErrorCode int
+
+// Standard JSON-RPC 2.0 errors. RPCInvalidRequest is also returned for unknown HTTP methods, and
+// RPCMethodNotFound for methods unknown to the node or disabled by its configuration.
+const (
+	RPCInvalidRequest RPCErrorCode = -32600
+	RPCMethodNotFound RPCErrorCode = -32601
+	RPCInvalidParams  RPCErrorCode = -32602
+	RPCInternalError  RPCErrorCode = -32603
+	RPCParseError     RPCErrorCode = -32700
+)
+
+// General application defined errors.
+const (
+	RPCMiscError             RPCErrorCode = -1  // std::exception thrown in command handling
+	RPCForbiddenBySafeMode   RPCErrorCode = -2  // no longer used by bitcoind
+	RPCTypeError             RPCErrorCode = -3  // unexpected type was passed as parameter
+	RPCInvalidAddressOrKey   RPCErrorCode = -5  // invalid address or key, also unknown blocks and transactions
+	RPCOutOfMemory           RPCErrorCode = -7  // ran out of memory during operation
+	RPCInvalidParameter      RPCErrorCode = -8  // invalid, missing or duplicate parameter
+	RPCDatabaseError         RPCErrorCode = -20 // database error
+	RPCDeserializationError  RPCErrorCode = -22 // error parsing or validating structure in raw format
+	RPCVerifyError           RPCErrorCode = -25 // general error during transaction or block submission
+	RPCVerifyRejected        RPCErrorCode = -26 // transaction or block was rejected by network rules
+	RPCVerifyAlreadyInChain  RPCErrorCode = -27 // transaction already in chain
+	RPCInWarmup              RPCErrorCode = -28 // client still warming up
+	RPCMethodDeprecated      RPCErrorCode = -32 // RPC method is deprecated
+	RPCClientMempoolDisabled RPCErrorCode = -33 // no mempool instance found
+)
+
+// P2P client errors.
+const (
+	RPCClientNotConnected        RPCErrorCode = -9  // node is not connected
+	RPCClientInInitialDownload   RPCErrorCode = -10 // still downloading initial blocks
+	RPCClientNodeAlreadyAdded    RPCErrorCode = -23 // node is already added
+	RPCClientNodeNotAdded        RPCErrorCode = -24 // node has not been added before
+	RPCClientNodeNotConnected    RPCErrorCode = -29 // node to disconnect not found in connected nodes
+	RPCClientInvalidIPOrSubnet   RPCErrorCode = -30 // invalid IP or subnet
+	RPCClientP2PDisabled         RPCErrorCode = -31 // no valid connection manager instance found
+	RPCClientNodeCapacityReached RPCErrorCode = -34 // max number of outbound or block-relay connections already open
+)
+
+// Wallet errors.
+const (
+	RPCWalletError               RPCErrorCode = -4  // unspecified problem with wallet, e.g. key not found
+	RPCWalletInsufficientFunds   RPCErrorCode = -6  // not enough funds in wallet or account
+	RPCWalletInvalidLabelName    RPCErrorCode = -11 // invalid label name
+	RPCWalletKeypoolRanOut       RPCErrorCode = -12 // keypool ran out, call keypoolrefill first
+	RPCWalletUnlockNeeded        RPCErrorCode = -13 // enter the wallet passphrase with walletpassphrase first
+	RPCWalletPassphraseIncorrect RPCErrorCode = -14 // the wallet passphrase entered was incorrect
+	RPCWalletWrongEncState       RPCErrorCode = -15 // command given in wrong wallet encryption state
+	RPCWalletEncryptionFailed    RPCErrorCode = -16 // failed to encrypt the wallet
+	RPCWalletAlreadyUnlocked     RPCErrorCode = -17 // wallet is already unlocked
+	RPCWalletNotFound            RPCErrorCode = -18 // invalid wallet specified
+	RPCWalletNotSpecified        RPCErrorCode = -19 // no wallet specified with multiple wallets loaded
+	RPCWalletAlreadyLoaded       RPCErrorCode = -35 // this same wallet is already loaded
+	RPCWalletAlreadyExists       RPCErrorCode = -36 // there is already a wallet with the same name
+)
+
+var rpcErrorCodeNames = map[RPCErrorCode]string{
+	RPCInvalidRequest: "RPC_INVALID_REQUEST",
+	RPCMethodNotFound: "RPC_METHOD_NOT_FOUND",
+	RPCInvalidParams:  "RPC_INVALID_PARAMS",
+	RPCInternalError:  "RPC_INTERNAL_ERROR",
+	RPCParseError:     "RPC_PARSE_ERROR",
+
+	RPCMiscError:             "RPC_MISC_ERROR",
+	RPCForbiddenBySafeMode:   "RPC_FORBIDDEN_BY_SAFE_MODE",
+	RPCTypeError:             "RPC_TYPE_ERROR",
+	RPCInvalidAddressOrKey:   "RPC_INVALID_ADDRESS_OR_KEY",
+	RPCOutOfMemory:           "RPC_OUT_OF_MEMORY",
+	RPCInvalidParameter:      "RPC_INVALID_PARAMETER",
+	RPCDatabaseError:         "RPC_DATABASE_ERROR",
+	RPCDeserializationError:  "RPC_DESERIALIZATION_ERROR",
+	RPCVerifyError:           "RPC_VERIFY_ERROR",
+	RPCVerifyRejected:        "RPC_VERIFY_REJECTED",
+	RPCVerifyAlreadyInChain:  "RPC_VERIFY_ALREADY_IN_CHAIN",
+	RPCInWarmup:              "RPC_IN_WARMUP",
+	RPCMethodDeprecated:      "RPC_METHOD_DEPRECATED",
+	RPCClientMempoolDisabled: "RPC_CLIENT_MEMPOOL_DISABLED",
+
+	RPCClientNotConnected:        "RPC_CLIENT_NOT_CONNECTED",
+	RPCClientInInitialDownload:   "RPC_CLIENT_IN_INITIAL_DOWNLOAD",
+	RPCClientNodeAlreadyAdded:    "RPC_CLIENT_NODE_ALREADY_ADDED",
+	RPCClientNodeNotAdded:        "RPC_CLIENT_NODE_NOT_ADDED",
+	RPCClientNodeNotConnected:    "RPC_CLIENT_NODE_NOT_CONNECTED",
+	RPCClientInvalidIPOrSubnet:   "RPC_CLIENT_INVALID_IP_OR_SUBNET",
+	RPCClientP2PDisabled:         "RPC_CLIENT_P2P_DISABLED",
+	RPCClientNodeCapacityReached: "RPC_CLIENT_NODE_CAPACITY_REACHED",
+
+	RPCWalletError:               "RPC_WALLET_ERROR",
+	RPCWalletInsufficientFunds:   "RPC_WALLET_INSUFFICIENT_FUNDS",
+	RPCWalletInvalidLabelName:    "RPC_WALLET_INVALID_LABEL_NAME",
+	RPCWalletKeypoolRanOut:       "RPC_WALLET_KEYPOOL_RAN_OUT",
+	RPCWalletUnlockNeeded:        "RPC_WALLET_UNLOCK_NEEDED",
+	RPCWalletPassphraseIncorrect: "RPC_WALLET_PASSPHRASE_INCORRECT",
+	RPCWalletWrongEncState:       "RPC_WALLET_WRONG_ENC_STATE",
+	RPCWalletEncryptionFailed:    "RPC_WALLET_ENCRYPTION_FAILED",
+	RPCWalletAlreadyUnlocked:     "RPC_WALLET_ALREADY_UNLOCKED",
+	RPCWalletNotFound:            "RPC_WALLET_NOT_FOUND",
+	RPCWalletNotSpecified:        "RPC_WALLET_NOT_SPECIFIED",
+	RPCWalletAlreadyLoaded:       "RPC_WALLET_ALREADY_LOADED",
+	RPCWalletAlreadyExists:       "RPC_WALLET_ALREADY_EXISTS",
+}
+
+// String returns the name of the code in bitcoind, e.g. RPC_IN_WARMUP, or the number for unknown codes.
+func (c RPCErrorCode) String() string {
+	if name, ok := rpcErrorCodeNames[c]; ok {
+		return name
+	}
+	return strconv.Itoa(int(c))
+}
+
+// Error makes codes usable as targets of errors.Is, e.g. errors.Is(err, RPCInWarmup).
+func (c RPCErrorCode) Error() string {
+	return c.String()
+}
+
+// RPCError is an error returned by the node. Every call wraps the errors of the node in one, so that callers
+// can branch on its code with errors.As, or with errors.Is and a code or another *RPCError of the same code.
+type RPCError struct {
+	Code    RPCErrorCode
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("ERROR %d: %s", int(e.Code), e.Message)
+}
+
+// Is reports whether target is the code of e, or an *RPCError with the same code.
+func (e *RPCError) Is(target error) bool {
+	switch t := target.(type) {
+	case RPCErrorCode:
+		return e.Code == t
+	case *RPCError:
+		return t != nil && e.Code == t.Code
+	}
+	return false
+}
+
+// ErrorCode returns the code of an error returned by the node, also when it was wrapped. It reports false
+// for errors without a code, such as connection failures.
+func ErrorCode(err error) (RPCErrorCode, bool) {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code, true
+	}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		// Errors of the node that went through other formatting, e.g. a journal, read "ERROR <code>: <message>"
+		msg := err.Error()
+		if !strings.HasPrefix(msg, "ERROR ") {
+			continue
+		}
+
+		end := strings.Index(msg, ":")
+		if end < 0 {
+			continue
+		}
+
+		code, convErr := strconv.Atoi(msg[len("ERROR "):end])
+		if convErr != nil {
+			continue
+		}
+
+		return RPCErrorCode(code), true
+	}
+
+	return 0, false
+}
+
+// HasErrorCode reports whether err is an error returned by the node with the given code.
+func HasErrorCode(err error, code RPCErrorCode) bool {
+	c, ok := ErrorCode(err)
+	return ok && c == code
+}
+
+// IsRetryable reports whether err is returned by a node that cannot answer yet but will once it finished
+// starting up or syncing: RPC_IN_WARMUP, RPC_CLIENT_NOT_CONNECTED or RPC_CLIENT_IN_INITIAL_DOWNLOAD. Whether
+// the call itself may be repeated is a separate question, answered by IsRetrySafe.
+func IsRetryable(err error) bool {
+	code, ok := ErrorCode(err)
+	if !ok {
+		return false
+	}
+
+	switch code {
+	case RPCInWarmup, RPCClientNotConnected, RPCClientInInitialDownload:
+		return true
+	}
+	return false
+}
+
+// IsWalletLocked reports whether err is returned because the wallet must be unlocked with walletpassphrase.
+func IsWalletLocked(err error) bool {
+	return HasErrorCode(err, RPCWalletUnlockNeeded)
+}
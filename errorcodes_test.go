@@ -0,0 +1,74 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCode(t *testing.T) {
+	code, ok := ErrorCode(errors.New("ERROR -28: Loading block index..."))
+	assert.True(t, ok)
+	assert.Equal(t, RPCInWarmup, code)
+	assert.Equal(t, "RPC_IN_WARMUP", code.String())
+	assert.Equal(t, "-99", RPCErrorCode(-99).String())
+
+	wrapped := fmt.Errorf("failed to fund: %w", errors.New("ERROR -6: Insufficient funds"))
+	assert.True(t, HasErrorCode(wrapped, RPCWalletInsufficientFunds))
+
+	_, ok = ErrorCode(errors.New("failed to do request: connection refused"))
+	assert.False(t, ok)
+	_, ok = ErrorCode(nil)
+	assert.False(t, ok)
+	_, ok = ErrorCode(errors.New("ERROR: unexpected"))
+	assert.False(t, ok)
+
+	assert.True(t, IsRetryable(errors.New("ERROR -28: Verifying blocks...")))
+	assert.True(t, IsRetryable(errors.New("ERROR -10: Bitcoin Core is in initial sync and waiting for blocks...")))
+	assert.False(t, IsRetryable(errors.New("ERROR -26: min relay fee not met")))
+	assert.False(t, IsRetryable(errors.New("failed to do request: connection refused")))
+
+	assert.True(t, IsWalletLocked(errors.New("ERROR -13: Error: Please enter the wallet passphrase with walletpassphrase first.")))
+	assert.False(t, IsWalletLocked(errors.New("ERROR -14: Error: The wallet passphrase entered was incorrect.")))
+}
+
+func TestErrorCodeOfResponse(t *testing.T) {
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":null,"error":{"code":-13,"message":"Error: Please enter the wallet passphrase with walletpassphrase first."},"id":1}`)
+	})
+	defer closeServer()
+
+	var result json.RawMessage
+	err := b.uncachedCall("signrawtransactionwithwallet", []interface{}{"00"}, &result)
+	assert.True(t, IsWalletLocked(err))
+}
+
+func TestRPCError(t *testing.T) {
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"result":null,"error":{"code":-29,"message":"Node not found in connected nodes"},"id":1}`)
+	})
+	defer closeServer()
+
+	err := b.DisconnectNode(7)
+	assert.EqualError(t, err, "unexpected response code 500: Node not found in connected nodes")
+
+	var rpcErr *RPCError
+	if assert.True(t, errors.As(err, &rpcErr)) {
+		assert.Equal(t, RPCClientNodeNotConnected, rpcErr.Code)
+		assert.Equal(t, "Node not found in connected nodes", rpcErr.Message)
+	}
+	assert.True(t, errors.Is(err, RPCClientNodeNotConnected))
+	assert.True(t, errors.Is(err, &RPCError{Code: RPCClientNodeNotConnected}))
+	assert.False(t, errors.Is(err, RPCClientNodeNotAdded))
+	assert.True(t, HasErrorCode(err, RPCClientNodeNotConnected))
+
+	wrapped := fmt.Errorf("failed to sign: %w", &RPCError{Code: RPCWalletUnlockNeeded, Message: "Please enter the wallet passphrase"})
+	assert.EqualError(t, wrapped, "failed to sign: ERROR -13: Please enter the wallet passphrase")
+	assert.True(t, IsWalletLocked(wrapped))
+	assert.True(t, errors.Is(wrapped, RPCWalletUnlockNeeded))
+}
@@ -0,0 +1,200 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// esploraPageSize is the number of confirmed transactions Esplora returns per address history page.
+const esploraPageSize = 25
+
+// EsploraClient is a TxSource and ChainBackend backed by the REST API of an Esplora server, such as
+// https://blockstream.info/api or https://mempool.space/api.
+type EsploraClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewEsploraClient returns a client for the Esplora API at baseURL.
+func NewEsploraClient(baseURL string) *EsploraClient {
+	return &EsploraClient{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request and returns the response body, marking network errors and server side failures as
+// ErrSourceUnavailable.
+func (c *EsploraClient) do(ctx context.Context, method, path string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32*1024*1024))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: esplora %s %s: %s", ErrSourceUnavailable, method, path, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("esplora %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
+
+// GetTransactionHex implements TxSource.
+func (c *EsploraClient) GetTransactionHex(ctx context.Context, txid string) (string, error) {
+	data, err := c.do(ctx, http.MethodGet, "/tx/"+txid+"/hex", nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+type esploraTx struct {
+	TxID   string `json:"txid"`
+	Status struct {
+		Confirmed   bool `json:"confirmed"`
+		BlockHeight int  `json:"block_height"`
+	} `json:"status"`
+}
+
+// AddressHistory implements TxSource, following the pagination of confirmed transactions.
+func (c *EsploraClient) AddressHistory(ctx context.Context, address string) ([]AddressTx, error) {
+	var confirmed, unconfirmed []AddressTx
+
+	// The first page holds the mempool transactions followed by the newest confirmed ones
+	path := "/address/" + address + "/txs"
+
+	for {
+		data, err := c.do(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var txs []esploraTx
+		if err := json.Unmarshal(data, &txs); err != nil {
+			return nil, fmt.Errorf("esplora: %w", err)
+		}
+
+		page := 0
+		for _, tx := range txs {
+			if !tx.Status.Confirmed {
+				unconfirmed = append(unconfirmed, AddressTx{TxID: tx.TxID})
+				continue
+			}
+			confirmed = append(confirmed, AddressTx{TxID: tx.TxID, Height: tx.Status.BlockHeight})
+			page++
+		}
+
+		if page < esploraPageSize {
+			break
+		}
+		path = "/address/" + address + "/txs/chain/" + confirmed[len(confirmed)-1].TxID
+	}
+
+	// Esplora lists newest first
+	for i, j := 0, len(confirmed)-1; i < j; i, j = i+1, j-1 {
+		confirmed[i], confirmed[j] = confirmed[j], confirmed[i]
+	}
+
+	return append(confirmed, unconfirmed...), nil
+}
+
+// Broadcast implements TxSource.
+func (c *EsploraClient) Broadcast(ctx context.Context, txHex string) (string, error) {
+	data, err := c.do(ctx, http.MethodPost, "/tx", strings.NewReader(txHex))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// GetBestBlockHash implements ChainBackend.
+func (c *EsploraClient) GetBestBlockHash(ctx context.Context) (string, error) {
+	data, err := c.do(ctx, http.MethodGet, "/blocks/tip/hash", nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// GetBlockHash implements ChainBackend.
+func (c *EsploraClient) GetBlockHash(ctx context.Context, height int) (string, error) {
+	data, err := c.do(ctx, http.MethodGet, "/block-height/"+strconv.Itoa(height), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// GetRawBlock implements ChainBackend.
+func (c *EsploraClient) GetRawBlock(ctx context.Context, hash string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, "/block/"+hash+"/raw", nil)
+}
+
+// GetMempoolTxIDs implements ChainBackend.
+func (c *EsploraClient) GetMempoolTxIDs(ctx context.Context) ([]string, error) {
+	data, err := c.do(ctx, http.MethodGet, "/mempool/txids", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var txids []string
+	if err := json.Unmarshal(data, &txids); err != nil {
+		return nil, fmt.Errorf("esplora: %w", err)
+	}
+
+	return txids, nil
+}
+
+// EstimateFeeRate implements ChainBackend. Esplora only estimates for some targets, the estimate of the
+// highest one not above confTarget is returned.
+func (c *EsploraClient) EstimateFeeRate(ctx context.Context, confTarget int) (float64, error) {
+	data, err := c.do(ctx, http.MethodGet, "/fee-estimates", nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var estimates map[string]float64
+	if err := json.Unmarshal(data, &estimates); err != nil {
+		return 0, fmt.Errorf("esplora: %w", err)
+	}
+
+	best, rate := 0, 0.0
+	for key, r := range estimates {
+		target, err := strconv.Atoi(key)
+		if err == nil && target <= confTarget && target > best {
+			best, rate = target, r
+		}
+	}
+
+	if best == 0 {
+		return 0, fmt.Errorf("esplora: no fee estimate for %d blocks", confTarget)
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,79 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEsploraClient(t *testing.T) {
+	confirmed := func(from, to int) string {
+		var txs []string
+		for h := to; h >= from; h-- {
+			txs = append(txs, fmt.Sprintf(`{"txid":"tx%d","status":{"confirmed":true,"block_height":%d}}`, h, h))
+		}
+		return "[" + strings.Join(txs, ",") + "]"
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /tx/abc/hex":
+			_, _ = io.WriteString(w, "0100\n")
+		case "GET /tx/def/hex":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "Transaction not found")
+		case "GET /address/addr/txs":
+			// One mempool transaction and the newest page of confirmed ones
+			page := confirmed(6, 30)
+			_, _ = io.WriteString(w, `[{"txid":"mempool","status":{"confirmed":false}},`+page[1:])
+		case "GET /address/addr/txs/chain/tx6":
+			_, _ = io.WriteString(w, confirmed(1, 5))
+		case "POST /tx":
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != "0100" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = io.WriteString(w, "abc")
+		case "GET /tx/busy/hex":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewEsploraClient(srv.URL + "/")
+	ctx := context.Background()
+
+	txHex, err := c.GetTransactionHex(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "0100", txHex)
+
+	_, err = c.GetTransactionHex(ctx, "def")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSourceUnavailable)
+
+	_, err = c.GetTransactionHex(ctx, "busy")
+	assert.ErrorIs(t, err, ErrSourceUnavailable)
+
+	history, err := c.AddressHistory(ctx, "addr")
+	require.NoError(t, err)
+	require.Len(t, history, 31)
+	assert.Equal(t, AddressTx{TxID: "tx1", Height: 1}, history[0])
+	assert.Equal(t, AddressTx{TxID: "tx30", Height: 30}, history[29])
+	assert.Equal(t, AddressTx{TxID: "mempool"}, history[30])
+
+	txid, err := c.Broadcast(ctx, "0100")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", txid)
+
+	srv.Close()
+	_, err = c.GetTransactionHex(ctx, "abc")
+	assert.ErrorIs(t, err, ErrSourceUnavailable)
+}
@@ -0,0 +1,132 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+// MinInputWeight is the weight of an input with an empty scriptSig and an empty witness, the lowest weight the
+// node accepts in input_weights.
+const MinInputWeight = 4*(32+4+1+4) + 1
+
+// maxStandardTxWeight is the weight of the largest transaction the node relays.
+const maxStandardTxWeight = 400000
+
+// maxSignatureSize is the size of a DER encoded ECDSA signature with its sighash byte at most, which the node
+// also assumes for external inputs.
+const maxSignatureSize = 72
+
+// ExternalInput is an input the wallet does not own, such as an output of a hardware wallet or of a multisig
+// address, to fund a PSBT with.
+type ExternalInput struct {
+	TxID string
+	Vout int
+	// Sequence is the nSequence of the input, 0 for the node's default.
+	Sequence uint32
+	// Weight is the weight of the input once signed, scriptSig and witness included, for the fee calculation.
+	// 0 leaves it to the node, which then needs the SolvingData of the input.
+	Weight int
+}
+
+// SolvingData lets the wallet work out the size of external inputs it cannot solve otherwise: the public
+// keys, redeem and witness scripts, or descriptors of the outputs they spend. Keys and scripts are hex.
+type SolvingData struct {
+	PubKeys     []string `json:"pubkeys,omitempty"`
+	Scripts     []string `json:"scripts,omitempty"`
+	Descriptors []string `json:"descriptors,omitempty"`
+}
+
+func (s *SolvingData) empty() bool {
+	return s == nil || len(s.PubKeys)+len(s.Scripts)+len(s.Descriptors) == 0
+}
+
+// FundPSBTOptions are the options of FundPSBTWithExternalInputs.
+type FundPSBTOptions struct {
+	FeeRate       float64 // sat/vB, 0 for the wallet's estimate
+	ChangeAddress string  // empty for a new wallet address
+	Replaceable   bool
+	LockTime      uint32
+	// IncludeWatching lets the wallet add inputs of watch-only addresses.
+	IncludeWatching bool
+	// SolvingData is needed for the external inputs without a Weight.
+	SolvingData *SolvingData
+}
+
+// FundPSBTWithExternalInputs creates a PSBT spending the external inputs to outputs (address to BTC amount),
+// letting the wallet add its own inputs for the rest of the amount and the fee, and change. The fee accounts
+// for the external inputs with their Weight, which takes a v24 node, or with what the wallet can solve from
+// opts.SolvingData, which takes a v23 node. Inputs with neither are rejected before calling the node.
+func (b *Bitcoind) FundPSBTWithExternalInputs(inputs []ExternalInput, outputs map[string]float64, opts *FundPSBTOptions) (*FundedPSBT, error) {
+	if opts == nil {
+		opts = &FundPSBTOptions{}
+	}
+
+	params := make([]map[string]interface{}, len(inputs))
+	var weights []map[string]interface{}
+
+	for i, in := range inputs {
+		params[i] = map[string]interface{}{"txid": in.TxID, "vout": in.Vout}
+		if in.Sequence != 0 {
+			params[i]["sequence"] = in.Sequence
+		}
+
+		switch {
+		case in.Weight == 0 && opts.SolvingData.empty():
+			return nil, fmt.Errorf("input %s:%d has neither a weight nor solving data", in.TxID, in.Vout)
+		case in.Weight == 0:
+		case in.Weight < MinInputWeight || in.Weight > maxStandardTxWeight:
+			return nil, fmt.Errorf("input %s:%d has invalid weight %d", in.TxID, in.Vout, in.Weight)
+		default:
+			weights = append(weights, map[string]interface{}{"txid": in.TxID, "vout": in.Vout, "weight": in.Weight})
+		}
+	}
+
+	if len(outputs) == 0 {
+		return nil, errors.New("no outputs")
+	}
+
+	amounts := make(map[string]interface{}, len(outputs))
+	for address, amount := range outputs {
+		amounts[address] = json.Number(fmt.Sprintf("%.8f", amount))
+	}
+
+	options := map[string]interface{}{
+		"add_inputs":      true,
+		"includeWatching": opts.IncludeWatching,
+		"replaceable":     opts.Replaceable,
+	}
+	if opts.FeeRate != 0 {
+		options["fee_rate"] = opts.FeeRate
+	}
+	if opts.ChangeAddress != "" {
+		options["changeAddress"] = opts.ChangeAddress
+	}
+	if weights != nil {
+		options["input_weights"] = weights
+	}
+	if !opts.SolvingData.empty() {
+		options["solving_data"] = opts.SolvingData
+	}
+
+	var funded FundedPSBT
+	if err := b.uncachedCall("walletcreatefundedpsbt", []interface{}{params, amounts, opts.LockTime, options, true}, &funded); err != nil {
+		return nil, err
+	}
+
+	return &funded, nil
+}
+
+// MultisigInputWeight returns the weight of an input spending an m of n P2WSH multisig output once signed,
+// with signatures of the maximum size, for ExternalInput.Weight.
+func MultisigInputWeight(m, n int) int {
+	script := 3 + 34*n // OP_m, n pushes of compressed keys, OP_n and OP_CHECKMULTISIG
+
+	// The empty item for the CHECKMULTISIG bug, the signatures and the witness script, the item count being
+	// part of MinInputWeight
+	witness := 1 + m*(1+maxSignatureSize) + len(cryptolib.VarInt(uint64(script))) + script
+
+	return MinInputWeight + witness
+}
@@ -0,0 +1,72 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFundPSBTWithExternalInputs(t *testing.T) {
+	var params []interface{}
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+
+		_, _ = w.Write([]byte(`{"result":{"psbt":"cHNidP8=","fee":0.00000520,"changepos":1},"error":null,"id":1}`))
+	})
+	defer closeServer()
+
+	multisig := strings.Repeat("ab", 32)
+	hardware := strings.Repeat("cd", 32)
+
+	funded, err := b.FundPSBTWithExternalInputs([]ExternalInput{
+		{TxID: multisig, Vout: 1, Weight: MultisigInputWeight(2, 3)},
+		{TxID: hardware, Sequence: 10},
+	}, map[string]float64{"addr": 0.001}, &FundPSBTOptions{
+		FeeRate:     2,
+		Replaceable: true,
+		SolvingData: &SolvingData{Descriptors: []string{"wpkh([d34db33f/84h/0h/0h]xpub/0/*)"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &FundedPSBT{PSBT: "cHNidP8=", Fee: 0.0000052, ChangePos: 1}, funded)
+
+	assert.Equal(t, []interface{}{
+		[]interface{}{
+			map[string]interface{}{"txid": multisig, "vout": float64(1)},
+			map[string]interface{}{"txid": hardware, "vout": float64(0), "sequence": float64(10)},
+		},
+		map[string]interface{}{"addr": 0.001},
+		float64(0),
+		map[string]interface{}{
+			"add_inputs":      true,
+			"includeWatching": false,
+			"replaceable":     true,
+			"fee_rate":        float64(2),
+			"input_weights":   []interface{}{map[string]interface{}{"txid": multisig, "vout": float64(1), "weight": float64(418)}},
+			"solving_data":    map[string]interface{}{"descriptors": []interface{}{"wpkh([d34db33f/84h/0h/0h]xpub/0/*)"}},
+		},
+		true,
+	}, params)
+
+	// Inputs the wallet cannot size are rejected without calling the node
+	params = nil
+	_, err = b.FundPSBTWithExternalInputs([]ExternalInput{{TxID: hardware}}, map[string]float64{"addr": 0.001}, nil)
+	assert.EqualError(t, err, "input "+hardware+":0 has neither a weight nor solving data")
+
+	_, err = b.FundPSBTWithExternalInputs([]ExternalInput{{TxID: hardware, Weight: 100}}, map[string]float64{"addr": 0.001}, nil)
+	assert.EqualError(t, err, "input "+hardware+":0 has invalid weight 100")
+	assert.Nil(t, params)
+}
+
+func TestMultisigInputWeight(t *testing.T) {
+	// Core estimates 104.5 vB for a 2 of 3 P2WSH input with signatures of maximum size
+	assert.Equal(t, 418, MultisigInputWeight(2, 3))
+	assert.Equal(t, 165+1+73+1+37, MultisigInputWeight(1, 1))
+}
@@ -0,0 +1,103 @@
+package bitcoin
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSigningIncomplete is returned by SignerSend when the signer did not sign every input, e.g. because the
+// user declined on the device.
+var ErrSigningIncomplete = errors.New("signing incomplete")
+
+// ExternalSigner is a signing device found by enumeratesigners, through the -signer command of the node,
+// usually HWI.
+type ExternalSigner struct {
+	Fingerprint string `json:"fingerprint"` // of the master key, hex
+	Name        string `json:"name"`
+}
+
+// CreatedWallet is the result of createwallet.
+type CreatedWallet struct {
+	Name     string   `json:"name"`
+	Warning  string   `json:"warning,omitempty"`  // nodes before v25
+	Warnings []string `json:"warnings,omitempty"` // v25 and later
+}
+
+// EnumerateSigners returns the signing devices connected to the node. The node must be started with
+// -signer set to the path of HWI or a compatible command.
+func (b *Bitcoind) EnumerateSigners() ([]ExternalSigner, error) {
+	var res struct {
+		Signers []ExternalSigner `json:"signers"`
+	}
+	if err := b.uncachedCall("enumeratesigners", nil, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Signers, nil
+}
+
+// CreateSignerWallet creates and loads a descriptor wallet without private keys, importing its descriptors
+// from the single connected signing device, which then signs for it. It takes a v22 node built with
+// external signer support.
+func (b *Bitcoind) CreateSignerWallet(name string) (*CreatedWallet, error) {
+	// wallet_name, disable_private_keys, blank, passphrase, avoid_reuse, descriptors, load_on_startup,
+	// external_signer
+	params := []interface{}{name, true, false, "", false, true, nil, true}
+
+	var res CreatedWallet
+	if err := b.uncachedCall("createwallet", params, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// WalletDisplayAddress shows address on the signing device of an external signer wallet, for the user to
+// verify it before handing it out, and returns it once the device displayed it.
+func (b *Bitcoind) WalletDisplayAddress(address string) (string, error) {
+	var res struct {
+		Address string `json:"address"`
+	}
+	if err := b.uncachedCall("walletdisplayaddress", []interface{}{address}, &res); err != nil {
+		return "", err
+	}
+
+	return res.Address, nil
+}
+
+// SignerSend pays outputs (address to BTC amount) at feeRate (sat/vB) from an external signer wallet: the
+// wallet funds a PSBT, has the signing device sign it, which waits for the user to confirm on the device,
+// and the transaction is broadcast, subject to the withdrawal policy. Calls to walletprocesspsbt therefore
+// take as long as the user does, which a TimeoutProfile should allow for.
+func (b *Bitcoind) SignerSend(outputs map[string]float64, feeRate float64) (string, error) {
+	funded, err := b.WalletCreateFundedPSBT(outputs, map[string]interface{}{
+		"fee_rate":    feeRate,
+		"replaceable": true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	processed, err := b.WalletProcessPSBT(funded.PSBT, nil)
+	if err != nil {
+		return "", fmt.Errorf("signing with the external signer failed: %w", err)
+	}
+	if !processed.Complete {
+		return "", ErrSigningIncomplete
+	}
+
+	txHex := processed.Hex
+	if txHex == "" {
+		// Nodes before v26 do not return the finalized transaction
+		final, err := b.FinalizePSBT(processed.PSBT)
+		if err != nil {
+			return "", err
+		}
+		if !final.Complete {
+			return "", ErrSigningIncomplete
+		}
+		txHex = final.Hex
+	}
+
+	return b.broadcast(txHex)
+}
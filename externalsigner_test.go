@@ -0,0 +1,75 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalSigner(t *testing.T) {
+	signed := hex.EncodeToString(testTx([]Outpoint{{TxID: strings.Repeat("ab", 32)}}, "51", 1000))
+	txid, _, _ := TransactionIDs(signed)
+
+	complete := true
+	var methods []string
+	var createParams []interface{}
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		methods = append(methods, req.Method)
+
+		switch req.Method {
+		case "enumeratesigners":
+			fmt.Fprint(w, `{"result":{"signers":[{"fingerprint":"d34db33f","name":"trezor_t"}]},"error":null,"id":1}`)
+		case "createwallet":
+			createParams = req.Params
+			fmt.Fprint(w, `{"result":{"name":"hww","warnings":[]},"error":null,"id":1}`)
+		case "walletdisplayaddress":
+			fmt.Fprintf(w, `{"result":{"address":%q},"error":null,"id":1}`, req.Params[0])
+		case "walletcreatefundedpsbt":
+			fmt.Fprint(w, `{"result":{"psbt":"funded","fee":0.00000500,"changepos":1},"error":null,"id":1}`)
+		case "walletprocesspsbt":
+			fmt.Fprintf(w, `{"result":{"psbt":"signed","complete":%t},"error":null,"id":1}`, complete)
+		case "finalizepsbt":
+			fmt.Fprintf(w, `{"result":{"hex":%q,"complete":true},"error":null,"id":1}`, signed)
+		case "sendrawtransaction":
+			fmt.Fprintf(w, `{"result":%q,"error":null,"id":1}`, txid)
+		}
+	})
+	defer closeServer()
+
+	signers, err := b.EnumerateSigners()
+	require.NoError(t, err)
+	assert.Equal(t, []ExternalSigner{{Fingerprint: "d34db33f", Name: "trezor_t"}}, signers)
+
+	wallet, err := b.CreateSignerWallet("hww")
+	require.NoError(t, err)
+	assert.Equal(t, "hww", wallet.Name)
+	assert.Equal(t, []interface{}{"hww", true, false, "", false, true, nil, true}, createParams)
+
+	address, err := b.WalletDisplayAddress("bc1qaddress")
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qaddress", address)
+
+	methods = nil
+	sent, err := b.SignerSend(map[string]float64{"bc1qdest": 0.001}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, txid, sent)
+	assert.Equal(t, []string{"walletcreatefundedpsbt", "walletprocesspsbt", "finalizepsbt", "sendrawtransaction"}, methods)
+
+	// A signature declined on the device is not broadcast
+	complete = false
+	methods = nil
+	_, err = b.SignerSend(map[string]float64{"bc1qdest": 0.001}, 2)
+	assert.ErrorIs(t, err, ErrSigningIncomplete)
+	assert.NotContains(t, methods, "sendrawtransaction")
+}
@@ -0,0 +1,152 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by TxSource implementations. FallbackSource moves on to the next source on either.
+var (
+	ErrSourceUnavailable = errors.New("source unavailable")
+	ErrNotSupported      = errors.New("operation not supported by this source")
+)
+
+// AddressTx is an entry of the transaction history of an address.
+type AddressTx struct {
+	TxID   string `json:"txid"`
+	Height int    `json:"height"` // 0 while unconfirmed
+}
+
+// TxSource is the subset of read and broadcast operations that public infrastructure such as Esplora and
+// ElectrumX servers offers as well as the node.
+type TxSource interface {
+	// GetTransactionHex returns the serialized transaction with the given txid.
+	GetTransactionHex(ctx context.Context, txid string) (string, error)
+	// AddressHistory returns the transactions paying to or spending from an address, confirmed ones first.
+	AddressHistory(ctx context.Context, address string) ([]AddressTx, error)
+	// Broadcast sends a signed transaction and returns its txid.
+	Broadcast(ctx context.Context, txHex string) (string, error)
+}
+
+// nodeSource is the TxSource of a node, refusing to answer while the node is not ready.
+type nodeSource struct {
+	*nodeBackend
+}
+
+// NodeSource returns b as a TxSource. It reports ErrSourceUnavailable while the node is unreachable or in
+// initial block download, when its answers are not authoritative, and ErrNotSupported for AddressHistory.
+func NodeSource(b *Bitcoind) TxSource {
+	return &nodeSource{&nodeBackend{b: b}}
+}
+
+func (s *nodeSource) ready() error {
+	info, err := s.b.GetBlockchainInfo()
+	if err != nil {
+		return nodeSourceError(err)
+	}
+
+	if info.InitialBlockDownload {
+		return fmt.Errorf("%w: node is syncing, %d of %d blocks", ErrSourceUnavailable, info.Blocks, info.Headers)
+	}
+
+	return nil
+}
+
+func (s *nodeSource) GetTransactionHex(ctx context.Context, txid string) (string, error) {
+	if err := s.ready(); err != nil {
+		return "", err
+	}
+
+	txHex, err := s.nodeBackend.GetTransactionHex(ctx, txid)
+	if err != nil {
+		return "", nodeSourceError(err)
+	}
+
+	return txHex, nil
+}
+
+func (s *nodeSource) Broadcast(ctx context.Context, txHex string) (string, error) {
+	if err := s.ready(); err != nil {
+		return "", err
+	}
+
+	txid, err := s.nodeBackend.Broadcast(ctx, txHex)
+	if err != nil {
+		return "", nodeSourceError(err)
+	}
+
+	return txid, nil
+}
+
+// nodeSourceError marks connection failures, warmup and syncing as ErrSourceUnavailable.
+func nodeSourceError(err error) error {
+	msg := err.Error()
+	if strings.Contains(msg, "failed to do request") || IsRetryable(err) || strings.Contains(msg, "Loading") {
+		return fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+
+	return err
+}
+
+// FallbackSource is a TxSource trying a list of sources in order, typically the node followed by public
+// servers. The next source is only asked when a source is unavailable or does not support the operation, so
+// that a definitive answer such as a rejected transaction is returned as is.
+type FallbackSource struct {
+	sources []TxSource
+	logger  Logger
+}
+
+// NewFallbackSource returns a source trying the given sources in order. Fallbacks are logged as warnings
+// to logger, which may be nil.
+func NewFallbackSource(logger Logger, sources ...TxSource) *FallbackSource {
+	if logger == nil {
+		logger = &DefaultLogger{}
+	}
+
+	return &FallbackSource{sources: sources, logger: logger}
+}
+
+func (f *FallbackSource) try(op string, fn func(TxSource) error) error {
+	err := fmt.Errorf("%w: no sources configured", ErrSourceUnavailable)
+
+	for i, s := range f.sources {
+		if err = fn(s); err == nil || !(errors.Is(err, ErrSourceUnavailable) || errors.Is(err, ErrNotSupported)) {
+			return err
+		}
+
+		if i < len(f.sources)-1 {
+			f.logger.Warnf("FallbackSource: %s: source %d: %v, trying the next one", op, i, err)
+		}
+	}
+
+	return err
+}
+
+// GetTransactionHex implements TxSource.
+func (f *FallbackSource) GetTransactionHex(ctx context.Context, txid string) (txHex string, err error) {
+	err = f.try("GetTransactionHex", func(s TxSource) (err error) {
+		txHex, err = s.GetTransactionHex(ctx, txid)
+		return
+	})
+	return
+}
+
+// AddressHistory implements TxSource.
+func (f *FallbackSource) AddressHistory(ctx context.Context, address string) (history []AddressTx, err error) {
+	err = f.try("AddressHistory", func(s TxSource) (err error) {
+		history, err = s.AddressHistory(ctx, address)
+		return
+	})
+	return
+}
+
+// Broadcast implements TxSource.
+func (f *FallbackSource) Broadcast(ctx context.Context, txHex string) (txid string, err error) {
+	err = f.try("Broadcast", func(s TxSource) (err error) {
+		txid, err = s.Broadcast(ctx, txHex)
+		return
+	})
+	return
+}
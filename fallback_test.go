@@ -0,0 +1,97 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	txHex string
+	err   error
+	calls int
+}
+
+func (s *fakeSource) GetTransactionHex(ctx context.Context, txid string) (string, error) {
+	s.calls++
+	return s.txHex, s.err
+}
+
+func (s *fakeSource) AddressHistory(ctx context.Context, address string) ([]AddressTx, error) {
+	s.calls++
+	return nil, s.err
+}
+
+func (s *fakeSource) Broadcast(ctx context.Context, txHex string) (string, error) {
+	s.calls++
+	return "txid", s.err
+}
+
+type discardLogger struct{ DefaultLogger }
+
+func (discardLogger) Warnf(format string, args ...interface{}) {}
+
+func TestFallbackSource(t *testing.T) {
+	ctx := context.Background()
+
+	syncing := &fakeSource{err: fmt.Errorf("%w: syncing", ErrSourceUnavailable)}
+	public := &fakeSource{txHex: "0100"}
+
+	f := NewFallbackSource(&discardLogger{}, syncing, public)
+	txHex, err := f.GetTransactionHex(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "0100", txHex)
+	assert.Equal(t, 1, syncing.calls)
+
+	// A definitive answer is not second guessed
+	rejecting := &fakeSource{err: errors.New("bad-txns-inputs-missingorspent")}
+	public.calls = 0
+	f = NewFallbackSource(&discardLogger{}, rejecting, public)
+	_, err = f.Broadcast(ctx, "0100")
+	assert.EqualError(t, err, "bad-txns-inputs-missingorspent")
+	assert.Equal(t, 0, public.calls)
+
+	f = NewFallbackSource(&discardLogger{}, NodeSource(&Bitcoind{}), syncing)
+	_, err = f.AddressHistory(ctx, "addr")
+	assert.ErrorIs(t, err, ErrSourceUnavailable)
+}
+
+func TestNodeSource(t *testing.T) {
+	var synced int32
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "getblockchaininfo":
+			fmt.Fprintf(w, `{"result":{"blocks":10,"headers":800000,"initialblockdownload":%v},"error":null,"id":1}`, atomic.LoadInt32(&synced) == 0)
+		case "getrawtransaction":
+			_, _ = w.Write([]byte(`{"result":"0100","error":null,"id":1}`))
+		}
+	})
+	defer done()
+
+	// The node's state changes between calls
+	b.Storage = cache.New(time.Nanosecond, time.Minute)
+	s := NodeSource(b)
+
+	_, err := s.GetTransactionHex(context.Background(), "abc")
+	assert.ErrorIs(t, err, ErrSourceUnavailable)
+
+	atomic.StoreInt32(&synced, 1)
+	time.Sleep(time.Millisecond) // let the cached blockchain info expire
+
+	txHex, err := s.GetTransactionHex(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "0100", txHex)
+}
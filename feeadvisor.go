@@ -0,0 +1,257 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FeeOracle estimates fee rates in sat/vB. ChainBackend implementations such as EsploraClient are fee
+// oracles, as is HTTPFeeOracle for other services.
+type FeeOracle interface {
+	EstimateFeeRate(ctx context.Context, confTarget int) (float64, error)
+}
+
+// HTTPFeeOracle is a FeeOracle reading the estimates of a fee service from URL, decoded by Parse.
+type HTTPFeeOracle struct {
+	URL        string
+	HTTPClient *http.Client
+	// Parse returns the fee rate in sat/vB for confTarget from the response body.
+	Parse func(body []byte, confTarget int) (float64, error)
+}
+
+// NewMempoolSpaceOracle returns an oracle for the recommended fees of the mempool.space API at baseURL,
+// e.g. https://mempool.space/api. The fastest fee is used for the next block, the half hour fee for up to
+// 3 blocks, the hour fee for up to 6 and the economy fee beyond.
+func NewMempoolSpaceOracle(baseURL string) *HTTPFeeOracle {
+	return &HTTPFeeOracle{
+		URL:        strings.TrimRight(baseURL, "/") + "/v1/fees/recommended",
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		Parse:      parseMempoolSpaceFees,
+	}
+}
+
+func parseMempoolSpaceFees(body []byte, confTarget int) (float64, error) {
+	var fees struct {
+		Fastest  float64 `json:"fastestFee"`
+		HalfHour float64 `json:"halfHourFee"`
+		Hour     float64 `json:"hourFee"`
+		Economy  float64 `json:"economyFee"`
+	}
+	if err := json.Unmarshal(body, &fees); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case confTarget <= 1:
+		return fees.Fastest, nil
+	case confTarget <= 3:
+		return fees.HalfHour, nil
+	case confTarget <= 6:
+		return fees.Hour, nil
+	default:
+		return fees.Economy, nil
+	}
+}
+
+// EstimateFeeRate implements FeeOracle.
+func (o *HTTPFeeOracle) EstimateFeeRate(ctx context.Context, confTarget int) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%w: %s: %s", ErrSourceUnavailable, o.URL, resp.Status)
+	}
+
+	rate, err := o.Parse(data, confTarget)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", o.URL, err)
+	}
+	if rate <= 0 {
+		return 0, fmt.Errorf("%s: no fee estimate for %d blocks", o.URL, confTarget)
+	}
+
+	return rate, nil
+}
+
+// FeePolicy chooses the fee rate a FeeAdvisor recommends.
+type FeePolicy int
+
+const (
+	// FeePolicyNode recommends the node's estimate, and the median of the external ones if it has none.
+	FeePolicyNode FeePolicy = iota
+	// FeePolicyExternalOnDivergence recommends the node's estimate unless it diverges from the external
+	// ones, e.g. while the node's estimator lacks data after a restart, and then their median.
+	FeePolicyExternalOnDivergence
+	// FeePolicyMedian recommends the median of all estimates.
+	FeePolicyMedian
+	// FeePolicyHighest recommends the highest estimate, for payments that must not get stuck.
+	FeePolicyHighest
+)
+
+// FeeAdvice is the recommendation of a FeeAdvisor with the estimates it is based on.
+type FeeAdvice struct {
+	FeeRate   float64            // sat/vB, as chosen by the policy
+	NodeRate  float64            // 0 if the node had no estimate
+	Estimates map[string]float64 // of the external sources that answered, by name
+	Errors    map[string]error   // of the sources that did not, by name, "node" for the node
+	// Divergence is the ratio of the higher to the lower of the node's estimate and the median of the
+	// external ones, 0 if either is missing.
+	Divergence float64
+	Divergent  bool // Divergence exceeds the advisor's MaxDivergence
+}
+
+// FeeAdvisor cross-checks the node's estimatesmartfee against external fee oracles, flagging estimates that
+// diverge by more than MaxDivergence and recommending a fee rate according to Policy. Divergent estimates
+// are logged as warnings.
+type FeeAdvisor struct {
+	Sources       map[string]FeeOracle
+	Policy        FeePolicy
+	MaxDivergence float64 // ratio of the higher to the lower estimate
+
+	b *Bitcoind
+}
+
+// NewFeeAdvisor returns an advisor checking the node's estimates against sources, flagging them when they
+// are off by more than a factor of two and recommending the node's estimate.
+func NewFeeAdvisor(b *Bitcoind, sources map[string]FeeOracle) *FeeAdvisor {
+	return &FeeAdvisor{
+		Sources:       sources,
+		Policy:        FeePolicyNode,
+		MaxDivergence: 2,
+		b:             b,
+	}
+}
+
+// Advise queries the node and the external sources concurrently and recommends a fee rate for confirmation
+// within confTarget blocks. It fails only if no source has an estimate.
+func (a *FeeAdvisor) Advise(ctx context.Context, confTarget int) (*FeeAdvice, error) {
+	type estimate struct {
+		name string
+		rate float64
+		err  error
+	}
+
+	results := make(chan estimate, len(a.Sources)+1)
+	go func() {
+		rate, err := a.b.Backend().EstimateFeeRate(ctx, confTarget)
+		results <- estimate{"node", rate, err}
+	}()
+	for name, source := range a.Sources {
+		go func(name string, source FeeOracle) {
+			rate, err := source.EstimateFeeRate(ctx, confTarget)
+			results <- estimate{name, rate, err}
+		}(name, source)
+	}
+
+	advice := &FeeAdvice{Estimates: make(map[string]float64), Errors: make(map[string]error)}
+	for i := 0; i < len(a.Sources)+1; i++ {
+		e := <-results
+		switch {
+		case e.err != nil:
+			advice.Errors[e.name] = e.err
+		case e.name == "node":
+			advice.NodeRate = e.rate
+		default:
+			advice.Estimates[e.name] = e.rate
+		}
+	}
+
+	external := make([]float64, 0, len(advice.Estimates))
+	for _, rate := range advice.Estimates {
+		external = append(external, rate)
+	}
+	externalMedian := median(external)
+
+	if advice.NodeRate > 0 && externalMedian > 0 {
+		advice.Divergence = advice.NodeRate / externalMedian
+		if advice.Divergence < 1 {
+			advice.Divergence = 1 / advice.Divergence
+		}
+		advice.Divergent = advice.Divergence > a.MaxDivergence
+	}
+
+	if advice.Divergent {
+		a.b.client.logger.Warnf("FeeAdvisor: node estimates %.2f sat/vB for %d blocks, external sources %.2f sat/vB", advice.NodeRate,
+			confTarget, externalMedian)
+	}
+
+	all := append([]float64{}, external...)
+	if advice.NodeRate > 0 {
+		all = append(all, advice.NodeRate)
+	}
+	if len(all) == 0 {
+		return nil, fmt.Errorf("no fee estimate for %d blocks: %s", confTarget, joinErrors(advice.Errors))
+	}
+
+	switch a.Policy {
+	case FeePolicyMedian:
+		advice.FeeRate = median(all)
+	case FeePolicyHighest:
+		sort.Float64s(all)
+		advice.FeeRate = all[len(all)-1]
+	case FeePolicyExternalOnDivergence:
+		advice.FeeRate = advice.NodeRate
+		if advice.Divergent || advice.NodeRate == 0 {
+			advice.FeeRate = externalMedian
+		}
+	default:
+		advice.FeeRate = advice.NodeRate
+		if advice.NodeRate == 0 {
+			advice.FeeRate = externalMedian
+		}
+	}
+
+	return advice, nil
+}
+
+// median returns the median of rates, 0 for none. rates is sorted in place.
+func median(rates []float64) float64 {
+	if len(rates) == 0 {
+		return 0
+	}
+
+	sort.Float64s(rates)
+	mid := len(rates) / 2
+	if len(rates)%2 == 0 {
+		return (rates[mid-1] + rates[mid]) / 2
+	}
+	return rates[mid]
+}
+
+// joinErrors lists errors by name in name order.
+func joinErrors(errs map[string]error) string {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, errs[name])
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,100 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedFeeOracle struct {
+	rate float64
+	err  error
+}
+
+func (o fixedFeeOracle) EstimateFeeRate(ctx context.Context, confTarget int) (float64, error) {
+	return o.rate, o.err
+}
+
+func TestMempoolSpaceOracle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/fees/recommended" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{"fastestFee":40,"halfHourFee":30,"hourFee":20,"economyFee":8,"minimumFee":4}`)
+	}))
+	defer srv.Close()
+
+	oracle := NewMempoolSpaceOracle(srv.URL + "/api/")
+	for target, expected := range map[int]float64{1: 40, 2: 30, 6: 20, 144: 8} {
+		rate, err := oracle.EstimateFeeRate(context.Background(), target)
+		require.NoError(t, err)
+		assert.Equal(t, expected, rate, target)
+	}
+
+	_, err := NewMempoolSpaceOracle(srv.URL).EstimateFeeRate(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrSourceUnavailable)
+}
+
+func TestFeeAdvisor(t *testing.T) {
+	nodeRate := "0.00010000" // BTC/kvB, 10 sat/vB
+	logger := &countingLogger{}
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"result":{"feerate":%s,"blocks":2},"error":null,"id":1}`, nodeRate)
+	}, WithOptionalLogger(logger))
+	defer closeServer()
+
+	advisor := NewFeeAdvisor(b, map[string]FeeOracle{
+		"mempool.space": fixedFeeOracle{rate: 12},
+		"blockstream":   fixedFeeOracle{rate: 14},
+		"down":          fixedFeeOracle{err: errors.New("timeout")},
+	})
+
+	advice, err := advisor.Advise(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, advice.NodeRate)
+	assert.Equal(t, 10.0, advice.FeeRate)
+	assert.Equal(t, map[string]float64{"mempool.space": 12, "blockstream": 14}, advice.Estimates)
+	assert.EqualError(t, advice.Errors["down"], "timeout")
+	assert.InDelta(t, 1.3, advice.Divergence, 1e-9)
+	assert.False(t, advice.Divergent)
+	assert.Zero(t, logger.messages)
+
+	advisor.Policy = FeePolicyHighest
+	advice, err = advisor.Advise(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 14.0, advice.FeeRate)
+
+	advisor.Policy = FeePolicyMedian
+	advice, err = advisor.Advise(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 12.0, advice.FeeRate)
+
+	// The node's estimator lacking data after a restart
+	nodeRate = "0.00001000"
+	b.Storage.Flush()
+	advisor.Policy = FeePolicyExternalOnDivergence
+	advice, err = advisor.Advise(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, advice.Divergent)
+	assert.Equal(t, 13.0, advice.FeeRate)
+	assert.Equal(t, int64(1), logger.messages)
+
+	advisor.Policy = FeePolicyNode
+	advice, err = advisor.Advise(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, advice.FeeRate)
+
+	advisor.Sources = map[string]FeeOracle{"down": fixedFeeOracle{err: errors.New("timeout")}}
+	nodeRate = "null"
+	b.Storage.Flush()
+	_, err = advisor.Advise(context.Background(), 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrStaleRate is returned when the exchange rate of a RateProvider is older than the converter accepts.
+var ErrStaleRate = errors.New("exchange rate is stale")
+
+// ExchangeRate is the price of one bitcoin in a fiat currency.
+type ExchangeRate struct {
+	Currency string    // ISO 4217 code, e.g. USD
+	Rate     float64   // units of the currency per BTC
+	Time     time.Time // when the rate was quoted
+}
+
+// RateProvider quotes exchange rates, e.g. from an exchange's ticker or a rate service with its own cache.
+// The package ships no provider.
+type RateProvider interface {
+	Rate(ctx context.Context, currency string) (ExchangeRate, error)
+}
+
+// Rounding is how a FiatConverter rounds converted amounts to whole satoshis.
+type Rounding int
+
+const (
+	RoundNearest Rounding = iota
+	RoundUp               // in favor of the receiver, e.g. for invoices
+	RoundDown             // in favor of the sender, e.g. for payouts
+)
+
+// FiatConverter converts fiat amounts to satoshis at the rate of its provider when invoices are created or
+// payouts sent, refusing rates older than MaxAge.
+type FiatConverter struct {
+	Provider RateProvider
+	MaxAge   time.Duration // 0 accepts rates of any age
+	Rounding Rounding
+	// InvoiceValidity is how long the amount of a FiatInvoice holds at its rate.
+	InvoiceValidity time.Duration
+	// Clock is the source of time for rate ages and invoice expiry, the system clock if nil.
+	Clock Clock
+}
+
+// NewFiatConverter returns a converter accepting rates up to 5 minutes old, rounding to the nearest satoshi
+// and quoting invoices for 15 minutes.
+func NewFiatConverter(provider RateProvider) *FiatConverter {
+	return &FiatConverter{
+		Provider:        provider,
+		MaxAge:          5 * time.Minute,
+		Rounding:        RoundNearest,
+		InvoiceValidity: 15 * time.Minute,
+	}
+}
+
+// Rate returns the current rate of currency, or ErrStaleRate if the provider's rate is older than MaxAge.
+func (c *FiatConverter) Rate(ctx context.Context, currency string) (ExchangeRate, error) {
+	rate, err := c.Provider.Rate(ctx, currency)
+	if err != nil {
+		return ExchangeRate{}, fmt.Errorf("failed to get %s rate: %w", currency, err)
+	}
+
+	if !strings.EqualFold(rate.Currency, currency) {
+		return ExchangeRate{}, fmt.Errorf("provider returned a %s rate for %s", rate.Currency, currency)
+	}
+	if rate.Rate <= 0 || math.IsInf(rate.Rate, 0) || math.IsNaN(rate.Rate) {
+		return ExchangeRate{}, fmt.Errorf("invalid %s rate %v", currency, rate.Rate)
+	}
+
+	if age := clockOrSystem(c.Clock).Now().Sub(rate.Time); c.MaxAge > 0 && age > c.MaxAge {
+		return ExchangeRate{}, fmt.Errorf("%w: %s rate is %s old", ErrStaleRate, currency, age.Round(time.Second))
+	}
+
+	return rate, nil
+}
+
+// ToSatoshis converts amount of currency to satoshis at the current rate, returned with the result.
+func (c *FiatConverter) ToSatoshis(ctx context.Context, amount float64, currency string) (uint64, ExchangeRate, error) {
+	if amount < 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return 0, ExchangeRate{}, fmt.Errorf("invalid amount %v", amount)
+	}
+
+	rate, err := c.Rate(ctx, currency)
+	if err != nil {
+		return 0, ExchangeRate{}, err
+	}
+
+	return c.round(amount / rate.Rate * 1e8), rate, nil
+}
+
+func (c *FiatConverter) round(satoshis float64) uint64 {
+	switch c.Rounding {
+	case RoundUp:
+		return uint64(math.Ceil(satoshis))
+	case RoundDown:
+		return uint64(math.Floor(satoshis))
+	default:
+		return uint64(math.Round(satoshis))
+	}
+}
+
+// FiatInvoice is a request for a fiat amount, converted to satoshis when it was created. Satoshis is due
+// until Expires, after which a new invoice has to be created at the rate of then.
+type FiatInvoice struct {
+	Address  string
+	Amount   float64 // in Currency
+	Currency string
+	Satoshis uint64
+	Rate     ExchangeRate
+	Expires  time.Time
+}
+
+// Expired reports whether the invoice's amount no longer holds at time t.
+func (i *FiatInvoice) Expired(t time.Time) bool {
+	return !t.Before(i.Expires)
+}
+
+// Invoice creates an invoice for amount of currency paid to address, at the current rate.
+func (c *FiatConverter) Invoice(ctx context.Context, address string, amount float64, currency string) (*FiatInvoice, error) {
+	satoshis, rate, err := c.ToSatoshis(ctx, amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FiatInvoice{
+		Address:  address,
+		Amount:   amount,
+		Currency: currency,
+		Satoshis: satoshis,
+		Rate:     rate,
+		Expires:  clockOrSystem(c.Clock).Now().Add(c.InvoiceValidity),
+	}, nil
+}
+
+// Payout converts a payout of amount of currency at the current rate, for queueing with a PayoutBatcher
+// right away so that it is sent close to the rate it was converted at.
+func (c *FiatConverter) Payout(ctx context.Context, id, address string, amount float64, currency string) (Payout, ExchangeRate, error) {
+	satoshis, rate, err := c.ToSatoshis(ctx, amount, currency)
+	if err != nil {
+		return Payout{}, ExchangeRate{}, err
+	}
+
+	return Payout{ID: id, Address: address, Amount: float64(satoshis) / 1e8}, rate, nil
+}
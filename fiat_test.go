@@ -0,0 +1,66 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedRateProvider struct {
+	rate ExchangeRate
+	err  error
+}
+
+func (p *fixedRateProvider) Rate(ctx context.Context, currency string) (ExchangeRate, error) {
+	return p.rate, p.err
+}
+
+func TestFiatConverter(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	provider := &fixedRateProvider{rate: ExchangeRate{Currency: "USD", Rate: 30000, Time: clock.Now()}}
+
+	c := NewFiatConverter(provider)
+	c.Clock = clock
+	ctx := context.Background()
+
+	// 10 USD at 30000 USD/BTC are 33333.33 satoshis
+	satoshis, rate, err := c.ToSatoshis(ctx, 10, "usd")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(33333), satoshis)
+	assert.Equal(t, 30000.0, rate.Rate)
+
+	c.Rounding = RoundUp
+	invoice, err := c.Invoice(ctx, "bc1qaddress", 10, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(33334), invoice.Satoshis)
+	assert.Equal(t, clock.Now().Add(15*time.Minute), invoice.Expires)
+	assert.False(t, invoice.Expired(clock.Now()))
+	assert.True(t, invoice.Expired(clock.Now().Add(15*time.Minute)))
+
+	c.Rounding = RoundDown
+	payout, _, err := c.Payout(ctx, "p1", "bc1qaddress", 20, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, Payout{ID: "p1", Address: "bc1qaddress", Amount: 0.00066666}, payout)
+
+	clock.Advance(6 * time.Minute)
+	_, _, err = c.ToSatoshis(ctx, 10, "USD")
+	assert.ErrorIs(t, err, ErrStaleRate)
+
+	c.MaxAge = 0
+	_, _, err = c.ToSatoshis(ctx, 10, "USD")
+	assert.NoError(t, err)
+
+	_, _, err = c.ToSatoshis(ctx, 10, "EUR")
+	assert.EqualError(t, err, "provider returned a USD rate for EUR")
+
+	_, _, err = c.ToSatoshis(ctx, -1, "USD")
+	assert.Error(t, err)
+
+	provider.err = errors.New("ticker down")
+	_, _, err = c.ToSatoshis(ctx, 10, "USD")
+	assert.EqualError(t, err, "failed to get USD rate: ticker down")
+}
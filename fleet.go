@@ -0,0 +1,277 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kinds of divergence reported by a Fleet.
+const (
+	DivergenceUnreachable = "unreachable"
+	DivergenceFork        = "fork"
+	DivergenceLagging     = "lagging"
+	DivergenceStalled     = "stalled"
+	DivergenceMempool     = "mempool"
+	DivergencePeers       = "peers"
+	DivergenceVersion     = "version"
+)
+
+// NodeStatus is a snapshot of the state of one node in a Fleet.
+type NodeStatus struct {
+	Name          string    `json:"name"`
+	Height        int32     `json:"height"`
+	BestBlockHash string    `json:"bestblockhash"`
+	MempoolSize   int       `json:"mempoolsize"`
+	Connections   int       `json:"connections"`
+	Version       int       `json:"version"`
+	SubVersion    string    `json:"subversion"`
+	Time          time.Time `json:"time"`
+	Err           error     `json:"-"`
+}
+
+// Divergence describes a difference between nodes of a Fleet.
+type Divergence struct {
+	Kind   string   `json:"kind"`
+	Nodes  []string `json:"nodes"`
+	Detail string   `json:"detail"`
+}
+
+// Fleet periodically compares several nodes and reports when they drift apart, catching a forked or
+// stalled node early.
+type Fleet struct {
+	// MaxHeightLag is the number of blocks a node may be behind the highest node before it is reported as lagging.
+	MaxHeightLag int32
+	// MaxMempoolDiff is the relative difference in mempool size (0.2 = 20%) tolerated between a node and the largest mempool.
+	MaxMempoolDiff float64
+	// StallTimeout is how long the tip of a node may stay unchanged while another node advances.
+	StallTimeout time.Duration
+	// MinConnections is the number of peers below which a node is reported, as it is close to losing touch
+	// with the network.
+	MinConnections int
+	// OnStatus is called with the status of all nodes after every check.
+	OnStatus func([]NodeStatus)
+	// OnDivergence is called for every divergence found.
+	OnDivergence func(Divergence)
+	// Clock is used for the interval of Run and the stall timeout, nil means the system clock.
+	Clock Clock
+
+	names []string
+	nodes map[string]*Bitcoind
+
+	mu         sync.Mutex
+	lastChange map[string]time.Time
+	lastTip    map[string]string
+}
+
+// NewFleet returns a Fleet comparing the given named nodes.
+func NewFleet(nodes map[string]*Bitcoind) *Fleet {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return &Fleet{
+		MaxHeightLag:   2,
+		MaxMempoolDiff: 0.2,
+		StallTimeout:   30 * time.Minute,
+		MinConnections: 4,
+		names:          names,
+		nodes:          nodes,
+		lastChange:     make(map[string]time.Time),
+		lastTip:        make(map[string]string),
+	}
+}
+
+// Run checks the fleet every interval until the context is done.
+func (f *Fleet) Run(ctx context.Context, interval time.Duration) {
+	ticker := clockOrSystem(f.Clock).NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		f.Check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// Check queries all nodes once, reports the results to the callbacks and returns them.
+func (f *Fleet) Check() ([]NodeStatus, []Divergence) {
+	statuses := make([]NodeStatus, len(f.names))
+
+	var wg sync.WaitGroup
+	for i, name := range f.names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			statuses[i] = nodeStatus(name, f.nodes[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	divergences := f.compare(statuses)
+
+	if f.OnStatus != nil {
+		f.OnStatus(statuses)
+	}
+	if f.OnDivergence != nil {
+		for _, d := range divergences {
+			f.OnDivergence(d)
+		}
+	}
+
+	return statuses, divergences
+}
+
+// nodeStatus reads the state of a node uncached, a check must not compare the state of an earlier one.
+func nodeStatus(name string, b *Bitcoind) NodeStatus {
+	s := NodeStatus{Name: name, Time: b.client.clock.Now()}
+
+	var info BlockchainInfo
+	if err := b.uncachedCall("getblockchaininfo", nil, &info); err != nil {
+		s.Err = err
+		return s
+	}
+	s.Height = info.Blocks
+	s.BestBlockHash = info.BestBlockHash
+
+	var mempool MempoolInfo
+	if err := b.uncachedCall("getmempoolinfo", nil, &mempool); err != nil {
+		s.Err = err
+		return s
+	}
+	s.MempoolSize = mempool.Size
+
+	var network NetworkInfo
+	if err := b.uncachedCall("getnetworkinfo", nil, &network); err != nil {
+		s.Err = err
+		return s
+	}
+	s.Connections = network.Connections
+	s.Version = network.Version
+	s.SubVersion = network.SubVersion
+
+	return s
+}
+
+func (f *Fleet) compare(statuses []NodeStatus) []Divergence {
+	var divergences []Divergence
+
+	var live []NodeStatus
+	for _, s := range statuses {
+		if s.Err != nil {
+			divergences = append(divergences, Divergence{
+				Kind:   DivergenceUnreachable,
+				Nodes:  []string{s.Name},
+				Detail: s.Err.Error(),
+			})
+			continue
+		}
+		live = append(live, s)
+	}
+
+	if len(live) == 0 {
+		return divergences
+	}
+
+	var maxHeight int32
+	maxMempool := 0
+	for _, s := range live {
+		if s.Height > maxHeight {
+			maxHeight = s.Height
+		}
+		if s.MempoolSize > maxMempool {
+			maxMempool = s.MempoolSize
+		}
+	}
+
+	// Nodes at the same height must agree on the tip
+	tips := make(map[int32]map[string][]string)
+	for _, s := range live {
+		if tips[s.Height] == nil {
+			tips[s.Height] = make(map[string][]string)
+		}
+		tips[s.Height][s.BestBlockHash] = append(tips[s.Height][s.BestBlockHash], s.Name)
+	}
+	for height, hashes := range tips {
+		if len(hashes) > 1 {
+			var nodes []string
+			for _, names := range hashes {
+				nodes = append(nodes, names...)
+			}
+			sort.Strings(nodes)
+			divergences = append(divergences, Divergence{
+				Kind:   DivergenceFork,
+				Nodes:  nodes,
+				Detail: fmt.Sprintf("%d different tips at height %d", len(hashes), height),
+			})
+		}
+	}
+
+	versions := make(map[string][]string)
+	now := clockOrSystem(f.Clock).Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, s := range live {
+		if maxHeight-s.Height > f.MaxHeightLag {
+			divergences = append(divergences, Divergence{
+				Kind:   DivergenceLagging,
+				Nodes:  []string{s.Name},
+				Detail: fmt.Sprintf("height %d is %d blocks behind %d", s.Height, maxHeight-s.Height, maxHeight),
+			})
+		}
+
+		if f.lastTip[s.Name] != s.BestBlockHash {
+			f.lastTip[s.Name] = s.BestBlockHash
+			f.lastChange[s.Name] = now
+		} else if s.Height < maxHeight && f.StallTimeout > 0 && now.Sub(f.lastChange[s.Name]) > f.StallTimeout {
+			divergences = append(divergences, Divergence{
+				Kind:   DivergenceStalled,
+				Nodes:  []string{s.Name},
+				Detail: fmt.Sprintf("tip %s unchanged since %s", s.BestBlockHash, f.lastChange[s.Name].Format(time.RFC3339)),
+			})
+		}
+
+		if maxMempool > 0 && float64(maxMempool-s.MempoolSize)/float64(maxMempool) > f.MaxMempoolDiff {
+			divergences = append(divergences, Divergence{
+				Kind:   DivergenceMempool,
+				Nodes:  []string{s.Name},
+				Detail: fmt.Sprintf("mempool has %d transactions, largest has %d", s.MempoolSize, maxMempool),
+			})
+		}
+
+		if s.Connections < f.MinConnections {
+			divergences = append(divergences, Divergence{
+				Kind:   DivergencePeers,
+				Nodes:  []string{s.Name},
+				Detail: fmt.Sprintf("%d connections, fewer than %d", s.Connections, f.MinConnections),
+			})
+		}
+
+		versions[s.SubVersion] = append(versions[s.SubVersion], s.Name)
+	}
+
+	if len(versions) > 1 {
+		var detail []string
+		for v, names := range versions {
+			detail = append(detail, fmt.Sprintf("%s: %v", v, names))
+		}
+		sort.Strings(detail)
+		divergences = append(divergences, Divergence{
+			Kind:   DivergenceVersion,
+			Nodes:  f.names,
+			Detail: fmt.Sprintf("%v", detail),
+		})
+	}
+
+	return divergences
+}
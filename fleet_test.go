@@ -0,0 +1,98 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFleetCompare(t *testing.T) {
+	f := NewFleet(map[string]*Bitcoind{"a": nil, "b": nil, "c": nil})
+
+	statuses := []NodeStatus{
+		{Name: "a", Height: 100, BestBlockHash: "aa", MempoolSize: 1000, Connections: 8, SubVersion: "/Satoshi:27.0.0/"},
+		{Name: "b", Height: 100, BestBlockHash: "bb", MempoolSize: 950, Connections: 8, SubVersion: "/Satoshi:27.0.0/"},
+		{Name: "c", Height: 90, BestBlockHash: "cc", MempoolSize: 100, Connections: 8, SubVersion: "/Satoshi:27.0.0/"},
+	}
+
+	kinds := make(map[string]int)
+	for _, d := range f.compare(statuses) {
+		kinds[d.Kind]++
+	}
+
+	if kinds[DivergenceFork] != 1 || kinds[DivergenceLagging] != 1 || kinds[DivergenceMempool] != 1 || kinds[DivergencePeers] != 0 {
+		t.Errorf("Unexpected divergences %v", kinds)
+	}
+	if kinds[DivergenceVersion] != 0 {
+		t.Errorf("Expected no version divergence, got %v", kinds)
+	}
+}
+
+// fleetNode serves the status of a node whose tip is at the height returned by height.
+func fleetNode(height func() int32, connections int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "getblockchaininfo":
+			h := height()
+			fmt.Fprintf(w, `{"result":{"blocks":%d,"bestblockhash":"%064x"},"error":null,"id":1}`, h, h)
+		case "getmempoolinfo":
+			fmt.Fprint(w, `{"result":{"size":1000},"error":null,"id":1}`)
+		case "getnetworkinfo":
+			fmt.Fprintf(w, `{"result":{"version":270000,"subversion":"/Satoshi:27.0.0/","connections":%d},"error":null,"id":1}`, connections)
+		}
+	}
+}
+
+func TestFleetCheck(t *testing.T) {
+	var tip int32 = 100
+	height := func() int32 { return atomic.LoadInt32(&tip) }
+	frozen := func() int32 { return 100 }
+
+	a, doneA := newTestNode(t, fleetNode(height, 10))
+	defer doneA()
+	stalled, doneStalled := newTestNode(t, fleetNode(frozen, 10))
+	defer doneStalled()
+	isolated, doneIsolated := newTestNode(t, fleetNode(height, 1))
+	defer doneIsolated()
+
+	clock := NewFakeClock(time.Unix(1600000000, 0))
+	f := NewFleet(map[string]*Bitcoind{"a": a, "stalled": stalled, "isolated": isolated})
+	f.Clock = clock
+
+	var reported []Divergence
+	f.OnDivergence = func(d Divergence) { reported = append(reported, d) }
+
+	kinds := func(divergences []Divergence) map[string][]string {
+		res := make(map[string][]string)
+		for _, d := range divergences {
+			res[d.Kind] = append(res[d.Kind], d.Nodes...)
+		}
+		return res
+	}
+
+	statuses, divergences := f.Check()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "isolated", statuses[1].Name)
+	assert.Equal(t, 1, statuses[1].Connections)
+	assert.Equal(t, map[string][]string{DivergencePeers: {"isolated"}}, kinds(divergences))
+
+	// The other nodes advance while the tip of one stays put for longer than the stall timeout
+	atomic.StoreInt32(&tip, 101)
+	clock.Advance(31 * time.Minute)
+
+	statuses, divergences = f.Check()
+	assert.Equal(t, int32(101), statuses[0].Height)
+	assert.Equal(t, int32(100), statuses[2].Height)
+	assert.Equal(t, map[string][]string{DivergencePeers: {"isolated"}, DivergenceStalled: {"stalled"}}, kinds(divergences))
+
+	assert.Equal(t, map[string][]string{DivergencePeers: {"isolated", "isolated"}, DivergenceStalled: {"stalled"}}, kinds(reported))
+}
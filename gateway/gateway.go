@@ -0,0 +1,340 @@
+// Package gateway serves read-only REST endpoints backed by a node, for use as the backend of a block
+// explorer or other public API:
+//
+//	GET /block/{hash}            block with txids, as returned by getblock
+//	GET /block-height/{height}   block of the active chain at the given height
+//	GET /tx/{txid}               decoded transaction, as returned by getrawtransaction
+//	GET /address/{address}/utxo  unspent outputs of an address watched by the configured UTXOMirror
+//	GET /fee-estimates           fee rates in sat/vB by confirmation target
+//
+// Responses are cached and requests are rate limited per client IP, so that the node is shielded from
+// bursts of identical or abusive requests. Errors are returned as {"error": "..."}.
+package gateway
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+// Config configures a Server. The zero value serves every endpoint but the address one, caches responses
+// for 10 seconds and does not rate limit.
+type Config struct {
+	Mirror     *bitcoin.UTXOMirror // serves /address/{address}/utxo, which answers 501 without it
+	FeeTargets []int               // confirmation targets of /fee-estimates, 1, 2, 3, 6, 12, 24, 144 and 1008 by default
+	CacheTTL   time.Duration       // how long responses are cached, 10 seconds by default
+	RateLimit  float64             // requests per second per client IP, 0 disables rate limiting
+	Burst      int                 // requests a client may make at once, RateLimit rounded up by default
+	Clock      bitcoin.Clock       // used for rate limiting, the system clock by default
+}
+
+// Server is an http.Handler serving the gateway endpoints.
+type Server struct {
+	b       *bitcoin.Bitcoind
+	cfg     Config
+	cache   *cache.Cache
+	limiter *limiter
+}
+
+var defaultFeeTargets = []int{1, 2, 3, 6, 12, 24, 144, 1008}
+
+// New returns a Server using the given client.
+func New(b *bitcoin.Bitcoind, cfg Config) *Server {
+	if len(cfg.FeeTargets) == 0 {
+		cfg.FeeTargets = defaultFeeTargets
+	}
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = 10 * time.Second
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = bitcoin.SystemClock
+	}
+
+	s := &Server{
+		b:     b,
+		cfg:   cfg,
+		cache: cache.New(cfg.CacheTTL, 2*cfg.CacheTTL),
+	}
+
+	if cfg.RateLimit > 0 {
+		burst := cfg.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(cfg.RateLimit))
+		}
+		s.limiter = &limiter{rate: cfg.RateLimit, burst: float64(burst), clock: cfg.Clock, buckets: make(map[string]*bucket)}
+	}
+
+	return s
+}
+
+// errorStatus carries the HTTP status of a failed request.
+type errorStatus struct {
+	status int
+	err    error
+}
+
+func (e *errorStatus) Error() string { return e.err.Error() }
+
+func withStatus(status int, format string, args ...interface{}) error {
+	return &errorStatus{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, withStatus(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		return
+	}
+
+	if s.limiter != nil {
+		if wait, ok := s.limiter.allow(clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, withStatus(http.StatusTooManyRequests, "rate limit exceeded"))
+			return
+		}
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+
+	if data, found := s.cache.Get(path); found {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, data.([]byte))
+		return
+	}
+
+	v, err := s.route(strings.Split(path, "/"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.cache.SetDefault(path, data)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, data)
+}
+
+func (s *Server) route(parts []string) (interface{}, error) {
+	switch {
+	case len(parts) == 2 && parts[0] == "block":
+		return s.block(parts[1])
+
+	case len(parts) == 2 && parts[0] == "block-height":
+		height, err := strconv.Atoi(parts[1])
+		if err != nil || height < 0 {
+			return nil, withStatus(http.StatusBadRequest, "invalid height %q", parts[1])
+		}
+
+		hash, err := s.b.GetBlockHash(height)
+		if err != nil {
+			return nil, nodeError(err)
+		}
+
+		return s.block(hash)
+
+	case len(parts) == 2 && parts[0] == "tx":
+		if !isHash(parts[1]) {
+			return nil, withStatus(http.StatusBadRequest, "invalid txid %q", parts[1])
+		}
+
+		tx, err := s.b.GetRawTransaction(parts[1])
+		if err != nil {
+			return nil, nodeError(err)
+		}
+
+		return tx, nil
+
+	case len(parts) == 3 && parts[0] == "address" && parts[2] == "utxo":
+		return s.addressUTXOs(parts[1])
+
+	case len(parts) == 1 && parts[0] == "fee-estimates":
+		return s.feeEstimates()
+	}
+
+	return nil, withStatus(http.StatusNotFound, "unknown endpoint")
+}
+
+func (s *Server) block(hash string) (interface{}, error) {
+	if !isHash(hash) {
+		return nil, withStatus(http.StatusBadRequest, "invalid block hash %q", hash)
+	}
+
+	block, err := s.b.GetBlock(hash)
+	if err != nil {
+		return nil, nodeError(err)
+	}
+
+	return block, nil
+}
+
+// UTXO is an unspent output returned by /address/{address}/utxo.
+type UTXO struct {
+	TxID      string `json:"txid"`
+	Vout      uint32 `json:"vout"`
+	Value     uint64 `json:"value"` // satoshis
+	Confirmed bool   `json:"confirmed"`
+	BlockHash string `json:"blockhash,omitempty"`
+}
+
+func (s *Server) addressUTXOs(address string) (interface{}, error) {
+	if s.cfg.Mirror == nil {
+		return nil, withStatus(http.StatusNotImplemented, "address index not configured")
+	}
+
+	addr, err := s.b.ValidateAddress(address)
+	if err != nil {
+		return nil, nodeError(err)
+	}
+	if !addr.IsValid {
+		return nil, withStatus(http.StatusBadRequest, "invalid address %q", address)
+	}
+	if !s.cfg.Mirror.Watches(addr.ScriptPubKey) {
+		return nil, withStatus(http.StatusNotFound, "address %s is not indexed", address)
+	}
+
+	utxos := []UTXO{}
+	for _, u := range s.cfg.Mirror.UTXOs(addr.ScriptPubKey) {
+		utxos = append(utxos, UTXO{
+			TxID:      u.TxID,
+			Vout:      u.Vout,
+			Value:     u.Value,
+			Confirmed: u.BlockHash != "",
+			BlockHash: u.BlockHash,
+		})
+	}
+
+	return utxos, nil
+}
+
+func (s *Server) feeEstimates() (interface{}, error) {
+	estimates := make(map[string]float64, len(s.cfg.FeeTargets))
+
+	for _, target := range s.cfg.FeeTargets {
+		estimate, err := s.b.EstimateSmartFee(target, "")
+		if err != nil {
+			return nil, nodeError(err)
+		}
+
+		// Targets without an estimate are left out, as the node does until it has seen enough blocks
+		if estimate.FeeRate > 0 {
+			estimates[strconv.Itoa(target)] = estimate.FeeRate * 1e5 // BTC/kvB to sat/vB
+		}
+	}
+
+	return estimates, nil
+}
+
+// nodeError maps an error of the node to the status returned to the client. Details of other errors are
+// not passed on, they may reveal the node's configuration.
+func nodeError(err error) error {
+	msg := err.Error()
+
+	switch {
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "No such mempool"), strings.Contains(msg, "out of range"),
+		bitcoin.HasErrorCode(err, bitcoin.RPCInvalidAddressOrKey), bitcoin.HasErrorCode(err, bitcoin.RPCInvalidParameter):
+		return withStatus(http.StatusNotFound, "not found")
+	case strings.Contains(msg, "failed to do request"), bitcoin.IsRetryable(err):
+		return withStatus(http.StatusServiceUnavailable, "node unavailable")
+	}
+
+	return withStatus(http.StatusBadGateway, "node error")
+}
+
+func writeJSON(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var es *errorStatus
+	if errors.As(err, &es) {
+		status = es.status
+	}
+
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func isHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limiter is a token bucket rate limiter per client.
+type limiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	clock   bitcoin.Clock
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// allow takes a token from the client's bucket, or returns how long until one is available.
+func (l *limiter) allow(client string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+
+	b, found := l.buckets[client]
+	if !found {
+		// Clients whose buckets are full again are forgotten, keeping the map bounded by the active ones
+		for c, other := range l.buckets {
+			if other.tokens+now.Sub(other.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, c)
+			}
+		}
+
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+	}
+
+	b.tokens--
+	return 0, true
+}
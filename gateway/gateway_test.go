@@ -0,0 +1,173 @@
+package gateway
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+	"github.com/shuber/go-bitcoin/testvectors"
+)
+
+func testNode(t *testing.T, chain *testvectors.Chain, calls *int32) *bitcoin.Bitcoind {
+	block := chain.Blocks[0]
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		atomic.AddInt32(calls, 1)
+
+		reply := func(v interface{}) {
+			result, _ := json.Marshal(v)
+			_, _ = w.Write([]byte(`{"result":` + string(result) + `,"error":null,"id":1}`))
+		}
+
+		switch req.Method {
+		case "getblockhash":
+			if string(req.Params[0]) != strconv.Itoa(block.Height) {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"result":null,"error":{"code":-8,"message":"Block height out of range"},"id":1}`))
+				return
+			}
+			reply(block.Hash)
+		case "getblock":
+			if string(req.Params[0]) != `"`+block.Hash+`"` {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"result":null,"error":{"code":-5,"message":"Block not found"},"id":1}`))
+				return
+			}
+			reply(map[string]interface{}{"hash": block.Hash, "height": block.Height, "tx": []string{block.Tx[0].TxID}})
+		case "validateaddress":
+			var address string
+			_ = json.Unmarshal(req.Params[0], &address)
+			reply(map[string]interface{}{"isvalid": address == chain.Address, "address": address, "scriptPubKey": chain.ScriptPubKey})
+		case "estimatesmartfee":
+			if string(req.Params[0]) == "1" {
+				reply(map[string]interface{}{"errors": []string{"Insufficient data or no feerate found"}, "blocks": 2})
+				return
+			}
+			reply(map[string]interface{}{"feerate": 0.0001, "blocks": 6})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":1}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	b, err := bitcoin.New(u.Hostname(), port, "", "user", "pass", false)
+	require.NoError(t, err)
+
+	return b
+}
+
+func get(s http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestServer(t *testing.T) {
+	chain, err := testvectors.Generate("", 1)
+	require.NoError(t, err)
+
+	raw, _ := hex.DecodeString(chain.Blocks[0].Hex)
+	mirror := bitcoin.NewUTXOMirror(6)
+	mirror.Watch(chain.ScriptPubKey)
+	require.NoError(t, mirror.ApplyBlock(raw))
+
+	var calls int32
+	s := New(testNode(t, chain, &calls), Config{Mirror: mirror, FeeTargets: []int{1, 6}})
+
+	w := get(s, "/block-height/1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), chain.Blocks[0].Hash)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+
+	before := atomic.LoadInt32(&calls)
+	w = get(s, "/block-height/1")
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, before, atomic.LoadInt32(&calls))
+
+	w = get(s, "/block/"+chain.Blocks[0].Hash)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = get(s, "/address/"+chain.Address+"/utxo")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var utxos []UTXO
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &utxos))
+	require.Len(t, utxos, 1)
+	assert.Equal(t, chain.Blocks[0].Tx[0].TxID, utxos[0].TxID)
+	assert.Equal(t, uint64(50e8), utxos[0].Value)
+	assert.True(t, utxos[0].Confirmed)
+
+	w = get(s, "/fee-estimates")
+	assert.JSONEq(t, `{"6":10}`, w.Body.String())
+}
+
+func TestServerErrors(t *testing.T) {
+	chain, err := testvectors.Generate("", 1)
+	require.NoError(t, err)
+
+	var calls int32
+	s := New(testNode(t, chain, &calls), Config{})
+
+	for path, status := range map[string]int{
+		"/block/xyz":                        http.StatusBadRequest,
+		"/block/" + testvectors.GenesisHash: http.StatusNotFound,
+		"/block-height/-1":                  http.StatusBadRequest,
+		"/block-height/7":                   http.StatusNotFound,
+		"/tx/123":                           http.StatusBadRequest,
+		"/address/x/utxo":                   http.StatusNotImplemented,
+		"/unknown":                          http.StatusNotFound,
+	} {
+		w := get(s, path)
+		assert.Equal(t, status, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"error"`, path)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/fee-estimates", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServerRateLimit(t *testing.T) {
+	chain, err := testvectors.Generate("", 1)
+	require.NoError(t, err)
+
+	var calls int32
+	clock := bitcoin.NewFakeClock(time.Unix(1700000000, 0))
+	s := New(testNode(t, chain, &calls), Config{RateLimit: 1, Burst: 2, Clock: clock})
+
+	assert.Equal(t, http.StatusOK, get(s, "/fee-estimates").Code)
+	assert.Equal(t, http.StatusOK, get(s, "/fee-estimates").Code)
+
+	w := get(s, "/fee-estimates")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Other clients have their own bucket
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/fee-estimates", nil)
+	r.RemoteAddr = "192.0.2.2:1234"
+	s.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, http.StatusOK, get(s, "/fee-estimates").Code)
+	assert.Equal(t, http.StatusTooManyRequests, get(s, "/fee-estimates").Code)
+}
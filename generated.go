@@ -0,0 +1,44 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// The wrappers of the methods without a hand written one are generated by cmd/rpcgen, from the help output
+// of a node dumped into api.json.
+//go:generate go run ./cmd/rpcgen -api api.json -out rpc_generated.go
+
+// callGenerated calls method for the wrappers generated by rpcgen and decodes the result into v, unless v is
+// nil. Trailing null params, optional arguments that were not set, are dropped so that the node applies its
+// defaults.
+func (b *Bitcoind) callGenerated(ctx context.Context, method string, params []interface{}, v interface{}) error {
+	for len(params) > 0 && isNull(params[len(params)-1]) {
+		params = params[:len(params)-1]
+	}
+
+	result, err := b.RawCallContext(ctx, method, params...)
+	if err != nil {
+		return err
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(result, v)
+}
+
+// isNull reports whether v is encoded as null.
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
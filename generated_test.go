@@ -0,0 +1,35 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallGenerated(t *testing.T) {
+	var params []json.RawMessage
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+		_, _ = w.Write([]byte(`{"result":"bcrt1qchange","error":null,"id":1}`))
+	})
+	defer closeServer()
+
+	// Optional arguments that are not set are dropped from the end, and sent as null before a set one
+	var unset *string
+	minConf := int64(1)
+	var address string
+	require.NoError(t, b.callGenerated(context.Background(), "getrawchangeaddress", []interface{}{"label", unset, &minConf, unset, nil}, &address))
+	assert.Equal(t, "bcrt1qchange", address)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`"label"`), json.RawMessage(`null`), json.RawMessage(`1`)}, params)
+
+	require.NoError(t, b.callGenerated(context.Background(), "walletlock", []interface{}{}, nil))
+	assert.Empty(t, params)
+}
@@ -0,0 +1,894 @@
+// Curated, read mostly view of a node, served by the grpcserver package on top of nodeservice.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: bitcoin.proto
+
+package bitcoinpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Event_Kind int32
+
+const (
+	Event_KIND_UNSPECIFIED Event_Kind = 0
+	Event_KIND_BLOCK       Event_Kind = 1
+	Event_KIND_TRANSACTION Event_Kind = 2
+)
+
+// Enum value maps for Event_Kind.
+var (
+	Event_Kind_name = map[int32]string{
+		0: "KIND_UNSPECIFIED",
+		1: "KIND_BLOCK",
+		2: "KIND_TRANSACTION",
+	}
+	Event_Kind_value = map[string]int32{
+		"KIND_UNSPECIFIED": 0,
+		"KIND_BLOCK":       1,
+		"KIND_TRANSACTION": 2,
+	}
+)
+
+func (x Event_Kind) Enum() *Event_Kind {
+	p := new(Event_Kind)
+	*p = x
+	return p
+}
+
+func (x Event_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Event_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_bitcoin_proto_enumTypes[0].Descriptor()
+}
+
+func (Event_Kind) Type() protoreflect.EnumType {
+	return &file_bitcoin_proto_enumTypes[0]
+}
+
+func (x Event_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Event_Kind.Descriptor instead.
+func (Event_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type GetChainInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChainInfoRequest) Reset() {
+	*x = GetChainInfoRequest{}
+	mi := &file_bitcoin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChainInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChainInfoRequest) ProtoMessage() {}
+
+func (x *GetChainInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChainInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetChainInfoRequest) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{0}
+}
+
+type ChainInfo struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Chain                string                 `protobuf:"bytes,1,opt,name=chain,proto3" json:"chain,omitempty"`
+	Blocks               int64                  `protobuf:"varint,2,opt,name=blocks,proto3" json:"blocks,omitempty"`
+	Headers              int64                  `protobuf:"varint,3,opt,name=headers,proto3" json:"headers,omitempty"`
+	BestBlockHash        string                 `protobuf:"bytes,4,opt,name=best_block_hash,json=bestBlockHash,proto3" json:"best_block_hash,omitempty"`
+	MedianTime           int64                  `protobuf:"varint,5,opt,name=median_time,json=medianTime,proto3" json:"median_time,omitempty"`
+	VerificationProgress float64                `protobuf:"fixed64,6,opt,name=verification_progress,json=verificationProgress,proto3" json:"verification_progress,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ChainInfo) Reset() {
+	*x = ChainInfo{}
+	mi := &file_bitcoin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChainInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainInfo) ProtoMessage() {}
+
+func (x *ChainInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainInfo.ProtoReflect.Descriptor instead.
+func (*ChainInfo) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChainInfo) GetChain() string {
+	if x != nil {
+		return x.Chain
+	}
+	return ""
+}
+
+func (x *ChainInfo) GetBlocks() int64 {
+	if x != nil {
+		return x.Blocks
+	}
+	return 0
+}
+
+func (x *ChainInfo) GetHeaders() int64 {
+	if x != nil {
+		return x.Headers
+	}
+	return 0
+}
+
+func (x *ChainInfo) GetBestBlockHash() string {
+	if x != nil {
+		return x.BestBlockHash
+	}
+	return ""
+}
+
+func (x *ChainInfo) GetMedianTime() int64 {
+	if x != nil {
+		return x.MedianTime
+	}
+	return 0
+}
+
+func (x *ChainInfo) GetVerificationProgress() float64 {
+	if x != nil {
+		return x.VerificationProgress
+	}
+	return 0
+}
+
+type GetBlockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Height        int64                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"` // used when hash is empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBlockRequest) Reset() {
+	*x = GetBlockRequest{}
+	mi := &file_bitcoin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBlockRequest) ProtoMessage() {}
+
+func (x *GetBlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBlockRequest.ProtoReflect.Descriptor instead.
+func (*GetBlockRequest) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetBlockRequest) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *GetBlockRequest) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type Block struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Hash              string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Height            int64                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	PreviousBlockHash string                 `protobuf:"bytes,3,opt,name=previous_block_hash,json=previousBlockHash,proto3" json:"previous_block_hash,omitempty"`
+	Time              int64                  `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+	MedianTime        int64                  `protobuf:"varint,5,opt,name=median_time,json=medianTime,proto3" json:"median_time,omitempty"`
+	MerkleRoot        string                 `protobuf:"bytes,6,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	Confirmations     int64                  `protobuf:"varint,7,opt,name=confirmations,proto3" json:"confirmations,omitempty"`
+	Txids             []string               `protobuf:"bytes,8,rep,name=txids,proto3" json:"txids,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	mi := &file_bitcoin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Block) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Block) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Block) GetPreviousBlockHash() string {
+	if x != nil {
+		return x.PreviousBlockHash
+	}
+	return ""
+}
+
+func (x *Block) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Block) GetMedianTime() int64 {
+	if x != nil {
+		return x.MedianTime
+	}
+	return 0
+}
+
+func (x *Block) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+func (x *Block) GetConfirmations() int64 {
+	if x != nil {
+		return x.Confirmations
+	}
+	return 0
+}
+
+func (x *Block) GetTxids() []string {
+	if x != nil {
+		return x.Txids
+	}
+	return nil
+}
+
+type GetTransactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          string                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTransactionRequest) Reset() {
+	*x = GetTransactionRequest{}
+	mi := &file_bitcoin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionRequest) ProtoMessage() {}
+
+func (x *GetTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionRequest.ProtoReflect.Descriptor instead.
+func (*GetTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetTransactionRequest) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          string                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Hex           string                 `protobuf:"bytes,2,opt,name=hex,proto3" json:"hex,omitempty"`
+	BlockHash     string                 `protobuf:"bytes,3,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"` // empty while unconfirmed
+	Confirmations int64                  `protobuf:"varint,4,opt,name=confirmations,proto3" json:"confirmations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_bitcoin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Transaction) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+func (x *Transaction) GetHex() string {
+	if x != nil {
+		return x.Hex
+	}
+	return ""
+}
+
+func (x *Transaction) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *Transaction) GetConfirmations() int64 {
+	if x != nil {
+		return x.Confirmations
+	}
+	return 0
+}
+
+type EstimateFeeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConfTarget    int32                  `protobuf:"varint,1,opt,name=conf_target,json=confTarget,proto3" json:"conf_target,omitempty"` // 1 to 1008 blocks
+	Mode          string                 `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`                                // "economical" (default) or "conservative"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateFeeRequest) Reset() {
+	*x = EstimateFeeRequest{}
+	mi := &file_bitcoin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateFeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateFeeRequest) ProtoMessage() {}
+
+func (x *EstimateFeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateFeeRequest.ProtoReflect.Descriptor instead.
+func (*EstimateFeeRequest) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EstimateFeeRequest) GetConfTarget() int32 {
+	if x != nil {
+		return x.ConfTarget
+	}
+	return 0
+}
+
+func (x *EstimateFeeRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type FeeEstimate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SatPerVbyte   float64                `protobuf:"fixed64,1,opt,name=sat_per_vbyte,json=satPerVbyte,proto3" json:"sat_per_vbyte,omitempty"`
+	Blocks        int32                  `protobuf:"varint,2,opt,name=blocks,proto3" json:"blocks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeeEstimate) Reset() {
+	*x = FeeEstimate{}
+	mi := &file_bitcoin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeeEstimate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeeEstimate) ProtoMessage() {}
+
+func (x *FeeEstimate) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeeEstimate.ProtoReflect.Descriptor instead.
+func (*FeeEstimate) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FeeEstimate) GetSatPerVbyte() float64 {
+	if x != nil {
+		return x.SatPerVbyte
+	}
+	return 0
+}
+
+func (x *FeeEstimate) GetBlocks() int32 {
+	if x != nil {
+		return x.Blocks
+	}
+	return 0
+}
+
+type BroadcastRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hex           string                 `protobuf:"bytes,1,opt,name=hex,proto3" json:"hex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BroadcastRequest) Reset() {
+	*x = BroadcastRequest{}
+	mi := &file_bitcoin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BroadcastRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastRequest) ProtoMessage() {}
+
+func (x *BroadcastRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastRequest.ProtoReflect.Descriptor instead.
+func (*BroadcastRequest) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BroadcastRequest) GetHex() string {
+	if x != nil {
+		return x.Hex
+	}
+	return ""
+}
+
+type BroadcastResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          string                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BroadcastResponse) Reset() {
+	*x = BroadcastResponse{}
+	mi := &file_bitcoin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BroadcastResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastResponse) ProtoMessage() {}
+
+func (x *BroadcastResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastResponse.ProtoReflect.Descriptor instead.
+func (*BroadcastResponse) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BroadcastResponse) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Blocks        bool                   `protobuf:"varint,1,opt,name=blocks,proto3" json:"blocks,omitempty"`
+	Transactions  bool                   `protobuf:"varint,2,opt,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_bitcoin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubscribeRequest) GetBlocks() bool {
+	if x != nil {
+		return x.Blocks
+	}
+	return false
+}
+
+func (x *SubscribeRequest) GetTransactions() bool {
+	if x != nil {
+		return x.Transactions
+	}
+	return false
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          Event_Kind             `protobuf:"varint,1,opt,name=kind,proto3,enum=gobitcoin.v1.Event_Kind" json:"kind,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_bitcoin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_bitcoin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_bitcoin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Event) GetKind() Event_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Event_KIND_UNSPECIFIED
+}
+
+func (x *Event) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+var File_bitcoin_proto protoreflect.FileDescriptor
+
+const file_bitcoin_proto_rawDesc = "" +
+	"\n" +
+	"\rbitcoin.proto\x12\fgobitcoin.v1\"\x15\n" +
+	"\x13GetChainInfoRequest\"\xd1\x01\n" +
+	"\tChainInfo\x12\x14\n" +
+	"\x05chain\x18\x01 \x01(\tR\x05chain\x12\x16\n" +
+	"\x06blocks\x18\x02 \x01(\x03R\x06blocks\x12\x18\n" +
+	"\aheaders\x18\x03 \x01(\x03R\aheaders\x12&\n" +
+	"\x0fbest_block_hash\x18\x04 \x01(\tR\rbestBlockHash\x12\x1f\n" +
+	"\vmedian_time\x18\x05 \x01(\x03R\n" +
+	"medianTime\x123\n" +
+	"\x15verification_progress\x18\x06 \x01(\x01R\x14verificationProgress\"=\n" +
+	"\x0fGetBlockRequest\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x03R\x06height\"\xf5\x01\n" +
+	"\x05Block\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x03R\x06height\x12.\n" +
+	"\x13previous_block_hash\x18\x03 \x01(\tR\x11previousBlockHash\x12\x12\n" +
+	"\x04time\x18\x04 \x01(\x03R\x04time\x12\x1f\n" +
+	"\vmedian_time\x18\x05 \x01(\x03R\n" +
+	"medianTime\x12\x1f\n" +
+	"\vmerkle_root\x18\x06 \x01(\tR\n" +
+	"merkleRoot\x12$\n" +
+	"\rconfirmations\x18\a \x01(\x03R\rconfirmations\x12\x14\n" +
+	"\x05txids\x18\b \x03(\tR\x05txids\"+\n" +
+	"\x15GetTransactionRequest\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\tR\x04txid\"x\n" +
+	"\vTransaction\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\tR\x04txid\x12\x10\n" +
+	"\x03hex\x18\x02 \x01(\tR\x03hex\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\x03 \x01(\tR\tblockHash\x12$\n" +
+	"\rconfirmations\x18\x04 \x01(\x03R\rconfirmations\"I\n" +
+	"\x12EstimateFeeRequest\x12\x1f\n" +
+	"\vconf_target\x18\x01 \x01(\x05R\n" +
+	"confTarget\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\tR\x04mode\"I\n" +
+	"\vFeeEstimate\x12\"\n" +
+	"\rsat_per_vbyte\x18\x01 \x01(\x01R\vsatPerVbyte\x12\x16\n" +
+	"\x06blocks\x18\x02 \x01(\x05R\x06blocks\"$\n" +
+	"\x10BroadcastRequest\x12\x10\n" +
+	"\x03hex\x18\x01 \x01(\tR\x03hex\"'\n" +
+	"\x11BroadcastResponse\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\tR\x04txid\"N\n" +
+	"\x10SubscribeRequest\x12\x16\n" +
+	"\x06blocks\x18\x01 \x01(\bR\x06blocks\x12\"\n" +
+	"\ftransactions\x18\x02 \x01(\bR\ftransactions\"\x8d\x01\n" +
+	"\x05Event\x12,\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x18.gobitcoin.v1.Event.KindR\x04kind\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\"B\n" +
+	"\x04Kind\x12\x14\n" +
+	"\x10KIND_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"KIND_BLOCK\x10\x01\x12\x14\n" +
+	"\x10KIND_TRANSACTION\x10\x022\xc5\x03\n" +
+	"\aBitcoin\x12J\n" +
+	"\fGetChainInfo\x12!.gobitcoin.v1.GetChainInfoRequest\x1a\x17.gobitcoin.v1.ChainInfo\x12>\n" +
+	"\bGetBlock\x12\x1d.gobitcoin.v1.GetBlockRequest\x1a\x13.gobitcoin.v1.Block\x12P\n" +
+	"\x0eGetTransaction\x12#.gobitcoin.v1.GetTransactionRequest\x1a\x19.gobitcoin.v1.Transaction\x12J\n" +
+	"\vEstimateFee\x12 .gobitcoin.v1.EstimateFeeRequest\x1a\x19.gobitcoin.v1.FeeEstimate\x12L\n" +
+	"\tBroadcast\x12\x1e.gobitcoin.v1.BroadcastRequest\x1a\x1f.gobitcoin.v1.BroadcastResponse\x12B\n" +
+	"\tSubscribe\x12\x1e.gobitcoin.v1.SubscribeRequest\x1a\x13.gobitcoin.v1.Event0\x01B3Z1github.com/shuber/go-bitcoin/grpcserver/bitcoinpbb\x06proto3"
+
+var (
+	file_bitcoin_proto_rawDescOnce sync.Once
+	file_bitcoin_proto_rawDescData []byte
+)
+
+func file_bitcoin_proto_rawDescGZIP() []byte {
+	file_bitcoin_proto_rawDescOnce.Do(func() {
+		file_bitcoin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bitcoin_proto_rawDesc), len(file_bitcoin_proto_rawDesc)))
+	})
+	return file_bitcoin_proto_rawDescData
+}
+
+var file_bitcoin_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_bitcoin_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_bitcoin_proto_goTypes = []any{
+	(Event_Kind)(0),               // 0: gobitcoin.v1.Event.Kind
+	(*GetChainInfoRequest)(nil),   // 1: gobitcoin.v1.GetChainInfoRequest
+	(*ChainInfo)(nil),             // 2: gobitcoin.v1.ChainInfo
+	(*GetBlockRequest)(nil),       // 3: gobitcoin.v1.GetBlockRequest
+	(*Block)(nil),                 // 4: gobitcoin.v1.Block
+	(*GetTransactionRequest)(nil), // 5: gobitcoin.v1.GetTransactionRequest
+	(*Transaction)(nil),           // 6: gobitcoin.v1.Transaction
+	(*EstimateFeeRequest)(nil),    // 7: gobitcoin.v1.EstimateFeeRequest
+	(*FeeEstimate)(nil),           // 8: gobitcoin.v1.FeeEstimate
+	(*BroadcastRequest)(nil),      // 9: gobitcoin.v1.BroadcastRequest
+	(*BroadcastResponse)(nil),     // 10: gobitcoin.v1.BroadcastResponse
+	(*SubscribeRequest)(nil),      // 11: gobitcoin.v1.SubscribeRequest
+	(*Event)(nil),                 // 12: gobitcoin.v1.Event
+}
+var file_bitcoin_proto_depIdxs = []int32{
+	0,  // 0: gobitcoin.v1.Event.kind:type_name -> gobitcoin.v1.Event.Kind
+	1,  // 1: gobitcoin.v1.Bitcoin.GetChainInfo:input_type -> gobitcoin.v1.GetChainInfoRequest
+	3,  // 2: gobitcoin.v1.Bitcoin.GetBlock:input_type -> gobitcoin.v1.GetBlockRequest
+	5,  // 3: gobitcoin.v1.Bitcoin.GetTransaction:input_type -> gobitcoin.v1.GetTransactionRequest
+	7,  // 4: gobitcoin.v1.Bitcoin.EstimateFee:input_type -> gobitcoin.v1.EstimateFeeRequest
+	9,  // 5: gobitcoin.v1.Bitcoin.Broadcast:input_type -> gobitcoin.v1.BroadcastRequest
+	11, // 6: gobitcoin.v1.Bitcoin.Subscribe:input_type -> gobitcoin.v1.SubscribeRequest
+	2,  // 7: gobitcoin.v1.Bitcoin.GetChainInfo:output_type -> gobitcoin.v1.ChainInfo
+	4,  // 8: gobitcoin.v1.Bitcoin.GetBlock:output_type -> gobitcoin.v1.Block
+	6,  // 9: gobitcoin.v1.Bitcoin.GetTransaction:output_type -> gobitcoin.v1.Transaction
+	8,  // 10: gobitcoin.v1.Bitcoin.EstimateFee:output_type -> gobitcoin.v1.FeeEstimate
+	10, // 11: gobitcoin.v1.Bitcoin.Broadcast:output_type -> gobitcoin.v1.BroadcastResponse
+	12, // 12: gobitcoin.v1.Bitcoin.Subscribe:output_type -> gobitcoin.v1.Event
+	7,  // [7:13] is the sub-list for method output_type
+	1,  // [1:7] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_bitcoin_proto_init() }
+func file_bitcoin_proto_init() {
+	if File_bitcoin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bitcoin_proto_rawDesc), len(file_bitcoin_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bitcoin_proto_goTypes,
+		DependencyIndexes: file_bitcoin_proto_depIdxs,
+		EnumInfos:         file_bitcoin_proto_enumTypes,
+		MessageInfos:      file_bitcoin_proto_msgTypes,
+	}.Build()
+	File_bitcoin_proto = out.File
+	file_bitcoin_proto_goTypes = nil
+	file_bitcoin_proto_depIdxs = nil
+}
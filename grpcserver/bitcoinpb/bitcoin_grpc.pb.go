@@ -0,0 +1,323 @@
+// Curated, read mostly view of a node, served by the grpcserver package on top of nodeservice.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: bitcoin.proto
+
+package bitcoinpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Bitcoin_GetChainInfo_FullMethodName   = "/gobitcoin.v1.Bitcoin/GetChainInfo"
+	Bitcoin_GetBlock_FullMethodName       = "/gobitcoin.v1.Bitcoin/GetBlock"
+	Bitcoin_GetTransaction_FullMethodName = "/gobitcoin.v1.Bitcoin/GetTransaction"
+	Bitcoin_EstimateFee_FullMethodName    = "/gobitcoin.v1.Bitcoin/EstimateFee"
+	Bitcoin_Broadcast_FullMethodName      = "/gobitcoin.v1.Bitcoin/Broadcast"
+	Bitcoin_Subscribe_FullMethodName      = "/gobitcoin.v1.Bitcoin/Subscribe"
+)
+
+// BitcoinClient is the client API for Bitcoin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BitcoinClient interface {
+	GetChainInfo(ctx context.Context, in *GetChainInfoRequest, opts ...grpc.CallOption) (*ChainInfo, error)
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	// Fails with UNAVAILABLE while the node has no estimate.
+	EstimateFee(ctx context.Context, in *EstimateFeeRequest, opts ...grpc.CallOption) (*FeeEstimate, error)
+	// Subject to the withdrawal policy of the client, failing with PERMISSION_DENIED when it rejects.
+	Broadcast(ctx context.Context, in *BroadcastRequest, opts ...grpc.CallOption) (*BroadcastResponse, error)
+	// Streams block and transaction announcements until the client cancels.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type bitcoinClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBitcoinClient(cc grpc.ClientConnInterface) BitcoinClient {
+	return &bitcoinClient{cc}
+}
+
+func (c *bitcoinClient) GetChainInfo(ctx context.Context, in *GetChainInfoRequest, opts ...grpc.CallOption) (*ChainInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChainInfo)
+	err := c.cc.Invoke(ctx, Bitcoin_GetChainInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bitcoinClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Block)
+	err := c.cc.Invoke(ctx, Bitcoin_GetBlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bitcoinClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, Bitcoin_GetTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bitcoinClient) EstimateFee(ctx context.Context, in *EstimateFeeRequest, opts ...grpc.CallOption) (*FeeEstimate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FeeEstimate)
+	err := c.cc.Invoke(ctx, Bitcoin_EstimateFee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bitcoinClient) Broadcast(ctx context.Context, in *BroadcastRequest, opts ...grpc.CallOption) (*BroadcastResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BroadcastResponse)
+	err := c.cc.Invoke(ctx, Bitcoin_Broadcast_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bitcoinClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Bitcoin_ServiceDesc.Streams[0], Bitcoin_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Bitcoin_SubscribeClient = grpc.ServerStreamingClient[Event]
+
+// BitcoinServer is the server API for Bitcoin service.
+// All implementations must embed UnimplementedBitcoinServer
+// for forward compatibility.
+type BitcoinServer interface {
+	GetChainInfo(context.Context, *GetChainInfoRequest) (*ChainInfo, error)
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error)
+	// Fails with UNAVAILABLE while the node has no estimate.
+	EstimateFee(context.Context, *EstimateFeeRequest) (*FeeEstimate, error)
+	// Subject to the withdrawal policy of the client, failing with PERMISSION_DENIED when it rejects.
+	Broadcast(context.Context, *BroadcastRequest) (*BroadcastResponse, error)
+	// Streams block and transaction announcements until the client cancels.
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedBitcoinServer()
+}
+
+// UnimplementedBitcoinServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBitcoinServer struct{}
+
+func (UnimplementedBitcoinServer) GetChainInfo(context.Context, *GetChainInfoRequest) (*ChainInfo, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetChainInfo not implemented")
+}
+func (UnimplementedBitcoinServer) GetBlock(context.Context, *GetBlockRequest) (*Block, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedBitcoinServer) GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedBitcoinServer) EstimateFee(context.Context, *EstimateFeeRequest) (*FeeEstimate, error) {
+	return nil, status.Error(codes.Unimplemented, "method EstimateFee not implemented")
+}
+func (UnimplementedBitcoinServer) Broadcast(context.Context, *BroadcastRequest) (*BroadcastResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Broadcast not implemented")
+}
+func (UnimplementedBitcoinServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedBitcoinServer) mustEmbedUnimplementedBitcoinServer() {}
+func (UnimplementedBitcoinServer) testEmbeddedByValue()                 {}
+
+// UnsafeBitcoinServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BitcoinServer will
+// result in compilation errors.
+type UnsafeBitcoinServer interface {
+	mustEmbedUnimplementedBitcoinServer()
+}
+
+func RegisterBitcoinServer(s grpc.ServiceRegistrar, srv BitcoinServer) {
+	// If the following call panics, it indicates UnimplementedBitcoinServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Bitcoin_ServiceDesc, srv)
+}
+
+func _Bitcoin_GetChainInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChainInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BitcoinServer).GetChainInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bitcoin_GetChainInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BitcoinServer).GetChainInfo(ctx, req.(*GetChainInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bitcoin_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BitcoinServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bitcoin_GetBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BitcoinServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bitcoin_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BitcoinServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bitcoin_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BitcoinServer).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bitcoin_EstimateFee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EstimateFeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BitcoinServer).EstimateFee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bitcoin_EstimateFee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BitcoinServer).EstimateFee(ctx, req.(*EstimateFeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bitcoin_Broadcast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BroadcastRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BitcoinServer).Broadcast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bitcoin_Broadcast_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BitcoinServer).Broadcast(ctx, req.(*BroadcastRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bitcoin_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BitcoinServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Bitcoin_SubscribeServer = grpc.ServerStreamingServer[Event]
+
+// Bitcoin_ServiceDesc is the grpc.ServiceDesc for Bitcoin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Bitcoin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gobitcoin.v1.Bitcoin",
+	HandlerType: (*BitcoinServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetChainInfo",
+			Handler:    _Bitcoin_GetChainInfo_Handler,
+		},
+		{
+			MethodName: "GetBlock",
+			Handler:    _Bitcoin_GetBlock_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _Bitcoin_GetTransaction_Handler,
+		},
+		{
+			MethodName: "EstimateFee",
+			Handler:    _Bitcoin_EstimateFee_Handler,
+		},
+		{
+			MethodName: "Broadcast",
+			Handler:    _Bitcoin_Broadcast_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Bitcoin_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bitcoin.proto",
+}
@@ -0,0 +1,139 @@
+// Package grpcserver serves nodeservice over gRPC, using the stubs generated from proto/bitcoin.proto into
+// the bitcoinpb package. It is a module of its own, so that only programs importing it depend on gRPC:
+//
+//	s := grpc.NewServer()
+//	bitcoinpb.RegisterBitcoinServer(s, grpcserver.New(nodeservice.NewService(b, zmq)))
+//	err := s.Serve(listener)
+package grpcserver
+
+//go:generate protoc -I proto --go_out=. --go_opt=module=github.com/shuber/go-bitcoin/grpcserver --go-grpc_out=. --go-grpc_opt=module=github.com/shuber/go-bitcoin/grpcserver proto/bitcoin.proto
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/shuber/go-bitcoin/grpcserver/bitcoinpb"
+	"github.com/shuber/go-bitcoin/nodeservice"
+)
+
+// Server implements bitcoinpb.BitcoinServer by delegating to a nodeservice.Service.
+type Server struct {
+	bitcoinpb.UnimplementedBitcoinServer
+
+	s *nodeservice.Service
+}
+
+// New returns a Server for the given service.
+func New(s *nodeservice.Service) *Server {
+	return &Server{s: s}
+}
+
+// GetChainInfo returns the state of the node's chain.
+func (g *Server) GetChainInfo(ctx context.Context, _ *bitcoinpb.GetChainInfoRequest) (*bitcoinpb.ChainInfo, error) {
+	info, err := g.s.GetChainInfo(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &bitcoinpb.ChainInfo{
+		Chain:                info.Chain,
+		Blocks:               info.Blocks,
+		Headers:              info.Headers,
+		BestBlockHash:        info.BestBlockHash,
+		MedianTime:           info.MedianTime,
+		VerificationProgress: info.VerificationProgress,
+	}, nil
+}
+
+// GetBlock returns a block of the active chain by hash or height.
+func (g *Server) GetBlock(ctx context.Context, req *bitcoinpb.GetBlockRequest) (*bitcoinpb.Block, error) {
+	block, err := g.s.GetBlock(ctx, &nodeservice.GetBlockRequest{Hash: req.GetHash(), Height: req.GetHeight()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &bitcoinpb.Block{
+		Hash:              block.Hash,
+		Height:            block.Height,
+		PreviousBlockHash: block.PreviousBlockHash,
+		Time:              block.Time,
+		MedianTime:        block.MedianTime,
+		MerkleRoot:        block.MerkleRoot,
+		Confirmations:     block.Confirmations,
+		Txids:             block.Txids,
+	}, nil
+}
+
+// GetTransaction returns a transaction from the mempool or, with -txindex, from the chain.
+func (g *Server) GetTransaction(ctx context.Context, req *bitcoinpb.GetTransactionRequest) (*bitcoinpb.Transaction, error) {
+	tx, err := g.s.GetTransaction(ctx, req.GetTxid())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &bitcoinpb.Transaction{
+		Txid:          tx.Txid,
+		Hex:           tx.Hex,
+		BlockHash:     tx.BlockHash,
+		Confirmations: tx.Confirmations,
+	}, nil
+}
+
+// EstimateFee returns the fee rate for confirmation within the target number of blocks.
+func (g *Server) EstimateFee(ctx context.Context, req *bitcoinpb.EstimateFeeRequest) (*bitcoinpb.FeeEstimate, error) {
+	fee, err := g.s.EstimateFee(ctx, &nodeservice.EstimateFeeRequest{ConfTarget: req.GetConfTarget(), Mode: req.GetMode()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &bitcoinpb.FeeEstimate{SatPerVbyte: fee.SatPerVbyte, Blocks: fee.Blocks}, nil
+}
+
+// Broadcast sends a signed transaction and returns its txid.
+func (g *Server) Broadcast(ctx context.Context, req *bitcoinpb.BroadcastRequest) (*bitcoinpb.BroadcastResponse, error) {
+	txid, err := g.s.Broadcast(ctx, req.GetHex())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &bitcoinpb.BroadcastResponse{Txid: txid}, nil
+}
+
+// Subscribe streams block and transaction announcements until the client cancels.
+func (g *Server) Subscribe(req *bitcoinpb.SubscribeRequest, stream bitcoinpb.Bitcoin_SubscribeServer) error {
+	err := g.s.Subscribe(stream.Context(), &nodeservice.SubscribeRequest{Blocks: req.GetBlocks(), Transactions: req.GetTransactions()}, func(e *nodeservice.Event) error {
+		return stream.Send(&bitcoinpb.Event{Kind: bitcoinpb.Event_Kind(e.Kind), Hash: e.Hash})
+	})
+
+	return statusError(err)
+}
+
+// statusCodes maps the classes of nodeservice.Code to gRPC status codes.
+var statusCodes = map[string]codes.Code{
+	"InvalidArgument":  codes.InvalidArgument,
+	"NotFound":         codes.NotFound,
+	"PermissionDenied": codes.PermissionDenied,
+	"Unavailable":      codes.Unavailable,
+	"Canceled":         codes.Canceled,
+	"DeadlineExceeded": codes.DeadlineExceeded,
+}
+
+// statusError converts an error of the service to a gRPC status error, keeping its message.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code, found := statusCodes[nodeservice.Code(err)]
+	if !found {
+		code = codes.Internal
+	}
+
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,171 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+	"github.com/shuber/go-bitcoin/grpcserver/bitcoinpb"
+	"github.com/shuber/go-bitcoin/nodeservice"
+)
+
+const (
+	testHash = "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206"
+	testTxid = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+)
+
+type fakeNotifier struct {
+	mu   sync.Mutex
+	subs map[string]chan []string
+}
+
+func (n *fakeNotifier) Subscribe(topic string, ch chan []string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.subs[topic] = ch
+	return nil
+}
+
+func (n *fakeNotifier) Unsubscribe(topic string, ch chan []string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.subs, topic)
+	return nil
+}
+
+func (n *fakeNotifier) publish(topic, hash string) bool {
+	n.mu.Lock()
+	ch, found := n.subs[topic]
+	n.mu.Unlock()
+
+	if found {
+		ch <- []string{topic, hash, "0"}
+	}
+	return found
+}
+
+// testClient serves a Server for a node answering getblockchaininfo, getblock and estimatesmartfee over an
+// in-memory connection, and rejecting all transactions.
+func testClient(t *testing.T, events nodeservice.Notifier) bitcoinpb.BitcoinClient {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "getblockchaininfo":
+			_, _ = w.Write([]byte(`{"result":{"chain":"regtest","blocks":101,"headers":101,"bestblockhash":"` + testHash + `","mediantime":1296688602,"verificationprogress":1},"error":null,"id":1}`))
+		case "getblock":
+			_, _ = w.Write([]byte(`{"result":{"hash":"` + testHash + `","height":0,"time":1296688602,"mediantime":1296688602,"merkleroot":"` + testTxid + `","confirmations":102,"tx":["` + testTxid + `"]},"error":null,"id":1}`))
+		case "estimatesmartfee":
+			_, _ = w.Write([]byte(`{"result":{"errors":["Insufficient data or no feerate found"],"blocks":2},"error":null,"id":1}`))
+		case "sendrawtransaction":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-26,"message":"mandatory-script-verify-flag-failed"},"id":1}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":1}`))
+		}
+	}))
+	t.Cleanup(node.Close)
+
+	u, _ := url.Parse(node.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	b, err := bitcoin.New(u.Hostname(), port, "", "user", "pass", false)
+	require.NoError(t, err)
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	bitcoinpb.RegisterBitcoinServer(s, New(nodeservice.NewService(b, events)))
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return bitcoinpb.NewBitcoinClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	c := testClient(t, nil)
+	ctx := context.Background()
+
+	info, err := c.GetChainInfo(ctx, &bitcoinpb.GetChainInfoRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "regtest", info.GetChain())
+	assert.Equal(t, int64(101), info.GetBlocks())
+	assert.Equal(t, testHash, info.GetBestBlockHash())
+
+	block, err := c.GetBlock(ctx, &bitcoinpb.GetBlockRequest{Hash: testHash})
+	require.NoError(t, err)
+	assert.Equal(t, int64(102), block.GetConfirmations())
+	assert.Equal(t, []string{testTxid}, block.GetTxids())
+}
+
+func TestServerErrors(t *testing.T) {
+	c := testClient(t, nil)
+	ctx := context.Background()
+
+	_, err := c.GetBlock(ctx, &bitcoinpb.GetBlockRequest{Hash: "zz"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), err)
+
+	_, err = c.EstimateFee(ctx, &bitcoinpb.EstimateFeeRequest{ConfTarget: 2})
+	assert.Equal(t, codes.Unavailable, status.Code(err), err)
+
+	_, err = c.Broadcast(ctx, &bitcoinpb.BroadcastRequest{Hex: "0200"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), err)
+	assert.Contains(t, status.Convert(err).Message(), "mandatory-script-verify-flag-failed")
+
+	// Without an event source the stream fails on its first receive
+	stream, err := c.Subscribe(ctx, &bitcoinpb.SubscribeRequest{Blocks: true})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err), err)
+}
+
+func TestServerSubscribe(t *testing.T) {
+	n := &fakeNotifier{subs: make(map[string]chan []string)}
+	c := testClient(t, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.Subscribe(ctx, &bitcoinpb.SubscribeRequest{Transactions: true})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return n.publish("hashtx", testTxid) }, time.Second, time.Millisecond)
+
+	e, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, bitcoinpb.Event_KIND_TRANSACTION, e.GetKind())
+	assert.Equal(t, testTxid, e.GetHash())
+
+	// Cancelling the stream unsubscribes on the server
+	cancel()
+	assert.Eventually(t, func() bool {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		return len(n.subs) == 0
+	}, time.Second, time.Millisecond)
+}
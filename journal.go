@@ -0,0 +1,280 @@
+package bitcoin
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JournalState is the state of a journaled call.
+type JournalState string
+
+const (
+	JournalPending  JournalState = "pending"  // about to be sent, never seen again after a crash mid call
+	JournalDone     JournalState = "done"     // answered with a result
+	JournalFailed   JournalState = "failed"   // answered with an error, the node did not carry it out
+	JournalUnknown  JournalState = "unknown"  // sent without an answer, it may or may not have been carried out
+	JournalResolved JournalState = "resolved" // a pending or unknown call checked by recovery code
+)
+
+// JournalEntry records a state changing call.
+type JournalEntry struct {
+	ID     string          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	State  JournalState    `json:"state"`
+	Time   time.Time       `json:"time"` // of the last state change
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// TxID returns the txid of the transaction of a sendrawtransaction entry, for recovery code to look it
+// up, or "" for other entries.
+func (e JournalEntry) TxID() string {
+	var params []json.RawMessage
+	if e.Method != "sendrawtransaction" || json.Unmarshal(e.Params, &params) != nil || len(params) == 0 {
+		return ""
+	}
+
+	var txHex string
+	if json.Unmarshal(params[0], &txHex) != nil {
+		return ""
+	}
+
+	raw, err := hex.DecodeString(txHex)
+	if err != nil {
+		return ""
+	}
+
+	txid, _ := ComputeTxID(raw)
+	return txid
+}
+
+// JournalStore durably records journal entries. SaveEntry must not return before the entry is persisted,
+// and replaces an earlier entry with the same ID.
+type JournalStore interface {
+	SaveEntry(e JournalEntry) error
+	// LoadEntries returns the latest version of every entry, in the order they were first saved.
+	LoadEntries() ([]JournalEntry, error)
+}
+
+// UnfinishedEntries returns the entries of calls whose outcome is not known, pending and unknown ones,
+// which recovery code has to check against the node before deciding whether to send them again. Once
+// checked, an entry is marked by saving it with state JournalResolved.
+func UnfinishedEntries(store JournalStore) ([]JournalEntry, error) {
+	entries, err := store.LoadEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var unfinished []JournalEntry
+	for _, e := range entries {
+		if e.State == JournalPending || e.State == JournalUnknown {
+			unfinished = append(unfinished, e)
+		}
+	}
+
+	return unfinished, nil
+}
+
+// journal writes the entries of a client, shared by the clients derived with WithOptions.
+type journal struct {
+	store JournalStore
+	seq   uint64
+}
+
+// WithJournal records every call to a state changing method, any method IsRetrySafe does not report as
+// safe, in store: as pending before it is sent, and with its outcome once answered. A call is not sent if
+// its entry cannot be saved. After a crash, UnfinishedEntries tells which calls may have been issued.
+func WithJournal(store JournalStore) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.journal = &journal{store: store}
+	}
+}
+
+// beginJournal saves the pending entry of a call, or returns nil if the method is not journaled.
+func (c *rpcClient) beginJournal(method string, params interface{}) (*JournalEntry, error) {
+	if c.journal == nil || IsRetrySafe(method) {
+		return nil, nil
+	}
+
+	p, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.clock.Now()
+	e := &JournalEntry{
+		ID:     fmt.Sprintf("%d-%d", now.UnixNano(), atomic.AddUint64(&c.journal.seq, 1)),
+		Method: method,
+		Params: p,
+		State:  JournalPending,
+		Time:   now,
+	}
+
+	if err := c.journal.store.SaveEntry(*e); err != nil {
+		return nil, fmt.Errorf("failed to journal %s: %w", method, err)
+	}
+
+	return e, nil
+}
+
+// endJournal records the outcome of a journaled call. Failing to do so leaves the entry pending, which
+// recovery treats like an unknown outcome, so the error is only logged.
+func (c *rpcClient) endJournal(e *JournalEntry, rr rpcResponse, err error) {
+	if e == nil {
+		return
+	}
+
+	switch {
+	case err != nil:
+		e.State, e.Error = JournalUnknown, err.Error()
+	case rr.Err != nil:
+		e.State, e.Error = JournalFailed, responseError(rr.Err).Error()
+	default:
+		e.State, e.Result = JournalDone, rr.Result
+	}
+	e.Time = c.clock.Now()
+
+	if err := c.journal.store.SaveEntry(*e); err != nil {
+		c.logger.Errorf("Failed to journal the outcome of %s %s: %v", e.Method, e.ID, err)
+	}
+}
+
+// MemoryJournalStore is a JournalStore that keeps entries in memory, which is mostly useful for tests.
+type MemoryJournalStore struct {
+	mu      sync.Mutex
+	entries []JournalEntry
+	index   map[string]int
+}
+
+// NewMemoryJournalStore returns an empty MemoryJournalStore.
+func NewMemoryJournalStore() *MemoryJournalStore {
+	return &MemoryJournalStore{index: make(map[string]int)}
+}
+
+func (s *MemoryJournalStore) SaveEntry(e JournalEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i, found := s.index[e.ID]; found {
+		s.entries[i] = e
+		return nil
+	}
+
+	s.index[e.ID] = len(s.entries)
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func (s *MemoryJournalStore) LoadEntries() ([]JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]JournalEntry{}, s.entries...), nil
+}
+
+// FileJournalStore is a JournalStore appending entries as JSON lines to a file, which is synced after
+// every entry. The latest line of an entry wins when the file is read back; a line torn by a crash is
+// ignored.
+type FileJournalStore struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// NewFileJournalStore returns a FileJournalStore appending to the file at path, creating it if needed.
+func NewFileJournalStore(path string) (*FileJournalStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	// End a line torn by a crash, so that it does not swallow the next entry
+	if err := endTornLine(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to repair journal: %w", err)
+	}
+
+	return &FileJournalStore{path: path, f: f}, nil
+}
+
+func endTornLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil || last[0] == '\n' {
+		return err
+	}
+
+	_, err = f.Write([]byte{'\n'})
+	return err
+}
+
+func (s *FileJournalStore) SaveEntry(e JournalEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := s.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileJournalStore) LoadEntries() ([]JournalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), stdioMaxLine)
+	for scanner.Scan() {
+		var e JournalEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || e.ID == "" {
+			continue
+		}
+
+		if i, found := index[e.ID]; found {
+			entries[i] = e
+			continue
+		}
+		index[e.ID] = len(entries)
+		entries = append(entries, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Close closes the journal file.
+func (s *FileJournalStore) Close() error {
+	return s.f.Close()
+}
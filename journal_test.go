@@ -0,0 +1,105 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	c, closeServer := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "sendtoaddress":
+			fmt.Fprint(w, `{"result":null,"error":{"code":-6,"message":"Insufficient funds"},"id":1}`)
+		case "sendmany":
+			// Hang up without an answer
+			hj, _ := w.(http.Hijacker)
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+		default:
+			fmt.Fprint(w, `{"result":"ok","error":null,"id":1}`)
+		}
+	})
+	defer closeServer()
+
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	store, err := NewFileJournalStore(path)
+	require.NoError(t, err)
+	WithJournal(store)(c)
+
+	txHex := hex.EncodeToString(testTx([]Outpoint{{TxID: strings.Repeat("ab", 32)}}, "51", 1000))
+
+	_, err = c.call("sendrawtransaction", []interface{}{txHex})
+	require.NoError(t, err)
+	_, err = c.call("getblockcount", nil)
+	require.NoError(t, err)
+	_, err = c.call("sendtoaddress", []interface{}{"addr", 1})
+	require.NoError(t, err)
+	_, err = c.call("sendmany", []interface{}{"", map[string]float64{"addr": 1}})
+	require.Error(t, err)
+	require.NoError(t, store.Close())
+
+	// A crash after writing the pending entry of another call, and while writing a line
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, _ = f.WriteString(`{"id":"crashed","method":"sendrawtransaction","params":["` + txHex + `"],"state":"pending"}` + "\n" + `{"id":"torn","meth`)
+	require.NoError(t, f.Close())
+
+	store, err = NewFileJournalStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	entries, err := store.LoadEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, JournalDone, entries[0].State)
+	assert.Equal(t, `"ok"`, string(entries[0].Result))
+	assert.Equal(t, JournalFailed, entries[1].State)
+	assert.Equal(t, "ERROR -6: Insufficient funds", entries[1].Error)
+
+	unfinished, err := UnfinishedEntries(store)
+	require.NoError(t, err)
+	require.Len(t, unfinished, 2)
+	assert.Equal(t, "sendmany", unfinished[0].Method)
+	assert.Equal(t, JournalUnknown, unfinished[0].State)
+	assert.Equal(t, "", unfinished[0].TxID())
+
+	txid, err := ComputeTxID(testTx([]Outpoint{{TxID: strings.Repeat("ab", 32)}}, "51", 1000))
+	require.NoError(t, err)
+	assert.Equal(t, txid, unfinished[1].TxID())
+
+	unfinished[1].State = JournalResolved
+	require.NoError(t, store.SaveEntry(unfinished[1]))
+	unfinished, err = UnfinishedEntries(store)
+	require.NoError(t, err)
+	assert.Len(t, unfinished, 1)
+}
+
+func TestJournalRefusesUnjournaledCalls(t *testing.T) {
+	calls := 0
+	c, closeServer := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"result":"ok","error":null,"id":1}`)
+	})
+	defer closeServer()
+
+	store, err := NewFileJournalStore(filepath.Join(t.TempDir(), "journal.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+	WithJournal(store)(c)
+
+	_, err = c.call("sendtoaddress", []interface{}{"addr", 1})
+	assert.Error(t, err)
+	assert.Equal(t, 0, calls)
+}
@@ -0,0 +1,97 @@
+package bitcoin
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// KeepAlive keeps the connection to the node warm by pinging it with a cheap call while the client is idle.
+// The node closes connections idle for longer than its -rpcservertimeout, 30 seconds by default, so that the
+// first call after a quiet period pays for a new connection; pinging more often avoids that and notices a
+// dead node before a real call does. Connections are dropped when a ping fails, so that the next call
+// reconnects rather than reusing a broken one.
+type KeepAlive struct {
+	Method string // called without params, uptime by default
+	// OnDown is called when a ping fails after the previous one succeeded, OnUp when one succeeds again.
+	OnDown func(error)
+	OnUp   func()
+
+	b    *Bitcoind
+	mu   sync.Mutex
+	down bool
+}
+
+// NewKeepAlive returns a KeepAlive pinging the node with uptime.
+func NewKeepAlive(b *Bitcoind) *KeepAlive {
+	return &KeepAlive{Method: "uptime", b: b}
+}
+
+// Ping calls the node once, returning the error of the call.
+func (k *KeepAlive) Ping() error {
+	r, err := k.b.client.call(k.Method, nil)
+	if err == nil && r.Err != nil {
+		err = responseError(r.Err)
+	}
+
+	k.mu.Lock()
+	changed := (err != nil) != k.down
+	k.down = err != nil
+	k.mu.Unlock()
+
+	if err != nil {
+		k.b.client.httpClient.CloseIdleConnections()
+	}
+
+	switch {
+	case !changed:
+	case err != nil:
+		k.b.client.logger.Warnf("KeepAlive: node is not responding: %v", err)
+		if k.OnDown != nil {
+			k.OnDown(err)
+		}
+	default:
+		k.b.client.logger.Infof("KeepAlive: node is responding again")
+		if k.OnUp != nil {
+			k.OnUp()
+		}
+	}
+
+	return err
+}
+
+// Run pings the node right away, opening a connection before the first real call, and then every interval
+// unless a call was made during it, until the context is done. As the client can be idle for up to twice
+// interval, it should be less than half the node's -rpcservertimeout.
+func (k *KeepAlive) Run(ctx context.Context, interval time.Duration) {
+	_ = k.Ping()
+
+	ticker := k.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+
+		if k.idle() < interval {
+			continue
+		}
+
+		_ = k.Ping()
+	}
+}
+
+// idle returns how long ago the last call of the client, or of a client derived from it, finished.
+func (k *KeepAlive) idle() time.Duration {
+	if k.b.client.lastActivity == nil {
+		return math.MaxInt64
+	}
+
+	last := atomic.LoadInt64(k.b.client.lastActivity)
+	return k.b.client.clock.Now().Sub(time.Unix(0, last))
+}
@@ -0,0 +1,92 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepAlivePing(t *testing.T) {
+	var failing int32
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":1}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":3600,"error":null,"id":1}`))
+	}, WithOptionalLogger(&countingLogger{}))
+	defer closeServer()
+
+	k := NewKeepAlive(b)
+	var downs, ups int
+	k.OnDown = func(err error) {
+		downs++
+		assert.True(t, IsRetryable(err))
+	}
+	k.OnUp = func() { ups++ }
+
+	require.NoError(t, k.Ping())
+
+	atomic.StoreInt32(&failing, 1)
+	assert.Error(t, k.Ping())
+	assert.Error(t, k.Ping())
+	assert.Equal(t, 1, downs)
+
+	atomic.StoreInt32(&failing, 0)
+	require.NoError(t, k.Ping())
+	assert.Equal(t, 1, ups)
+}
+
+func TestKeepAliveRun(t *testing.T) {
+	pings := make(chan struct{}, 10)
+	clock := NewFakeClock(time.Now())
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "uptime" {
+			pings <- struct{}{}
+		}
+		_, _ = w.Write([]byte(`{"result":1,"error":null,"id":1}`))
+	}, WithClock(clock))
+	defer closeServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewKeepAlive(b).Run(ctx, 15*time.Second)
+
+	waitForPing := func() {
+		select {
+		case <-pings:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no ping")
+		}
+	}
+
+	// Prewarming, the ticker is the only waiter once the call finished
+	waitForPing()
+	for atomic.LoadInt64(b.client.lastActivity) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.BlockUntil(1)
+
+	clock.Advance(15 * time.Second)
+	waitForPing()
+
+	// A call made since the last tick makes the next one skip the ping
+	clock.Advance(10 * time.Second)
+	_, err := b.client.call("getblockcount", nil)
+	require.NoError(t, err)
+	clock.Advance(5 * time.Second)
+
+	clock.Advance(15 * time.Second)
+	waitForPing()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, pings)
+}
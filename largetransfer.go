@@ -0,0 +1,213 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// LargeTransferOutput is an output of a LargeTransferAlert.
+type LargeTransferOutput struct {
+	Vout    int
+	Value   uint64 // satoshis
+	Address string // empty for outputs without an address
+}
+
+// LargeTransferAlert reports a transaction moving more than the thresholds of a LargeTransferMonitor.
+type LargeTransferAlert struct {
+	TxID      string
+	BlockHash string // empty for mempool transactions
+	Height    int    // 0 for mempool transactions
+	Value     uint64 // satoshis, sum of all outputs of the transaction
+	// Outputs are the outputs at or above MinOutputValue, or all outputs if the alert was raised by
+	// MinTransferValue alone.
+	Outputs []LargeTransferOutput
+}
+
+// LargeTransferMonitor scans new blocks, and optionally the mempool, for transactions with an output of
+// at least MinOutputValue or a total output value of at least MinTransferValue. A zero threshold is not
+// checked. Mempool transactions are reported when first seen and again when mined.
+type LargeTransferMonitor struct {
+	MinOutputValue   uint64 // satoshis
+	MinTransferValue uint64 // satoshis
+	Mempool          bool
+	Params           *ChainParams // for the addresses of outputs, nil means MainNetParams
+	// OnAlert is called for every transaction above the thresholds.
+	OnAlert func(LargeTransferAlert)
+
+	b      *Bitcoind
+	mu     sync.Mutex
+	height int             // last scanned block, -1 before the first Check
+	seen   map[string]bool // mempool transactions already scanned
+}
+
+// NewLargeTransferMonitor returns a monitor scanning the blocks found after startHeight, or the blocks
+// mined after its first Check if startHeight is negative.
+func NewLargeTransferMonitor(b *Bitcoind, minOutputValue uint64, startHeight int) *LargeTransferMonitor {
+	return &LargeTransferMonitor{
+		MinOutputValue: minOutputValue,
+		b:              b,
+		height:         startHeight,
+		seen:           make(map[string]bool),
+	}
+}
+
+// Check scans the blocks mined since the last call, and the mempool if enabled, and returns the alerts,
+// which are also passed to OnAlert. A block that fails to load is retried on the next call.
+func (m *LargeTransferMonitor) Check() ([]LargeTransferAlert, error) {
+	var alerts []LargeTransferAlert
+
+	m.mu.Lock()
+	err := m.check(&alerts)
+	m.mu.Unlock()
+
+	if m.OnAlert != nil {
+		for _, alert := range alerts {
+			m.OnAlert(alert)
+		}
+	}
+
+	return alerts, err
+}
+
+func (m *LargeTransferMonitor) check(alerts *[]LargeTransferAlert) error {
+	var tip int
+	if err := m.b.uncachedCall("getblockcount", nil, &tip); err != nil {
+		return err
+	}
+
+	if m.height < 0 {
+		m.height = tip
+	}
+
+	for m.height < tip {
+		height := m.height + 1
+
+		var hash string
+		if err := m.b.uncachedCall("getblockhash", []interface{}{height}, &hash); err != nil {
+			return err
+		}
+
+		raw, err := m.b.GetRawBlock(hash)
+		if err != nil {
+			return err
+		}
+
+		block, err := parseBlock(raw)
+		if err != nil {
+			return err
+		}
+
+		// The coinbase transaction is not a transfer
+		for i, tx := range block.Txs {
+			if i == 0 {
+				continue
+			}
+			if alert, found := m.scan(tx); found {
+				alert.BlockHash = hash
+				alert.Height = height
+				*alerts = append(*alerts, alert)
+			}
+			delete(m.seen, tx.TxID)
+		}
+
+		m.height = height
+	}
+
+	if !m.Mempool {
+		return nil
+	}
+
+	var txids []string
+	if err := m.b.uncachedCall("getrawmempool", nil, &txids); err != nil {
+		return err
+	}
+
+	inMempool := make(map[string]bool, len(txids))
+	for _, txid := range txids {
+		inMempool[txid] = true
+		if m.seen[txid] {
+			continue
+		}
+
+		txHex, err := m.b.GetRawTransactionHex(txid)
+		if err != nil {
+			// The transaction left the mempool in the meantime
+			continue
+		}
+
+		raw, err := hex.DecodeString(*txHex)
+		if err != nil {
+			return err
+		}
+
+		tx, err := parseTx(raw)
+		if err != nil {
+			return err
+		}
+
+		if alert, found := m.scan(tx); found {
+			*alerts = append(*alerts, alert)
+		}
+		m.seen[txid] = true
+	}
+
+	for txid := range m.seen {
+		if !inMempool[txid] {
+			delete(m.seen, txid)
+		}
+	}
+
+	return nil
+}
+
+// scan checks a transaction against the thresholds.
+func (m *LargeTransferMonitor) scan(tx *parsedTx) (LargeTransferAlert, bool) {
+	params := m.Params
+	if params == nil {
+		params = MainNetParams
+	}
+
+	alert := LargeTransferAlert{TxID: tx.TxID}
+	outputs := make([]LargeTransferOutput, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		alert.Value += out.Value
+
+		outputs[i] = LargeTransferOutput{Vout: i, Value: out.Value}
+		outputs[i].Address, _ = ScriptToAddress(out.ScriptPubKey, params)
+
+		if m.MinOutputValue > 0 && out.Value >= m.MinOutputValue {
+			alert.Outputs = append(alert.Outputs, outputs[i])
+		}
+	}
+
+	if len(alert.Outputs) > 0 {
+		return alert, true
+	}
+
+	if m.MinTransferValue > 0 && alert.Value >= m.MinTransferValue {
+		alert.Outputs = outputs
+		return alert, true
+	}
+
+	return alert, false
+}
+
+// Run checks for large transfers every interval until the context is done.
+func (m *LargeTransferMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := m.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+
+		if _, err := m.Check(); err != nil {
+			m.b.client.logger.Errorf("LargeTransferMonitor: %v", err)
+		}
+	}
+}
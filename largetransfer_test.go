@@ -0,0 +1,110 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLargeTransferMonitor(t *testing.T) {
+	p2wpkh, _ := hex.DecodeString("0014751e76e8199196d454941c45d1b3a323f1433bd6")
+	nullData, _ := hex.DecodeString("6a0568656c6c6f")
+
+	whale := &transaction{
+		Version: 2,
+		Inputs:  []input{{index: 0, sequence: SequenceFinal}},
+		Outputs: []output{{value: 500000000, lockingScript: p2wpkh}, {value: 1000, lockingScript: nullData}},
+	}
+	// Many small outputs adding up to 1.2 BTC
+	split := &transaction{
+		Version: 2,
+		Inputs:  []input{{index: 1, sequence: SequenceFinal}},
+		Outputs: []output{{value: 60000000, lockingScript: p2wpkh}, {value: 60000000, lockingScript: p2wpkh}},
+	}
+	small := &transaction{
+		Version: 2,
+		Inputs:  []input{{index: 2, sequence: SequenceFinal}},
+		Outputs: []output{{value: 1000, lockingScript: p2wpkh}},
+	}
+	whaleTx, _ := parseTx(whale.ToHex())
+	splitTx, _ := parseTx(split.ToHex())
+	smallTx, _ := parseTx(small.ToHex())
+
+	coinbase := testCoinbaseBlock([]byte{0x01, 0x65}, output{value: 5000000000, lockingScript: p2wpkh})
+	block := append(append(coinbase[:80:80], 2), coinbase[81:]...)
+	block = append(block, whale.ToHex()...)
+
+	var mu sync.Mutex
+	tip := 100
+	mempool := []string{splitTx.TxID, smallTx.TxID}
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		var result interface{}
+		switch req.Method {
+		case "getblockcount":
+			result = tip
+		case "getblockhash":
+			result = "block101"
+		case "getblock":
+			result = hex.EncodeToString(block)
+		case "getrawmempool":
+			result = mempool
+		case "getrawtransaction":
+			for _, tx := range []*parsedTx{whaleTx, splitTx, smallTx} {
+				if tx.TxID == req.Params.([]interface{})[0] {
+					result = hex.EncodeToString(tx.Raw)
+				}
+			}
+		}
+
+		data, _ := json.Marshal(result)
+		_, _ = w.Write([]byte(`{"result":` + string(data) + `,"error":null,"id":1}`))
+	})
+	defer done()
+
+	m := NewLargeTransferMonitor(b, 100000000, -1)
+	m.MinTransferValue = 100000000
+	m.Mempool = true
+
+	var reported []string
+	m.OnAlert = func(alert LargeTransferAlert) { reported = append(reported, alert.TxID) }
+
+	alerts, err := m.Check()
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, splitTx.TxID, alerts[0].TxID)
+	assert.Equal(t, uint64(120000000), alerts[0].Value)
+	assert.Len(t, alerts[0].Outputs, 2, "raised by the transfer value, so all outputs are listed")
+	assert.Empty(t, alerts[0].BlockHash)
+
+	// Already seen mempool transactions are not reported again
+	alerts, err = m.Check()
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	mu.Lock()
+	tip = 101
+	mempool = nil
+	mu.Unlock()
+
+	alerts, err = m.Check()
+	require.NoError(t, err)
+	require.Len(t, alerts, 1, "the coinbase is not reported")
+	assert.Equal(t, whaleTx.TxID, alerts[0].TxID)
+	assert.Equal(t, "block101", alerts[0].BlockHash)
+	assert.Equal(t, 101, alerts[0].Height)
+	assert.Equal(t, []LargeTransferOutput{{Vout: 0, Value: 500000000, Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}}, alerts[0].Outputs)
+
+	assert.Equal(t, []string{splitTx.TxID, whaleTx.TxID}, reported)
+}
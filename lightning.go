@@ -0,0 +1,424 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lightning commitment transactions with anchor outputs (option_anchors, BOLT 3).
+const (
+	// AnchorOutputValue is the value of an anchor output in satoshis.
+	AnchorOutputValue = 330
+
+	// anchorInputWeight is the weight of an input spending an anchor with the funding key: outpoint,
+	// sequence and empty scriptSig (41 bytes, 164 WU) plus the witness of item count, a 73 byte signature
+	// and the 40 byte witness script with their lengths.
+	anchorInputWeight = 164 + 1 + 1 + 73 + 1 + 40
+
+	// cpfpFundingRounds bounds how often BumpCommitment refunds the child when its size changes.
+	cpfpFundingRounds = 3
+)
+
+// AnchorScript returns the witness script of the anchor output for a funding pubkey:
+// <funding_pubkey> OP_CHECKSIG OP_IFDUP OP_NOTIF OP_16 OP_CHECKSEQUENCEVERIFY OP_ENDIF
+func AnchorScript(fundingPubKey []byte) []byte {
+	script := append([]byte{byte(len(fundingPubKey))}, fundingPubKey...)
+	return append(script, 0xac, 0x73, 0x64, 0x60, 0xb2, 0x68)
+}
+
+// AnchorOutput is an anchor output of a commitment transaction.
+type AnchorOutput struct {
+	Outpoint
+	ScriptPubKey  string // hex
+	FundingPubKey string // hex, empty when the output was matched by value and type only
+}
+
+// FindAnchorOutputs returns the anchor outputs of a commitment transaction. With funding pubkeys given,
+// only the anchors spendable with one of them are returned; without, every 330 sat P2WSH output is taken
+// for an anchor, which may match other outputs of that value.
+func FindAnchorOutputs(commitmentHex string, fundingPubKeys ...string) ([]AnchorOutput, error) {
+	raw, err := hex.DecodeString(commitmentHex)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := parseTx(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := make(map[string]string, len(fundingPubKeys))
+	for _, key := range fundingPubKeys {
+		pubKey, err := hex.DecodeString(key)
+		if err != nil || len(pubKey) != 33 {
+			return nil, fmt.Errorf("invalid funding pubkey %q", key)
+		}
+
+		hash := sha256.Sum256(AnchorScript(pubKey))
+		scripts[hex.EncodeToString(append([]byte{0x00, 0x20}, hash[:]...))] = strings.ToLower(key)
+	}
+
+	var anchors []AnchorOutput
+	for i, out := range tx.Outputs {
+		if out.Value != AnchorOutputValue || len(out.ScriptPubKey) != 34 || !bytes.HasPrefix(out.ScriptPubKey, []byte{0x00, 0x20}) {
+			continue
+		}
+
+		script := hex.EncodeToString(out.ScriptPubKey)
+		key, found := scripts[script]
+		if len(scripts) > 0 && !found {
+			continue
+		}
+
+		anchors = append(anchors, AnchorOutput{
+			Outpoint:      Outpoint{TxID: tx.TxID, Vout: uint32(i)},
+			ScriptPubKey:  script,
+			FundingPubKey: key,
+		})
+	}
+
+	return anchors, nil
+}
+
+// CommitmentBump describes the CPFP of a commitment transaction through its anchor output.
+type CommitmentBump struct {
+	CommitmentHex string
+	Anchor        AnchorOutput
+	// CommitmentFee is the fee the commitment pays in satoshis, the channel capacity minus its outputs.
+	// It is taken from the mempool when zero.
+	CommitmentFee uint64
+	// ConfTarget is the number of blocks the commitment should confirm in, usually the blocks left until
+	// the earliest HTLC expiry. Fees are estimated in conservative mode, as missing the deadline risks funds.
+	ConfTarget int
+	// FeeRate in sat/vB overrides the estimate when set.
+	FeeRate float64
+}
+
+// CommitmentBumpResult is the child transaction created by BumpCommitment.
+type CommitmentBumpResult struct {
+	// PSBT spends the anchor and wallet outputs to a wallet change address. The wallet inputs are signed;
+	// the anchor input has to be signed with the funding key by the Lightning node before the package is
+	// finalized and submitted with SubmitPackage.
+	PSBT           string
+	ChildFee       uint64  // satoshis
+	ChildVSize     int     // estimated, including the anchor signature
+	PackageFeeRate float64 // sat/vB of commitment and child together
+}
+
+// BumpCommitment funds a child transaction spending the anchor output of a commitment transaction so that
+// commitment and child together pay the target fee rate. The commitment must be in the node's mempool, so
+// that the wallet can look up the anchor, and the node must be v24 or later for the input_weights funding
+// option.
+func (b *Bitcoind) BumpCommitment(req CommitmentBump) (*CommitmentBumpResult, error) {
+	raw, err := hex.DecodeString(req.CommitmentHex)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := parseTx(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Anchor.TxID != parent.TxID {
+		return nil, fmt.Errorf("anchor %s:%d is not an output of commitment %s", req.Anchor.TxID, req.Anchor.Vout, parent.TxID)
+	}
+
+	parentFee := req.CommitmentFee
+	if parentFee == 0 {
+		entry, err := b.GetMempoolEntry(parent.TxID)
+		if err != nil {
+			return nil, fmt.Errorf("commitment fee unknown and not in mempool: %w", err)
+		}
+		parentFee = uint64(math.Round(entry.Fee * 1e8))
+		if entry.Fees != nil {
+			parentFee = uint64(math.Round(entry.Fees.Base * 1e8))
+		}
+	}
+
+	target := req.FeeRate
+	if target == 0 {
+		if target, err = b.commitmentFeeRate(req.ConfTarget); err != nil {
+			return nil, err
+		}
+	}
+
+	parentVSize := parent.vsize()
+
+	changeAddress, err := b.GetNewAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	// The child's size depends on the wallet inputs selected, which depend on the fee rate asked for
+	rate := target
+	var funded *FundedPSBT
+	var childFee uint64
+	var childVSize int
+
+	for round := 0; round < cpfpFundingRounds; round++ {
+		if funded, err = b.fundAnchorSpend(req.Anchor, changeAddress, rate); err != nil {
+			return nil, err
+		}
+
+		childFee = uint64(math.Round(funded.Fee * 1e8))
+		childVSize = int(math.Round(float64(childFee) / rate))
+
+		required := target*float64(parentVSize+childVSize) - float64(parentFee)
+		if float64(childFee) >= required {
+			break
+		}
+
+		rate = math.Ceil(required/float64(childVSize)*1000) / 1000
+	}
+
+	var processed FinalizedPSBT
+	if err := b.uncachedCall("walletprocesspsbt", []interface{}{funded.PSBT, true, "ALL", true, false}, &processed); err != nil {
+		return nil, err
+	}
+
+	return &CommitmentBumpResult{
+		PSBT:           processed.PSBT,
+		ChildFee:       childFee,
+		ChildVSize:     childVSize,
+		PackageFeeRate: float64(parentFee+childFee) / float64(parentVSize+childVSize),
+	}, nil
+}
+
+// commitmentFeeRate returns the conservative fee estimate for confTarget blocks in sat/vB, never below the
+// node's mempool minimum so that the package is relayed.
+func (b *Bitcoind) commitmentFeeRate(confTarget int) (float64, error) {
+	if confTarget < 1 {
+		return 0, errors.New("confirmation target or fee rate required")
+	}
+
+	estimate, err := b.EstimateSmartFee(confTarget, "conservative")
+	if err != nil {
+		return 0, err
+	}
+	if estimate.FeeRate == 0 {
+		return 0, fmt.Errorf("no fee estimate for %d blocks: %s", confTarget, strings.Join(estimate.Errors, ", "))
+	}
+
+	rate := estimate.FeeRate * 1e5
+
+	info, err := b.GetMempoolInfo()
+	if err == nil && info.MemPoolMinFree*1e5 > rate {
+		rate = info.MemPoolMinFree * 1e5
+	}
+
+	return rate, nil
+}
+
+// fundAnchorSpend creates a PSBT spending the anchor together with wallet outputs paying the fee.
+func (b *Bitcoind) fundAnchorSpend(anchor AnchorOutput, changeAddress string, feeRate float64) (*FundedPSBT, error) {
+	inputs := []map[string]interface{}{{"txid": anchor.TxID, "vout": anchor.Vout}}
+	outputs := []map[string]interface{}{{changeAddress: json.Number(fmt.Sprintf("0.%08d", AnchorOutputValue))}}
+	options := map[string]interface{}{
+		"add_inputs":     true,
+		"fee_rate":       feeRate,
+		"input_weights":  []map[string]interface{}{{"txid": anchor.TxID, "vout": anchor.Vout, "weight": anchorInputWeight}},
+		"include_unsafe": false,
+		"replaceable":    true,
+	}
+
+	var funded FundedPSBT
+	if err := b.uncachedCall("walletcreatefundedpsbt", []interface{}{inputs, outputs, 0, options, true}, &funded); err != nil {
+		return nil, err
+	}
+
+	return &funded, nil
+}
+
+// PackageResult is the result of submitpackage.
+type PackageResult struct {
+	Message string                     `json:"package_msg"`
+	Results map[string]PackageTxResult `json:"tx-results"` // by wtxid
+}
+
+// PackageTxResult is the result for one transaction of a package.
+type PackageTxResult struct {
+	TxID  string `json:"txid"`
+	WTxID string `json:"-"` // the key of the result in PackageResult.Results
+	// OtherWTxID is set when the mempool already held the transaction with a different witness, which was
+	// kept instead; the transaction then confirms under this wtxid.
+	OtherWTxID string `json:"other-wtxid,omitempty"`
+	VSize      int    `json:"vsize"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SubmitPackage submits a child with its unconfirmed parents to the node's mempool, evaluating the fee
+// rate of the package as a whole so that a parent below the mempool minimum, such as a commitment, can be
+// bumped by its child. Transactions are ordered parents first. Every transaction is subject to the
+// client's withdrawal policy. Requires bitcoind v26 or later.
+func (b *Bitcoind) SubmitPackage(txHexes []string) (*PackageResult, error) {
+	for _, txHex := range txHexes {
+		if err := b.authorizeTransaction(context.Background(), "submitpackage", txHex); err != nil {
+			return nil, err
+		}
+	}
+
+	var res PackageResult
+	if err := b.uncachedCall("submitpackage", []interface{}{txHexes}, &res); err != nil {
+		return nil, err
+	}
+
+	for wtxid, tx := range res.Results {
+		tx.WTxID = wtxid
+		res.Results[wtxid] = tx
+	}
+
+	if res.Message != "success" {
+		var reasons []string
+		for _, tx := range res.Results {
+			if tx.Error != "" {
+				reasons = append(reasons, tx.TxID+": "+tx.Error)
+			}
+		}
+		return &res, fmt.Errorf("package rejected: %s %s", res.Message, strings.Join(reasons, ", "))
+	}
+
+	return &res, nil
+}
+
+// ChannelTxKind tells what a watched channel transaction is.
+type ChannelTxKind int
+
+// Kinds of channel transactions.
+const (
+	ChannelFunding ChannelTxKind = iota
+	ChannelClosing
+)
+
+func (k ChannelTxKind) String() string {
+	if k == ChannelClosing {
+		return "closing"
+	}
+	return "funding"
+}
+
+// ChannelTxEvent reports a change of the confirmation state of a watched channel transaction.
+type ChannelTxEvent struct {
+	ChannelID string
+	TxID      string
+	Kind      ChannelTxKind
+	Status    TxConfirmations
+	Previous  TxConfirmations
+}
+
+// Reorged reports whether the transaction left the chain since the previous check.
+func (e ChannelTxEvent) Reorged() bool {
+	return e.Previous.BlockHash != "" && e.Status.BlockHash != e.Previous.BlockHash
+}
+
+type channelTx struct {
+	channelID string
+	kind      ChannelTxKind
+	minConf   int
+	status    TxConfirmations
+}
+
+// ChannelMonitor watches the funding and closing transactions of Lightning channels, reporting when they
+// are seen, reach their required depth, or are reorged out. It uses ConfirmationsForTxids, so all
+// channels are checked with a few batched calls.
+type ChannelMonitor struct {
+	// OnChange is called for every change found by Check.
+	OnChange func(ChannelTxEvent)
+
+	b   *Bitcoind
+	mu  sync.Mutex
+	txs map[string]*channelTx
+}
+
+// NewChannelMonitor returns a ChannelMonitor for the given node.
+func NewChannelMonitor(b *Bitcoind) *ChannelMonitor {
+	return &ChannelMonitor{b: b, txs: make(map[string]*channelTx)}
+}
+
+// Add watches a channel transaction until it is removed. minConf is the depth at which it counts as
+// confirmed, e.g. the minimum_depth negotiated for a funding transaction.
+func (m *ChannelMonitor) Add(channelID, txid string, kind ChannelTxKind, minConf int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.txs[txid] = &channelTx{channelID: channelID, kind: kind, minConf: minConf}
+}
+
+// Remove stops watching a transaction.
+func (m *ChannelMonitor) Remove(txid string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.txs, txid)
+}
+
+// Check looks up every watched transaction and returns the changes since the previous check: a transaction
+// appearing or disappearing, entering or leaving a block, or reaching its required depth.
+func (m *ChannelMonitor) Check() ([]ChannelTxEvent, error) {
+	m.mu.Lock()
+	byDepth := make(map[int][]string)
+	for txid, tx := range m.txs {
+		byDepth[tx.minConf] = append(byDepth[tx.minConf], txid)
+	}
+	m.mu.Unlock()
+
+	var events []ChannelTxEvent
+
+	for minConf, txids := range byDepth {
+		statuses, err := m.b.ConfirmationsForTxids(txids, minConf)
+		if err != nil {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		for txid, status := range statuses {
+			tx, found := m.txs[txid]
+			if !found {
+				continue
+			}
+
+			prev := tx.status
+			tx.status = status
+
+			if status.Found != prev.Found || status.BlockHash != prev.BlockHash || status.Confirmed != prev.Confirmed {
+				events = append(events, ChannelTxEvent{ChannelID: tx.channelID, TxID: txid, Kind: tx.kind, Status: status, Previous: prev})
+			}
+		}
+		m.mu.Unlock()
+	}
+
+	if m.OnChange != nil {
+		for _, e := range events {
+			m.OnChange(e)
+		}
+	}
+
+	return events, nil
+}
+
+// Run checks every interval until the context is done.
+func (m *ChannelMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := m.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Check(); err != nil {
+			m.b.client.logger.Errorf("ChannelMonitor: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
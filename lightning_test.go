@@ -0,0 +1,222 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFundingPubKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+// testCommitmentTx serializes a transaction with one input and the given outputs.
+func testCommitmentTx(outputs ...parsedOutput) string {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(2))
+	buf.Write(cryptolib.VarInt(1))
+	buf.Write(bytes.Repeat([]byte{0x11}, 32))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(0))
+	buf.Write(cryptolib.VarInt(0))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(0x80000000))
+
+	buf.Write(cryptolib.VarInt(uint64(len(outputs))))
+	for _, out := range outputs {
+		_ = binary.Write(&buf, binary.LittleEndian, out.Value)
+		buf.Write(cryptolib.VarInt(uint64(len(out.ScriptPubKey))))
+		buf.Write(out.ScriptPubKey)
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(0x20000000))
+
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func anchorScriptPubKey(pubKeyHex string) []byte {
+	pubKey, _ := hex.DecodeString(pubKeyHex)
+	hash := sha256.Sum256(AnchorScript(pubKey))
+	return append([]byte{0x00, 0x20}, hash[:]...)
+}
+
+func TestFindAnchorOutputs(t *testing.T) {
+	otherKey := "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+
+	txHex := testCommitmentTx(
+		parsedOutput{Value: 500000, ScriptPubKey: append([]byte{0x00, 0x14}, make([]byte, 20)...)},
+		parsedOutput{Value: AnchorOutputValue, ScriptPubKey: anchorScriptPubKey(testFundingPubKey)},
+		parsedOutput{Value: AnchorOutputValue, ScriptPubKey: anchorScriptPubKey(otherKey)},
+	)
+
+	pubKey, _ := hex.DecodeString(testFundingPubKey)
+	assert.Equal(t, "21"+testFundingPubKey+"ac736460b268", hex.EncodeToString(AnchorScript(pubKey)))
+
+	anchors, err := FindAnchorOutputs(txHex)
+	require.NoError(t, err)
+	require.Len(t, anchors, 2)
+	assert.Equal(t, uint32(1), anchors[0].Vout)
+	assert.Equal(t, "", anchors[0].FundingPubKey)
+
+	anchors, err = FindAnchorOutputs(txHex, testFundingPubKey)
+	require.NoError(t, err)
+	require.Len(t, anchors, 1)
+	assert.Equal(t, uint32(1), anchors[0].Vout)
+	assert.Equal(t, testFundingPubKey, anchors[0].FundingPubKey)
+
+	_, err = FindAnchorOutputs(txHex, "02abcd")
+	assert.Error(t, err)
+}
+
+func TestParsedTxVSize(t *testing.T) {
+	raw, _ := hex.DecodeString(testCommitmentTx(parsedOutput{Value: 1, ScriptPubKey: []byte{0x51}}))
+	tx, err := parseTx(raw)
+	require.NoError(t, err)
+	assert.Equal(t, len(raw), tx.vsize())
+
+	// The same transaction with a witness of one 72 byte item: 2 marker and flag bytes, 1 item count,
+	// 1 length byte and the item add 76 WU, 19 vbytes
+	tx.Inputs[0].Witness = [][]byte{make([]byte, 72)}
+	tx.Raw = make([]byte, len(raw)+76)
+	assert.Equal(t, len(raw)+19, tx.vsize())
+}
+
+func TestBumpCommitment(t *testing.T) {
+	txHex := testCommitmentTx(
+		parsedOutput{Value: 500000, ScriptPubKey: append([]byte{0x00, 0x14}, make([]byte, 20)...)},
+		parsedOutput{Value: AnchorOutputValue, ScriptPubKey: anchorScriptPubKey(testFundingPubKey)},
+	)
+	anchors, err := FindAnchorOutputs(txHex, testFundingPubKey)
+	require.NoError(t, err)
+
+	// A child of 150 vbytes, growing by an input once the fee rate passes 30 sat/vB
+	var rates []float64
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params, _ := req.Params.([]interface{})
+
+		switch req.Method {
+		case "getnewaddress":
+			_, _ = w.Write([]byte(`{"result":"bcrt1qchange","error":null,"id":1}`))
+		case "walletcreatefundedpsbt":
+			options := params[3].(map[string]interface{})
+			rate := options["fee_rate"].(float64)
+			rates = append(rates, rate)
+
+			vsize := 150.0
+			if rate > 30 {
+				vsize = 218
+			}
+			fmt.Fprintf(w, `{"result":{"psbt":"unsigned","fee":%.8f,"changepos":1},"error":null,"id":1}`, rate*vsize/1e8)
+		case "walletprocesspsbt":
+			_, _ = w.Write([]byte(`{"result":{"psbt":"signed","complete":false},"error":null,"id":1}`))
+		}
+	})
+	defer done()
+
+	raw, _ := hex.DecodeString(txHex)
+	parent, _ := parseTx(raw)
+	parentVSize := parent.vsize()
+
+	res, err := b.BumpCommitment(CommitmentBump{CommitmentHex: txHex, Anchor: anchors[0], CommitmentFee: uint64(parentVSize), FeeRate: 20})
+	require.NoError(t, err)
+
+	assert.Equal(t, "signed", res.PSBT)
+	assert.GreaterOrEqual(t, res.PackageFeeRate, 20.0)
+	assert.Less(t, res.PackageFeeRate, 25.0) // overpaid as the child grew
+	require.Len(t, rates, 2)
+	assert.Equal(t, 20.0, rates[0])
+	assert.Greater(t, rates[1], 30.0)
+	assert.Equal(t, 218, res.ChildVSize)
+
+	_, err = b.BumpCommitment(CommitmentBump{CommitmentHex: txHex, Anchor: AnchorOutput{Outpoint: Outpoint{TxID: "other"}}, FeeRate: 20})
+	assert.Error(t, err)
+}
+
+func TestChannelMonitor(t *testing.T) {
+	var mined int32
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var reqs []rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			_, _ = w.Write([]byte(`{"result":{"blocks":102},"error":null,"id":1}`))
+			return
+		}
+
+		var parts []string
+		for _, req := range reqs {
+			result := `{"result":{},"error":null`
+			switch {
+			case req.Method == "getblockheader":
+				result = `{"result":{"height":100},"error":null`
+			case atomic.LoadInt32(&mined) == 1:
+				result = `{"result":{"blockhash":"block100"},"error":null`
+			}
+			parts = append(parts, fmt.Sprintf(`%s,"id":%d}`, result, req.ID))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(parts, ",") + "]"))
+	})
+	defer done()
+
+	m := NewChannelMonitor(b)
+	var changes []ChannelTxEvent
+	m.OnChange = func(e ChannelTxEvent) { changes = append(changes, e) }
+
+	m.Add("chan1", "funding", ChannelFunding, 3)
+
+	events, err := m.Check()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Status.Found)
+	assert.False(t, events[0].Status.Confirmed)
+
+	events, err = m.Check()
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	atomic.StoreInt32(&mined, 1)
+	events, err = m.Check()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "chan1", events[0].ChannelID)
+	assert.True(t, events[0].Status.Confirmed)
+	assert.Equal(t, 3, events[0].Status.Confirmations)
+
+	atomic.StoreInt32(&mined, 0)
+	events, err = m.Check()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Reorged())
+
+	assert.Len(t, changes, 3)
+
+	m.Remove("funding")
+	events, err = m.Check()
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestSubmitPackage(t *testing.T) {
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":{"package_msg":"success","tx-results":{
+			"w1":{"txid":"t1","other-wtxid":"w0","vsize":150},
+			"w2":{"txid":"t2","vsize":120}
+		}},"error":null,"id":1}`))
+	})
+	defer done()
+
+	txHex := testCommitmentTx(parsedOutput{Value: 1000, ScriptPubKey: []byte{0x51}})
+
+	res, err := b.SubmitPackage([]string{txHex, txHex})
+	require.NoError(t, err)
+
+	assert.Equal(t, PackageTxResult{TxID: "t1", WTxID: "w1", OtherWTxID: "w0", VSize: 150}, res.Results["w1"])
+	assert.Equal(t, "w2", res.Results["w2"].WTxID)
+}
@@ -1,6 +1,7 @@
 package bitcoin
 
 import (
+	"context"
 	"fmt"
 	"log"
 )
@@ -18,6 +19,22 @@ type Logger interface {
 	Fatalf(format string, args ...interface{})
 }
 
+// ContextLogger is a Logger that adds the request-scoped values of a context, such as trace or tenant IDs, to
+// its messages. The client logs the messages about a call to the logger WithContext returns for the context
+// of the call.
+type ContextLogger interface {
+	Logger
+	WithContext(ctx context.Context) Logger
+}
+
+// loggerFor returns the logger for messages about a call made with ctx.
+func (c *rpcClient) loggerFor(ctx context.Context) Logger {
+	if l, ok := c.logger.(ContextLogger); ok && ctx != nil {
+		return l.WithContext(ctx)
+	}
+	return c.logger
+}
+
 type DefaultLogger struct{}
 
 func (l *DefaultLogger) Debugf(format string, args ...interface{}) {
@@ -0,0 +1,83 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// longPollTimeout bounds a single long poll. Nodes answer once the tip changes, or after a minute when
+// the mempool changed.
+const longPollTimeout = 30 * time.Minute
+
+// TemplateLongPoll follows the node's block templates with getblocktemplate long polling (BIP 22), so that
+// pools learn of a new template without polling on a timer.
+type TemplateLongPoll struct {
+	// OnTemplate is called with the current template and then with every new one.
+	OnTemplate func(*BlockTemplate)
+	// Reconnect is how failed polls are retried. After a failure the current template is fetched straight
+	// away, so the first template passed to OnTemplate after OnReconnect replaces any missed ones.
+	Reconnect ReconnectPolicy
+
+	b *Bitcoind
+}
+
+// NewTemplateLongPoll returns a long poll of the templates of b, retrying with DefaultReconnectPolicy.
+func NewTemplateLongPoll(b *Bitcoind) *TemplateLongPoll {
+	return &TemplateLongPoll{Reconnect: DefaultReconnectPolicy, b: b}
+}
+
+// Run polls until the context is done or Reconnect gives up, and returns why it stopped.
+func (p *TemplateLongPoll) Run(ctx context.Context) error {
+	c := p.b.client.derive(WithTimeoutDuration(longPollTimeout), WithTimeoutProfile(TimeoutProfile{}))
+	r := newReconnector(p.Reconnect, c.clock)
+
+	var longPollID string
+	for {
+		template, err := p.poll(ctx, c, longPollID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			c.logger.Errorf("TemplateLongPoll: %v", err)
+			longPollID = ""
+			if err := r.failed(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		r.succeeded()
+
+		if p.OnTemplate != nil {
+			p.OnTemplate(template)
+		}
+
+		if template.LongPollID == "" {
+			return errors.New("the node does not support long polling")
+		}
+		longPollID = template.LongPollID
+	}
+}
+
+func (p *TemplateLongPoll) poll(ctx context.Context, c *rpcClient, longPollID string) (*BlockTemplate, error) {
+	params := gbtParams{Rules: []string{"segwit"}, LongPollID: longPollID}
+
+	r, err := c.callContext(ctx, "getblocktemplate", []interface{}{params})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Err != nil {
+		return nil, responseError(r.Err)
+	}
+
+	var template BlockTemplate
+	if err := json.Unmarshal(r.Result, &template); err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
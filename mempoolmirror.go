@@ -0,0 +1,210 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+)
+
+// MempoolMirror keeps an in-memory replica of a node's mempool, fed by the sequence ZMQ topic, so that
+// membership and fee rate queries can be answered locally instead of with an RPC call each.
+type MempoolMirror struct {
+	b      *Bitcoind
+	stream *SequenceStream
+
+	mu       sync.RWMutex
+	feeRates map[string]float64 // txid -> sat/vB
+	wtxids   map[string]string  // txid -> wtxid
+	txids    map[string]string  // wtxid -> txid
+}
+
+// NewMempoolMirror returns an empty mirror of the given node's mempool. It is populated once Follow is called
+// and the first sequence message arrives, or straight away by calling Resync.
+func NewMempoolMirror(b *Bitcoind) *MempoolMirror {
+	m := &MempoolMirror{
+		b:        b,
+		stream:   NewSequenceStream(b),
+		feeRates: make(map[string]float64),
+		wtxids:   make(map[string]string),
+		txids:    make(map[string]string),
+	}
+
+	m.stream.OnResync = m.onResync
+	m.stream.OnEvent = m.onEvent
+
+	return m
+}
+
+// Follow subscribes to the sequence topic of the given ZMQ connection and applies every event to the mirror.
+func (m *MempoolMirror) Follow(zmq *ZMQ) error {
+	return m.stream.Follow(zmq)
+}
+
+// Resync rebuilds the mirror from the node's mempool.
+func (m *MempoolMirror) Resync() error {
+	return m.stream.Resync()
+}
+
+// Contains reports whether the transaction is in the mempool.
+func (m *MempoolMirror) Contains(txid string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, found := m.feeRates[txid]
+	return found
+}
+
+// WTxID returns the wtxid of a mempool transaction, which tells which of the variants of a transaction
+// differing only in their witness the mempool holds. Nodes before v0.21 do not report wtxids.
+func (m *MempoolMirror) WTxID(txid string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wtxid, found := m.wtxids[txid]
+	return wtxid, found
+}
+
+// ContainsWTxID reports whether the mempool holds a transaction with exactly this witness and returns its
+// txid.
+func (m *MempoolMirror) ContainsWTxID(wtxid string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	txid, found := m.txids[wtxid]
+	return txid, found
+}
+
+// FeeRate returns the fee rate of a mempool transaction in satoshis per virtual byte.
+func (m *MempoolMirror) FeeRate(txid string) (float64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rate, found := m.feeRates[txid]
+	return rate, found
+}
+
+// Size returns the number of transactions in the mempool.
+func (m *MempoolMirror) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.feeRates)
+}
+
+// FeeRatePercentile returns the fee rate, in satoshis per virtual byte, below which the given percentage
+// (0 to 100) of mempool transactions fall. It returns 0 when the mempool is empty.
+func (m *MempoolMirror) FeeRatePercentile(p float64) float64 {
+	m.mu.RLock()
+	rates := make([]float64, 0, len(m.feeRates))
+	for _, rate := range m.feeRates {
+		rates = append(rates, rate)
+	}
+	m.mu.RUnlock()
+
+	if len(rates) == 0 {
+		return 0
+	}
+
+	sort.Float64s(rates)
+
+	i := int(math.Ceil(p/100*float64(len(rates)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(rates) {
+		i = len(rates) - 1
+	}
+
+	return rates[i]
+}
+
+func (m *MempoolMirror) onResync(txids []string, _ uint64) {
+	// getrawmempool cannot return entries and the sequence number in one call, so the fee rates are fetched
+	// separately. Transactions that left the mempool in between are dropped, they will be followed by an R event.
+	r, err := m.b.client.call("getrawmempool", []interface{}{true})
+	if err == nil && r.Err != nil {
+		err = responseError(r.Err)
+	}
+
+	var entries map[string]MempoolEntry
+	if err == nil {
+		err = json.Unmarshal(r.Result, &entries)
+	}
+
+	if err != nil {
+		m.b.client.logger.Errorf("MempoolMirror: could not fetch mempool entries: %v", err)
+	}
+
+	feeRates := make(map[string]float64, len(txids))
+	wtxids := make(map[string]string, len(txids))
+	byWTxID := make(map[string]string, len(txids))
+	for _, txid := range txids {
+		if entry, found := entries[txid]; found {
+			feeRates[txid] = entry.FeeRate()
+			if entry.WTxID != "" {
+				wtxids[txid] = entry.WTxID
+				byWTxID[entry.WTxID] = txid
+			}
+		}
+	}
+
+	m.mu.Lock()
+	m.feeRates = feeRates
+	m.wtxids = wtxids
+	m.txids = byWTxID
+	m.mu.Unlock()
+}
+
+func (m *MempoolMirror) onEvent(e SequenceEvent) {
+	switch e.Label {
+	case SequenceTxAdded:
+		entry, err := m.b.GetMempoolEntry(e.Hash)
+		if err != nil {
+			// Most likely already evicted or mined again, which will be reflected by a later event
+			m.b.client.logger.Debugf("MempoolMirror: could not fetch mempool entry %s: %v", e.Hash, err)
+			return
+		}
+
+		m.mu.Lock()
+		m.feeRates[e.Hash] = entry.FeeRate()
+		if entry.WTxID != "" {
+			m.wtxids[e.Hash] = entry.WTxID
+			m.txids[entry.WTxID] = e.Hash
+		}
+		m.mu.Unlock()
+
+	case SequenceTxRemoved:
+		m.mu.Lock()
+		m.remove(e.Hash)
+		m.mu.Unlock()
+
+	case SequenceBlockConnected:
+		// Transactions mined in the block are not announced as removed, so remove them here
+		raw, err := m.b.GetRawBlock(e.Hash)
+		if err == nil {
+			var block *parsedBlock
+			if block, err = parseBlock(raw); err == nil {
+				m.mu.Lock()
+				for _, tx := range block.Txs {
+					m.remove(tx.TxID)
+				}
+				m.mu.Unlock()
+				return
+			}
+		}
+
+		// Called with the stream locked, so marking it out of sync makes the next message resync the mirror
+		m.b.client.logger.Errorf("MempoolMirror: could not apply block %s, resyncing: %v", e.Hash, err)
+		m.stream.synced = false
+	}
+}
+
+// remove drops a transaction from the mirror, which has to be locked.
+func (m *MempoolMirror) remove(txid string) {
+	delete(m.feeRates, txid)
+	if wtxid, found := m.wtxids[txid]; found {
+		delete(m.txids, wtxid)
+		delete(m.wtxids, txid)
+	}
+}
@@ -0,0 +1,76 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMempoolMirror(t *testing.T) {
+	txA := strings.Repeat("aa", 32)
+	txB := strings.Repeat("bb", 32)
+	txC := strings.Repeat("cc", 32)
+	wtxA := strings.Repeat("a1", 32)
+	wtxC := strings.Repeat("c1", 32)
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var result string
+		switch {
+		case req.Method == "getrawmempool" && fmt.Sprint(req.Params) == "[false true]":
+			result = `{"txids":["` + txA + `","` + txB + `"],"mempool_sequence":10}`
+		case req.Method == "getrawmempool":
+			// txB left the mempool between the two calls
+			result = `{"` + txA + `":{"vsize":100,"wtxid":"` + wtxA + `","fees":{"base":0.00001}}}`
+		case req.Method == "getmempoolentry":
+			result = `{"vsize":200,"wtxid":"` + wtxC + `","fees":{"base":0.00004}}`
+		default:
+			t.Errorf("unexpected call %s", req.Method)
+		}
+
+		_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+	})
+	defer done()
+
+	m := NewMempoolMirror(b)
+
+	if err := m.stream.Handle(sequenceMessage(txC, SequenceTxAdded, 11, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Size() != 2 || !m.Contains(txA) || m.Contains(txB) || !m.Contains(txC) {
+		t.Fatalf("unexpected mirror %v", m.feeRates)
+	}
+
+	if rate, _ := m.FeeRate(txC); rate != 20 {
+		t.Errorf("expected fee rate 20, got %v", rate)
+	}
+
+	if p := m.FeeRatePercentile(50); p != 10 {
+		t.Errorf("expected median 10, got %v", p)
+	}
+
+	if wtxid, _ := m.WTxID(txC); wtxid != wtxC {
+		t.Errorf("expected wtxid %s, got %s", wtxC, wtxid)
+	}
+
+	if txid, found := m.ContainsWTxID(wtxA); !found || txid != txA {
+		t.Errorf("expected %s to be found by wtxid", txA)
+	}
+
+	if err := m.stream.Handle(sequenceMessage(txA, SequenceTxRemoved, 12, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Contains(txA) || m.Size() != 1 {
+		t.Errorf("expected %s to be removed", txA)
+	}
+
+	if _, found := m.ContainsWTxID(wtxA); found {
+		t.Errorf("expected %s to be removed by wtxid", wtxA)
+	}
+}
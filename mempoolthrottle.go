@@ -0,0 +1,150 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PendingBroadcast is a transaction to submit through a BroadcastThrottle.
+type PendingBroadcast struct {
+	ID  string // caller's reference, reported back in the ThrottledBroadcast
+	Hex string
+	Fee uint64 // satoshis, for the fee rate of the transaction
+}
+
+// ThrottledBroadcast reports what a BroadcastThrottle did with a transaction: submitted it, successfully
+// with TxID or not with Err, or deferred it with the reason in Deferred. Deferred transactions are to be
+// submitted again later, or fee bumped.
+type ThrottledBroadcast struct {
+	PendingBroadcast
+	TxID     string
+	Deferred string
+	Err      error
+}
+
+// BroadcastThrottle submits batches of transactions while watching the node's mempool with getmempoolinfo.
+// Transactions paying less than the mempool's minimum fee are deferred as they would be rejected, and once
+// the mempool usage reaches HighWater of its maximum so are transactions paying less than FeeMultiple
+// times the minimum fee, as they would be the first evicted when it fills up. It is safe for concurrent use.
+type BroadcastThrottle struct {
+	HighWater   float64       // fraction of maxmempool
+	FeeMultiple float64       // of the mempool's minimum fee
+	MaxAge      time.Duration // how long a getmempoolinfo result is used
+
+	b       *Bitcoind
+	clock   Clock
+	mu      sync.Mutex
+	info    MempoolInfo
+	fetched time.Time
+}
+
+// NewBroadcastThrottle returns a throttle deferring transactions paying less than twice the minimum fee
+// once the mempool is 80% full, reading the mempool state at most every 10 seconds.
+func NewBroadcastThrottle(b *Bitcoind) *BroadcastThrottle {
+	return &BroadcastThrottle{
+		HighWater:   0.8,
+		FeeMultiple: 2,
+		MaxAge:      10 * time.Second,
+		b:           b,
+		clock:       b.client.clock,
+	}
+}
+
+// mempoolInfo returns the mempool state, read again once it is older than MaxAge.
+func (t *BroadcastThrottle) mempoolInfo() (MempoolInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	if !t.fetched.IsZero() && now.Sub(t.fetched) < t.MaxAge {
+		return t.info, nil
+	}
+
+	var info MempoolInfo
+	if err := t.b.uncachedCall("getmempoolinfo", nil, &info); err != nil {
+		return MempoolInfo{}, fmt.Errorf("failed to get mempool info: %w", err)
+	}
+
+	t.info, t.fetched = info, now
+	return info, nil
+}
+
+// Check returns why a transaction paying feeRate sat/vB would be deferred at the moment, or an empty string
+// if it would be submitted.
+func (t *BroadcastThrottle) Check(feeRate float64) (string, error) {
+	info, err := t.mempoolInfo()
+	if err != nil {
+		return "", err
+	}
+
+	// Fees are reported in BTC/kvB
+	minFee := info.MemPoolMinFree
+	if info.MinRelayTxFee > minFee {
+		minFee = info.MinRelayTxFee
+	}
+	minFeeRate := minFee * 1e5
+
+	if feeRate < minFeeRate {
+		return fmt.Sprintf("fee rate %.2f sat/vB is below the mempool minimum of %.2f sat/vB", feeRate, minFeeRate), nil
+	}
+
+	if info.MaxMemPool > 0 {
+		usage := float64(info.Usage) / float64(info.MaxMemPool)
+		if usage >= t.HighWater && feeRate < minFeeRate*t.FeeMultiple {
+			return fmt.Sprintf("mempool is %.0f%% full and fee rate %.2f sat/vB is below %.2f sat/vB", usage*100, feeRate,
+				minFeeRate*t.FeeMultiple), nil
+		}
+	}
+
+	return "", nil
+}
+
+// Broadcast submits the transactions in order, deferring those the mempool is not expected to keep along with
+// their descendants in the batch. It only fails if the mempool state cannot be read, errors of single
+// transactions are reported in their ThrottledBroadcast.
+func (t *BroadcastThrottle) Broadcast(txs []PendingBroadcast) ([]ThrottledBroadcast, error) {
+	results := make([]ThrottledBroadcast, len(txs))
+	deferred := make(map[string]bool)
+
+	for i, pending := range txs {
+		result := &results[i]
+		result.PendingBroadcast = pending
+
+		raw, err := hex.DecodeString(pending.Hex)
+		if err != nil {
+			result.Err = fmt.Errorf("invalid transaction hex: %w", err)
+			continue
+		}
+
+		tx, err := parseTx(raw)
+		if err != nil {
+			result.Err = err
+			continue
+		}
+
+		for _, in := range tx.Inputs {
+			if deferred[in.PrevTxID] {
+				result.Deferred = "spends deferred transaction " + in.PrevTxID
+				break
+			}
+		}
+
+		if result.Deferred == "" {
+			result.Deferred, err = t.Check(float64(pending.Fee) / float64(tx.vsize()))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if result.Deferred != "" {
+			deferred[tx.TxID] = true
+			continue
+		}
+
+		result.TxID, result.Err = t.b.broadcast(pending.Hex)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,82 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcastThrottle(t *testing.T) {
+	usage := 90
+	var infoCalls int
+	var sent []string
+	clock := NewFakeClock(time.Now())
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "getmempoolinfo":
+			infoCalls++
+			fmt.Fprintf(w, `{"result":{"usage":%d,"maxmempool":100,"mempoolminfee":0.00001,"minrelaytxfee":0.00001},"error":null,"id":1}`, usage)
+		case "sendrawtransaction":
+			txid, _, _ := TransactionIDs(req.Params[0].(string))
+			sent = append(sent, txid)
+			fmt.Fprintf(w, `{"result":"%s","error":null,"id":1}`, txid)
+		}
+	}, WithClock(clock))
+	defer closeServer()
+
+	throttle := NewBroadcastThrottle(b)
+
+	script := "0014" + strings.Repeat("11", 20)
+	high := hex.EncodeToString(testTx([]Outpoint{{TxID: strings.Repeat("aa", 32)}}, script, 1000))
+	low := hex.EncodeToString(testTx([]Outpoint{{TxID: strings.Repeat("bb", 32)}}, script, 1000))
+	lowID, _, _ := TransactionIDs(low)
+	child := hex.EncodeToString(testTx([]Outpoint{{TxID: lowID}}, script, 500))
+	tooLow := hex.EncodeToString(testTx([]Outpoint{{TxID: strings.Repeat("cc", 32)}}, script, 1000))
+
+	// The transactions are 82 vbytes, the minimum fee rate is 1 sat/vB
+	results, err := throttle.Broadcast([]PendingBroadcast{
+		{ID: "high", Hex: high, Fee: 1000},
+		{ID: "low", Hex: low, Fee: 100},
+		{ID: "child", Hex: child, Fee: 1000},
+		{ID: "toolow", Hex: tooLow, Fee: 10},
+		{ID: "invalid", Hex: "zz"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	highID, _, _ := TransactionIDs(high)
+	assert.Equal(t, highID, results[0].TxID)
+	assert.NoError(t, results[0].Err)
+	assert.Empty(t, results[0].Deferred)
+
+	assert.Equal(t, "mempool is 90% full and fee rate 1.22 sat/vB is below 2.00 sat/vB", results[1].Deferred)
+	assert.Equal(t, "spends deferred transaction "+lowID, results[2].Deferred)
+	assert.Equal(t, "fee rate 0.12 sat/vB is below the mempool minimum of 1.00 sat/vB", results[3].Deferred)
+	assert.Error(t, results[4].Err)
+
+	assert.Equal(t, []string{highID}, sent)
+	assert.Equal(t, 1, infoCalls)
+
+	// Once the mempool drained and the state is read again, the deferred transactions are submitted
+	usage = 50
+	clock.Advance(10 * time.Second)
+	results, err = throttle.Broadcast([]PendingBroadcast{{Hex: low, Fee: 100}, {Hex: child, Fee: 1000}})
+	require.NoError(t, err)
+	assert.Empty(t, results[0].Deferred)
+	assert.Empty(t, results[1].Deferred)
+	assert.Equal(t, 2, infoCalls)
+	assert.Len(t, sent, 3)
+}
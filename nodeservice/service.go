@@ -0,0 +1,375 @@
+// Package nodeservice is a curated subset of the client API for exposing a node to services written in
+// other languages. Requests are validated before they reach the node, and only read calls and broadcasting,
+// subject to the client's withdrawal policy, are offered.
+//
+// The package is transport agnostic. Service works on plain request and response structs and returns errors
+// that Code classifies, so that a server of any RPC framework only has to copy fields and map the codes to
+// its status codes. The grpcserver module is such a server.
+package nodeservice
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+// MaxTransactionHexLen bounds the transactions accepted by Broadcast, twice the maximum standard size.
+const MaxTransactionHexLen = 2 * 400000
+
+// Errors returned by Service, wrapped with details. Code classifies them.
+var (
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrNotFound         = errors.New("not found")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrUnavailable      = errors.New("unavailable")
+)
+
+// Code returns the class of an error returned by Service: OK, InvalidArgument, NotFound, PermissionDenied,
+// Unavailable, Canceled, DeadlineExceeded or Internal, named like the status codes of common RPC frameworks.
+func Code(err error) string {
+	switch {
+	case err == nil:
+		return "OK"
+	case errors.Is(err, ErrInvalidArgument):
+		return "InvalidArgument"
+	case errors.Is(err, ErrNotFound):
+		return "NotFound"
+	case errors.Is(err, ErrPermissionDenied), errors.Is(err, bitcoin.ErrWithdrawalRejected):
+		return "PermissionDenied"
+	case errors.Is(err, ErrUnavailable):
+		return "Unavailable"
+	case errors.Is(err, context.Canceled):
+		return "Canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "DeadlineExceeded"
+	}
+	return "Internal"
+}
+
+// ChainInfo is the state of the node's chain.
+type ChainInfo struct {
+	Chain                string
+	Blocks               int64
+	Headers              int64
+	BestBlockHash        string
+	MedianTime           int64
+	VerificationProgress float64
+}
+
+// GetBlockRequest selects a block of the active chain.
+type GetBlockRequest struct {
+	Hash   string
+	Height int64 // used when Hash is empty
+}
+
+// Block is a block of the active chain with its txids.
+type Block struct {
+	Hash              string
+	Height            int64
+	PreviousBlockHash string
+	Time              int64
+	MedianTime        int64
+	MerkleRoot        string
+	Confirmations     int64
+	Txids             []string
+}
+
+// Transaction is a raw transaction with the block it is in, if any.
+type Transaction struct {
+	Txid          string
+	Hex           string
+	BlockHash     string
+	Confirmations int64
+}
+
+// EstimateFeeRequest asks for the fee rate confirming within ConfTarget blocks in the estimate mode Mode.
+type EstimateFeeRequest struct {
+	ConfTarget int32
+	Mode       string
+}
+
+// FeeEstimate is a fee rate and the number of blocks it is estimated for.
+type FeeEstimate struct {
+	SatPerVbyte float64
+	Blocks      int32
+}
+
+// SubscribeRequest selects the announcements Subscribe sends.
+type SubscribeRequest struct {
+	Blocks       bool
+	Transactions bool
+}
+
+// Event kinds, matching Event.Kind.
+const (
+	EventBlock       = 1
+	EventTransaction = 2
+)
+
+// Event announces a block or transaction.
+type Event struct {
+	Kind int32
+	Hash string
+}
+
+// Notifier is the part of *bitcoin.ZMQ Subscribe needs, also implemented by *bitcoin.NotifyListener.
+type Notifier interface {
+	Subscribe(topic string, ch chan []string) error
+	Unsubscribe(topic string, ch chan []string) error
+}
+
+// Service implements the service on top of a client.
+type Service struct {
+	b      *bitcoin.Bitcoind
+	events Notifier
+}
+
+// NewService returns a Service using the given client. events may be nil, Subscribe then reports the
+// service as unavailable.
+func NewService(b *bitcoin.Bitcoind, events Notifier) *Service {
+	return &Service{b: b, events: events}
+}
+
+// GetChainInfo returns the state of the node's chain.
+func (s *Service) GetChainInfo(ctx context.Context) (*ChainInfo, error) {
+	info, err := s.b.GetBlockchainInfo()
+	if err != nil {
+		return nil, s.translate(err)
+	}
+
+	return &ChainInfo{
+		Chain:                info.Chain,
+		Blocks:               int64(info.Blocks),
+		Headers:              int64(info.Headers),
+		BestBlockHash:        info.BestBlockHash,
+		MedianTime:           info.MedianTime,
+		VerificationProgress: info.VerificationProgress,
+	}, nil
+}
+
+// GetBlock returns a block of the active chain by hash or height.
+func (s *Service) GetBlock(ctx context.Context, req *GetBlockRequest) (*Block, error) {
+	hash := req.Hash
+	if hash == "" {
+		if req.Height < 0 || req.Height > math.MaxInt32 {
+			return nil, fmt.Errorf("%w: height %d", ErrInvalidArgument, req.Height)
+		}
+
+		var err error
+		if hash, err = s.b.GetBlockHash(int(req.Height)); err != nil {
+			return nil, s.translate(err)
+		}
+	} else if err := validateHash(hash); err != nil {
+		return nil, err
+	}
+
+	result, err := s.b.RawCall("getblock", hash, 1)
+	if err != nil {
+		return nil, s.translate(err)
+	}
+
+	var block bitcoin.Block
+	if err := json.Unmarshal(result, &block); err != nil {
+		return nil, err
+	}
+
+	return &Block{
+		Hash:              block.Hash,
+		Height:            int64(block.Height),
+		PreviousBlockHash: block.PreviousBlockHash,
+		Time:              int64(block.Time),
+		MedianTime:        int64(block.MedianTime),
+		MerkleRoot:        block.MerkleRoot,
+		Confirmations:     block.Confirmations,
+		Txids:             block.Tx,
+	}, nil
+}
+
+// GetTransaction returns a transaction from the mempool or, with -txindex, from the chain.
+func (s *Service) GetTransaction(ctx context.Context, txid string) (*Transaction, error) {
+	if err := validateHash(txid); err != nil {
+		return nil, err
+	}
+
+	result, err := s.b.RawCall("getrawtransaction", txid, true)
+	if err != nil {
+		return nil, s.translate(err)
+	}
+
+	var tx bitcoin.RawTransaction
+	if err := json.Unmarshal(result, &tx); err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		Txid:          tx.TxID,
+		Hex:           tx.Hex,
+		BlockHash:     tx.BlockHash,
+		Confirmations: int64(tx.Confirmations),
+	}, nil
+}
+
+// EstimateFee returns the fee rate for confirmation within the target number of blocks.
+func (s *Service) EstimateFee(ctx context.Context, req *EstimateFeeRequest) (*FeeEstimate, error) {
+	if req.ConfTarget < 1 || req.ConfTarget > 1008 {
+		return nil, fmt.Errorf("%w: confirmation target %d not in 1-1008", ErrInvalidArgument, req.ConfTarget)
+	}
+
+	mode := strings.ToLower(req.Mode)
+	switch mode {
+	case "":
+		mode = "economical"
+	case "economical", "conservative":
+	default:
+		return nil, fmt.Errorf("%w: mode %q", ErrInvalidArgument, req.Mode)
+	}
+
+	estimate, err := s.b.EstimateSmartFee(int(req.ConfTarget), mode)
+	if err != nil {
+		return nil, s.translate(err)
+	}
+
+	// The node reports errors instead of a fee rate until it has seen enough blocks
+	if estimate.FeeRate == 0 {
+		return nil, fmt.Errorf("%w: no fee estimate: %s", ErrUnavailable, strings.Join(estimate.Errors, ", "))
+	}
+
+	// BTC/kvB to sat/vB
+	return &FeeEstimate{SatPerVbyte: estimate.FeeRate * 1e5, Blocks: int32(estimate.Blocks)}, nil
+}
+
+// Broadcast sends a signed transaction and returns its txid.
+func (s *Service) Broadcast(ctx context.Context, txHex string) (string, error) {
+	if len(txHex) == 0 || len(txHex) > MaxTransactionHexLen || len(txHex)%2 != 0 {
+		return "", fmt.Errorf("%w: transaction of %d hex characters", ErrInvalidArgument, len(txHex))
+	}
+
+	if _, err := hex.DecodeString(txHex); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	result, err := s.b.RawCall("sendrawtransaction", txHex)
+	if err != nil {
+		return "", s.translateBroadcast(err)
+	}
+
+	var txid string
+	if err := json.Unmarshal(result, &txid); err != nil {
+		return "", err
+	}
+
+	return txid, nil
+}
+
+// Subscribe calls send for every announced block and transaction requested until the context is done or
+// send fails, which for a stream means the client went away.
+func (s *Service) Subscribe(ctx context.Context, req *SubscribeRequest, send func(*Event) error) error {
+	if s.events == nil {
+		return fmt.Errorf("%w: no event source configured", ErrUnavailable)
+	}
+
+	kinds := map[string]int32{}
+	if req.Blocks {
+		kinds["hashblock"] = EventBlock
+	}
+	if req.Transactions {
+		kinds["hashtx"] = EventTransaction
+	}
+	if len(kinds) == 0 {
+		return fmt.Errorf("%w: nothing to subscribe to", ErrInvalidArgument)
+	}
+
+	ch := make(chan []string, 100)
+	for topic := range kinds {
+		if err := s.events.Subscribe(topic, ch); err != nil {
+			return s.translate(err)
+		}
+	}
+
+	defer func() {
+		for topic := range kinds {
+			_ = s.events.Unsubscribe(topic, ch)
+		}
+
+		// The notifier may still be delivering, keep it from blocking until the unsubscription took effect
+		go func() {
+			timeout := time.After(time.Minute)
+			for {
+				select {
+				case <-ch:
+				case <-timeout:
+					return
+				}
+			}
+		}()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-ch:
+			if len(msg) < 2 {
+				continue
+			}
+			if err := send(&Event{Kind: kinds[msg[0]], Hash: msg[1]}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// translate maps node errors to the errors of the service. bitcoind answers most errors with HTTP status
+// 500, in which case the client only passes on the message, so both codes and messages are matched.
+func (s *Service) translate(err error) error {
+	msg := err.Error()
+
+	switch {
+	// RPC_INVALID_ADDRESS_OR_KEY for unknown blocks and transactions, RPC_INVALID_PARAMETER for heights
+	// above the tip
+	case bitcoin.HasErrorCode(err, bitcoin.RPCInvalidAddressOrKey), bitcoin.HasErrorCode(err, bitcoin.RPCInvalidParameter),
+		strings.Contains(msg, "not found"), strings.Contains(msg, "No such mempool"), strings.Contains(msg, "out of range"):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	// Nodes starting up or syncing, and connection failures
+	case bitcoin.IsRetryable(err), strings.Contains(msg, "failed to do request"):
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	return err
+}
+
+// translateBroadcast maps errors of sendrawtransaction, where the node rejecting the transaction is the
+// caller's fault rather than an internal error.
+func (s *Service) translateBroadcast(err error) error {
+	if code, ok := bitcoin.ErrorCode(err); ok {
+		switch code {
+		case bitcoin.RPCDeserializationError, bitcoin.RPCVerifyError, bitcoin.RPCVerifyRejected, bitcoin.RPCVerifyAlreadyInChain:
+			return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+		}
+	}
+
+	if strings.HasPrefix(err.Error(), "unexpected response code 500:") {
+		return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	return s.translate(err)
+}
+
+func validateHash(hash string) error {
+	if len(hash) != 64 {
+		return fmt.Errorf("%w: hash %q is not 64 hex characters", ErrInvalidArgument, hash)
+	}
+
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("%w: hash %q is not hex", ErrInvalidArgument, hash)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package nodeservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+const (
+	testHash = "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206"
+	testTxid = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+)
+
+func testService(t *testing.T, events Notifier) *Service {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		reply := func(result string) {
+			_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+		}
+		fail := func(code int, message string) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":` + strconv.Itoa(code) + `,"message":"` + message + `"},"id":1}`))
+		}
+
+		switch req.Method {
+		case "getblockchaininfo":
+			reply(`{"chain":"regtest","blocks":101,"headers":101,"bestblockhash":"` + testHash + `","mediantime":1296688602,"verificationprogress":1}`)
+		case "getblockhash":
+			if string(req.Params[0]) != "0" {
+				fail(-8, "Block height out of range")
+				return
+			}
+			reply(`"` + testHash + `"`)
+		case "getblock":
+			if string(req.Params[0]) != `"`+testHash+`"` {
+				fail(-5, "Block not found")
+				return
+			}
+			reply(`{"hash":"` + testHash + `","height":0,"time":1296688602,"mediantime":1296688602,"merkleroot":"` + testTxid + `","confirmations":102,"tx":["` + testTxid + `"]}`)
+		case "getrawtransaction":
+			if string(req.Params[0]) != `"`+testTxid+`"` {
+				fail(-5, "No such mempool or blockchain transaction")
+				return
+			}
+			reply(`{"txid":"` + testTxid + `","hex":"01000000","blockhash":"` + testHash + `","confirmations":102}`)
+		case "estimatesmartfee":
+			if string(req.Params[0]) == "1" {
+				reply(`{"errors":["Insufficient data or no feerate found"],"blocks":2}`)
+				return
+			}
+			reply(`{"feerate":0.00012,"blocks":6}`)
+		case "sendrawtransaction":
+			if string(req.Params[0]) != `"0100"` {
+				fail(-26, "mandatory-script-verify-flag-failed")
+				return
+			}
+			reply(`"` + testTxid + `"`)
+		default:
+			fail(-32601, "Method not found")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	b, err := bitcoin.New(u.Hostname(), port, "", "user", "pass", false)
+	require.NoError(t, err)
+
+	return NewService(b, events)
+}
+
+func TestService(t *testing.T) {
+	s := testService(t, nil)
+	ctx := context.Background()
+
+	info, err := s.GetChainInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "regtest", info.Chain)
+	assert.Equal(t, int64(101), info.Blocks)
+	assert.Equal(t, testHash, info.BestBlockHash)
+
+	block, err := s.GetBlock(ctx, &GetBlockRequest{Height: 0})
+	require.NoError(t, err)
+	assert.Equal(t, testHash, block.Hash)
+	assert.Equal(t, []string{testTxid}, block.Txids)
+
+	block, err = s.GetBlock(ctx, &GetBlockRequest{Hash: testHash})
+	require.NoError(t, err)
+	assert.Equal(t, int64(102), block.Confirmations)
+
+	tx, err := s.GetTransaction(ctx, testTxid)
+	require.NoError(t, err)
+	assert.Equal(t, testHash, tx.BlockHash)
+
+	fee, err := s.EstimateFee(ctx, &EstimateFeeRequest{ConfTarget: 6})
+	require.NoError(t, err)
+	assert.InDelta(t, 12, fee.SatPerVbyte, 1e-9)
+	assert.Equal(t, int32(6), fee.Blocks)
+
+	txid, err := s.Broadcast(ctx, "0100")
+	require.NoError(t, err)
+	assert.Equal(t, testTxid, txid)
+}
+
+func TestServiceErrors(t *testing.T) {
+	s := testService(t, nil)
+	ctx := context.Background()
+
+	_, err := s.GetBlock(ctx, &GetBlockRequest{Height: -1})
+	assert.Equal(t, "InvalidArgument", Code(err))
+
+	_, err = s.GetBlock(ctx, &GetBlockRequest{Height: 5})
+	assert.Equal(t, "NotFound", Code(err))
+
+	_, err = s.GetBlock(ctx, &GetBlockRequest{Hash: "zz"})
+	assert.Equal(t, "InvalidArgument", Code(err))
+
+	_, err = s.GetBlock(ctx, &GetBlockRequest{Hash: strings.Repeat("00", 32)})
+	assert.Equal(t, "NotFound", Code(err), err)
+
+	_, err = s.GetTransaction(ctx, strings.Repeat("ab", 32))
+	assert.Equal(t, "NotFound", Code(err))
+
+	_, err = s.EstimateFee(ctx, &EstimateFeeRequest{ConfTarget: 0})
+	assert.Equal(t, "InvalidArgument", Code(err))
+
+	_, err = s.EstimateFee(ctx, &EstimateFeeRequest{ConfTarget: 6, Mode: "fast"})
+	assert.Equal(t, "InvalidArgument", Code(err))
+
+	_, err = s.EstimateFee(ctx, &EstimateFeeRequest{ConfTarget: 1})
+	assert.Equal(t, "Unavailable", Code(err))
+
+	_, err = s.Broadcast(ctx, "xyz")
+	assert.Equal(t, "InvalidArgument", Code(err))
+
+	_, err = s.Broadcast(ctx, "0200")
+	assert.Equal(t, "InvalidArgument", Code(err))
+
+	err = s.Subscribe(ctx, &SubscribeRequest{Blocks: true}, func(*Event) error { return nil })
+	assert.Equal(t, "Unavailable", Code(err))
+}
+
+type fakeNotifier struct {
+	mu   sync.Mutex
+	subs map[string]chan []string
+}
+
+func (n *fakeNotifier) Subscribe(topic string, ch chan []string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.subs[topic] = ch
+	return nil
+}
+
+func (n *fakeNotifier) Unsubscribe(topic string, ch chan []string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.subs, topic)
+	return nil
+}
+
+func (n *fakeNotifier) publish(topic, hash string) bool {
+	n.mu.Lock()
+	ch, found := n.subs[topic]
+	n.mu.Unlock()
+
+	if found {
+		ch <- []string{topic, hash, "0"}
+	}
+	return found
+}
+
+func TestServiceSubscribe(t *testing.T) {
+	n := &fakeNotifier{subs: make(map[string]chan []string)}
+	s := testService(t, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *Event, 10)
+	done := make(chan error)
+	go func() {
+		done <- s.Subscribe(ctx, &SubscribeRequest{Blocks: true}, func(e *Event) error {
+			events <- e
+			return nil
+		})
+	}()
+
+	assert.Eventually(t, func() bool { return n.publish("hashblock", testHash) }, time.Second, time.Millisecond)
+	assert.False(t, n.publish("hashtx", testTxid))
+
+	assert.Equal(t, &Event{Kind: EventBlock, Hash: testHash}, <-events)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.False(t, n.publish("hashblock", testHash))
+}
@@ -0,0 +1,147 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var notifyTopics = map[string]string{
+	"block":  "hashblock",
+	"wallet": "hashtx",
+}
+
+// NotifyListener turns calls from the -blocknotify and -walletnotify hooks of a node into the events of
+// ZMQ, for nodes where ZMQ cannot be enabled. The node runs the hooks as shell commands, which request
+// /block/<hash> and /wallet/<txid> from the listener:
+//
+//	-blocknotify='curl -s http://127.0.0.1:8335/block/%s'
+//	-walletnotify='curl -s --unix-socket /run/bitcoin/notify.sock http://localhost/wallet/%s'
+//
+// Subscribers receive the same messages as from ZMQ: the topic (hashblock or hashtx), the hash and a
+// sequence number counted per topic. walletnotify only reports wallet transactions, and neither hook
+// reports mempool removals. The listener does not authenticate callers, so it should only listen on a
+// loopback address or a Unix socket.
+type NotifyListener struct {
+	mu            sync.Mutex
+	subscriptions map[string][]chan []string
+	sequences     map[string]uint32
+	logger        Logger
+}
+
+// NewNotifyListener returns a listener without subscribers. optionalLogger defaults to the DefaultLogger.
+func NewNotifyListener(optionalLogger ...Logger) *NotifyListener {
+	l := &NotifyListener{
+		subscriptions: make(map[string][]chan []string),
+		sequences:     make(map[string]uint32),
+		logger:        &DefaultLogger{},
+	}
+
+	if len(optionalLogger) > 0 {
+		l.logger = optionalLogger[0]
+	}
+
+	return l
+}
+
+// Subscribe sends the events of the topic, hashblock or hashtx, to ch.
+func (l *NotifyListener) Subscribe(topic string, ch chan []string) error {
+	if topic != "hashblock" && topic != "hashtx" {
+		return fmt.Errorf("topic must be hashblock or hashtx, received %q", topic)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.subscriptions[topic] = append(l.subscriptions[topic], ch)
+	return nil
+}
+
+// Unsubscribe stops sending the events of the topic to ch.
+func (l *NotifyListener) Unsubscribe(topic string, ch chan []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	subscribers := l.subscriptions[topic]
+	for i, subscriber := range subscribers {
+		if subscriber == ch {
+			l.subscriptions[topic] = append(subscribers[:i:i], subscribers[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// ServeHTTP handles a hook call. Delivery to subscribers blocks the call, which the node runs on a
+// separate thread, until every subscriber received the event or the caller went away.
+func (l *NotifyListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	topic, found := notifyTopics[parts[0]]
+	if !found || len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	hash := strings.ToLower(parts[1])
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+		http.Error(w, "invalid hash", http.StatusBadRequest)
+		return
+	}
+
+	l.mu.Lock()
+	sequence := l.sequences[topic]
+	l.sequences[topic]++
+	subscribers := append([]chan []string{}, l.subscriptions[topic]...)
+	l.mu.Unlock()
+
+	msg := []string{topic, hash, strconv.FormatUint(uint64(sequence), 10)}
+	for _, subscriber := range subscribers {
+		select {
+		case subscriber <- msg:
+		case <-r.Context().Done():
+			l.logger.Warnf("NotifyListener: %s %s not delivered to every subscriber: %v", topic, hash, r.Context().Err())
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListenAndServe serves hook calls on a TCP address such as 127.0.0.1:8335 or, with network "unix", on a
+// Unix socket path until the context is done. A leftover socket file from a previous run is removed.
+func (l *NotifyListener) ListenAndServe(ctx context.Context, network, address string) error {
+	if network == "unix" {
+		if err := os.Remove(address); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{Handler: l, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	l.logger.Infof("NotifyListener: listening on %s %s", network, ln.Addr())
+
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
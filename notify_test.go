@@ -0,0 +1,98 @@
+package bitcoin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyListener(t *testing.T) {
+	l := NewNotifyListener()
+
+	blocks := make(chan []string, 2)
+	txs := make(chan []string, 1)
+	require.NoError(t, l.Subscribe("hashblock", blocks))
+	require.NoError(t, l.Subscribe("hashtx", txs))
+	assert.Error(t, l.Subscribe("rawtx", txs))
+
+	hash := strings.Repeat("ab", 32)
+
+	for _, tc := range []struct {
+		path string
+		code int
+	}{
+		{"/block/" + hash, http.StatusNoContent},
+		{"/block/" + strings.ToUpper(hash), http.StatusNoContent},
+		{"/wallet/" + hash, http.StatusNoContent},
+		{"/block/abcd", http.StatusBadRequest},
+		{"/mempool/" + hash, http.StatusNotFound},
+		{"/block/" + hash + "/extra", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		l.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.code, rec.Code, tc.path)
+	}
+
+	assert.Equal(t, []string{"hashblock", hash, "0"}, <-blocks)
+	assert.Equal(t, []string{"hashblock", hash, "1"}, <-blocks)
+	assert.Equal(t, []string{"hashtx", hash, "0"}, <-txs)
+
+	require.NoError(t, l.Unsubscribe("hashtx", txs))
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+hash, nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, txs)
+}
+
+func TestNotifyListenerBlockedSubscriber(t *testing.T) {
+	l := NewNotifyListener()
+	require.NoError(t, l.Subscribe("hashblock", make(chan []string)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/block/"+strings.Repeat("00", 32), nil).WithContext(ctx))
+	assert.NotEqual(t, http.StatusNoContent, rec.Code)
+}
+
+func TestNotifyListenerUnixSocket(t *testing.T) {
+	l := NewNotifyListener()
+	blocks := make(chan []string, 1)
+	require.NoError(t, l.Subscribe("hashblock", blocks))
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- l.ListenAndServe(ctx, "unix", path) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	hash := strings.Repeat("cd", 32)
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("http://localhost/block/" + hash)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusNoContent
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"hashblock", hash, "0"}, <-blocks)
+
+	cancel()
+	assert.NoError(t, <-served)
+}
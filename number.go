@@ -0,0 +1,130 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInexactNumber is returned by the number accessors for values that cannot be converted without
+// losing precision, e.g. an amount with more than 8 decimals or a fractional count.
+var ErrInexactNumber = errors.New("number cannot be converted exactly")
+
+// unmarshal is json.Unmarshal decoding numbers held in interface{} values, such as error codes and
+// untyped results, as json.Number instead of float64, so that large integers and amounts are kept
+// exactly. Numbers decoded into typed fields are not affected, the amounts of the wrappers' result structs
+// stay float64.
+func unmarshal(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+
+	// Like json.Unmarshal, reject anything but whitespace after the value
+	if _, err := d.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+
+	return nil
+}
+
+// DecodeNumbers decodes a result, e.g. one returned by RawCall, into maps, slices and scalars like
+// json.Unmarshal into an interface{}, except that numbers are json.Number and keep their precision.
+// Convert them with NumberInt64, NumberUint64 or NumberSatoshis, or with their Float64 method for
+// values such as the difficulty. It is the way to read a result exactly, the typed results of the wrappers
+// hold float64 amounts.
+func DecodeNumbers(result []byte) (interface{}, error) {
+	var v interface{}
+	if err := unmarshal(result, &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// NumberInt64 converts a number to an int64, failing for fractions and values out of range.
+func NumberInt64(n json.Number) (int64, error) {
+	i, err := strconv.ParseInt(string(n), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s is not an int64", ErrInexactNumber, n)
+	}
+
+	return i, nil
+}
+
+// NumberUint64 converts a number to a uint64, failing for fractions, negative values and values out of
+// range.
+func NumberUint64(n json.Number) (uint64, error) {
+	u, err := strconv.ParseUint(string(n), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s is not a uint64", ErrInexactNumber, n)
+	}
+
+	return u, nil
+}
+
+// NumberSatoshis converts an amount in BTC, as returned by the node, to satoshis without going through a
+// float64. Amounts may be negative, as fees are in wallet transactions.
+func NumberSatoshis(n json.Number) (int64, error) {
+	s := string(n)
+
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+
+	// Digits past the satoshi are only allowed if they are zeros
+	if len(frac) > 8 {
+		if strings.Trim(frac[8:], "0") != "" {
+			return 0, fmt.Errorf("%w: %s has more than 8 decimals", ErrInexactNumber, n)
+		}
+		frac = frac[:8]
+	}
+	frac += strings.Repeat("0", 8-len(frac))
+
+	if whole == "" || strings.ContainsAny(whole, "+-") || strings.ContainsAny(frac, "+-") {
+		return 0, fmt.Errorf("%w: %s is not an amount", ErrInexactNumber, n)
+	}
+
+	sats, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s is not an amount", ErrInexactNumber, n)
+	}
+
+	if negative {
+		sats = -sats
+	}
+
+	return sats, nil
+}
+
+// errorCode returns the code of the error member of a response, which is a json.Number when decoded by
+// the client but a float64 when decoded elsewhere. It returns false for errors that are not an object with a
+// code, such as the plain strings some proxies send.
+func errorCode(e interface{}) (RPCErrorCode, bool) {
+	rr, ok := e.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+
+	switch code := rr["code"].(type) {
+	case json.Number:
+		i, err := code.Int64()
+		return RPCErrorCode(i), err == nil
+	case float64:
+		return RPCErrorCode(code), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,89 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeNumbers(t *testing.T) {
+	// 2^53 + 1 is the first integer a float64 cannot hold
+	v, err := DecodeNumbers([]byte(`{"difficulty":83148355189239.77,"total":9007199254740993,"amount":0.1,"fee":-0.00000141}`))
+	require.NoError(t, err)
+	m := v.(map[string]interface{})
+
+	total, err := NumberUint64(m["total"].(json.Number))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9007199254740993), total)
+
+	sats, err := NumberSatoshis(m["amount"].(json.Number))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000000), sats)
+
+	sats, err = NumberSatoshis(m["fee"].(json.Number))
+	require.NoError(t, err)
+	assert.Equal(t, int64(-141), sats)
+
+	difficulty, err := m["difficulty"].(json.Number).Float64()
+	require.NoError(t, err)
+	assert.Equal(t, 83148355189239.77, difficulty)
+
+	_, err = DecodeNumbers([]byte(`{} {}`))
+	assert.Error(t, err)
+}
+
+func TestNumberAccessors(t *testing.T) {
+	for _, tc := range []struct {
+		n    json.Number
+		sats int64
+		ok   bool
+	}{
+		{"21000000", 2100000000000000, true},
+		{"1.23456789", 123456789, true},
+		{"0.000000010", 1, true},
+		{"0.000000015", 0, false},
+		{"1e-8", 0, false},
+		{"--1", 0, false},
+		{"1.-5", 0, false},
+		{"100000000000", 0, false},
+	} {
+		sats, err := NumberSatoshis(tc.n)
+		if !tc.ok {
+			assert.ErrorIs(t, err, ErrInexactNumber, tc.n)
+			continue
+		}
+		assert.NoError(t, err, tc.n)
+		assert.Equal(t, tc.sats, sats, tc.n)
+	}
+
+	_, err := NumberInt64("1.5")
+	assert.ErrorIs(t, err, ErrInexactNumber)
+
+	_, err = NumberUint64("-1")
+	assert.ErrorIs(t, err, ErrInexactNumber)
+
+	i, err := NumberInt64("-9223372036854775808")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-9223372036854775808), i)
+}
+
+func TestErrorCodeAsNumber(t *testing.T) {
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":null,"error":{"code":-5,"message":"Block not found"},"id":1}`)
+	})
+	defer closeServer()
+
+	r, err := b.client.call("getblock", nil)
+	require.NoError(t, err)
+	code, ok := errorCode(r.Err)
+	assert.True(t, ok)
+	assert.Equal(t, RPCInvalidAddressOrKey, code)
+
+	// Wrappers format the code with %s, which only reads well for a json.Number
+	_, err = b.GetBlock("00")
+	assert.EqualError(t, err, "ERROR -5: Block not found")
+}
@@ -0,0 +1,587 @@
+// Package p2p is a minimal client of the bitcoin peer-to-peer protocol. It connects to a node directly,
+// without RPC access to any node, to sync headers, fetch transactions and blocks, broadcast transactions
+// and list the mempool. It does not validate what peers send beyond the hashes asked for, so applications
+// should connect to peers they trust or cross-check several.
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+// DefaultUserAgent is the user agent sent in version messages when Config.UserAgent is empty.
+const DefaultUserAgent = "/go-bitcoin:0.1/"
+
+const (
+	defaultTimeout     = 30 * time.Second
+	defaultQuietPeriod = 2 * time.Second
+)
+
+var (
+	// ErrNotFound is returned when a peer answers a request with notfound.
+	ErrNotFound = errors.New("not found")
+	// ErrNotSupported is returned for requests the peer does not advertise support for.
+	ErrNotSupported = errors.New("not supported by peer")
+)
+
+// Config configures a connection to a peer.
+type Config struct {
+	Network     Network
+	UserAgent   string // DefaultUserAgent if empty
+	Services    uint64 // advertised to the peer, none by default as a client serves no data
+	StartHeight int32  // height of the best block known to the client
+	// Relay asks the peer to announce the transactions entering its mempool with inv messages.
+	Relay bool
+	// Timeout bounds the handshake, every request without a deadline of its own and every write. Defaults
+	// to 30s.
+	Timeout time.Duration
+	// QuietPeriod is how long Mempool waits for more inventory before it considers the answer complete.
+	// Defaults to 2s.
+	QuietPeriod time.Duration
+	// OnInv is called from the reading goroutine with inventory the peer announces, and must not block.
+	OnInv  func(invs []InvVect)
+	Logger bitcoin.Logger // no logging if nil
+}
+
+// Peer is a connection to a node that completed the version handshake. Its methods are safe for concurrent
+// use. Pings of the peer are answered in the background.
+type Peer struct {
+	conn   net.Conn
+	cfg    Config
+	remote *VersionMessage
+
+	writeMu sync.Mutex
+
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+
+	done chan struct{}
+	err  error
+}
+
+// subscription receives the messages with one of its commands until it is removed.
+type subscription struct {
+	commands map[string]bool
+	ch       chan *Message
+	done     chan struct{}
+}
+
+// Connect connects to the peer at addr, host:port or host for the default port of the network, and
+// completes the version handshake.
+func Connect(ctx context.Context, addr string, cfg Config) (*Peer, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(cfg.Network.DefaultPort()))
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := NewPeer(ctx, conn, cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// NewPeer completes the version handshake over an established connection, e.g. one through a proxy.
+func NewPeer(ctx context.Context, conn net.Conn, cfg Config) (*Peer, error) {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.QuietPeriod <= 0 {
+		cfg.QuietPeriod = defaultQuietPeriod
+	}
+
+	p := &Peer{
+		conn: conn,
+		cfg:  cfg,
+		subs: make(map[*subscription]struct{}),
+		done: make(chan struct{}),
+	}
+
+	if err := p.handshake(ctx); err != nil {
+		return nil, fmt.Errorf("handshake with %s failed: %w", conn.RemoteAddr(), err)
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	p.debugf("p2p: connected to %s (%s, version %d, height %d)", conn.RemoteAddr(), p.remote.UserAgent, p.remote.Version, p.remote.StartHeight)
+
+	go p.readLoop()
+
+	return p, nil
+}
+
+func (p *Peer) handshake(ctx context.Context) error {
+	if err := p.conn.SetDeadline(time.Now().Add(p.cfg.Timeout)); err != nil {
+		return err
+	}
+
+	// Unblock the handshake when the context is done, which thereby is done before the reads and writes fail
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			_ = p.conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	version := &VersionMessage{
+		Version:     ProtocolVersion,
+		Services:    p.cfg.Services,
+		Timestamp:   time.Now(),
+		Nonce:       rand.Uint64(),
+		UserAgent:   p.cfg.UserAgent,
+		StartHeight: p.cfg.StartHeight,
+		Relay:       p.cfg.Relay,
+	}
+	if err := WriteMessage(p.conn, p.cfg.Network, "version", version.encode(p.conn.RemoteAddr())); err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	var verack bool
+	for p.remote == nil || !verack {
+		msg, err := ReadMessage(p.conn, p.cfg.Network)
+		if err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		switch msg.Command {
+		case "version":
+			if p.remote, err = decodeVersion(msg.Payload); err != nil {
+				return err
+			}
+			if p.remote.Nonce == version.Nonce {
+				return errors.New("connected to self")
+			}
+			if err := WriteMessage(p.conn, p.cfg.Network, "verack", nil); err != nil {
+				return ctxErr(ctx, err)
+			}
+		case "verack":
+			verack = true
+		default:
+			// wtxidrelay, sendaddrv2 and the like, which only matter to full nodes
+		}
+	}
+
+	return nil
+}
+
+// ctxErr returns the error of ctx if it is done, which caused err.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Remote returns the version message of the peer.
+func (p *Peer) Remote() VersionMessage {
+	return *p.remote
+}
+
+// Addr returns the address of the peer.
+func (p *Peer) Addr() net.Addr {
+	return p.conn.RemoteAddr()
+}
+
+// Done returns a channel closed when the connection is lost or closed, after which Err returns why.
+func (p *Peer) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns why the connection ended, or nil while it is open.
+func (p *Peer) Err() error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
+		return nil
+	}
+}
+
+// Close closes the connection.
+func (p *Peer) Close() error {
+	err := p.conn.Close()
+	<-p.done
+	return err
+}
+
+func (p *Peer) readLoop() {
+	var err error
+	defer func() {
+		p.conn.Close()
+		p.err = err
+		close(p.done)
+	}()
+
+	for {
+		var msg *Message
+		msg, err = ReadMessage(p.conn, p.cfg.Network)
+		if err != nil {
+			p.debugf("p2p: connection to %s ended: %v", p.conn.RemoteAddr(), err)
+			return
+		}
+
+		switch msg.Command {
+		case "ping":
+			// Written concurrently, so that a peer waiting on us to read does not block the pong
+			go func(nonce []byte) {
+				if err := p.Send("pong", nonce); err != nil {
+					p.debugf("p2p: failed to answer ping of %s: %v", p.conn.RemoteAddr(), err)
+				}
+			}(msg.Payload)
+		case "inv":
+			if p.cfg.OnInv != nil {
+				if invs, err := decodeInv(msg.Payload); err == nil {
+					p.cfg.OnInv(invs)
+				}
+			}
+		}
+
+		p.deliver(msg)
+	}
+}
+
+func (p *Peer) deliver(msg *Message) {
+	p.mu.Lock()
+	var subs []*subscription
+	for s := range p.subs {
+		if s.commands[msg.Command] {
+			subs = append(subs, s)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, s := range subs {
+		select {
+		case s.ch <- msg:
+		case <-s.done:
+		}
+	}
+}
+
+func (p *Peer) subscribe(commands ...string) *subscription {
+	s := &subscription{
+		commands: make(map[string]bool, len(commands)),
+		ch:       make(chan *Message, 16),
+		done:     make(chan struct{}),
+	}
+	for _, c := range commands {
+		s.commands[c] = true
+	}
+
+	p.mu.Lock()
+	p.subs[s] = struct{}{}
+	p.mu.Unlock()
+
+	return s
+}
+
+func (p *Peer) unsubscribe(s *subscription) {
+	p.mu.Lock()
+	delete(p.subs, s)
+	p.mu.Unlock()
+	close(s.done)
+}
+
+// next returns the next message of s.
+func (p *Peer) next(ctx context.Context, s *subscription) (*Message, error) {
+	select {
+	case msg := <-s.ch:
+		return msg, nil
+	case <-p.done:
+		return nil, fmt.Errorf("connection to %s lost: %w", p.conn.RemoteAddr(), p.err)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// withTimeout bounds ctx by the timeout of the config unless it has a deadline.
+func (p *Peer) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.cfg.Timeout)
+}
+
+// Send sends a message to the peer, e.g. one this package has no method for.
+func (p *Peer) Send(command string, payload []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	if err := p.conn.SetWriteDeadline(time.Now().Add(p.cfg.Timeout)); err != nil {
+		return err
+	}
+
+	return WriteMessage(p.conn, p.cfg.Network, command, payload)
+}
+
+// GetHeaders returns the headers of the peer's active chain following the first hash of locator it knows,
+// up to stop, or the first 2000 if stop is empty. The locator lists hashes from the tip back, e.g. the last
+// header synced; the genesis hash requests the chain from the start.
+func (p *Peer) GetHeaders(ctx context.Context, locator []string, stop string) ([]*bitcoin.RawBlockHeader, error) {
+	payload, err := encodeGetHeaders(locator, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	s := p.subscribe("headers")
+	defer p.unsubscribe(s)
+
+	if err := p.Send("getheaders", payload); err != nil {
+		return nil, err
+	}
+
+	msg, err := p.next(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := decodeHeaders(msg.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(headers); i++ {
+		if headers[i].PrevHash != headers[i-1].Hash {
+			return nil, fmt.Errorf("header %s does not link to %s", headers[i].Hash, headers[i-1].Hash)
+		}
+	}
+
+	return headers, nil
+}
+
+// SyncHeaders returns the headers of the peer's active chain following the block from, fetching them 2000
+// at a time, up to max headers or the tip of the peer if max is 0. Each header is checked to link to the one
+// before, but not validated against the proof of work.
+func (p *Peer) SyncHeaders(ctx context.Context, from string, max int) ([]*bitcoin.RawBlockHeader, error) {
+	var headers []*bitcoin.RawBlockHeader
+
+	last := from
+	for max == 0 || len(headers) < max {
+		batch, err := p.GetHeaders(ctx, []string{last}, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) > 0 && batch[0].PrevHash != last {
+			return nil, fmt.Errorf("header %s does not link to %s, which the peer may not know", batch[0].Hash, last)
+		}
+
+		headers = append(headers, batch...)
+		if len(batch) < maxHeadersPerMessage {
+			break
+		}
+		last = batch[len(batch)-1].Hash
+	}
+
+	if max > 0 && len(headers) > max {
+		headers = headers[:max]
+	}
+
+	return headers, nil
+}
+
+// GetData requests the transactions and blocks of invs and returns them raw by the hash asked for, with the
+// hashes the peer answered notfound for. Blocks are sent only if the peer has them, typically not when they
+// are older than 288 blocks and it is pruned; transactions only if they are in its mempool.
+func (p *Peer) GetData(ctx context.Context, invs []InvVect) (map[string][]byte, []string, error) {
+	if len(invs) > maxInvPerMessage {
+		return nil, nil, fmt.Errorf("%d inventory vectors exceed %d", len(invs), maxInvPerMessage)
+	}
+
+	payload, err := encodeInv(invs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending := make(map[string]bool, len(invs))
+	for _, inv := range invs {
+		pending[inv.Hash] = true
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	s := p.subscribe("tx", "block", "notfound")
+	defer p.unsubscribe(s)
+
+	if err := p.Send("getdata", payload); err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[string][]byte, len(invs))
+	var notFound []string
+
+	for len(pending) > 0 {
+		msg, err := p.next(ctx, s)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch msg.Command {
+		case "notfound":
+			missing, err := decodeInv(msg.Payload)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, inv := range missing {
+				if pending[inv.Hash] {
+					delete(pending, inv.Hash)
+					notFound = append(notFound, inv.Hash)
+				}
+			}
+		case "block":
+			if len(msg.Payload) < 80 {
+				return nil, nil, fmt.Errorf("block of %d bytes", len(msg.Payload))
+			}
+			hash := hashString(cryptolib.Sha256d(msg.Payload[:80]))
+			if pending[hash] {
+				delete(pending, hash)
+				found[hash] = msg.Payload
+			}
+		case "tx":
+			txid, err := bitcoin.ComputeTxID(msg.Payload)
+			if err != nil {
+				return nil, nil, err
+			}
+			wtxid, err := bitcoin.ComputeWTxID(msg.Payload)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, hash := range []string{txid, wtxid} {
+				if pending[hash] {
+					delete(pending, hash)
+					found[hash] = msg.Payload
+				}
+			}
+		}
+	}
+
+	return found, notFound, nil
+}
+
+// GetTransaction returns the raw transaction with txid, including its witness, from the mempool of the peer.
+// Peers do not serve confirmed transactions; ErrNotFound is returned for those.
+func (p *Peer) GetTransaction(ctx context.Context, txid string) ([]byte, error) {
+	return p.getOne(ctx, InvVect{Type: InvWitnessTx, Hash: txid})
+}
+
+// GetBlock returns the raw block with hash, including witnesses.
+func (p *Peer) GetBlock(ctx context.Context, hash string) ([]byte, error) {
+	return p.getOne(ctx, InvVect{Type: InvWitnessBlock, Hash: hash})
+}
+
+func (p *Peer) getOne(ctx context.Context, inv InvVect) ([]byte, error) {
+	found, _, err := p.GetData(ctx, []InvVect{inv})
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := found[inv.Hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, inv.Hash)
+	}
+
+	return raw, nil
+}
+
+// BroadcastTx sends the raw transaction to the peer and returns its txid. Peers do not acknowledge
+// transactions, nor reject them since BIP61 was removed; a transaction the peer accepted to its mempool can
+// be fetched with GetTransaction, from this or another peer once relayed.
+func (p *Peer) BroadcastTx(ctx context.Context, raw []byte) (string, error) {
+	txid, err := bitcoin.ComputeTxID(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if err := p.Send("tx", raw); err != nil {
+		return "", err
+	}
+
+	p.debugf("p2p: sent transaction %s to %s", txid, p.conn.RemoteAddr())
+
+	return txid, nil
+}
+
+// Mempool returns the inventory of the mempool of the peer. Peers answer only if they serve bloom filters,
+// with -peerbloomfilters, or grant the mempool permission to this client, and disconnect otherwise; without
+// NodeBloom among the services of the peer ErrNotSupported is returned rather than risking that. The answer
+// is complete once an inv message of fewer than 50000 entries arrives or none has for the quiet period of the
+// config, as an empty mempool is not answered at all.
+func (p *Peer) Mempool(ctx context.Context) ([]InvVect, error) {
+	if p.remote.Services&NodeBloom == 0 {
+		return nil, fmt.Errorf("mempool: %w", ErrNotSupported)
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	s := p.subscribe("inv")
+	defer p.unsubscribe(s)
+
+	if err := p.Send("mempool", nil); err != nil {
+		return nil, err
+	}
+
+	var invs []InvVect
+	for {
+		quiet, cancelQuiet := context.WithTimeout(ctx, p.cfg.QuietPeriod)
+		msg, err := p.next(quiet, s)
+		cancelQuiet()
+
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				return invs, nil
+			}
+			return nil, err
+		}
+
+		batch, err := decodeInv(msg.Payload)
+		if err != nil {
+			return nil, err
+		}
+		invs = append(invs, batch...)
+
+		if len(batch) < maxInvPerMessage {
+			return invs, nil
+		}
+	}
+}
+
+func (p *Peer) debugf(format string, args ...interface{}) {
+	if p.cfg.Logger != nil {
+		p.cfg.Logger.Debugf(format, args...)
+	}
+}
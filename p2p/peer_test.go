@@ -0,0 +1,277 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuber/go-bitcoin/testvectors"
+)
+
+// fakeNode answers the requests of a Peer from a generated chain, and records the tx and pong messages it
+// receives.
+type fakeNode struct {
+	chain    *testvectors.Chain
+	services uint64
+	mempool  []string
+	received chan *Message
+}
+
+func startFakeNode(t *testing.T, node *fakeNode) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	node.received = make(chan *Message, 16)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		node.serve(conn)
+	}()
+
+	return l.Addr().String()
+}
+
+func (n *fakeNode) serve(conn net.Conn) {
+	send := func(command string, payload []byte) {
+		_ = WriteMessage(conn, RegTest, command, payload)
+	}
+
+	msg, err := ReadMessage(conn, RegTest)
+	if err != nil || msg.Command != "version" {
+		return
+	}
+
+	version := &VersionMessage{
+		Version:     ProtocolVersion,
+		Services:    n.services,
+		Timestamp:   time.Now(),
+		Nonce:       1,
+		UserAgent:   "/Satoshi:27.0.0/",
+		StartHeight: int32(n.chain.Tip().Height),
+	}
+	send("version", version.encode(nil))
+	send("sendaddrv2", nil)
+	send("verack", nil)
+	send("ping", []byte{1, 2, 3, 4, 5, 6, 7, 8})
+
+	for {
+		msg, err := ReadMessage(conn, RegTest)
+		if err != nil {
+			return
+		}
+
+		switch msg.Command {
+		case "getheaders":
+			locator := hashString(msg.Payload[5:37])
+			var payload bytes.Buffer
+			var headers [][]byte
+			for i, b := range n.chain.Blocks {
+				if b.PreviousBlockHash == locator {
+					for _, b := range n.chain.Blocks[i:] {
+						raw, _ := hex.DecodeString(b.Hex[:160])
+						headers = append(headers, raw)
+					}
+				}
+			}
+			payload.WriteByte(byte(len(headers)))
+			for _, h := range headers {
+				payload.Write(h)
+				payload.WriteByte(0)
+			}
+			send("headers", payload.Bytes())
+		case "getdata":
+			invs, _ := decodeInv(msg.Payload)
+			var missing []InvVect
+			for _, inv := range invs {
+				if raw := n.find(inv.Hash); raw != nil {
+					send(map[InvType]string{InvWitnessTx: "tx", InvWitnessBlock: "block"}[inv.Type], raw)
+				} else {
+					missing = append(missing, inv)
+				}
+			}
+			if len(missing) > 0 {
+				payload, _ := encodeInv(missing)
+				send("notfound", payload)
+			}
+		case "mempool":
+			var invs []InvVect
+			for _, txid := range n.mempool {
+				invs = append(invs, InvVect{Type: InvTx, Hash: txid})
+			}
+			payload, _ := encodeInv(invs)
+			send("inv", payload)
+		case "tx", "pong":
+			n.received <- msg
+		}
+	}
+}
+
+func (n *fakeNode) find(hash string) []byte {
+	for _, b := range n.chain.Blocks {
+		if b.Hash == hash {
+			raw, _ := hex.DecodeString(b.Hex)
+			return raw
+		}
+		for _, tx := range b.Tx {
+			if tx.TxID == hash {
+				raw, _ := hex.DecodeString(tx.Hex)
+				return raw
+			}
+		}
+	}
+	return nil
+}
+
+func (n *fakeNode) next(t *testing.T) *Message {
+	select {
+	case msg := <-n.received:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func TestMessageFraming(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteMessage(&buf, MainNet, "ping", []byte{1, 2, 3}))
+	assert.Equal(t, "f9beb4d9", hex.EncodeToString(buf.Bytes()[:4]))
+
+	raw := buf.Bytes()
+
+	msg, err := ReadMessage(bytes.NewReader(raw), MainNet)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", msg.Command)
+	assert.Equal(t, []byte{1, 2, 3}, msg.Payload)
+
+	_, err = ReadMessage(bytes.NewReader(raw), TestNet3)
+	assert.Error(t, err)
+
+	corrupt := append([]byte(nil), raw...)
+	corrupt[len(corrupt)-1] ^= 1
+	_, err = ReadMessage(bytes.NewReader(corrupt), MainNet)
+	assert.True(t, errors.Is(err, ErrBadChecksum))
+
+	assert.Error(t, WriteMessage(&buf, MainNet, "averylongcommand", nil))
+}
+
+func TestPeer(t *testing.T) {
+	chain, err := testvectors.Generate("", 5)
+	require.NoError(t, err)
+
+	node := &fakeNode{chain: chain, services: NodeNetwork | NodeBloom, mempool: []string{chain.Tip().Tx[0].TxID}}
+	addr := startFakeNode(t, node)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	p, err := Connect(ctx, addr, Config{Network: RegTest, Timeout: 5 * time.Second, QuietPeriod: 100 * time.Millisecond})
+	require.NoError(t, err)
+	defer p.Close()
+
+	assert.Equal(t, "/Satoshi:27.0.0/", p.Remote().UserAgent)
+	assert.Equal(t, int32(chain.Tip().Height), p.Remote().StartHeight)
+
+	t.Run("ping", func(t *testing.T) {
+		pong := node.next(t)
+		assert.Equal(t, "pong", pong.Command)
+		assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, pong.Payload)
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		headers, err := p.SyncHeaders(ctx, chain.Blocks[0].PreviousBlockHash, 0)
+		require.NoError(t, err)
+		require.Len(t, headers, len(chain.Blocks))
+		for i, h := range headers {
+			assert.Equal(t, chain.Blocks[i].Hash, h.Hash)
+			assert.Equal(t, chain.Blocks[i].MerkleRoot, h.MerkleRoot)
+		}
+
+		headers, err = p.SyncHeaders(ctx, chain.Blocks[0].PreviousBlockHash, 2)
+		require.NoError(t, err)
+		assert.Len(t, headers, 2)
+	})
+
+	t.Run("getdata", func(t *testing.T) {
+		block, err := p.GetBlock(ctx, chain.Blocks[2].Hash)
+		require.NoError(t, err)
+		assert.Equal(t, chain.Blocks[2].Hex, hex.EncodeToString(block))
+
+		tx := chain.Blocks[3].Tx[0]
+		raw, err := p.GetTransaction(ctx, tx.TxID)
+		require.NoError(t, err)
+		assert.Equal(t, tx.Hex, hex.EncodeToString(raw))
+
+		_, err = p.GetTransaction(ctx, "00000000000000000000000000000000000000000000000000000000000000aa")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("broadcast", func(t *testing.T) {
+		tx := chain.Tip().Tx[0]
+		raw, _ := hex.DecodeString(tx.Hex)
+
+		txid, err := p.BroadcastTx(ctx, raw)
+		require.NoError(t, err)
+		assert.Equal(t, tx.TxID, txid)
+
+		msg := node.next(t)
+		assert.Equal(t, "tx", msg.Command)
+		assert.Equal(t, raw, msg.Payload)
+	})
+
+	t.Run("mempool", func(t *testing.T) {
+		invs, err := p.Mempool(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []InvVect{{Type: InvTx, Hash: chain.Tip().Tx[0].TxID}}, invs)
+	})
+
+	require.NoError(t, p.Close())
+	assert.Error(t, p.Err())
+}
+
+func TestPeerMempoolNotSupported(t *testing.T) {
+	chain, err := testvectors.Generate("", 1)
+	require.NoError(t, err)
+
+	addr := startFakeNode(t, &fakeNode{chain: chain, services: NodeNetwork})
+
+	p, err := Connect(context.Background(), addr, Config{Network: RegTest, Timeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer p.Close()
+
+	_, err = p.Mempool(context.Background())
+	assert.True(t, errors.Is(err, ErrNotSupported))
+}
+
+func TestConnectCancelled(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	// Accepts but never answers the version message
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = Connect(ctx, l.Addr().String(), Config{Network: RegTest})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,401 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+// Network identifies a bitcoin network by the magic bytes starting its messages, read as a little endian
+// uint32.
+type Network uint32
+
+const (
+	MainNet  Network = 0xd9b4bef9
+	TestNet3 Network = 0x0709110b
+	TestNet4 Network = 0x283f161c
+	Signet   Network = 0x40cf030a // the default signet
+	RegTest  Network = 0xdab5bffa
+)
+
+var defaultPorts = map[Network]int{
+	MainNet:  8333,
+	TestNet3: 18333,
+	TestNet4: 48333,
+	Signet:   38333,
+	RegTest:  18444,
+}
+
+// DefaultPort returns the port nodes of the network listen on by default, 0 for unknown networks.
+func (n Network) DefaultPort() int {
+	return defaultPorts[n]
+}
+
+// ProtocolVersion is the protocol version this package speaks, that of bitcoind 0.21 and later.
+const ProtocolVersion = 70016
+
+// Service flags advertised in version messages.
+const (
+	NodeNetwork        uint64 = 1 << 0  // serves the full chain
+	NodeBloom          uint64 = 1 << 2  // serves bloom filters and mempool requests
+	NodeWitness        uint64 = 1 << 3  // serves witness data
+	NodeCompactFilters uint64 = 1 << 6  // serves BIP157 compact block filters
+	NodeNetworkLimited uint64 = 1 << 10 // serves the last 288 blocks
+)
+
+const (
+	messageHeaderSize = 24
+	// maxMessagePayload is the largest payload accepted, that of bitcoind
+	maxMessagePayload = 32 * 1024 * 1024
+	// maxInvPerMessage is the most inventory vectors in an inv, getdata or notfound message.
+	maxInvPerMessage = 50000
+	// maxHeadersPerMessage is the most headers in a headers message.
+	maxHeadersPerMessage = 2000
+)
+
+// ErrBadChecksum is returned when the checksum of a message does not match its payload.
+var ErrBadChecksum = errors.New("bad message checksum")
+
+// Message is a message of the wire protocol.
+type Message struct {
+	Command string
+	Payload []byte
+}
+
+// WriteMessage writes a message with the given command and payload for network to w.
+func WriteMessage(w io.Writer, network Network, command string, payload []byte) error {
+	if len(command) > 12 {
+		return fmt.Errorf("command %q is longer than 12 bytes", command)
+	}
+
+	msg := make([]byte, messageHeaderSize, messageHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(msg[0:4], uint32(network))
+	copy(msg[4:16], command)
+	binary.LittleEndian.PutUint32(msg[16:20], uint32(len(payload)))
+	copy(msg[20:24], cryptolib.Sha256d(payload)[:4])
+	msg = append(msg, payload...)
+
+	_, err := w.Write(msg)
+	return err
+}
+
+// ReadMessage reads the next message for network from r.
+func ReadMessage(r io.Reader, network Network) (*Message, error) {
+	header := make([]byte, messageHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	if magic := Network(binary.LittleEndian.Uint32(header[0:4])); magic != network {
+		return nil, fmt.Errorf("message for network %08x rather than %08x", uint32(magic), uint32(network))
+	}
+
+	command := string(bytes.TrimRight(header[4:16], "\x00"))
+	length := binary.LittleEndian.Uint32(header[16:20])
+	if length > maxMessagePayload {
+		return nil, fmt.Errorf("%s message of %d bytes is too large", command, length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(cryptolib.Sha256d(payload)[:4], header[20:24]) {
+		return nil, fmt.Errorf("%w of %s message", ErrBadChecksum, command)
+	}
+
+	return &Message{Command: command, Payload: payload}, nil
+}
+
+// InvType is the type of an inventory vector.
+type InvType uint32
+
+const (
+	InvTx            InvType = 1
+	InvBlock         InvType = 2
+	InvFilteredBlock InvType = 3
+	InvCompactBlock  InvType = 4
+	InvWTx           InvType = 5 // by wtxid, announced to peers that sent wtxidrelay
+	InvWitnessFlag   InvType = 1 << 30
+
+	InvWitnessTx    = InvTx | InvWitnessFlag
+	InvWitnessBlock = InvBlock | InvWitnessFlag
+)
+
+// InvVect is an inventory vector, announcing or requesting a transaction or block by its hash.
+type InvVect struct {
+	Type InvType
+	Hash string // byte reversed hex, as shown by bitcoind
+}
+
+// VersionMessage is the version message peers open a connection with. Their addresses are not part of it,
+// as they are of no use to a client.
+type VersionMessage struct {
+	Version     int32
+	Services    uint64
+	Timestamp   time.Time
+	Nonce       uint64
+	UserAgent   string
+	StartHeight int32
+	Relay       bool // whether the sender wants transactions announced
+}
+
+func (v *VersionMessage) encode(remote net.Addr) []byte {
+	var buf bytes.Buffer
+	writeLE(&buf, v.Version)
+	writeLE(&buf, v.Services)
+	writeLE(&buf, v.Timestamp.Unix())
+	buf.Write(networkAddress(0, remote))
+	buf.Write(networkAddress(v.Services, nil))
+	writeLE(&buf, v.Nonce)
+	buf.Write(cryptolib.VarInt(uint64(len(v.UserAgent))))
+	buf.WriteString(v.UserAgent)
+	writeLE(&buf, v.StartHeight)
+	if v.Relay {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+
+	return buf.Bytes()
+}
+
+func decodeVersion(payload []byte) (*VersionMessage, error) {
+	r := &payloadReader{b: payload}
+
+	v := &VersionMessage{Version: int32(r.uint32()), Services: r.uint64()}
+	v.Timestamp = time.Unix(int64(r.uint64()), 0)
+	r.read(2 * 26) // addresses of the receiver and sender
+	v.Nonce = r.uint64()
+	v.UserAgent = string(r.read(int(r.varInt())))
+	v.StartHeight = int32(r.uint32())
+	if r.err == nil && r.pos < len(payload) {
+		v.Relay = r.read(1)[0] != 0
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to decode version message: %w", r.err)
+	}
+
+	return v, nil
+}
+
+// networkAddress encodes addr as the services, IPv6 address and big endian port of a version message.
+func networkAddress(services uint64, addr net.Addr) []byte {
+	b := make([]byte, 26)
+	binary.LittleEndian.PutUint64(b[0:8], services)
+
+	if addr == nil {
+		return b
+	}
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return b
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		copy(b[8:24], ip.To16())
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		binary.BigEndian.PutUint16(b[24:26], uint16(p))
+	}
+
+	return b
+}
+
+func encodeInv(invs []InvVect) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(cryptolib.VarInt(uint64(len(invs))))
+	for _, inv := range invs {
+		hash, err := decodeHash(inv.Hash)
+		if err != nil {
+			return nil, err
+		}
+		writeLE(&buf, uint32(inv.Type))
+		buf.Write(hash)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeInv(payload []byte) ([]InvVect, error) {
+	r := &payloadReader{b: payload}
+
+	n := r.count(36)
+	if n > maxInvPerMessage {
+		return nil, fmt.Errorf("inventory of %d entries exceeds %d", n, maxInvPerMessage)
+	}
+
+	invs := make([]InvVect, n)
+	for i := range invs {
+		invs[i].Type = InvType(r.uint32())
+		invs[i].Hash = hashString(r.read(32))
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to decode inventory: %w", r.err)
+	}
+
+	return invs, nil
+}
+
+// encodeGetHeaders encodes a getheaders message asking for the headers after the first hash of locator on
+// the peer's active chain, up to stop or 2000 headers.
+func encodeGetHeaders(locator []string, stop string) ([]byte, error) {
+	var buf bytes.Buffer
+	writeLE(&buf, uint32(ProtocolVersion))
+	buf.Write(cryptolib.VarInt(uint64(len(locator))))
+	for _, h := range locator {
+		hash, err := decodeHash(h)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(hash)
+	}
+
+	stopHash := make([]byte, 32)
+	if stop != "" {
+		var err error
+		if stopHash, err = decodeHash(stop); err != nil {
+			return nil, err
+		}
+	}
+	buf.Write(stopHash)
+
+	return buf.Bytes(), nil
+}
+
+func decodeHeaders(payload []byte) ([]*bitcoin.RawBlockHeader, error) {
+	r := &payloadReader{b: payload}
+
+	n := r.count(81)
+	if n > maxHeadersPerMessage {
+		return nil, fmt.Errorf("%d headers exceed %d", n, maxHeadersPerMessage)
+	}
+
+	headers := make([]*bitcoin.RawBlockHeader, 0, n)
+	for i := 0; i < n; i++ {
+		raw := r.read(80)
+		r.varInt() // transaction count, always 0
+		if r.err != nil {
+			break
+		}
+		headers = append(headers, parseHeader(raw))
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to decode headers: %w", r.err)
+	}
+
+	return headers, nil
+}
+
+// parseHeader decodes an 80 byte block header.
+func parseHeader(raw []byte) *bitcoin.RawBlockHeader {
+	return &bitcoin.RawBlockHeader{
+		Hash:       hashString(cryptolib.Sha256d(raw)),
+		Version:    int32(binary.LittleEndian.Uint32(raw[0:4])),
+		PrevHash:   hashString(raw[4:36]),
+		MerkleRoot: hashString(raw[36:68]),
+		Time:       binary.LittleEndian.Uint32(raw[68:72]),
+		Bits:       binary.LittleEndian.Uint32(raw[72:76]),
+		Nonce:      binary.LittleEndian.Uint32(raw[76:80]),
+		Raw:        append([]byte(nil), raw...),
+	}
+}
+
+// hashString returns the byte reversed hex of a hash in internal byte order.
+func hashString(b []byte) string {
+	return hex.EncodeToString(cryptolib.ReverseBytes(append([]byte(nil), b...)))
+}
+
+// decodeHash returns the internal byte order of a byte reversed hex hash.
+func decodeHash(s string) ([]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		return nil, fmt.Errorf("invalid hash %q", s)
+	}
+
+	return cryptolib.ReverseBytes(b), nil
+}
+
+func writeLE(buf *bytes.Buffer, v interface{}) {
+	_ = binary.Write(buf, binary.LittleEndian, v)
+}
+
+var errShortPayload = errors.New("unexpected end of payload")
+
+// payloadReader reads wire encoded values from a payload. The first error is sticky.
+type payloadReader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *payloadReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.b) {
+		r.err = errShortPayload
+		return nil
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *payloadReader) uint32() uint32 {
+	if b := r.read(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *payloadReader) uint64() uint64 {
+	if b := r.read(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *payloadReader) varInt() uint64 {
+	prefix := r.read(1)
+	if prefix == nil {
+		return 0
+	}
+
+	switch prefix[0] {
+	case 0xfd:
+		if b := r.read(2); b != nil {
+			return uint64(binary.LittleEndian.Uint16(b))
+		}
+	case 0xfe:
+		return uint64(r.uint32())
+	case 0xff:
+		return r.uint64()
+	default:
+		return uint64(prefix[0])
+	}
+	return 0
+}
+
+// count reads an element count and checks it against the remaining payload, where each element occupies
+// at least minSize bytes.
+func (r *payloadReader) count(minSize int) int {
+	n := r.varInt()
+	if r.err == nil && n > uint64((len(r.b)-r.pos)/minSize) {
+		r.err = fmt.Errorf("element count %d exceeds remaining payload", n)
+		return 0
+	}
+	return int(n)
+}
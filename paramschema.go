@@ -0,0 +1,265 @@
+package bitcoin
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidParams is returned, before anything is sent, for calls whose params do not match the schema of
+// their method, see WithParamValidation.
+var ErrInvalidParams = errors.New("invalid params")
+
+// ParamType is the set of JSON types accepted for a param.
+type ParamType uint
+
+const (
+	ParamString ParamType = 1 << iota
+	ParamNumber           // any number, e.g. an amount or a fee rate
+	ParamInt              // a number without fraction, e.g. a height or a count
+	ParamBool
+	ParamArray
+	ParamObject
+
+	ParamAny = ParamString | ParamNumber | ParamInt | ParamBool | ParamArray | ParamObject
+)
+
+var paramTypeNames = []struct {
+	t    ParamType
+	name string
+}{
+	{ParamString, "string"},
+	{ParamNumber, "number"},
+	{ParamInt, "integer"},
+	{ParamBool, "bool"},
+	{ParamArray, "array"},
+	{ParamObject, "object"},
+}
+
+func (t ParamType) String() string {
+	var names []string
+	for _, n := range paramTypeNames {
+		if t&n.t != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// ParamSpec describes a positional param of a method. Optional params may be omitted or null.
+type ParamSpec struct {
+	Name     string
+	Type     ParamType
+	Optional bool
+}
+
+func param(name string, t ParamType) ParamSpec {
+	return ParamSpec{Name: name, Type: t}
+}
+
+func optional(name string, t ParamType) ParamSpec {
+	return ParamSpec{Name: name, Type: t, Optional: true}
+}
+
+// paramSchemas lists the params of the methods wrapped by this package, as of Bitcoin Core v27. Methods not
+// listed are not validated.
+var paramSchemas = map[string][]ParamSpec{
+	// blockchain
+	"getbestblockhash":  {},
+	"getblock":          {param("blockhash", ParamString), optional("verbosity", ParamInt|ParamBool)},
+	"getblockchaininfo": {},
+	"getblockcount":     {},
+	"getblockhash":      {param("height", ParamInt)},
+	"getblockheader":    {param("blockhash", ParamString), optional("verbose", ParamBool)},
+	"getblockstats":     {param("hash_or_height", ParamString|ParamInt), optional("stats", ParamArray)},
+	"getchaintips":      {},
+	"getchaintxstats":   {optional("nblocks", ParamInt), optional("blockhash", ParamString)},
+	"getdifficulty":     {},
+	"getmempoolentry":   {param("txid", ParamString)},
+	"getmempoolinfo":    {},
+	"getrawmempool":     {optional("verbose", ParamBool), optional("mempool_sequence", ParamBool)},
+	"gettxout":          {param("txid", ParamString), param("n", ParamInt), optional("include_mempool", ParamBool)},
+	"gettxoutproof":     {param("txids", ParamArray), optional("blockhash", ParamString)},
+	"scantxoutset":      {param("action", ParamString), optional("scanobjects", ParamArray)},
+	"verifytxoutproof":  {param("proof", ParamString)},
+
+	// network
+	"addnode":            {param("node", ParamString), param("command", ParamString), optional("v2transport", ParamBool)},
+	"getaddednodeinfo":   {optional("node", ParamString)},
+	"getconnectioncount": {},
+	"getnetworkinfo":     {},
+	"getpeerinfo":        {},
+	"listbanned":         {},
+	"ping":               {},
+	"setban": {param("subnet", ParamString), param("command", ParamString), optional("bantime", ParamInt),
+		optional("absolute", ParamBool)},
+
+	// mining
+	"generatetoaddress": {param("nblocks", ParamInt), param("address", ParamString), optional("maxtries", ParamInt)},
+	"getblocktemplate":  {optional("template_request", ParamObject)},
+	"getmininginfo":     {},
+	"submitblock":       {param("hexdata", ParamString), optional("dummy", ParamString)},
+
+	// raw transactions and utilities
+	"createrawtransaction": {param("inputs", ParamArray), param("outputs", ParamArray|ParamObject),
+		optional("locktime", ParamInt), optional("replaceable", ParamBool)},
+	"decoderawtransaction": {param("hexstring", ParamString), optional("iswitness", ParamBool)},
+	"decodescript":         {param("hexstring", ParamString)},
+	"deriveaddresses":      {param("descriptor", ParamString), optional("range", ParamInt|ParamArray)},
+	"estimaterawfee":       {param("conf_target", ParamInt), optional("threshold", ParamNumber)},
+	"estimatesmartfee":     {param("conf_target", ParamInt), optional("estimate_mode", ParamString)},
+	"getdescriptorinfo":    {param("descriptor", ParamString)},
+	"getrawtransaction": {param("txid", ParamString), optional("verbosity", ParamInt|ParamBool),
+		optional("blockhash", ParamString)},
+	// SendRawTransactionWithoutFeeCheck passes the allowhighfees and dontcheckfee flags of Bitcoin SV nodes
+	"sendrawtransaction": {param("hexstring", ParamString), optional("maxfeerate", ParamNumber|ParamString|ParamBool),
+		optional("maxburnamount", ParamNumber|ParamString|ParamBool)},
+	"testmempoolaccept": {param("rawtxs", ParamArray), optional("maxfeerate", ParamNumber|ParamString)},
+	"validateaddress":   {param("address", ParamString)},
+
+	// control
+	"help":   {optional("command", ParamString)},
+	"uptime": {},
+
+	// wallet
+	"getaddressinfo": {param("address", ParamString)},
+	"getbalance": {optional("dummy", ParamString), optional("minconf", ParamInt), optional("include_watchonly", ParamBool),
+		optional("avoid_reuse", ParamBool)},
+	"getnewaddress": {optional("label", ParamString), optional("address_type", ParamString)},
+	"getreceivedbyaddress": {param("address", ParamString), optional("minconf", ParamInt),
+		optional("include_immature_coinbase", ParamBool)},
+	"gettransaction": {param("txid", ParamString), optional("include_watchonly", ParamBool), optional("verbose", ParamBool)},
+	"getwalletinfo":  {},
+	"listsinceblock": {optional("blockhash", ParamString), optional("target_confirmations", ParamInt),
+		optional("include_watchonly", ParamBool), optional("include_removed", ParamBool), optional("include_change", ParamBool),
+		optional("label", ParamString)},
+	"listtransactions": {optional("label", ParamString), optional("count", ParamInt), optional("skip", ParamInt),
+		optional("include_watchonly", ParamBool)},
+	"listunspent": {optional("minconf", ParamInt), optional("maxconf", ParamInt), optional("addresses", ParamArray),
+		optional("include_unsafe", ParamBool), optional("query_options", ParamObject)},
+	"listwallets": {},
+	"sendtoaddress": {param("address", ParamString), param("amount", ParamNumber|ParamString), optional("comment", ParamString),
+		optional("comment_to", ParamString), optional("subtractfeefromamount", ParamBool), optional("replaceable", ParamBool),
+		optional("conf_target", ParamInt), optional("estimate_mode", ParamString), optional("avoid_reuse", ParamBool),
+		optional("fee_rate", ParamNumber|ParamString), optional("verbose", ParamBool)},
+	"signrawtransactionwithwallet": {param("hexstring", ParamString), optional("prevtxs", ParamArray),
+		optional("sighashtype", ParamString)},
+	"walletpassphrase": {param("passphrase", ParamString), param("timeout", ParamInt)},
+}
+
+// WithParamValidation checks the params of calls against the schema of their method before they are sent,
+// failing with ErrInvalidParams and the name of the offending param instead of the node's RPC_INVALID_PARAMS
+// or RPC_TYPE_ERROR. schemas adds or replaces the schemas of methods, e.g. for a node with patched RPCs, and
+// may be nil. Calls with named params are not validated.
+func WithParamValidation(schemas map[string][]ParamSpec) func(*rpcClient) {
+	return func(p *rpcClient) {
+		merged := make(map[string][]ParamSpec, len(paramSchemas)+len(schemas))
+		for method, params := range paramSchemas {
+			merged[method] = params
+		}
+		for method, params := range schemas {
+			merged[strings.ToLower(method)] = params
+		}
+
+		p.paramSchemas = merged
+	}
+}
+
+// ValidateParams checks params against the schema of method, returning an error wrapping ErrInvalidParams
+// if they do not match. Methods without a schema are not validated.
+func ValidateParams(method string, params []interface{}) error {
+	return validateParams(paramSchemas, method, params)
+}
+
+// validateParams checks the params of a call if validation is enabled.
+func (c *rpcClient) validateParams(method string, params interface{}) error {
+	if c.paramSchemas == nil {
+		return nil
+	}
+
+	var p []interface{}
+	switch params := params.(type) {
+	case nil:
+	case []interface{}:
+		p = params
+	default:
+		return nil
+	}
+
+	return validateParams(c.paramSchemas, method, p)
+}
+
+func validateParams(schemas map[string][]ParamSpec, method string, params []interface{}) error {
+	specs, found := schemas[strings.ToLower(method)]
+	if !found {
+		return nil
+	}
+
+	if len(params) > len(specs) {
+		return fmt.Errorf("%w: %s takes at most %d params, got %d", ErrInvalidParams, method, len(specs), len(params))
+	}
+
+	for i, spec := range specs {
+		if i >= len(params) || params[i] == nil {
+			if !spec.Optional {
+				return fmt.Errorf("%w: %s param %d (%s) is required", ErrInvalidParams, method, i+1, spec.Name)
+			}
+			continue
+		}
+
+		if t := paramTypeOf(params[i]); t&spec.Type == 0 {
+			return fmt.Errorf("%w: %s param %d (%s) must be %s, got %s", ErrInvalidParams, method, i+1, spec.Name, spec.Type, t)
+		}
+	}
+
+	return nil
+}
+
+// paramTypeOf returns the JSON types a value is encoded as, where integers are also numbers.
+func paramTypeOf(v interface{}) ParamType {
+	switch v := v.(type) {
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return ParamNumber | ParamInt
+		}
+		return ParamNumber
+	case json.RawMessage:
+		return ParamAny
+	case []byte:
+		return ParamString
+	case json.Marshaler, encoding.TextMarshaler:
+		return ParamAny
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return ParamAny
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return ParamString
+	case reflect.Bool:
+		return ParamBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ParamNumber | ParamInt
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); f == float64(int64(f)) {
+			return ParamNumber | ParamInt
+		}
+		return ParamNumber
+	case reflect.Slice, reflect.Array:
+		return ParamArray
+	case reflect.Map, reflect.Struct:
+		return ParamObject
+	}
+
+	return ParamAny
+}
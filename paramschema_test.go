@@ -0,0 +1,62 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateParams(t *testing.T) {
+	assert.NoError(t, ValidateParams("getblock", []interface{}{"00", 2}))
+	assert.NoError(t, ValidateParams("getblock", []interface{}{"00", true}))
+	assert.NoError(t, ValidateParams("getblockstats", []interface{}{uint64(100), []string{"avgfee"}}))
+	assert.NoError(t, ValidateParams("sendtoaddress", []interface{}{"addr", 0.1, nil, nil, true}))
+	assert.NoError(t, ValidateParams("getblocktemplate", []interface{}{gbtParams{Rules: []string{"segwit"}}}))
+	assert.NoError(t, ValidateParams("estimatesmartfee", []interface{}{json.Number("6")}))
+	assert.NoError(t, ValidateParams("unknownmethod", []interface{}{1, "two", false}))
+
+	err := ValidateParams("getblockhash", []interface{}{"100"})
+	assert.True(t, errors.Is(err, ErrInvalidParams))
+	assert.EqualError(t, err, "invalid params: getblockhash param 1 (height) must be integer, got string")
+
+	assert.EqualError(t, ValidateParams("getblockhash", []interface{}{100.5}),
+		"invalid params: getblockhash param 1 (height) must be integer, got number")
+	assert.EqualError(t, ValidateParams("getblock", []interface{}{"00", "2"}),
+		"invalid params: getblock param 2 (verbosity) must be integer or bool, got string")
+	assert.EqualError(t, ValidateParams("gettxout", []interface{}{"00"}),
+		"invalid params: gettxout param 2 (n) is required")
+	assert.EqualError(t, ValidateParams("getblockcount", []interface{}{1}),
+		"invalid params: getblockcount takes at most 0 params, got 1")
+}
+
+func TestWithParamValidation(t *testing.T) {
+	var requests int
+	c, closeServer := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"result":"ok","error":null,"id":1}`))
+	})
+	defer closeServer()
+
+	v := c.derive(WithParamValidation(map[string][]ParamSpec{
+		"getblockhash": {{Name: "height", Type: ParamInt | ParamString}},
+	}))
+
+	_, err := v.call("gettxout", []interface{}{"00", "0"})
+	assert.True(t, errors.Is(err, ErrInvalidParams))
+	_, err = v.batch(context.Background(), "getblockheader", [][]interface{}{{"00"}, {"00", "false"}})
+	assert.True(t, errors.Is(err, ErrInvalidParams))
+	assert.Equal(t, 0, requests)
+
+	_, err = v.call("getblockhash", []interface{}{"100"})
+	require.NoError(t, err)
+
+	// Validation is opt-in
+	_, err = c.call("gettxout", []interface{}{"00", "0"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
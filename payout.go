@@ -0,0 +1,232 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Payout is a payment queued with a PayoutBatcher.
+type Payout struct {
+	ID      string // caller's reference, reported back in the PayoutResult
+	Address string
+	Amount  float64 // BTC
+}
+
+// PayoutResult reports where a payout was sent. When Err is set together with TxID the transaction was
+// built and signed but broadcasting it failed; it may or may not have reached the network, so the payout
+// is not queued again and has to be reconciled by the caller.
+type PayoutResult struct {
+	Payout
+	TxID string
+	Vout int
+	Err  error
+}
+
+// PayoutResults are the results of the payouts of a batch.
+type PayoutResults []PayoutResult
+
+// Batch returns the results as a BatchResult whose values are the PayoutResults, for handling them like
+// the results of other batch operations.
+func (r PayoutResults) Batch() *BatchResult {
+	result := newBatchResult(len(r))
+	for i, payout := range r {
+		result.Items[i].Value = payout
+		result.Items[i].Err = payout.Err
+	}
+
+	return result
+}
+
+// PayoutBatcher accumulates payouts and pays them in batched transactions from the wallet.
+type PayoutBatcher struct {
+	MaxOutputs  int     // payouts per transaction, excluding change
+	FeeRate     float64 // sat/vB, 0 lets the wallet estimate
+	MaxFee      uint64  // satoshis per transaction, a batch exceeding it is not sent; 0 means no limit
+	Replaceable bool    // signal BIP 125 replaceability so batches can be fee bumped
+	// OnResult is called for every payout processed by Run.
+	OnResult func(PayoutResult)
+
+	b     *Bitcoind
+	mu    sync.Mutex
+	queue []Payout
+	full  chan struct{}
+}
+
+// NewPayoutBatcher returns a PayoutBatcher paying from the given wallet, with up to 100 outputs per
+// transaction and replaceability enabled.
+func NewPayoutBatcher(b *Bitcoind) *PayoutBatcher {
+	return &PayoutBatcher{
+		MaxOutputs:  100,
+		Replaceable: true,
+		b:           b,
+		full:        make(chan struct{}, 1),
+	}
+}
+
+// Queue adds a payout to the next batch.
+func (p *PayoutBatcher) Queue(payout Payout) {
+	p.mu.Lock()
+	p.queue = append(p.queue, payout)
+	full := len(p.queue) >= p.MaxOutputs
+	p.mu.Unlock()
+
+	if full {
+		select {
+		case p.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Pending returns the number of queued payouts.
+func (p *PayoutBatcher) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.queue)
+}
+
+// Flush pays up to MaxOutputs queued payouts in one transaction. Payouts to an address already in the
+// batch wait for the next one, as a transaction cannot pay the same address twice. If the transaction
+// cannot be built the payouts stay queued and the error is returned.
+func (p *PayoutBatcher) Flush() (PayoutResults, error) {
+	p.mu.Lock()
+	var batch, rest []Payout
+	seen := make(map[string]bool)
+	for _, payout := range p.queue {
+		if len(batch) < p.MaxOutputs && !seen[payout.Address] {
+			seen[payout.Address] = true
+			batch = append(batch, payout)
+		} else {
+			rest = append(rest, payout)
+		}
+	}
+	p.queue = rest
+	p.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	signed, changePos, err := p.build(batch)
+	if err == nil {
+		err = p.b.authorizeTransaction(context.Background(), "sendrawtransaction", signed)
+	}
+
+	if err != nil {
+		p.mu.Lock()
+		p.queue = append(batch, p.queue...)
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	var txid string
+	raw, _ := hex.DecodeString(signed)
+	if tx, parseErr := parseTx(raw); parseErr == nil {
+		txid = tx.TxID
+	}
+
+	err = p.b.uncachedCall("sendrawtransaction", []interface{}{signed}, &txid)
+
+	results := make(PayoutResults, len(batch))
+	for i, payout := range batch {
+		vout := i
+		if changePos >= 0 && i >= changePos {
+			vout++
+		}
+		results[i] = PayoutResult{Payout: payout, TxID: txid, Vout: vout, Err: err}
+	}
+
+	return results, err
+}
+
+// build creates, funds and signs the batch transaction, returning it with the position of the change output.
+func (p *PayoutBatcher) build(batch []Payout) (string, int, error) {
+	// An array of single entry objects keeps the outputs in the order of the batch
+	outputs := make([]map[string]interface{}, len(batch))
+	for i, payout := range batch {
+		outputs[i] = map[string]interface{}{payout.Address: json.Number(fmt.Sprintf("%.8f", payout.Amount))}
+	}
+
+	var unfunded string
+	if err := p.b.uncachedCall("createrawtransaction", []interface{}{[]interface{}{}, outputs, 0, p.Replaceable}, &unfunded); err != nil {
+		return "", 0, err
+	}
+
+	options := map[string]interface{}{"replaceable": p.Replaceable}
+	if p.FeeRate > 0 {
+		options["fee_rate"] = p.FeeRate
+	}
+
+	var funded struct {
+		Hex       string  `json:"hex"`
+		Fee       float64 `json:"fee"`
+		ChangePos int     `json:"changepos"`
+	}
+	if err := p.b.uncachedCall("fundrawtransaction", []interface{}{unfunded, options}, &funded); err != nil {
+		return "", 0, err
+	}
+
+	if fee := uint64(math.Round(funded.Fee * 1e8)); p.MaxFee > 0 && fee > p.MaxFee {
+		return "", 0, fmt.Errorf("batch of %d payouts would pay a fee of %d satoshis, above the maximum of %d", len(batch), fee, p.MaxFee)
+	}
+
+	var signed struct {
+		Hex      string `json:"hex"`
+		Complete bool   `json:"complete"`
+	}
+	if err := p.b.uncachedCall("signrawtransactionwithwallet", []interface{}{funded.Hex}, &signed); err != nil {
+		return "", 0, err
+	}
+
+	if !signed.Complete {
+		return "", 0, errors.New("wallet could not sign the batch transaction")
+	}
+
+	return signed.Hex, funded.ChangePos, nil
+}
+
+// Run flushes the queue every interval, and as soon as a full batch is queued, until the context is done.
+func (p *PayoutBatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := p.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		case <-p.full:
+		}
+
+		for p.Pending() > 0 {
+			results, err := p.Flush()
+
+			if err != nil && len(results) == 0 {
+				p.b.client.logger.Errorf("PayoutBatcher: %v", err)
+				break
+			}
+
+			if err != nil {
+				p.b.client.logger.Errorf("PayoutBatcher: broadcasting %s failed, payouts need reconciliation: %v", results[0].TxID, err)
+			}
+
+			if p.OnResult != nil {
+				for _, r := range results {
+					p.OnResult(r)
+				}
+			}
+
+			// Only keep going while full batches are waiting, smaller ones wait for the next interval
+			if p.Pending() < p.MaxOutputs {
+				break
+			}
+		}
+	}
+}
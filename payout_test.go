@@ -0,0 +1,63 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestPayoutBatcher(t *testing.T) {
+	var outputs string
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		result := `null`
+		switch req.Method {
+		case "createrawtransaction":
+			outputs = fmt.Sprint(req.Params.([]interface{})[1])
+			result = `"unfunded"`
+		case "fundrawtransaction":
+			result = `{"hex":"funded","fee":0.00002,"changepos":1}`
+		case "signrawtransactionwithwallet":
+			result = `{"hex":"signed","complete":true}`
+		case "sendrawtransaction":
+			result = `"txid"`
+		}
+
+		_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+	})
+	defer done()
+
+	p := NewPayoutBatcher(b)
+	p.Queue(Payout{ID: "1", Address: "addr1", Amount: 0.1})
+	p.Queue(Payout{ID: "2", Address: "addr1", Amount: 0.2})
+	p.Queue(Payout{ID: "3", Address: "addr2", Amount: 0.3})
+
+	p.MaxFee = 1000
+	if _, err := p.Flush(); err == nil || p.Pending() != 3 {
+		t.Fatalf("expected the fee limit to keep the payouts queued, got %v with %d pending", err, p.Pending())
+	}
+
+	p.MaxFee = 0
+	results, err := p.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if outputs != "[map[addr1:0.1] map[addr2:0.3]]" {
+		t.Errorf("unexpected outputs %s", outputs)
+	}
+
+	// The change output at position 1 shifts the second payout
+	if len(results) != 2 || results[0].ID != "1" || results[0].Vout != 0 || results[1].ID != "3" || results[1].Vout != 2 || results[1].TxID != "txid" {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	// The second payout to addr1 waits for the next batch
+	if p.Pending() != 1 {
+		t.Errorf("expected 1 pending payout, got %d", p.Pending())
+	}
+}
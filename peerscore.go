@@ -0,0 +1,130 @@
+package bitcoin
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+)
+
+// PeerScoringPolicy configures how peers are scored by ScorePeers and which ones PrunePeers acts on.
+type PeerScoringPolicy struct {
+	MaxPingTime      float64       // seconds; slower peers are penalised
+	MaxMinFeeFilter  float64       // BTC/kvB; peers filtering above this are penalised
+	RequiredServices uint64        // service bits every peer should advertise
+	StallTimeout     time.Duration // a peer with blocks in flight that sent nothing for this long is starving us
+	DisconnectBelow  float64       // PrunePeers disconnects peers scoring below this
+	BanBelow         float64       // PrunePeers bans peers scoring below this
+	BanTime          time.Duration // duration of bans issued by PrunePeers
+	MaxActions       int           // maximum number of peers PrunePeers acts on per run, 0 means no limit
+}
+
+// DefaultPeerScoringPolicy is a conservative policy that only disconnects clearly bad peers and never bans.
+var DefaultPeerScoringPolicy = PeerScoringPolicy{
+	MaxPingTime:      2.0,
+	MaxMinFeeFilter:  0.0001,
+	RequiredServices: NodeNetwork | NodeWitness,
+	StallTimeout:     2 * time.Minute,
+	DisconnectBelow:  40,
+	MaxActions:       2,
+}
+
+// PeerScore is the score of a single peer between 0 (worst) and 100 (best) with the reasons for any penalties.
+type PeerScore struct {
+	Peer    Peer
+	Score   float64
+	Reasons []string
+}
+
+// ScorePeers scores the given peers according to the policy and returns them worst first. Stalls are measured
+// up to now, the time the peers were listed at.
+func ScorePeers(peers PeerInfo, policy PeerScoringPolicy, now time.Time) []PeerScore {
+	scores := make([]PeerScore, 0, len(peers))
+
+	for _, p := range peers {
+		s := PeerScore{Peer: p, Score: 100}
+
+		penalise := func(points float64, format string, args ...interface{}) {
+			s.Score -= points
+			s.Reasons = append(s.Reasons, fmt.Sprintf(format, args...))
+		}
+
+		if policy.MaxPingTime > 0 && p.PingTime > policy.MaxPingTime {
+			penalise(25, "ping %.3fs exceeds %.3fs", p.PingTime, policy.MaxPingTime)
+		}
+
+		if policy.MaxMinFeeFilter > 0 && p.MinFeeFilter > policy.MaxMinFeeFilter {
+			penalise(20, "minfeefilter %.8f exceeds %.8f", p.MinFeeFilter, policy.MaxMinFeeFilter)
+		}
+
+		if policy.RequiredServices != 0 {
+			services, err := p.ServiceFlags()
+			if err != nil || !services.Has(policy.RequiredServices) {
+				penalise(20, "missing required services (has %s)", p.Services)
+			}
+		}
+
+		if silent := now.Unix() - int64(p.LastRecv); policy.StallTimeout > 0 && len(p.Inflight) > 0 && silent > int64(policy.StallTimeout.Seconds()) {
+			penalise(40, "%d blocks in flight but nothing received for %ds", len(p.Inflight), silent)
+		}
+
+		if s.Score < 0 {
+			s.Score = 0
+		}
+
+		scores = append(scores, s)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score < scores[j].Score
+	})
+
+	return scores
+}
+
+// PrunePeers scores the node's current peers and disconnects or bans the worst offenders according to the
+// policy. Manually added and whitelisted peers are never acted on. It returns the scores of the peers acted on.
+func (b *Bitcoind) PrunePeers(policy PeerScoringPolicy) ([]PeerScore, error) {
+	peers, err := b.GetPeerInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	var acted []PeerScore
+
+	for _, s := range ScorePeers(peers, policy, b.client.clock.Now()) {
+		if policy.MaxActions > 0 && len(acted) >= policy.MaxActions {
+			break
+		}
+
+		if s.Peer.AddNode || s.Peer.WhiteListed || s.Peer.ConnectionType == "manual" {
+			continue
+		}
+
+		switch {
+		case policy.BanBelow > 0 && s.Score < policy.BanBelow:
+			host, _, err := net.SplitHostPort(s.Peer.Addr)
+			if err != nil {
+				host = s.Peer.Addr
+			}
+			if err := b.SetBan(host, "add", int64(policy.BanTime.Seconds()), false); err != nil {
+				return acted, err
+			}
+			b.client.logger.Infof("Banned peer %s with score %.0f: %v", s.Peer.Addr, s.Score, s.Reasons)
+
+		case policy.DisconnectBelow > 0 && s.Score < policy.DisconnectBelow:
+			if err := b.DisconnectNode(s.Peer.ID); err != nil {
+				return acted, err
+			}
+			b.client.logger.Infof("Disconnected peer %s with score %.0f: %v", s.Peer.Addr, s.Score, s.Reasons)
+
+		default:
+			// Scores are sorted worst first, so no other peer qualifies
+			return acted, nil
+		}
+
+		acted = append(acted, s)
+	}
+
+	return acted, nil
+}
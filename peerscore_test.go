@@ -0,0 +1,138 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScorePeers(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	peers := PeerInfo{
+		{ID: 1, Services: "0000000000000409", PingTime: 0.1},
+		{ID: 2, Services: "0000000000000001", PingTime: 5, MinFeeFilter: 0.001},
+		{ID: 3, Services: "0000000000000409", Inflight: []int{800000}, LastRecv: int(now.Add(-time.Hour).Unix())},
+		// Received recently enough as of now, even though long ago by the system clock
+		{ID: 4, Services: "0000000000000409", Inflight: []int{800000}, LastRecv: int(now.Add(-time.Minute).Unix())},
+	}
+
+	scores := ScorePeers(peers, DefaultPeerScoringPolicy, now)
+
+	if scores[0].Peer.ID != 2 || scores[0].Score != 35 {
+		t.Errorf("Expected peer 2 with score 35 first, got peer %d with %.0f", scores[0].Peer.ID, scores[0].Score)
+	}
+
+	if scores[1].Peer.ID != 3 || scores[1].Score != 60 || scores[1].Reasons[0] != "1 blocks in flight but nothing received for 3600s" {
+		t.Errorf("Expected stalled peer 3 with score 60 second, got peer %d with %.0f %v", scores[1].Peer.ID, scores[1].Score, scores[1].Reasons)
+	}
+
+	if scores[3].Peer.ID != 4 || scores[3].Score != 100 {
+		t.Errorf("Expected peer 4 with score 100 last, got peer %d with %.0f", scores[3].Peer.ID, scores[3].Score)
+	}
+}
+
+// peerNode lists the given peers and records the disconnectnode and setban calls it receives.
+type peerNode struct {
+	peers PeerInfo
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (n *peerNode) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	if req.Method == "getpeerinfo" {
+		result, _ := json.Marshal(n.peers)
+		fmt.Fprintf(w, `{"result":%s,"error":null,"id":1}`, result)
+		return
+	}
+
+	n.mu.Lock()
+	n.calls = append(n.calls, fmt.Sprintf("%s%v", req.Method, req.Params))
+	n.mu.Unlock()
+
+	fmt.Fprint(w, `{"result":null,"error":null,"id":1}`)
+}
+
+func TestPrunePeers(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	slow := func(id int, addr string) Peer {
+		return Peer{ID: id, Addr: addr, Services: "0000000000000001", PingTime: 5, MinFeeFilter: 0.001}
+	}
+
+	peers := PeerInfo{
+		{ID: 1, Addr: "198.51.100.1:8333", Services: "0000000000000409", PingTime: 0.1},
+		slow(2, "198.51.100.2:8333"),
+		{ID: 3, Addr: "[2001:db8::3]:8333", Services: "0000000000000409", Inflight: []int{800000}, LastRecv: int(now.Add(-time.Hour).Unix())},
+		{ID: 4, Addr: "198.51.100.4:8333", Services: "0000000000000409", Inflight: []int{800000}, LastRecv: int(now.Add(-time.Minute).Unix())},
+	}
+
+	// Peers the operator chose are never acted on
+	manual := slow(5, "198.51.100.5:8333")
+	manual.ConnectionType = "manual"
+	added := slow(6, "198.51.100.6:8333")
+	added.AddNode = true
+	whitelisted := slow(7, "198.51.100.7:8333")
+	whitelisted.WhiteListed = true
+	peers = append(peers, manual, added, whitelisted)
+
+	policy := DefaultPeerScoringPolicy
+	policy.BanBelow = 50
+	policy.BanTime = 24 * time.Hour
+	policy.DisconnectBelow = 70
+	policy.MaxActions = 0
+
+	node := &peerNode{peers: peers}
+	b, done := newTestNode(t, node.handle, WithClock(NewFakeClock(now)))
+	defer done()
+
+	acted, err := b.PrunePeers(policy)
+	require.NoError(t, err)
+
+	require.Len(t, acted, 2)
+	assert.Equal(t, 2, acted[0].Peer.ID)
+	assert.Equal(t, 3, acted[1].Peer.ID)
+	assert.Equal(t, []string{"setban[198.51.100.2 add 86400 false]", "disconnectnode[ 3]"}, node.calls)
+
+	// MaxActions limits the peers acted on per run, worst first
+	node.calls = nil
+	policy.MaxActions = 1
+
+	acted, err = b.PrunePeers(policy)
+	require.NoError(t, err)
+
+	require.Len(t, acted, 1)
+	assert.Equal(t, 2, acted[0].Peer.ID)
+	assert.Equal(t, []string{"setban[198.51.100.2 add 86400 false]"}, node.calls)
+}
+
+func TestPrunePeersError(t *testing.T) {
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		if req.Method == "getpeerinfo" {
+			fmt.Fprint(w, `{"result":[{"id":1,"addr":"198.51.100.1:8333","services":"0000000000000001","pingtime":5},{"id":2,"addr":"198.51.100.2:8333","services":"0000000000000001","pingtime":5}],"error":null,"id":1}`)
+			return
+		}
+		fmt.Fprint(w, `{"result":null,"error":{"code":-29,"message":"Node not found in connected nodes"},"id":1}`)
+	})
+	defer done()
+
+	policy := DefaultPeerScoringPolicy
+	policy.MaxActions = 0
+	policy.DisconnectBelow = 60
+
+	// The first failure stops the run
+	acted, err := b.PrunePeers(policy)
+	assert.Empty(t, acted)
+	assert.True(t, HasErrorCode(err, RPCClientNodeNotConnected))
+}
@@ -0,0 +1,85 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingLogger is a Logger counting the messages it receives.
+type countingLogger struct {
+	messages int64
+}
+
+func (l *countingLogger) log(string, ...interface{})                { atomic.AddInt64(&l.messages, 1) }
+func (l *countingLogger) Debugf(format string, args ...interface{}) { l.log(format, args...) }
+func (l *countingLogger) Infof(format string, args ...interface{})  { l.log(format, args...) }
+func (l *countingLogger) Warnf(format string, args ...interface{})  { l.log(format, args...) }
+func (l *countingLogger) Errorf(format string, args ...interface{}) { l.log(format, args...) }
+func (l *countingLogger) Fatalf(format string, args ...interface{}) { l.log(format, args...) }
+
+// TestConcurrentClient shares one client between goroutines using every part of the call path. It
+// finds nothing without the race detector, run it with go test -race.
+func TestConcurrentClient(t *testing.T) {
+	logger := &countingLogger{}
+	var hooks int64
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			// A batch
+			_, _ = w.Write([]byte(`[{"result":1,"error":null,"id":0},{"result":2,"error":null,"id":1}]`))
+			return
+		}
+
+		if req.Method == "slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(`{"result":"00","error":null,"id":1}`))
+	},
+		WithOptionalLogger(logger),
+		WithHTTPDebug(true),
+		WithBeforeHook(func(string, []interface{}) { atomic.AddInt64(&hooks, 1) }),
+		WithAfterHook(func(string, time.Duration, error) { atomic.AddInt64(&hooks, 1) }),
+		WithSlowQueryLog(time.Millisecond, nil),
+		WithTimeoutDuration(20*time.Millisecond),
+	)
+	defer done()
+
+	// Entries expire at once, so that cached calls keep going through the whole call path
+	b.Storage = cache.New(time.Nanosecond, time.Minute)
+
+	var wg sync.WaitGroup
+	run := func(f func(i int)) {
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				f(i)
+			}(i)
+		}
+	}
+
+	run(func(int) { _, _ = b.GetBestBlockHash() })
+	run(func(int) { _, _ = b.RawCall("getbestblockhash") })
+	run(func(int) { _, _ = b.client.call("slow", nil) })
+	run(func(int) { _, _ = b.client.batch(context.Background(), "getblockhash", [][]interface{}{{1}, {2}}) })
+	run(func(int) { _, _ = b.GetRawTransactionHex("aa") })
+	run(func(i int) {
+		derived := b.WithOptions(WithHeader("X-Worker", "w"), WithBeforeHook(func(string, []interface{}) {}))
+		_, _ = derived.GetBestBlockHash()
+	})
+	run(func(int) { b.UseTxIndex(NewTxIndex(1)) })
+
+	wg.Wait()
+
+	assert.NotZero(t, atomic.LoadInt64(&hooks))
+	assert.NotZero(t, atomic.LoadInt64(&logger.messages))
+}
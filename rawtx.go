@@ -0,0 +1,161 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+// SequenceReplaceable is the highest nSequence signaling BIP 125 replaceability. A transaction with any
+// input below SequenceFinal-1 signals, so relative locks imply it.
+const SequenceReplaceable uint32 = SequenceFinal - 2
+
+// ErrTxSigned is returned when rewriting a transaction that has signatures, which the change would invalidate.
+var ErrTxSigned = errors.New("transaction is signed")
+
+// RawTxInput is an input of a transaction built by CreateRawTransaction.
+type RawTxInput struct {
+	TxID string
+	Vout int
+	// Sequence is the nSequence of the input, 0 to derive it from Replaceable and LockTime.
+	Sequence uint32
+}
+
+// RawTxOptions are the options of CreateRawTransaction.
+type RawTxOptions struct {
+	LockTime    uint32
+	Replaceable bool // signal BIP 125 replaceability in the inputs without an explicit Sequence
+	Version     int32
+}
+
+// CreateRawTransaction builds an unsigned transaction spending inputs to outputs (address to BTC amount).
+// Inputs without a Sequence get SequenceReplaceable if opts.Replaceable is set, and otherwise SequenceFinal-1
+// with a lock time or SequenceFinal without, as the node would choose. A non zero opts.Version replaces the
+// node's default version, e.g. 3 for TRUC transactions, also on nodes too old to accept it as an argument.
+func (b *Bitcoind) CreateRawTransaction(inputs []RawTxInput, outputs map[string]float64, opts *RawTxOptions) (string, error) {
+	if opts == nil {
+		opts = &RawTxOptions{}
+	}
+
+	params := make([]map[string]interface{}, len(inputs))
+	for i, in := range inputs {
+		params[i] = map[string]interface{}{"txid": in.TxID, "vout": in.Vout}
+		if in.Sequence != 0 {
+			params[i]["sequence"] = in.Sequence
+		}
+	}
+
+	amounts := make(map[string]interface{}, len(outputs))
+	for address, amount := range outputs {
+		amounts[address] = json.Number(fmt.Sprintf("%.8f", amount))
+	}
+
+	var txHex string
+	if err := b.uncachedCall("createrawtransaction", []interface{}{params, amounts, opts.LockTime, opts.Replaceable}, &txHex); err != nil {
+		return "", err
+	}
+
+	if opts.Version == 0 {
+		return txHex, nil
+	}
+
+	return SetTxVersion(txHex, opts.Version)
+}
+
+// SetTxVersion returns the unsigned transaction txHex with its version replaced.
+func SetTxVersion(txHex string, version int32) (string, error) {
+	tx, err := parseUnsignedTx(txHex)
+	if err != nil {
+		return "", err
+	}
+
+	tx.Version = version
+	return hex.EncodeToString(serializeTx(tx)), nil
+}
+
+// SetReplaceable returns the unsigned transaction txHex opted in to or out of BIP 125 replacement. Opting in
+// lowers the nSequence of final inputs to SequenceReplaceable, opting out raises that of the other inputs
+// to SequenceFinal-1, keeping the lock time enforced. Inputs with a relative lock cannot opt out.
+func SetReplaceable(txHex string, replaceable bool) (string, error) {
+	tx, err := parseUnsignedTx(txHex)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range tx.Inputs {
+		in := &tx.Inputs[i]
+		if replaceable {
+			if in.Sequence > SequenceReplaceable {
+				in.Sequence = SequenceReplaceable
+			}
+			continue
+		}
+
+		if _, locked := DecodeRelativeLock(in.Sequence); locked && tx.Version >= 2 {
+			return "", fmt.Errorf("input %d has a relative lock, which signals replaceability", i)
+		}
+		if in.Sequence < SequenceFinal-1 {
+			in.Sequence = SequenceFinal - 1
+		}
+	}
+
+	return hex.EncodeToString(serializeTx(tx)), nil
+}
+
+// SignalsReplaceability reports whether a transaction opts in to BIP 125 replacement.
+func SignalsReplaceability(txHex string) (bool, error) {
+	tx, err := parseTxHex(txHex)
+	if err != nil {
+		return false, err
+	}
+
+	for _, in := range tx.Inputs {
+		if in.Sequence < SequenceFinal-1 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func parseUnsignedTx(txHex string) (*parsedTx, error) {
+	tx, err := parseTxHex(txHex)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, in := range tx.Inputs {
+		if len(in.ScriptSig) > 0 || len(in.Witness) > 0 {
+			return nil, fmt.Errorf("%w: input %d", ErrTxSigned, i)
+		}
+	}
+
+	return tx, nil
+}
+
+// serializeTx serializes a transaction without its witness data.
+func serializeTx(tx *parsedTx) []byte {
+	var buf bytes.Buffer
+
+	writeLE(&buf, tx.Version)
+	buf.Write(cryptolib.VarInt(uint64(len(tx.Inputs))))
+	for i := range tx.Inputs {
+		in := &tx.Inputs[i]
+		buf.Write(outpointBytes(in))
+		buf.Write(cryptolib.VarInt(uint64(len(in.ScriptSig))))
+		buf.Write(in.ScriptSig)
+		writeLE(&buf, in.Sequence)
+	}
+
+	buf.Write(cryptolib.VarInt(uint64(len(tx.Outputs))))
+	for i := range tx.Outputs {
+		writeOutput(&buf, &tx.Outputs[i])
+	}
+
+	writeLE(&buf, tx.LockTime)
+	return buf.Bytes()
+}
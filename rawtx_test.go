@@ -0,0 +1,84 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRawTransaction(t *testing.T) {
+	unsigned := hex.EncodeToString(testTx([]Outpoint{{TxID: strings.Repeat("ab", 32)}, {TxID: strings.Repeat("cd", 32), Vout: 1}}, "51", 1000))
+
+	var params []interface{}
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+
+		fmt.Fprintf(w, `{"result":%q,"error":null,"id":1}`, unsigned)
+	})
+	defer closeServer()
+
+	txHex, err := b.CreateRawTransaction([]RawTxInput{
+		{TxID: strings.Repeat("ab", 32)},
+		{TxID: strings.Repeat("cd", 32), Vout: 1, Sequence: 10},
+	}, map[string]float64{"addr": 0.00001}, &RawTxOptions{LockTime: 800000, Replaceable: true, Version: 3})
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{
+		[]interface{}{
+			map[string]interface{}{"txid": strings.Repeat("ab", 32), "vout": float64(0)},
+			map[string]interface{}{"txid": strings.Repeat("cd", 32), "vout": float64(1), "sequence": float64(10)},
+		},
+		map[string]interface{}{"addr": 0.00001},
+		float64(800000),
+		true,
+	}, params)
+
+	tx, err := parseTxHex(txHex)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), tx.Version)
+	assert.Equal(t, unsigned[8:], txHex[8:])
+
+	txHex, err = b.CreateRawTransaction(nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, unsigned, txHex)
+}
+
+func TestSetReplaceable(t *testing.T) {
+	unsigned := hex.EncodeToString(testTx([]Outpoint{{TxID: strings.Repeat("ab", 32)}}, "51", 1000))
+
+	signals, err := SignalsReplaceability(unsigned)
+	require.NoError(t, err)
+	assert.False(t, signals)
+
+	replaceable, err := SetReplaceable(unsigned, true)
+	require.NoError(t, err)
+	signals, err = SignalsReplaceability(replaceable)
+	require.NoError(t, err)
+	assert.True(t, signals)
+
+	final, err := SetReplaceable(replaceable, false)
+	require.NoError(t, err)
+	tx, err := parseTxHex(final)
+	require.NoError(t, err)
+	assert.Equal(t, SequenceFinal-1, tx.Inputs[0].Sequence)
+
+	// A relative lock signals replaceability in version 2 transactions
+	locked, err := SetTxVersion(strings.Replace(unsigned, "00ffffffff", "000a000000", 1), 2)
+	require.NoError(t, err)
+	_, err = SetReplaceable(locked, false)
+	assert.Error(t, err)
+
+	signed := strings.Replace(unsigned, "00ffffffff", "0151ffffffff", 1)
+	_, err = SetTxVersion(signed, 2)
+	assert.ErrorIs(t, err, ErrTxSigned)
+}
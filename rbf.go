@@ -0,0 +1,57 @@
+package bitcoin
+
+import "fmt"
+
+// ResolveRBFChain follows the replacements of an in-wallet transaction (bumpfee or other conflicting spends)
+// starting at the given original txid. It returns the transaction of the chain that confirmed, or the most
+// recent replacement if none has confirmed yet; callers can tell the two apart by its Confirmations. The
+// transactions are fetched bypassing the cache, so replacements and confirmations are seen immediately.
+func (b *Bitcoind) ResolveRBFChain(txid string) (*WalletTransaction, error) {
+	seen := make(map[string]bool)
+
+	getTransaction := func(txid string) (tx *WalletTransaction, err error) {
+		err = b.uncachedCall("gettransaction", []interface{}{txid}, &tx)
+		return
+	}
+
+	for {
+		if seen[txid] {
+			return nil, fmt.Errorf("replacement cycle at transaction %s", txid)
+		}
+		seen[txid] = true
+
+		tx, err := getTransaction(txid)
+		if err != nil {
+			return nil, err
+		}
+
+		if tx.Confirmations > 0 {
+			return tx, nil
+		}
+
+		if tx.ReplacedByTxID != "" {
+			txid = tx.ReplacedByTxID
+			continue
+		}
+
+		// A conflicted transaction that was not replaced by the wallet itself, check which conflict confirmed
+		if tx.Confirmations < 0 {
+			for _, conflict := range tx.WalletConflicts {
+				if seen[conflict] {
+					continue
+				}
+
+				conflictTx, err := getTransaction(conflict)
+				if err != nil {
+					return nil, err
+				}
+
+				if conflictTx.Confirmations > 0 {
+					return conflictTx, nil
+				}
+			}
+		}
+
+		return tx, nil
+	}
+}
@@ -0,0 +1,130 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rbfWallet is a wallet whose gettransaction results can change between calls.
+type rbfWallet struct {
+	mu  sync.Mutex
+	txs map[string]*WalletTransaction
+}
+
+func (w *rbfWallet) set(tx *WalletTransaction) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.txs[tx.TxID] = tx
+}
+
+func newRBFWallet(txs ...*WalletTransaction) *rbfWallet {
+	wallet := &rbfWallet{txs: make(map[string]*WalletTransaction)}
+	for _, tx := range txs {
+		wallet.set(tx)
+	}
+	return wallet
+}
+
+// handle answers gettransaction.
+func (w *rbfWallet) handle(rw http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	txid := req.Params.([]interface{})[0].(string)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, found := w.txs[txid]
+	if !found {
+		fmt.Fprint(rw, `{"result":null,"error":{"code":-5,"message":"Invalid or non-wallet transaction id"},"id":1}`)
+		return
+	}
+	result, _ := json.Marshal(tx)
+	fmt.Fprintf(rw, `{"result":%s,"error":null,"id":1}`, result)
+}
+
+func TestResolveRBFChainUnconfirmed(t *testing.T) {
+	b, done := newTestNode(t, newRBFWallet(
+		&WalletTransaction{TxID: "a", ReplacedByTxID: "b"},
+		&WalletTransaction{TxID: "b", ReplacesTxID: "a", ReplacedByTxID: "c"},
+		&WalletTransaction{TxID: "c", ReplacesTxID: "b"},
+	).handle)
+	defer done()
+
+	tx, err := b.ResolveRBFChain("a")
+	require.NoError(t, err)
+	assert.Equal(t, "c", tx.TxID)
+	assert.Equal(t, int64(0), tx.Confirmations)
+}
+
+func TestResolveRBFChainConfirmed(t *testing.T) {
+	b, done := newTestNode(t, newRBFWallet(
+		&WalletTransaction{TxID: "a", Confirmations: -2, ReplacedByTxID: "b", WalletConflicts: []string{"b"}},
+		&WalletTransaction{TxID: "b", Confirmations: 2, ReplacesTxID: "a", WalletConflicts: []string{"a"}},
+	).handle)
+	defer done()
+
+	tx, err := b.ResolveRBFChain("a")
+	require.NoError(t, err)
+	assert.Equal(t, "b", tx.TxID)
+	assert.Equal(t, int64(2), tx.Confirmations)
+}
+
+func TestResolveRBFChainConflict(t *testing.T) {
+	// The original was double spent by a transaction the wallet did not create as a replacement
+	b, done := newTestNode(t, newRBFWallet(
+		&WalletTransaction{TxID: "a", Confirmations: -1, WalletConflicts: []string{"x", "y"}},
+		&WalletTransaction{TxID: "x", Confirmations: -1, WalletConflicts: []string{"a", "y"}},
+		&WalletTransaction{TxID: "y", Confirmations: 1, WalletConflicts: []string{"a", "x"}},
+	).handle)
+	defer done()
+
+	tx, err := b.ResolveRBFChain("a")
+	require.NoError(t, err)
+	assert.Equal(t, "y", tx.TxID)
+}
+
+func TestResolveRBFChainSeesNewReplacement(t *testing.T) {
+	wallet := newRBFWallet(&WalletTransaction{TxID: "a"})
+	b, done := newTestNode(t, wallet.handle)
+	defer done()
+
+	tx, err := b.ResolveRBFChain("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", tx.TxID)
+
+	// Cached by an unrelated caller before the replacement confirmed
+	_, err = b.GetTransaction("a")
+	require.NoError(t, err)
+
+	wallet.set(&WalletTransaction{TxID: "a", Confirmations: -1, ReplacedByTxID: "b", WalletConflicts: []string{"b"}})
+	wallet.set(&WalletTransaction{TxID: "b", Confirmations: 1, ReplacesTxID: "a", WalletConflicts: []string{"a"}})
+
+	tx, err = b.ResolveRBFChain("a")
+	require.NoError(t, err)
+	assert.Equal(t, "b", tx.TxID)
+	assert.Equal(t, int64(1), tx.Confirmations)
+}
+
+func TestResolveRBFChainErrors(t *testing.T) {
+	b, done := newTestNode(t, newRBFWallet(
+		&WalletTransaction{TxID: "a", ReplacedByTxID: "b"},
+		&WalletTransaction{TxID: "b", ReplacedByTxID: "a"},
+		&WalletTransaction{TxID: "c", ReplacedByTxID: "missing"},
+	).handle)
+	defer done()
+
+	_, err := b.ResolveRBFChain("a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "replacement cycle")
+
+	_, err = b.ResolveRBFChain("c")
+	assert.True(t, HasErrorCode(err, RPCInvalidAddressOrKey))
+}
@@ -0,0 +1,87 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrReconnectGaveUp is returned by streaming components once a ReconnectPolicy's MaxAttempts failed in a row.
+var ErrReconnectGaveUp = errors.New("gave up reconnecting")
+
+// ReconnectPolicy is how long-lived subscriptions, the ZMQ subscriber, WalletTxStream and TemplateLongPoll,
+// wait after losing their connection to the node: InitialDelay after the first failure, growing by
+// Multiplier with every further failure up to MaxDelay.
+type ReconnectPolicy struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64 // 1 if 0 or less, a constant delay
+	MaxAttempts  int     // failures in a row before giving up, 0 to never give up
+	// OnReconnect is called once the connection works again after failures, with their number, for
+	// consumers to resync the events they may have missed in between.
+	OnReconnect func(failures int)
+}
+
+// DefaultReconnectPolicy waits 1s after the first failure, doubling up to a minute, and never gives up.
+var DefaultReconnectPolicy = ReconnectPolicy{
+	InitialDelay: time.Second,
+	MaxDelay:     time.Minute,
+	Multiplier:   2,
+}
+
+// Delay returns the wait after the given number of failures in a row, at least 1.
+func (p ReconnectPolicy) Delay(failures int) time.Duration {
+	d := p.InitialDelay
+	for i := 1; i < failures && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		if p.Multiplier <= 1 {
+			break
+		}
+		d = time.Duration(float64(d) * p.Multiplier)
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// reconnector tracks the failures of a connection under a ReconnectPolicy.
+type reconnector struct {
+	policy   ReconnectPolicy
+	clock    Clock
+	failures int
+}
+
+func newReconnector(policy ReconnectPolicy, clock Clock) *reconnector {
+	return &reconnector{policy: policy, clock: clockOrSystem(clock)}
+}
+
+// failed records a failure and waits for the delay before the next attempt. It returns the context's error
+// when it is done first, and ErrReconnectGaveUp once MaxAttempts is reached.
+func (r *reconnector) failed(ctx context.Context) error {
+	r.failures++
+	if r.policy.MaxAttempts > 0 && r.failures >= r.policy.MaxAttempts {
+		return fmt.Errorf("%w after %d attempts", ErrReconnectGaveUp, r.failures)
+	}
+
+	timer := r.clock.NewTimer(r.policy.Delay(r.failures))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// succeeded resets the failures, calling OnReconnect if there were any.
+func (r *reconnector) succeeded() {
+	failures := r.failures
+	r.failures = 0
+
+	if failures > 0 && r.policy.OnReconnect != nil {
+		r.policy.OnReconnect(failures)
+	}
+}
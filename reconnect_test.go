@@ -0,0 +1,103 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconnectPolicyDelay(t *testing.T) {
+	p := ReconnectPolicy{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 3}
+	assert.Equal(t, time.Second, p.Delay(1))
+	assert.Equal(t, 3*time.Second, p.Delay(2))
+	assert.Equal(t, 9*time.Second, p.Delay(3))
+	assert.Equal(t, 10*time.Second, p.Delay(4))
+	assert.Equal(t, 10*time.Second, p.Delay(100))
+
+	assert.Equal(t, 10*time.Second, zmqReconnectPolicy.Delay(5))
+}
+
+func TestReconnector(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	var reconnected []int
+	r := newReconnector(ReconnectPolicy{
+		InitialDelay: time.Second,
+		Multiplier:   2,
+		MaxAttempts:  3,
+		OnReconnect:  func(failures int) { reconnected = append(reconnected, failures) },
+	}, clock)
+
+	r.succeeded()
+	assert.Empty(t, reconnected)
+
+	done := make(chan error)
+	go func() { done <- r.failed(context.Background()) }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	require.NoError(t, <-done)
+
+	go func() { done <- r.failed(context.Background()) }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	select {
+	case <-done:
+		t.Fatal("the second retry waits 2s")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	require.NoError(t, <-done)
+
+	assert.ErrorIs(t, r.failed(context.Background()), ErrReconnectGaveUp)
+
+	r.succeeded()
+	assert.Equal(t, []int{3}, reconnected)
+}
+
+func TestTemplateLongPoll(t *testing.T) {
+	var longPollIDs []string
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []gbtParams `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		longPollIDs = append(longPollIDs, req.Params[0].LongPollID)
+
+		switch len(longPollIDs) {
+		case 1:
+			fmt.Fprint(w, `{"result":null,"error":{"code":-10,"message":"Bitcoin Core is in initial sync"},"id":1}`)
+		case 4:
+			// Lost the connection during a long poll
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprintf(w, `{"result":{"height":%d,"longpollid":"id%d"},"error":null,"id":1}`, len(longPollIDs), len(longPollIDs))
+		}
+	})
+	defer closeServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var heights []uint32
+	var reconnects []int
+	p := NewTemplateLongPoll(b)
+	p.Reconnect = ReconnectPolicy{InitialDelay: time.Millisecond, OnReconnect: func(failures int) { reconnects = append(reconnects, failures) }}
+	p.OnTemplate = func(template *BlockTemplate) {
+		heights = append(heights, template.Height)
+		if len(heights) == 3 {
+			cancel()
+		}
+	}
+
+	err := p.Run(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, []uint32{2, 3, 5}, heights)
+	assert.Equal(t, []string{"", "", "id2", "id3", ""}, longPollIDs)
+	assert.Equal(t, []int{1, 1}, reconnects)
+}
@@ -0,0 +1,151 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MissedEventsError is returned by ReplayCursor.Next when the consumer fell behind by more than the window
+// of the buffer. The cursor continues at the oldest event still buffered.
+type MissedEventsError struct {
+	Missed uint64
+}
+
+func (e *MissedEventsError) Error() string {
+	return fmt.Sprintf("missed %d events", e.Missed)
+}
+
+// ReplayBuffer keeps the latest events of a ZMQ subscription in a ring buffer, so that a consumer that is
+// briefly slow catches up instead of blocking the subscriber or losing events. Consumers read through their
+// own ReplayCursor; a consumer falling behind by more than the window is told how many events it missed. It
+// is safe for concurrent use.
+type ReplayBuffer struct {
+	mu     sync.Mutex
+	events []ZMQMessage
+	next   uint64 // position of the next event added, the count of all events added
+	added  chan struct{}
+}
+
+// NewReplayBuffer returns a buffer keeping the latest size events.
+func NewReplayBuffer(size int) *ReplayBuffer {
+	if size < 1 {
+		size = 1
+	}
+
+	return &ReplayBuffer{
+		events: make([]ZMQMessage, size),
+		added:  make(chan struct{}),
+	}
+}
+
+// Add appends an event, overwriting the oldest once the buffer is full. It never blocks.
+func (r *ReplayBuffer) Add(m ZMQMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[r.next%uint64(len(r.events))] = m
+	r.next++
+
+	close(r.added)
+	r.added = make(chan struct{})
+}
+
+// Follow adds the messages of the given topics of zmq to the buffer.
+func (r *ReplayBuffer) Follow(zmq *ZMQ, topics ...string) error {
+	ch := make(chan ZMQMessage, 100)
+
+	go func() {
+		for m := range ch {
+			r.Add(m)
+		}
+	}()
+
+	for _, topic := range topics {
+		if err := zmq.SubscribeRaw(topic, ch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Cursor returns a cursor starting at the next event added.
+func (r *ReplayBuffer) Cursor() *ReplayCursor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &ReplayCursor{buffer: r, pos: r.next}
+}
+
+// CursorAt returns a cursor replaying the events from position pos on, as returned by Position, for example
+// to resume a consumer where it left off. Positions after the latest event start at the next one.
+func (r *ReplayBuffer) CursorAt(pos uint64) *ReplayCursor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pos > r.next {
+		pos = r.next
+	}
+	return &ReplayCursor{buffer: r, pos: pos}
+}
+
+// ReplayCursor is the position of a consumer in a ReplayBuffer. It is not safe for concurrent use.
+type ReplayCursor struct {
+	buffer *ReplayBuffer
+	pos    uint64
+}
+
+// Position returns the position of the next event the cursor returns.
+func (c *ReplayCursor) Position() uint64 {
+	return c.pos
+}
+
+// Next returns the next event, waiting for it until the context is done. If the events after the previous
+// one have been overwritten it returns a *MissedEventsError, and the oldest buffered event on the next call.
+func (c *ReplayCursor) Next(ctx context.Context) (ZMQMessage, error) {
+	r := c.buffer
+
+	for {
+		r.mu.Lock()
+		size := uint64(len(r.events))
+
+		if r.next > size && c.pos < r.next-size {
+			missed := r.next - size - c.pos
+			c.pos = r.next - size
+			r.mu.Unlock()
+			return ZMQMessage{}, &MissedEventsError{Missed: missed}
+		}
+
+		if c.pos < r.next {
+			m := r.events[c.pos%size]
+			c.pos++
+			r.mu.Unlock()
+			return m, nil
+		}
+
+		added := r.added
+		r.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ZMQMessage{}, ctx.Err()
+		case <-added:
+		}
+	}
+}
+
+// Len returns the number of events buffered and not yet returned by the cursor.
+func (c *ReplayCursor) Len() int {
+	r := c.buffer
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c.pos >= r.next {
+		return 0
+	}
+	if n := r.next - c.pos; n < uint64(len(r.events)) {
+		return int(n)
+	}
+	return len(r.events)
+}
@@ -0,0 +1,64 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayBuffer(t *testing.T) {
+	r := NewReplayBuffer(3)
+	ctx := context.Background()
+
+	early := r.Cursor()
+	for i := uint32(1); i <= 2; i++ {
+		r.Add(ZMQMessage{Topic: "hashblock", Sequence: i})
+	}
+	late := r.Cursor()
+
+	m, err := early.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), m.Sequence)
+	assert.Equal(t, 1, early.Len())
+	assert.Equal(t, 0, late.Len())
+
+	// The slow consumer falls behind the window
+	for i := uint32(3); i <= 6; i++ {
+		r.Add(ZMQMessage{Topic: "hashblock", Sequence: i})
+	}
+
+	_, err = early.Next(ctx)
+	var missed *MissedEventsError
+	require.True(t, errors.As(err, &missed))
+	assert.Equal(t, uint64(2), missed.Missed)
+
+	for i := uint32(4); i <= 6; i++ {
+		m, err = early.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, i, m.Sequence)
+	}
+
+	// A consumer resumes from its position
+	resumed := r.CursorAt(late.Position())
+	_, err = resumed.Next(ctx)
+	assert.True(t, errors.As(err, &missed))
+	assert.Equal(t, uint64(1), missed.Missed)
+
+	// Waiting for the next event
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		r.Add(ZMQMessage{Topic: "hashtx", Sequence: 7})
+	}()
+	m, err = early.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hashtx", m.Topic)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = early.Next(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
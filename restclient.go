@@ -0,0 +1,296 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RestClient reads from the REST interface of a node, enabled with -rest. The interface needs no
+// authentication and serves blocks and transactions faster than JSON-RPC, but cannot estimate fees or
+// broadcast; those calls return ErrNotSupported.
+type RestClient struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    http.Header
+	logger     Logger
+	debugHTTP  bool
+}
+
+// RestFormat is the output format of a REST resource, chosen by the extension of its path.
+type RestFormat string
+
+const (
+	RestBinary RestFormat = "bin"
+	RestHex    RestFormat = "hex"
+	RestJSON   RestFormat = "json"
+)
+
+// NewRestClient returns a client for the REST interface of the node at host and port.
+func NewRestClient(host string, port int, useSSL bool) *RestClient {
+	scheme := "http"
+	if useSSL {
+		scheme = "https"
+	}
+
+	return &RestClient{
+		baseURL:    fmt.Sprintf("%s://%s:%d/rest", scheme, host, port),
+		httpClient: http.DefaultClient,
+		logger:     &DefaultLogger{},
+	}
+}
+
+// Rest returns a client for the REST interface of the node b is connected to, sharing its HTTP client and
+// therefore its transport, headers, logger and HTTP debugging.
+func (b *Bitcoind) Rest() *RestClient {
+	u, err := url.Parse(b.client.serverAddr)
+	if err != nil {
+		u = &url.URL{Scheme: "http", Host: b.client.serverAddr}
+	}
+
+	return &RestClient{
+		baseURL:    u.Scheme + "://" + u.Host + "/rest",
+		httpClient: b.client.httpClient,
+		headers:    b.client.headers,
+		logger:     b.client.logger,
+		debugHTTP:  b.client.debugHTTP,
+	}
+}
+
+// open requests path and returns the body of the response unread.
+func (c *RestClient) open(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	if c.debugHTTP {
+		c.logger.Infof("REST GET %s", req.URL)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		return nil, fmt.Errorf("ERROR: code %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return resp.Body, nil
+}
+
+func (c *RestClient) get(ctx context.Context, path string) ([]byte, error) {
+	body, err := c.open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return data, nil
+}
+
+// Get returns a REST resource in format, e.g. "block/notxdetails/<hash>" in RestJSON or "getutxos/checkmempool/
+// <txid>-<n>" in RestBinary. Hex output is returned without the trailing newline.
+func (c *RestClient) Get(ctx context.Context, resource string, format RestFormat) ([]byte, error) {
+	path, query := strings.TrimPrefix(resource, "/"), ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i:]
+	}
+
+	data, err := c.get(ctx, "/"+path+"."+string(format)+query)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == RestHex {
+		data = []byte(strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
+
+// GetBlock returns the block hash in format. Its JSON includes the details of every transaction.
+func (c *RestClient) GetBlock(ctx context.Context, hash string, format RestFormat) ([]byte, error) {
+	return c.Get(ctx, "block/"+hash, format)
+}
+
+// GetTransaction returns the transaction txid in format. Without -txindex only mempool transactions are found.
+func (c *RestClient) GetTransaction(ctx context.Context, txid string, format RestFormat) ([]byte, error) {
+	return c.Get(ctx, "tx/"+txid, format)
+}
+
+// StreamBlock returns the binary block hash as it is received, for decoding large blocks without holding
+// them in memory twice. The caller has to close it.
+func (c *RestClient) StreamBlock(ctx context.Context, hash string) (io.ReadCloser, error) {
+	return c.open(ctx, "/block/"+hash+".bin")
+}
+
+// GetBestBlockHash implements ChainBackend.
+func (c *RestClient) GetBestBlockHash(ctx context.Context) (string, error) {
+	data, err := c.get(ctx, "/chaininfo.json")
+	if err != nil {
+		return "", err
+	}
+
+	var info BlockchainInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", err
+	}
+
+	return info.BestBlockHash, nil
+}
+
+// GetBlockHash implements ChainBackend. The blockhashbyheight endpoint requires bitcoind 23 or later.
+func (c *RestClient) GetBlockHash(ctx context.Context, height int) (string, error) {
+	data, err := c.get(ctx, "/blockhashbyheight/"+strconv.Itoa(height)+".hex")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// GetRawBlock implements ChainBackend.
+func (c *RestClient) GetRawBlock(ctx context.Context, hash string) ([]byte, error) {
+	return c.get(ctx, "/block/"+hash+".bin")
+}
+
+// GetTransactionHex implements ChainBackend. Without -txindex only mempool transactions are found.
+func (c *RestClient) GetTransactionHex(ctx context.Context, txid string) (string, error) {
+	data, err := c.get(ctx, "/tx/"+txid+".bin")
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// maxRestHeaders is the most headers the headers endpoint returns per request.
+const maxRestHeaders = 2000
+
+// RawBlockHeader is a block header decoded from its 80 byte serialization.
+type RawBlockHeader struct {
+	Hash       string
+	Version    int32
+	PrevHash   string
+	MerkleRoot string
+	Time       uint32
+	Bits       uint32
+	Nonce      uint32
+	Raw        []byte
+}
+
+// GetHeaders returns up to count headers of the active chain starting with the block startHash, fewer if the
+// chain ends before. The headers are read in binary in batches of 2000 and decoded locally, which syncs a
+// chain of headers orders of magnitude faster than getblockheader calls. Each header is checked to link to
+// the one before. The count query parameter requires bitcoind 24 or later.
+func (c *RestClient) GetHeaders(ctx context.Context, startHash string, count int) ([]*RawBlockHeader, error) {
+	headers := make([]*RawBlockHeader, 0, count)
+	hash := startHash
+
+	for len(headers) < count {
+		// Batches after the first start again at the last header, as only its hash is known
+		n, skip := count-len(headers), 0
+		if len(headers) > 0 {
+			n, skip = n+1, 1
+		}
+		if n > maxRestHeaders {
+			n = maxRestHeaders
+		}
+
+		data, err := c.get(ctx, "/headers/"+hash+".bin?count="+strconv.Itoa(n))
+		if err != nil {
+			return nil, err
+		}
+		if len(data)%80 != 0 {
+			return nil, fmt.Errorf("headers response of %d bytes is not a multiple of 80", len(data))
+		}
+
+		received := len(data) / 80
+		for i := skip; i < received; i++ {
+			block, err := parseBlockHeader(data[i*80 : (i+1)*80])
+			if err != nil {
+				return nil, err
+			}
+
+			if len(headers) > 0 && block.PrevHash != headers[len(headers)-1].Hash {
+				return nil, fmt.Errorf("header %s does not follow %s", block.Hash, headers[len(headers)-1].Hash)
+			}
+			if len(headers) == 0 && block.Hash != startHash {
+				return nil, fmt.Errorf("first header is %s rather than %s", block.Hash, startHash)
+			}
+
+			headers = append(headers, &RawBlockHeader{
+				Hash:       block.Hash,
+				Version:    block.Version,
+				PrevHash:   block.PrevHash,
+				MerkleRoot: block.MerkleRoot,
+				Time:       block.Time,
+				Bits:       block.Bits,
+				Nonce:      block.Nonce,
+				Raw:        block.Header,
+			})
+		}
+
+		if received < n {
+			break
+		}
+		hash = headers[len(headers)-1].Hash
+	}
+
+	return headers, nil
+}
+
+// GetMempoolTxIDs implements ChainBackend.
+func (c *RestClient) GetMempoolTxIDs(ctx context.Context) ([]string, error) {
+	data, err := c.get(ctx, "/mempool/contents.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var contents map[string]json.RawMessage
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, err
+	}
+
+	txids := make([]string, 0, len(contents))
+	for txid := range contents {
+		txids = append(txids, txid)
+	}
+
+	return txids, nil
+}
+
+// EstimateFeeRate is not supported by the REST interface.
+func (c *RestClient) EstimateFeeRate(ctx context.Context, confTarget int) (float64, error) {
+	return 0, ErrNotSupported
+}
+
+// Broadcast is not supported by the REST interface.
+func (c *RestClient) Broadcast(ctx context.Context, txHex string) (string, error) {
+	return "", ErrNotSupported
+}
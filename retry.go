@@ -0,0 +1,183 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// retrySafeMethods lists the methods that only read node state and can therefore be retried automatically.
+// Any method not listed here, in particular anything that sends funds, broadcasts or changes wallet or node
+// state, is never retried: a retry after a timeout could repeat an action that already happened.
+var retrySafeMethods = map[string]bool{
+	// blockchain
+	"getbestblockhash":      true,
+	"getblock":              true,
+	"getblockbyheight":      true,
+	"getblockchaininfo":     true,
+	"getblockcount":         true,
+	"getblockfilter":        true,
+	"getblockhash":          true,
+	"getblockheader":        true,
+	"getblockstats":         true,
+	"getblockstatsbyheight": true,
+	"getchainstates":        true,
+	"getchaintips":          true,
+	"getchaintxstats":       true,
+	"getdeploymentinfo":     true,
+	"getdifficulty":         true,
+	"getmempoolancestors":   true,
+	"getmempooldescendants": true,
+	"getmempoolentry":       true,
+	"getmempoolinfo":        true,
+	"getorphantxs":          true,
+	"getrawmempool":         true,
+	"getrawnonfinalmempool": true,
+	"gettxout":              true,
+	"gettxoutproof":         true,
+	"gettxoutsetinfo":       true,
+	"gettxspendingprevout":  true,
+	"verifytxoutproof":      true,
+	"bb_getblock":           true,
+
+	// network
+	"getaddednodeinfo":   true,
+	"getconnectioncount": true,
+	"getnettotals":       true,
+	"getnetworkinfo":     true,
+	"getnodeaddresses":   true,
+	"getpeerinfo":        true,
+	"listbanned":         true,
+	"ping":               true,
+
+	// mining
+	"getblocktemplate":           true,
+	"getmininginfo":              true,
+	"getminingcandidate":         true,
+	"getnetworkhashps":           true,
+	"getprioritisedtransactions": true,
+
+	// raw transactions and utilities
+	"analyzepsbt":          true,
+	"decodepsbt":           true,
+	"decoderawtransaction": true,
+	"decodescript":         true,
+	"deriveaddresses":      true,
+	"estimaterawfee":       true,
+	"estimatesmartfee":     true,
+	"getdescriptorinfo":    true,
+	"getindexinfo":         true,
+	"getrawtransaction":    true,
+	"testmempoolaccept":    true,
+	"validateaddress":      true,
+	"verifymessage":        true,
+
+	// control
+	"getinfo":       true,
+	"getmemoryinfo": true,
+	"getrpcinfo":    true,
+	"getsettings":   true,
+	"help":          true,
+	"uptime":        true,
+
+	// wallet reads
+	"getaddressesbylabel":   true,
+	"getaddressinfo":        true,
+	"getbalance":            true,
+	"getbalances":           true,
+	"getreceivedbyaddress":  true,
+	"getreceivedbylabel":    true,
+	"gettransaction":        true,
+	"getwalletinfo":         true,
+	"listaddressgroupings":  true,
+	"listdescriptors":       true,
+	"listlabels":            true,
+	"listlockunspent":       true,
+	"listreceivedbyaddress": true,
+	"listreceivedbylabel":   true,
+	"listsinceblock":        true,
+	"listtransactions":      true,
+	"listunspent":           true,
+	"listwallets":           true,
+	"listwalletdir":         true,
+}
+
+// IsRetrySafe reports whether the given RPC method is a pure read that can safely be retried automatically.
+// State changing methods such as sendtoaddress or sendrawtransaction, and methods unknown to this package,
+// are reported as unsafe.
+func IsRetrySafe(method string) bool {
+	return retrySafeMethods[strings.ToLower(method)]
+}
+
+// retryPolicy is set with WithRetry.
+type retryPolicy struct {
+	maxAttempts int
+	backoff     func(failures int) time.Duration
+}
+
+// WithRetry repeats failed calls to the methods IsRetrySafe reports as safe, up to maxAttempts calls in
+// total, when they fail with a network error, a timeout, a 5xx response that is not an error of the node, or
+// an error of a node still starting up or syncing (see IsRetryable). backoff returns the wait after the given
+// number of failures in a row, e.g. the Delay of a ReconnectPolicy for an exponential backoff:
+//
+//	WithRetry(5, ReconnectPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second, Multiplier: 2}.Delay)
+//
+// A nil backoff waits as DefaultReconnectPolicy. Every attempt runs the hooks and is counted in ErrorStats.
+func WithRetry(maxAttempts int, backoff func(failures int) time.Duration) func(*rpcClient) {
+	return func(p *rpcClient) {
+		if backoff == nil {
+			backoff = DefaultReconnectPolicy.Delay
+		}
+		p.retry = &retryPolicy{maxAttempts: maxAttempts, backoff: backoff}
+	}
+}
+
+// retryAfter reports whether the call to method failing with err on the given attempt is to be repeated,
+// after waiting for the backoff. It reports false if ctx is done before.
+func (c *rpcClient) retryAfter(ctx context.Context, method string, attempt int, err error) bool {
+	if err == nil || c.retry == nil || attempt >= c.retry.maxAttempts || !IsRetrySafe(method) || !isTransient(err) ||
+		ctx.Err() != nil {
+		return false
+	}
+
+	delay := c.retry.backoff(attempt)
+	c.loggerFor(ctx).Infof("Retrying %s in %s after attempt %d failed: %v", method, delay, attempt, err)
+
+	timer := c.clock.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
+
+// isTransient reports whether err may go away by itself: a failure to reach the node or read its response,
+// a 5xx response of something in front of the node, or an error of a node that is not ready yet.
+func isTransient(err error) bool {
+	var tooLarge *ResponseTooLargeError
+	if errors.As(err, &tooLarge) {
+		return false
+	}
+
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		// The node answers errors with status 500, only some of them are transient
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			return IsRetryable(err)
+		}
+		return statusErr.status >= 500
+	}
+
+	if errors.Is(err, ErrTimeout) || IsRetryable(err) {
+		return true
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || strings.HasPrefix(err.Error(), "failed to read response")
+}
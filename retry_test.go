@@ -0,0 +1,116 @@
+package bitcoin
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetrySafe(t *testing.T) {
+	for _, method := range []string{"getblock", "getrawtransaction", "GetBlockHash", "listunspent"} {
+		if !IsRetrySafe(method) {
+			t.Errorf("Expected %s to be retry safe", method)
+		}
+	}
+
+	for _, method := range []string{"sendtoaddress", "sendrawtransaction", "sendmany", "submitblock", "unknownmethod"} {
+		if IsRetrySafe(method) {
+			t.Errorf("Expected %s not to be retry safe", method)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	noWait := func(int) time.Duration { return 0 }
+
+	tests := []struct {
+		name     string
+		method   string
+		failures int32 // responses failing with fail before the node answers
+		fail     func(w http.ResponseWriter)
+		hits     int32
+		wantErr  string
+	}{
+		{"proxy error", "getblockcount", 2, func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, 3, ""},
+		{"warmup", "getblockcount", 1, func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":1}`))
+		}, 2, ""},
+		{"warmup with status 200", "getblockcount", 1, func(w http.ResponseWriter) {
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":1}`))
+		}, 2, ""},
+		{"node error", "getblockhash", 1, func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-8,"message":"Block height out of range"},"id":1}`))
+		}, 1, "unexpected response code 500: Block height out of range"},
+		{"not retry safe", "sendtoaddress", 1, func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, 1, "unexpected response code 503: HTTP error: 503 Service Unavailable"},
+		{"attempts exhausted", "getblockcount", 5, func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, 3, "unexpected response code 503: HTTP error: 503 Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&hits, 1) <= tt.failures {
+					tt.fail(w)
+					return
+				}
+				_, _ = w.Write([]byte(`{"result":42,"error":null,"id":1}`))
+			}, WithRetry(3, noWait))
+			defer done()
+
+			rr, err := b.client.call(tt.method, nil)
+
+			assert.Equal(t, tt.hits, atomic.LoadInt32(&hits))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Nil(t, rr.Err)
+			assert.Equal(t, "42", string(rr.Result))
+
+			stats := b.ErrorStats()
+			assert.Equal(t, uint64(tt.hits), stats.Calls)
+			assert.Equal(t, uint64(tt.failures), stats.Errors)
+		})
+	}
+}
+
+func TestWithRetryBackoff(t *testing.T) {
+	var hits int32
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var delays []int
+	c = c.derive(WithRetry(5, func(failures int) time.Duration {
+		delays = append(delays, failures)
+		if failures == 3 {
+			// Cancelling during the wait returns the last error
+			cancel()
+			return time.Hour
+		}
+		return time.Millisecond
+	}))
+
+	_, err := c.batch(ctx, "getblockhash", [][]interface{}{{0}, {1}})
+	assert.EqualError(t, err, "unexpected response code 503: HTTP error: 503 Service Unavailable")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+	assert.Equal(t, []int{1, 2, 3}, delays)
+}
@@ -2,6 +2,7 @@ package bitcoin
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -12,18 +13,39 @@ import (
 	"net/http/httputil"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	rpcClientTimeoutSecondsDefault = 120
+	slowQueryParamMaxLen           = 64
 )
 
-var (
-	ErrTimeout        = errors.New("Timeout reading data from server")
-	debugHttpDumpBody = os.Getenv("debug_http_dump_body")
-	debugHttp         = os.Getenv("debug_http")
-)
+// abortActions maps long running methods to the call that stops them on the node.
+var abortActions = map[string]struct {
+	method string
+	params []interface{}
+}{
+	"scantxoutset":      {"scantxoutset", []interface{}{"abort"}},
+	"rescanblockchain":  {"abortrescan", nil},
+	"importdescriptors": {"abortrescan", nil},
+	"importmulti":       {"abortrescan", nil},
+}
+
+// startsWork reports whether a call to a method in abortActions starts node side work, as opposed to
+// e.g. querying the status of a scan.
+func startsWork(method string, params interface{}) bool {
+	if method != "scantxoutset" {
+		return true
+	}
+
+	p, _ := params.([]interface{})
+	return len(p) > 0 && p[0] == "start"
+}
+
+var ErrTimeout = errors.New("Timeout reading data from server")
 
 // A rpcClient represents a JSON RPC client (over HTTP(s)).
 type rpcClient struct {
@@ -33,6 +55,83 @@ type rpcClient struct {
 	httpClient       *http.Client
 	logger           Logger
 	rpcClientTimeout time.Duration
+	headers          http.Header
+	beforeHooks      []func(ctx context.Context, method string, params []interface{})
+	afterHooks       []func(ctx context.Context, method string, dur time.Duration, err error)
+	callHooks        []func(CallInfo)
+	bandwidthHooks   []func(BandwidthSample)
+	maxResponseBytes int64
+	slowThreshold    time.Duration
+	onSlowQuery      func(SlowQuery)
+	hiddenCalls      bool
+	withdrawalPolicy *WithdrawalPolicy
+	clock            Clock
+	debugHTTP        bool
+	debugHTTPBody    bool
+	deprecations     *deprecationState
+	journal          *journal
+	timeouts         *TimeoutProfile
+	paramSchemas     map[string][]ParamSpec
+	lastActivity     *int64 // unix nanoseconds of the end of the last call, shared by derived clients
+	stats            *callStats
+	retry            *retryPolicy
+}
+
+// SlowQuery describes a call that exceeded the threshold set with WithSlowQueryLog.
+type SlowQuery struct {
+	Method   string
+	Params   string // summary of the parameters with long values shortened
+	Duration time.Duration
+	Err      error
+	Context  context.Context // of the call, with its request-scoped values
+}
+
+// CallInfo describes a finished request, see WithCallHook.
+type CallInfo struct {
+	Method        string
+	Params        []interface{} // nil for batches
+	Duration      time.Duration
+	Err           error
+	Context       context.Context // of the call, with its request-scoped values
+	RequestBytes  int             // size of the encoded request body
+	ResponseBytes int             // size of the response body, zero for streamed responses whose body the caller reads
+}
+
+// ResponseTooLargeError is returned when a response exceeds the limit set with WithMaxResponseBytes.
+type ResponseTooLargeError struct {
+	Method string
+	Limit  int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response to %s exceeds the limit of %d bytes", e.Method, e.Limit)
+}
+
+// limitedBody aborts reading a response body once more than limit bytes have been read.
+type limitedBody struct {
+	io.ReadCloser
+	method    string
+	limit     int64
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Probe for a single byte to distinguish a body of exactly limit bytes from a larger one
+		var probe [1]byte
+		n, err := l.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, &ResponseTooLargeError{Method: l.method, Limit: l.limit}
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	return n, err
 }
 
 // rpcRequest represent a RCP request
@@ -56,14 +155,30 @@ type rpcResponse struct {
 	Err    interface{}     `json:"error"`
 }
 
-func (c *rpcClient) debug(data []byte, err error) {
-	if err == nil {
-		c.logger.Infof("%s\n\n", data)
-	} else {
-		c.logger.Errorf("ERROR: %s\n\n", err)
+// debugFor returns the function logging HTTP dumps of a request made with ctx.
+func (c *rpcClient) debugFor(ctx context.Context) func(data []byte, err error) {
+	logger := c.loggerFor(ctx)
+	return func(data []byte, err error) {
+		if err == nil {
+			logger.Infof("%s\n\n", data)
+		} else {
+			logger.Errorf("ERROR: %s\n\n", err)
+		}
+	}
+}
+
+// WithHTTPDebug logs every request and response, including their bodies if dumpBody is set. Without the
+// option this is enabled by setting the debug_http environment variable to true, and debug_http_dump_body
+// for the bodies.
+func WithHTTPDebug(dumpBody bool) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.debugHTTP = true
+		p.debugHTTPBody = dumpBody
 	}
 }
 
+// WithTimeoutDuration sets the timeout of calls, the default of 120s. With WithTimeoutProfile it only
+// applies to the methods the profile sets no timeout for.
 func WithTimeoutDuration(d time.Duration) func(*rpcClient) {
 	return func(p *rpcClient) {
 		p.rpcClientTimeout = d
@@ -76,6 +191,90 @@ func WithOptionalLogger(l Logger) func(*rpcClient) {
 	}
 }
 
+// WithHeader adds an HTTP header that is sent with every request.
+func WithHeader(key, value string) func(*rpcClient) {
+	return func(p *rpcClient) {
+		if p.headers == nil {
+			p.headers = make(http.Header)
+		}
+		p.headers.Add(key, value)
+	}
+}
+
+// WithBeforeHook registers a function that is called before every request is sent.
+func WithBeforeHook(hook func(method string, params []interface{})) func(*rpcClient) {
+	return WithBeforeHookContext(func(_ context.Context, method string, params []interface{}) {
+		hook(method, params)
+	})
+}
+
+// WithBeforeHookContext is WithBeforeHook for hooks that need the context of the call, e.g. to read the
+// trace ID of the request it is made for.
+func WithBeforeHookContext(hook func(ctx context.Context, method string, params []interface{})) func(*rpcClient) {
+	return func(p *rpcClient) {
+		// limit the capacity so that clients derived with WithOptions never share the backing array
+		p.beforeHooks = append(p.beforeHooks[:len(p.beforeHooks):len(p.beforeHooks)], hook)
+	}
+}
+
+// WithAfterHook registers a function that is called after every request with its duration and error, if any.
+func WithAfterHook(hook func(method string, dur time.Duration, err error)) func(*rpcClient) {
+	return WithAfterHookContext(func(_ context.Context, method string, dur time.Duration, err error) {
+		hook(method, dur, err)
+	})
+}
+
+// WithAfterHookContext is WithAfterHook for hooks that need the context of the call, e.g. to label a metric
+// with the tenant it is made for.
+func WithAfterHookContext(hook func(ctx context.Context, method string, dur time.Duration, err error)) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.afterHooks = append(p.afterHooks[:len(p.afterHooks):len(p.afterHooks)], hook)
+	}
+}
+
+// WithCallHook registers a function that is called after every request with a description of it, including
+// the number of bytes sent and received, e.g. to export the client's traffic as metrics.
+func WithCallHook(hook func(CallInfo)) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.callHooks = append(p.callHooks[:len(p.callHooks):len(p.callHooks)], hook)
+	}
+}
+
+// WithMaxResponseBytes limits the size of responses. Reading a larger response is aborted with a
+// *ResponseTooLargeError, protecting memory when a call unexpectedly returns a huge result.
+func WithMaxResponseBytes(n int64) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.maxResponseBytes = n
+	}
+}
+
+// WithSlowQueryLog logs a warning for every call taking longer than threshold. If callback is not nil it is
+// also called, for example to record a metric.
+func WithSlowQueryLog(threshold time.Duration, callback func(SlowQuery)) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.slowThreshold = threshold
+		p.onSlowQuery = callback
+	}
+}
+
+// WithTransport sends requests through the given round tripper, e.g. to add tracing, or a ChaosTransport
+// in resilience tests. It replaces the transport configured for SSL; wrap it to keep it.
+func WithTransport(rt http.RoundTripper) func(*rpcClient) {
+	return func(p *rpcClient) {
+		hc := *p.httpClient
+		hc.Transport = rt
+		p.httpClient = &hc
+	}
+}
+
+// WithHiddenCalls enables wrappers for hidden RPCs such as estimaterawfee. Their output is not part of the
+// node's stable interface and may change between releases.
+func WithHiddenCalls() func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.hiddenCalls = true
+	}
+}
+
 type Option func(f *rpcClient)
 
 func newClient(host string, port int, path, user, passwd string, useSSL bool, opts ...Option) (c *rpcClient, err error) {
@@ -108,6 +307,11 @@ func newClient(host string, port int, path, user, passwd string, useSSL bool, op
 		httpClient:       httpClient,
 		logger:           &DefaultLogger{},
 		rpcClientTimeout: rpcClientTimeoutSecondsDefault * time.Second,
+		clock:            SystemClock,
+		lastActivity:     new(int64),
+		stats:            &callStats{},
+		debugHTTP:        os.Getenv("debug_http") == "true",
+		debugHTTPBody:    os.Getenv("debug_http_dump_body") == "true",
 	}
 
 	// apply options to client
@@ -118,35 +322,269 @@ func newClient(host string, port int, path, user, passwd string, useSSL bool, op
 	return
 }
 
-// doTimeoutRequest process a HTTP request with timeout
-func (c *rpcClient) doTimeoutRequest(timer *time.Timer, req *http.Request) (*http.Response, error) {
-	type result struct {
-		resp *http.Response
-		err  error
+// derive returns a copy of the client sharing the underlying http client (and therefore its connections)
+// with the given options applied.
+func (c *rpcClient) derive(opts ...Option) *rpcClient {
+	d := *c
+	d.headers = c.headers.Clone()
+
+	for _, opt := range opts {
+		opt(&d)
+	}
+
+	return &d
+}
+
+// limitBody applies the configured response size limit to a response body.
+func (c *rpcClient) limitBody(method string, body io.ReadCloser) io.ReadCloser {
+	if c.maxResponseBytes <= 0 {
+		return body
+	}
+
+	return &limitedBody{ReadCloser: body, method: method, limit: c.maxResponseBytes, remaining: c.maxResponseBytes}
+}
+
+func (c *rpcClient) setHeaders(req *http.Request) {
+	req.Header.Add("Content-Type", "application/json;charset=utf-8")
+	req.Header.Add("Accept", "application/json")
+
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
 	}
-	done := make(chan result, 1)
-	go func() {
-		if debugHttp == "true" {
-			c.debug(httputil.DumpRequestOut(req, debugHttpDumpBody == "true"))
+}
+
+// doTimeoutRequest sends a HTTP request that is aborted when timer fires before the response body is
+// closed. The request context is cancelled on timeout, so the connection is torn down rather than left
+// to finish in the background, and reads of the body return ErrTimeout from then on.
+func (c *rpcClient) doTimeoutRequest(timer Timer, req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	req = req.WithContext(ctx)
+
+	t := &timeoutBody{stop: make(chan struct{}), cancel: cancel}
+	go t.watch(timer)
+
+	if c.debugHTTP {
+		c.debugFor(ctx)(httputil.DumpRequestOut(req, c.debugHTTPBody))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		t.release()
+		if t.expired() {
+			err = ErrTimeout
+		}
+		if c.debugHTTP {
+			c.debugFor(ctx)(nil, err)
 		}
-		resp, err := c.httpClient.Do(req)
-		done <- result{resp, err}
-	}()
-	// Wait for the read or the timeout
+		return nil, err
+	}
+
+	if c.debugHTTP {
+		c.debugFor(ctx)(httputil.DumpResponse(resp, c.debugHTTPBody))
+	}
+
+	t.ReadCloser = resp.Body
+	resp.Body = t
+	return resp, nil
+}
+
+// timeoutBody is the body of a response sent by doTimeoutRequest. Closing it stops watching the timer.
+type timeoutBody struct {
+	io.ReadCloser
+	stop     chan struct{}
+	cancel   context.CancelFunc
+	once     sync.Once
+	timedOut int32
+}
+
+// watch cancels the request when timer fires before the body is closed.
+func (t *timeoutBody) watch(timer Timer) {
 	select {
-	case r := <-done:
-		if debugHttp == "true" {
-			c.debug(httputil.DumpResponse(r.resp, debugHttpDumpBody == "true"))
+	case <-timer.C():
+		atomic.StoreInt32(&t.timedOut, 1)
+		t.cancel()
+	case <-t.stop:
+	}
+}
+
+func (t *timeoutBody) expired() bool {
+	return atomic.LoadInt32(&t.timedOut) == 1
+}
+
+func (t *timeoutBody) release() {
+	t.once.Do(func() {
+		close(t.stop)
+		t.cancel()
+	})
+}
+
+func (t *timeoutBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && t.expired() {
+		err = ErrTimeout
+	}
+	return n, err
+}
+
+func (t *timeoutBody) Close() error {
+	err := t.ReadCloser.Close()
+	t.release()
+	return err
+}
+
+func (c *rpcClient) runBeforeHooks(ctx context.Context, method string, params interface{}) {
+	if len(c.beforeHooks) == 0 {
+		return
+	}
+
+	p, _ := params.([]interface{})
+	for _, hook := range c.beforeHooks {
+		hook(ctx, method, p)
+	}
+}
+
+func (c *rpcClient) runAfterHooks(ctx context.Context, method string, params interface{}, start time.Time, t transfer, err error) {
+	now := c.clock.Now()
+	dur := now.Sub(start)
+
+	if c.lastActivity != nil {
+		atomic.StoreInt64(c.lastActivity, now.UnixNano())
+	}
+
+	if c.slowThreshold > 0 && dur > c.slowThreshold {
+		q := SlowQuery{
+			Method:   method,
+			Params:   summarizeParams(params),
+			Duration: dur,
+			Err:      err,
+			Context:  ctx,
 		}
-		return r.resp, r.err
-	case <-timer.C:
-		return nil, ErrTimeout
+
+		c.loggerFor(ctx).Warnf("Slow RPC call %s(%s) took %s", q.Method, q.Params, q.Duration)
+
+		if c.onSlowQuery != nil {
+			c.onSlowQuery(q)
+		}
+	}
+
+	for _, hook := range c.afterHooks {
+		hook(ctx, method, dur, err)
+	}
+
+	if len(c.callHooks) > 0 {
+		p, _ := params.([]interface{})
+		info := CallInfo{
+			Method:        method,
+			Params:        p,
+			Duration:      dur,
+			Err:           err,
+			Context:       ctx,
+			RequestBytes:  t.sent,
+			ResponseBytes: t.received,
+		}
+		for _, hook := range c.callHooks {
+			hook(info)
+		}
+	}
+}
+
+// transfer counts the bytes of a request and its response for the call hooks.
+type transfer struct {
+	sent, received int
+}
+
+// summarizeParams renders call parameters for logging, shortening long values such as raw transactions.
+func summarizeParams(params interface{}) string {
+	p, ok := params.([]interface{})
+	if !ok {
+		if params == nil {
+			return ""
+		}
+		p = []interface{}{params}
+	}
+
+	parts := make([]string, len(p))
+	for i, v := range p {
+		s := fmt.Sprintf("%v", v)
+		if len(s) > slowQueryParamMaxLen {
+			s = fmt.Sprintf("%s...(%d chars)", s[:slowQueryParamMaxLen], len(s))
+		}
+		parts[i] = s
 	}
+
+	return strings.Join(parts, ", ")
 }
 
-// call prepare & exec the request
+// call runs the hooks around doCall
 func (c *rpcClient) call(method string, params interface{}) (rpcResponse, error) {
-	connectTimer := time.NewTimer(c.rpcClientTimeout)
+	return c.callContext(context.Background(), method, params)
+}
+
+// callContext is call with a context that cancels the request. Cancelling a long running method that can be
+// stopped node side (see abortActions) also sends the corresponding abort call, releasing the node's resources.
+func (c *rpcClient) callContext(ctx context.Context, method string, params interface{}) (rpcResponse, error) {
+	if err := c.validateParams(method, params); err != nil {
+		return rpcResponse{}, err
+	}
+
+	if abort, ok := abortActions[method]; ok && ctx.Done() != nil && startsWork(method, params) {
+		finished := make(chan struct{})
+		defer close(finished)
+
+		go func() {
+			select {
+			case <-ctx.Done():
+				c.loggerFor(ctx).Infof("Call to %s cancelled, sending %s to the node", method, abort.method)
+				// The context of the call is done, the abort is sent regardless
+				if _, err := c.callContext(context.Background(), abort.method, abort.params); err != nil {
+					c.loggerFor(ctx).Warnf("Failed to abort %s: %v", method, err)
+				}
+			case <-finished:
+			}
+		}()
+	}
+
+	c.warnKnownDeprecation(method)
+
+	entry, err := c.beginJournal(method, params)
+	if err != nil {
+		return rpcResponse{}, err
+	}
+
+	var rr rpcResponse
+	for attempt := 1; ; attempt++ {
+		start := c.clock.Now()
+		c.runBeforeHooks(ctx, method, params)
+
+		var t transfer
+		rr, err = c.doCall(ctx, method, params, &t)
+
+		c.runAfterHooks(ctx, method, params, start, t, err)
+		c.stats.record(c.clock.Now(), method, rr, err)
+
+		// Nodes answering JSON-RPC 2.0 requests send their errors with status 200
+		failure := err
+		if failure == nil && rr.Err != nil {
+			failure = responseError(rr.Err)
+		}
+		if !c.retryAfter(ctx, method, attempt, failure) {
+			break
+		}
+	}
+	c.endJournal(entry, rr, err)
+
+	if err == nil {
+		c.checkDeprecation(method, rr)
+	}
+	return rr, err
+}
+
+// doCall prepare & exec the request
+func (c *rpcClient) doCall(ctx context.Context, method string, params interface{}, t *transfer) (rpcResponse, error) {
+	connectTimer := c.clock.NewTimer(c.timeoutFor(method))
+	defer connectTimer.Stop()
 	rpcR := rpcRequest{method, params, time.Now().UnixNano(), "1.0"}
 	payloadBuffer := &bytes.Buffer{}
 	jsonEncoder := json.NewEncoder(payloadBuffer)
@@ -155,8 +593,9 @@ func (c *rpcClient) call(method string, params interface{}) (rpcResponse, error)
 	if err != nil {
 		return rpcResponse{}, fmt.Errorf("failed to encode rpc request: %w", err)
 	}
+	t.sent = payloadBuffer.Len()
 
-	req, err := http.NewRequest("POST", c.serverAddr, payloadBuffer)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.serverAddr, payloadBuffer)
 	if err != nil {
 		return rpcResponse{}, fmt.Errorf("failed to create new http request: %w", err)
 	}
@@ -164,18 +603,17 @@ func (c *rpcClient) call(method string, params interface{}) (rpcResponse, error)
 	if os.Getenv("HTTP_TRACE") == "TRUE" {
 		trace := &httptrace.ClientTrace{
 			DNSDone: func(dnsInfo httptrace.DNSDoneInfo) {
-				c.logger.Debugf("HTTP_TRACE - DNS: %+v\n", dnsInfo)
+				c.loggerFor(ctx).Debugf("HTTP_TRACE - DNS: %+v\n", dnsInfo)
 			},
 			GotConn: func(connInfo httptrace.GotConnInfo) {
-				c.logger.Debugf("HTTP_TRACE - Conn: %+v\n", connInfo)
+				c.loggerFor(ctx).Debugf("HTTP_TRACE - Conn: %+v\n", connInfo)
 			}}
 		ctxTrace := httptrace.WithClientTrace(req.Context(), trace)
 
 		req = req.WithContext(ctxTrace)
 	}
 
-	req.Header.Add("Content-Type", "application/json;charset=utf-8")
-	req.Header.Add("Accept", "application/json")
+	c.setHeaders(req)
 
 	// Auth ?
 	if len(c.user) > 0 || len(c.passwd) > 0 {
@@ -188,7 +626,8 @@ func (c *rpcClient) call(method string, params interface{}) (rpcResponse, error)
 	}
 	defer resp.Body.Close()
 
-	data, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(c.limitBody(method, resp.Body))
+	t.received = len(data)
 	if err != nil {
 		return rpcResponse{}, fmt.Errorf("failed to read response: %w", err)
 	}
@@ -196,18 +635,12 @@ func (c *rpcClient) call(method string, params interface{}) (rpcResponse, error)
 	var rr rpcResponse
 
 	if resp.StatusCode != 200 {
-		_ = json.Unmarshal(data, &rr)
-		v, ok := rr.Err.(map[string]interface{})
-		if ok {
-			err = errors.New(v["message"].(string))
-		} else {
-			err = errors.New("HTTP error: " + resp.Status)
-		}
+		_ = unmarshal(data, &rr)
 
-		return rr, fmt.Errorf("unexpected response code %d: %w", resp.StatusCode, err)
+		return rr, statusError(resp, rr)
 	}
 
-	err = json.Unmarshal(data, &rr)
+	err = unmarshal(data, &rr)
 	if err != nil {
 		return rr, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
@@ -215,9 +648,124 @@ func (c *rpcClient) call(method string, params interface{}) (rpcResponse, error)
 	return rr, nil
 }
 
-// call prepare & exec the request
-func (c *rpcClient) read(method string, params interface{}) (io.ReadCloser, error) {
-	connectTimer := time.NewTimer(c.rpcClientTimeout)
+// batch sends one request per element of params to method in a single JSON-RPC batch and returns the
+// responses in the same order. Errors of individual requests are left in the Err field of their response.
+func (c *rpcClient) batch(ctx context.Context, method string, params [][]interface{}) ([]rpcResponse, error) {
+	for _, p := range params {
+		if err := c.validateParams(method, p); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := c.clock.Now()
+		c.runBeforeHooks(ctx, method, nil)
+
+		var t transfer
+		rr, err := c.doBatch(ctx, method, params, &t)
+
+		c.runAfterHooks(ctx, method, nil, start, t, err)
+		c.stats.record(c.clock.Now(), method, rpcResponse{}, err)
+
+		if !c.retryAfter(ctx, method, attempt, err) {
+			return rr, err
+		}
+	}
+}
+
+func (c *rpcClient) doBatch(ctx context.Context, method string, params [][]interface{}, t *transfer) ([]rpcResponse, error) {
+	connectTimer := c.clock.NewTimer(c.timeoutFor(method))
+	defer connectTimer.Stop()
+
+	reqs := make([]rpcRequest, len(params))
+	for i, p := range params {
+		reqs[i] = rpcRequest{method, p, int64(i), "1.0"}
+	}
+
+	payload, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rpc batch: %w", err)
+	}
+	t.sent = len(payload)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.serverAddr, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new http request: %w", err)
+	}
+
+	c.setHeaders(req)
+
+	// Auth ?
+	if len(c.user) > 0 || len(c.passwd) > 0 {
+		req.SetBasicAuth(c.user, c.passwd)
+	}
+
+	resp, err := c.doTimeoutRequest(connectTimer, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(c.limitBody(method, resp.Body))
+	t.received = len(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, statusError(resp, rpcResponse{})
+	}
+
+	var responses []rpcResponse
+	if err := unmarshal(data, &responses); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch response: %w", err)
+	}
+
+	// The responses to a batch may come back in any order
+	ordered := make([]rpcResponse, len(params))
+	found := make([]bool, len(params))
+	for _, r := range responses {
+		if r.ID < 0 || r.ID >= int64(len(params)) || found[r.ID] {
+			return nil, fmt.Errorf("unexpected id %d in batch response", r.ID)
+		}
+		ordered[r.ID] = r
+		found[r.ID] = true
+	}
+
+	for i := range found {
+		if !found[i] {
+			return nil, fmt.Errorf("batch response is missing id %d", i)
+		}
+	}
+
+	return ordered, nil
+}
+
+// read runs the hooks around doRead, the duration passed to the after hooks excludes reading the body
+func (c *rpcClient) read(ctx context.Context, method string, params interface{}) (io.ReadCloser, error) {
+	if err := c.validateParams(method, params); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := c.clock.Now()
+		c.runBeforeHooks(ctx, method, params)
+
+		var t transfer
+		body, err := c.doRead(ctx, method, params, &t)
+
+		c.runAfterHooks(ctx, method, params, start, t, err)
+
+		if !c.retryAfter(ctx, method, attempt, err) {
+			return body, err
+		}
+	}
+}
+
+// doRead prepare & exec the request, returning the response body unread
+func (c *rpcClient) doRead(ctx context.Context, method string, params interface{}, t *transfer) (io.ReadCloser, error) {
+	connectTimer := c.clock.NewTimer(c.timeoutFor(method))
+	defer connectTimer.Stop()
 	rpcR := rpcRequest{method, params, time.Now().UnixNano(), "1.0"}
 	payloadBuffer := &bytes.Buffer{}
 	jsonEncoder := json.NewEncoder(payloadBuffer)
@@ -226,14 +774,14 @@ func (c *rpcClient) read(method string, params interface{}) (io.ReadCloser, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode rpc request: %w", err)
 	}
+	t.sent = payloadBuffer.Len()
 
-	req, err := http.NewRequest("POST", c.serverAddr, payloadBuffer)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.serverAddr, payloadBuffer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new http request: %w", err)
 	}
 
-	req.Header.Add("Content-Type", "application/json;charset=utf-8")
-	req.Header.Add("Accept", "application/json")
+	c.setHeaders(req)
 
 	// Auth ?
 	if len(c.user) > 0 || len(c.passwd) > 0 {
@@ -249,22 +797,49 @@ func (c *rpcClient) read(method string, params interface{}) (io.ReadCloser, erro
 		defer resp.Body.Close()
 
 		var rr rpcResponse
-		data, err := io.ReadAll(resp.Body)
-
+		data, err := io.ReadAll(c.limitBody(method, resp.Body))
+		t.received = len(data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read response: %w", err)
 		}
 
-		_ = json.Unmarshal(data, &rr)
-		v, ok := rr.Err.(map[string]interface{})
-		if ok {
-			err = errors.New(v["message"].(string))
-		} else {
-			err = errors.New("HTTP error: " + resp.Status)
-		}
+		_ = unmarshal(data, &rr)
+
+		return nil, statusError(resp, rr)
+	}
+
+	return c.limitBody(method, resp.Body), nil
+}
+
+// httpStatusError is the error of a response with a status other than 200. It reads as the status and the
+// message of the node, and unwraps to the *RPCError carrying its code.
+type httpStatusError struct {
+	status int
+	err    error
+}
 
-		return nil, fmt.Errorf("unexpected response code %d: %w", resp.StatusCode, err)
+func (e *httpStatusError) Error() string {
+	msg := e.err.Error()
+	if rpcErr, ok := e.err.(*RPCError); ok {
+		msg = rpcErr.Message
+	}
+	return fmt.Sprintf("unexpected response code %d: %s", e.status, msg)
+}
+
+func (e *httpStatusError) Unwrap() error {
+	return e.err
+}
+
+// statusError returns the error of resp, which did not have status 200, with rr decoded from its body.
+func statusError(resp *http.Response, rr rpcResponse) error {
+	if v, ok := rr.Err.(map[string]interface{}); ok {
+		if err, ok := responseError(v).(*RPCError); ok {
+			return &httpStatusError{status: resp.StatusCode, err: err}
+		}
+		if message, ok := v["message"].(string); ok {
+			return &httpStatusError{status: resp.StatusCode, err: errors.New(message)}
+		}
 	}
 
-	return resp.Body, nil
+	return &httpStatusError{status: resp.StatusCode, err: errors.New("HTTP error: " + resp.Status)}
 }
@@ -0,0 +1,450 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+)
+
+func TestDeriveClient(t *testing.T) {
+	c, err := newClient("localhost", 8332, "", "user", "pass", false, WithHeader("X-App", "payments"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := c.derive(WithTimeoutDuration(5*time.Second), WithHeader("X-Subsystem", "analytics"))
+
+	if d.httpClient != c.httpClient {
+		t.Error("Expected derived client to share the http client")
+	}
+
+	if d.rpcClientTimeout != 5*time.Second || c.rpcClientTimeout != rpcClientTimeoutSecondsDefault*time.Second {
+		t.Errorf("Unexpected timeouts: parent %s, derived %s", c.rpcClientTimeout, d.rpcClientTimeout)
+	}
+
+	if d.headers.Get("X-App") != "payments" || d.headers.Get("X-Subsystem") != "analytics" {
+		t.Errorf("Unexpected derived headers %v", d.headers)
+	}
+
+	if c.headers.Get("X-Subsystem") != "" {
+		t.Errorf("Derived header leaked into parent %v", c.headers)
+	}
+}
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) (*rpcClient, func()) {
+	srv := httptest.NewServer(handler)
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	c, err := newClient(u.Hostname(), port, "", "user", "pass", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, srv.Close
+}
+
+// newTestNode returns a node with a response cache whose client, derived with the given options, talks to a
+// test server with the given handler.
+func newTestNode(t *testing.T, handler http.HandlerFunc, opts ...Option) (*Bitcoind, func()) {
+	c, done := newTestServer(t, handler)
+
+	return &Bitcoind{client: c.derive(opts...), Storage: cache.New(time.Minute, time.Minute)}, done
+}
+
+func TestHooks(t *testing.T) {
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":42,"error":null,"id":1}`))
+	})
+	defer done()
+
+	var before, after []string
+
+	c = c.derive(
+		WithBeforeHook(func(method string, params []interface{}) {
+			before = append(before, fmt.Sprintf("%s%v", method, params))
+		}),
+		WithAfterHook(func(method string, dur time.Duration, err error) {
+			after = append(after, fmt.Sprintf("%s:%v", method, err))
+		}),
+	)
+
+	if _, err := c.call("getblockcount", []interface{}{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(before) != 1 || before[0] != "getblockcount[1]" {
+		t.Errorf("Unexpected before hook calls %v", before)
+	}
+
+	if len(after) != 1 || after[0] != "getblockcount:<nil>" {
+		t.Errorf("Unexpected after hook calls %v", after)
+	}
+}
+
+func TestCallHook(t *testing.T) {
+	const response = `{"result":42,"error":null,"id":1}`
+	const batchResponse = `[{"result":"00","error":null,"id":0},{"result":"01","error":null,"id":1}]`
+
+	var requests []int
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, len(body))
+		if strings.HasPrefix(string(body), "[") {
+			_, _ = w.Write([]byte(batchResponse))
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	})
+	defer done()
+
+	var calls []CallInfo
+	c = c.derive(WithCallHook(func(info CallInfo) {
+		calls = append(calls, info)
+	}))
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "trace-1")
+	if _, err := c.callContext(ctx, "getblockcount", []interface{}{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.batch(ctx, "getblockhash", [][]interface{}{{0}, {1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("Unexpected call hook calls %+v", calls)
+	}
+
+	call := calls[0]
+	if call.Method != "getblockcount" || fmt.Sprint(call.Params) != "[1]" || call.Context.Value(traceIDKey{}) != "trace-1" {
+		t.Errorf("Unexpected call info %+v", call)
+	}
+	if call.RequestBytes != requests[0] || call.ResponseBytes != len(response) {
+		t.Errorf("Expected %d bytes sent and %d received, got %d and %d", requests[0], len(response), call.RequestBytes, call.ResponseBytes)
+	}
+
+	batch := calls[1]
+	if batch.Method != "getblockhash" || batch.Params != nil {
+		t.Errorf("Unexpected call info %+v", batch)
+	}
+	if batch.RequestBytes != requests[1] || batch.ResponseBytes != len(batchResponse) {
+		t.Errorf("Expected %d bytes sent and %d received, got %d and %d", requests[1], len(batchResponse), batch.RequestBytes, batch.ResponseBytes)
+	}
+}
+
+type traceIDKey struct{}
+
+// traceLogger is a ContextLogger prefixing its messages with the trace ID of the context.
+type traceLogger struct {
+	countingLogger
+	traceID  string
+	messages *[]string
+}
+
+func (l *traceLogger) WithContext(ctx context.Context) Logger {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return &traceLogger{traceID: traceID, messages: l.messages}
+}
+
+func (l *traceLogger) Warnf(format string, args ...interface{}) {
+	*l.messages = append(*l.messages, l.traceID+": "+fmt.Sprintf(format, args...))
+}
+
+func TestContextHooks(t *testing.T) {
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		if body, _ := io.ReadAll(r.Body); strings.HasPrefix(string(body), "[") {
+			_, _ = w.Write([]byte(`[{"result":"00","error":null,"id":0}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":42,"error":null,"id":1}`))
+	})
+	defer done()
+
+	var before, after, logged []string
+	var slow []SlowQuery
+
+	c = c.derive(
+		WithOptionalLogger(&traceLogger{messages: &logged}),
+		WithBeforeHookContext(func(ctx context.Context, method string, params []interface{}) {
+			before = append(before, fmt.Sprintf("%v %s", ctx.Value(traceIDKey{}), method))
+		}),
+		WithAfterHookContext(func(ctx context.Context, method string, dur time.Duration, err error) {
+			after = append(after, fmt.Sprintf("%v %s", ctx.Value(traceIDKey{}), method))
+		}),
+		WithSlowQueryLog(10*time.Millisecond, func(q SlowQuery) {
+			slow = append(slow, q)
+		}),
+	)
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "trace-1")
+	if _, err := c.callContext(ctx, "getblockcount", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.batch(ctx, "getblockhash", [][]interface{}{{0}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(before, ",") != "trace-1 getblockcount,trace-1 getblockhash" {
+		t.Errorf("Unexpected before hook calls %v", before)
+	}
+	if strings.Join(after, ",") != "trace-1 getblockcount,trace-1 getblockhash" {
+		t.Errorf("Unexpected after hook calls %v", after)
+	}
+
+	if len(slow) != 2 || slow[0].Context.Value(traceIDKey{}) != "trace-1" {
+		t.Fatalf("Unexpected slow queries %+v", slow)
+	}
+	if len(logged) != 2 || !strings.HasPrefix(logged[0], "trace-1: Slow RPC call getblockcount()") {
+		t.Errorf("Unexpected log messages %v", logged)
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":"` + strings.Repeat("00", 1000) + `","error":null,"id":1}`))
+	})
+	defer done()
+
+	if _, err := c.derive(WithMaxResponseBytes(10000)).call("getblock", nil); err != nil {
+		t.Errorf("Expected response within limit to succeed, got %v", err)
+	}
+
+	_, err := c.derive(WithMaxResponseBytes(100)).call("getblock", nil)
+
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 100 {
+		t.Errorf("Expected ResponseTooLargeError, got %v", err)
+	}
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"result":null,"error":null,"id":1}`))
+	})
+	defer done()
+
+	var slow []SlowQuery
+	c = c.derive(WithSlowQueryLog(10*time.Millisecond, func(q SlowQuery) {
+		slow = append(slow, q)
+	}))
+
+	if _, err := c.call("sendrawtransaction", []interface{}{strings.Repeat("ab", 100)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(slow) != 1 || slow[0].Method != "sendrawtransaction" || !strings.HasSuffix(slow[0].Params, "...(200 chars)") {
+		t.Errorf("Unexpected slow queries %+v", slow)
+	}
+}
+
+func TestCancelAbortsScan(t *testing.T) {
+	aborted := make(chan struct{})
+
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		if p, _ := req.Params.([]interface{}); len(p) > 0 && p[0] == "abort" {
+			close(aborted)
+			_, _ = w.Write([]byte(`{"result":true,"error":null,"id":1}`))
+			return
+		}
+
+		select {
+		case <-aborted:
+		case <-time.After(time.Second):
+		}
+		_, _ = w.Write([]byte(`{"result":{"success":false},"error":null,"id":1}`))
+	})
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.callContext(ctx, "scantxoutset", []interface{}{"start", []string{}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Error("Expected scantxoutset abort to be sent")
+	}
+}
+
+func TestCallCtx(t *testing.T) {
+	aborted := make(chan struct{})
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "getconnectioncount":
+			_, _ = w.Write([]byte(`{"result":7,"error":null,"id":1}`))
+		case "getblockchaininfo":
+			time.Sleep(50 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"result":{"chain":"regtest","blocks":5},"error":null,"id":1}`))
+		default:
+			<-r.Context().Done()
+			close(aborted)
+		}
+	})
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	if _, err := b.CallCtx(ctx, "waitfornewblock", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not aborted")
+	}
+
+	// Wrappers use the context too, a done context fails them before anything is sent
+	if _, err := b.GetConnectionCountCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// The calls of b without a context are unaffected
+	count, err := b.GetConnectionCount()
+	if err != nil || count != 7 {
+		t.Errorf("Expected 7, got %d, %v", count, err)
+	}
+
+	// Callers sharing a cached request each stop waiting with their own context only
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+
+	var wg sync.WaitGroup
+	var shortErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, shortErr = b.GetBlockchainInfoCtx(short)
+	}()
+
+	time.Sleep(2 * time.Millisecond)
+	info, err := b.GetBlockchainInfoCtx(context.Background())
+	wg.Wait()
+
+	if !errors.Is(shortErr, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", shortErr)
+	}
+	if err != nil || info.Chain != "regtest" {
+		t.Errorf("Expected the shared request to succeed, got %+v, %v", info, err)
+	}
+}
+
+func TestHiddenCalls(t *testing.T) {
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":{"short":{"feerate":0.0001,"decay":0.962,"scale":1,"pass":{"startrange":9500,"endrange":10000,"withintarget":80,"totalconfirmed":82,"inmempool":0,"leftmempool":0}}},"error":null,"id":1}`))
+	})
+	defer done()
+
+	if _, err := b.EstimateRawFee(6, 0); !errors.Is(err, ErrHiddenCallsDisabled) {
+		t.Fatalf("Expected ErrHiddenCallsDisabled, got %v", err)
+	}
+
+	estimate, err := b.WithOptions(WithHiddenCalls()).EstimateRawFee(6, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if estimate.Short == nil || estimate.Short.Pass == nil || estimate.Short.Pass.TotalConfirmed != 82 || estimate.Long != nil {
+		t.Errorf("Unexpected estimate %+v", estimate)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	calls := 0
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		var reqs []rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&reqs)
+
+		// Answer in reverse order
+		var parts []string
+		for i := len(reqs) - 1; i >= 0; i-- {
+			height := reqs[i].Params.([]interface{})[0].(float64)
+			parts = append(parts, fmt.Sprintf(`{"result":"hash%.0f","error":null,"id":%d}`, height, reqs[i].ID))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(parts, ",") + "]"))
+	})
+	defer done()
+
+	hashes, err := b.GetBlockHashes(10, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(hashes, ",") != "hash10,hash11,hash12" || calls != 1 {
+		t.Errorf("Unexpected hashes %v after %d calls", hashes, calls)
+	}
+}
+
+func TestTimeoutAbortsRequest(t *testing.T) {
+	aborted := make(chan struct{})
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices a closed connection once the request body has been read
+		_, _ = io.ReadAll(r.Body)
+		<-r.Context().Done()
+		close(aborted)
+	})
+	defer done()
+
+	c = c.derive(WithTimeoutDuration(20*time.Millisecond), WithHTTPDebug(false), WithOptionalLogger(&countingLogger{}))
+
+	if _, err := c.call("getblockcount", nil); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+
+	// The server sees the connection go away instead of the request running on in the background
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not aborted")
+	}
+}
+
+func TestTimeoutWhileReadingBody(t *testing.T) {
+	release := make(chan struct{})
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer done()
+	defer close(release)
+
+	c = c.derive(WithTimeoutDuration(20 * time.Millisecond))
+
+	if _, err := c.call("getblockcount", nil); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package bitcoin
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Labels of the events published on the sequence ZMQ topic.
+const (
+	SequenceBlockConnected    byte = 'C'
+	SequenceBlockDisconnected byte = 'D'
+	SequenceTxAdded           byte = 'A'
+	SequenceTxRemoved         byte = 'R'
+)
+
+// SequenceEvent is a single message of the sequence ZMQ topic.
+type SequenceEvent struct {
+	Hash            string // block hash for C and D, txid for A and R
+	Label           byte
+	MempoolSequence uint64 // only set for A and R
+	Sequence        uint32 // ZMQ message sequence number of the sequence topic
+}
+
+// ParseSequenceMessage decodes a message received from a subscription to the sequence topic.
+func ParseSequenceMessage(msg []string) (*SequenceEvent, error) {
+	if len(msg) < 3 || msg[0] != "sequence" {
+		return nil, fmt.Errorf("not a sequence message: %v", msg)
+	}
+
+	body, err := hex.DecodeString(msg[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) < 33 {
+		return nil, fmt.Errorf("sequence message too short: %d bytes", len(body))
+	}
+
+	seq, err := strconv.ParseUint(msg[2], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("sequence message without sequence number: %v", err)
+	}
+
+	e := &SequenceEvent{
+		Hash:     hex.EncodeToString(body[:32]),
+		Label:    body[32],
+		Sequence: uint32(seq),
+	}
+
+	switch e.Label {
+	case SequenceBlockConnected, SequenceBlockDisconnected:
+	case SequenceTxAdded, SequenceTxRemoved:
+		if len(body) != 41 {
+			return nil, fmt.Errorf("sequence message %c has %d bytes, expected 41", e.Label, len(body))
+		}
+		e.MempoolSequence = binary.LittleEndian.Uint64(body[33:])
+	default:
+		return nil, fmt.Errorf("unknown sequence label %q", e.Label)
+	}
+
+	return e, nil
+}
+
+// SequenceTracker detects gaps in the per topic sequence numbers of ZMQ messages. It is safe for concurrent use.
+type SequenceTracker struct {
+	mu   sync.Mutex
+	last map[string]uint32
+}
+
+// NewSequenceTracker returns an empty SequenceTracker.
+func NewSequenceTracker() *SequenceTracker {
+	return &SequenceTracker{last: make(map[string]uint32)}
+}
+
+// Observe records the sequence number of a message on the topic and returns the number of messages missed
+// since the previous one. The first message observed on a topic never reports a gap.
+func (t *SequenceTracker) Observe(topic string, seq uint32) uint32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, seen := t.last[topic]
+	t.last[topic] = seq
+
+	if !seen {
+		return 0
+	}
+
+	// Unsigned arithmetic handles the wrap around at 2^32
+	return seq - last - 1
+}
+
+// Reset forgets the topic, for example after reconnecting to a restarted node.
+func (t *SequenceTracker) Reset(topic string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.last, topic)
+}
+
+// SequenceStream follows the sequence ZMQ topic and keeps a consumer in step with the node's mempool. Whenever
+// messages were missed, and before the first event, it fetches the mempool with getrawmempool and passes it to
+// OnResync; events already reflected in that snapshot are then dropped.
+type SequenceStream struct {
+	// OnResync is called with the complete mempool whenever the consumer has to rebuild its state.
+	OnResync func(txids []string, mempoolSequence uint64)
+	// OnEvent is called for every event after the most recent resync.
+	OnEvent func(SequenceEvent)
+
+	b        *Bitcoind
+	tracker  *SequenceTracker
+	mu       sync.Mutex
+	synced   bool
+	snapshot uint64
+}
+
+// NewSequenceStream returns a SequenceStream resyncing from the given node.
+func NewSequenceStream(b *Bitcoind) *SequenceStream {
+	return &SequenceStream{
+		b:       b,
+		tracker: NewSequenceTracker(),
+	}
+}
+
+// Follow subscribes to the sequence topic of the given ZMQ connection and handles every message received.
+func (s *SequenceStream) Follow(zmq *ZMQ) error {
+	ch := make(chan []string, 100)
+
+	go func() {
+		for msg := range ch {
+			if err := s.Handle(msg); err != nil {
+				zmq.logger.Errorf("SequenceStream: %v", err)
+			}
+		}
+	}()
+
+	return zmq.Subscribe("sequence", ch)
+}
+
+// Handle processes a single message of the sequence topic.
+func (s *SequenceStream) Handle(msg []string) error {
+	e, err := ParseSequenceMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if missed := s.tracker.Observe("sequence", e.Sequence); missed > 0 {
+		s.b.client.logger.Warnf("SequenceStream: missed %d messages, resyncing mempool", missed)
+		s.synced = false
+	}
+
+	if !s.synced {
+		if err := s.resync(); err != nil {
+			return err
+		}
+	}
+
+	if (e.Label == SequenceTxAdded || e.Label == SequenceTxRemoved) && e.MempoolSequence <= s.snapshot {
+		return nil
+	}
+
+	if s.OnEvent != nil {
+		s.OnEvent(*e)
+	}
+
+	return nil
+}
+
+// Resync fetches the mempool and passes it to OnResync straight away.
+func (s *SequenceStream) Resync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.resync()
+}
+
+func (s *SequenceStream) resync() error {
+	m, err := s.b.GetRawMempoolSequence()
+	if err != nil {
+		return err
+	}
+
+	s.snapshot = m.MempoolSequence
+	s.synced = true
+
+	if s.OnResync != nil {
+		s.OnResync(m.TxIDs, m.MempoolSequence)
+	}
+
+	return nil
+}
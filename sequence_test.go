@@ -0,0 +1,83 @@
+package bitcoin
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func sequenceMessage(hash string, label byte, mempoolSequence uint64, seq uint32) []string {
+	body, _ := hex.DecodeString(hash)
+	body = append(body, label)
+	if label == SequenceTxAdded || label == SequenceTxRemoved {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], mempoolSequence)
+		body = append(body, b[:]...)
+	}
+	return []string{"sequence", hex.EncodeToString(body), strconv.FormatUint(uint64(seq), 10)}
+}
+
+func TestSequenceTracker(t *testing.T) {
+	tr := NewSequenceTracker()
+
+	for i, tt := range []struct {
+		seq    uint32
+		missed uint32
+	}{
+		{seq: 10, missed: 0},
+		{seq: 11, missed: 0},
+		{seq: 14, missed: 2},
+		{seq: 0xffffffff, missed: 0xffffffff - 15},
+		{seq: 0, missed: 0},
+	} {
+		if got := tr.Observe("sequence", tt.seq); got != tt.missed {
+			t.Errorf("%d: expected %d missed, got %d", i, tt.missed, got)
+		}
+	}
+}
+
+func TestSequenceStreamResync(t *testing.T) {
+	txA := strings.Repeat("aa", 32)
+	txB := strings.Repeat("bb", 32)
+
+	resyncs := 0
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		resyncs++
+		_, _ = w.Write([]byte(`{"result":{"txids":["` + txA + `"],"mempool_sequence":5},"error":null,"id":1}`))
+	})
+	defer done()
+
+	s := NewSequenceStream(b)
+
+	var mempool []string
+	var events []string
+	s.OnResync = func(txids []string, _ uint64) { mempool = txids }
+	s.OnEvent = func(e SequenceEvent) { events = append(events, string(e.Label)+e.Hash[:2]) }
+
+	msgs := [][]string{
+		sequenceMessage(txA, SequenceTxAdded, 5, 1), // already in the snapshot
+		sequenceMessage(txB, SequenceTxAdded, 6, 2),
+		sequenceMessage(txB, SequenceTxRemoved, 9, 5), // gap, triggers a second resync
+	}
+
+	for _, msg := range msgs {
+		if err := s.Handle(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if resyncs != 2 {
+		t.Errorf("expected 2 resyncs, got %d", resyncs)
+	}
+
+	if len(mempool) != 1 || mempool[0] != txA {
+		t.Errorf("unexpected mempool %v", mempool)
+	}
+
+	if strings.Join(events, ",") != "Abb,Rbb" {
+		t.Errorf("unexpected events %v", events)
+	}
+}
@@ -0,0 +1,96 @@
+package bitcoin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Service bits advertised by nodes.
+const (
+	NodeNetwork        uint64 = 1 << 0
+	NodeGetUTXO        uint64 = 1 << 1
+	NodeBloom          uint64 = 1 << 2
+	NodeWitness        uint64 = 1 << 3
+	NodeCompactFilters uint64 = 1 << 6
+	NodeNetworkLimited uint64 = 1 << 10
+	NodeP2PV2          uint64 = 1 << 11
+)
+
+// serviceNames are the names of the service bits as the node reports them in localservicesnames.
+var serviceNames = []struct {
+	bit  uint64
+	name string
+}{
+	{NodeNetwork, "NETWORK"},
+	{NodeGetUTXO, "GETUTXO"},
+	{NodeBloom, "BLOOM"},
+	{NodeWitness, "WITNESS"},
+	{NodeCompactFilters, "COMPACT_FILTERS"},
+	{NodeNetworkLimited, "NETWORK_LIMITED"},
+	{NodeP2PV2, "P2P_V2"},
+}
+
+// ServiceFlags are the service bits advertised by a node, as in the localservices of getnetworkinfo and
+// the services of getpeerinfo.
+type ServiceFlags uint64
+
+// ParseServiceFlags parses service bits in the hex format used by the node, e.g. "0000000000000409".
+func ParseServiceFlags(s string) (ServiceFlags, error) {
+	flags, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid service flags %q: %w", s, err)
+	}
+
+	return ServiceFlags(flags), nil
+}
+
+// Has reports whether all the given service bits are set.
+func (f ServiceFlags) Has(bits uint64) bool {
+	return uint64(f)&bits == bits
+}
+
+// Names returns the names of the set bits, with unknown bits as e.g. "UNKNOWN[1<<24]".
+func (f ServiceFlags) Names() []string {
+	var names []string
+	rest := uint64(f)
+	for _, s := range serviceNames {
+		if rest&s.bit != 0 {
+			names = append(names, s.name)
+			rest &^= s.bit
+		}
+	}
+
+	for bit := 0; bit < 64; bit++ {
+		if rest&(1<<bit) != 0 {
+			names = append(names, fmt.Sprintf("UNKNOWN[1<<%d]", bit))
+		}
+	}
+
+	return names
+}
+
+func (f ServiceFlags) String() string {
+	return strings.Join(f.Names(), "|")
+}
+
+// Services returns the service bits the node advertises.
+func (n NetworkInfo) Services() (ServiceFlags, error) {
+	return ParseServiceFlags(n.LocalServices)
+}
+
+// RelayFeeRate returns the minimum fee rate of transactions the node relays, in satoshis per virtual byte.
+func (n NetworkInfo) RelayFeeRate() float64 {
+	return n.RelayFee * 1e5 // BTC/kvB to sat/vB
+}
+
+// IncrementalFeeRate returns the fee rate a replacement has to add for its own size on top of the fees
+// of the transactions it replaces, in satoshis per virtual byte.
+func (n NetworkInfo) IncrementalFeeRate() float64 {
+	return n.IncrementalFee * 1e5 // BTC/kvB to sat/vB
+}
+
+// ServiceFlags returns the service bits the peer advertises.
+func (p Peer) ServiceFlags() (ServiceFlags, error) {
+	return ParseServiceFlags(p.Services)
+}
@@ -0,0 +1,28 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkInfoServices(t *testing.T) {
+	v, err := DecodeResponse("getnetworkinfo", responseCorpus(t)["getnetworkinfo"])
+	require.NoError(t, err)
+	info := v.(*NetworkInfo)
+
+	services, err := info.Services()
+	require.NoError(t, err)
+	assert.True(t, services.Has(NodeNetwork|NodeWitness))
+	assert.True(t, services.Has(NodeNetworkLimited))
+	assert.False(t, services.Has(NodeWitness|NodeCompactFilters))
+	assert.Equal(t, info.LocalServicesNames, services.Names())
+	assert.InDelta(t, 1.0, info.RelayFeeRate(), 1e-9)
+	assert.InDelta(t, 1.0, info.IncrementalFeeRate(), 1e-9)
+
+	assert.Equal(t, "WITNESS|COMPACT_FILTERS|P2P_V2|UNKNOWN[1<<24]", (ServiceFlags(NodeWitness|NodeCompactFilters|NodeP2PV2) | 1<<24).String())
+
+	_, err = Peer{Services: "zz"}.ServiceFlags()
+	assert.Error(t, err)
+}
@@ -0,0 +1,277 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SinceBlockTransaction is an entry of the transactions or removed arrays of listsinceblock, one per
+// address and category a transaction touches.
+type SinceBlockTransaction struct {
+	Address           string   `json:"address"`
+	Category          string   `json:"category"`
+	Amount            float64  `json:"amount"`
+	Label             string   `json:"label"`
+	Vout              uint32   `json:"vout"`
+	Fee               float64  `json:"fee"`
+	Confirmations     int64    `json:"confirmations"`
+	Generated         bool     `json:"generated"`
+	Trusted           bool     `json:"trusted"`
+	BlockHash         string   `json:"blockhash"`
+	BlockHeight       int64    `json:"blockheight"`
+	BlockIndex        int64    `json:"blockindex"`
+	BlockTime         int64    `json:"blocktime"`
+	TxID              string   `json:"txid"`
+	WalletConflicts   []string `json:"walletconflicts"`
+	Time              int64    `json:"time"`
+	TimeReceived      int64    `json:"timereceived"`
+	BIP125Replaceable string   `json:"bip125-replaceable"`
+	Abandoned         bool     `json:"abandoned"`
+}
+
+// SinceBlockResult is the result of listsinceblock.
+type SinceBlockResult struct {
+	Transactions []SinceBlockTransaction `json:"transactions"`
+	// Removed holds the transactions of blocks that were reorged out, if the block passed to ListSinceBlock
+	// is no longer in the active chain. Transactions that were mined again also appear in Transactions.
+	Removed []SinceBlockTransaction `json:"removed"`
+	// LastBlock is the block at depth targetConfirmations-1, to pass to the next call.
+	LastBlock string `json:"lastblock"`
+}
+
+// ListSinceBlock returns the wallet transactions in blocks after blockHash, or all of them if blockHash is
+// empty, together with every unconfirmed wallet transaction and the transactions removed by reorgs.
+func (b *Bitcoind) ListSinceBlock(blockHash string, targetConfirmations int) (*SinceBlockResult, error) {
+	var blockHashParam interface{}
+	if blockHash != "" {
+		blockHashParam = blockHash
+	}
+
+	var res SinceBlockResult
+	if err := b.uncachedCall("listsinceblock", []interface{}{blockHashParam, targetConfirmations, true, true}, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// WalletTxEventKind tells whether a wallet transaction appeared or disappeared.
+type WalletTxEventKind int
+
+// Kinds of wallet transaction events.
+const (
+	// WalletTxAdded reports a transaction seen for the first time or in a different block than before.
+	WalletTxAdded WalletTxEventKind = iota
+	// WalletTxRemoved reports a transaction that left the block it was reported in, or vanished while
+	// unconfirmed. It is followed by a WalletTxAdded event if the transaction is back in the mempool or in
+	// another block.
+	WalletTxRemoved
+)
+
+func (k WalletTxEventKind) String() string {
+	if k == WalletTxRemoved {
+		return "removed"
+	}
+	return "added"
+}
+
+// WalletTxEvent is an event of a WalletTxStream. Tx describes the transaction as it is now for added
+// transactions, and as it was reported before for removed ones.
+type WalletTxEvent struct {
+	Kind WalletTxEventKind
+	Tx   SinceBlockTransaction
+}
+
+type sinceBlockKey struct {
+	txid     string
+	vout     uint32
+	category string
+}
+
+func sinceBlockKeyOf(tx *SinceBlockTransaction) sinceBlockKey {
+	return sinceBlockKey{tx.TxID, tx.Vout, tx.Category}
+}
+
+// WalletTxStream turns repeated listsinceblock calls into a stream of wallet transaction events that
+// accounts for reorgs. Transactions that are not yet targetConfirmations deep are listed by every call;
+// the stream remembers them and only reports changes, and reports the transactions of reorged blocks as
+// removed whether the reorg is shallower than targetConfirmations, and only visible in the listed
+// transactions, or deeper, and reported in the removed array.
+//
+// The stream resumes from Cursor, which should be persisted together with the effects of the events, e.g.
+// with Checkpoint. Transactions in blocks after the cursor are reported again after a restart, so consumers
+// have to be idempotent per txid, vout, category and block hash.
+type WalletTxStream struct {
+	// OnEvent is called for every event returned by Next.
+	OnEvent func(WalletTxEvent)
+	// Reconnect, if set, makes Run back off while calls fail instead of retrying every interval, and stop
+	// once it gives up. Events missed in between are returned by the next successful call.
+	Reconnect *ReconnectPolicy
+
+	b                   *Bitcoind
+	targetConfirmations int
+	mu                  sync.Mutex
+	cursor              Cursor
+	pending             map[sinceBlockKey]SinceBlockTransaction
+}
+
+// NewWalletTxStream returns a stream of the wallet transactions after the block cursor, or of all wallet
+// transactions if the cursor has no hash. Transactions count as final at targetConfirmations, 6 if it is 0.
+func NewWalletTxStream(b *Bitcoind, cursor Cursor, targetConfirmations int) *WalletTxStream {
+	if targetConfirmations <= 0 {
+		targetConfirmations = 6
+	}
+
+	return &WalletTxStream{
+		b:                   b,
+		targetConfirmations: targetConfirmations,
+		cursor:              cursor,
+		pending:             make(map[sinceBlockKey]SinceBlockTransaction),
+	}
+}
+
+// Cursor returns the block to resume the stream from.
+func (s *WalletTxStream) Cursor() Cursor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursor
+}
+
+// SetCursor makes the stream resume after the given block.
+func (s *WalletTxStream) SetCursor(c Cursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursor = c
+	return nil
+}
+
+// Next calls listsinceblock and returns the events since the previous call, removals first.
+func (s *WalletTxStream) Next() ([]WalletTxEvent, error) {
+	events, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.OnEvent != nil {
+		for _, e := range events {
+			s.OnEvent(e)
+		}
+	}
+
+	return events, nil
+}
+
+func (s *WalletTxStream) next() ([]WalletTxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.b.ListSinceBlock(s.cursor.Hash, s.targetConfirmations)
+	if err != nil {
+		return nil, err
+	}
+	if res.LastBlock == "" {
+		return nil, fmt.Errorf("listsinceblock returned no lastblock")
+	}
+
+	cursor := s.cursor
+	if res.LastBlock != cursor.Hash {
+		header, err := s.b.GetBlockHeader(res.LastBlock)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("lastblock %s not found", res.LastBlock)
+		}
+		cursor = Cursor{Height: header.Height, Hash: res.LastBlock}
+	}
+
+	current := make(map[sinceBlockKey]SinceBlockTransaction, len(res.Transactions))
+	for _, tx := range res.Transactions {
+		current[sinceBlockKeyOf(&tx)] = tx
+	}
+
+	var events []WalletTxEvent
+	removed := make(map[sinceBlockKey]bool)
+
+	// Reorgs deeper than the cursor, reported by the node
+	for _, tx := range res.Removed {
+		key := sinceBlockKeyOf(&tx)
+		if cur, found := current[key]; (found && cur.BlockHash == tx.BlockHash) || removed[key] {
+			continue
+		}
+		removed[key] = true
+		events = append(events, WalletTxEvent{Kind: WalletTxRemoved, Tx: tx})
+	}
+
+	// Reorgs above the cursor, where previously listed transactions moved or vanished
+	for key, prev := range s.pending {
+		if removed[key] {
+			continue
+		}
+		cur, found := current[key]
+		if !found || (prev.BlockHash != "" && cur.BlockHash != prev.BlockHash) {
+			removed[key] = true
+			events = append(events, WalletTxEvent{Kind: WalletTxRemoved, Tx: prev})
+		}
+	}
+
+	pending := make(map[sinceBlockKey]SinceBlockTransaction)
+	for _, tx := range res.Transactions {
+		key := sinceBlockKeyOf(&tx)
+		if prev, found := s.pending[key]; !found || removed[key] || prev.BlockHash != tx.BlockHash {
+			events = append(events, WalletTxEvent{Kind: WalletTxAdded, Tx: tx})
+		}
+
+		// Transactions at the target depth are in or below the new cursor and not listed again
+		if tx.Confirmations < int64(s.targetConfirmations) {
+			pending[key] = tx
+		}
+	}
+
+	s.pending = pending
+	s.cursor = cursor
+
+	return events, nil
+}
+
+// Run calls Next every interval until the context is done, or Reconnect gives up.
+func (s *WalletTxStream) Run(ctx context.Context, interval time.Duration) {
+	ticker := s.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	var r *reconnector
+	if s.Reconnect != nil {
+		r = newReconnector(*s.Reconnect, s.b.client.clock)
+	}
+
+	for {
+		_, err := s.Next()
+		if err != nil {
+			s.b.client.logger.Errorf("WalletTxStream: %v", err)
+		}
+
+		switch {
+		case r == nil:
+		case err == nil:
+			r.succeeded()
+		default:
+			if err := r.failed(ctx); err != nil {
+				if ctx.Err() == nil {
+					s.b.client.logger.Errorf("WalletTxStream: %v", err)
+				}
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
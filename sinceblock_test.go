@@ -0,0 +1,100 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletTxStream(t *testing.T) {
+	var cursors []interface{}
+	responses := []string{
+		`{"transactions":[
+			{"txid":"a","category":"receive","vout":0,"confirmations":5,"blockhash":"h1"},
+			{"txid":"b","category":"receive","vout":1,"confirmations":1,"blockhash":"h3"},
+			{"txid":"c","category":"send","vout":0,"confirmations":0}
+		],"removed":[],"lastblock":"h2"}`,
+		// h3 was replaced by h3', which mined c but not b
+		`{"transactions":[
+			{"txid":"b","category":"receive","vout":1,"confirmations":0},
+			{"txid":"c","category":"send","vout":0,"confirmations":1,"blockhash":"h3'"}
+		],"removed":[],"lastblock":"h2"}`,
+		// A deeper reorg replaced h2, the node reports d from it as removed and c as mined again
+		`{"transactions":[
+			{"txid":"b","category":"receive","vout":1,"confirmations":0},
+			{"txid":"c","category":"send","vout":0,"confirmations":2,"blockhash":"h3'"}
+		],"removed":[
+			{"txid":"d","category":"receive","vout":0,"confirmations":0,"blockhash":"h2"},
+			{"txid":"c","category":"send","vout":0,"confirmations":2,"blockhash":"h3'"}
+		],"lastblock":"h2'"}`,
+		// c reached the target depth, b was dropped from the wallet
+		`{"transactions":[
+			{"txid":"c","category":"send","vout":0,"confirmations":3,"blockhash":"h3'"}
+		],"removed":[],"lastblock":"h3'"}`,
+		`{"transactions":[],"removed":[],"lastblock":"h4"}`,
+	}
+
+	heights := map[string]int{"h2": 2, "h2'": 2, "h3'": 3, "h4": 4}
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		params := req.Params.([]interface{})
+		if req.Method == "getblockheader" {
+			hash := params[0].(string)
+			_, _ = fmt.Fprintf(w, `{"result":{"hash":%q,"height":%d},"error":null,"id":1}`, hash, heights[hash])
+			return
+		}
+
+		cursors = append(cursors, params[0])
+		assert.Equal(t, []interface{}{float64(3), true, true}, params[1:])
+
+		result := responses[0]
+		responses = responses[1:]
+		_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+	})
+	defer done()
+
+	s := NewWalletTxStream(b, Cursor{}, 3)
+
+	var reported int
+	s.OnEvent = func(WalletTxEvent) { reported++ }
+
+	type event struct {
+		kind      WalletTxEventKind
+		txid      string
+		blockHash string
+	}
+	next := func() []event {
+		events, err := s.Next()
+		require.NoError(t, err)
+
+		var res []event
+		for _, e := range events {
+			res = append(res, event{e.Kind, e.Tx.TxID, e.Tx.BlockHash})
+		}
+		return res
+	}
+
+	assert.Equal(t, []event{{WalletTxAdded, "a", "h1"}, {WalletTxAdded, "b", "h3"}, {WalletTxAdded, "c", ""}}, next())
+	assert.Equal(t, Cursor{Height: 2, Hash: "h2"}, s.Cursor())
+
+	assert.Equal(t, []event{{WalletTxRemoved, "b", "h3"}, {WalletTxAdded, "b", ""}, {WalletTxAdded, "c", "h3'"}}, next())
+
+	assert.Equal(t, []event{{WalletTxRemoved, "d", "h2"}}, next())
+	assert.Equal(t, Cursor{Height: 2, Hash: "h2'"}, s.Cursor())
+
+	assert.Equal(t, []event{{WalletTxRemoved, "b", ""}}, next())
+
+	// c is no longer tracked once it is below the cursor
+	assert.Empty(t, next())
+	assert.Equal(t, Cursor{Height: 4, Hash: "h4"}, s.Cursor())
+
+	assert.Equal(t, []interface{}{nil, "h2", "h2", "h2'", "h3'"}, cursors)
+	assert.Equal(t, 8, reported)
+}
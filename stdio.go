@@ -0,0 +1,189 @@
+package bitcoin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sync"
+)
+
+// ErrStdioClosed is returned for requests sent through a StdioTransport whose stream has ended.
+var ErrStdioClosed = errors.New("stdio transport closed")
+
+// stdioMaxLine is the longest response line a StdioTransport accepts, enough for the largest blocks.
+const stdioMaxLine = 64 << 20
+
+// StdioTransport is an http.RoundTripper speaking JSON-RPC over a pair of streams instead of HTTP, one
+// request or batch per line in each direction, for nodes embedded in another process and for test
+// doubles. Install it with WithTransport; the host passed to New then only has to resolve and is not
+// connected to, and HTTP headers and credentials are not sent.
+//
+// Requests are answered in order, so only one is in flight at a time. A request abandoned when its
+// context is done still occupies the stream until its response has been read and discarded.
+type StdioTransport struct {
+	w      io.Writer
+	closer func() error
+
+	sem   chan struct{} // held by the request in flight
+	lines chan []byte
+
+	mu  sync.Mutex
+	err error // why the response stream ended
+}
+
+// NewStdioTransport returns a transport writing requests to w and reading responses from r.
+func NewStdioTransport(r io.Reader, w io.Writer) *StdioTransport {
+	t := &StdioTransport{
+		w:     w,
+		sem:   make(chan struct{}, 1),
+		lines: make(chan []byte),
+	}
+
+	go t.readLoop(r)
+
+	return t
+}
+
+// NewProcessTransport starts cmd and returns a transport writing requests to its standard input and
+// reading responses from its standard output. Close stops the process by closing its standard input.
+func NewProcessTransport(cmd *exec.Cmd) (*StdioTransport, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+
+	t := NewStdioTransport(stdout, stdin)
+	t.closer = func() error {
+		_ = stdin.Close()
+		return cmd.Wait()
+	}
+
+	return t, nil
+}
+
+// Close closes the request stream, and waits for the process to exit if the transport was created by
+// NewProcessTransport.
+func (t *StdioTransport) Close() error {
+	if t.closer != nil {
+		return t.closer()
+	}
+
+	if c, ok := t.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+func (t *StdioTransport) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), stdioMaxLine)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		t.lines <- append([]byte{}, line...)
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+
+	t.mu.Lock()
+	t.err = err
+	t.mu.Unlock()
+
+	close(t.lines)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *StdioTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case t.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// The JSON encoder ends requests with a newline, a request must not contain any other
+	line := append(bytes.TrimSpace(body), '\n')
+	if bytes.ContainsAny(line[:len(line)-1], "\r\n") {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, line); err != nil {
+			<-t.sem
+			return nil, err
+		}
+		line = append(compact.Bytes(), '\n')
+	}
+
+	if _, err := t.w.Write(line); err != nil {
+		<-t.sem
+		return nil, fmt.Errorf("failed to write request: %w", err)
+	}
+
+	select {
+	case resp, ok := <-t.lines:
+		<-t.sem
+		if !ok {
+			return nil, t.closedErr()
+		}
+
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(resp)),
+			ContentLength: int64(len(resp)),
+			Request:       req,
+		}, nil
+
+	case <-ctx.Done():
+		// The response still arrives and has to be skipped before the next request can be answered
+		go func() {
+			<-t.lines
+			<-t.sem
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+func (t *StdioTransport) closedErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err == io.EOF {
+		return ErrStdioClosed
+	}
+	return fmt.Errorf("%w: %v", ErrStdioClosed, t.err)
+}
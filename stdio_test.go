@@ -0,0 +1,87 @@
+package bitcoin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stdioNode answers getblockcount requests read from r with an increasing count, and batches with the
+// index of each request. A request for "slow" is answered only after release is closed.
+func stdioNode(r io.Reader, w io.WriteCloser, release chan struct{}) {
+	defer w.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		var batch []rpcRequest
+		if json.Unmarshal(line, &batch) == nil {
+			var responses []string
+			for i := range batch {
+				responses = append(responses, fmt.Sprintf(`{"result":%d,"error":null,"id":%d}`, i, i))
+			}
+			fmt.Fprintln(w, "["+strings.Join(responses, ",")+"]")
+			continue
+		}
+
+		var req rpcRequest
+		_ = json.Unmarshal(line, &req)
+		if req.Method == "slow" {
+			<-release
+		}
+
+		count++
+		fmt.Fprintf(w, `{"result":%d,"error":null,"id":1}`+"\n", count)
+	}
+}
+
+func TestStdioTransport(t *testing.T) {
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	release := make(chan struct{})
+	go stdioNode(reqR, respW, release)
+
+	transport := NewStdioTransport(respR, reqW)
+
+	c, err := newClient("stdio", 0, "", "", "", false, WithTransport(transport))
+	require.NoError(t, err)
+	b := &Bitcoind{client: c, Storage: cache.New(time.Nanosecond, time.Minute)}
+
+	var count int
+	require.NoError(t, b.uncachedCall("getblockcount", nil, &count))
+	assert.Equal(t, 1, count)
+
+	responses, err := c.batch(context.Background(), "getblockhash", [][]interface{}{{1}, {2}, {3}})
+	require.NoError(t, err)
+	require.Len(t, responses, 3)
+	assert.Equal(t, "2", string(responses[2].Result))
+
+	// An abandoned request keeps its place in the stream, its response is not handed to the next one
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.callContext(ctx, "slow", nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	require.NoError(t, b.uncachedCall("getblockcount", nil, &count))
+	assert.Equal(t, 3, count)
+
+	require.NoError(t, transport.Close())
+	err = b.uncachedCall("getblockcount", nil, &count)
+	assert.True(t, errors.Is(err, ErrStdioClosed) || errors.Is(err, io.ErrClosedPipe), "%v", err)
+}
@@ -0,0 +1,190 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+// maxCoinbaseScriptSize is the largest coinbase script consensus allows.
+const maxCoinbaseScriptSize = 100
+
+// StratumCoinbase describes the coinbase transaction of Stratum jobs built by NewStratumJob.
+type StratumCoinbase struct {
+	PayoutScript []byte // output script receiving the block reward
+	Tag          []byte // arbitrary data after the BIP34 height, such as the pool name
+	// ExtraNonceSize is the combined size of extranonce1 and extranonce2 in bytes, placed after Tag.
+	ExtraNonceSize int
+}
+
+// StratumJob holds the parameters of a Stratum v1 mining.notify message, hex encoded in the byte orders
+// miners expect.
+type StratumJob struct {
+	JobID string
+	// PrevHash is the hash of the previous block in internal byte order with the bytes of every 4 byte word
+	// swapped, which is the display order of the hash with its words reversed.
+	PrevHash string
+	// Coinb1 and Coinb2 are the coinbase transaction, serialized without witness, before and after the
+	// extranonce.
+	Coinb1       string
+	Coinb2       string
+	MerkleBranch []string // hashes in internal byte order combined with the coinbase txid, in order
+	Version      string   // big endian
+	Bits         string   // big endian
+	NTime        string   // big endian
+	CleanJobs    bool
+}
+
+// Params returns the parameters of the mining.notify message of the job.
+func (j *StratumJob) Params() []interface{} {
+	return []interface{}{j.JobID, j.PrevHash, j.Coinb1, j.Coinb2, j.MerkleBranch, j.Version, j.Bits, j.NTime, j.CleanJobs}
+}
+
+// NewStratumJob converts a block template into a Stratum v1 job. The coinbase pays the template's coinbase
+// value to cb.PayoutScript and carries its witness commitment, if any. A block found for a template with
+// witness commitment needs the 32 zero byte witness reserved value as the coinbase witness, which the pool
+// adds when assembling the block.
+func NewStratumJob(template *BlockTemplate, jobID string, cb StratumCoinbase, cleanJobs bool) (*StratumJob, error) {
+	if len(cb.PayoutScript) == 0 {
+		return nil, errors.New("stratum job without payout script")
+	}
+
+	prevHash, err := stratumPrevHash(template.PreviousBlockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	coinb1, coinb2, err := stratumCoinbase(template, cb)
+	if err != nil {
+		return nil, err
+	}
+
+	txids := make([]string, len(template.Transactions))
+	for i, tx := range template.Transactions {
+		txids[i] = tx.TXID
+		if txids[i] == "" {
+			txids[i] = tx.Hash
+		}
+	}
+
+	branch, err := merkleBranch(txids)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StratumJob{
+		JobID:        jobID,
+		PrevHash:     prevHash,
+		Coinb1:       hex.EncodeToString(coinb1),
+		Coinb2:       hex.EncodeToString(coinb2),
+		MerkleBranch: branch,
+		Version:      fmt.Sprintf("%08x", template.Version),
+		Bits:         template.Bits,
+		NTime:        fmt.Sprintf("%08x", uint32(template.CurTime)),
+		CleanJobs:    cleanJobs,
+	}, nil
+}
+
+func stratumPrevHash(hash string) (string, error) {
+	if h, err := hex.DecodeString(hash); err != nil || len(h) != 32 {
+		return "", fmt.Errorf("invalid previous block hash %q", hash)
+	}
+
+	var s string
+	for i := len(hash) - 8; i >= 0; i -= 8 {
+		s += hash[i : i+8]
+	}
+	return s, nil
+}
+
+// stratumCoinbase serializes the coinbase transaction of a template, split where the extranonce goes.
+func stratumCoinbase(template *BlockTemplate, cb StratumCoinbase) ([]byte, []byte, error) {
+	script := append(scriptNumPush(int64(template.Height)), cb.Tag...)
+	scriptSize := len(script) + cb.ExtraNonceSize
+	if scriptSize > maxCoinbaseScriptSize {
+		return nil, nil, fmt.Errorf("coinbase script of %d bytes exceeds %d", scriptSize, maxCoinbaseScriptSize)
+	}
+
+	var buf bytes.Buffer
+	writeLE(&buf, int32(2))
+	buf.Write([]byte{1})
+	buf.Write(make([]byte, 32))
+	writeLE(&buf, uint32(0xffffffff))
+	buf.Write(cryptolib.VarInt(uint64(scriptSize)))
+	buf.Write(script)
+	coinb1 := append([]byte{}, buf.Bytes()...)
+
+	buf.Reset()
+	writeLE(&buf, SequenceFinal)
+
+	outputs := []parsedOutput{{Value: template.CoinbaseValue, ScriptPubKey: cb.PayoutScript}}
+	if template.DefaultWitnessCommitment != "" {
+		commitment, err := hex.DecodeString(template.DefaultWitnessCommitment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid witness commitment: %w", err)
+		}
+		outputs = append(outputs, parsedOutput{ScriptPubKey: commitment})
+	}
+
+	buf.Write(cryptolib.VarInt(uint64(len(outputs))))
+	for i := range outputs {
+		writeOutput(&buf, &outputs[i])
+	}
+	writeLE(&buf, uint32(0))
+
+	return coinb1, buf.Bytes(), nil
+}
+
+// scriptNumPush returns the script pushing n as a minimally encoded script number, as BIP34 requires for
+// the height.
+func scriptNumPush(n int64) []byte {
+	switch {
+	case n == 0:
+		return []byte{0x00}
+	case n >= 1 && n <= 16:
+		return []byte{0x50 + byte(n)}
+	}
+
+	var num []byte
+	for v := n; v > 0; v >>= 8 {
+		num = append(num, byte(v))
+	}
+	if num[len(num)-1]&0x80 != 0 {
+		num = append(num, 0x00)
+	}
+
+	return append([]byte{byte(len(num))}, num...)
+}
+
+// merkleBranch returns the hashes, in internal byte order, combined with the first leaf of a merkle tree
+// whose other leaves are txids to compute the root.
+func merkleBranch(txids []string) ([]string, error) {
+	level := make([][]byte, len(txids)+1)
+	for i, txid := range txids {
+		h, err := hex.DecodeString(txid)
+		if err != nil || len(h) != 32 {
+			return nil, fmt.Errorf("invalid txid %q", txid)
+		}
+		level[i+1] = cryptolib.ReverseBytes(h)
+	}
+
+	branch := []string{}
+	for len(level) > 1 {
+		branch = append(branch, hex.EncodeToString(level[1]))
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+
+		// The first node depends on the coinbase and is left empty
+		next := make([][]byte, 1, len(level)/2)
+		for i := 2; i < len(level); i += 2 {
+			next = append(next, cryptolib.Sha256d(append(append([]byte{}, level[i]...), level[i+1]...)))
+		}
+		level = next
+	}
+
+	return branch, nil
+}
@@ -0,0 +1,71 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStratumJob(t *testing.T) {
+	txids := []string{strings.Repeat("11", 32), strings.Repeat("22", 32), strings.Repeat("33", 32)}
+	template := &BlockTemplate{
+		Version:                  0x20000000,
+		PreviousBlockHash:        "00000000000000000001a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f7",
+		Transactions:             []Transaction{{TXID: txids[0]}, {TXID: txids[1]}, {Hash: txids[2]}},
+		Bits:                     "17034219",
+		CurTime:                  0x65e1f2a0,
+		CoinbaseValue:            312500000,
+		Height:                   840000,
+		DefaultWitnessCommitment: "6a24aa21a9ed" + strings.Repeat("ee", 32),
+	}
+
+	job, err := NewStratumJob(template, "1f", StratumCoinbase{
+		PayoutScript:   []byte{0x51},
+		Tag:            []byte("/pool/"),
+		ExtraNonceSize: 8,
+	}, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, "c4d5e6f78091a2b34c5d6e7f08192a3bc4d5e6f70001a2b30000000000000000", job.PrevHash)
+	assert.Equal(t, "20000000", job.Version)
+	assert.Equal(t, "17034219", job.Bits)
+	assert.Equal(t, "65e1f2a0", job.NTime)
+	assert.Equal(t, []interface{}{"1f", job.PrevHash, job.Coinb1, job.Coinb2, job.MerkleBranch, "20000000", "17034219", "65e1f2a0", true}, job.Params())
+
+	// A miner fills in the extranonce and hashes up the branch
+	coinbase, err := hex.DecodeString(job.Coinb1 + "0102030405060708" + job.Coinb2)
+	require.NoError(t, err)
+	tx, err := parseTx(coinbase)
+	require.NoError(t, err)
+
+	height, _ := coinbaseHeight(tx.Inputs[0].ScriptSig)
+	assert.Equal(t, int64(840000), height)
+	assert.Equal(t, uint64(312500000), tx.Outputs[0].Value)
+	assert.Len(t, tx.Outputs, 2)
+
+	root, _ := hex.DecodeString(tx.TxID)
+	root = cryptolib.ReverseBytes(root)
+	for _, h := range job.MerkleBranch {
+		step, _ := hex.DecodeString(h)
+		root = cryptolib.Sha256d(append(root, step...))
+	}
+
+	expected, err := merkleRoot(append([]string{tx.TxID}, txids...))
+	require.NoError(t, err)
+	assert.Equal(t, expected, hex.EncodeToString(cryptolib.ReverseBytes(root)))
+
+	_, err = NewStratumJob(template, "20", StratumCoinbase{PayoutScript: []byte{0x51}, ExtraNonceSize: 100}, false)
+	assert.Error(t, err)
+}
+
+func TestScriptNumPush(t *testing.T) {
+	assert.Equal(t, []byte{0x00}, scriptNumPush(0))
+	assert.Equal(t, []byte{0x60}, scriptNumPush(16))
+	assert.Equal(t, []byte{0x01, 0x11}, scriptNumPush(17))
+	assert.Equal(t, []byte{0x02, 0x80, 0x00}, scriptNumPush(128))
+	assert.Equal(t, []byte{0x03, 0x40, 0xd1, 0x0c}, scriptNumPush(840000))
+}
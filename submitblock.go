@@ -0,0 +1,100 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SubmitStatus classifies the outcome of submitting a block.
+type SubmitStatus string
+
+const (
+	SubmitAccepted              SubmitStatus = "accepted"               // the block was new and valid
+	SubmitDuplicate             SubmitStatus = "duplicate"              // the node already has the block, valid
+	SubmitDuplicateInconclusive SubmitStatus = "duplicate-inconclusive" // the node already has the block, not yet validated
+	SubmitInconclusive          SubmitStatus = "inconclusive"           // stored but not validated, e.g. not on the best chain
+	SubmitRejected              SubmitStatus = "rejected"               // invalid, Reason tells why
+)
+
+// SubmitOutcome is the decoded result of submitblock.
+type SubmitOutcome struct {
+	Status SubmitStatus
+	// Reason is the node's reject reason, e.g. "high-hash" or "bad-txnmrklroot", for rejected blocks.
+	// It is "duplicate-invalid" for a block the node already knows to be invalid. Inconclusive proposals
+	// tell why they could not be checked, e.g. "inconclusive-not-best-prevblk".
+	Reason string
+}
+
+// Accepted reports whether the block is part of the node's block tree, including duplicates.
+func (o SubmitOutcome) Accepted() bool {
+	return o.Status == SubmitAccepted || o.Status == SubmitDuplicate
+}
+
+func (o SubmitOutcome) String() string {
+	if o.Status == SubmitRejected {
+		return fmt.Sprintf("%s: %s", o.Status, o.Reason)
+	}
+	return string(o.Status)
+}
+
+// ClassifySubmitResult decodes the result of submitblock, null or a string. It also decodes the results
+// of getblocktemplate in proposal mode, which uses the same strings.
+func ClassifySubmitResult(result json.RawMessage) (SubmitOutcome, error) {
+	if len(result) == 0 || string(result) == "null" {
+		return SubmitOutcome{Status: SubmitAccepted}, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(result, &s); err != nil {
+		return SubmitOutcome{}, fmt.Errorf("unexpected submitblock result %s", result)
+	}
+
+	switch s {
+	case "duplicate":
+		return SubmitOutcome{Status: SubmitDuplicate}, nil
+	case "duplicate-inconclusive":
+		return SubmitOutcome{Status: SubmitDuplicateInconclusive}, nil
+	case "inconclusive":
+		return SubmitOutcome{Status: SubmitInconclusive}, nil
+	}
+
+	if strings.HasPrefix(s, "inconclusive-") {
+		return SubmitOutcome{Status: SubmitInconclusive, Reason: s}, nil
+	}
+
+	return SubmitOutcome{Status: SubmitRejected, Reason: s}, nil
+}
+
+// SubmitBlockOutcome submits a block like SubmitBlock, returning the classified result instead of an
+// error for blocks the node did not accept. Errors are returned for blocks that cannot be decoded.
+func (b *Bitcoind) SubmitBlockOutcome(hexData string) (SubmitOutcome, error) {
+	r, err := b.client.call("submitblock", []interface{}{hexData})
+	if err != nil {
+		return SubmitOutcome{}, err
+	}
+
+	if r.Err != nil {
+		return SubmitOutcome{}, responseError(r.Err)
+	}
+
+	return ClassifySubmitResult(r.Result)
+}
+
+// ProposeBlock checks a candidate block against the node with getblocktemplate in proposal mode, without
+// submitting it. The proof of work is not checked, so a block with any nonce can be proposed; a block
+// that does not build on the node's tip is inconclusive. Accepted means the block would be valid.
+func (b *Bitcoind) ProposeBlock(hexData string) (SubmitOutcome, error) {
+	params := gbtParams{Mode: "proposal", Data: hexData, Rules: []string{"segwit"}}
+
+	r, err := b.client.call("getblocktemplate", []interface{}{params})
+	if err != nil {
+		return SubmitOutcome{}, err
+	}
+
+	if r.Err != nil {
+		return SubmitOutcome{}, responseError(r.Err)
+	}
+
+	return ClassifySubmitResult(r.Result)
+}
@@ -0,0 +1,75 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifySubmitResult(t *testing.T) {
+	tests := []struct {
+		result   string
+		expected SubmitOutcome
+		accepted bool
+	}{
+		{`null`, SubmitOutcome{Status: SubmitAccepted}, true},
+		{`"duplicate"`, SubmitOutcome{Status: SubmitDuplicate}, true},
+		{`"duplicate-inconclusive"`, SubmitOutcome{Status: SubmitDuplicateInconclusive}, false},
+		{`"inconclusive"`, SubmitOutcome{Status: SubmitInconclusive}, false},
+		{`"duplicate-invalid"`, SubmitOutcome{Status: SubmitRejected, Reason: "duplicate-invalid"}, false},
+		{`"high-hash"`, SubmitOutcome{Status: SubmitRejected, Reason: "high-hash"}, false},
+	}
+
+	for _, test := range tests {
+		outcome, err := ClassifySubmitResult(json.RawMessage(test.result))
+		require.NoError(t, err, test.result)
+		assert.Equal(t, test.expected, outcome, test.result)
+		assert.Equal(t, test.accepted, outcome.Accepted(), test.result)
+	}
+
+	_, err := ClassifySubmitResult(json.RawMessage(`true`))
+	assert.Error(t, err)
+}
+
+func TestSubmitBlockOutcome(t *testing.T) {
+	result := `"bad-txnmrklroot"`
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		if result == "" {
+			fmt.Fprint(w, `{"result":null,"error":{"code":-22,"message":"Block decode failed"},"id":1}`)
+			return
+		}
+		fmt.Fprintf(w, `{"result":%s,"error":null,"id":1}`, result)
+	})
+	defer closeServer()
+
+	outcome, err := b.SubmitBlockOutcome("00")
+	require.NoError(t, err)
+	assert.Equal(t, "rejected: bad-txnmrklroot", outcome.String())
+
+	result = ""
+	_, err = b.SubmitBlockOutcome("00")
+	assert.EqualError(t, err, "ERROR -22: Block decode failed")
+}
+
+func TestProposeBlock(t *testing.T) {
+	var params []gbtParams
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []gbtParams `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+
+		fmt.Fprint(w, `{"result":"inconclusive-not-best-prevblk","error":null,"id":1}`)
+	})
+	defer closeServer()
+
+	outcome, err := b.ProposeBlock("0011")
+	require.NoError(t, err)
+	assert.Equal(t, SubmitOutcome{Status: SubmitInconclusive, Reason: "inconclusive-not-best-prevblk"}, outcome)
+	assert.Equal(t, []gbtParams{{Mode: "proposal", Data: "0011", Rules: []string{"segwit"}}}, params)
+}
@@ -0,0 +1,163 @@
+package bitcoin
+
+import "fmt"
+
+// versionRange is the range of node versions supporting a method or argument, in the format of the
+// version reported by getnetworkinfo, e.g. 250000 for v25.0 and 170100 for v0.17.1.
+type versionRange struct {
+	Added   int // 0 if the method or argument predates the table
+	Removed int // 0 if it is still supported
+}
+
+func (r versionRange) contains(nodeVersion int) bool {
+	return nodeVersion >= r.Added && (r.Removed == 0 || nodeVersion < r.Removed)
+}
+
+// methodVersions lists the methods added or removed since v0.15, compiled from the Bitcoin Core release
+// notes. Methods not listed are supported by every version.
+var methodVersions = map[string]versionRange{
+	"analyzepsbt":                  {Added: 180000},
+	"combinepsbt":                  {Added: 170000},
+	"converttopsbt":                {Added: 170000},
+	"createpsbt":                   {Added: 170000},
+	"createwallet":                 {Added: 170000},
+	"decodepsbt":                   {Added: 170000},
+	"deriveaddresses":              {Added: 170000},
+	"dumptxoutset":                 {Added: 180000},
+	"enumeratesigners":             {Added: 220000},
+	"estimatefee":                  {Removed: 170000},
+	"finalizepsbt":                 {Added: 170000},
+	"generate":                     {Removed: 190000},
+	"generateblock":                {Added: 210000},
+	"generatetodescriptor":         {Added: 200000},
+	"getaccount":                   {Removed: 180000},
+	"getaccountaddress":            {Removed: 180000},
+	"getaddressesbyaccount":        {Removed: 180000},
+	"getbalances":                  {Added: 190000},
+	"getblockfrompeer":             {Added: 230000},
+	"getblockstats":                {Added: 170000},
+	"getchainstates":               {Added: 260000},
+	"getdeploymentinfo":            {Added: 230000},
+	"getdescriptorinfo":            {Added: 170000},
+	"getindexinfo":                 {Added: 210000},
+	"getinfo":                      {Removed: 160000},
+	"getnodeaddresses":             {Added: 180000},
+	"getorphantxs":                 {Added: 280000},
+	"getprioritisedtransactions":   {Added: 260000},
+	"getreceivedbyaccount":         {Removed: 180000},
+	"getrpcinfo":                   {Added: 180000},
+	"gettxspendingprevout":         {Added: 240000},
+	"getzmqnotifications":          {Added: 170000},
+	"importdescriptors":            {Added: 210000},
+	"importmempool":                {Added: 270000},
+	"joinpsbts":                    {Added: 180000},
+	"listaccounts":                 {Removed: 180000},
+	"listdescriptors":              {Added: 220000},
+	"listwalletdir":                {Added: 180000},
+	"loadtxoutset":                 {Added: 260000},
+	"migratewallet":                {Added: 240000},
+	"move":                         {Removed: 180000},
+	"rescanblockchain":             {Added: 160000},
+	"savemempool":                  {Added: 160000},
+	"scanblocks":                   {Added: 250000},
+	"scantxoutset":                 {Added: 170000},
+	"send":                         {Added: 210000},
+	"sendall":                      {Added: 240000},
+	"sendfrom":                     {Removed: 180000},
+	"setaccount":                   {Removed: 180000},
+	"setwalletflag":                {Added: 190000},
+	"signrawtransaction":           {Removed: 180000},
+	"signrawtransactionwithkey":    {Added: 170000},
+	"signrawtransactionwithwallet": {Added: 170000},
+	"simulaterawtransaction":       {Added: 250000},
+	"submitpackage":                {Added: 260000},
+	"testmempoolaccept":            {Added: 170000},
+	"upgradewallet":                {Added: 210000},
+	"utxoupdatepsbt":               {Added: 180000},
+	"walletcreatefundedpsbt":       {Added: 170000},
+	"walletdisplayaddress":         {Added: 220000},
+	"walletprocesspsbt":            {Added: 170000},
+}
+
+// argumentVersions lists, by method, the named arguments and option keys added or removed since the
+// method itself was added. Option keys are given as "options.<key>".
+var argumentVersions = map[string]map[string]versionRange{
+	"createwallet": {
+		"descriptors":     {Added: 210000},
+		"load_on_startup": {Added: 210000},
+		"external_signer": {Added: 220000},
+	},
+	"decoderawtransaction": {
+		"iswitness": {Added: 160000},
+	},
+	"estimatesmartfee": {
+		"estimate_mode": {Added: 160000},
+	},
+	"fundrawtransaction": {
+		"options.input_weights": {Added: 240000},
+		"options.solving_data":  {Added: 230000},
+	},
+	"getrawmempool": {
+		"mempool_sequence": {Added: 210000},
+	},
+	"gettxoutsetinfo": {
+		"hash_type": {Added: 210000},
+	},
+	"listsinceblock": {
+		"include_removed": {Added: 160000},
+	},
+	"sendrawtransaction": {
+		"allowhighfees": {Removed: 190000},
+		"maxfeerate":    {Added: 190000},
+		"maxburnamount": {Added: 250000},
+	},
+	"testmempoolaccept": {
+		"allowhighfees": {Removed: 190000},
+		"maxfeerate":    {Added: 190000},
+	},
+	"walletcreatefundedpsbt": {
+		"options.input_weights": {Added: 240000},
+		"options.solving_data":  {Added: 230000},
+	},
+}
+
+// Supports reports whether a node of the given version, as reported by getnetworkinfo, has method.
+// Methods the table does not know of are assumed to be supported, so that calls to newer or non Core
+// methods are not blocked by it.
+func Supports(method string, nodeVersion int) bool {
+	r, found := methodVersions[method]
+	return !found || r.contains(nodeVersion)
+}
+
+// SupportsArgument reports whether a node of the given version has method and accepts its named argument,
+// or its option key given as "options.<key>".
+func SupportsArgument(method, argument string, nodeVersion int) bool {
+	if !Supports(method, nodeVersion) {
+		return false
+	}
+
+	r, found := argumentVersions[method][argument]
+	return !found || r.contains(nodeVersion)
+}
+
+// Supports reports whether the connected node has method, see the function of the same name. The node
+// version is read with getnetworkinfo, whose result is cached like any other call.
+func (b *Bitcoind) Supports(method string) (bool, error) {
+	info, err := b.GetNetworkInfo()
+	if err != nil {
+		return false, fmt.Errorf("failed to get node version: %w", err)
+	}
+
+	return Supports(method, info.Version), nil
+}
+
+// SupportsArgument reports whether the connected node has method and accepts argument, see the function
+// of the same name.
+func (b *Bitcoind) SupportsArgument(method, argument string) (bool, error) {
+	info, err := b.GetNetworkInfo()
+	if err != nil {
+		return false, fmt.Errorf("failed to get node version: %w", err)
+	}
+
+	return SupportsArgument(method, argument, info.Version), nil
+}
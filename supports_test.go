@@ -0,0 +1,45 @@
+package bitcoin
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupports(t *testing.T) {
+	assert.False(t, Supports("sendall", 230200))
+	assert.True(t, Supports("sendall", 240000))
+	assert.True(t, Supports("getinfo", 150100))
+	assert.False(t, Supports("getinfo", 160000))
+	assert.True(t, Supports("getblockcount", 100000))
+	assert.True(t, Supports("getminingcandidate", 250000))
+
+	assert.True(t, SupportsArgument("sendrawtransaction", "allowhighfees", 180100))
+	assert.False(t, SupportsArgument("sendrawtransaction", "allowhighfees", 190000))
+	assert.False(t, SupportsArgument("sendrawtransaction", "maxburnamount", 240000))
+	assert.True(t, SupportsArgument("walletcreatefundedpsbt", "options.input_weights", 250000))
+	assert.False(t, SupportsArgument("walletcreatefundedpsbt", "options.input_weights", 160000))
+	assert.True(t, SupportsArgument("getblock", "verbosity", 100000))
+
+	for method, r := range methodVersions {
+		assert.True(t, r.Removed == 0 || r.Removed > r.Added, method)
+	}
+}
+
+func TestBitcoindSupports(t *testing.T) {
+	b, closeServer := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":{"version":230000,"subversion":"/Satoshi:23.0.0/"},"error":null,"id":1}`)
+	})
+	defer closeServer()
+
+	supported, err := b.Supports("gettxspendingprevout")
+	require.NoError(t, err)
+	assert.False(t, supported)
+
+	supported, err = b.SupportsArgument("getrawmempool", "mempool_sequence")
+	require.NoError(t, err)
+	assert.True(t, supported)
+}
@@ -0,0 +1,129 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// sweepInputVSize is the virtual size of a P2WPKH input, used by SweepWallet to decide which outputs are
+// worth spending on nodes without sendall.
+const sweepInputVSize = 68
+
+// ErrNothingToSweep is returned by SweepWallet when the wallet holds no output worth spending at the fee rate.
+var ErrNothingToSweep = errors.New("no spendable outputs worth sweeping")
+
+// SweepWallet sends the whole spendable balance of the wallet to destAddr in a single transaction, paying
+// feeRate (sat/vB) out of the swept amount, and returns the txid. Outputs that cost more to spend than they
+// are worth are left behind. It uses sendall where the node supports it and otherwise funds a transaction
+// spending every safe output with fundrawtransaction, subtracting the fee from the single output. Either
+// way the transaction is broadcast by sendrawtransaction, subject to the client's withdrawal policy.
+func (b *Bitcoind) SweepWallet(destAddr string, feeRate float64) (string, error) {
+	options := map[string]interface{}{"send_max": true, "add_to_wallet": false}
+	r, err := b.client.call("sendall", []interface{}{[]string{destAddr}, nil, "unset", feeRate, options})
+	if err != nil {
+		return "", err
+	}
+
+	if r.Err != nil {
+		// sendall was added in v24
+		if code, _ := errorCode(r.Err); code != RPCMethodNotFound {
+			return "", responseError(r.Err)
+		}
+
+		return b.sweepWithFundRawTransaction(destAddr, feeRate)
+	}
+
+	var res struct {
+		Complete bool   `json:"complete"`
+		Hex      string `json:"hex"`
+	}
+	if err := json.Unmarshal(r.Result, &res); err != nil {
+		return "", err
+	}
+
+	if !res.Complete {
+		return "", errors.New("sendall could not sign the sweep transaction")
+	}
+
+	return b.broadcast(res.Hex)
+}
+
+func (b *Bitcoind) sweepWithFundRawTransaction(destAddr string, feeRate float64) (string, error) {
+	var utxos []*UnspentTransaction
+	if err := b.uncachedCall("listunspent", []interface{}{1}, &utxos); err != nil {
+		return "", err
+	}
+
+	minValue := uint64(math.Ceil(feeRate * sweepInputVSize))
+
+	var inputs []*UnspentTransaction
+	for _, utxo := range utxos {
+		if utxo.Spendable && utxo.Safe && uint64(math.Round(utxo.Amount*1e8)) > minValue {
+			inputs = append(inputs, utxo)
+		}
+	}
+
+	if len(inputs) == 0 {
+		return "", ErrNothingToSweep
+	}
+
+	return b.spendInputsTo(inputs, destAddr, feeRate)
+}
+
+// spendInputsTo sends the full value of the given wallet outputs to destAddr in one transaction without
+// change, paying feeRate (sat/vB) out of the output.
+func (b *Bitcoind) spendInputsTo(utxos []*UnspentTransaction, destAddr string, feeRate float64) (string, error) {
+	inputs := make([]map[string]interface{}, len(utxos))
+	var total uint64
+	for i, utxo := range utxos {
+		inputs[i] = map[string]interface{}{"txid": utxo.TXID, "vout": utxo.Vout}
+		total += uint64(math.Round(utxo.Amount * 1e8))
+	}
+
+	amount := json.Number(fmt.Sprintf("%d.%08d", total/1e8, total%1e8))
+
+	var unfunded string
+	if err := b.uncachedCall("createrawtransaction", []interface{}{inputs, map[string]interface{}{destAddr: amount}}, &unfunded); err != nil {
+		return "", err
+	}
+
+	var funded struct {
+		Hex string `json:"hex"`
+	}
+	options := map[string]interface{}{
+		"add_inputs":             false,
+		"subtractFeeFromOutputs": []int{0},
+		"fee_rate":               feeRate,
+	}
+	if err := b.uncachedCall("fundrawtransaction", []interface{}{unfunded, options}, &funded); err != nil {
+		return "", err
+	}
+
+	var signed struct {
+		Hex      string `json:"hex"`
+		Complete bool   `json:"complete"`
+	}
+	if err := b.uncachedCall("signrawtransactionwithwallet", []interface{}{funded.Hex}, &signed); err != nil {
+		return "", err
+	}
+
+	if !signed.Complete {
+		return "", errors.New("wallet could not sign the transaction")
+	}
+
+	return b.broadcast(signed.Hex)
+}
+
+// broadcast sends a transaction built by the wallet, subject to the client's withdrawal policy.
+func (b *Bitcoind) broadcast(txHex string) (string, error) {
+	if err := b.authorizeTransaction(context.Background(), "sendrawtransaction", txHex); err != nil {
+		return "", err
+	}
+
+	var txid string
+	err := b.uncachedCall("sendrawtransaction", []interface{}{txHex}, &txid)
+	return txid, err
+}
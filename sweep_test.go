@@ -0,0 +1,70 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestSweepWalletFallback(t *testing.T) {
+	var created []interface{}
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		result := `null`
+		switch req.Method {
+		case "sendall":
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":1}`))
+			return
+		case "listunspent":
+			result = `[
+				{"txid":"aa","vout":0,"amount":0.5,"spendable":true,"safe":true},
+				{"txid":"bb","vout":1,"amount":0.00000500,"spendable":true,"safe":true},
+				{"txid":"cc","vout":0,"amount":0.25,"spendable":true,"safe":false},
+				{"txid":"dd","vout":2,"amount":0.1,"spendable":true,"safe":true}
+			]`
+		case "createrawtransaction":
+			created = req.Params.([]interface{})
+			result = `"unfunded"`
+		case "fundrawtransaction":
+			result = `{"hex":"funded","fee":0.00001,"changepos":-1}`
+		case "signrawtransactionwithwallet":
+			result = `{"hex":"signed","complete":true}`
+		case "sendrawtransaction":
+			result = `"txid"`
+		}
+
+		_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":1}`))
+	})
+	defer done()
+
+	txid, err := b.SweepWallet("bc1qdest", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if txid != "txid" {
+		t.Errorf("unexpected txid %q", txid)
+	}
+
+	// The dust output and the unsafe output are left behind
+	if got := fmt.Sprint(created); got != "[[map[txid:aa vout:0] map[txid:dd vout:2]] map[bc1qdest:0.6]]" {
+		t.Errorf("unexpected createrawtransaction params %s", got)
+	}
+}
+
+func TestSweepWalletStringError(t *testing.T) {
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		// Proxies in front of the node have been seen to send plain string errors
+		_, _ = w.Write([]byte(`{"result":null,"error":"upstream unavailable","id":1}`))
+	})
+	defer done()
+
+	_, err := b.SweepWallet("bc1qdest", 10)
+	if err == nil || err.Error() != "ERROR: upstream unavailable" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package bitcoin
+
+import "strings"
+
+// SyncStatus summarizes how far a node is synced. A node started from an assumeutxo snapshot serves the
+// chain from the snapshot onwards while it validates the blocks below the snapshot in the background; it
+// reports Synced but not Validated until the background validation completes.
+type SyncStatus struct {
+	Blocks               int32
+	Headers              int32
+	VerificationProgress float64
+	InitialBlockDownload bool
+	// Synced is set once the node left initial block download and caught up with the best known headers.
+	Synced bool
+	// Validated is set when every block up to the tip was validated, which is not the case while a snapshot
+	// is validated in the background.
+	Validated bool
+
+	// SnapshotBlockHash is the block of the assumeutxo snapshot the active chainstate was loaded from.
+	SnapshotBlockHash string
+	// BackgroundHeight and BackgroundProgress tell how far the background validation got, while it runs.
+	BackgroundHeight   int32
+	BackgroundProgress float64
+}
+
+// BackgroundValidation reports whether the node is validating an assumeutxo snapshot in the background.
+func (s *SyncStatus) BackgroundValidation() bool {
+	return s.SnapshotBlockHash != "" && !s.Validated
+}
+
+// SyncStatus returns the sync status of the node. Chainstates are looked up with getchainstates on nodes
+// supporting it (v26+); older nodes cannot load snapshots and count as validated once synced.
+func (b *Bitcoind) SyncStatus() (*SyncStatus, error) {
+	info, err := b.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SyncStatus{
+		Blocks:               info.Blocks,
+		Headers:              info.Headers,
+		VerificationProgress: info.VerificationProgress,
+		InitialBlockDownload: info.InitialBlockDownload,
+	}
+	s.Synced = !info.InitialBlockDownload && info.Blocks >= info.Headers
+	s.Validated = true
+
+	states, err := b.GetChainStates()
+	if err != nil {
+		if isMethodNotFound(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	if len(states.ChainStates) == 0 {
+		return s, nil
+	}
+
+	active := states.ChainStates[len(states.ChainStates)-1]
+	s.SnapshotBlockHash = active.SnapshotBlockHash
+	s.Validated = active.Validated
+
+	if len(states.ChainStates) > 1 {
+		background := states.ChainStates[0]
+		s.BackgroundHeight = background.Blocks
+		s.BackgroundProgress = background.VerificationProgress
+	}
+
+	return s, nil
+}
+
+// isMethodNotFound reports whether a call failed because the node does not know the method, whether the
+// node answered with HTTP 200 or 404.
+func isMethodNotFound(err error) bool {
+	return strings.Contains(err.Error(), "Method not found")
+}
@@ -0,0 +1,65 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncStatus(t *testing.T) {
+	var chainStates string
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "getblockchaininfo":
+			_, _ = w.Write([]byte(`{"result":{"blocks":840000,"headers":840000,"verificationprogress":0.9999,"initialblockdownload":false},"error":null,"id":1}`))
+		case "getchainstates":
+			if chainStates == "" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":1}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"result":` + chainStates + `,"error":null,"id":1}`))
+		}
+	})
+	defer done()
+
+	// The node's state changes between calls
+	b.Storage = cache.New(time.Nanosecond, time.Minute)
+
+	status := func() *SyncStatus {
+		s, err := b.SyncStatus()
+		require.NoError(t, err)
+		return s
+	}
+
+	// Nodes without getchainstates
+	s := status()
+	assert.True(t, s.Synced)
+	assert.True(t, s.Validated)
+	assert.False(t, s.BackgroundValidation())
+
+	chainStates = `{"headers":840000,"chainstates":[{"blocks":840000,"bestblockhash":"tip","verificationprogress":0.9999,"validated":true}]}`
+	s = status()
+	assert.True(t, s.Validated)
+	assert.Empty(t, s.SnapshotBlockHash)
+
+	chainStates = `{"headers":840000,"chainstates":[` +
+		`{"blocks":312000,"bestblockhash":"bg","verificationprogress":0.21,"validated":true},` +
+		`{"blocks":840000,"bestblockhash":"tip","verificationprogress":0.9999,"snapshot_blockhash":"snapshot","validated":false}]}`
+	s = status()
+	assert.True(t, s.Synced)
+	assert.False(t, s.Validated)
+	assert.True(t, s.BackgroundValidation())
+	assert.Equal(t, "snapshot", s.SnapshotBlockHash)
+	assert.Equal(t, int32(312000), s.BackgroundHeight)
+	assert.Equal(t, 0.21, s.BackgroundProgress)
+}
@@ -0,0 +1,332 @@
+// Package testvectors generates deterministic regtest chains for tests. The same seed and length always
+// produce the same keys, blocks and transactions, so fixtures exported from a Chain can be checked into
+// downstream repositories and compared byte for byte across runs and machines.
+//
+// Blocks are mined offline on top of the regtest genesis block. Every coinbase pays the chain's key, and
+// from height 101 on every block also contains a transaction spending the then mature coinbase of 100
+// blocks earlier back to the same key. A fresh regtest node accepts the chain with Submit.
+package testvectors
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/bitcoinsv/bsvd/bsvec"
+	bitcoin "github.com/shuber/go-bitcoin"
+)
+
+// Regtest consensus parameters the generated chains follow.
+const (
+	GenesisHash = "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206"
+	GenesisTime = 1296688602
+
+	blockVersion     = 0x20000000
+	blockBits        = 0x207fffff
+	blockInterval    = 600
+	halvingInterval  = 150
+	coinbaseMaturity = 100
+	spendFee         = 1000 // satoshis
+)
+
+// DefaultSeed is the seed used when Generate is called with an empty one.
+const DefaultSeed = "go-bitcoin testvectors"
+
+// Transaction is a transaction of a generated block.
+type Transaction struct {
+	TxID string `json:"txid"`
+	Hex  string `json:"hex"`
+}
+
+// Block is a generated block.
+type Block struct {
+	Height            int           `json:"height"`
+	Hash              string        `json:"hash"`
+	PreviousBlockHash string        `json:"previousblockhash"`
+	MerkleRoot        string        `json:"merkleroot"`
+	Time              uint32        `json:"time"`
+	Nonce             uint32        `json:"nonce"`
+	Hex               string        `json:"hex"`
+	Tx                []Transaction `json:"tx"`
+}
+
+// Chain is a generated regtest chain together with the key its outputs pay to.
+type Chain struct {
+	Seed         string  `json:"seed"`
+	PrivateKey   string  `json:"privatekey"` // WIF, for importing into a regtest wallet
+	PublicKey    string  `json:"publickey"`
+	Address      string  `json:"address"` // P2PKH
+	ScriptPubKey string  `json:"scriptpubkey"`
+	Blocks       []Block `json:"blocks"`
+}
+
+// Tip returns the last block of the chain.
+func (c *Chain) Tip() Block {
+	return c.Blocks[len(c.Blocks)-1]
+}
+
+// Generate returns a chain of the given number of blocks above genesis, using the key derived from seed.
+func Generate(seed string, blocks int) (*Chain, error) {
+	if seed == "" {
+		seed = DefaultSeed
+	}
+
+	keyBytes := sha256.Sum256([]byte(seed))
+	priv, pub := bsvec.PrivKeyFromBytes(bsvec.S256(), keyBytes[:])
+	pubKey := pub.SerializeCompressed()
+	script := cryptolib.PublicKeyToP2PKHScript(pubKey)
+
+	c := &Chain{
+		Seed:         seed,
+		PrivateKey:   regtestWIF(keyBytes[:]),
+		PublicKey:    hex.EncodeToString(pubKey),
+		Address:      cryptolib.AddressFromPublicKey(pub, false),
+		ScriptPubKey: hex.EncodeToString(script),
+	}
+
+	target := new(big.Int).Lsh(big.NewInt(blockBits&0xffffff), 8*(blockBits>>24-3))
+	prevHash := GenesisHash
+	coinbases := make([]string, blocks+1)
+
+	for height := 1; height <= blocks; height++ {
+		var txs [][]byte
+		var fees uint64
+
+		if height > coinbaseMaturity {
+			spent := height - coinbaseMaturity
+			spend, err := spendTx(priv, pubKey, script, coinbases[spent], subsidy(spent))
+			if err != nil {
+				return nil, err
+			}
+			txs = append(txs, spend)
+			fees += spendFee
+		}
+
+		coinbase := coinbaseTx(height, subsidy(height)+fees, script)
+		txs = append([][]byte{coinbase}, txs...)
+
+		block := Block{
+			Height:            height,
+			PreviousBlockHash: prevHash,
+			Time:              uint32(GenesisTime + height*blockInterval),
+		}
+
+		var merkle [][]byte
+		for _, tx := range txs {
+			hash := cryptolib.Sha256d(tx)
+			merkle = append(merkle, hash)
+			block.Tx = append(block.Tx, Transaction{TxID: hashString(hash), Hex: hex.EncodeToString(tx)})
+		}
+		coinbases[height] = block.Tx[0].TxID
+
+		root := merkleRoot(merkle)
+		block.MerkleRoot = hashString(root)
+
+		header := make([]byte, 80)
+		binary.LittleEndian.PutUint32(header[0:], blockVersion)
+		prev, _ := hex.DecodeString(prevHash)
+		copy(header[4:], cryptolib.ReverseBytes(prev))
+		copy(header[36:], root)
+		binary.LittleEndian.PutUint32(header[68:], block.Time)
+		binary.LittleEndian.PutUint32(header[72:], blockBits)
+
+		// Grind the nonce, with regtest's minimum difficulty every other hash is expected to do
+		for nonce := uint32(0); ; nonce++ {
+			binary.LittleEndian.PutUint32(header[76:], nonce)
+			hash := cryptolib.ReverseBytes(cryptolib.Sha256d(header))
+			if new(big.Int).SetBytes(hash).Cmp(target) <= 0 {
+				block.Nonce = nonce
+				block.Hash = hex.EncodeToString(hash)
+				break
+			}
+			if nonce == ^uint32(0) {
+				return nil, fmt.Errorf("no valid nonce for block %d", height)
+			}
+		}
+
+		var raw bytes.Buffer
+		raw.Write(header)
+		raw.Write(cryptolib.VarInt(uint64(len(txs))))
+		for _, tx := range txs {
+			raw.Write(tx)
+		}
+		block.Hex = hex.EncodeToString(raw.Bytes())
+
+		c.Blocks = append(c.Blocks, block)
+		prevHash = block.Hash
+	}
+
+	return c, nil
+}
+
+// WriteFixture writes the chain as indented JSON.
+func (c *Chain) WriteFixture(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ReadFixture reads a chain written by WriteFixture.
+func ReadFixture(path string) (*Chain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Chain
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &c, nil
+}
+
+// Submit submits the blocks of the chain in order to a regtest node. The node has to be at genesis, as
+// blocks of a competing chain of the same length are not activated.
+func (c *Chain) Submit(b *bitcoin.Bitcoind) error {
+	for _, block := range c.Blocks {
+		if _, err := b.SubmitBlock(block.Hex); err != nil {
+			return fmt.Errorf("block %d (%s): %w", block.Height, block.Hash, err)
+		}
+	}
+
+	return nil
+}
+
+// subsidy returns the block reward at the given height in satoshis.
+func subsidy(height int) uint64 {
+	halvings := height / halvingInterval
+	if halvings >= 64 {
+		return 0
+	}
+	return (50 * 1e8) >> uint(halvings)
+}
+
+// coinbaseTx returns a coinbase transaction paying value to script, with the height in the scriptSig as
+// required by BIP 34.
+func coinbaseTx(height int, value uint64, script []byte) []byte {
+	scriptSig := append(scriptNum(height), 0x00) // OP_0 keeps the scriptSig at the minimum of 2 bytes
+
+	var tx bytes.Buffer
+	writeUint32(&tx, 1)
+	tx.WriteByte(1)
+	tx.Write(make([]byte, 32))
+	writeUint32(&tx, 0xffffffff)
+	writeScript(&tx, scriptSig)
+	writeUint32(&tx, 0xffffffff)
+	tx.WriteByte(1)
+	writeUint64(&tx, value)
+	writeScript(&tx, script)
+	writeUint32(&tx, 0)
+
+	return tx.Bytes()
+}
+
+// spendTx returns a signed transaction spending the first output of the given coinbase back to script.
+func spendTx(priv *bsvec.PrivateKey, pubKey []byte, script []byte, coinbaseTxID string, value uint64) ([]byte, error) {
+	txid, err := hex.DecodeString(coinbaseTxID)
+	if err != nil {
+		return nil, err
+	}
+	outpoint := cryptolib.ReverseBytes(txid)
+
+	serialize := func(scriptSig []byte) []byte {
+		var tx bytes.Buffer
+		writeUint32(&tx, 2)
+		tx.WriteByte(1)
+		tx.Write(outpoint)
+		writeUint32(&tx, 0)
+		writeScript(&tx, scriptSig)
+		writeUint32(&tx, 0xffffffff)
+		tx.WriteByte(1)
+		writeUint64(&tx, value-spendFee)
+		writeScript(&tx, script)
+		writeUint32(&tx, 0)
+		return tx.Bytes()
+	}
+
+	// Legacy SIGHASH_ALL: the transaction with the spent output's script in place of the scriptSig
+	preimage := append(serialize(script), 1, 0, 0, 0)
+	sig, err := priv.Sign(cryptolib.Sha256d(preimage))
+	if err != nil {
+		return nil, err
+	}
+
+	var scriptSig bytes.Buffer
+	writeScript(&scriptSig, append(sig.Serialize(), 0x01))
+	writeScript(&scriptSig, pubKey)
+
+	return serialize(scriptSig.Bytes()), nil
+}
+
+// scriptNum returns the script pushing n the way bitcoind serializes it in coinbases.
+func scriptNum(n int) []byte {
+	if n >= 1 && n <= 16 {
+		return []byte{0x50 + byte(n)}
+	}
+
+	var num []byte
+	for v := n; v > 0; v >>= 8 {
+		num = append(num, byte(v))
+	}
+	// Keep the number positive
+	if num[len(num)-1]&0x80 != 0 {
+		num = append(num, 0)
+	}
+
+	return append([]byte{byte(len(num))}, num...)
+}
+
+// regtestWIF encodes a private key for a compressed public key in the test network WIF format.
+func regtestWIF(key []byte) string {
+	b := append([]byte{0xef}, key...)
+	b = append(b, 0x01)
+	return cryptolib.EncodeToString(append(b, cryptolib.Sha256d(b)[:4]...))
+}
+
+// merkleRoot returns the merkle root of the given transaction hashes, in internal byte order.
+func merkleRoot(hashes [][]byte) []byte {
+	for len(hashes) > 1 {
+		if len(hashes)%2 == 1 {
+			hashes = append(hashes, hashes[len(hashes)-1])
+		}
+
+		next := make([][]byte, 0, len(hashes)/2)
+		for i := 0; i < len(hashes); i += 2 {
+			next = append(next, cryptolib.Sha256d(append(append([]byte{}, hashes[i]...), hashes[i+1]...)))
+		}
+		hashes = next
+	}
+
+	return hashes[0]
+}
+
+func hashString(hash []byte) string {
+	return hex.EncodeToString(cryptolib.ReverseBytes(hash))
+}
+
+func writeUint32(w *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	w.Write(b[:])
+}
+
+func writeUint64(w *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	w.Write(b[:])
+}
+
+func writeScript(w *bytes.Buffer, script []byte) {
+	w.Write(cryptolib.VarInt(uint64(len(script))))
+	w.Write(script)
+}
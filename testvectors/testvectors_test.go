@@ -0,0 +1,88 @@
+package testvectors
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	a, err := Generate("", 105)
+	require.NoError(t, err)
+	b, err := Generate(DefaultSeed, 105)
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+	assert.Len(t, a.Blocks, 105)
+	assert.True(t, strings.HasPrefix(a.PrivateKey, "c"))
+
+	// Pinned so that changes to the generated chains are noticed, fixtures of downstream users depend on them
+	assert.Equal(t, "mh4BLokhfWkce5rS4FyYtwaF8EqgFuEcms", a.Address)
+	assert.Equal(t, "0501e92cf68c2a73bedb8f29a104d9999aa6ad3f702c69bac57b3098651f92a0", a.Blocks[0].Hash)
+
+	other, err := Generate("other", 105)
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Tip().Hash, other.Tip().Hash)
+	assert.NotEqual(t, a.Address, other.Address)
+}
+
+func TestGenerateChainStructure(t *testing.T) {
+	c, err := Generate("", 102)
+	require.NoError(t, err)
+
+	prev := GenesisHash
+	for i, block := range c.Blocks {
+		assert.Equal(t, i+1, block.Height)
+		assert.Equal(t, prev, block.PreviousBlockHash)
+
+		raw, err := hex.DecodeString(block.Hex)
+		require.NoError(t, err)
+		assert.Equal(t, block.Hash, hex.EncodeToString(cryptolib.ReverseBytes(cryptolib.Sha256d(raw[:80]))))
+		// Regtest proof of work leaves the top bit of the hash clear
+		assert.Less(t, block.Hash[0], byte('8'))
+
+		for _, tx := range block.Tx {
+			txRaw, err := hex.DecodeString(tx.Hex)
+			require.NoError(t, err)
+			assert.Equal(t, tx.TxID, hashString(cryptolib.Sha256d(txRaw)))
+			assert.Contains(t, block.Hex, tx.Hex)
+		}
+
+		prev = block.Hash
+	}
+
+	assert.Len(t, c.Blocks[99].Tx, 1)
+	require.Len(t, c.Blocks[100].Tx, 2)
+
+	// The spend in block 101 pays the coinbase of block 1 back to the key, less the fee
+	spend, err := hex.DecodeString(c.Blocks[100].Tx[1].Hex)
+	require.NoError(t, err)
+	coinbase, _ := hex.DecodeString(c.Blocks[0].Tx[0].TxID)
+	assert.Equal(t, cryptolib.ReverseBytes(coinbase), spend[5:37])
+	assert.Contains(t, c.Blocks[100].Tx[1].Hex, c.ScriptPubKey)
+}
+
+func TestScriptNum(t *testing.T) {
+	assert.Equal(t, []byte{0x51}, scriptNum(1))
+	assert.Equal(t, []byte{0x60}, scriptNum(16))
+	assert.Equal(t, []byte{0x01, 0x11}, scriptNum(17))
+	assert.Equal(t, []byte{0x02, 0x80, 0x00}, scriptNum(128))
+	assert.Equal(t, []byte{0x02, 0x2c, 0x01}, scriptNum(300))
+}
+
+func TestFixtureRoundTrip(t *testing.T) {
+	c, err := Generate("", 3)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "chain.json")
+	require.NoError(t, c.WriteFixture(path))
+
+	read, err := ReadFixture(path)
+	require.NoError(t, err)
+	assert.Equal(t, c, read)
+}
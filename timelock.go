@@ -0,0 +1,436 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Consensus constants of absolute (nLockTime, BIP 65) and relative (nSequence, BIP 68 and 112) time locks.
+const (
+	// LockTimeThreshold separates nLockTime values that are block heights from Unix timestamps.
+	LockTimeThreshold uint32 = 500000000
+	// SequenceFinal disables both nLockTime and the relative lock of an input.
+	SequenceFinal uint32 = 0xffffffff
+	// SequenceLockTimeDisableFlag marks an nSequence as not encoding a relative lock.
+	SequenceLockTimeDisableFlag uint32 = 1 << 31
+	// SequenceLockTimeTypeFlag marks a relative lock as counted in units of 512 seconds instead of blocks.
+	SequenceLockTimeTypeFlag uint32 = 1 << 22
+	// SequenceLockTimeMask extracts the value of a relative lock.
+	SequenceLockTimeMask uint32 = 0x0000ffff
+	// SequenceLockTimeGranularity is the number of seconds in a unit of a time based relative lock.
+	SequenceLockTimeGranularity = 512
+)
+
+// ErrInvalidTimeLock is returned for time locks that cannot be encoded or would not be enforced.
+var ErrInvalidTimeLock = errors.New("invalid time lock")
+
+// LockTimeAtHeight returns the nLockTime of a transaction that can be mined in the block after height.
+func LockTimeAtHeight(height int) (uint32, error) {
+	if height < 0 || uint32(height) >= LockTimeThreshold {
+		return 0, fmt.Errorf("%w: height %d out of range", ErrInvalidTimeLock, height)
+	}
+
+	return uint32(height), nil
+}
+
+// LockTimeAtTime returns the nLockTime of a transaction that can be mined once the median time past of the
+// chain is after t.
+func LockTimeAtTime(t time.Time) (uint32, error) {
+	unix := t.Unix()
+	if unix < int64(LockTimeThreshold) || unix > math.MaxUint32 {
+		return 0, fmt.Errorf("%w: time %s out of range", ErrInvalidTimeLock, t.UTC().Format(time.RFC3339))
+	}
+
+	return uint32(unix), nil
+}
+
+// RelativeLockBlocks returns the nSequence of an input that can be mined blocks after the output it spends.
+func RelativeLockBlocks(blocks int) (uint32, error) {
+	if blocks < 0 || blocks > int(SequenceLockTimeMask) {
+		return 0, fmt.Errorf("%w: %d blocks out of range", ErrInvalidTimeLock, blocks)
+	}
+
+	return uint32(blocks), nil
+}
+
+// RelativeLockDuration returns the nSequence of an input that can be mined d after the output it spends,
+// rounded up to the 512 second granularity of relative locks.
+func RelativeLockDuration(d time.Duration) (uint32, error) {
+	units := int64(math.Ceil(d.Seconds() / SequenceLockTimeGranularity))
+	if units < 0 || units > int64(SequenceLockTimeMask) {
+		return 0, fmt.Errorf("%w: duration %s out of range", ErrInvalidTimeLock, d)
+	}
+
+	return SequenceLockTimeTypeFlag | uint32(units), nil
+}
+
+// RelativeLock is the relative lock encoded in the nSequence of an input.
+type RelativeLock struct {
+	Blocks  int   // blocks after the spent output confirmed
+	Seconds int64 // seconds after the median time past of the block before the one confirming the spent output
+}
+
+// DecodeRelativeLock returns the relative lock encoded in an nSequence, and false if it encodes none. The
+// lock is only enforced in transactions of version 2 or later.
+func DecodeRelativeLock(sequence uint32) (RelativeLock, bool) {
+	if sequence&SequenceLockTimeDisableFlag != 0 {
+		return RelativeLock{}, false
+	}
+
+	value := sequence & SequenceLockTimeMask
+	if sequence&SequenceLockTimeTypeFlag != 0 {
+		return RelativeLock{Seconds: int64(value) * SequenceLockTimeGranularity}, true
+	}
+
+	return RelativeLock{Blocks: int(value)}, true
+}
+
+// ValidateTimeLocks checks that the time locks of a transaction are enforced as intended: an nLockTime needs
+// at least one input with a non-final nSequence, and relative locks need a transaction version of 2 or later.
+func ValidateTimeLocks(txHex string) error {
+	tx, err := parseTxHex(txHex)
+	if err != nil {
+		return err
+	}
+
+	return validateTimeLocks(tx)
+}
+
+func validateTimeLocks(tx *parsedTx) error {
+	final := true
+	for i, in := range tx.Inputs {
+		if in.Sequence != SequenceFinal {
+			final = false
+		}
+
+		if _, ok := DecodeRelativeLock(in.Sequence); ok && tx.Version < 2 {
+			return fmt.Errorf("%w: input %d has a relative lock, which version %d transactions do not enforce", ErrInvalidTimeLock, i, tx.Version)
+		}
+	}
+
+	if tx.LockTime != 0 && final {
+		return fmt.Errorf("%w: nLockTime %d is disabled by the final nSequence of every input", ErrInvalidTimeLock, tx.LockTime)
+	}
+
+	return nil
+}
+
+func parseTxHex(txHex string) (*parsedTx, error) {
+	raw, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction hex: %w", err)
+	}
+
+	return parseTx(raw)
+}
+
+// TimeLockedInput is an input of a transaction built by CreateTimeLockedTransaction.
+type TimeLockedInput struct {
+	TxID string
+	Vout int
+	// RelativeLock is an nSequence returned by RelativeLockBlocks or RelativeLockDuration, 0 for none.
+	RelativeLock uint32
+}
+
+// CreateTimeLockedTransaction builds a transaction spending inputs to outputs (address to BTC amount) that
+// cannot be mined before lockTime, as returned by LockTimeAtHeight or LockTimeAtTime, or 0 for none, nor
+// before the relative locks of its inputs expire. Inputs without a relative lock get the nSequence
+// 0xfffffffe, which enforces nLockTime without signaling replaceability. The transaction is signed with
+// the wallet and returned without broadcasting it; complete is false when inputs remain to be signed
+// elsewhere, such as those spending CSV scripts.
+func (b *Bitcoind) CreateTimeLockedTransaction(inputs []TimeLockedInput, outputs map[string]float64, lockTime uint32) (txHex string, complete bool, err error) {
+	params := make([]map[string]interface{}, len(inputs))
+	for i, in := range inputs {
+		sequence := SequenceFinal - 1
+		if in.RelativeLock != 0 {
+			sequence = in.RelativeLock
+		}
+		params[i] = map[string]interface{}{"txid": in.TxID, "vout": in.Vout, "sequence": sequence}
+	}
+
+	amounts := make(map[string]interface{}, len(outputs))
+	for address, amount := range outputs {
+		amounts[address] = json.Number(fmt.Sprintf("%.8f", amount))
+	}
+
+	var unsigned string
+	if err = b.uncachedCall("createrawtransaction", []interface{}{params, amounts, lockTime}, &unsigned); err != nil {
+		return "", false, err
+	}
+
+	if err = ValidateTimeLocks(unsigned); err != nil {
+		return "", false, err
+	}
+
+	var signed struct {
+		Hex      string `json:"hex"`
+		Complete bool   `json:"complete"`
+	}
+	if err = b.uncachedCall("signrawtransactionwithwallet", []interface{}{unsigned}, &signed); err != nil {
+		return "", false, err
+	}
+
+	return signed.Hex, signed.Complete, nil
+}
+
+// TimeLockStatus tells when a time locked transaction can be broadcast. Nodes accept a transaction into
+// their mempool once it is valid in the block after the tip: a height lock expires when the tip reaches
+// Height and a time lock when the median time past of the tip reaches MedianTime.
+type TimeLockStatus struct {
+	Final         bool  // the transaction can be broadcast now
+	Height        int   // tip height required, 0 without height locks
+	MedianTime    int64 // median time past of the tip required, 0 without time locks
+	TipHeight     int
+	TipMedianTime int64
+	// Unconfirmed is set when an input with a relative lock spends an unconfirmed output, whose lock only
+	// starts once it confirms; Height and MedianTime do not account for it.
+	Unconfirmed bool
+}
+
+// EstimatedBroadcastTime estimates when the transaction can be broadcast, assuming 10 minute blocks and a
+// median time past advancing at the pace of the wall clock. It returns now for final transactions and a
+// zero time while inputs are unconfirmed.
+func (s *TimeLockStatus) EstimatedBroadcastTime(now time.Time) time.Time {
+	if s.Final {
+		return now
+	}
+	if s.Unconfirmed {
+		return time.Time{}
+	}
+
+	wait := time.Duration(0)
+	if blocks := s.Height - s.TipHeight; blocks > 0 {
+		wait = time.Duration(blocks) * 10 * time.Minute
+	}
+	if seconds := s.MedianTime - s.TipMedianTime; seconds > 0 && time.Duration(seconds)*time.Second > wait {
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	return now.Add(wait)
+}
+
+// TimeLockStatus returns when the given transaction can be broadcast. Relative locks are resolved by looking
+// up the outputs the transaction spends, which therefore have to be unspent.
+func (b *Bitcoind) TimeLockStatus(txHex string) (*TimeLockStatus, error) {
+	tx, err := parseTxHex(txHex)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateTimeLocks(tx); err != nil {
+		return nil, err
+	}
+
+	info, err := b.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &TimeLockStatus{TipHeight: int(info.Blocks), TipMedianTime: info.MedianTime}
+
+	if tx.LockTime != 0 {
+		if tx.LockTime < LockTimeThreshold {
+			s.Height = int(tx.LockTime)
+		} else {
+			// The lock time has to be strictly before the median time past
+			s.MedianTime = int64(tx.LockTime) + 1
+		}
+	}
+
+	if tx.Version >= 2 {
+		for i, in := range tx.Inputs {
+			lock, ok := DecodeRelativeLock(in.Sequence)
+			if !ok || (lock.Blocks == 0 && lock.Seconds == 0) {
+				continue
+			}
+
+			out, err := b.GetTxOut(in.PrevTxID, int(in.PrevIndex), true)
+			if err != nil {
+				return nil, err
+			}
+			if out == nil {
+				return nil, fmt.Errorf("input %d spends %s:%d, which is spent or unknown", i, in.PrevTxID, in.PrevIndex)
+			}
+
+			if out.Confirmations == 0 {
+				s.Unconfirmed = true
+				continue
+			}
+
+			confirmedAt := s.TipHeight - out.Confirmations + 1
+
+			if lock.Blocks > 0 {
+				// Valid in block confirmedAt+Blocks, so once the tip is one below it
+				if h := confirmedAt + lock.Blocks - 1; h > s.Height {
+					s.Height = h
+				}
+				continue
+			}
+
+			mtp, err := b.medianTimeAt(confirmedAt - 1)
+			if err != nil {
+				return nil, err
+			}
+			if t := mtp + lock.Seconds; t > s.MedianTime {
+				s.MedianTime = t
+			}
+		}
+	}
+
+	s.Final = !s.Unconfirmed && s.TipHeight >= s.Height && s.TipMedianTime >= s.MedianTime
+
+	return s, nil
+}
+
+// medianTimeAt returns the median time past of the block at the given height of the active chain.
+func (b *Bitcoind) medianTimeAt(height int) (int64, error) {
+	hash, err := b.GetBlockHash(height)
+	if err != nil {
+		return 0, err
+	}
+
+	header, err := b.GetBlockHeader(hash)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, fmt.Errorf("block %s not found", hash)
+	}
+
+	return int64(header.MedianTime), nil
+}
+
+// TimeLockedBroadcast reports the broadcast of a transaction held by a TimeLockScheduler. When Err is set
+// the node rejected the transaction and it is no longer held.
+type TimeLockedBroadcast struct {
+	ID   string
+	TxID string
+	Err  error
+}
+
+// TimeLockScheduler holds signed time locked transactions and broadcasts each one as soon as its locks
+// expire. Broadcasts are subject to the client's withdrawal policy.
+type TimeLockScheduler struct {
+	// OnBroadcast is called for every transaction broadcast or rejected by Check.
+	OnBroadcast func(TimeLockedBroadcast)
+
+	b   *Bitcoind
+	mu  sync.Mutex
+	txs map[string]string
+}
+
+// NewTimeLockScheduler returns a TimeLockScheduler broadcasting through the given node.
+func NewTimeLockScheduler(b *Bitcoind) *TimeLockScheduler {
+	return &TimeLockScheduler{b: b, txs: make(map[string]string)}
+}
+
+// Schedule holds a signed transaction under the caller's id until its locks expire, replacing any
+// transaction scheduled under the same id.
+func (s *TimeLockScheduler) Schedule(id, txHex string) error {
+	if err := ValidateTimeLocks(txHex); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.txs[id] = txHex
+	return nil
+}
+
+// Cancel stops holding the transaction scheduled under id.
+func (s *TimeLockScheduler) Cancel(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.txs, id)
+}
+
+// Pending returns the number of transactions held.
+func (s *TimeLockScheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.txs)
+}
+
+// Check broadcasts the held transactions whose locks have expired and returns the results. Transactions
+// whose status cannot be determined stay held, and the first such error is returned.
+func (s *TimeLockScheduler) Check() ([]TimeLockedBroadcast, error) {
+	s.mu.Lock()
+	txs := make(map[string]string, len(s.txs))
+	for id, txHex := range s.txs {
+		txs[id] = txHex
+	}
+	s.mu.Unlock()
+
+	var results []TimeLockedBroadcast
+	var firstErr error
+
+	for id, txHex := range txs {
+		status, err := s.b.TimeLockStatus(txHex)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", id, err)
+			}
+			continue
+		}
+
+		if !status.Final {
+			continue
+		}
+
+		txid, err := s.b.broadcast(txHex)
+		if err != nil && isNonFinal(err) {
+			// The locks expired by our view of the chain but not yet by the node's, try again next time
+			continue
+		}
+
+		s.mu.Lock()
+		if s.txs[id] == txHex {
+			delete(s.txs, id)
+		}
+		s.mu.Unlock()
+
+		results = append(results, TimeLockedBroadcast{ID: id, TxID: txid, Err: err})
+	}
+
+	if s.OnBroadcast != nil {
+		for _, r := range results {
+			s.OnBroadcast(r)
+		}
+	}
+
+	return results, firstErr
+}
+
+// isNonFinal reports whether a broadcast was rejected for locks that have not expired.
+func isNonFinal(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "non-final") || strings.Contains(msg, "non-BIP68-final")
+}
+
+// Run checks every interval until the context is done.
+func (s *TimeLockScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := s.b.client.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Check(); err != nil {
+			s.b.client.logger.Errorf("TimeLockScheduler: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTimeLockedTx serializes a transaction spending one output per sequence.
+func testTimeLockedTx(version int32, lockTime uint32, sequences ...uint32) string {
+	tx := transaction{Version: version, LockTime: lockTime}
+	for i, sequence := range sequences {
+		var hash [32]byte
+		hash[0] = byte(i + 1)
+		tx.Inputs = append(tx.Inputs, input{hash: hash, sequence: sequence})
+	}
+	tx.Outputs = []output{{value: 1000, lockingScript: []byte{0x51}}}
+
+	return hex.EncodeToString(tx.ToHex())
+}
+
+func TestTimeLockEncoding(t *testing.T) {
+	lockTime, err := LockTimeAtHeight(800000)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(800000), lockTime)
+
+	_, err = LockTimeAtHeight(int(LockTimeThreshold))
+	assert.True(t, errors.Is(err, ErrInvalidTimeLock))
+
+	lockTime, err = LockTimeAtTime(time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1700000000), lockTime)
+
+	_, err = LockTimeAtTime(time.Unix(1000, 0))
+	assert.True(t, errors.Is(err, ErrInvalidTimeLock))
+
+	sequence, err := RelativeLockBlocks(144)
+	require.NoError(t, err)
+	lock, ok := DecodeRelativeLock(sequence)
+	assert.True(t, ok)
+	assert.Equal(t, RelativeLock{Blocks: 144}, lock)
+
+	// Durations round up to units of 512 seconds
+	sequence, err = RelativeLockDuration(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, SequenceLockTimeTypeFlag|8, sequence)
+	lock, ok = DecodeRelativeLock(sequence)
+	assert.True(t, ok)
+	assert.Equal(t, RelativeLock{Seconds: 4096}, lock)
+
+	_, err = RelativeLockBlocks(70000)
+	assert.True(t, errors.Is(err, ErrInvalidTimeLock))
+
+	_, ok = DecodeRelativeLock(SequenceFinal - 1)
+	assert.False(t, ok)
+}
+
+func TestValidateTimeLocks(t *testing.T) {
+	assert.NoError(t, ValidateTimeLocks(testTimeLockedTx(2, 800000, SequenceFinal-1)))
+	assert.NoError(t, ValidateTimeLocks(testTimeLockedTx(2, 0, 10)))
+
+	err := ValidateTimeLocks(testTimeLockedTx(2, 800000, SequenceFinal))
+	assert.True(t, errors.Is(err, ErrInvalidTimeLock), "%v", err)
+
+	err = ValidateTimeLocks(testTimeLockedTx(1, 0, 10))
+	assert.True(t, errors.Is(err, ErrInvalidTimeLock), "%v", err)
+
+	assert.Error(t, ValidateTimeLocks("zz"))
+}
+
+// timeLockNode serves a chain with its tip at the given height, where block n has the median time past
+// 1600000000 + 600n, and unspent outputs of the transactions in confirmed with their confirmations. The
+// transactions it creates have the requested nLockTime and nSequences, and the wallet signs them completely
+// unless incomplete is set.
+type timeLockNode struct {
+	mu         sync.Mutex
+	tip        int
+	confirmed  map[string]int
+	sent       []string
+	reject     string
+	created    []interface{}
+	signed     []string
+	incomplete bool
+}
+
+func (n *timeLockNode) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	params, _ := req.Params.([]interface{})
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var result interface{}
+	switch req.Method {
+	case "getblockchaininfo":
+		result = map[string]interface{}{"blocks": n.tip, "mediantime": 1600000000 + 600*n.tip}
+	case "gettxout":
+		if conf, found := n.confirmed[params[0].(string)]; found {
+			result = map[string]interface{}{"confirmations": conf}
+		}
+	case "getblockhash":
+		result = strings.Repeat("0", 56) + hex.EncodeToString([]byte{0, 0, 0, byte(params[0].(float64))})
+	case "getblockheader":
+		height, _ := hex.DecodeString(params[0].(string)[56:])
+		result = map[string]interface{}{"mediantime": 1600000000 + 600*int(height[3])}
+	case "createrawtransaction":
+		n.created = params
+		var sequences []uint32
+		for _, in := range params[0].([]interface{}) {
+			sequences = append(sequences, uint32(in.(map[string]interface{})["sequence"].(float64)))
+		}
+		result = testTimeLockedTx(2, uint32(params[2].(float64)), sequences...)
+	case "signrawtransactionwithwallet":
+		n.signed = append(n.signed, params[0].(string))
+		result = map[string]interface{}{"hex": "signed", "complete": !n.incomplete}
+	case "sendrawtransaction":
+		if n.reject != "" {
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-26,"message":"` + n.reject + `"},"id":1}`))
+			return
+		}
+		n.sent = append(n.sent, params[0].(string))
+		result = "txid"
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"result": result, "error": nil, "id": 1})
+	_, _ = w.Write(data)
+}
+
+func TestCreateTimeLockedTransaction(t *testing.T) {
+	node := &timeLockNode{tip: 100}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	outputs := map[string]float64{"bc1qdest": 0.1, "bc1qchange": 0.00012345}
+
+	// Height lock: inputs without a relative lock get the non-final nSequence that enforces it
+	lockTime, err := LockTimeAtHeight(800000)
+	require.NoError(t, err)
+	txHex, complete, err := b.CreateTimeLockedTransaction([]TimeLockedInput{{TxID: "aa", Vout: 1}}, outputs, lockTime)
+	require.NoError(t, err)
+	assert.Equal(t, "signed", txHex)
+	assert.True(t, complete)
+
+	created, _ := json.Marshal(node.created)
+	assert.JSONEq(t, `[[{"txid":"aa","vout":1,"sequence":4294967294}],{"bc1qdest":0.1,"bc1qchange":0.00012345},800000]`, string(created))
+	assert.Equal(t, []string{testTimeLockedTx(2, 800000, SequenceFinal-1)}, node.signed)
+
+	// Time lock, with the relative lock of an input kept as its nSequence
+	lockTime, err = LockTimeAtTime(time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	sequence, err := RelativeLockBlocks(144)
+	require.NoError(t, err)
+
+	node.mu.Lock()
+	node.signed = nil
+	node.incomplete = true
+	node.mu.Unlock()
+
+	inputs := []TimeLockedInput{{TxID: "aa", Vout: 0}, {TxID: "bb", Vout: 2, RelativeLock: sequence}}
+	_, complete, err = b.CreateTimeLockedTransaction(inputs, map[string]float64{"bc1qdest": 0.1}, lockTime)
+	require.NoError(t, err)
+	assert.False(t, complete)
+
+	created, _ = json.Marshal(node.created)
+	assert.JSONEq(t, `[[{"txid":"aa","vout":0,"sequence":4294967294},{"txid":"bb","vout":2,"sequence":144}],{"bc1qdest":0.1},1700000000]`, string(created))
+	assert.Equal(t, []string{testTimeLockedTx(2, 1700000000, SequenceFinal-1, 144)}, node.signed)
+}
+
+func TestTimeLockStatus(t *testing.T) {
+	node := &timeLockNode{tip: 100, confirmed: map[string]int{}}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	prevTxID := func(i int) string {
+		return "0" + string(rune('0'+i)) + strings.Repeat("0", 62)
+	}
+	node.confirmed[prevTxID(1)] = 10 // confirmed at height 91
+	node.confirmed[prevTxID(2)] = 0
+
+	// The node's state changes between calls
+	b.Storage = cache.New(time.Nanosecond, time.Minute)
+
+	// Height lock: valid in block 120, broadcastable at a tip of 120
+	s, err := b.TimeLockStatus(testTimeLockedTx(2, 120, SequenceFinal-1))
+	require.NoError(t, err)
+	assert.False(t, s.Final)
+	assert.Equal(t, 120, s.Height)
+	assert.Equal(t, time.Unix(0, 0).Add(200*time.Minute), s.EstimatedBroadcastTime(time.Unix(0, 0)))
+
+	// Time lock strictly before the median time past of the tip
+	s, err = b.TimeLockStatus(testTimeLockedTx(2, 1600000000+600*100, SequenceFinal-1))
+	require.NoError(t, err)
+	assert.False(t, s.Final)
+	s, err = b.TimeLockStatus(testTimeLockedTx(2, 1600000000+600*100-1, SequenceFinal-1))
+	require.NoError(t, err)
+	assert.True(t, s.Final)
+
+	// 10 blocks after confirming at 91 is block 101, broadcastable at a tip of 100
+	sequence, _ := RelativeLockBlocks(10)
+	s, err = b.TimeLockStatus(testTimeLockedTx(2, 0, sequence))
+	require.NoError(t, err)
+	assert.True(t, s.Final)
+	assert.Equal(t, 100, s.Height)
+
+	sequence, _ = RelativeLockBlocks(11)
+	s, err = b.TimeLockStatus(testTimeLockedTx(2, 0, sequence))
+	require.NoError(t, err)
+	assert.False(t, s.Final)
+
+	// 4 units of 512 seconds after the median time past of block 90
+	sequence, _ = RelativeLockDuration(2048 * time.Second)
+	s, err = b.TimeLockStatus(testTimeLockedTx(2, 0, sequence))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1600000000+600*90+2048), s.MedianTime)
+	assert.True(t, s.Final)
+
+	// The lock of an unconfirmed parent has not started
+	sequence, _ = RelativeLockBlocks(1)
+	s, err = b.TimeLockStatus(testTimeLockedTx(2, 0, SequenceFinal, sequence))
+	require.NoError(t, err)
+	assert.True(t, s.Unconfirmed)
+	assert.False(t, s.Final)
+	assert.True(t, s.EstimatedBroadcastTime(time.Now()).IsZero())
+
+	// Spent parents cannot be resolved
+	node.mu.Lock()
+	node.confirmed = map[string]int{}
+	node.mu.Unlock()
+	_, err = b.TimeLockStatus(testTimeLockedTx(2, 0, sequence))
+	assert.Error(t, err)
+}
+
+func TestTimeLockScheduler(t *testing.T) {
+	node := &timeLockNode{tip: 100, confirmed: map[string]int{}}
+	b, done := newTestNode(t, node.handle)
+	defer done()
+
+	// The node's state changes between calls
+	b.Storage = cache.New(time.Nanosecond, time.Minute)
+	s := NewTimeLockScheduler(b)
+
+	var reported []TimeLockedBroadcast
+	s.OnBroadcast = func(r TimeLockedBroadcast) { reported = append(reported, r) }
+
+	require.Error(t, s.Schedule("bad", testTimeLockedTx(2, 120, SequenceFinal)))
+
+	due := testTimeLockedTx(2, 100, SequenceFinal-1)
+	later := testTimeLockedTx(2, 110, SequenceFinal-1)
+	require.NoError(t, s.Schedule("due", due))
+	require.NoError(t, s.Schedule("later", later))
+
+	results, err := s.Check()
+	require.NoError(t, err)
+	assert.Equal(t, []TimeLockedBroadcast{{ID: "due", TxID: "txid"}}, results)
+	assert.Equal(t, results, reported)
+	assert.Equal(t, []string{due}, node.sent)
+	assert.Equal(t, 1, s.Pending())
+
+	// A node that does not consider the transaction final yet keeps it held
+	node.mu.Lock()
+	node.tip = 110
+	node.reject = "non-final"
+	node.mu.Unlock()
+
+	results, err = s.Check()
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, 1, s.Pending())
+
+	// Other rejections are reported and drop the transaction
+	node.mu.Lock()
+	node.reject = "bad-txns-inputs-missingorspent"
+	node.mu.Unlock()
+
+	results, err = s.Check()
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "later", results[0].ID)
+	assert.Error(t, results[0].Err)
+	assert.Equal(t, 0, s.Pending())
+}
@@ -0,0 +1,124 @@
+package bitcoin
+
+import "time"
+
+// TimeoutClass groups methods with similar latencies.
+type TimeoutClass int
+
+const (
+	TimeoutDefault TimeoutClass = iota // methods not listed in any class
+	TimeoutFast                        // lookups answered from memory or an index
+	TimeoutBlock                       // reading, assembling or validating whole blocks
+	TimeoutScan                        // scanning the chain, the UTXO set or the wallet
+)
+
+// timeoutClasses classifies the methods whose latency differs from the default by orders of magnitude.
+var timeoutClasses = map[string]TimeoutClass{
+	"getbestblockhash":   TimeoutFast,
+	"getblockcount":      TimeoutFast,
+	"getblockhash":       TimeoutFast,
+	"getblockheader":     TimeoutFast,
+	"getblockchaininfo":  TimeoutFast,
+	"getconnectioncount": TimeoutFast,
+	"getdifficulty":      TimeoutFast,
+	"getmempoolentry":    TimeoutFast,
+	"getmempoolinfo":     TimeoutFast,
+	"getmininginfo":      TimeoutFast,
+	"getnetworkinfo":     TimeoutFast,
+	"getpeerinfo":        TimeoutFast,
+	"gettxout":           TimeoutFast,
+	"estimatesmartfee":   TimeoutFast,
+	"uptime":             TimeoutFast,
+	"validateaddress":    TimeoutFast,
+
+	"getblock":          TimeoutBlock,
+	"getblockstats":     TimeoutBlock,
+	"getblocktemplate":  TimeoutBlock,
+	"getchaintxstats":   TimeoutBlock,
+	"preciousblock":     TimeoutBlock,
+	"submitblock":       TimeoutBlock,
+	"submitheader":      TimeoutBlock,
+	"invalidateblock":   TimeoutBlock,
+	"reconsiderblock":   TimeoutBlock,
+	"getblockfrompeer":  TimeoutBlock,
+	"gettxoutproof":     TimeoutBlock,
+	"verifytxoutproof":  TimeoutBlock,
+	"getrawtransaction": TimeoutBlock, // reads the block when the transaction is not in the mempool
+
+	"scantxoutset":      TimeoutScan,
+	"scanblocks":        TimeoutScan,
+	"rescanblockchain":  TimeoutScan,
+	"gettxoutsetinfo":   TimeoutScan,
+	"dumptxoutset":      TimeoutScan,
+	"loadtxoutset":      TimeoutScan,
+	"verifychain":       TimeoutScan,
+	"importaddress":     TimeoutScan,
+	"importdescriptors": TimeoutScan,
+	"importmulti":       TimeoutScan,
+	"importprivkey":     TimeoutScan,
+	"importpubkey":      TimeoutScan,
+	"importwallet":      TimeoutScan,
+	"loadwallet":        TimeoutScan,
+	"restorewallet":     TimeoutScan,
+}
+
+// TimeoutProfile sets the timeout of calls by the class of their method. A zero duration leaves the class
+// at the client's timeout, set with WithTimeoutDuration. Methods overrides the timeout of single methods.
+type TimeoutProfile struct {
+	Fast    time.Duration
+	Block   time.Duration
+	Scan    time.Duration
+	Methods map[string]time.Duration
+}
+
+// DefaultTimeoutProfile suits a node on a local network: fast reads are cut short at 5s, block fetches at
+// 2m and scans at 30m.
+var DefaultTimeoutProfile = TimeoutProfile{
+	Fast:  5 * time.Second,
+	Block: 2 * time.Minute,
+	Scan:  30 * time.Minute,
+}
+
+// ClassifyTimeout returns the timeout class of a method.
+func ClassifyTimeout(method string) TimeoutClass {
+	return timeoutClasses[method]
+}
+
+// WithTimeoutProfile sets the timeout of calls by method instead of a single timeout for all of them.
+func WithTimeoutProfile(profile TimeoutProfile) func(*rpcClient) {
+	return func(p *rpcClient) {
+		methods := make(map[string]time.Duration, len(profile.Methods))
+		for method, d := range profile.Methods {
+			methods[method] = d
+		}
+		profile.Methods = methods
+
+		p.timeouts = &profile
+	}
+}
+
+// timeoutFor returns the timeout of a call to method.
+func (c *rpcClient) timeoutFor(method string) time.Duration {
+	if c.timeouts == nil {
+		return c.rpcClientTimeout
+	}
+
+	if d, found := c.timeouts.Methods[method]; found && d > 0 {
+		return d
+	}
+
+	var d time.Duration
+	switch ClassifyTimeout(method) {
+	case TimeoutFast:
+		d = c.timeouts.Fast
+	case TimeoutBlock:
+		d = c.timeouts.Block
+	case TimeoutScan:
+		d = c.timeouts.Scan
+	}
+
+	if d <= 0 {
+		return c.rpcClientTimeout
+	}
+	return d
+}
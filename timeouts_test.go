@@ -0,0 +1,48 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutProfile(t *testing.T) {
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		if req.Method != "uptime" {
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		_, _ = w.Write([]byte(`{"result":1,"error":null,"id":1}`))
+	})
+	defer done()
+
+	c = c.derive(WithTimeoutDuration(5*time.Second), WithTimeoutProfile(TimeoutProfile{
+		Fast:    20 * time.Millisecond,
+		Methods: map[string]time.Duration{"getnetworkinfo": time.Second},
+	}))
+
+	_, err := c.call("getblockcount", nil)
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	_, err = c.call("uptime", nil)
+	assert.NoError(t, err)
+
+	// Overridden, and left at the client's timeout as the profile sets none for blocks
+	_, err = c.call("getnetworkinfo", nil)
+	assert.NoError(t, err)
+	_, err = c.call("getblock", []interface{}{"00"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, TimeoutScan, ClassifyTimeout("scantxoutset"))
+	assert.Equal(t, TimeoutDefault, ClassifyTimeout("sendrawtransaction"))
+	assert.Equal(t, 30*time.Minute, c.derive(WithTimeoutProfile(DefaultTimeoutProfile)).timeoutFor("rescanblockchain"))
+}
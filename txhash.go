@@ -0,0 +1,380 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+// Signature hash types. SigHashDefault is only valid for taproot, where it commits to the same data as
+// SigHashAll.
+const (
+	SigHashDefault      byte = 0x00
+	SigHashAll          byte = 0x01
+	SigHashNone         byte = 0x02
+	SigHashSingle       byte = 0x03
+	SigHashAnyoneCanPay byte = 0x80
+)
+
+// ErrInvalidSigHash is returned for signature hashes that cannot be computed, such as a taproot hash type
+// outside the ones defined by BIP 341.
+var ErrInvalidSigHash = errors.New("invalid signature hash")
+
+// sigHashName returns the name of a hash type in the format of the node's sighashtype arguments, e.g.
+// "ALL|ANYONECANPAY".
+func sigHashName(hashType byte) (string, error) {
+	names := map[byte]string{SigHashDefault: "DEFAULT", SigHashAll: "ALL", SigHashNone: "NONE", SigHashSingle: "SINGLE"}
+
+	name, found := names[hashType&^SigHashAnyoneCanPay]
+	switch {
+	case !found, hashType == SigHashDefault|SigHashAnyoneCanPay:
+		return "", fmt.Errorf("%w: hash type 0x%02x", ErrInvalidSigHash, hashType)
+	case hashType&SigHashAnyoneCanPay != 0:
+		return name + "|ANYONECANPAY", nil
+	}
+
+	return name, nil
+}
+
+// PrevOut is an output spent by a transaction, as needed for signature hashes committing to amounts.
+type PrevOut struct {
+	Value        uint64 // satoshis
+	ScriptPubKey []byte
+}
+
+// ComputeTxID returns the txid of a serialized transaction, the hash of its serialization without witness data.
+func ComputeTxID(raw []byte) (string, error) {
+	tx, err := parseTx(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return tx.TxID, nil
+}
+
+// ComputeWTxID returns the wtxid of a serialized transaction, the hash of its full serialization.
+func ComputeWTxID(raw []byte) (string, error) {
+	tx, err := parseTx(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return tx.WTxID, nil
+}
+
+// LegacySigHash returns the hash signed by an input spending a pre-segwit output. subscript is the script
+// being executed, the scriptPubKey of the spent output or the redeem script of a P2SH output; its
+// OP_CODESEPARATORs are removed. The signature itself is not removed from subscript, which only matters for
+// verifying signatures that are part of the script they sign.
+func LegacySigHash(raw []byte, inputIndex int, subscript []byte, hashType uint32) ([]byte, error) {
+	tx, err := parseTx(raw)
+	if err != nil {
+		return nil, err
+	}
+	if inputIndex < 0 || inputIndex >= len(tx.Inputs) {
+		return nil, fmt.Errorf("%w: input %d out of range", ErrInvalidSigHash, inputIndex)
+	}
+
+	base := byte(hashType) & 0x1f
+	anyoneCanPay := byte(hashType)&SigHashAnyoneCanPay != 0
+
+	// SIGHASH_SINGLE without a matching output signs the number one, a consensus bug kept for compatibility
+	if base == SigHashSingle && inputIndex >= len(tx.Outputs) {
+		one := make([]byte, 32)
+		one[0] = 1
+		return one, nil
+	}
+
+	var buf bytes.Buffer
+	writeLE(&buf, uint32(tx.Version))
+
+	inputs := tx.Inputs
+	if anyoneCanPay {
+		inputs = tx.Inputs[inputIndex : inputIndex+1]
+		buf.Write(cryptolib.VarInt(1))
+	} else {
+		buf.Write(cryptolib.VarInt(uint64(len(inputs))))
+	}
+
+	script := removeCodeSeparators(subscript)
+	for i := range inputs {
+		in := &inputs[i]
+		signing := anyoneCanPay || i == inputIndex
+
+		buf.Write(outpointBytes(in))
+		if signing {
+			buf.Write(cryptolib.VarInt(uint64(len(script))))
+			buf.Write(script)
+		} else {
+			buf.WriteByte(0)
+		}
+
+		// Other inputs can be updated by their owners under NONE and SINGLE
+		if !signing && (base == SigHashNone || base == SigHashSingle) {
+			writeLE(&buf, uint32(0))
+		} else {
+			writeLE(&buf, in.Sequence)
+		}
+	}
+
+	switch base {
+	case SigHashNone:
+		buf.Write(cryptolib.VarInt(0))
+	case SigHashSingle:
+		buf.Write(cryptolib.VarInt(uint64(inputIndex + 1)))
+		for i := 0; i < inputIndex; i++ {
+			writeLE(&buf, ^uint64(0))
+			buf.WriteByte(0)
+		}
+		writeOutput(&buf, &tx.Outputs[inputIndex])
+	default:
+		buf.Write(cryptolib.VarInt(uint64(len(tx.Outputs))))
+		for i := range tx.Outputs {
+			writeOutput(&buf, &tx.Outputs[i])
+		}
+	}
+
+	writeLE(&buf, tx.LockTime)
+	writeLE(&buf, hashType)
+
+	return cryptolib.Sha256d(buf.Bytes()), nil
+}
+
+// WitnessV0SigHash returns the hash signed by an input spending a segwit version 0 output, as defined by
+// BIP 143. scriptCode is the script being executed: for P2WPKH the P2PKH script of the key hash, for P2WSH
+// the witness script. amount is the value of the spent output in satoshis.
+func WitnessV0SigHash(raw []byte, inputIndex int, scriptCode []byte, amount uint64, hashType uint32) ([]byte, error) {
+	tx, err := parseTx(raw)
+	if err != nil {
+		return nil, err
+	}
+	if inputIndex < 0 || inputIndex >= len(tx.Inputs) {
+		return nil, fmt.Errorf("%w: input %d out of range", ErrInvalidSigHash, inputIndex)
+	}
+
+	base := byte(hashType) & 0x1f
+	anyoneCanPay := byte(hashType)&SigHashAnyoneCanPay != 0
+
+	zero := make([]byte, 32)
+	hashPrevouts, hashSequence, hashOutputs := zero, zero, zero
+
+	if !anyoneCanPay {
+		var prevouts bytes.Buffer
+		for i := range tx.Inputs {
+			prevouts.Write(outpointBytes(&tx.Inputs[i]))
+		}
+		hashPrevouts = cryptolib.Sha256d(prevouts.Bytes())
+	}
+
+	if !anyoneCanPay && base != SigHashSingle && base != SigHashNone {
+		var sequences bytes.Buffer
+		for _, in := range tx.Inputs {
+			writeLE(&sequences, in.Sequence)
+		}
+		hashSequence = cryptolib.Sha256d(sequences.Bytes())
+	}
+
+	switch {
+	case base != SigHashSingle && base != SigHashNone:
+		var outputs bytes.Buffer
+		for i := range tx.Outputs {
+			writeOutput(&outputs, &tx.Outputs[i])
+		}
+		hashOutputs = cryptolib.Sha256d(outputs.Bytes())
+	case base == SigHashSingle && inputIndex < len(tx.Outputs):
+		var output bytes.Buffer
+		writeOutput(&output, &tx.Outputs[inputIndex])
+		hashOutputs = cryptolib.Sha256d(output.Bytes())
+	}
+
+	in := &tx.Inputs[inputIndex]
+
+	var buf bytes.Buffer
+	writeLE(&buf, uint32(tx.Version))
+	buf.Write(hashPrevouts)
+	buf.Write(hashSequence)
+	buf.Write(outpointBytes(in))
+	buf.Write(cryptolib.VarInt(uint64(len(scriptCode))))
+	buf.Write(scriptCode)
+	writeLE(&buf, amount)
+	writeLE(&buf, in.Sequence)
+	buf.Write(hashOutputs)
+	writeLE(&buf, tx.LockTime)
+	writeLE(&buf, hashType)
+
+	return cryptolib.Sha256d(buf.Bytes()), nil
+}
+
+// TaprootSigHash returns the hash signed by an input spending a segwit version 1 output, as defined by
+// BIP 341 and, for script path spends, BIP 342. prevOuts are the outputs spent by every input of the
+// transaction, in order. leafHash is the tapleaf hash of the executed script for script path spends and
+// nil for key path spends. An annex is taken from the input's witness if it carries one.
+func TaprootSigHash(raw []byte, inputIndex int, prevOuts []PrevOut, hashType byte, leafHash []byte) ([]byte, error) {
+	tx, err := parseTx(raw)
+	if err != nil {
+		return nil, err
+	}
+	if inputIndex < 0 || inputIndex >= len(tx.Inputs) {
+		return nil, fmt.Errorf("%w: input %d out of range", ErrInvalidSigHash, inputIndex)
+	}
+	if len(prevOuts) != len(tx.Inputs) {
+		return nil, fmt.Errorf("%w: %d spent outputs for %d inputs", ErrInvalidSigHash, len(prevOuts), len(tx.Inputs))
+	}
+	if !(hashType <= SigHashSingle || (hashType >= 0x81 && hashType <= 0x83)) {
+		return nil, fmt.Errorf("%w: hash type 0x%02x", ErrInvalidSigHash, hashType)
+	}
+	if leafHash != nil && len(leafHash) != 32 {
+		return nil, fmt.Errorf("%w: leaf hash of %d bytes", ErrInvalidSigHash, len(leafHash))
+	}
+
+	base := hashType & 0x03
+	anyoneCanPay := hashType&SigHashAnyoneCanPay != 0
+
+	if base == SigHashSingle && inputIndex >= len(tx.Outputs) {
+		return nil, fmt.Errorf("%w: SIGHASH_SINGLE without output %d", ErrInvalidSigHash, inputIndex)
+	}
+
+	var msg bytes.Buffer
+	msg.WriteByte(0x00) // epoch
+	msg.WriteByte(hashType)
+	writeLE(&msg, uint32(tx.Version))
+	writeLE(&msg, tx.LockTime)
+
+	if !anyoneCanPay {
+		var prevouts, amounts, scriptPubKeys, sequences bytes.Buffer
+		for i := range tx.Inputs {
+			prevouts.Write(outpointBytes(&tx.Inputs[i]))
+			writeLE(&amounts, prevOuts[i].Value)
+			scriptPubKeys.Write(cryptolib.VarInt(uint64(len(prevOuts[i].ScriptPubKey))))
+			scriptPubKeys.Write(prevOuts[i].ScriptPubKey)
+			writeLE(&sequences, tx.Inputs[i].Sequence)
+		}
+		msg.Write(sha256Bytes(prevouts.Bytes()))
+		msg.Write(sha256Bytes(amounts.Bytes()))
+		msg.Write(sha256Bytes(scriptPubKeys.Bytes()))
+		msg.Write(sha256Bytes(sequences.Bytes()))
+	}
+
+	if base != SigHashNone && base != SigHashSingle {
+		var outputs bytes.Buffer
+		for i := range tx.Outputs {
+			writeOutput(&outputs, &tx.Outputs[i])
+		}
+		msg.Write(sha256Bytes(outputs.Bytes()))
+	}
+
+	in := &tx.Inputs[inputIndex]
+
+	// An annex is the last of at least two witness items when it starts with 0x50
+	var annex []byte
+	if n := len(in.Witness); n >= 2 && len(in.Witness[n-1]) > 0 && in.Witness[n-1][0] == 0x50 {
+		annex = in.Witness[n-1]
+	}
+
+	spendType := byte(0)
+	if leafHash != nil {
+		spendType |= 2
+	}
+	if annex != nil {
+		spendType |= 1
+	}
+	msg.WriteByte(spendType)
+
+	if anyoneCanPay {
+		msg.Write(outpointBytes(in))
+		writeLE(&msg, prevOuts[inputIndex].Value)
+		msg.Write(cryptolib.VarInt(uint64(len(prevOuts[inputIndex].ScriptPubKey))))
+		msg.Write(prevOuts[inputIndex].ScriptPubKey)
+		writeLE(&msg, in.Sequence)
+	} else {
+		writeLE(&msg, uint32(inputIndex))
+	}
+
+	if annex != nil {
+		msg.Write(sha256Bytes(append(cryptolib.VarInt(uint64(len(annex))), annex...)))
+	}
+
+	if base == SigHashSingle {
+		var output bytes.Buffer
+		writeOutput(&output, &tx.Outputs[inputIndex])
+		msg.Write(sha256Bytes(output.Bytes()))
+	}
+
+	if leafHash != nil {
+		msg.Write(leafHash)
+		msg.WriteByte(0x00)               // key version
+		writeLE(&msg, uint32(0xffffffff)) // no OP_CODESEPARATOR executed
+	}
+
+	return TaggedHash("TapSighash", msg.Bytes()), nil
+}
+
+// TaggedHash returns the BIP 340 tagged hash SHA256(SHA256(tag) || SHA256(tag) || msg).
+func TaggedHash(tag string, msg []byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	h.Write(msg)
+
+	return h.Sum(nil)
+}
+
+func sha256Bytes(b []byte) []byte {
+	h := sha256.Sum256(b)
+	return h[:]
+}
+
+func writeLE(buf *bytes.Buffer, v interface{}) {
+	_ = binary.Write(buf, binary.LittleEndian, v)
+}
+
+func outpointBytes(in *parsedInput) []byte {
+	txid, _ := hex.DecodeString(in.PrevTxID)
+	b := cryptolib.ReverseBytes(txid)
+	return append(b, cryptolib.GetLittleEndianBytes(in.PrevIndex, 4)...)
+}
+
+func writeOutput(buf *bytes.Buffer, out *parsedOutput) {
+	writeLE(buf, out.Value)
+	buf.Write(cryptolib.VarInt(uint64(len(out.ScriptPubKey))))
+	buf.Write(out.ScriptPubKey)
+}
+
+// removeCodeSeparators returns script without its OP_CODESEPARATOR opcodes, leaving pushed data alone.
+func removeCodeSeparators(script []byte) []byte {
+	out := make([]byte, 0, len(script))
+
+	for i := 0; i < len(script); {
+		op := script[i]
+		size := 1
+
+		switch {
+		case op >= 0x01 && op <= 0x4b:
+			size += int(op)
+		case op == 0x4c && i+1 < len(script):
+			size += 1 + int(script[i+1])
+		case op == 0x4d && i+2 < len(script):
+			size += 2 + int(binary.LittleEndian.Uint16(script[i+1:]))
+		case op == 0x4e && i+4 < len(script):
+			size += 4 + int(binary.LittleEndian.Uint32(script[i+1:]))
+		}
+
+		if i+size > len(script) {
+			size = len(script) - i
+		}
+
+		if op != 0xab {
+			out = append(out, script[i:i+size]...)
+		}
+		i += size
+	}
+
+	return out
+}
@@ -0,0 +1,183 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeTxIDs(t *testing.T) {
+	raw, _ := hex.DecodeString(testTimeLockedTx(2, 0, SequenceFinal))
+
+	txid, err := ComputeTxID(raw)
+	require.NoError(t, err)
+	assert.Equal(t, hashString(raw), txid)
+
+	wtxid, err := ComputeWTxID(raw)
+	require.NoError(t, err)
+	assert.Equal(t, txid, wtxid)
+
+	_, err = ComputeTxID(raw[:10])
+	assert.Error(t, err)
+}
+
+// BIP 143, native P2WPKH example
+func TestWitnessV0SigHash(t *testing.T) {
+	raw, _ := hex.DecodeString("0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000")
+	scriptCode, _ := hex.DecodeString("76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac")
+
+	hash, err := WitnessV0SigHash(raw, 1, scriptCode, 600000000, uint32(SigHashAll))
+	require.NoError(t, err)
+	assert.Equal(t, "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670", hex.EncodeToString(hash))
+
+	_, err = WitnessV0SigHash(raw, 2, scriptCode, 600000000, uint32(SigHashAll))
+	assert.True(t, errors.Is(err, ErrInvalidSigHash))
+}
+
+func TestLegacySigHash(t *testing.T) {
+	txHex := testTimeLockedTx(1, 0, 1, 2, 3)
+	raw, _ := hex.DecodeString(txHex)
+	subscript, _ := hex.DecodeString("76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac")
+
+	// expected builds the preimage the way the original client did: a copy of the transaction with the
+	// subscript as the scriptSig of the signed input
+	expected := func(modify func(tx *transaction), hashType byte) string {
+		tx, _ := TransactionFromHex(txHex)
+		for i := range tx.Inputs {
+			tx.Inputs[i].unlockingScript = nil
+		}
+		tx.Inputs[1].unlockingScript = subscript
+		modify(tx)
+		return hex.EncodeToString(cryptolib.Sha256d(append(tx.ToHex(), hashType, 0, 0, 0)))
+	}
+
+	hash, err := LegacySigHash(raw, 1, subscript, uint32(SigHashAll))
+	require.NoError(t, err)
+	assert.Equal(t, expected(func(*transaction) {}, SigHashAll), hex.EncodeToString(hash))
+
+	hash, err = LegacySigHash(raw, 1, subscript, uint32(SigHashNone))
+	require.NoError(t, err)
+	assert.Equal(t, expected(func(tx *transaction) {
+		tx.Outputs = nil
+		tx.Inputs[0].sequence = 0
+		tx.Inputs[2].sequence = 0
+	}, SigHashNone), hex.EncodeToString(hash))
+
+	hash, err = LegacySigHash(raw, 1, subscript, uint32(SigHashAll|SigHashAnyoneCanPay))
+	require.NoError(t, err)
+	assert.Equal(t, expected(func(tx *transaction) {
+		tx.Inputs = tx.Inputs[1:2]
+	}, SigHashAll|SigHashAnyoneCanPay), hex.EncodeToString(hash))
+
+	// OP_CODESEPARATORs are not signed
+	withSeparator := append([]byte{0xab}, subscript...)
+	hash, err = LegacySigHash(raw, 1, withSeparator, uint32(SigHashAll))
+	require.NoError(t, err)
+	assert.Equal(t, expected(func(*transaction) {}, SigHashAll), hex.EncodeToString(hash))
+
+	// The transaction has a single output
+	hash, err = LegacySigHash(raw, 1, subscript, uint32(SigHashSingle))
+	require.NoError(t, err)
+	assert.Equal(t, "0100000000000000000000000000000000000000000000000000000000000000", hex.EncodeToString(hash))
+}
+
+func TestRemoveCodeSeparators(t *testing.T) {
+	// OP_CODESEPARATOR, a push of two 0xab bytes, OP_CODESEPARATOR, OP_PUSHDATA1 of one 0xab byte
+	script := []byte{0xab, 0x02, 0xab, 0xab, 0xab, 0x4c, 0x01, 0xab}
+	assert.Equal(t, []byte{0x02, 0xab, 0xab, 0x4c, 0x01, 0xab}, removeCodeSeparators(script))
+
+	// Truncated pushes are kept as they are
+	assert.Equal(t, []byte{0x05, 0xab}, removeCodeSeparators([]byte{0x05, 0xab}))
+}
+
+func TestTaprootSigHash(t *testing.T) {
+	raw, _ := hex.DecodeString(testTimeLockedTx(2, 0, 1, 2))
+	prevOuts := []PrevOut{
+		{Value: 1000, ScriptPubKey: append([]byte{0x51, 0x20}, make([]byte, 32)...)},
+		{Value: 2000, ScriptPubKey: append([]byte{0x51, 0x20}, make([]byte, 32)...)},
+	}
+
+	seen := make(map[string]bool)
+	for _, hashType := range []byte{SigHashDefault, SigHashAll, SigHashNone, SigHashSingle, 0x81, 0x82, 0x83} {
+		hash, err := TaprootSigHash(raw, 0, prevOuts, hashType, nil)
+		require.NoError(t, err)
+		require.Len(t, hash, 32)
+		seen[hex.EncodeToString(hash)] = true
+	}
+	assert.Len(t, seen, 7, "every hash type commits to different data")
+
+	keyPath, _ := TaprootSigHash(raw, 0, prevOuts, SigHashDefault, nil)
+	scriptPath, err := TaprootSigHash(raw, 0, prevOuts, SigHashDefault, make([]byte, 32))
+	require.NoError(t, err)
+	assert.NotEqual(t, keyPath, scriptPath)
+
+	// Amounts of all inputs are committed to
+	prevOuts[1].Value++
+	changed, _ := TaprootSigHash(raw, 0, prevOuts, SigHashDefault, nil)
+	assert.NotEqual(t, keyPath, changed)
+	anyoneCanPay, _ := TaprootSigHash(raw, 0, prevOuts, 0x81, nil)
+	prevOuts[1].Value--
+	unchanged, _ := TaprootSigHash(raw, 0, prevOuts, 0x81, nil)
+	assert.Equal(t, unchanged, anyoneCanPay)
+
+	for _, tc := range []struct {
+		index    int
+		prevOuts []PrevOut
+		hashType byte
+	}{
+		{0, prevOuts, 0x04},
+		{0, prevOuts, 0x80},
+		{0, prevOuts[:1], SigHashDefault},
+		{1, prevOuts, SigHashSingle},
+		{2, prevOuts, SigHashDefault},
+	} {
+		_, err := TaprootSigHash(raw, tc.index, tc.prevOuts, tc.hashType, nil)
+		assert.True(t, errors.Is(err, ErrInvalidSigHash), "%+v: %v", tc, err)
+	}
+}
+
+// BIP 341 wallet-test-vectors.json, keyPathSpending
+func TestTaprootSigHashVectors(t *testing.T) {
+	raw, _ := hex.DecodeString("02000000097de20cbff686da83a54981d2b9bab3586f4ca7e48f57f5b55963115f3b334e9c010000000000000000d7b7cab57b1393ace2d064f4d4a2cb8af6def61273e127517d44759b6dafdd990000000000fffffffff8e1f583384333689228c5d28eac13366be082dc57441760d957275419a418420000000000fffffffff0689180aa63b30cb162a73c6d2a38b7eeda2a83ece74310fda0843ad604853b0100000000feffffffaa5202bdf6d8ccd2ee0f0202afbbb7461d9264a25e5bfd3c5a52ee1239e0ba6c0000000000feffffff956149bdc66faa968eb2be2d2faa29718acbfe3941215893a2a3446d32acd050000000000000000000e664b9773b88c09c32cb70a2a3e4da0ced63b7ba3b22f848531bbb1d5d5f4c94010000000000000000e9aa6b8e6c9de67619e6a3924ae25696bb7b694bb677a632a74ef7eadfd4eabf0000000000ffffffffa778eb6a263dc090464cd125c466b5a99667720b1c110468831d058aa1b82af10100000000ffffffff0200ca9a3b000000001976a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac807840cb0000000020ac9a87f5594be208f8532db38cff670c450ed2fea8fcdefcc9a663f78bab962b0065cd1d")
+
+	var prevOuts []PrevOut
+	for _, u := range []struct {
+		scriptPubKey string
+		amount       uint64
+	}{
+		{"512053a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343", 420000000},
+		{"5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3", 462000000},
+		{"76a914751e76e8199196d454941c45d1b3a323f1433bd688ac", 294000000},
+		{"5120e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e", 504000000},
+		{"512091b64d5324723a985170e4dc5a0f84c041804f2cd12660fa5dec09fc21783605", 630000000},
+		{"00147dd65592d0ab2fe0d0257d571abf032cd9db93dc", 378000000},
+		{"512075169f4001aa68f15bbed28b218df1d0a62cbbcf1188c6665110c293c907b831", 672000000},
+		{"5120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5", 546000000},
+		{"512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220", 588000000},
+	} {
+		script, _ := hex.DecodeString(u.scriptPubKey)
+		prevOuts = append(prevOuts, PrevOut{Value: u.amount, ScriptPubKey: script})
+	}
+
+	for _, tc := range []struct {
+		index    int
+		hashType byte
+		sigHash  string
+	}{
+		{0, 0x03, "2514a6272f85cfa0f45eb907fcb0d121b808ed37c6ea160a5a9046ed5526d555"},
+		{1, 0x83, "325a644af47e8a5a2591cda0ab0723978537318f10e6a63d4eed783b96a71a4d"},
+		{3, 0x01, "bf013ea93474aa67815b1b6cc441d23b64fa310911d991e713cd34c7f5d46669"},
+		{4, 0x00, "4f900a0bae3f1446fd48490c2958b5a023228f01661cda3496a11da502a7f7ef"},
+		{6, 0x02, "15f25c298eb5cdc7eb1d638dd2d45c97c4c59dcaec6679cfc16ad84f30876b85"},
+		{7, 0x82, "cd292de50313804dabe4685e83f923d2969577191a3e1d2882220dca88cbeb10"},
+		{8, 0x81, "cccb739eca6c13a8a89e6e5cd317ffe55669bbda23f2fd37b0f18755e008edd2"},
+	} {
+		hash, err := TaprootSigHash(raw, tc.index, prevOuts, tc.hashType, nil)
+		require.NoError(t, err)
+		assert.Equal(t, tc.sigHash, hex.EncodeToString(hash), "input %d, hash type %#x", tc.index, tc.hashType)
+	}
+}
@@ -0,0 +1,235 @@
+package bitcoin
+
+import (
+	"strings"
+	"sync"
+)
+
+// zeroHash is the previous block hash of the genesis block.
+var zeroHash = strings.Repeat("0", 64)
+
+// TxLocation is the position of a transaction within a block.
+type TxLocation struct {
+	BlockHash string `json:"blockhash"`
+	Position  int    `json:"position"`
+}
+
+// TxIndex is a lightweight in-memory txid -> block index built from the block stream. It allows
+// GetRawTransaction to work against nodes running without -txindex (or pruned nodes) for any
+// transaction in a block that is still retained by the node.
+//
+// TxIndex implements Checkpointer: its cursor is the last block added at the tip of the index. After a
+// restart the blocks following the restored cursor can be indexed with Backfill before following new ones.
+type TxIndex struct {
+	mu        sync.RWMutex
+	maxBlocks int
+	txs       map[string]TxLocation
+	blocks    map[string]*indexedBlock
+	order     []string // blockhashes, oldest first
+	cursor    Cursor
+}
+
+type indexedBlock struct {
+	txids    []string
+	height   int64 // -1 if unknown
+	prevHash string
+}
+
+// NewTxIndex returns an empty index keeping at most maxBlocks blocks. When more blocks are added the oldest
+// ones are dropped, which should be set to match the node's retained range. A maxBlocks of 0 means unlimited.
+func NewTxIndex(maxBlocks int) *TxIndex {
+	return &TxIndex{
+		maxBlocks: maxBlocks,
+		txs:       make(map[string]TxLocation),
+		blocks:    make(map[string]*indexedBlock),
+	}
+}
+
+// AddBlock parses a serialized block and indexes all of its transactions.
+func (idx *TxIndex) AddBlock(raw []byte) error {
+	block, err := parseBlock(raw)
+	if err != nil {
+		return err
+	}
+
+	txids := make([]string, len(block.Txs))
+	for i, tx := range block.Txs {
+		txids[i] = tx.TxID
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, found := idx.blocks[block.Hash]; found {
+		return nil
+	}
+
+	height := idx.blockHeight(block)
+
+	for i, txid := range txids {
+		idx.txs[txid] = TxLocation{BlockHash: block.Hash, Position: i}
+	}
+	idx.blocks[block.Hash] = &indexedBlock{txids: txids, height: height, prevHash: block.PrevHash}
+	idx.order = append(idx.order, block.Hash)
+
+	// Blocks of the tip or a competing branch at the same height move the cursor, older ones do not
+	if height >= 0 && (idx.cursor.Hash == "" || uint64(height) >= idx.cursor.Height) {
+		idx.cursor = Cursor{Height: uint64(height), Hash: block.Hash}
+	}
+
+	for idx.maxBlocks > 0 && len(idx.order) > idx.maxBlocks {
+		idx.removeBlock(idx.order[0])
+	}
+
+	return nil
+}
+
+// blockHeight returns the height of block: one above its parent if the parent is the cursor or indexed,
+// otherwise the BIP34 height in its coinbase, or -1 if there is none.
+func (idx *TxIndex) blockHeight(block *parsedBlock) int64 {
+	if block.PrevHash == zeroHash {
+		return 0
+	}
+	if idx.cursor.Hash != "" && block.PrevHash == idx.cursor.Hash {
+		return int64(idx.cursor.Height) + 1
+	}
+	if prev, found := idx.blocks[block.PrevHash]; found && prev.height >= 0 {
+		return prev.height + 1
+	}
+
+	if len(block.Txs) > 0 && len(block.Txs[0].Inputs) > 0 {
+		if height, n := coinbaseHeight(block.Txs[0].Inputs[0].ScriptSig); n > 0 {
+			return height
+		}
+	}
+
+	return -1
+}
+
+// RemoveBlock drops all transactions of the given block from the index, for example after a reorg. If it is
+// the block of the cursor, the cursor moves back to its parent.
+func (idx *TxIndex) RemoveBlock(blockHash string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if block, found := idx.blocks[blockHash]; found && blockHash == idx.cursor.Hash {
+		idx.cursor = Cursor{}
+		if block.height > 0 {
+			idx.cursor = Cursor{Height: uint64(block.height) - 1, Hash: block.prevHash}
+		}
+	}
+
+	idx.removeBlock(blockHash)
+}
+
+// Cursor returns the last block added at the tip of the index.
+func (idx *TxIndex) Cursor() Cursor {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.cursor
+}
+
+// SetCursor rewinds the index to the given block, dropping the blocks above it and those competing with it
+// at its height, e.g. blocks reorged out while the application was down as found by Restore.
+func (idx *TxIndex) SetCursor(c Cursor) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for hash, block := range idx.blocks {
+		if block.height > int64(c.Height) || (block.height == int64(c.Height) && hash != c.Hash) {
+			idx.removeBlock(hash)
+		}
+	}
+	idx.cursor = c
+
+	return nil
+}
+
+func (idx *TxIndex) removeBlock(blockHash string) {
+	block, found := idx.blocks[blockHash]
+	if !found {
+		return
+	}
+
+	for _, txid := range block.txids {
+		// The same txid may have been re-mined in a later block
+		if loc := idx.txs[txid]; loc.BlockHash == blockHash {
+			delete(idx.txs, txid)
+		}
+	}
+	delete(idx.blocks, blockHash)
+
+	for i, h := range idx.order {
+		if h == blockHash {
+			idx.order = append(idx.order[:i], idx.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Lookup returns the location of the given transaction if it is indexed.
+func (idx *TxIndex) Lookup(txid string) (TxLocation, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	loc, found := idx.txs[txid]
+	return loc, found
+}
+
+// Len returns the number of indexed transactions.
+func (idx *TxIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.txs)
+}
+
+// Follow subscribes to the rawblock topic of the given ZMQ connection and indexes every block received.
+func (idx *TxIndex) Follow(zmq *ZMQ) error {
+	ch := make(chan ZMQMessage, 10)
+
+	go func() {
+		for msg := range ch {
+			if err := idx.AddBlock(msg.Body); err != nil {
+				zmq.logger.Errorf("TxIndex: could not index block: %v", err)
+			}
+		}
+	}()
+
+	return zmq.SubscribeRaw("rawblock", ch)
+}
+
+// IndexBlock fetches the block with the given hash from the node and adds it to the index.
+func (b *Bitcoind) IndexBlock(idx *TxIndex, blockHash string) error {
+	raw, err := b.GetRawBlock(blockHash)
+	if err != nil {
+		return err
+	}
+
+	return idx.AddBlock(raw)
+}
+
+// UseTxIndex makes GetRawTransaction and GetRawTransactionHex consult the given index and pass the
+// containing blockhash to the node, which is required when the node is running without -txindex.
+func (b *Bitcoind) UseTxIndex(idx *TxIndex) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.txIndex = idx
+}
+
+// rawTransactionParams returns the getrawtransaction params, adding the blockhash when the transaction is indexed.
+func (b *Bitcoind) rawTransactionParams(txID string, verbose int) []interface{} {
+	b.mu.RLock()
+	idx := b.txIndex
+	b.mu.RUnlock()
+
+	if idx != nil {
+		if loc, found := idx.Lookup(txID); found {
+			return []interface{}{txID, verbose, loc.BlockHash}
+		}
+	}
+
+	return []interface{}{txID, verbose}
+}
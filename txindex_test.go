@@ -0,0 +1,83 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const genesisBlockHex = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c0101000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+func TestTxIndexGenesis(t *testing.T) {
+	raw, _ := hex.DecodeString(genesisBlockHex)
+
+	idx := NewTxIndex(1)
+	if err := idx.AddBlock(raw); err != nil {
+		t.Fatal(err)
+	}
+
+	loc, found := idx.Lookup("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b")
+	if !found {
+		t.Fatal("Expected genesis coinbase to be indexed")
+	}
+
+	expected := "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	if loc.BlockHash != expected || loc.Position != 0 {
+		t.Errorf("Expected %s:0, got %s:%d", expected, loc.BlockHash, loc.Position)
+	}
+
+	idx.RemoveBlock(expected)
+	if idx.Len() != 0 {
+		t.Errorf("Expected empty index, got %d entries", idx.Len())
+	}
+}
+
+func TestTxIndexTruncatedBlock(t *testing.T) {
+	raw, _ := hex.DecodeString(genesisBlockHex)
+
+	if err := NewTxIndex(0).AddBlock(raw[:len(raw)-10]); err == nil {
+		t.Error("Expected error for truncated block")
+	}
+}
+
+func TestTxIndexCursor(t *testing.T) {
+	coinbase := Outpoint{TxID: zeroHash, Vout: 0xffffffff}
+
+	var hashes []string
+	block := func(prev string, nonce uint32) []byte {
+		raw := testBlock(prev, nonce, testTx([]Outpoint{coinbase}, "51", uint64(nonce)))
+		parsed, err := parseBlock(raw)
+		require.NoError(t, err)
+		hashes = append(hashes, parsed.Hash)
+		return raw
+	}
+
+	idx := NewTxIndex(0)
+	assert.Equal(t, Cursor{}, idx.Cursor())
+
+	b0 := block(zeroHash, 0)
+	b1 := block(hashes[0], 1)
+	b2 := block(hashes[1], 2)
+	b2competing := block(hashes[1], 3)
+	for _, raw := range [][]byte{b0, b1, b2} {
+		require.NoError(t, idx.AddBlock(raw))
+	}
+	assert.Equal(t, Cursor{Height: 2, Hash: hashes[2]}, idx.Cursor())
+
+	// A competing block at the tip height moves the cursor, re-adding an older one does not
+	require.NoError(t, idx.AddBlock(b2competing))
+	assert.Equal(t, Cursor{Height: 2, Hash: hashes[3]}, idx.Cursor())
+	idx.RemoveBlock(hashes[0])
+	require.NoError(t, idx.AddBlock(b0))
+	assert.Equal(t, Cursor{Height: 2, Hash: hashes[3]}, idx.Cursor())
+
+	// Rewinding drops both blocks at height 2
+	require.NoError(t, idx.SetCursor(Cursor{Height: 1, Hash: hashes[1]}))
+	assert.Equal(t, 2, idx.Len())
+
+	// Removing the block of the cursor moves it to the parent
+	idx.RemoveBlock(hashes[1])
+	assert.Equal(t, Cursor{Height: 0, Hash: hashes[0]}, idx.Cursor())
+}
@@ -0,0 +1,387 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WatchedUTXO is an unspent output paying to a script watched by a UTXOMirror.
+type WatchedUTXO struct {
+	Outpoint
+	ScriptPubKey string // hex
+	Value        uint64 // satoshis
+	BlockHash    string // empty while unconfirmed
+}
+
+// ScriptBalance is the balance of a watched script in satoshis.
+type ScriptBalance struct {
+	Confirmed   uint64
+	Unconfirmed int64 // net effect of mempool transactions, negative when they spend confirmed outputs
+}
+
+type utxoBlockUndo struct {
+	hash    string
+	prev    string
+	created []Outpoint
+	spent   []*WatchedUTXO
+}
+
+type utxoMempoolTx struct {
+	created []Outpoint
+	spent   []Outpoint
+}
+
+// UTXOMirror maintains the unspent outputs of a set of watched scripts by applying connected blocks and
+// mempool transactions, answering balance and UTXO queries without wallet imports or rescans. Blocks must be
+// applied in chain order; reorgs up to the configured depth are handled by undoing the disconnected blocks.
+type UTXOMirror struct {
+	mu           sync.RWMutex
+	scripts      map[string]bool
+	used         map[string]bool // watched scripts an output was seen paying to
+	confirmed    map[Outpoint]*WatchedUTXO
+	mempool      map[Outpoint]*WatchedUTXO
+	mempoolSpent map[Outpoint]string // outpoint -> txid of the mempool transaction spending it
+	mempoolTxs   map[string]*utxoMempoolTx
+	undo         []*utxoBlockUndo
+	maxUndo      int
+	tip          string
+}
+
+// NewUTXOMirror returns an empty mirror able to undo reorgs of up to maxReorgDepth blocks.
+func NewUTXOMirror(maxReorgDepth int) *UTXOMirror {
+	return &UTXOMirror{
+		scripts:      make(map[string]bool),
+		used:         make(map[string]bool),
+		confirmed:    make(map[Outpoint]*WatchedUTXO),
+		mempool:      make(map[Outpoint]*WatchedUTXO),
+		mempoolSpent: make(map[Outpoint]string),
+		mempoolTxs:   make(map[string]*utxoMempoolTx),
+		maxUndo:      maxReorgDepth,
+	}
+}
+
+// Watch adds a hex encoded scriptPubKey to the watched set. Only outputs created after the call are picked
+// up; use Load to include existing outputs.
+func (m *UTXOMirror) Watch(scriptPubKey string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.scripts[strings.ToLower(scriptPubKey)] = true
+}
+
+// Watches reports whether a hex encoded scriptPubKey is in the watched set.
+func (m *UTXOMirror) Watches(scriptPubKey string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.scripts[strings.ToLower(scriptPubKey)]
+}
+
+// Used reports whether an output paying to a watched script was seen since it was watched, even if the
+// output was spent or its transaction left the mempool since.
+func (m *UTXOMirror) Used(scriptPubKey string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.used[strings.ToLower(scriptPubKey)]
+}
+
+// Load replaces the confirmed outputs with the current UTXO set of the watched scripts, using scantxoutset.
+// The mirror's tip is set to the block the scan was taken at.
+func (m *UTXOMirror) Load(ctx context.Context, b *Bitcoind) error {
+	m.mu.RLock()
+	descriptors := make([]string, 0, len(m.scripts))
+	for script := range m.scripts {
+		descriptors = append(descriptors, "raw("+script+")")
+	}
+	m.mu.RUnlock()
+
+	res, err := b.ScanTxOutSet(ctx, descriptors)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.confirmed = make(map[Outpoint]*WatchedUTXO, len(res.Unspents))
+	for _, u := range res.Unspents {
+		op := Outpoint{TxID: u.TxID, Vout: u.Vout}
+		m.used[strings.ToLower(u.ScriptPubKey)] = true
+		m.confirmed[op] = &WatchedUTXO{
+			Outpoint:     op,
+			ScriptPubKey: u.ScriptPubKey,
+			Value:        uint64(math.Round(u.Amount * 1e8)),
+			BlockHash:    u.BlockHash,
+		}
+	}
+
+	m.undo = nil
+	m.tip = res.BestBlock
+
+	return nil
+}
+
+// Follow subscribes to the rawblock and rawtx topics of the given ZMQ connection and applies every block and
+// transaction received.
+func (m *UTXOMirror) Follow(zmq *ZMQ) error {
+	ch := make(chan ZMQMessage, 100)
+
+	go func() {
+		for msg := range ch {
+			var err error
+			if msg.Topic == "rawblock" {
+				err = m.ApplyBlock(msg.Body)
+			} else {
+				err = m.ApplyMempoolTx(msg.Body)
+			}
+
+			if err != nil {
+				zmq.logger.Errorf("UTXOMirror: %v", err)
+			}
+		}
+	}()
+
+	if err := zmq.SubscribeRaw("rawblock", ch); err != nil {
+		return err
+	}
+
+	return zmq.SubscribeRaw("rawtx", ch)
+}
+
+// ApplyBlock applies a connected block. A block that does not build on the current tip undoes blocks until
+// its parent is reached, or fails when the parent is not among the blocks that can be undone.
+func (m *UTXOMirror) ApplyBlock(raw []byte) error {
+	block, err := parseBlock(raw)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if block.Hash == m.tip {
+		return nil
+	}
+
+	if m.tip != "" && block.PrevHash != m.tip && !m.rewind(block.PrevHash) {
+		return fmt.Errorf("block %s does not connect to the mirror, its parent %s is unknown", block.Hash, block.PrevHash)
+	}
+
+	u := &utxoBlockUndo{hash: block.Hash, prev: block.PrevHash}
+
+	for _, tx := range block.Txs {
+		for _, in := range tx.Inputs {
+			op := Outpoint{TxID: in.PrevTxID, Vout: in.PrevIndex}
+
+			if utxo, found := m.confirmed[op]; found {
+				u.spent = append(u.spent, utxo)
+				delete(m.confirmed, op)
+			}
+
+			// A different mempool transaction spending the same output has been double spent
+			if spender, found := m.mempoolSpent[op]; found && spender != tx.TxID {
+				m.removeMempoolTx(spender, true)
+			}
+		}
+
+		// Mined, so mempool transactions spending its outputs stay valid
+		m.removeMempoolTx(tx.TxID, false)
+
+		for i, out := range tx.Outputs {
+			script := hex.EncodeToString(out.ScriptPubKey)
+			if !m.scripts[script] {
+				continue
+			}
+
+			op := Outpoint{TxID: tx.TxID, Vout: uint32(i)}
+			m.used[script] = true
+			m.confirmed[op] = &WatchedUTXO{Outpoint: op, ScriptPubKey: script, Value: out.Value, BlockHash: block.Hash}
+			u.created = append(u.created, op)
+		}
+	}
+
+	m.undo = append(m.undo, u)
+	if len(m.undo) > m.maxUndo {
+		m.undo = m.undo[len(m.undo)-m.maxUndo:]
+	}
+	m.tip = block.Hash
+
+	return nil
+}
+
+// rewind undoes blocks until the given hash is the tip, reporting whether it was reached.
+func (m *UTXOMirror) rewind(hash string) bool {
+	for m.tip != hash && len(m.undo) > 0 {
+		u := m.undo[len(m.undo)-1]
+		m.undo = m.undo[:len(m.undo)-1]
+
+		for _, op := range u.created {
+			delete(m.confirmed, op)
+		}
+		for _, utxo := range u.spent {
+			m.confirmed[utxo.Outpoint] = utxo
+		}
+
+		m.tip = u.prev
+	}
+
+	return m.tip == hash
+}
+
+// ApplyMempoolTx applies a transaction accepted to the mempool.
+func (m *UTXOMirror) ApplyMempoolTx(raw []byte) error {
+	tx, err := parseTx(raw)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.mempoolTxs[tx.TxID]; found {
+		return nil
+	}
+
+	mtx := &utxoMempoolTx{}
+
+	for _, in := range tx.Inputs {
+		op := Outpoint{TxID: in.PrevTxID, Vout: in.PrevIndex}
+		if m.confirmed[op] != nil || m.mempool[op] != nil {
+			m.mempoolSpent[op] = tx.TxID
+			mtx.spent = append(mtx.spent, op)
+		}
+	}
+
+	for i, out := range tx.Outputs {
+		script := hex.EncodeToString(out.ScriptPubKey)
+		op := Outpoint{TxID: tx.TxID, Vout: uint32(i)}
+
+		// rawtx is also published for transactions of connected blocks, which are already confirmed here
+		if !m.scripts[script] || m.confirmed[op] != nil {
+			continue
+		}
+
+		m.used[script] = true
+		m.mempool[op] = &WatchedUTXO{Outpoint: op, ScriptPubKey: script, Value: out.Value}
+		mtx.created = append(mtx.created, op)
+	}
+
+	if len(mtx.spent) > 0 || len(mtx.created) > 0 {
+		m.mempoolTxs[tx.TxID] = mtx
+	}
+
+	return nil
+}
+
+// RemoveMempoolTx drops a transaction that left the mempool without being mined, for example when it was
+// evicted or replaced.
+func (m *UTXOMirror) RemoveMempoolTx(txid string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeMempoolTx(txid, true)
+}
+
+// removeMempoolTx forgets a mempool transaction and, with cascade set, every mempool transaction spending
+// its outputs.
+func (m *UTXOMirror) removeMempoolTx(txid string, cascade bool) {
+	mtx, found := m.mempoolTxs[txid]
+	if !found {
+		return
+	}
+
+	for _, op := range mtx.spent {
+		if m.mempoolSpent[op] == txid {
+			delete(m.mempoolSpent, op)
+		}
+	}
+
+	for _, op := range mtx.created {
+		delete(m.mempool, op)
+
+		if spender, found := m.mempoolSpent[op]; found && cascade {
+			m.removeMempoolTx(spender, true)
+		}
+	}
+
+	delete(m.mempoolTxs, txid)
+}
+
+// Tip returns the hash of the last block applied.
+func (m *UTXOMirror) Tip() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.tip
+}
+
+// Balance returns the balance of a watched script.
+func (m *UTXOMirror) Balance(scriptPubKey string) ScriptBalance {
+	script := strings.ToLower(scriptPubKey)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var bal ScriptBalance
+
+	for _, utxo := range m.confirmed {
+		if utxo.ScriptPubKey == script {
+			bal.Confirmed += utxo.Value
+		}
+	}
+
+	for _, utxo := range m.mempool {
+		if utxo.ScriptPubKey == script {
+			bal.Unconfirmed += int64(utxo.Value)
+		}
+	}
+
+	for op := range m.mempoolSpent {
+		utxo := m.confirmed[op]
+		if utxo == nil {
+			utxo = m.mempool[op]
+		}
+		if utxo != nil && utxo.ScriptPubKey == script {
+			bal.Unconfirmed -= int64(utxo.Value)
+		}
+	}
+
+	return bal
+}
+
+// UTXOs returns the outputs of a watched script that are unspent taking the mempool into account, confirmed
+// ones first.
+func (m *UTXOMirror) UTXOs(scriptPubKey string) []WatchedUTXO {
+	script := strings.ToLower(scriptPubKey)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var utxos []WatchedUTXO
+
+	for _, set := range []map[Outpoint]*WatchedUTXO{m.confirmed, m.mempool} {
+		start := len(utxos)
+
+		for op, utxo := range set {
+			if _, spent := m.mempoolSpent[op]; !spent && utxo.ScriptPubKey == script {
+				utxos = append(utxos, *utxo)
+			}
+		}
+
+		part := utxos[start:]
+		sort.Slice(part, func(i, j int) bool {
+			if part[i].TxID != part[j].TxID {
+				return part[i].TxID < part[j].TxID
+			}
+			return part[i].Vout < part[j].Vout
+		})
+	}
+
+	return utxos
+}
@@ -0,0 +1,120 @@
+package bitcoin
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"bitbucket.org/simon_ordish/cryptolib"
+)
+
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func le64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
+
+// testTx serializes a legacy transaction spending the given outpoints to outputs of the given script and values.
+func testTx(inputs []Outpoint, script string, values ...uint64) []byte {
+	tx := []byte{1, 0, 0, 0, byte(len(inputs))}
+	for _, in := range inputs {
+		h, _ := hex.DecodeString(in.TxID)
+		tx = append(tx, cryptolib.ReverseBytes(h)...)
+		tx = append(tx, le32(in.Vout)...)
+		tx = append(tx, 0, 0xff, 0xff, 0xff, 0xff)
+	}
+
+	s, _ := hex.DecodeString(script)
+	tx = append(tx, byte(len(values)))
+	for _, v := range values {
+		tx = append(tx, le64(v)...)
+		tx = append(tx, byte(len(s)))
+		tx = append(tx, s...)
+	}
+
+	return append(tx, 0, 0, 0, 0)
+}
+
+// testBlock serializes a block on top of prev containing the given transactions. The merkle root is not set.
+func testBlock(prev string, nonce uint32, txs ...[]byte) []byte {
+	p, _ := hex.DecodeString(prev)
+
+	b := []byte{1, 0, 0, 0}
+	b = append(b, cryptolib.ReverseBytes(p)...)
+	b = append(b, make([]byte, 32+8)...)
+	b = append(b, le32(nonce)...)
+	b = append(b, byte(len(txs)))
+	for _, tx := range txs {
+		b = append(b, tx...)
+	}
+
+	return b
+}
+
+func TestUTXOMirror(t *testing.T) {
+	const watched = "0014aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const other = "0014bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	genesis := strings.Repeat("00", 32)
+	coinbase := Outpoint{TxID: strings.Repeat("00", 32), Vout: 0xffffffff}
+
+	m := NewUTXOMirror(10)
+	m.Watch(watched)
+
+	fund := testTx([]Outpoint{coinbase}, watched, 1000, 2000)
+	fundTx, _ := parseTx(fund)
+
+	block1 := testBlock(genesis, 1, fund)
+	if err := m.ApplyBlock(block1); err != nil {
+		t.Fatal(err)
+	}
+	hash1, _ := parseBlockHeader(block1)
+
+	if bal := m.Balance(watched); bal.Confirmed != 3000 || bal.Unconfirmed != 0 {
+		t.Fatalf("unexpected balance after block 1: %+v", bal)
+	}
+
+	// Spend the first output in the mempool to another script
+	spend := testTx([]Outpoint{{TxID: fundTx.TxID, Vout: 0}}, other, 900)
+	if err := m.ApplyMempoolTx(spend); err != nil {
+		t.Fatal(err)
+	}
+
+	if bal := m.Balance(watched); bal.Confirmed != 3000 || bal.Unconfirmed != -1000 {
+		t.Fatalf("unexpected balance with mempool spend: %+v", bal)
+	}
+
+	if utxos := m.UTXOs(watched); len(utxos) != 1 || utxos[0].Vout != 1 {
+		t.Fatalf("unexpected utxos %+v", utxos)
+	}
+
+	// Mine the spend
+	block2 := testBlock(hash1.Hash, 2, testTx([]Outpoint{coinbase}, other, 5000), spend)
+	if err := m.ApplyBlock(block2); err != nil {
+		t.Fatal(err)
+	}
+
+	if bal := m.Balance(watched); bal.Confirmed != 2000 || bal.Unconfirmed != 0 {
+		t.Fatalf("unexpected balance after block 2: %+v", bal)
+	}
+
+	// A competing block 2 without the spend reorgs it out again
+	block2b := testBlock(hash1.Hash, 3, testTx([]Outpoint{coinbase}, other, 6000))
+	if err := m.ApplyBlock(block2b); err != nil {
+		t.Fatal(err)
+	}
+
+	if bal := m.Balance(watched); bal.Confirmed != 3000 {
+		t.Fatalf("unexpected balance after reorg: %+v", bal)
+	}
+
+	if err := m.ApplyBlock(testBlock(strings.Repeat("11", 32), 4)); err == nil {
+		t.Error("expected an error for a block that does not connect")
+	}
+}
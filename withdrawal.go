@@ -0,0 +1,184 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrWithdrawalRejected is wrapped by the errors returned when a WithdrawalPolicy rejects a send.
+var ErrWithdrawalRejected = errors.New("withdrawal rejected")
+
+// WithdrawalOutput is a payment to an address outside the wallet.
+type WithdrawalOutput struct {
+	Address string // empty for outputs without an address, e.g. OP_RETURN
+	Amount  float64
+}
+
+// Withdrawal is a send submitted to a WithdrawalPolicy.
+type Withdrawal struct {
+	Method  string // the RPC that would broadcast it
+	TxID    string // empty for sends the wallet builds itself, e.g. sendtoaddress
+	Outputs []WithdrawalOutput
+}
+
+// Total returns the amount leaving the wallet in BTC, excluding the fee.
+func (w Withdrawal) Total() float64 {
+	var total float64
+	for _, out := range w.Outputs {
+		total += out.Amount
+	}
+	return total
+}
+
+type withdrawalRecord struct {
+	time   time.Time
+	amount float64
+}
+
+// WithdrawalPolicy guards every send made through a client created with WithWithdrawalPolicy: the
+// sendtoaddress, sendrawtransaction and sendrawtransactions wrappers as well as the helpers built on them,
+// such as SweepWallet, PayoutBatcher and ColdStorage. Outputs of raw transactions paying back to the
+// wallet, such as change, are not counted. A withdrawal that passes counts towards the window limit even
+// if broadcasting it fails afterwards.
+type WithdrawalPolicy struct {
+	MaxPerWithdrawal float64         // BTC, 0 means no limit
+	MaxPerWindow     float64         // BTC, 0 means no limit
+	Window           time.Duration   // rolling window MaxPerWindow applies to
+	Allowlist        map[string]bool // destination addresses allowed, nil allows any
+	// Approve, if set, is called last, e.g. to obtain a second service's sign-off. Returning an error
+	// rejects the withdrawal.
+	Approve func(ctx context.Context, w Withdrawal) error
+
+	mu      sync.Mutex
+	history []withdrawalRecord
+}
+
+// Authorize checks a withdrawal against the limits and allowlist, asks for approval and records it.
+func (p *WithdrawalPolicy) Authorize(ctx context.Context, w Withdrawal) error {
+	total := w.Total()
+
+	if p.MaxPerWithdrawal > 0 && total > p.MaxPerWithdrawal {
+		return fmt.Errorf("%w: %.8f BTC exceeds the limit of %.8f BTC per withdrawal", ErrWithdrawalRejected, total, p.MaxPerWithdrawal)
+	}
+
+	if p.Allowlist != nil {
+		for _, out := range w.Outputs {
+			if !p.Allowlist[out.Address] {
+				return fmt.Errorf("%w: destination %q is not allowed", ErrWithdrawalRejected, out.Address)
+			}
+		}
+	}
+
+	// Hold the lock during approval so concurrent withdrawals cannot together exceed the window limit
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	if p.MaxPerWindow > 0 {
+		var used float64
+		kept := p.history[:0]
+		for _, r := range p.history {
+			if now.Sub(r.time) < p.Window {
+				kept = append(kept, r)
+				used += r.amount
+			}
+		}
+		p.history = kept
+
+		if used+total > p.MaxPerWindow {
+			return fmt.Errorf("%w: %.8f BTC would exceed the limit of %.8f BTC per %s, %.8f BTC already used", ErrWithdrawalRejected, total, p.MaxPerWindow, p.Window, used)
+		}
+	}
+
+	if p.Approve != nil {
+		if err := p.Approve(ctx, w); err != nil {
+			return fmt.Errorf("%w: not approved: %v", ErrWithdrawalRejected, err)
+		}
+	}
+
+	p.history = append(p.history, withdrawalRecord{time: now, amount: total})
+
+	return nil
+}
+
+// WithWithdrawalPolicy makes every send through the client subject to the given policy.
+func WithWithdrawalPolicy(policy *WithdrawalPolicy) func(*rpcClient) {
+	return func(p *rpcClient) {
+		p.withdrawalPolicy = policy
+	}
+}
+
+// authorizeSend submits a wallet built send to the withdrawal policy, if any.
+func (b *Bitcoind) authorizeSend(ctx context.Context, method string, outputs ...WithdrawalOutput) error {
+	if b.client.withdrawalPolicy == nil {
+		return nil
+	}
+
+	return b.client.withdrawalPolicy.Authorize(ctx, Withdrawal{Method: method, Outputs: outputs})
+}
+
+// authorizeTransaction submits a raw transaction about to be broadcast to the withdrawal policy, if any.
+// Its outputs are decoded by the node and those paying to the wallet are left out.
+func (b *Bitcoind) authorizeTransaction(ctx context.Context, method string, txHex string) error {
+	if b.client.withdrawalPolicy == nil {
+		return nil
+	}
+
+	var tx struct {
+		TxID string `json:"txid"`
+		Vout []struct {
+			Value        float64      `json:"value"`
+			ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+		} `json:"vout"`
+	}
+	if err := b.uncachedCallContext(ctx, "decoderawtransaction", []interface{}{txHex}, &tx); err != nil {
+		return err
+	}
+
+	w := Withdrawal{Method: method, TxID: tx.TxID}
+
+	var params [][]interface{}
+	for _, out := range tx.Vout {
+		address := out.ScriptPubKey.Address
+		if address == "" && len(out.ScriptPubKey.Addresses) == 1 {
+			address = out.ScriptPubKey.Addresses[0]
+		}
+
+		// Data carrier outputs do not move funds
+		if address == "" && out.Value == 0 {
+			continue
+		}
+
+		w.Outputs = append(w.Outputs, WithdrawalOutput{Address: address, Amount: out.Value})
+		params = append(params, []interface{}{address})
+	}
+
+	if len(params) == 0 {
+		return b.client.withdrawalPolicy.Authorize(ctx, w)
+	}
+
+	// Leave out outputs to the wallet's own addresses
+	responses, err := b.client.batch(ctx, "getaddressinfo", params)
+	if err != nil {
+		return err
+	}
+
+	external := w.Outputs[:0]
+	for i, r := range responses {
+		var info struct {
+			IsMine bool `json:"ismine"`
+		}
+		if r.Err == nil && json.Unmarshal(r.Result, &info) == nil && info.IsMine {
+			continue
+		}
+		external = append(external, w.Outputs[i])
+	}
+	w.Outputs = external
+
+	return b.client.withdrawalPolicy.Authorize(ctx, w)
+}
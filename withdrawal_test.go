@@ -0,0 +1,145 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithdrawalPolicy(t *testing.T) {
+	approvals := 0
+
+	p := &WithdrawalPolicy{
+		MaxPerWithdrawal: 1,
+		MaxPerWindow:     1.5,
+		Window:           time.Hour,
+		Allowlist:        map[string]bool{"cold": true, "exchange": true},
+		Approve: func(ctx context.Context, w Withdrawal) error {
+			approvals++
+			if w.Outputs[0].Address == "exchange" {
+				return errors.New("second signer declined")
+			}
+			return nil
+		},
+	}
+
+	for i, tt := range []struct {
+		address string
+		amount  float64
+		ok      bool
+	}{
+		{"cold", 1.1, false},      // above the per withdrawal limit
+		{"elsewhere", 0.1, false}, // not allowed
+		{"cold", 1, true},
+		{"cold", 0.6, false}, // would exceed the window limit
+		{"exchange", 0.1, false},
+		{"cold", 0.5, true},
+	} {
+		err := p.Authorize(context.Background(), Withdrawal{Outputs: []WithdrawalOutput{{Address: tt.address, Amount: tt.amount}}})
+		if (err == nil) != tt.ok || (err != nil && !errors.Is(err, ErrWithdrawalRejected)) {
+			t.Errorf("%d: unexpected result %v", i, err)
+		}
+	}
+
+	if approvals != 3 {
+		t.Errorf("expected approval to be asked 3 times, got %d", approvals)
+	}
+}
+
+func TestWithdrawalPolicyIgnoresChange(t *testing.T) {
+	var sent bool
+	policy := &WithdrawalPolicy{MaxPerWithdrawal: 1, Allowlist: map[string]bool{"external": true}}
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var reqs []rpcRequest
+		if json.Unmarshal(body, &reqs) == nil {
+			// getaddressinfo batch, the change address belongs to the wallet
+			var parts []string
+			for _, q := range reqs {
+				mine := q.Params.([]interface{})[0] == "change"
+				parts = append(parts, fmt.Sprintf(`{"result":{"ismine":%v},"error":null,"id":%d}`, mine, q.ID))
+			}
+			_, _ = w.Write([]byte("[" + strings.Join(parts, ",") + "]"))
+			return
+		}
+
+		var req rpcRequest
+		_ = json.Unmarshal(body, &req)
+
+		switch req.Method {
+		case "decoderawtransaction":
+			_, _ = w.Write([]byte(`{"result":{"txid":"tx","vout":[
+				{"value":0.9,"scriptPubKey":{"address":"external"}},
+				{"value":5,"scriptPubKey":{"address":"change"}},
+				{"value":0,"scriptPubKey":{"type":"nulldata"}}
+			]},"error":null,"id":1}`))
+		case "sendrawtransaction":
+			sent = true
+			_, _ = w.Write([]byte(`{"result":"tx","error":null,"id":1}`))
+		}
+	}, WithWithdrawalPolicy(policy))
+	defer done()
+
+	if txid, err := b.broadcast("00"); err != nil || txid != "tx" || !sent {
+		t.Fatalf("expected the transaction to be sent, got %q, %v", txid, err)
+	}
+
+	policy.MaxPerWithdrawal = 0.5
+	sent = false
+	if _, err := b.broadcast("00"); !errors.Is(err, ErrWithdrawalRejected) || sent {
+		t.Errorf("expected the transaction to be rejected, got %v", err)
+	}
+}
+
+func TestRawCallWithdrawalPolicy(t *testing.T) {
+	var methods []string
+
+	b, done := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		body, _ := io.ReadAll(r.Body)
+		if json.Unmarshal(body, &req) != nil {
+			// A batch, only getaddressinfo is sent as one
+			req.Method = "getaddressinfo"
+		}
+		methods = append(methods, req.Method)
+
+		switch req.Method {
+		case "decoderawtransaction":
+			_, _ = w.Write([]byte(`{"result":{"txid":"aa","vout":[{"value":2,"scriptPubKey":{"address":"elsewhere"}}]},"error":null,"id":1}`))
+		case "getaddressinfo":
+			_, _ = w.Write([]byte(`[{"result":{"ismine":false},"error":null,"id":0}]`))
+		default:
+			_, _ = w.Write([]byte(`{"result":800000,"error":null,"id":1}`))
+		}
+	}, WithWithdrawalPolicy(&WithdrawalPolicy{MaxPerWithdrawal: 1}))
+	defer done()
+
+	res, err := b.RawCall("getblockcount")
+	if err != nil || string(res) != "800000" {
+		t.Fatalf("unexpected result %s, %v", res, err)
+	}
+
+	if _, err := b.RawCall("sendtoaddress", "elsewhere", 0.1); !errors.Is(err, ErrWithdrawalRejected) {
+		t.Errorf("expected sendtoaddress to be refused, got %v", err)
+	}
+
+	if _, err := b.RawCall("sendrawtransaction", "0200"); !errors.Is(err, ErrWithdrawalRejected) {
+		t.Errorf("expected the transaction to be rejected, got %v", err)
+	}
+
+	if _, err := b.RawCall("sendrawtransaction"); !errors.Is(err, ErrWithdrawalRejected) {
+		t.Errorf("expected a call without transaction to be rejected, got %v", err)
+	}
+
+	if got := strings.Join(methods, ","); got != "getblockcount,decoderawtransaction,getaddressinfo" {
+		t.Errorf("unexpected calls %s", got)
+	}
+}
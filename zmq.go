@@ -6,7 +6,6 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
 	"strconv"
 	"time"
 
@@ -25,11 +24,22 @@ var allowedTopics = []string{
 	"discardedfrommempool",
 	"removedfrommempoolblock",
 	"invalidtx",
+	"sequence",
 }
 
 type subscriptionRequest struct {
 	topic string
 	ch    chan []string
+	raw   chan ZMQMessage
+}
+
+// ZMQMessage is a message received from the node. Unlike the messages of Subscribe, the body is passed on
+// as received, so that large rawblock payloads are neither hex encoded nor copied.
+type ZMQMessage struct {
+	Topic       string
+	Body        []byte // shared by all raw subscribers of the topic, which must not modify it
+	Sequence    uint32 // counted per topic by the node
+	HasSequence bool   // false for messages without a sequence frame
 }
 
 // ZMQ struct
@@ -39,8 +49,11 @@ type ZMQ struct {
 	connected          bool
 	err                error
 	subscriptions      map[string][]chan []string
+	rawSubscriptions   map[string][]chan ZMQMessage
 	addSubscription    chan subscriptionRequest
 	removeSubscription chan subscriptionRequest
+	sequences          *SequenceTracker
+	reconnect          *reconnector
 	logger             Logger
 }
 
@@ -50,13 +63,25 @@ func NewZMQ(host string, port int, optionalLogger ...Logger) *ZMQ {
 	return NewZMQWithContext(ctx, host, port, optionalLogger...)
 }
 
+// zmqReconnectPolicy retries every 10 seconds for ever.
+var zmqReconnectPolicy = ReconnectPolicy{InitialDelay: 10 * time.Second}
+
 func NewZMQWithContext(ctx context.Context, host string, port int, optionalLogger ...Logger) *ZMQ {
+	return NewZMQWithReconnectPolicy(ctx, host, port, zmqReconnectPolicy, optionalLogger...)
+}
 
+// NewZMQWithReconnectPolicy subscribes like NewZMQWithContext, reconnecting after losing the connection as
+// policy says. Once it gives up, the subscriber stops. policy.OnReconnect is called after the topics have
+// been subscribed again, but messages sent by the node in between are lost.
+func NewZMQWithReconnectPolicy(ctx context.Context, host string, port int, policy ReconnectPolicy, optionalLogger ...Logger) *ZMQ {
 	zmq := &ZMQ{
 		address:            fmt.Sprintf("tcp://%s:%d", host, port),
 		subscriptions:      make(map[string][]chan []string),
+		rawSubscriptions:   make(map[string][]chan ZMQMessage),
 		addSubscription:    make(chan subscriptionRequest, 10),
 		removeSubscription: make(chan subscriptionRequest, 10),
+		sequences:          NewSequenceTracker(),
+		reconnect:          newReconnector(policy, nil),
 		logger:             &DefaultLogger{},
 	}
 
@@ -95,6 +120,35 @@ func (zmq *ZMQ) Unsubscribe(topic string, ch chan []string) error {
 	return nil
 }
 
+// SubscribeRaw sends the messages of the topic to ch with their body as received, which suits rawblock and
+// rawtx subscribers handing the payload to a parser.
+func (zmq *ZMQ) SubscribeRaw(topic string, ch chan ZMQMessage) error {
+	if !contains(allowedTopics, topic) {
+		return fmt.Errorf("topic must be %+v, received %q", allowedTopics, topic)
+	}
+
+	zmq.addSubscription <- subscriptionRequest{
+		topic: topic,
+		raw:   ch,
+	}
+
+	return nil
+}
+
+// UnsubscribeRaw stops sending the messages of the topic to ch.
+func (zmq *ZMQ) UnsubscribeRaw(topic string, ch chan ZMQMessage) error {
+	if !contains(allowedTopics, topic) {
+		return fmt.Errorf("topic must be %+v, received %q", allowedTopics, topic)
+	}
+
+	zmq.removeSubscription <- subscriptionRequest{
+		topic: topic,
+		raw:   ch,
+	}
+
+	return nil
+}
+
 func (zmq *ZMQ) start(ctx context.Context) {
 	for {
 		zmq.socket = zmq4.NewSub(ctx, zmq4.WithID(zmq4.SocketIdentity("sub")))
@@ -108,14 +162,18 @@ func (zmq *ZMQ) start(ctx context.Context) {
 		if err := zmq.socket.Dial(zmq.address); err != nil {
 			zmq.err = err
 			zmq.logger.Errorf("Could not dial ZMQ at %s: %v", zmq.address, err)
-			zmq.logger.Infof("Attempting to re-establish ZMQ connection in 10 seconds...")
-			time.Sleep(10 * time.Second)
+			if !zmq.retry(ctx) {
+				return
+			}
 			continue
 		}
 
 		zmq.logger.Infof("ZMQ: Connecting to %s", zmq.address)
 
-		for topic := range zmq.subscriptions {
+		// Sequence numbers restart when the node does
+		zmq.sequences = NewSequenceTracker()
+
+		for _, topic := range zmq.topics() {
 			if err := zmq.socket.SetOption(zmq4.OptionSubscribe, topic); err != nil {
 				zmq.err = fmt.Errorf("%+v", err)
 				return
@@ -123,6 +181,8 @@ func (zmq *ZMQ) start(ctx context.Context) {
 			zmq.logger.Infof("ZMQ: Subscribed to %s", topic)
 		}
 
+		zmq.reconnect.succeeded()
+
 	OUT:
 		for {
 			select {
@@ -136,21 +196,10 @@ func (zmq *ZMQ) start(ctx context.Context) {
 					zmq.logger.Infof("ZMQ: Subscribed to %s", req.topic)
 				}
 
-				subscribers := zmq.subscriptions[req.topic]
-				subscribers = append(subscribers, req.ch)
-
-				zmq.subscriptions[req.topic] = subscribers
+				zmq.add(req)
 
 			case req := <-zmq.removeSubscription:
-				subscribers := zmq.subscriptions[req.topic]
-				for i, subscriber := range subscribers {
-					if subscriber == req.ch {
-						subscribers = append(subscribers[:i], subscribers[i+1:]...)
-						zmq.logger.Infof("Removed subscription from %s topic", req.topic)
-						break
-					}
-				}
-				zmq.subscriptions[req.topic] = subscribers
+				zmq.remove(req)
 
 			default:
 				msg, err := zmq.socket.Recv()
@@ -166,18 +215,7 @@ func (zmq *ZMQ) start(ctx context.Context) {
 						zmq.logger.Infof("ZMQ: Connection to %s observed\n", zmq.address)
 					}
 
-					subscribers := zmq.subscriptions[string(msg.Frames[0])]
-
-					sequence := "N/A"
-
-					if len(msg.Frames) > 2 && len(msg.Frames[2]) == 4 {
-						s := binary.LittleEndian.Uint32(msg.Frames[2])
-						sequence = strconv.FormatInt(int64(s), 10)
-					}
-
-					for _, subscriber := range subscribers {
-						subscriber <- []string{string(msg.Frames[0]), hex.EncodeToString(msg.Frames[1]), sequence}
-					}
+					zmq.handle(msg)
 				}
 			}
 		}
@@ -186,9 +224,106 @@ func (zmq *ZMQ) start(ctx context.Context) {
 			zmq.socket.Close()
 			zmq.connected = false
 		}
-		log.Printf("Attempting to re-establish ZMQ connection in 10 seconds...")
-		time.Sleep(10 * time.Second)
+		if !zmq.retry(ctx) {
+			return
+		}
+	}
+}
+
+// retry waits before the next connection attempt, and reports false if there is none.
+func (zmq *ZMQ) retry(ctx context.Context) bool {
+	zmq.logger.Infof("Attempting to re-establish ZMQ connection in %s...", zmq.reconnect.policy.Delay(zmq.reconnect.failures+1))
+
+	if err := zmq.reconnect.failed(ctx); err != nil {
+		if !errors.Is(err, ctx.Err()) {
+			zmq.err = err
+			zmq.logger.Errorf("ZMQ: %v", err)
+		}
+		return false
+	}
+
+	return true
+}
 
+func (zmq *ZMQ) add(req subscriptionRequest) {
+	if req.raw != nil {
+		zmq.rawSubscriptions[req.topic] = append(zmq.rawSubscriptions[req.topic], req.raw)
+		return
+	}
+
+	zmq.subscriptions[req.topic] = append(zmq.subscriptions[req.topic], req.ch)
+}
+
+func (zmq *ZMQ) remove(req subscriptionRequest) {
+	if req.raw != nil {
+		subscribers := zmq.rawSubscriptions[req.topic]
+		for i, subscriber := range subscribers {
+			if subscriber == req.raw {
+				zmq.rawSubscriptions[req.topic] = append(subscribers[:i:i], subscribers[i+1:]...)
+				zmq.logger.Infof("Removed subscription from %s topic", req.topic)
+				break
+			}
+		}
+		return
+	}
+
+	subscribers := zmq.subscriptions[req.topic]
+	for i, subscriber := range subscribers {
+		if subscriber == req.ch {
+			subscribers = append(subscribers[:i], subscribers[i+1:]...)
+			zmq.logger.Infof("Removed subscription from %s topic", req.topic)
+			break
+		}
+	}
+	zmq.subscriptions[req.topic] = subscribers
+}
+
+// topics returns the topics with subscribers of either kind.
+func (zmq *ZMQ) topics() []string {
+	var topics []string
+	for topic := range zmq.subscriptions {
+		topics = append(topics, topic)
+	}
+	for topic := range zmq.rawSubscriptions {
+		if _, found := zmq.subscriptions[topic]; !found {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// handle delivers a multipart message of the node: the topic, the body and the sequence number of the
+// message within its topic, little endian. Gaps in the sequence numbers mean messages were dropped, e.g.
+// because the node's send buffer filled up while a large block was received.
+func (zmq *ZMQ) handle(msg zmq4.Msg) {
+	if len(msg.Frames) < 2 {
+		zmq.logger.Errorf("ZMQ: Ignoring message with %d frames", len(msg.Frames))
+		return
+	}
+
+	m := ZMQMessage{Topic: string(msg.Frames[0]), Body: msg.Frames[1]}
+
+	sequence := "N/A"
+	if len(msg.Frames) > 2 && len(msg.Frames[2]) == 4 {
+		m.Sequence = binary.LittleEndian.Uint32(msg.Frames[2])
+		m.HasSequence = true
+		sequence = strconv.FormatInt(int64(m.Sequence), 10)
+
+		if missed := zmq.sequences.Observe(m.Topic, m.Sequence); missed > 0 {
+			zmq.logger.Warnf("ZMQ: Missed %d %s messages", missed, m.Topic)
+		}
+	}
+
+	for _, subscriber := range zmq.rawSubscriptions[m.Topic] {
+		subscriber <- m
+	}
+
+	// Only hex encode the body, twice the size of a block for rawblock, when someone wants it
+	if subscribers := zmq.subscriptions[m.Topic]; len(subscribers) > 0 {
+		body := hex.EncodeToString(m.Body)
+		for _, subscriber := range subscribers {
+			subscriber <- []string{m.Topic, body, sequence}
+		}
 	}
 }
 
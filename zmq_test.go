@@ -0,0 +1,55 @@
+package bitcoin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-zeromq/zmq4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZMQHandle(t *testing.T) {
+	logger := &countingLogger{}
+	zmq := &ZMQ{
+		subscriptions:    make(map[string][]chan []string),
+		rawSubscriptions: make(map[string][]chan ZMQMessage),
+		sequences:        NewSequenceTracker(),
+		logger:           logger,
+	}
+
+	raw := make(chan ZMQMessage, 10)
+	hashes := make(chan []string, 10)
+	zmq.add(subscriptionRequest{topic: "rawblock", raw: raw})
+	zmq.add(subscriptionRequest{topic: "hashblock", ch: hashes})
+	assert.ElementsMatch(t, []string{"rawblock", "hashblock"}, zmq.topics())
+
+	// A 4 MB block is passed on as received
+	block := bytes.Repeat([]byte{0xab}, 4_000_000)
+	zmq.handle(zmq4.NewMsgFrom([]byte("rawblock"), block, le32(7)))
+	m := <-raw
+	assert.Equal(t, "rawblock", m.Topic)
+	assert.True(t, m.HasSequence)
+	assert.Equal(t, uint32(7), m.Sequence)
+	assert.Same(t, &block[0], &m.Body[0])
+
+	zmq.handle(zmq4.NewMsgFrom([]byte("hashblock"), []byte{0x01, 0x02}, le32(1)))
+	assert.Equal(t, []string{"hashblock", "0102", "1"}, <-hashes)
+
+	// Sequence numbers are counted per topic, a gap in one is logged
+	zmq.handle(zmq4.NewMsgFrom([]byte("hashblock"), []byte{0x03}, le32(2)))
+	<-hashes
+	assert.Equal(t, int64(0), logger.messages)
+	zmq.handle(zmq4.NewMsgFrom([]byte("rawblock"), block, le32(10)))
+	<-raw
+	assert.Equal(t, int64(1), logger.messages)
+
+	// Malformed messages are dropped
+	zmq.handle(zmq4.NewMsgFrom([]byte("rawblock")))
+	zmq.handle(zmq4.NewMsgFrom([]byte("hashblock"), []byte{0x04}))
+	assert.Equal(t, []string{"hashblock", "04", "N/A"}, <-hashes)
+	assert.Empty(t, raw)
+
+	zmq.remove(subscriptionRequest{topic: "rawblock", raw: raw})
+	zmq.handle(zmq4.NewMsgFrom([]byte("rawblock"), block, le32(11)))
+	assert.Empty(t, raw)
+}